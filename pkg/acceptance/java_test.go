@@ -173,6 +173,25 @@ public class main {
 		if (!returnedUuid.equals(uuid)) {
 			throw new Exception("expected " + uuid + " but got " + returnedUuid);
 		}
+
+		// With a fetch size, the driver reads the results of a query in
+		// batches through suspended portals. Two portals are kept open at
+		// the same time.
+		conn.setAutoCommit(false);
+		stmt = conn.prepareStatement("SELECT * FROM generate_series(1, 10)");
+		stmt.setFetchSize(3);
+		PreparedStatement stmt2 = conn.prepareStatement("SELECT * FROM generate_series(11, 20)");
+		stmt2.setFetchSize(4);
+		rs = stmt.executeQuery();
+		ResultSet rs2 = stmt2.executeQuery();
+		int sum = 0;
+		while (rs.next() && rs2.next()) {
+			sum += rs.getInt(1) + rs2.getInt(1);
+		}
+		if (sum != 210) {
+			throw new Exception("unexpected: fetched rows sum to " + sum + ", expecting 210");
+		}
+		conn.commit();
 	}
 }
 EOF
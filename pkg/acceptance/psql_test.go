@@ -85,5 +85,30 @@ psql -d testdb < import.sql | grep hooray
 # Assert the junk line wasn't added.
 psql -d testdb -c "SELECT * from playground WHERE type='junk'" | grep "0 rows"
 
+# Test COPY FROM in CSV format, with a header and quoted fields.
+cat > import.csv <<EOF
+equip_id,type,color,location,install_date
+5,"see, saw","dark ""red""",,2017-05-06
+EOF
+psql -d testdb -c "\copy playground FROM 'import.csv' WITH (FORMAT csv, HEADER)"
+psql -d testdb -c "SELECT * FROM playground WHERE location IS NULL" | grep 'see, saw'
+
+# Test COPY TO in text and CSV formats.
+psql -d testdb -c "COPY (SELECT type FROM playground WHERE equip_id = 1) TO STDOUT" | grep slide
+psql -d testdb -c "COPY playground (equip_id, color) TO STDOUT WITH (FORMAT csv, HEADER)" > export.csv
+grep 'equip_id,color' export.csv
+grep '5,"dark ""red"""' export.csv
+
+# Test COPY TO of a large result, whose rows are streamed, and of a
+# result that follows another one in the same query string.
+psql -d testdb -c "COPY (SELECT * FROM generate_series(1, 200000)) TO STDOUT" | wc -l | grep 200000
+psql -d testdb -c "SELECT 1; COPY (SELECT 'second') TO STDOUT" | grep second
+
+# Test a round trip through the binary format.
+psql -d testdb -c "CREATE TABLE playground2 AS SELECT * FROM playground WHERE false"
+psql -d testdb -c "\copy playground TO 'export.bin' WITH (FORMAT binary)"
+psql -d testdb -c "\copy playground2 FROM 'export.bin' WITH (FORMAT binary)"
+psql -d testdb -c "SELECT count(*) FROM playground2" | grep 5
+
 exit 0
 `
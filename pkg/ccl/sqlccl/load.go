@@ -264,6 +264,11 @@ func insertStmtToKVs(
 		return errors.Errorf("load insert: expected VALUES clause: %q", stmt)
 	}
 
+	computedCols, err := sqlbase.MakeComputedColumns(tableDesc, cols, ri.InsertColIDtoRowIndex)
+	if err != nil {
+		return err
+	}
+
 	b := inserter(f)
 	for _, tuple := range values.Tuples {
 		row := make([]parser.Datum, len(tuple.Exprs))
@@ -283,7 +288,7 @@ func insertStmtToKVs(
 			}
 		}
 		row, err := sql.GenerateInsertRow(
-			defaultExprs, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, row,
+			defaultExprs, computedCols, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, row,
 		)
 		if err != nil {
 			return errors.Wrapf(err, "process insert %q", row)
@@ -1589,7 +1589,11 @@ writing ` + os.DevNull + `
   debug/nodes/1/ranges/8
   debug/nodes/1/ranges/9
   debug/nodes/1/ranges/10
+  debug/nodes/1/ranges/11
+  debug/nodes/1/ranges/12
+  debug/nodes/1/ranges/13
   debug/schema/system@details
+  debug/schema/system/comments
   debug/schema/system/descriptor
   debug/schema/system/eventlog
   debug/schema/system/jobs
@@ -1597,7 +1601,9 @@ writing ` + os.DevNull + `
   debug/schema/system/namespace
   debug/schema/system/rangelog
   debug/schema/system/settings
+  debug/schema/system/table_statistics
   debug/schema/system/ui
+  debug/schema/system/user_settings
   debug/schema/system/users
   debug/schema/system/zones
 `
@@ -37,6 +37,16 @@ type SenderWithDistSQLBackdoor interface {
 	// GetTxnState returns the state that the TxnCoordSender has for a
 	// transaction. The bool is false is no state is found.
 	GetTxnState(txnID uuid.UUID) (roachpb.Transaction, bool)
+
+	// AugmentTxnState informs the TxnCoordSender about intents written on
+	// behalf of a transaction by requests that didn't go through it (i.e.
+	// writes performed by DistSQL processors), so that they are resolved when
+	// the transaction ends. txn is the transaction as returned by these
+	// requests; it is merged into the TxnCoordSender's state. If the
+	// TxnCoordSender isn't tracking the transaction yet, it starts doing so
+	// (and starts heartbeating its record). If txn is no longer PENDING, the
+	// TxnCoordSender stops tracking it.
+	AugmentTxnState(ctx context.Context, txn roachpb.Transaction, intents []roachpb.Span) error
 }
 
 // SenderFunc is an adapter to allow the use of ordinary functions
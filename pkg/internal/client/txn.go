@@ -75,11 +75,21 @@ type Txn struct {
 		// TODO(andrei): This is broken for DistSQL, which doesn't account for the
 		// requests it uses the transaction for.
 		commandCount int
+		// intents are the spans written by the requests sent through the
+		// transaction, when trackIntents is set.
+		intents []roachpb.Span
+		// remoteWrites is set once DistSQL processors may have performed
+		// writes on behalf of the transaction; see PrepareForRemoteWrites().
+		remoteWrites bool
 	}
 
 	// Set for DistSQL transactions that get errors that would otherwise be
 	// handled by the TxnCoordSender.
 	acceptUnhandledRetryableErrors bool
+
+	// Set for DistSQL transactions that perform writes on behalf of a
+	// transaction coordinated by another node; see TrackIntents().
+	trackIntents bool
 }
 
 // NewTxn returns a new txn.
@@ -104,6 +114,23 @@ func (txn *Txn) AcceptUnhandledRetryableErrors() {
 	txn.acceptUnhandledRetryableErrors = true
 }
 
+// TrackIntents makes the transaction record the spans of the intents written by
+// the requests sent through it; they are returned by Intents(). This is used by
+// DistSQL for transactions performing writes that bypass the TxnCoordSender of
+// the transaction's gateway, which has to be informed of the intents.
+func (txn *Txn) TrackIntents() {
+	txn.trackIntents = true
+}
+
+// Intents returns the spans of the intents written by the transaction, if
+// TrackIntents() was called. On errors, the spans of all the writes that were
+// attempted are included.
+func (txn *Txn) Intents() []roachpb.Span {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	return append([]roachpb.Span(nil), txn.mu.intents...)
+}
+
 // CommandCount returns the count of commands executed through this txn.
 // Retryable errors on the transaction will reset the count to 0.
 func (txn *Txn) CommandCount() int {
@@ -870,6 +897,18 @@ func (txn *Txn) send(
 	txn.mu.Lock()
 	defer txn.mu.Unlock()
 
+	if txn.trackIntents {
+		// Like the TxnCoordSender, record the intents even on error: the
+		// requests might have written some of them.
+		intentsBR := br
+		if pErr != nil {
+			intentsBR = nil
+		}
+		ba.IntentSpanIterate(intentsBR, func(key, endKey roachpb.Key) {
+			txn.mu.intents = append(txn.mu.intents, roachpb.Span{Key: key, EndKey: endKey})
+		})
+	}
+
 	// If we inserted a begin transaction request, remove it here. We also
 	// unset the flag writingTxnRecord flag in case another ever needs to
 	// be sent again (for instance, if we're aborted and need to restart).
@@ -984,6 +1023,60 @@ func firstWriteIndex(ba roachpb.BatchRequest) (int, *roachpb.Error) {
 	return firstWriteIdx, nil
 }
 
+// PrepareForRemoteWrites readies the transaction for writes performed on its
+// behalf by DistSQL processors, which bypass the TxnCoordSender. If the
+// transaction hasn't written yet, its record is created (anchored at anchorKey,
+// unless an anchor key was already set) so that the processors can run in the
+// Writing state, without sending BeginTransaction requests of their own. The
+// TxnCoordSender then starts tracking the transaction; AugmentRemoteWrites()
+// informs it about the intents written by the processors.
+func (txn *Txn) PrepareForRemoteWrites(ctx context.Context, anchorKey roachpb.Key) error {
+	txn.mu.Lock()
+	txn.mu.remoteWrites = true
+	if txn.mu.Proto.Writing {
+		txn.mu.Unlock()
+		return nil
+	}
+	if len(txn.mu.Proto.Key) == 0 {
+		txn.mu.Proto.Key = txn.mu.txnAnchorKey
+		if len(txn.mu.Proto.Key) == 0 {
+			txn.mu.Proto.Key = anchorKey
+		}
+	}
+	key := txn.mu.Proto.Key
+	txn.mu.Unlock()
+
+	var ba roachpb.BatchRequest
+	ba.Add(&roachpb.BeginTransactionRequest{Span: roachpb.Span{Key: key}})
+	if _, pErr := txn.send(ctx, ba); pErr != nil {
+		return pErr.GoError()
+	}
+	// The transaction record is recorded as an intent: this is harmless (there
+	// is no intent to resolve there) and allows the transaction to be committed
+	// even if the processors don't end up writing anything.
+	return txn.AugmentRemoteWrites(ctx, *txn.Proto(), []roachpb.Span{{Key: key}})
+}
+
+// AugmentRemoteWrites updates the transaction with the state returned by
+// DistSQL processors which performed writes on its behalf, and informs the
+// TxnCoordSender about the intents they wrote so that they are resolved when
+// the transaction ends.
+func (txn *Txn) AugmentRemoteWrites(
+	ctx context.Context, remoteTxn roachpb.Transaction, intents []roachpb.Span,
+) error {
+	txn.mu.Lock()
+	if !roachpb.TxnIDEqual(remoteTxn.ID, txn.mu.Proto.ID) {
+		// The writes were performed by a previous incarnation of the
+		// transaction, which has been aborted.
+		txn.mu.Unlock()
+		return nil
+	}
+	txn.mu.Proto.Update(&remoteTxn)
+	newTxn := txn.mu.Proto.Clone()
+	txn.mu.Unlock()
+	return txn.db.GetSender().(SenderWithDistSQLBackdoor).AugmentTxnState(ctx, newTxn, intents)
+}
+
 // UpdateStateOnRemoteRetryableErr updates the Txn, and the Transaction proto
 // inside it, in response to an error encountered when running a request through
 // the txn. If the error is not a RetryableTxnError, then this is a no-op. For a
@@ -1002,23 +1095,36 @@ func (txn *Txn) UpdateStateOnRemoteRetryableErr(ctx context.Context, pErr roachp
 		log.Fatalf(ctx, "unexpected retryable error with no txn ran through DistSQL: %s", pErr)
 	}
 
+	// Emulate the processing that the TxnCoordSender would have done on this
+	// error.
+	newTxn := roachpb.PrepareTransactionForRetry(ctx, &pErr, txn.mu.UserPriority)
+	newErr := roachpb.NewHandledRetryableTxnError(pErr.Message, pErr.GetTxn().ID, newTxn)
+
 	// Assert that the TxnCoordSender doesn't have any state for this transaction
-	// (and it shouldn't, since DistSQL isn't supposed to do any works in
-	// transaction that had performed writes and hence started being tracked). If
-	// the TxnCoordSender were to have state, it'd be a bad thing that we're not
-	// updating it.
+	// unless DistSQL performed writes on its behalf (see
+	// PrepareForRemoteWrites()). DistSQL isn't otherwise supposed to do any work
+	// in transactions that had performed writes and hence started being
+	// tracked. If the TxnCoordSender has state, we update it like it would have
+	// done itself.
 	// TODO(andrei): remove nil check once #15024 is merged.
 	if txnID := pErr.GetTxn().ID; txnID != nil {
-		if _, ok := txn.db.GetSender().(SenderWithDistSQLBackdoor).GetTxnState(*txnID); ok {
-			log.Fatalf(ctx, "unexpected state in TxnCoordSender for transaction in error: %s", pErr)
+		backdoor := txn.db.GetSender().(SenderWithDistSQLBackdoor)
+		if _, ok := backdoor.GetTxnState(*txnID); ok {
+			if !txn.mu.remoteWrites {
+				log.Fatalf(ctx, "unexpected state in TxnCoordSender for transaction in error: %s", pErr)
+			}
+			coordTxn := newTxn
+			if newTxn.ID == nil {
+				// The transaction was aborted; the TxnCoordSender stops tracking it.
+				coordTxn = pErr.GetTxn().Clone()
+				coordTxn.Status = roachpb.ABORTED
+			}
+			if err := backdoor.AugmentTxnState(ctx, coordTxn, nil /* intents */); err != nil {
+				log.Warningf(ctx, "failed to update the TxnCoordSender state: %s", err)
+			}
 		}
 	}
 
-	// Emulate the processing that the TxnCoordSender would have done on this
-	// error.
-	newTxn := roachpb.PrepareTransactionForRetry(ctx, &pErr, txn.mu.UserPriority)
-	newErr := roachpb.NewHandledRetryableTxnError(pErr.Message, pErr.GetTxn().ID, newTxn)
-
 	txn.updateStateOnRetryableErrLocked(
 		ctx, *newErr,
 		// We're passing the current ID and epoch as the "request"'s. In doing so,
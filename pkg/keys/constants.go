@@ -289,11 +289,14 @@ const (
 	// Reserved IDs for other system tables. If you're adding a new system table,
 	// it probably belongs here.
 	// NOTE: IDs must be <= MaxReservedDescID.
-	LeaseTableID      = 11
-	EventLogTableID   = 12
-	RangeEventTableID = 13
-	UITableID         = 14
-	JobsTableID       = 15
+	LeaseTableID           = 11
+	EventLogTableID        = 12
+	RangeEventTableID      = 13
+	UITableID              = 14
+	JobsTableID            = 15
+	CommentsTableID        = 19
+	TableStatisticsTableID = 20
+	UserSettingsTableID    = 21
 
 	// Reserved IDs used to refer to certain parts of the system ranges that
 	// come before the system config span and user table ranges.
@@ -932,26 +932,8 @@ func (tc *TxnCoordSender) updateState(
 			// we expect it to be committed/aborted at some point in the
 			// future.
 			if _, isEnding := ba.GetArg(roachpb.EndTransaction); pErr != nil || !isEnding {
-				log.Event(ctx, "coordinator spawns")
-				txnMeta = &txnMetadata{
-					txn:              newTxn,
-					keys:             keys,
-					firstUpdateNanos: startNS,
-					lastUpdateNanos:  tc.clock.PhysicalNow(),
-					timeoutDuration:  tc.clientTimeout,
-					txnEnd:           make(chan struct{}),
-				}
-				tc.txnMu.txns[txnID] = txnMeta
-
-				if err := tc.stopper.RunAsyncTask(
-					ctx, "kv.TxnCoordSender: heartbeat loop", func(ctx context.Context) {
-						tc.heartbeatLoop(ctx, txnID)
-					}); err != nil {
-					// The system is already draining and we can't start the
-					// heartbeat. We refuse new transactions for now because
-					// they're likely not going to have all intents committed.
-					// In principle, we can relax this as needed though.
-					tc.unregisterTxnLocked(txnID)
+				var err error
+				if txnMeta, err = tc.registerTxnLocked(ctx, newTxn, keys, startNS); err != nil {
 					return roachpb.NewError(err)
 				}
 			} else {
@@ -973,6 +955,37 @@ func (tc *TxnCoordSender) updateState(
 	return pErr
 }
 
+// registerTxnLocked starts tracking a transaction which has laid down intents,
+// and starts its heartbeat loop.
+func (tc *TxnCoordSender) registerTxnLocked(
+	ctx context.Context, txn roachpb.Transaction, keys []roachpb.Span, startNS int64,
+) (*txnMetadata, error) {
+	log.Event(ctx, "coordinator spawns")
+	txnID := *txn.ID
+	txnMeta := &txnMetadata{
+		txn:              txn,
+		keys:             keys,
+		firstUpdateNanos: startNS,
+		lastUpdateNanos:  tc.clock.PhysicalNow(),
+		timeoutDuration:  tc.clientTimeout,
+		txnEnd:           make(chan struct{}),
+	}
+	tc.txnMu.txns[txnID] = txnMeta
+
+	if err := tc.stopper.RunAsyncTask(
+		ctx, "kv.TxnCoordSender: heartbeat loop", func(ctx context.Context) {
+			tc.heartbeatLoop(ctx, txnID)
+		}); err != nil {
+		// The system is already draining and we can't start the
+		// heartbeat. We refuse new transactions for now because
+		// they're likely not going to have all intents committed.
+		// In principle, we can relax this as needed though.
+		tc.unregisterTxnLocked(txnID)
+		return nil, err
+	}
+	return txnMeta, nil
+}
+
 // GetTxnState is part of the SenderWithDistSQLBackdoor interface.
 func (tc *TxnCoordSender) GetTxnState(txnID uuid.UUID) (roachpb.Transaction, bool) {
 	tc.txnMu.Lock()
@@ -983,6 +996,64 @@ func (tc *TxnCoordSender) GetTxnState(txnID uuid.UUID) (roachpb.Transaction, boo
 	return roachpb.Transaction{}, false
 }
 
+// AugmentTxnState is part of the SenderWithDistSQLBackdoor interface.
+func (tc *TxnCoordSender) AugmentTxnState(
+	ctx context.Context, txn roachpb.Transaction, intents []roachpb.Span,
+) error {
+	ctx = tc.AnnotateCtx(ctx)
+	tc.txnMu.Lock()
+	defer tc.txnMu.Unlock()
+
+	// The remote writes have already been performed and don't go through the
+	// checks of updateState(): instead of losing track of intents past the
+	// limit, condense them into ranged spans.
+	txnMeta, ok := tc.txnMu.txns[*txn.ID]
+	if !ok {
+		if txn.Status != roachpb.PENDING {
+			if len(intents) > 0 {
+				// The intents can't be resolved by the coordinator anymore.
+				return errNoState
+			}
+			return nil
+		}
+		_, err := tc.registerTxnLocked(
+			ctx, txn.Clone(), condenseIntentSpans(intents, maxIntents.Get()), tc.clock.PhysicalNow())
+		return err
+	}
+
+	keys := append(txnMeta.keys, intents...)
+	if int64(len(keys)) > maxIntents.Get() {
+		keys = condenseIntentSpans(keys, maxIntents.Get())
+	}
+	txnMeta.keys = keys
+	if txn.Status != roachpb.PENDING {
+		tc.cleanupTxnLocked(ctx, txn)
+		return nil
+	}
+	txnMeta.txn.Update(&txn)
+	txnMeta.setLastUpdate(tc.clock.PhysicalNow())
+	return nil
+}
+
+// condenseIntentSpans merges the given intent spans and, if more than max
+// spans remain, replaces them by a single span covering all of them.
+// Resolving a ranged intent span resolves all the intents it contains, so no
+// intent is lost; the cost is a scan of the keys in between when the
+// transaction ends.
+func condenseIntentSpans(spans []roachpb.Span, max int64) []roachpb.Span {
+	spans, _ = roachpb.MergeSpans(append([]roachpb.Span(nil), spans...))
+	if int64(len(spans)) <= max {
+		return spans
+	}
+	// The merged spans are sorted and don't overlap.
+	last := spans[len(spans)-1]
+	endKey := last.EndKey
+	if len(endKey) == 0 {
+		endKey = last.Key.Next()
+	}
+	return []roachpb.Span{{Key: spans[0].Key, EndKey: endKey}}
+}
+
 // TODO(tschottdorf): this method is somewhat awkward but unless we want to
 // give this error back to the client, our options are limited. We'll have to
 // run the whole thing for them, or any restart will still end up at the client
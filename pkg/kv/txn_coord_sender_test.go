@@ -584,6 +584,158 @@ func TestTxnCoordSenderAddIntentOnError(t *testing.T) {
 	}
 }
 
+// TestTxnCoordSenderRemoteWrites verifies that the coordinator tracks the
+// intents written by a leaf transaction bypassing it (like DistSQL processors
+// do), once they're reported through the client.Txn.
+func TestTxnCoordSenderRemoteWrites(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, sender := createTestDB(t)
+	defer s.Stop()
+	ctx := context.TODO()
+
+	txn := client.NewTxn(s.DB)
+	if err := txn.PrepareForRemoteWrites(ctx, roachpb.Key("a")); err != nil {
+		t.Fatal(err)
+	}
+	if !txn.Proto().Writing {
+		t.Fatalf("expected the transaction to be writing: %s", txn.Proto())
+	}
+
+	leaf := client.NewTxnWithProto(client.NewDB(sender.wrapped, s.Clock), *txn.Proto())
+	leaf.AcceptUnhandledRetryableErrors()
+	leaf.TrackIntents()
+	if err := leaf.Put(ctx, "b", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.AugmentRemoteWrites(ctx, *leaf.Proto(), leaf.Intents()); err != nil {
+		t.Fatal(err)
+	}
+
+	sender.txnMu.Lock()
+	intentSpans, _ := roachpb.MergeSpans(sender.txnMu.txns[*txn.Proto().ID].keys)
+	sender.txnMu.Unlock()
+	expSpans := []roachpb.Span{{Key: roachpb.Key("a")}, {Key: roachpb.Key("b")}}
+	if !reflect.DeepEqual(intentSpans, expSpans) {
+		t.Fatalf("expected stored intents %v, got %v", expSpans, intentSpans)
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	kv, err := s.DB.Get(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := kv.Value.GetBytes(); err != nil || string(v) != "v" {
+		t.Fatalf("expected to read the remote write, got %v (%v)", kv.Value, err)
+	}
+}
+
+// TestTxnCoordSenderRemoteWritesTooManyIntents verifies that remote intents
+// exceeding the intent limit are condensed into a ranged span instead of being
+// dropped.
+func TestTxnCoordSenderRemoteWritesTooManyIntents(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer settings.TestingSetInt(&maxIntents, 3)()
+	s, sender := createTestDB(t)
+	defer s.Stop()
+	ctx := context.TODO()
+
+	txn := client.NewTxn(s.DB)
+	if err := txn.PrepareForRemoteWrites(ctx, roachpb.Key("a")); err != nil {
+		t.Fatal(err)
+	}
+	leaf := client.NewTxnWithProto(client.NewDB(sender.wrapped, s.Clock), *txn.Proto())
+	leaf.AcceptUnhandledRetryableErrors()
+	leaf.TrackIntents()
+	for i := 0; i < 5; i++ {
+		if err := leaf.Put(ctx, fmt.Sprintf("b%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := txn.AugmentRemoteWrites(ctx, *leaf.Proto(), leaf.Intents()); err != nil {
+		t.Fatal(err)
+	}
+
+	sender.txnMu.Lock()
+	intentSpans := sender.txnMu.txns[*txn.Proto().ID].keys
+	sender.txnMu.Unlock()
+	expSpans := []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b4").Next()}}
+	if !reflect.DeepEqual(intentSpans, expSpans) {
+		t.Fatalf("expected stored intents %v, got %v", expSpans, intentSpans)
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		key := roachpb.Key(fmt.Sprintf("b%d", i))
+		if kv, err := s.DB.Get(ctx, key); err != nil {
+			t.Fatal(err)
+		} else if !kv.Exists() {
+			t.Fatalf("missing value for %s", key)
+		}
+	}
+}
+
+// TestTxnCoordSenderRemoteWritesUntracked verifies that reporting intents of
+// a finished transaction the coordinator doesn't track is an error.
+func TestTxnCoordSenderRemoteWritesUntracked(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, sender := createTestDB(t)
+	defer s.Stop()
+	ctx := context.TODO()
+
+	txn := *roachpb.NewTransaction("test", roachpb.Key("a"), roachpb.NormalUserPriority,
+		enginepb.SERIALIZABLE, s.Clock.Now(), 0)
+	intents := []roachpb.Span{{Key: roachpb.Key("a")}}
+	txn.Status = roachpb.ABORTED
+	if err := sender.AugmentTxnState(ctx, txn, intents); err != errNoState {
+		t.Fatalf("expected %v, got %v", errNoState, err)
+	}
+	if err := sender.AugmentTxnState(ctx, txn, nil /* intents */); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sender.GetTxnState(*txn.ID); ok {
+		t.Fatal("expected the transaction to not be tracked")
+	}
+}
+
+// TestTxnCoordSenderRemoteRetryableErr verifies that a retryable error
+// encountered by remote writes updates the coordinator's state.
+func TestTxnCoordSenderRemoteRetryableErr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, sender := createTestDB(t)
+	defer s.Stop()
+	ctx := context.TODO()
+
+	txn := client.NewTxn(s.DB)
+	if err := txn.PrepareForRemoteWrites(ctx, roachpb.Key("a")); err != nil {
+		t.Fatal(err)
+	}
+	txnID := *txn.Proto().ID
+
+	// A restart increments the epoch of the tracked transaction.
+	pErr := roachpb.NewErrorWithTxn(
+		roachpb.NewTransactionRetryError(roachpb.RETRY_SERIALIZABLE), txn.Proto())
+	txn.UpdateStateOnRemoteRetryableErr(ctx, *pErr)
+	if coordTxn, ok := sender.GetTxnState(txnID); !ok {
+		t.Fatal("expected the transaction to be tracked")
+	} else if coordTxn.Epoch != 1 {
+		t.Fatalf("expected epoch 1, got %s", coordTxn)
+	}
+
+	// An abort makes the coordinator stop tracking the transaction.
+	pErr = roachpb.NewErrorWithTxn(&roachpb.TransactionAbortedError{}, txn.Proto())
+	txn.UpdateStateOnRemoteRetryableErr(ctx, *pErr)
+	testutils.SucceedsSoon(t, func() error {
+		if _, ok := sender.GetTxnState(txnID); ok {
+			return errors.Errorf("expected the transaction to no longer be tracked")
+		}
+		return nil
+	})
+}
+
 func assertTransactionRetryError(t *testing.T, e error) {
 	if retErr, ok := e.(*roachpb.HandledRetryableTxnError); ok {
 		if !testutils.IsError(retErr, "TransactionRetryError") {
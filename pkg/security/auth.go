@@ -146,3 +146,34 @@ func UserAuthPasswordHook(insecureMode bool, password string, hashedPassword []b
 		return nil
 	}
 }
+
+// UserAuthScramHook builds an authentication hook based on the security
+// mode and a SCRAM-SHA-256 exchange with the client. The hook must be called
+// after the client's final message was checked by the exchange.
+func UserAuthScramHook(insecureMode bool, exchange *ScramExchange) UserAuthHook {
+	return func(requestedUser string, clientConnection bool) error {
+		if len(requestedUser) == 0 {
+			return errors.New("user is missing")
+		}
+
+		if !clientConnection {
+			return errors.New("password authentication is only available for client connections")
+		}
+
+		if insecureMode {
+			return nil
+		}
+
+		if requestedUser == RootUser {
+			return errors.Errorf("user %s must use certificate authentication instead of password authentication", RootUser)
+		}
+
+		// Users without a SCRAM verifier, including those with an empty
+		// password, never complete the exchange.
+		if !exchange.verified {
+			return errors.New("invalid password")
+		}
+
+		return nil
+	}
+}
@@ -19,6 +19,7 @@ package security
 import (
 	"bytes"
 	"crypto/sha256"
+	"crypto/subtle"
 	"fmt"
 	"os"
 
@@ -31,20 +32,38 @@ import (
 // BCrypt cost should increase along with computation power.
 // For estimates, see: http://security.stackexchange.com/questions/17207/recommended-of-rounds-for-bcrypt
 // For now, we use the library's default cost.
+// Only password hashes stored before SCRAM support use bcrypt.
 const bcryptCost = bcrypt.DefaultCost
 
 // ErrEmptyPassword indicates that an empty password was attempted to be set.
 var ErrEmptyPassword = errors.New("empty passwords are not permitted")
 
+// compareHashAndPassword checks a cleartext password against a stored
+// password hash, which is either a SCRAM verifier or a legacy bcrypt hash.
 func compareHashAndPassword(hashedPassword []byte, password string) error {
+	if isScramVerifier(hashedPassword) {
+		v, err := parseScramVerifier(hashedPassword)
+		if err != nil {
+			return err
+		}
+		candidate := makeScramVerifier(password, v.salt, v.iterations)
+		if subtle.ConstantTimeCompare(candidate.storedKey, v.storedKey) != 1 {
+			return errors.New("password mismatch")
+		}
+		return nil
+	}
 	h := sha256.New()
 	return bcrypt.CompareHashAndPassword(hashedPassword, h.Sum([]byte(password)))
 }
 
-// HashPassword takes a raw password and returns a bcrypt hashed password.
+// HashPassword takes a raw password and returns a salted SCRAM-SHA-256
+// verifier, in the format used by postgres.
 func HashPassword(password string) ([]byte, error) {
-	h := sha256.New()
-	return bcrypt.GenerateFromPassword(h.Sum([]byte(password)), bcryptCost)
+	salt, err := randomBytes(scramSaltLen)
+	if err != nil {
+		return nil, err
+	}
+	return makeScramVerifier(password, salt, scramIterations).encode(), nil
 }
 
 // PromptForPassword prompts for a password.
@@ -87,8 +106,8 @@ func PromptForPasswordTwice() (string, error) {
 	return string(one), nil
 }
 
-// PromptForPasswordAndHash prompts for a password twice and returns the bcrypt
-// hash.
+// PromptForPasswordAndHash prompts for a password twice and returns its
+// SCRAM verifier.
 func PromptForPasswordAndHash() ([]byte, error) {
 	password, err := PromptForPasswordTwice()
 	if err != nil {
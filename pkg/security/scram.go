@@ -0,0 +1,315 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ScramMechanism is the name of the SASL mechanism implemented by
+// ScramExchange. See RFC 5802 and RFC 7677.
+const ScramMechanism = "SCRAM-SHA-256"
+
+const (
+	// scramIterations is the iteration count of the verifiers we store. It
+	// matches the default of postgres.
+	scramIterations = 4096
+	scramSaltLen    = 16
+	scramNonceLen   = 18
+)
+
+// scramVerifierPrefix starts the stored SCRAM verifiers, which use the
+// text format of postgres, SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>,
+// with the salt and keys encoded in base64. Password hashes stored before
+// SCRAM support are bcrypt hashes, which start with "$2".
+const scramVerifierPrefix = ScramMechanism + "$"
+
+// scramVerifier holds what the server needs to verify a SCRAM client
+// without knowing its password.
+type scramVerifier struct {
+	iterations int
+	salt       []byte
+	storedKey  []byte
+	serverKey  []byte
+}
+
+// scramHi is the Hi function of RFC 5802, i.e. PBKDF2 with HMAC-SHA-256
+// producing a single block.
+func scramHi(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	var one [4]byte
+	binary.BigEndian.PutUint32(one[:], 1)
+	mac.Write(one[:])
+	u := mac.Sum(nil)
+	res := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range res {
+			res[j] ^= u[j]
+		}
+	}
+	return res
+}
+
+func scramHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+// makeScramVerifier computes the verifier of a password. Passwords are used
+// verbatim, without SASLprep normalization.
+func makeScramVerifier(password string, salt []byte, iterations int) scramVerifier {
+	saltedPassword := scramHi([]byte(password), salt, iterations)
+	storedKey := sha256.Sum256(scramHMAC(saltedPassword, "Client Key"))
+	return scramVerifier{
+		iterations: iterations,
+		salt:       salt,
+		storedKey:  storedKey[:],
+		serverKey:  scramHMAC(saltedPassword, "Server Key"),
+	}
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// scramMockKey is the secret key from which the salts of mock verifiers
+// are derived. It is generated once per process, so that the mock salt of
+// a user is the same across authentication attempts, like a real salt.
+var scramMockKey struct {
+	once sync.Once
+	key  []byte
+	err  error
+}
+
+// scramMockSalt returns the salt of the mock verifier of a user, which is
+// HMAC(scramMockKey, username).
+func scramMockSalt(username string) ([]byte, error) {
+	scramMockKey.once.Do(func() {
+		scramMockKey.key, scramMockKey.err = randomBytes(sha256.Size)
+	})
+	if scramMockKey.err != nil {
+		return nil, scramMockKey.err
+	}
+	return scramHMAC(scramMockKey.key, username)[:scramSaltLen], nil
+}
+
+func (v scramVerifier) encode() []byte {
+	enc := base64.StdEncoding.EncodeToString
+	return []byte(fmt.Sprintf("%s%d:%s$%s:%s", scramVerifierPrefix,
+		v.iterations, enc(v.salt), enc(v.storedKey), enc(v.serverKey)))
+}
+
+// isScramVerifier returns whether a stored password hash is a SCRAM
+// verifier.
+func isScramVerifier(hashedPassword []byte) bool {
+	return bytes.HasPrefix(hashedPassword, []byte(scramVerifierPrefix))
+}
+
+func parseScramVerifier(hashedPassword []byte) (scramVerifier, error) {
+	var v scramVerifier
+	if !isScramVerifier(hashedPassword) {
+		return v, errors.New("password hash is not a SCRAM verifier")
+	}
+	parts := strings.Split(string(hashedPassword[len(scramVerifierPrefix):]), "$")
+	if len(parts) != 2 {
+		return v, errors.New("malformed SCRAM verifier")
+	}
+	iterSalt := strings.Split(parts[0], ":")
+	keys := strings.Split(parts[1], ":")
+	if len(iterSalt) != 2 || len(keys) != 2 {
+		return v, errors.New("malformed SCRAM verifier")
+	}
+	var err error
+	if v.iterations, err = strconv.Atoi(iterSalt[0]); err != nil || v.iterations < 1 {
+		return v, errors.New("malformed SCRAM verifier iteration count")
+	}
+	dec := base64.StdEncoding.DecodeString
+	if v.salt, err = dec(iterSalt[1]); err != nil {
+		return v, errors.Wrap(err, "malformed SCRAM verifier salt")
+	}
+	if v.storedKey, err = dec(keys[0]); err != nil {
+		return v, errors.Wrap(err, "malformed SCRAM verifier")
+	}
+	if v.serverKey, err = dec(keys[1]); err != nil {
+		return v, errors.Wrap(err, "malformed SCRAM verifier")
+	}
+	if len(v.storedKey) != sha256.Size || len(v.serverKey) != sha256.Size {
+		return v, errors.New("malformed SCRAM verifier")
+	}
+	return v, nil
+}
+
+// IsLegacyPasswordHash returns whether a stored password hash predates SCRAM
+// support. Such hashes can only be checked against a cleartext password.
+func IsLegacyPasswordHash(hashedPassword []byte) bool {
+	return len(hashedPassword) > 0 && !isScramVerifier(hashedPassword)
+}
+
+// ScramExchange is the server side of a SCRAM-SHA-256 authentication
+// exchange. The client sends its first message, answered by ServerFirst,
+// then its final message, checked by VerifyClientFinal. If the client
+// proved that it knows the password, the exchange completes with the
+// message returned by ServerFinal.
+type ScramExchange struct {
+	verifier scramVerifier
+	// valid is false if the user has no SCRAM verifier. The exchange then
+	// proceeds with a mock verifier, to not reveal whether the user has a
+	// password, and fails at the end. The salt of the mock verifier is
+	// derived from the user name, so that it doesn't change between
+	// attempts either.
+	valid bool
+	// verified is set once the client proof has been checked.
+	verified bool
+
+	gs2Header       string
+	nonce           string
+	clientFirstBare string
+	serverFirst     string
+	authMessage     string
+}
+
+// NewScramExchange starts a SCRAM-SHA-256 exchange for a user against its
+// stored password hash.
+func NewScramExchange(username string, hashedPassword []byte) (*ScramExchange, error) {
+	e := &ScramExchange{}
+	if v, err := parseScramVerifier(hashedPassword); err == nil {
+		e.verifier = v
+		e.valid = true
+	} else {
+		salt, err := scramMockSalt(username)
+		if err != nil {
+			return nil, err
+		}
+		e.verifier = scramVerifier{iterations: scramIterations, salt: salt}
+	}
+	return e, nil
+}
+
+// ServerFirst processes the client-first-message and returns the
+// server-first-message.
+func (e *ScramExchange) ServerFirst(clientFirst []byte) ([]byte, error) {
+	msg := string(clientFirst)
+	// The GS2 header is a channel binding flag and an optional
+	// authorization identity, each followed by a comma.
+	if len(msg) < 3 {
+		return nil, errors.New("malformed SCRAM message")
+	}
+	switch msg[0] {
+	case 'n', 'y':
+		if msg[1] != ',' {
+			return nil, errors.New("malformed SCRAM message")
+		}
+	case 'p':
+		return nil, errors.New("SCRAM channel binding is not supported")
+	default:
+		return nil, errors.Errorf("malformed SCRAM message: unexpected channel binding flag %q", msg[0])
+	}
+	if msg[2] != ',' {
+		return nil, errors.New("SCRAM authorization identities are not supported")
+	}
+	e.gs2Header = msg[:3]
+	e.clientFirstBare = msg[3:]
+
+	// The user name was sent in the startup message, and postgres clients
+	// leave it empty here.
+	attrs := strings.Split(e.clientFirstBare, ",")
+	if strings.HasPrefix(attrs[0], "m=") {
+		return nil, errors.New("SCRAM extensions are not supported")
+	}
+	if len(attrs) < 2 || !strings.HasPrefix(attrs[0], "n=") {
+		return nil, errors.New("malformed SCRAM message: expected user name")
+	}
+	if !strings.HasPrefix(attrs[1], "r=") || len(attrs[1]) == 2 {
+		return nil, errors.New("malformed SCRAM message: expected nonce")
+	}
+	serverNonce, err := randomBytes(scramNonceLen)
+	if err != nil {
+		return nil, err
+	}
+	e.nonce = attrs[1][2:] + base64.StdEncoding.EncodeToString(serverNonce)
+	e.serverFirst = fmt.Sprintf("r=%s,s=%s,i=%d", e.nonce,
+		base64.StdEncoding.EncodeToString(e.verifier.salt), e.verifier.iterations)
+	return []byte(e.serverFirst), nil
+}
+
+// VerifyClientFinal processes the client-final-message and checks the
+// client proof. An error is only returned for malformed messages; whether
+// the proof was valid is reported by the authentication hook.
+func (e *ScramExchange) VerifyClientFinal(clientFinal []byte) error {
+	if e.serverFirst == "" {
+		return errors.New("SCRAM exchange not started")
+	}
+	msg := string(clientFinal)
+	proofIdx := strings.LastIndex(msg, ",p=")
+	if proofIdx < 0 {
+		return errors.New("malformed SCRAM message: expected proof")
+	}
+	withoutProof := msg[:proofIdx]
+	attrs := strings.Split(withoutProof, ",")
+	if len(attrs) < 2 || !strings.HasPrefix(attrs[0], "c=") || !strings.HasPrefix(attrs[1], "r=") {
+		return errors.New("malformed SCRAM message")
+	}
+	binding, err := base64.StdEncoding.DecodeString(attrs[0][2:])
+	if err != nil || string(binding) != e.gs2Header {
+		return errors.New("SCRAM channel binding does not match")
+	}
+	if attrs[1][2:] != e.nonce {
+		return errors.New("SCRAM nonce does not match")
+	}
+	proof, err := base64.StdEncoding.DecodeString(msg[proofIdx+3:])
+	if err != nil || len(proof) != sha256.Size {
+		return errors.New("malformed SCRAM client proof")
+	}
+
+	e.authMessage = e.clientFirstBare + "," + e.serverFirst + "," + withoutProof
+	if !e.valid {
+		return nil
+	}
+	clientKey := scramHMAC(e.verifier.storedKey, e.authMessage)
+	for i := range clientKey {
+		clientKey[i] ^= proof[i]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	e.verified = subtle.ConstantTimeCompare(storedKey[:], e.verifier.storedKey) == 1
+	return nil
+}
+
+// ServerFinal returns the server-final-message, which proves to the client
+// that the server knows its verifier.
+func (e *ScramExchange) ServerFinal() []byte {
+	sig := scramHMAC(e.verifier.serverKey, e.authMessage)
+	return []byte("v=" + base64.StdEncoding.EncodeToString(sig))
+}
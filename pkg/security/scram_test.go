@@ -0,0 +1,231 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestScramExchangeRFC7677 checks the exchange against the example of
+// RFC 7677, section 3.
+func TestScramExchangeRFC7677(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	salt, err := base64.StdEncoding.DecodeString("W22ZaJ0SNY7soEsUEjb6gQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashedPassword := makeScramVerifier("pencil", salt, 4096).encode()
+
+	e, err := NewScramExchange("user", hashedPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.ServerFirst([]byte("n,,n=user,r=rOprNGfwEbeRWgbNEkqO")); err != nil {
+		t.Fatal(err)
+	}
+	// Replace the random server nonce by the one of the example.
+	e.nonce = "rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	e.serverFirst = "r=" + e.nonce + ",s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+
+	clientFinal := "c=biws,r=" + e.nonce + ",p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if err := e.VerifyClientFinal([]byte(clientFinal)); err != nil {
+		t.Fatal(err)
+	}
+	if err := UserAuthScramHook(false, e)("user", true); err != nil {
+		t.Fatal(err)
+	}
+	if out, expected := string(e.ServerFinal()),
+		"v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="; out != expected {
+		t.Errorf("expected server-final-message %q, got %q", expected, out)
+	}
+}
+
+// scramClientFinal computes the client-final-message of an exchange, as a
+// client knowing password would.
+func scramClientFinal(password, clientFirstBare, serverFirst string) (string, error) {
+	attrs := strings.Split(serverFirst, ",")
+	nonce := strings.TrimPrefix(attrs[0], "r=")
+	salt, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(attrs[1], "s="))
+	if err != nil {
+		return "", err
+	}
+	v := makeScramVerifier(password, salt, scramIterations)
+	clientKey := scramHMAC(scramHi([]byte(password), salt, scramIterations), "Client Key")
+
+	withoutProof := "c=biws,r=" + nonce
+	proof := scramHMAC(v.storedKey, clientFirstBare+","+serverFirst+","+withoutProof)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func TestScramExchange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	hashedPassword, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPassword, err := bcrypt.GenerateFromPassword(
+		sha256.New().Sum([]byte("secret")), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		hashedPassword []byte
+		user           string
+		password       string
+		success        bool
+	}{
+		{hashedPassword, "foo", "secret", true},
+		{hashedPassword, "foo", "wrong", false},
+		{hashedPassword, RootUser, "secret", false},
+		{legacyPassword, "foo", "secret", false},
+		{nil, "foo", "", false},
+	}
+	for i, tc := range testCases {
+		e, err := NewScramExchange(tc.user, tc.hashedPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const clientFirstBare = "n=,r=fyko+d2lbbFgONRv9qkxdawL"
+		serverFirst, err := e.ServerFirst([]byte("n,," + clientFirstBare))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientFinal, err := scramClientFinal(tc.password, clientFirstBare, string(serverFirst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.VerifyClientFinal([]byte(clientFinal)); err != nil {
+			t.Fatal(err)
+		}
+		err = UserAuthScramHook(false, e)(tc.user, true)
+		if (err == nil) != tc.success {
+			t.Errorf("%d: expected success=%t, got err=%v", i, tc.success, err)
+		}
+	}
+}
+
+// TestScramMockSalt checks that the salt sent to a user without a SCRAM
+// verifier is the same in every exchange, like the salt of a verifier.
+func TestScramMockSalt(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	serverFirstSalt := func(user string) string {
+		e, err := NewScramExchange(user, nil /* hashedPassword */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serverFirst, err := e.ServerFirst([]byte("n,,n=,r=abc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(string(serverFirst), ",")[1]
+	}
+	foo := serverFirstSalt("foo")
+	if again := serverFirstSalt("foo"); again != foo {
+		t.Errorf("expected the same salt for the same user, got %s and %s", foo, again)
+	}
+	if bar := serverFirstSalt("bar"); bar == foo {
+		t.Errorf("expected different salts for different users, got %s", bar)
+	}
+	salt, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(foo, "s="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(salt) != scramSaltLen {
+		t.Errorf("expected a salt of %d bytes, got %d", scramSaltLen, len(salt))
+	}
+}
+
+func TestScramExchangeErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	hashedPassword, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clientFirst := range []string{
+		"",
+		"x,,n=,r=abc",
+		"p=tls-server-end-point,,n=,r=abc",
+		"n,a=foo,n=,r=abc",
+		"n,,m=ext,n=,r=abc",
+		"n,,n=",
+		"n,,n=,r=",
+	} {
+		e, err := NewScramExchange("foo", hashedPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.ServerFirst([]byte(clientFirst)); err == nil {
+			t.Errorf("%q: expected error", clientFirst)
+		}
+	}
+
+	for _, clientFinal := range []string{
+		"c=biws,r=abc",
+		"c=eSws,r=%s,p=AAAA",
+		"c=biws,r=wrongnonce,p=AAAA",
+		"c=biws,r=%s,p=AAAA",
+	} {
+		e, err := NewScramExchange("foo", hashedPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.ServerFirst([]byte("n,,n=,r=abc")); err != nil {
+			t.Fatal(err)
+		}
+		msg := strings.Replace(clientFinal, "%s", e.nonce, 1)
+		if err := e.VerifyClientFinal([]byte(msg)); err == nil {
+			t.Errorf("%q: expected error", clientFinal)
+		}
+	}
+}
+
+func TestCompareHashAndPassword(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	scram, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword(sha256.New().Sum([]byte("secret")), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hashedPassword := range [][]byte{scram, legacy} {
+		if err := compareHashAndPassword(hashedPassword, "secret"); err != nil {
+			t.Errorf("%s: %v", hashedPassword, err)
+		}
+		if err := compareHashAndPassword(hashedPassword, "wrong"); err == nil {
+			t.Errorf("%s: expected error", hashedPassword)
+		}
+	}
+	if IsLegacyPasswordHash(scram) || !IsLegacyPasswordHash(legacy) || IsLegacyPasswordHash(nil) {
+		t.Error("unexpected result of IsLegacyPasswordHash")
+	}
+}
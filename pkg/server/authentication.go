@@ -0,0 +1,90 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+)
+
+// httpUserMetadataKey is the gRPC metadata key under which the grpc-gateway
+// passes the user of an HTTP request to the gRPC handler of the request. It
+// is set, possibly to an empty string, on every request made through the
+// gateway.
+const httpUserMetadataKey = "cockroach-http-user"
+
+// makeHTTPUserAnnotator returns the metadata annotator of the grpc-gateway,
+// which passes on the user authenticated by the client certificate of an
+// HTTP request. The user is empty if the request has no client certificate.
+// Insecure clusters don't authenticate their clients, and their HTTP
+// requests are made on behalf of root.
+func makeHTTPUserAnnotator(insecure bool) func(context.Context, *http.Request) metadata.MD {
+	return func(_ context.Context, r *http.Request) metadata.MD {
+		var user string
+		if insecure {
+			user = security.RootUser
+		} else if certUser, err := security.GetCertificateUser(r.TLS); err == nil {
+			user = certUser
+		}
+		return metadata.Pairs(httpUserMetadataKey, user)
+	}
+}
+
+// requestUser returns the user on whose behalf an RPC is made, and whether
+// the RPC is made by a node rather than by a client:
+// - RPCs made in-process by the SQL layer, and RPCs forwarded by other
+//   nodes, are made by a node on behalf of namedUser, the user named in the
+//   request;
+// - RPCs made through the HTTP gateway are made on behalf of the user
+//   authenticated by the gateway;
+// - other RPCs are made on behalf of the user of their client certificate.
+// Insecure clusters don't authenticate their clients, so all their RPCs but
+// those made through the gateway are treated as made by a node.
+func requestUser(ctx context.Context, insecure bool, namedUser string) (string, bool, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			certUser, err := security.GetCertificateUser(&tlsInfo.State)
+			if err != nil {
+				return "", false, grpc.Errorf(codes.Unauthenticated, "%s", err)
+			}
+			if certUser != security.NodeUser {
+				return certUser, false, nil
+			}
+		} else if !insecure {
+			return "", false, grpc.Errorf(codes.Unauthenticated, "request is not using TLS")
+		}
+		// The gateway connects to its own node with the node certificate. The
+		// user it passes on must be unique, so that it can't be supplemented
+		// through the headers of the HTTP request.
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if users, ok := md[httpUserMetadataKey]; ok {
+				if len(users) != 1 || users[0] == "" {
+					return "", false, grpc.Errorf(codes.Unauthenticated,
+						"no client certificate in HTTP request")
+				}
+				return users[0], false, nil
+			}
+		}
+	}
+	return namedUser, true, nil
+}
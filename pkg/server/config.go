@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"math"
 	"net"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -485,6 +486,30 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 	return enginesCopy, nil
 }
 
+// tempEngineMaxOpenFiles is the max_open_files limit of the temporary engine
+// DistSQL processors spill rows to. The engine only holds short-lived data, so
+// it is kept well below the limit of the stores.
+const tempEngineMaxOpenFiles = 256
+
+// CreateTempEngine creates the engine DistSQL processors use to store rows
+// that do not fit in memory. It lives in a subdirectory of the first store's
+// temp directory, which is cleared when the store is opened, or in memory if
+// the first store is in memory.
+func (cfg *Config) CreateTempEngine(firstStore engine.Engine) (engine.Engine, error) {
+	if cfg.Stores.Specs[0].InMemory {
+		return engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */), nil
+	}
+	cache := engine.NewRocksDBCache(0)
+	defer cache.Release()
+	return engine.NewRocksDB(
+		roachpb.Attributes{},
+		filepath.Join(firstStore.GetTempDir(), "distsql"),
+		cache,
+		0, /* maxSize */
+		tempEngineMaxOpenFiles,
+	)
+}
+
 // InitNode parses node attributes and initializes the gossip bootstrap
 // resolvers.
 func (cfg *Config) InitNode() error {
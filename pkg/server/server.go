@@ -662,6 +662,13 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 	s.stopper.AddCloser(&s.engines)
 
+	tempEngine, err := s.cfg.CreateTempEngine(s.engines[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp engine")
+	}
+	s.stopper.AddCloser(tempEngine)
+	s.distSQLServer.TempStorage = tempEngine
+
 	// We might have to sleep a bit to protect against this node producing non-
 	// monotonic timestamps. Before restarting, its clock might have been driven
 	// by other nodes' fast clocks, but when we restarted, we lost all this
@@ -827,6 +834,7 @@ func (s *Server) Start(ctx context.Context) error {
 		gwruntime.WithMarshalerOption(httputil.AltJSONContentType, jsonpb),
 		gwruntime.WithMarshalerOption(httputil.ProtoContentType, protopb),
 		gwruntime.WithMarshalerOption(httputil.AltProtoContentType, protopb),
+		gwruntime.WithMetadata(makeHTTPUserAnnotator(s.cfg.Insecure)),
 	)
 	gwCtx, gwCancel := context.WithCancel(s.AnnotateCtx(context.Background()))
 	s.stopper.AddCloser(stop.CloserFn(gwCancel))
@@ -898,6 +898,85 @@ func (s *statusServer) ListSessions(
 	return &resp, nil
 }
 
+// CancelQuery cancels the query with the given ID, which runs on the given
+// node. Only root and the user who issued the query can cancel it. If a
+// pgwire cancel key is given instead, the queries of the session with that
+// key are canceled; only nodes can do so, since the key is the only proof
+// that the client which sent it to a node owns the session.
+func (s *statusServer) CancelQuery(
+	ctx context.Context, req *serverpb.CancelQueryRequest,
+) (*serverpb.CancelQueryResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	user, fromNode, err := requestUser(ctx, s.cfg.Insecure, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if req.CancelKey != 0 && !fromNode {
+		return nil, grpc.Errorf(codes.PermissionDenied, "only nodes can cancel queries by key")
+	}
+	nodeID, local, err := s.parseNodeID(req.NodeID)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		// The node the request is forwarded to trusts the user it names.
+		forwardReq := *req
+		forwardReq.Username = user
+		return status.CancelQuery(ctx, &forwardReq)
+	}
+
+	output := &serverpb.CancelQueryResponse{}
+	if req.CancelKey != 0 {
+		output.Canceled = s.sessionRegistry.CancelQueriesByKey(req.CancelKey)
+		return output, nil
+	}
+	output.Canceled, err = s.sessionRegistry.CancelQuery(req.QueryID, user)
+	if err != nil {
+		output.Error = err.Error()
+	}
+	return output, nil
+}
+
+// CancelSession cancels the session with the given ID, which runs on the given
+// node, and closes its client connection. Only root and the user of the
+// session can cancel it.
+func (s *statusServer) CancelSession(
+	ctx context.Context, req *serverpb.CancelSessionRequest,
+) (*serverpb.CancelSessionResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	user, _, err := requestUser(ctx, s.cfg.Insecure, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	nodeID, local, err := s.parseNodeID(req.NodeID)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		// The node the request is forwarded to trusts the user it names.
+		forwardReq := *req
+		forwardReq.Username = user
+		return status.CancelSession(ctx, &forwardReq)
+	}
+
+	output := &serverpb.CancelSessionResponse{}
+	output.Canceled, err = s.sessionRegistry.CancelSession(req.SessionID, user)
+	if err != nil {
+		output.Error = err.Error()
+	}
+	return output, nil
+}
+
 // SpanStats requests the total statistics stored on a node for a given key
 // span, which may include multiple ranges.
 func (s *statusServer) SpanStats(
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -669,3 +670,153 @@ func TestCertificatesResponse(t *testing.T) {
 	}
 
 }
+
+func TestCancelQueryAuthentication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+
+	if _, err := sqlDB.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.test (k INT PRIMARY KEY, v INT);
+INSERT INTO t.test VALUES (1, 1);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lay down an intent which blocks a query of root.
+	txn, err := sqlDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txn.Exec("UPDATE t.test SET v = 2 WHERE k = 1"); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := sqlDB.Exec("SELECT v FROM t.test WHERE k = 1")
+		errCh <- err
+	}()
+	var queryID string
+	testutils.SucceedsSoon(t, func() error {
+		return sqlDB.QueryRow(
+			"SELECT query_id FROM [SHOW CLUSTER QUERIES] WHERE query LIKE 'SELECT v FROM t.test%'",
+		).Scan(&queryID)
+	})
+	url := ts.AdminURL() + statusPrefix + "cancel_query/local"
+
+	// The user named in a request doesn't authorize it: clients are
+	// identified by their certificate.
+	testHTTPClient, err := testutils.NewTestBaseContext(TestUser).GetHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response serverpb.CancelQueryResponse
+	request := serverpb.CancelQueryRequest{QueryID: queryID, Username: security.RootUser}
+	if err := httputil.PostJSON(testHTTPClient, url, &request, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Canceled || !strings.Contains(response.Error, "not found") {
+		t.Fatalf("expected the query not to be found, got %+v", response)
+	}
+
+	// Only nodes can cancel queries by pgwire cancel key.
+	rootHTTPClient, err := testutils.NewTestBaseContext(security.RootUser).GetHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyRequest := serverpb.CancelQueryRequest{CancelKey: 1}
+	if err := httputil.PostJSON(
+		rootHTTPClient, url, &keyRequest, &response,
+	); !testutils.IsError(err, "only nodes can cancel queries by key") {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+
+	// Cancellations are not made through GET requests.
+	if err := httputil.GetJSON(rootHTTPClient, url, &response); !testutils.IsError(
+		err, "Not Found|Not Implemented|Method Not Allowed",
+	) {
+		t.Fatalf("expected GET to be rejected, got %v", err)
+	}
+
+	// Root can cancel the query.
+	if err := httputil.PostJSON(rootHTTPClient, url, &request, &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Canceled {
+		t.Fatalf("expected the query to be canceled, got %+v", response)
+	}
+	if err := <-errCh; !testutils.IsError(err, "query execution canceled") {
+		t.Fatalf("expected the query to be canceled, got %v", err)
+	}
+	if err := txn.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancelSessionAuthentication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+
+	// Use a single connection, whose session is the one canceled below.
+	sqlDB.SetMaxOpenConns(1)
+	var sessionID string
+	if err := sqlDB.QueryRow(
+		"SELECT session_id FROM [SHOW LOCAL SESSIONS]",
+	).Scan(&sessionID); err != nil {
+		t.Fatal(err)
+	}
+	url := ts.AdminURL() + statusPrefix + "cancel_session/local"
+
+	// The user named in a request doesn't authorize it: clients are
+	// identified by their certificate.
+	testHTTPClient, err := testutils.NewTestBaseContext(TestUser).GetHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response serverpb.CancelSessionResponse
+	request := serverpb.CancelSessionRequest{SessionID: sessionID, Username: security.RootUser}
+	if err := httputil.PostJSON(testHTTPClient, url, &request, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Canceled || !strings.Contains(response.Error, "not found") {
+		t.Fatalf("expected the session not to be found, got %+v", response)
+	}
+
+	rpcContext := rpc.NewContext(
+		log.AmbientContext{}, testutils.NewTestBaseContext(TestUser), ts.Clock(), ts.Stopper(),
+	)
+	conn, err := rpcContext.GRPCDial(ts.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcResponse, err := serverpb.NewStatusClient(conn).CancelSession(context.Background(), &request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grpcResponse.Canceled {
+		t.Fatalf("expected the session not to be found, got %+v", grpcResponse)
+	}
+
+	// Cancellations are not made through GET requests.
+	rootHTTPClient, err := testutils.NewTestBaseContext(security.RootUser).GetHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := httputil.GetJSON(rootHTTPClient, url, &response); !testutils.IsError(
+		err, "Not Found|Not Implemented|Method Not Allowed",
+	) {
+		t.Fatalf("expected GET to be rejected, got %v", err)
+	}
+
+	// Root can cancel the session.
+	if err := httputil.PostJSON(rootHTTPClient, url, &request, &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Canceled {
+		t.Fatalf("expected the session to be canceled, got %+v", response)
+	}
+}
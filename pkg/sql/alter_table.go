@@ -82,6 +82,9 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 				return pgerror.Unimplemented(
 					"alter add fk", "adding a REFERENCES constraint via ALTER not supported")
 			}
+			if err := n.p.resolveColumnType(d); err != nil {
+				return err
+			}
 			col, idx, err := sqlbase.MakeColumnDefDescs(d, n.p.session.SearchPath, &n.p.evalCtx)
 			if err != nil {
 				return err
@@ -97,6 +100,11 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 			}
 
 			n.tableDesc.AddColumnMutation(*col, sqlbase.DescriptorMutation_ADD)
+			if col.Type.Kind == sqlbase.ColumnType_ENUM {
+				if err := n.p.addTypeReference(ctx, col.Type.UserDefinedTypeID, n.tableDesc.ID); err != nil {
+					return err
+				}
+			}
 			if idx != nil {
 				n.tableDesc.AddIndexMutation(*idx, sqlbase.DescriptorMutation_ADD)
 			}
@@ -214,6 +222,26 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 			if n.tableDesc.PrimaryIndex.ContainsColumnID(col.ID) {
 				return fmt.Errorf("column %q is referenced by the primary key", col.Name)
 			}
+			for _, policy := range n.tableDesc.Policies {
+				used, err := policyReferencesColumn(policy, t.Column.Normalize())
+				if err != nil {
+					return err
+				}
+				if used {
+					return fmt.Errorf("column %q is referenced by policy %q", col.Name, policy.Name)
+				}
+			}
+			// The computed columns, such as the shard columns of hash sharded
+			// indexes, cannot be computed without the columns they reference.
+			dependents, err := sqlbase.AddDependentComputedColumns(
+				n.tableDesc, []sqlbase.ColumnDescriptor{col})
+			if err != nil {
+				return err
+			}
+			if len(dependents) > 1 {
+				return fmt.Errorf("column %q is referenced by computed column %q",
+					col.Name, dependents[1].Name)
+			}
 			for _, idx := range n.tableDesc.AllNonDropIndexes() {
 				// We automatically drop indexes on that column that only
 				// index that column (and no other columns). If CASCADE is
@@ -291,6 +319,11 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 			if !found {
 				return fmt.Errorf("column %q in the middle of being added, try again later", t.Column)
 			}
+			if err := n.p.deleteComment(ctx, commentKey{
+				typ: columnCommentType, objID: n.tableDesc.ID, subID: uint32(col.ID),
+			}); err != nil {
+				return err
+			}
 
 		case *parser.AlterTableDropConstraint:
 			info, err := n.tableDesc.GetConstraintInfo(ctx, nil)
@@ -412,6 +445,12 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 			n.tableDesc.UpdateColumnDescriptor(col)
 			descriptorChanged = true
 
+		case *parser.AlterTableSetRowLevelSecurity:
+			if n.tableDesc.RowLevelSecurity != t.Enabled {
+				n.tableDesc.RowLevelSecurity = t.Enabled
+				descriptorChanged = true
+			}
+
 		default:
 			return fmt.Errorf("unsupported alter cmd: %T", cmd)
 		}
@@ -0,0 +1,120 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+type alterTypeNode struct {
+	p        *planner
+	n        *parser.AlterTypeAddValue
+	typeDesc *sqlbase.TypeDescriptor
+}
+
+// AlterTypeAddValue adds a value to an enum type.
+// Privileges: CREATE on type.
+//   Notes: postgres requires the type owner.
+func (p *planner) AlterTypeAddValue(
+	ctx context.Context, n *parser.AlterTypeAddValue,
+) (planNode, error) {
+	tn, err := n.Type.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	typeDesc, err := mustGetTypeDesc(ctx, p.txn, p.getVirtualTabler(), tn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(typeDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &alterTypeNode{p: p, n: n, typeDesc: typeDesc}, nil
+}
+
+func (n *alterTypeNode) Start(ctx context.Context) error {
+	var existing string
+	var before bool
+	if n.n.Placement != nil {
+		existing, before = n.n.Placement.ExistingVal, n.n.Placement.Before
+	}
+	// The type descriptor is read transactionally, so the new value can be
+	// used in casts right away.
+	member, added, err := n.typeDesc.AddEnumMember(
+		n.n.NewVal, existing, before, sqlbase.TypeDescriptor_EnumMember_ALL,
+	)
+	if err != nil {
+		return err
+	}
+	if !added {
+		if n.n.IfNotExists {
+			return nil
+		}
+		return pgerror.NewErrorf(pgerror.CodeDuplicateObjectError,
+			"enum label %q already exists", n.n.NewVal)
+	}
+	if err := n.p.writeTypeDesc(ctx, n.typeDesc); err != nil {
+		return err
+	}
+
+	// Tables are leased, so their columns first learn about the new value as
+	// READ_ONLY; the schema changer makes it writable once no node uses a
+	// version of the table which cannot decode it.
+	tables, err := n.p.liveTypeReferences(ctx, n.typeDesc)
+	if err != nil {
+		return err
+	}
+	member.Capability = sqlbase.TypeDescriptor_EnumMember_READ_ONLY
+	for _, tableDesc := range tables {
+		tableDesc.AddEnumMember(n.typeDesc.ID, member)
+		if err := tableDesc.SetUpVersion(); err != nil {
+			return err
+		}
+		if err := n.p.writeTableDesc(ctx, tableDesc); err != nil {
+			return err
+		}
+		n.p.notifySchemaChange(tableDesc, sqlbase.InvalidMutationID)
+	}
+
+	// Log Alter Type event. This is an auditable log event and is recorded
+	// in the same transaction as the type descriptor update.
+	return MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogAlterType,
+		int32(n.typeDesc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TypeName  string
+			Statement string
+			User      string
+		}{n.typeDesc.Name, n.n.String(), n.p.session.User},
+	)
+}
+
+func (*alterTypeNode) Next(context.Context) (bool, error) { return false, nil }
+func (*alterTypeNode) Close(context.Context)              {}
+
+func (*alterTypeNode) Values() parser.Datums      { return parser.Datums{} }
+func (*alterTypeNode) DebugValues() debugValues   { return debugValues{} }
+func (*alterTypeNode) MarkDebug(mode explainMode) {}
@@ -0,0 +1,206 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+)
+
+type alterUserSetNode struct {
+	p        *planner
+	n        *parser.AlterUserSet
+	username string
+	varName  string
+	// value is the new default of the variable, or nil if the user's default
+	// is removed.
+	value parser.TypedExpr
+}
+
+// AlterUserSet configures the default value of a session variable for the
+// sessions of a user. The default is applied to the user's new sessions and
+// restored by RESET. Other nodes cache the defaults for up to
+// sql.user_defaults.cache_ttl.
+// Privileges: UPDATE on system.user_settings.
+//   Notes: postgres requires the CREATEROLE privilege, or the user itself.
+func (p *planner) AlterUserSet(ctx context.Context, n *parser.AlterUserSet) (planNode, error) {
+	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &parser.TableName{DatabaseName: "system", TableName: "user_settings"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tDesc, privilege.UPDATE); err != nil {
+		return nil, err
+	}
+
+	username, err := NormalizeAndValidateUsername(string(n.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	varName := strings.ToLower(parser.AsStringWithFlags(n.VarName, parser.FmtBareIdentifiers))
+	v, ok := varGen[varName]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable: %q", varName)
+	}
+	if v.Set == nil {
+		return nil, fmt.Errorf("variable \"%s\" cannot be changed", varName)
+	}
+
+	node := &alterUserSetNode{p: p, n: n, username: username, varName: varName}
+	switch len(n.Values) {
+	case 0:
+	case 1:
+		node.value, err = parser.TypeCheck(n.Values[0], nil, parser.TypeString)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("ALTER USER ... SET %s requires a single value", varName)
+	}
+	return node, nil
+}
+
+func (n *alterUserSetNode) Start(ctx context.Context) error {
+	internalExecutor := InternalExecutor{LeaseManager: n.p.LeaseMgr()}
+
+	// The root user is not in system.users.
+	if n.username != security.RootUser {
+		row, err := internalExecutor.QueryRowInTransaction(
+			ctx,
+			"alter-user-exists",
+			n.p.txn,
+			"SELECT username FROM system.users WHERE username = $1",
+			n.username,
+		)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			return errors.Errorf("user %s does not exist", n.username)
+		}
+	}
+
+	n.p.session.userDefaultsCache.invalidate(n.p.txn, n.username)
+
+	if n.value == nil {
+		_, err := internalExecutor.ExecuteStatementInTransaction(
+			ctx,
+			"alter-user-reset",
+			n.p.txn,
+			"DELETE FROM system.user_settings WHERE username = $1 AND variable = $2",
+			n.username,
+			n.varName,
+		)
+		return err
+	}
+
+	// The value is stored as a string, which is handed to the variable's
+	// setter when the default is applied.
+	d, err := n.value.Eval(&n.p.evalCtx)
+	if err != nil {
+		return err
+	}
+	_, err = internalExecutor.ExecuteStatementInTransaction(
+		ctx,
+		"alter-user-set",
+		n.p.txn,
+		"UPSERT INTO system.user_settings (username, variable, value) VALUES ($1, $2, $3)",
+		n.username,
+		n.varName,
+		parser.AsStringWithFlags(d, parser.FmtBareStrings),
+	)
+	return err
+}
+
+func (*alterUserSetNode) Next(context.Context) (bool, error) { return false, nil }
+func (*alterUserSetNode) Close(context.Context)              {}
+
+func (*alterUserSetNode) Values() parser.Datums      { return parser.Datums{} }
+func (*alterUserSetNode) DebugValues() debugValues   { return debugValues{} }
+func (*alterUserSetNode) MarkDebug(mode explainMode) {}
+
+type alterUserSetPasswordNode struct {
+	p        *planner
+	username string
+	password string
+}
+
+// AlterUserSetPassword changes the password of a user.
+// Privileges: UPDATE on system.users.
+//   Notes: postgres requires the CREATEROLE privilege, or the user itself.
+func (p *planner) AlterUserSetPassword(
+	ctx context.Context, n *parser.AlterUserSetPassword,
+) (planNode, error) {
+	tDesc, err := getTableDesc(ctx, p.txn, p.getVirtualTabler(), &parser.TableName{DatabaseName: "system", TableName: "users"})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tDesc, privilege.UPDATE); err != nil {
+		return nil, err
+	}
+
+	username, err := NormalizeAndValidateUsername(string(n.Name))
+	if err != nil {
+		return nil, err
+	}
+	if username == security.RootUser {
+		return nil, errors.Errorf("user %s cannot use password authentication", security.RootUser)
+	}
+	if n.Password == "" {
+		return nil, security.ErrEmptyPassword
+	}
+
+	return &alterUserSetPasswordNode{p: p, username: username, password: n.Password}, nil
+}
+
+func (n *alterUserSetPasswordNode) Start(ctx context.Context) error {
+	hashedPassword, err := security.HashPassword(n.password)
+	if err != nil {
+		return err
+	}
+
+	internalExecutor := InternalExecutor{LeaseManager: n.p.LeaseMgr()}
+	rowsAffected, err := internalExecutor.ExecuteStatementInTransaction(
+		ctx,
+		"alter-user-password",
+		n.p.txn,
+		`UPDATE system.users SET "hashedPassword" = $2 WHERE username = $1`,
+		n.username,
+		hashedPassword,
+	)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.Errorf("user %s does not exist", n.username)
+	}
+	return nil
+}
+
+func (*alterUserSetPasswordNode) Next(context.Context) (bool, error) { return false, nil }
+func (*alterUserSetPasswordNode) Close(context.Context)              {}
+
+func (*alterUserSetPasswordNode) Values() parser.Datums      { return parser.Datums{} }
+func (*alterUserSetPasswordNode) DebugValues() debugValues   { return debugValues{} }
+func (*alterUserSetPasswordNode) MarkDebug(mode explainMode) {}
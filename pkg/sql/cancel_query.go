@@ -0,0 +1,76 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// cancelQueryNode is the planNode of CANCEL QUERY. The query ID embeds the
+// ID of the node running the query, so Start sends the request to that node
+// through the status server.
+type cancelQueryNode struct {
+	p       *planner
+	queryID func() (string, error)
+}
+
+// CancelQuery cancels a running query.
+// Privileges: None; users other than root can only cancel their own queries.
+//   Notes: postgres uses pg_cancel_backend(pid) instead.
+func (p *planner) CancelQuery(ctx context.Context, n *parser.CancelQuery) (planNode, error) {
+	queryID, err := p.TypeAsString(n.ID, "CANCEL QUERY")
+	if err != nil {
+		return nil, err
+	}
+	return &cancelQueryNode{p: p, queryID: queryID}, nil
+}
+
+func (n *cancelQueryNode) Start(ctx context.Context) error {
+	queryIDString, err := n.queryID()
+	if err != nil {
+		return err
+	}
+	queryID, err := StringToClusterWideID(queryIDString)
+	if err != nil {
+		return errors.Wrapf(err, "invalid query ID '%s'", queryIDString)
+	}
+
+	request := &serverpb.CancelQueryRequest{
+		NodeID:   fmt.Sprintf("%d", queryID.GetNodeID()),
+		QueryID:  queryIDString,
+		Username: n.p.session.User,
+	}
+	response, err := n.p.session.execCfg.StatusServer.CancelQuery(ctx, request)
+	if err != nil {
+		return err
+	}
+	if !response.Canceled {
+		return fmt.Errorf("could not cancel query %s: %s", queryIDString, response.Error)
+	}
+	return nil
+}
+
+func (*cancelQueryNode) Next(context.Context) (bool, error) { return false, nil }
+func (*cancelQueryNode) Close(context.Context)              {}
+
+func (*cancelQueryNode) Values() parser.Datums      { return parser.Datums{} }
+func (*cancelQueryNode) DebugValues() debugValues   { return debugValues{} }
+func (*cancelQueryNode) MarkDebug(mode explainMode) {}
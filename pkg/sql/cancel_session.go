@@ -0,0 +1,96 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// cancelSessionNode is the planNode of CANCEL SESSION. Like CANCEL QUERY,
+// Start sends the request to the node of the session, whose ID is embedded in
+// the session ID, and then records the cancellation in the event log.
+type cancelSessionNode struct {
+	p         *planner
+	n         *parser.CancelSession
+	sessionID func() (string, error)
+}
+
+// CancelSession cancels a session, aborting its transaction and closing its
+// client connection.
+// Privileges: root user.
+//   Notes: postgres uses pg_terminate_backend(pid) instead.
+func (p *planner) CancelSession(ctx context.Context, n *parser.CancelSession) (planNode, error) {
+	if err := p.RequireSuperUser("cancel sessions"); err != nil {
+		return nil, err
+	}
+	sessionID, err := p.TypeAsString(n.ID, "CANCEL SESSION")
+	if err != nil {
+		return nil, err
+	}
+	return &cancelSessionNode{p: p, n: n, sessionID: sessionID}, nil
+}
+
+func (n *cancelSessionNode) Start(ctx context.Context) error {
+	sessionIDString, err := n.sessionID()
+	if err != nil {
+		return err
+	}
+	sessionID, err := StringToClusterWideID(sessionIDString)
+	if err != nil {
+		return errors.Wrapf(err, "invalid session ID '%s'", sessionIDString)
+	}
+	nodeID := sessionID.GetNodeID()
+
+	request := &serverpb.CancelSessionRequest{
+		NodeID:    fmt.Sprintf("%d", nodeID),
+		SessionID: sessionIDString,
+		Username:  n.p.session.User,
+	}
+	response, err := n.p.session.execCfg.StatusServer.CancelSession(ctx, request)
+	if err != nil {
+		return err
+	}
+	if !response.Canceled {
+		return fmt.Errorf("could not cancel session %s: %s", sessionIDString, response.Error)
+	}
+
+	// Record the cancellation in the event log, as part of the transaction of
+	// the CANCEL SESSION statement.
+	return MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogCancelSession,
+		int32(nodeID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			SessionID string
+			Statement string
+			User      string
+		}{sessionIDString, n.n.String(), n.p.session.User},
+	)
+}
+
+func (*cancelSessionNode) Next(context.Context) (bool, error) { return false, nil }
+func (*cancelSessionNode) Close(context.Context)              {}
+
+func (*cancelSessionNode) Values() parser.Datums      { return parser.Datums{} }
+func (*cancelSessionNode) DebugValues() debugValues   { return debugValues{} }
+func (*cancelSessionNode) MarkDebug(mode explainMode) {}
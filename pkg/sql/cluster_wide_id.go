@@ -0,0 +1,54 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"encoding/binary"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/uint128"
+)
+
+// ClusterWideID represents an identifier that is guaranteed to be unique
+// across a cluster. It is made of the HLC timestamp at which it was generated
+// and of the ID of the node which generated it: the high 64 bits hold the wall
+// time, and the low 64 bits hold the logical time and the node ID.
+type ClusterWideID struct {
+	uint128.Uint128
+}
+
+// GenerateClusterWideID generates a new ClusterWideID from the given
+// timestamp and node ID.
+func GenerateClusterWideID(timestamp hlc.Timestamp, nodeID roachpb.NodeID) ClusterWideID {
+	loInt := (uint64)(timestamp.Logical)
+	loInt = loInt<<32 | uint64(nodeID)
+	return ClusterWideID{Uint128: uint128.FromInts((uint64)(timestamp.WallTime), loInt)}
+}
+
+// StringToClusterWideID converts the string representation of a
+// ClusterWideID, as returned by String(), back to a ClusterWideID.
+func StringToClusterWideID(s string) (ClusterWideID, error) {
+	id, err := uint128.FromString(s)
+	if err != nil {
+		return ClusterWideID{}, err
+	}
+	return ClusterWideID{Uint128: id}, nil
+}
+
+// GetNodeID returns the ID of the node which generated the ClusterWideID.
+func (id ClusterWideID) GetNodeID() roachpb.NodeID {
+	return roachpb.NodeID(binary.BigEndian.Uint32(id.GetBytes()[12:]))
+}
@@ -0,0 +1,296 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// commentType is the kind of object a row of system.comments is attached to.
+type commentType int
+
+const (
+	databaseCommentType commentType = 0
+	tableCommentType    commentType = 1
+	columnCommentType   commentType = 2
+	indexCommentType    commentType = 3
+)
+
+// commentKey identifies the object a comment is attached to. subID is the
+// column or index ID for column and index comments, and 0 otherwise.
+type commentKey struct {
+	typ   commentType
+	objID sqlbase.ID
+	subID uint32
+}
+
+// commentNode is the planNode of all the COMMENT ON statements. The object
+// is resolved when the plan is built; Start only writes the comment.
+type commentNode struct {
+	p       *planner
+	key     commentKey
+	comment *string
+}
+
+// CommentOnDatabase sets or removes the comment of a database.
+// Privileges: CREATE on database.
+//   Notes: postgres requires the database owner.
+func (p *planner) CommentOnDatabase(
+	ctx context.Context, n *parser.CommentOnDatabase,
+) (planNode, error) {
+	if n.Name == "" {
+		return nil, errEmptyDatabaseName
+	}
+	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), string(n.Name))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	return &commentNode{
+		p: p, key: commentKey{typ: databaseCommentType, objID: dbDesc.ID}, comment: n.Comment,
+	}, nil
+}
+
+// CommentOnTable sets or removes the comment of a table.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the table owner.
+func (p *planner) CommentOnTable(ctx context.Context, n *parser.CommentOnTable) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	tableDesc, err := p.getCommentTableDesc(ctx, tn)
+	if err != nil {
+		return nil, err
+	}
+	return &commentNode{
+		p: p, key: commentKey{typ: tableCommentType, objID: tableDesc.ID}, comment: n.Comment,
+	}, nil
+}
+
+// CommentOnColumn sets or removes the comment of a column.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the table owner.
+func (p *planner) CommentOnColumn(
+	ctx context.Context, n *parser.CommentOnColumn,
+) (planNode, error) {
+	if n.ColumnItem.TableName.TableName == "" {
+		return nil, errors.Errorf("column name %q must be qualified with a table name", n.ColumnItem)
+	}
+	tn := n.ColumnItem.TableName
+	if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+		return nil, err
+	}
+	tableDesc, err := p.getCommentTableDesc(ctx, &tn)
+	if err != nil {
+		return nil, err
+	}
+	col, err := tableDesc.FindActiveColumnByName(n.ColumnItem.ColumnName)
+	if err != nil {
+		return nil, err
+	}
+	return &commentNode{
+		p: p, comment: n.Comment,
+		key: commentKey{typ: columnCommentType, objID: tableDesc.ID, subID: uint32(col.ID)},
+	}, nil
+}
+
+// CommentOnIndex sets or removes the comment of an index.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the index owner.
+func (p *planner) CommentOnIndex(ctx context.Context, n *parser.CommentOnIndex) (planNode, error) {
+	tn, err := p.expandIndexName(ctx, n.Index)
+	if err != nil {
+		return nil, err
+	}
+	tableDesc, err := p.getCommentTableDesc(ctx, tn)
+	if err != nil {
+		return nil, err
+	}
+	var indexID sqlbase.IndexID
+	if n.Index.Index.Normalize() == parser.ReNormalizeName(tableDesc.PrimaryIndex.Name) {
+		indexID = tableDesc.PrimaryIndex.ID
+	} else {
+		idx, dropped, err := tableDesc.FindIndexByName(n.Index.Index)
+		if err != nil {
+			return nil, err
+		}
+		if dropped {
+			return nil, fmt.Errorf("index %q in the middle of being dropped", n.Index.Index)
+		}
+		indexID = idx.ID
+	}
+	return &commentNode{
+		p: p, comment: n.Comment,
+		key: commentKey{typ: indexCommentType, objID: tableDesc.ID, subID: uint32(indexID)},
+	}, nil
+}
+
+// getCommentTableDesc returns the descriptor of the table on which a comment
+// is being set, after checking that the user may do so.
+func (p *planner) getCommentTableDesc(
+	ctx context.Context, tn *parser.TableName,
+) (*sqlbase.TableDescriptor, error) {
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, true /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	if isVirtualDescriptor(tableDesc) {
+		return nil, errors.Errorf("cannot comment on virtual table %s", tn)
+	}
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	return tableDesc, nil
+}
+
+func (n *commentNode) Start(ctx context.Context) error {
+	if n.comment == nil {
+		return n.p.deleteComment(ctx, n.key)
+	}
+	ie := InternalExecutor{LeaseManager: n.p.LeaseMgr()}
+	_, err := ie.ExecuteStatementInTransaction(ctx, "upsert-comment", n.p.txn,
+		`UPSERT INTO system.comments VALUES ($1, $2, $3, $4)`,
+		int(n.key.typ), int(n.key.objID), int(n.key.subID), *n.comment)
+	return err
+}
+
+func (*commentNode) Next(context.Context) (bool, error) { return false, nil }
+func (*commentNode) Close(context.Context)              {}
+
+func (*commentNode) Values() parser.Datums      { return parser.Datums{} }
+func (*commentNode) DebugValues() debugValues   { return debugValues{} }
+func (*commentNode) MarkDebug(mode explainMode) {}
+
+// deleteComments removes the comments attached to the database or table with
+// the given ID, including those of the table's columns and indexes.
+// Descriptor IDs are unique across databases and tables, so the ID alone
+// identifies the comments to remove.
+func (p *planner) deleteComments(ctx context.Context, id sqlbase.ID) error {
+	ie := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	_, err := ie.ExecuteStatementInTransaction(ctx, "delete-comments", p.txn,
+		`DELETE FROM system.comments WHERE object_id = $1`, int(id))
+	return err
+}
+
+// deleteComment removes the comment attached to a single object.
+func (p *planner) deleteComment(ctx context.Context, key commentKey) error {
+	ie := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	_, err := ie.ExecuteStatementInTransaction(ctx, "delete-comment", p.txn,
+		`DELETE FROM system.comments WHERE type = $1 AND object_id = $2 AND sub_id = $3`,
+		int(key.typ), int(key.objID), int(key.subID))
+	return err
+}
+
+// getComments returns the comments attached to the table with the given ID
+// and its columns and indexes, or all the comments if tableID is 0.
+// The comments are read as root: which of them the user may see is decided
+// by the caller, based on the privileges on the objects they describe.
+func (p *planner) getComments(
+	ctx context.Context, tableID sqlbase.ID,
+) (map[commentKey]string, error) {
+	var rows []parser.Datums
+	var err error
+	if tableID == 0 {
+		rows, err = p.queryRowsAsRoot(ctx,
+			`SELECT type, object_id, sub_id, comment FROM system.comments`)
+	} else {
+		rows, err = p.queryRowsAsRoot(ctx,
+			`SELECT type, object_id, sub_id, comment FROM system.comments WHERE object_id = $1`,
+			int(tableID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	comments := make(map[commentKey]string, len(rows))
+	for _, row := range rows {
+		key := commentKey{
+			typ:   commentType(parser.MustBeDInt(row[0])),
+			objID: sqlbase.ID(parser.MustBeDInt(row[1])),
+			subID: uint32(parser.MustBeDInt(row[2])),
+		}
+		comments[key] = string(parser.MustBeDString(row[3]))
+	}
+	return comments, nil
+}
+
+// commentDatum returns the comment with the given key, or NULL if there is
+// none.
+func commentDatum(comments map[commentKey]string, key commentKey) parser.Datum {
+	if c, ok := comments[key]; ok {
+		return parser.NewDString(c)
+	}
+	return parser.DNull
+}
+
+// showCreateTableComments returns the COMMENT ON statements which recreate
+// the comments of the table and of its columns and indexes, each preceded by
+// a semicolon so that they can follow the CREATE TABLE statement.
+func showCreateTableComments(
+	tn parser.Name, desc *sqlbase.TableDescriptor, comments map[commentKey]string,
+) string {
+	var buf bytes.Buffer
+	write := func(stmt parser.Statement) {
+		buf.WriteString(";\n")
+		parser.FormatNode(&buf, parser.FmtSimple, stmt)
+	}
+	if c, ok := comments[commentKey{typ: tableCommentType, objID: desc.ID}]; ok {
+		c := c
+		write(&parser.CommentOnTable{
+			Table:   parser.NormalizableTableName{TableNameReference: parser.UnresolvedName{tn}},
+			Comment: &c,
+		})
+	}
+	for _, col := range desc.VisibleColumns() {
+		if c, ok := comments[commentKey{
+			typ: columnCommentType, objID: desc.ID, subID: uint32(col.ID),
+		}]; ok {
+			c := c
+			write(&parser.CommentOnColumn{
+				ColumnItem: &parser.ColumnItem{
+					TableName:  parser.TableName{TableName: tn},
+					ColumnName: parser.Name(col.Name),
+				},
+				Comment: &c,
+			})
+		}
+	}
+	indexes := append([]sqlbase.IndexDescriptor{desc.PrimaryIndex}, desc.Indexes...)
+	for _, idx := range indexes {
+		if c, ok := comments[commentKey{
+			typ: indexCommentType, objID: desc.ID, subID: uint32(idx.ID),
+		}]; ok {
+			c := c
+			write(&parser.CommentOnIndex{
+				Index: &parser.TableNameWithIndex{
+					Table: parser.NormalizableTableName{TableNameReference: parser.UnresolvedName{tn}},
+					Index: parser.Name(idx.Name),
+				},
+				Comment: &c,
+			})
+		}
+	}
+	return buf.String()
+}
@@ -19,13 +19,14 @@ package sql
 import (
 	"bytes"
 	"fmt"
-	"io"
+	"strings"
 	"time"
 	"unsafe"
 
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 )
@@ -42,15 +43,28 @@ import (
 // to increase performance by batching inserts), they are inserted with an
 // insertNode. A CopyDone message will flush and insert all remaining data.
 //
+// The data is in text format, CSV or binary (see CopyOptions). Rows in
+// binary format are decoded by the client protocol implementation and
+// handed over already parsed (see Executor.CopyRows).
+//
 // See: https://www.postgresql.org/docs/9.5/static/sql-copy.html
 type copyNode struct {
 	p             *planner
 	table         parser.TableExpr
 	columns       parser.UnresolvedNames
 	resultColumns sqlbase.ResultColumns
+	opts          CopyOptions
 	buf           bytes.Buffer
 	rows          []*parser.Tuple
 	rowsMemAcc    WrappableMemoryAccount
+	// decoded holds the rows passed to Executor.CopyRows until they are
+	// added to rows.
+	decoded []parser.Datums
+	// skipHeader is set until the header line of CSV data is skipped.
+	skipHeader bool
+	// done is set once the end-of-data marker is read; any data after it
+	// is ignored.
+	done bool
 }
 
 func (*copyNode) Values() parser.Datums              { return nil }
@@ -70,12 +84,139 @@ func (*copyNode) DebugValues() debugValues {
 	}
 }
 
+// CopyFormat is the data format of a COPY statement.
+type CopyFormat int
+
+const (
+	// CopyFormatText is the Postgres text format: one line per row, with
+	// backslash escapes.
+	CopyFormatText CopyFormat = iota
+	// CopyFormatCSV is the comma-separated values format.
+	CopyFormatCSV
+	// CopyFormatBinary is the Postgres binary format. It is encoded and
+	// decoded by the client protocol implementation.
+	CopyFormatBinary
+)
+
+// CopyOptions are the options of a COPY statement, resolved from its WITH
+// clause.
+type CopyOptions struct {
+	Format CopyFormat
+	// Delimiter separates the fields of a row.
+	Delimiter byte
+	// Null is the string representing a NULL value. In CSV, a quoted field
+	// is never NULL.
+	Null string
+	// Quote is the quoting character of CSV.
+	Quote byte
+	// Header indicates that the first line of CSV data holds the names of
+	// the columns.
+	Header bool
+}
+
+var copyOptionNames = map[string]struct{}{
+	"format":    {},
+	"header":    {},
+	"delimiter": {},
+	"null":      {},
+	"quote":     {},
+}
+
+// makeCopyOptions resolves the options of a COPY statement, using the
+// defaults of Postgres for the options that are not specified.
+func makeCopyOptions(opts parser.KVOptions) (CopyOptions, error) {
+	seen := make(map[string]struct{}, len(opts))
+	for _, o := range opts {
+		if _, ok := copyOptionNames[o.Key]; !ok {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"option %q not recognized", o.Key)
+		}
+		if _, ok := seen[o.Key]; ok {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"conflicting or redundant options")
+		}
+		seen[o.Key] = struct{}{}
+	}
+
+	var res CopyOptions
+	switch format, _ := opts.Get("format"); format {
+	case "", "text":
+		res = CopyOptions{Format: CopyFormatText, Delimiter: '\t', Null: `\N`}
+	case "csv":
+		res = CopyOptions{Format: CopyFormatCSV, Delimiter: ',', Quote: '"'}
+	case "binary":
+		res = CopyOptions{Format: CopyFormatBinary}
+	default:
+		return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"COPY format %q not recognized", format)
+	}
+
+	if v, ok := opts.Get("delimiter"); ok {
+		if res.Format == CopyFormatBinary {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"cannot specify DELIMITER in BINARY mode")
+		}
+		if len(v) != 1 {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"COPY delimiter must be a single one-byte character")
+		}
+		if v[0] == '\r' || v[0] == lineDelim {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+				"COPY delimiter cannot be newline or carriage return")
+		}
+		res.Delimiter = v[0]
+	}
+	if v, ok := opts.Get("null"); ok {
+		if res.Format == CopyFormatBinary {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"cannot specify NULL in BINARY mode")
+		}
+		res.Null = v
+	}
+	if v, ok := opts.Get("quote"); ok {
+		if res.Format != CopyFormatCSV {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"COPY quote available only in CSV mode")
+		}
+		if len(v) != 1 {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"COPY quote must be a single one-byte character")
+		}
+		res.Quote = v[0]
+	}
+	if v, ok := opts.Get("header"); ok {
+		if res.Format != CopyFormatCSV {
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+				"COPY HEADER available only in CSV mode")
+		}
+		switch strings.ToLower(v) {
+		case "", "true", "on", "1":
+			res.Header = true
+		case "false", "off", "0":
+		default:
+			return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeSyntaxError,
+				"header requires a Boolean value")
+		}
+	}
+	if res.Format == CopyFormatCSV && res.Delimiter == res.Quote {
+		return CopyOptions{}, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"COPY delimiter and quote must be different")
+	}
+	return res, nil
+}
+
 // CopyFrom begins a COPY.
 // Privileges: INSERT on table.
 func (p *planner) CopyFrom(ctx context.Context, n *parser.CopyFrom) (planNode, error) {
+	opts, err := makeCopyOptions(n.Options)
+	if err != nil {
+		return nil, err
+	}
 	cn := &copyNode{
-		table:   &n.Table,
-		columns: n.Columns,
+		table:      &n.Table,
+		columns:    n.Columns,
+		opts:       opts,
+		skipHeader: opts.Header,
 	}
 
 	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
@@ -110,6 +251,84 @@ func (n *copyNode) Start(context.Context) error {
 	return nil
 }
 
+// CopyOutWriter is implemented by the client protocol to stream the rows
+// of COPY TO statements to the client as they are computed. Rows are only
+// streamed for the first statement of a request, whose results don't need
+// to wait for the results of other statements; the rows of other COPY TO
+// statements are returned in their Result.
+type CopyOutWriter interface {
+	// BeginCopyOut starts the COPY data of a statement.
+	BeginCopyOut(columns sqlbase.ResultColumns, opts CopyOptions) error
+	// SendCopyRow sends a row of COPY data.
+	SendCopyRow(row parser.Datums, opts CopyOptions) error
+}
+
+// execCopyOut executes a COPY TO statement, streaming its rows to the
+// client through the session's CopyOutWriter. The query runs locally, like
+// the query of a cursor.
+func (e *Executor) execCopyOut(
+	session *Session,
+	stmt Statement,
+	n *parser.CopyTo,
+	opts CopyOptions,
+	pinfo *parser.PlaceholderInfo,
+	avoidCachedDescriptors bool,
+) (Result, error) {
+	ctx := session.Ctx()
+	c, err := e.declareCursor(session, stmt, copyToSelect(n), pinfo, avoidCachedDescriptors)
+	if err != nil {
+		return Result{}, err
+	}
+	defer c.close(ctx)
+
+	// Once data has been sent to the client, the transaction can't be
+	// retried automatically.
+	session.TxnState.autoRetry = false
+	if err := session.CopyOutWriter.BeginCopyOut(c.columns, opts); err != nil {
+		return Result{}, err
+	}
+	result := Result{
+		PGTag:       n.StatementTag(),
+		Type:        n.StatementType(),
+		Columns:     c.columns,
+		CopyOptions: opts,
+	}
+	for {
+		row, ok, err := c.next(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			break
+		}
+		if err := session.CopyOutWriter.SendCopyRow(row, opts); err != nil {
+			return Result{}, err
+		}
+		result.RowsAffected++
+	}
+	return result, nil
+}
+
+// copyToSelect returns the query whose results a COPY TO statement sends.
+func copyToSelect(n *parser.CopyTo) *parser.Select {
+	if n.Select != nil {
+		return n.Select
+	}
+	exprs := parser.SelectExprs{{Expr: parser.UnqualifiedStar{}}}
+	if len(n.Columns) > 0 {
+		exprs = make(parser.SelectExprs, len(n.Columns))
+		for i, c := range n.Columns {
+			exprs[i].Expr = c
+		}
+	}
+	return &parser.Select{
+		Select: &parser.SelectClause{
+			Exprs: exprs,
+			From:  &parser.From{Tables: parser.TableExprs{&n.Table}},
+		},
+	}
+}
+
 // CopyDataBlock represents a data block of a COPY FROM statement.
 type CopyDataBlock struct {
 	Done bool
@@ -122,12 +341,7 @@ const (
 	copyMsgData
 	copyMsgDone
 
-	nullString = `\N`
-	lineDelim  = '\n'
-)
-
-var (
-	fieldDelim = []byte{'\t'}
+	lineDelim = '\n'
 )
 
 // ProcessCopyData appends data to the planner's internal COPY state as
@@ -139,119 +353,168 @@ func (s *Session) ProcessCopyData(
 	ctx context.Context, data string, msg copyMsg,
 ) (StatementList, error) {
 	cf := s.copyFrom
-	buf := cf.buf
 
 	switch msg {
 	case copyMsgData:
-		// ignore
-	case copyMsgDone:
-		var err error
-		// If there's a line in the buffer without \n at EOL, add it here.
-		if buf.Len() > 0 {
-			err = cf.addRow(ctx, buf.Bytes())
+		for _, row := range cf.decoded {
+			if err := cf.addRow(ctx, row); err != nil {
+				return nil, err
+			}
 		}
+		cf.decoded = nil
+	case copyMsgDone:
+		// If there's a row in the buffer without \n at EOL, add it here.
+		err := cf.processBuffer(ctx, true /* atEOF */)
 		return StatementList{{AST: CopyDataBlock{Done: true}}}, err
 	default:
 		return nil, fmt.Errorf("expected copy command")
 	}
 
-	buf.WriteString(data)
-	for buf.Len() > 0 {
-		line, err := buf.ReadBytes(lineDelim)
-		if err != nil {
-			if err != io.EOF {
-				return nil, err
-			}
-		} else {
-			// Remove lineDelim from end.
-			line = line[:len(line)-1]
-			// Remove a single '\r' at EOL, if present.
-			if len(line) > 0 && line[len(line)-1] == '\r' {
-				line = line[:len(line)-1]
-			}
-		}
-		if buf.Len() == 0 && bytes.Equal(line, []byte(`\.`)) {
-			break
-		}
-		if err := cf.addRow(ctx, line); err != nil {
-			return nil, err
-		}
+	if cf.opts.Format == CopyFormatBinary && len(data) > 0 {
+		return nil, fmt.Errorf("unexpected binary COPY data")
+	}
+	cf.buf.WriteString(data)
+	if err := cf.processBuffer(ctx, false /* atEOF */); err != nil {
+		return nil, err
 	}
 	return StatementList{{AST: CopyDataBlock{}}}, nil
 }
 
-func (n *copyNode) addRow(ctx context.Context, line []byte) error {
-	var err error
-	parts := bytes.Split(line, fieldDelim)
-	if len(parts) != len(n.resultColumns) {
-		return fmt.Errorf("expected %d values, got %d", len(n.resultColumns), len(parts))
-	}
-	exprs := make(parser.Exprs, len(parts))
-	acc := n.rowsMemAcc.Wsession(n.p.session)
-	for i, part := range parts {
-		s := string(part)
-		if s == nullString {
-			exprs[i] = parser.DNull
-			continue
-		}
-		var d parser.Datum
-		switch t := n.resultColumns[i].Typ; t {
-		case parser.TypeBool:
-			d, err = parser.ParseDBool(s)
-		case parser.TypeBytes:
-			s, err = decodeCopy(s)
-			d = parser.NewDBytes(parser.DBytes(s))
-		case parser.TypeDate:
-			s, err = decodeCopy(s)
-			if err != nil {
-				break
+// processBuffer adds the complete rows held in the buffer. If atEOF is
+// set, the data remaining in the buffer is the last row.
+func (n *copyNode) processBuffer(ctx context.Context, atEOF bool) error {
+	for n.buf.Len() > 0 && !n.done {
+		switch n.opts.Format {
+		case CopyFormatText:
+			data := n.buf.Bytes()
+			var line []byte
+			if i := bytes.IndexByte(data, lineDelim); i >= 0 {
+				// Remove lineDelim from end.
+				line = n.buf.Next(i + 1)[:i]
+				// Remove a single '\r' at EOL, if present.
+				if len(line) > 0 && line[len(line)-1] == '\r' {
+					line = line[:len(line)-1]
+				}
+			} else if atEOF {
+				line = n.buf.Next(len(data))
+			} else {
+				return nil
 			}
-			d, err = parser.ParseDDate(s, n.p.session.Location)
-		case parser.TypeDecimal:
-			d, err = parser.ParseDDecimal(s)
-		case parser.TypeFloat:
-			d, err = parser.ParseDFloat(s)
-		case parser.TypeInt:
-			d, err = parser.ParseDInt(s)
-		case parser.TypeInterval:
-			s, err = decodeCopy(s)
-			if err != nil {
+			if bytes.Equal(line, []byte(`\.`)) {
+				n.done = true
 				break
 			}
-			d, err = parser.ParseDInterval(s)
-		case parser.TypeString:
-			s, err = decodeCopy(s)
-			d = parser.NewDString(s)
-		case parser.TypeTimestamp:
-			s, err = decodeCopy(s)
-			if err != nil {
-				break
+			if err := n.addTextRow(ctx, line); err != nil {
+				return err
 			}
-			d, err = parser.ParseDTimestamp(s, time.Microsecond)
-		case parser.TypeTimestampTZ:
-			s, err = decodeCopy(s)
-			if err != nil {
-				break
+
+		case CopyFormatCSV:
+			fields, size, ok, err := readCSVRecord(n.buf.Bytes(), n.opts, atEOF)
+			if err != nil || !ok {
+				return err
 			}
-			d, err = parser.ParseDTimestampTZ(s, n.p.session.Location, time.Microsecond)
-		case parser.TypeUUID:
-			s, err = decodeCopy(s)
-			if err != nil {
+			n.buf.Next(size)
+			if len(fields) == 1 && !fields[0].quoted && fields[0].val == `\.` {
+				n.done = true
 				break
 			}
-			d, err = parser.ParseDUuidFromString(s)
+			if n.skipHeader {
+				n.skipHeader = false
+				continue
+			}
+			if err := n.addCSVRow(ctx, fields); err != nil {
+				return err
+			}
+
 		default:
-			return fmt.Errorf("unknown type %s", t)
+			return fmt.Errorf("unexpected COPY format %d", n.opts.Format)
 		}
+	}
+	return nil
+}
+
+func (n *copyNode) addTextRow(ctx context.Context, line []byte) error {
+	parts := bytes.Split(line, []byte{n.opts.Delimiter})
+	if len(parts) != len(n.resultColumns) {
+		return fmt.Errorf("expected %d values, got %d", len(n.resultColumns), len(parts))
+	}
+	row := make(parser.Datums, len(parts))
+	for i, part := range parts {
+		s := string(part)
+		if s == n.opts.Null {
+			row[i] = parser.DNull
+			continue
+		}
+		s, err := decodeCopy(s)
 		if err != nil {
 			return err
 		}
+		if row[i], err = n.parseField(s, n.resultColumns[i].Typ); err != nil {
+			return err
+		}
+	}
+	return n.addRow(ctx, row)
+}
 
-		sz := d.Size()
-		if err := acc.Grow(ctx, int64(sz)); err != nil {
+func (n *copyNode) addCSVRow(ctx context.Context, fields []csvField) error {
+	if len(fields) != len(n.resultColumns) {
+		return fmt.Errorf("expected %d values, got %d", len(n.resultColumns), len(fields))
+	}
+	row := make(parser.Datums, len(fields))
+	for i, f := range fields {
+		if !f.quoted && f.val == n.opts.Null {
+			row[i] = parser.DNull
+			continue
+		}
+		var err error
+		if row[i], err = n.parseField(f.val, n.resultColumns[i].Typ); err != nil {
 			return err
 		}
+	}
+	return n.addRow(ctx, row)
+}
 
+// parseField parses the unescaped value of a field.
+func (n *copyNode) parseField(s string, t parser.Type) (parser.Datum, error) {
+	switch t {
+	case parser.TypeBool:
+		return parser.ParseDBool(s)
+	case parser.TypeBytes:
+		return parser.NewDBytes(parser.DBytes(s)), nil
+	case parser.TypeDate:
+		return parser.ParseDDate(s, n.p.session.Location)
+	case parser.TypeDecimal:
+		return parser.ParseDDecimal(s)
+	case parser.TypeFloat:
+		return parser.ParseDFloat(s)
+	case parser.TypeInt:
+		return parser.ParseDInt(s)
+	case parser.TypeInterval:
+		return parser.ParseDInterval(s)
+	case parser.TypeString:
+		return parser.NewDString(s), nil
+	case parser.TypeTimestamp:
+		return parser.ParseDTimestamp(s, time.Microsecond)
+	case parser.TypeTimestampTZ:
+		return parser.ParseDTimestampTZ(s, n.p.session.Location, time.Microsecond)
+	case parser.TypeUUID:
+		return parser.ParseDUuidFromString(s)
+	default:
+		return nil, fmt.Errorf("unknown type %s", t)
+	}
+}
+
+// addRow buffers a row to be inserted.
+func (n *copyNode) addRow(ctx context.Context, row parser.Datums) error {
+	if len(row) != len(n.resultColumns) {
+		return fmt.Errorf("expected %d values, got %d", len(n.resultColumns), len(row))
+	}
+	acc := n.rowsMemAcc.Wsession(n.p.session)
+	exprs := make(parser.Exprs, len(row))
+	for i, d := range row {
+		if err := acc.Grow(ctx, int64(d.Size())); err != nil {
+			return err
+		}
 		exprs[i] = d
 	}
 	tuple := &parser.Tuple{Exprs: exprs}
@@ -263,6 +526,79 @@ func (n *copyNode) addRow(ctx context.Context, line []byte) error {
 	return nil
 }
 
+// csvField is a field of a CSV record. Quoted fields are never NULL.
+type csvField struct {
+	val    string
+	quoted bool
+}
+
+// readCSVRecord reads a CSV record from the start of data, and returns its
+// fields and the number of bytes it spans, line terminator included. Quoted
+// fields may span several lines; a quote character inside a quoted field
+// is escaped by doubling it. ok is false if data doesn't hold a complete
+// record, unless atEOF is set in which case the remaining data is the last
+// record.
+func readCSVRecord(
+	data []byte, opts CopyOptions, atEOF bool,
+) (fields []csvField, size int, ok bool, err error) {
+	var field bytes.Buffer
+	var quoted, inQuotes bool
+	endField := func() {
+		fields = append(fields, csvField{val: field.String(), quoted: quoted})
+		field.Reset()
+		quoted = false
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inQuotes {
+			if c != opts.Quote {
+				field.WriteByte(c)
+				continue
+			}
+			if i+1 == len(data) && !atEOF {
+				// The quote may be the first half of an escaped quote.
+				return nil, 0, false, nil
+			}
+			if i+1 < len(data) && data[i+1] == opts.Quote {
+				field.WriteByte(c)
+				i++
+				continue
+			}
+			inQuotes = false
+			continue
+		}
+		switch c {
+		case opts.Quote:
+			inQuotes, quoted = true, true
+		case opts.Delimiter:
+			endField()
+		case lineDelim:
+			endField()
+			return fields, i + 1, true, nil
+		case '\r':
+			if i+1 == len(data) && !atEOF {
+				return nil, 0, false, nil
+			}
+			endField()
+			if i+1 < len(data) && data[i+1] == lineDelim {
+				i++
+			}
+			return fields, i + 1, true, nil
+		default:
+			field.WriteByte(c)
+		}
+	}
+	if !atEOF {
+		return nil, 0, false, nil
+	}
+	if inQuotes {
+		return nil, 0, false, pgerror.NewErrorf(pgerror.CodeBadCopyFileFormatError,
+			"unterminated CSV quoted field")
+	}
+	endField()
+	return fields, len(data), true, nil
+}
+
 // decodeCopy unescapes a single COPY field.
 //
 // See: https://www.postgresql.org/docs/9.5/static/sql-copy.html#AEN74432
@@ -17,8 +17,11 @@
 package sql
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -90,3 +93,178 @@ func TestDecodeCopy(t *testing.T) {
 		}
 	}
 }
+
+func TestReadCSVRecord(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	csv := CopyOptions{Format: CopyFormatCSV, Delimiter: ',', Quote: '"'}
+	semicolon := CopyOptions{Format: CopyFormatCSV, Delimiter: ';', Quote: '\''}
+
+	tests := []struct {
+		in     string
+		opts   CopyOptions
+		atEOF  bool
+		expect []csvField
+		size   int
+		ok     bool
+		err    bool
+	}{
+		{
+			in:     "a,b,c\nd",
+			opts:   csv,
+			expect: []csvField{{val: "a"}, {val: "b"}, {val: "c"}},
+			size:   6,
+			ok:     true,
+		},
+		{
+			in:     "a,,\"\"\r\n",
+			opts:   csv,
+			expect: []csvField{{val: "a"}, {val: ""}, {val: "", quoted: true}},
+			size:   7,
+			ok:     true,
+		},
+		{
+			in:     "\"a,b\",\"multi\nline \"\"quoted\"\"\"\n",
+			opts:   csv,
+			expect: []csvField{{val: "a,b", quoted: true}, {val: "multi\nline \"quoted\"", quoted: true}},
+			size:   30,
+			ok:     true,
+		},
+		{
+			in:     "a;'b;c'\n",
+			opts:   semicolon,
+			expect: []csvField{{val: "a"}, {val: "b;c", quoted: true}},
+			size:   8,
+			ok:     true,
+		},
+		{
+			in:     "a,b",
+			opts:   csv,
+			atEOF:  true,
+			expect: []csvField{{val: "a"}, {val: "b"}},
+			size:   3,
+			ok:     true,
+		},
+
+		// Incomplete records.
+
+		{
+			in:   "a,b",
+			opts: csv,
+		},
+		{
+			in:   "a,\"b\nc",
+			opts: csv,
+		},
+		{
+			// The quote could be the first half of an escaped quote.
+			in:   "a,\"b\"",
+			opts: csv,
+		},
+		{
+			in:   "a,b\r",
+			opts: csv,
+		},
+
+		// Error cases.
+
+		{
+			in:    "a,\"b",
+			opts:  csv,
+			atEOF: true,
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		fields, size, ok, err := readCSVRecord([]byte(test.in), test.opts, test.atEOF)
+		if gotErr := err != nil; gotErr != test.err {
+			if gotErr {
+				t.Errorf("%q: unexpected error: %v", test.in, err)
+				continue
+			}
+			t.Errorf("%q: expected error", test.in)
+			continue
+		}
+		if ok != test.ok || size != test.size {
+			t.Errorf("%q: got ok=%t size=%d, expected ok=%t size=%d",
+				test.in, ok, size, test.ok, test.size)
+			continue
+		}
+		if !reflect.DeepEqual(fields, test.expect) {
+			t.Errorf("%q: got %+v, expected %+v", test.in, fields, test.expect)
+		}
+	}
+}
+
+func TestMakeCopyOptions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tests := []struct {
+		opts   parser.KVOptions
+		expect CopyOptions
+		err    string
+	}{
+		{
+			expect: CopyOptions{Format: CopyFormatText, Delimiter: '\t', Null: `\N`},
+		},
+		{
+			opts:   parser.KVOptions{{Key: "format", Value: "csv"}, {Key: "header"}},
+			expect: CopyOptions{Format: CopyFormatCSV, Delimiter: ',', Quote: '"', Header: true},
+		},
+		{
+			opts: parser.KVOptions{
+				{Key: "format", Value: "csv"}, {Key: "delimiter", Value: "|"},
+				{Key: "null", Value: "NULL"}, {Key: "quote", Value: "'"},
+				{Key: "header", Value: "off"},
+			},
+			expect: CopyOptions{Format: CopyFormatCSV, Delimiter: '|', Null: "NULL", Quote: '\''},
+		},
+		{
+			opts:   parser.KVOptions{{Key: "format", Value: "binary"}},
+			expect: CopyOptions{Format: CopyFormatBinary},
+		},
+
+		// Error cases.
+
+		{
+			opts: parser.KVOptions{{Key: "foo"}},
+			err:  `option "foo" not recognized`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "format", Value: "xml"}},
+			err:  `COPY format "xml" not recognized`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "null", Value: "a"}, {Key: "null", Value: "b"}},
+			err:  `conflicting or redundant options`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "header"}},
+			err:  `COPY HEADER available only in CSV mode`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "format", Value: "binary"}, {Key: "delimiter", Value: ","}},
+			err:  `cannot specify DELIMITER in BINARY mode`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "format", Value: "csv"}, {Key: "quote", Value: ","}},
+			err:  `COPY delimiter and quote must be different`,
+		},
+		{
+			opts: parser.KVOptions{{Key: "delimiter", Value: "ab"}},
+			err:  `COPY delimiter must be a single one-byte character`,
+		},
+	}
+
+	for _, test := range tests {
+		opts, err := makeCopyOptions(test.opts)
+		if !testutils.IsError(err, test.err) {
+			t.Errorf("%v: expected error %q, got %v", test.opts, test.err, err)
+			continue
+		}
+		if err == nil && opts != test.expect {
+			t.Errorf("%v: got %+v, expected %+v", test.opts, opts, test.expect)
+		}
+	}
+}
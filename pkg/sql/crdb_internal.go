@@ -19,6 +19,7 @@ package sql
 import (
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -39,6 +40,8 @@ var crdbInternal = virtualSchema{
 		crdbInternalLeasesTable,
 		crdbInternalSchemaChangesTable,
 		crdbInternalStmtStatsTable,
+		crdbInternalIndexSelectionCacheTable,
+		crdbInternalIndexSelectionCacheStatsTable,
 		crdbInternalJobsTable,
 		crdbInternalSessionTraceTable,
 	},
@@ -110,6 +113,10 @@ CREATE TABLE crdb_internal.tables (
 				dbNames[db.ID] = db.Name
 			}
 		}
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
 		// Note: we do not use forEachTableDesc() here because we want to
 		// include added and dropped descriptors.
 		for _, desc := range descs {
@@ -129,7 +136,7 @@ CREATE TABLE crdb_internal.tables (
 					time.Unix(0, table.Lease.ExpirationTime), time.Nanosecond,
 				)
 			}
-			create, err := p.showCreateTable(ctx, parser.Name(table.Name), table)
+			create, err := p.showCreateTable(ctx, parser.Name(table.Name), table, comments)
 			if err != nil {
 				return err
 			}
@@ -456,6 +463,77 @@ CREATE TABLE crdb_internal.node_statement_statistics (
 	},
 }
 
+var crdbInternalIndexSelectionCacheTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.node_index_selection_cache (
+  node_id   INT NOT NULL,
+  statement STRING NOT NULL,
+  database  STRING NOT NULL,
+  user_name STRING NOT NULL,
+  indexes   STRING NOT NULL,
+  hits      INT NOT NULL
+);
+`,
+	populate: func(_ context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		if p.session.User != security.RootUser {
+			return errors.New("only root can access the index selection cache")
+		}
+
+		cache := p.session.indexSelectionCache
+		if cache == nil {
+			return errors.New("cannot access the index selection cache from this context")
+		}
+
+		nodeID := parser.NewDInt(parser.DInt(int64(p.LeaseMgr().nodeID.Get())))
+		for _, e := range cache.entries() {
+			if err := addRow(
+				nodeID,
+				parser.NewDString(e.key.sql),
+				parser.NewDString(e.key.database),
+				parser.NewDString(e.key.user),
+				parser.NewDString(strings.Join(e.indexes, ", ")),
+				parser.NewDInt(parser.DInt(e.hits)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var crdbInternalIndexSelectionCacheStatsTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.node_index_selection_cache_statistics (
+  node_id       INT NOT NULL,
+  entries       INT NOT NULL,
+  hits          INT NOT NULL,
+  misses        INT NOT NULL,
+  invalidations INT NOT NULL,
+  hit_rate      FLOAT NOT NULL
+);
+`,
+	populate: func(_ context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		cache := p.session.indexSelectionCache
+		if cache == nil {
+			return errors.New("cannot access the index selection cache from this context")
+		}
+
+		s := cache.stats()
+		var hitRate float64
+		if lookups := s.hits + s.misses; lookups > 0 {
+			hitRate = float64(s.hits) / float64(lookups)
+		}
+		return addRow(
+			parser.NewDInt(parser.DInt(int64(p.LeaseMgr().nodeID.Get()))),
+			parser.NewDInt(parser.DInt(s.entries)),
+			parser.NewDInt(parser.DInt(s.hits)),
+			parser.NewDInt(parser.DInt(s.misses)),
+			parser.NewDInt(parser.DInt(s.invalidations)),
+			parser.NewDFloat(parser.DFloat(hitRate)),
+		)
+	},
+}
+
 // crdbInternalSessionTraceTable exposes the latest trace collected on this
 // session (via SET TRACE={ON/OFF})
 var crdbInternalSessionTraceTable = virtualSchemaTable{
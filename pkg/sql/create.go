@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -123,6 +124,230 @@ func (*createDatabaseNode) Values() parser.Datums      { return parser.Datums{}
 func (*createDatabaseNode) DebugValues() debugValues   { return debugValues{} }
 func (*createDatabaseNode) MarkDebug(mode explainMode) {}
 
+type createTypeNode struct {
+	p      *planner
+	n      *parser.CreateType
+	tn     *parser.TableName
+	dbDesc *sqlbase.DatabaseDescriptor
+}
+
+// CreateType creates a user-defined type.
+// Privileges: CREATE on database.
+//   Notes: postgres requires CREATE on the schema.
+func (p *planner) CreateType(ctx context.Context, n *parser.CreateType) (planNode, error) {
+	tn, err := n.Name.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), tn.Database())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &createTypeNode{p: p, n: n, tn: tn, dbDesc: dbDesc}, nil
+}
+
+func (n *createTypeNode) Start(ctx context.Context) error {
+	members, err := sqlbase.MakeEnumMembers(n.n.EnumLabels)
+	if err != nil {
+		return err
+	}
+	desc := sqlbase.TypeDescriptor{
+		Name:        n.tn.Table(),
+		ParentID:    n.dbDesc.ID,
+		EnumMembers: members,
+		Privileges:  n.dbDesc.GetPrivileges(),
+	}
+
+	key := tableKey{parentID: n.dbDesc.ID, name: n.tn.Table()}
+	if _, err := n.p.createDescriptor(ctx, key, &desc, false /* ifNotExists */); err != nil {
+		return err
+	}
+	if err := desc.Validate(); err != nil {
+		return err
+	}
+
+	// Log Create Type event. This is an auditable log event and is
+	// recorded in the same transaction as the type descriptor update.
+	return MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogCreateType,
+		int32(desc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TypeName  string
+			Statement string
+			User      string
+		}{n.tn.String(), n.n.String(), n.p.session.User},
+	)
+}
+
+func (*createTypeNode) Next(context.Context) (bool, error) { return false, nil }
+func (*createTypeNode) Close(context.Context)              {}
+
+func (*createTypeNode) Values() parser.Datums      { return parser.Datums{} }
+func (*createTypeNode) DebugValues() debugValues   { return debugValues{} }
+func (*createTypeNode) MarkDebug(mode explainMode) {}
+
+type createFunctionNode struct {
+	p      *planner
+	n      *parser.CreateFunction
+	tn     *parser.TableName
+	dbDesc *sqlbase.DatabaseDescriptor
+	desc   sqlbase.FunctionDescriptor
+}
+
+// CreateFunction creates a user-defined function.
+// Privileges: CREATE on database.
+//   Notes: postgres requires CREATE on the schema.
+func (p *planner) CreateFunction(ctx context.Context, n *parser.CreateFunction) (planNode, error) {
+	tn, err := n.Name.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), tn.Database())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	// Calls to unqualified names always resolve to builtins first, so a
+	// function with the same name as a builtin could only ever be called
+	// with a qualified name.
+	if _, err := (parser.UnresolvedName{tn.TableName}).ResolveFunction(p.session.SearchPath); err == nil {
+		return nil, pgerror.NewErrorf(pgerror.CodeDuplicateFunctionError,
+			"function %q conflicts with a built-in function", tn.Table())
+	}
+
+	desc := sqlbase.FunctionDescriptor{
+		Name:       tn.Table(),
+		ParentID:   dbDesc.ID,
+		Params:     make([]sqlbase.FunctionDescriptor_Param, len(n.Params)),
+		Volatility: sqlbase.VolatilityFromParser(n.Options.Volatility),
+		Body:       n.Body,
+		Privileges: dbDesc.GetPrivileges(),
+	}
+	paramNames := make([]string, len(n.Params))
+	for i, param := range n.Params {
+		paramNames[i] = param.Name.Normalize()
+		colType, err := makeFunctionColumnType(param.Type)
+		if err != nil {
+			return nil, err
+		}
+		desc.Params[i] = sqlbase.FunctionDescriptor_Param{Name: paramNames[i], Type: colType}
+	}
+	if n.ReturnsTrigger {
+		// The body of a trigger function refers to the rows being modified,
+		// so it can only be checked when a trigger using the function fires.
+		if len(n.Params) > 0 {
+			return nil, pgerror.NewError(pgerror.CodeInvalidFunctionDefinitionError,
+				"trigger functions cannot have declared arguments")
+		}
+		if _, err := parser.ParseTriggerFunctionBody(n.Body); err != nil {
+			return nil, err
+		}
+		desc.ReturnsTrigger = true
+		return &createFunctionNode{p: p, n: n, tn: tn, dbDesc: dbDesc, desc: desc}, nil
+	}
+	desc.ReturnType, err = makeFunctionColumnType(n.ReturnType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plan the body once to check that it is valid and returns a value of
+	// the declared type, and to find the functions it calls.
+	body, err := parser.ParseFunctionBody(n.Body, paramNames)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := p.collectFunctionDeps(func() error {
+		return p.checkFunctionBody(ctx, body, &desc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	desc.DependsOn = deps
+
+	return &createFunctionNode{p: p, n: n, tn: tn, dbDesc: dbDesc, desc: desc}, nil
+}
+
+// checkFunctionBody plans the body of a user-defined function, with the
+// parameters typed as declared, and checks that it returns a single column of
+// the declared return type.
+func (p *planner) checkFunctionBody(
+	ctx context.Context, body *parser.Select, desc *sqlbase.FunctionDescriptor,
+) error {
+	saved := p.semaCtx.Placeholders
+	defer func() { p.semaCtx.Placeholders = saved }()
+	p.semaCtx.Placeholders = parser.MakePlaceholderInfo()
+	for i := range desc.Params {
+		if err := p.semaCtx.Placeholders.SetType(
+			strconv.Itoa(i+1), desc.Params[i].Type.ToDatumType(),
+		); err != nil {
+			return err
+		}
+	}
+
+	returnType := desc.ReturnType.ToDatumType()
+	plan, err := p.Select(ctx, body, []parser.Type{returnType})
+	if err != nil {
+		return err
+	}
+	defer plan.Close(ctx)
+
+	cols := planColumns(plan)
+	if len(cols) != 1 || (cols[0].Typ != parser.TypeNull && !cols[0].Typ.Equivalent(returnType)) {
+		return pgerror.NewErrorf(pgerror.CodeInvalidFunctionDefinitionError,
+			"return type mismatch in function declared to return %s", returnType)
+	}
+	return nil
+}
+
+func (n *createFunctionNode) Start(ctx context.Context) error {
+	key := tableKey{parentID: n.dbDesc.ID, name: n.tn.Table()}
+	if _, err := n.p.createDescriptor(ctx, key, &n.desc, false /* ifNotExists */); err != nil {
+		return err
+	}
+	if err := n.desc.Validate(); err != nil {
+		return err
+	}
+	if err := n.p.addFunctionReferences(ctx, n.desc.DependsOn, n.desc.ID); err != nil {
+		return err
+	}
+
+	// Log Create Function event. This is an auditable log event and is
+	// recorded in the same transaction as the function descriptor update.
+	return MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogCreateFunction,
+		int32(n.desc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			FunctionName string
+			Statement    string
+			User         string
+		}{n.tn.String(), n.n.String(), n.p.session.User},
+	)
+}
+
+func (*createFunctionNode) Next(context.Context) (bool, error) { return false, nil }
+func (*createFunctionNode) Close(context.Context)              {}
+
+func (*createFunctionNode) Values() parser.Datums      { return parser.Datums{} }
+func (*createFunctionNode) DebugValues() debugValues   { return debugValues{} }
+func (*createFunctionNode) MarkDebug(mode explainMode) {}
+
 type createIndexNode struct {
 	p         *planner
 	n         *parser.CreateIndex
@@ -170,6 +395,20 @@ func (n *createIndexNode) Start(ctx context.Context) error {
 	if err := indexDesc.FillColumns(n.n.Columns); err != nil {
 		return err
 	}
+	if n.n.Sharded != nil {
+		if n.n.Interleave != nil {
+			return errors.New("interleaved indexes cannot be hash sharded")
+		}
+		// The shard column of the index is added to the table along with the
+		// index, and backfilled before it.
+		if err := setupShardedIndex(n.tableDesc, &indexDesc, n.n.Sharded,
+			func(col sqlbase.ColumnDescriptor) {
+				n.tableDesc.AddColumnMutation(col, sqlbase.DescriptorMutation_ADD)
+			},
+		); err != nil {
+			return err
+		}
+	}
 
 	mutationIdx := len(n.tableDesc.Mutations)
 	n.tableDesc.AddIndexMutation(indexDesc, sqlbase.DescriptorMutation_ADD)
@@ -194,6 +433,11 @@ func (n *createIndexNode) Start(ctx context.Context) error {
 	if err := n.p.writeTableDesc(ctx, n.tableDesc); err != nil {
 		return err
 	}
+	if index := n.tableDesc.Mutations[mutationIdx].GetIndex(); index.IsSharded() {
+		if err := n.p.splitShardedIndex(ctx, n.tableDesc, index); err != nil {
+			return err
+		}
+	}
 
 	// Record index creation in the event log. This is an auditable log
 	// event and is recorded in the same transaction as the table descriptor
@@ -334,6 +578,9 @@ type createViewNode struct {
 	dbDesc      *sqlbase.DatabaseDescriptor
 	sourcePlan  planNode
 	sourceQuery string
+	// functionDeps holds the IDs of the user-defined functions called by
+	// the view.
+	functionDeps []sqlbase.ID
 }
 
 // CreateView creates a view.
@@ -360,7 +607,12 @@ func (p *planner) CreateView(ctx context.Context, n *parser.CreateView) (planNod
 	// depends on, make sure we use the most recent versions of table
 	// descriptors rather than the copies in the lease cache.
 	p.avoidCachedDescriptors = true
-	sourcePlan, err := p.Select(ctx, n.AsSource, []parser.Type{})
+	var sourcePlan planNode
+	functionDeps, err := p.collectFunctionDeps(func() error {
+		var err error
+		sourcePlan, err = p.Select(ctx, n.AsSource, []parser.Type{})
+		return err
+	})
 	if err != nil {
 		p.avoidCachedDescriptors = false
 		return nil, err
@@ -415,11 +667,12 @@ func (p *planner) CreateView(ctx context.Context, n *parser.CreateView) (planNod
 	// Set result rather than just returnning to ensure the defer'ed cleanup
 	// doesn't trigger.
 	result = &createViewNode{
-		p:           p,
-		n:           n,
-		dbDesc:      dbDesc,
-		sourcePlan:  sourcePlan,
-		sourceQuery: queryBuf.String(),
+		p:            p,
+		n:            n,
+		dbDesc:       dbDesc,
+		sourcePlan:   sourcePlan,
+		sourceQuery:  queryBuf.String(),
+		functionDeps: functionDeps,
 	}
 	return result, nil
 }
@@ -465,6 +718,9 @@ func (n *createViewNode) Start(ctx context.Context) error {
 			return err
 		}
 	}
+	if err := n.p.addFunctionReferences(ctx, n.functionDeps, desc.ID); err != nil {
+		return err
+	}
 	if desc.Adding() {
 		n.p.notifySchemaChange(&desc, sqlbase.InvalidMutationID)
 	}
@@ -531,6 +787,9 @@ func (p *planner) CreateTable(ctx context.Context, n *parser.CreateTable) (planN
 	}
 
 	hoistConstraints(n)
+	if err := p.resolveColumnTypes(n.Defs); err != nil {
+		return nil, err
+	}
 	for _, def := range n.Defs {
 		switch t := def.(type) {
 		case *parser.ForeignKeyConstraintTableDef:
@@ -640,6 +899,17 @@ func (n *createTableNode) Start(ctx context.Context) error {
 	if err := n.p.createDescriptorWithID(ctx, key, id, &desc); err != nil {
 		return err
 	}
+	for _, index := range desc.AllNonDropIndexes() {
+		if index.IsSharded() {
+			if err := n.p.splitShardedIndex(ctx, &desc, &index); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := n.p.addTypeReferences(ctx, &desc); err != nil {
+		return err
+	}
 
 	for _, updated := range affected {
 		if err := n.p.saveNonmutationAndNotify(ctx, updated); err != nil {
@@ -1286,6 +1556,11 @@ func MakeTableDesc(
 			if err := idx.FillColumns(d.Columns); err != nil {
 				return desc, err
 			}
+			if d.Sharded != nil {
+				if err := setupShardedIndex(&desc, &idx, d.Sharded, desc.AddColumn); err != nil {
+					return desc, err
+				}
+			}
 			if err := desc.AddIndex(idx, false); err != nil {
 				return desc, err
 			}
@@ -1301,6 +1576,11 @@ func MakeTableDesc(
 			if err := idx.FillColumns(d.Columns); err != nil {
 				return desc, err
 			}
+			if d.Sharded != nil {
+				if err := setupShardedIndex(&desc, &idx, d.Sharded, desc.AddColumn); err != nil {
+					return desc, err
+				}
+			}
 			if err := desc.AddIndex(idx, d.PrimaryKey); err != nil {
 				return desc, err
 			}
@@ -1349,6 +1629,9 @@ func MakeTableDesc(
 	}
 
 	if n.Interleave != nil {
+		if desc.PrimaryIndex.IsSharded() {
+			return desc, errors.New("interleaved tables cannot have a hash sharded primary key")
+		}
 		if err := addInterleave(ctx, txn, vt, &desc, &desc.PrimaryIndex, n.Interleave, sessionDB); err != nil {
 			return desc, err
 		}
@@ -0,0 +1,239 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/jobs"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+const (
+	// histogramSamples is the number of rows sampled to build the histograms.
+	histogramSamples = 10000
+	// histogramBuckets is the maximum number of buckets of a histogram.
+	histogramBuckets = 200
+	// distinctSketchSize is the number of hashes kept by the sketches used to
+	// estimate the number of distinct values of a column.
+	distinctSketchSize = 1024
+)
+
+// sampleAggregatorColumns describes the rows output by the sample aggregator
+// of a CREATE STATISTICS plan; see distsqlrun.SampleAggregatorSpec.
+var sampleAggregatorColumns = sqlbase.ResultColumns{
+	{Name: "columnID", Typ: parser.TypeInt},
+	{Name: "rowCount", Typ: parser.TypeInt},
+	{Name: "distinctCount", Typ: parser.TypeInt},
+	{Name: "nullCount", Typ: parser.TypeInt},
+	{Name: "histogram", Typ: parser.TypeBytes},
+}
+
+// createStatsNode is the planNode of CREATE STATISTICS and ANALYZE. Start
+// runs a job which samples the table with DistSQL and stores one statistic
+// per column in system.table_statistics.
+type createStatsNode struct {
+	p         *planner
+	tableDesc *sqlbase.TableDescriptor
+	// name is the name of the statistics; statistics collected by ANALYZE
+	// have no name.
+	name    *string
+	columns []sqlbase.ColumnDescriptor
+	// description is recorded in the job.
+	description string
+}
+
+// CreateStatistics collects statistics on columns of a table.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the table owner.
+func (p *planner) CreateStatistics(ctx context.Context, n *parser.CreateStats) (planNode, error) {
+	tableDesc, err := p.getStatsTableDesc(ctx, &n.Table)
+	if err != nil {
+		return nil, err
+	}
+	var columns []sqlbase.ColumnDescriptor
+	if len(n.ColumnNames) == 0 {
+		columns = tableDesc.Columns
+	} else {
+		seen := make(map[sqlbase.ColumnID]struct{}, len(n.ColumnNames))
+		for _, name := range n.ColumnNames {
+			col, err := tableDesc.FindActiveColumnByName(name)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[col.ID]; ok {
+				return nil, errors.Errorf("column %q specified more than once", name)
+			}
+			seen[col.ID] = struct{}{}
+			columns = append(columns, col)
+		}
+	}
+	name := string(n.Name)
+	return &createStatsNode{
+		p:           p,
+		tableDesc:   tableDesc,
+		name:        &name,
+		columns:     columns,
+		description: n.String(),
+	}, nil
+}
+
+// Analyze collects statistics on all the columns of a table.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the table owner.
+func (p *planner) Analyze(ctx context.Context, n *parser.Analyze) (planNode, error) {
+	tableDesc, err := p.getStatsTableDesc(ctx, &n.Table)
+	if err != nil {
+		return nil, err
+	}
+	return &createStatsNode{
+		p:           p,
+		tableDesc:   tableDesc,
+		columns:     tableDesc.Columns,
+		description: n.String(),
+	}, nil
+}
+
+// getStatsTableDesc returns the descriptor of the table on which statistics
+// are being collected, after checking that the user may do so.
+func (p *planner) getStatsTableDesc(
+	ctx context.Context, table *parser.NormalizableTableName,
+) (*sqlbase.TableDescriptor, error) {
+	tn, err := table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, false /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	if isVirtualDescriptor(tableDesc) {
+		return nil, errors.Errorf("cannot create statistics on virtual table %s", tn)
+	}
+	if !tableDesc.IsTable() {
+		return nil, errors.Errorf("cannot create statistics on view %s", tn)
+	}
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	return tableDesc, nil
+}
+
+func (n *createStatsNode) Start(ctx context.Context) error {
+	jobLogger := jobs.NewJobLogger(n.p.ExecCfg().DB, InternalExecutor{LeaseManager: n.p.LeaseMgr()}, jobs.JobRecord{
+		Description:   n.description,
+		Username:      n.p.User(),
+		DescriptorIDs: sqlbase.IDs{n.tableDesc.ID},
+		Details:       jobs.CreateStatsJobDetails{},
+	})
+	if err := jobLogger.Created(ctx); err != nil {
+		return err
+	}
+	if err := jobLogger.Started(ctx); err != nil {
+		return err
+	}
+	if err := n.createStats(ctx); err != nil {
+		jobLogger.Failed(ctx, err)
+		return err
+	}
+	if err := jobLogger.Succeeded(ctx); err != nil {
+		// The statistics are written in the statement's transaction; failing to
+		// mark the job as successful does not make them invalid.
+		log.Errorf(ctx, "CREATE STATISTICS ignoring error while marking job %d (%s) as successful: %+v",
+			*jobLogger.JobID(), n.description, err)
+	}
+	return nil
+}
+
+// createStats runs the sampling plan and writes the resulting statistics,
+// replacing the previous statistics with the same name.
+func (n *createStatsNode) createStats(ctx context.Context) error {
+	p := n.p
+	dsp := p.session.distSQLPlanner
+	planCtx := dsp.NewPlanningCtx(ctx, p.txn)
+	plan, err := dsp.createPlanForCreateStats(&planCtx, n.tableDesc, n.columns)
+	if err != nil {
+		return err
+	}
+
+	rows := sqlbase.NewRowContainer(
+		p.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(sampleAggregatorColumns), 0,
+	)
+	defer rows.Close(ctx)
+	recv, err := makeDistSQLReceiver(
+		ctx, rows,
+		p.ExecCfg().RangeDescriptorCache, p.ExecCfg().LeaseHolderCache,
+		p.txn,
+		func(ts hlc.Timestamp) {
+			_ = p.ExecCfg().Clock.Update(ts)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := dsp.Run(&planCtx, p.txn, &plan, &recv, p.evalCtx); err != nil {
+		return err
+	}
+	if recv.err != nil {
+		return recv.err
+	}
+
+	var name interface{}
+	if n.name != nil {
+		name = *n.name
+	}
+	ie := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.At(i)
+		if _, err := ie.ExecuteStatementInTransaction(ctx, "delete-table-statistics", p.txn,
+			`DELETE FROM system.table_statistics
+			 WHERE "tableID" = $1 AND "columnID" = $2 AND name IS NOT DISTINCT FROM $3`,
+			int(n.tableDesc.ID), row[0], name,
+		); err != nil {
+			return err
+		}
+		if _, err := ie.ExecuteStatementInTransaction(ctx, "insert-table-statistics", p.txn,
+			`INSERT INTO system.table_statistics
+			 ("tableID", name, "columnID", "rowCount", "distinctCount", "nullCount", histogram)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			int(n.tableDesc.ID), name, row[0], row[1], row[2], row[3], row[4],
+		); err != nil {
+			return err
+		}
+	}
+	p.session.tableStats.invalidate(p.txn, n.tableDesc.ID)
+	p.session.indexSelectionCache.invalidate(p.txn, n.tableDesc.ID)
+	return nil
+}
+
+func (*createStatsNode) Next(context.Context) (bool, error) { return false, nil }
+func (*createStatsNode) Close(context.Context)              {}
+
+func (*createStatsNode) Values() parser.Datums      { return parser.Datums{} }
+func (*createStatsNode) DebugValues() debugValues   { return debugValues{} }
+func (*createStatsNode) MarkDebug(mode explainMode) {}
+
+// deleteTableStats removes the statistics of the table with the given ID.
+func (p *planner) deleteTableStats(ctx context.Context, id sqlbase.ID) error {
+	ie := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	_, err := ie.ExecuteStatementInTransaction(ctx, "delete-table-statistics", p.txn,
+		`DELETE FROM system.table_statistics WHERE "tableID" = $1`, int(id))
+	return err
+}
@@ -0,0 +1,245 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// sqlCursor is an open query whose rows are pulled on demand. It backs
+// the cursors created by DECLARE ... CURSOR, the portals whose execution
+// is suspended by the row limit of an Execute message, and the COPY TO
+// statements whose rows are streamed to the client.
+//
+// The plan of the cursor stays open until the cursor is closed, and rows
+// are only computed as they are fetched. The memory used by the plan is
+// tracked by a monitor of its own, subject to the per-query limit. Cursors
+// have the following limitations:
+// - the query always runs locally, never through DistSQL;
+// - the query reads at the transaction's timestamp, so rows written by the
+//   transaction after the cursor was declared may or may not be visible to
+//   it.
+type sqlCursor struct {
+	columns sqlbase.ResultColumns
+	plan    planNode
+	mon     *mon.MemoryMonitor
+	// rowAcc accounts for the memory used to compute the current row.
+	rowAcc mon.BoundAccount
+	// done is set once the plan has returned all its rows.
+	done bool
+}
+
+// next advances the cursor to its next row, and returns false if the
+// cursor is exhausted. The values of the row are only valid until the
+// following call.
+func (c *sqlCursor) next(ctx context.Context) (parser.Datums, bool, error) {
+	if c.done {
+		return nil, false, nil
+	}
+	c.rowAcc.Clear(ctx)
+	next, err := c.plan.Next(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if !next {
+		c.done = true
+		return nil, false, nil
+	}
+	values := c.plan.Values()
+	for _, val := range values {
+		if err := checkResultType(val.ResolvedType()); err != nil {
+			return nil, false, err
+		}
+	}
+	return values, true, nil
+}
+
+// fetch pulls up to count rows from the cursor and adds them to rows,
+// unless rows is nil. It returns the number of rows pulled, which is less
+// than count only if the cursor is exhausted.
+func (c *sqlCursor) fetch(
+	ctx context.Context, count int64, rows *sqlbase.RowContainer,
+) (int, error) {
+	n := 0
+	for ; int64(n) < count; n++ {
+		values, ok, err := c.next(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		if rows == nil {
+			continue
+		}
+		if _, err := rows.AddRow(ctx, values); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (c *sqlCursor) close(ctx context.Context) {
+	c.plan.Close(ctx)
+	c.rowAcc.Close(ctx)
+	c.mon.Stop(ctx)
+}
+
+// declareCursor plans and starts the query of a cursor. The cursor must be
+// closed by the caller.
+func (e *Executor) declareCursor(
+	session *Session,
+	stmt Statement,
+	sel *parser.Select,
+	pinfo *parser.PlaceholderInfo,
+	avoidCachedDescriptors bool,
+) (*sqlCursor, error) {
+	ctx := session.Ctx()
+	txnState := &session.TxnState
+	// The planner of the cursor outlives the statement, so it can't be the
+	// cached planner of the session.
+	p := session.newPlanner(e, txnState.mu.txn)
+	p.evalCtx.SetTxnTimestamp(txnState.sqlTimestamp)
+	p.evalCtx.SetStmtTimestamp(e.cfg.Clock.PhysicalTime())
+	p.semaCtx.Placeholders.Assign(pinfo)
+	p.avoidCachedDescriptors = avoidCachedDescriptors
+
+	c := &sqlCursor{mon: session.startStmtMonitor()}
+	p.evalCtx.Mon = c.mon
+	c.rowAcc = c.mon.MakeBoundAccount()
+	p.evalCtx.ActiveMemAcc = &c.rowAcc
+
+	stmt.AST = sel
+	plan, err := p.makePlan(ctx, stmt)
+	if err != nil {
+		c.rowAcc.Close(ctx)
+		c.mon.Stop(ctx)
+		return nil, err
+	}
+	c.plan = plan
+	c.columns = planColumns(plan)
+	for _, col := range c.columns {
+		if err := checkResultType(col.Typ); err != nil {
+			c.close(ctx)
+			return nil, err
+		}
+	}
+	session.setQueryExecutionMode(stmt.queryHandle, false /* isDistributed */)
+	if err := p.startPlan(ctx, plan); err != nil {
+		c.close(ctx)
+		return nil, err
+	}
+	return c, nil
+}
+
+// cursorCollection holds the cursors of a session. Cursors only live as
+// long as the transaction that declared them.
+type cursorCollection struct {
+	cursors map[string]*sqlCursor
+}
+
+func (cc *cursorCollection) get(name string) (*sqlCursor, error) {
+	c, ok := cc.cursors[name]
+	if !ok {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidCursorNameError,
+			"cursor %q does not exist", name)
+	}
+	return c, nil
+}
+
+func (cc *cursorCollection) exists(name string) bool {
+	_, ok := cc.cursors[name]
+	return ok
+}
+
+// add registers a cursor, replacing any cursor with the same name.
+func (cc *cursorCollection) add(ctx context.Context, name string, c *sqlCursor) {
+	if prev, ok := cc.cursors[name]; ok {
+		prev.close(ctx)
+	}
+	if cc.cursors == nil {
+		cc.cursors = make(map[string]*sqlCursor)
+	}
+	cc.cursors[name] = c
+}
+
+// close closes the named cursor, or all the cursors if name is empty.
+func (cc *cursorCollection) close(ctx context.Context, name string) error {
+	if name == "" {
+		cc.closeAll(ctx)
+		return nil
+	}
+	c, err := cc.get(name)
+	if err != nil {
+		return err
+	}
+	c.close(ctx)
+	delete(cc.cursors, name)
+	return nil
+}
+
+func (cc *cursorCollection) closeAll(ctx context.Context) {
+	for _, c := range cc.cursors {
+		c.close(ctx)
+	}
+	cc.cursors = nil
+}
+
+// closeCursors closes the cursors and the suspended portals of the
+// session, which don't outlive the transaction, or the restart of the
+// transaction, that opened them.
+func (s *Session) closeCursors(ctx context.Context) {
+	s.cursors.closeAll(ctx)
+	s.PreparedPortals.closeSuspended(ctx)
+}
+
+// execFetch executes a FETCH or MOVE statement, on the cursor of the
+// portal the statement is executed for if there is one. FETCH copies the
+// fetched rows into a new result; MOVE only reports how many rows were
+// skipped.
+func (s *Session) execFetch(stmt Statement, n *parser.FetchCursor) (Result, error) {
+	var c *sqlCursor
+	if stmt.portal != nil {
+		c = stmt.portal.cursor
+	} else {
+		var err error
+		if c, err = s.cursors.get(string(n.Name)); err != nil {
+			return Result{}, err
+		}
+	}
+	result := Result{PGTag: n.StatementTag(), Type: n.StatementType()}
+	if n.Move {
+		count, err := c.fetch(s.Ctx(), n.Count, nil /* rows */)
+		if err != nil {
+			return Result{}, err
+		}
+		result.RowsAffected = count
+		return result, nil
+	}
+	result.Columns = c.columns
+	result.Rows = sqlbase.NewRowContainer(
+		s.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(result.Columns), 0,
+	)
+	if _, err := c.fetch(s.Ctx(), n.Count, result.Rows); err != nil {
+		result.Close(s.Ctx())
+		return Result{}, err
+	}
+	return result, nil
+}
@@ -526,10 +526,11 @@ func (p *planner) getPlanForDesc(
 		return planDataSource{}, err
 	}
 
-	return planDataSource{
+	src := planDataSource{
 		info: newSourceInfoForSingleTable(*tn, planColumns(scan)),
 		plan: scan,
-	}, nil
+	}
+	return p.addPolicyFilter(ctx, src, desc)
 }
 
 // getViewPlan builds a planDataSource for the view specified by the
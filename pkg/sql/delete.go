@@ -58,8 +58,14 @@ func (p *planner) Delete(
 		return nil, err
 	}
 
+	en.triggers, err = p.makeTriggerRunner(ctx, en.tableDesc, sqlbase.TableDescriptor_Trigger_DELETE)
+	if err != nil {
+		return nil, err
+	}
+
 	var requestedCols []sqlbase.ColumnDescriptor
-	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs {
+	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs ||
+		(en.triggers != nil && en.triggers.hasRowTriggers) {
 		// TODO(dan): This could be made tighter, just the rows needed for RETURNING
 		// exprs.
 		requestedCols = en.tableDesc.Columns
@@ -73,18 +79,23 @@ func (p *planner) Delete(
 	if err != nil {
 		return nil, err
 	}
-	tw := tableDeleter{rd: rd, autoCommit: p.autoCommit}
+	// The AFTER triggers fire once the batch has been run, so the transaction
+	// cannot be committed along with it.
+	tw := tableDeleter{rd: rd, autoCommit: p.autoCommit && en.triggers == nil, triggers: en.triggers}
 
 	// TODO(knz): Until we split the creation of the node from Start()
 	// for the SelectClause too, we cannot cache this. This is because
 	// this node's initSelect() method both does type checking and also
 	// performs index selection. We cannot perform index selection
 	// properly until the placeholder values are known.
+	// The rows to delete are restricted by the DELETE policies of the table.
+	p.policyTarget = policyTarget{tableID: en.tableDesc.ID, cmd: sqlbase.TableDescriptor_Policy_DELETE}
 	rows, err := p.SelectClause(ctx, &parser.SelectClause{
 		Exprs: sqlbase.ColumnsSelectors(rd.FetchCols),
 		From:  &parser.From{Tables: []parser.TableExpr{n.Table}},
 		Where: n.Where,
 	}, nil, nil, nil, publicAndNonPublicColumns)
+	p.policyTarget = policyTarget{}
 	if err != nil {
 		return nil, err
 	}
@@ -116,11 +127,7 @@ func (d *deleteNode) Start(ctx context.Context) error {
 		//
 		// (When explain == explainDebug, we use the slow path so that
 		// each debugVal gets a chance to be reported via Next().)
-		maybeScan := d.run.rows
-		if sel, ok := maybeScan.(*renderNode); ok {
-			maybeScan = sel.source.plan
-		}
-		if scan, ok := maybeScan.(*scanNode); ok && canDeleteWithoutScan(ctx, d.n, scan, &d.tw) {
+		if scan := d.fastPathScan(ctx); scan != nil {
 			d.run.fastPath = true
 			err := d.fastDelete(ctx, scan)
 			return err
@@ -150,6 +157,9 @@ func (d *deleteNode) Next(ctx context.Context) (bool, error) {
 			// We're done. Finish the batch.
 			err = d.tw.finalize(ctx, traceKV)
 		}
+		if err == nil {
+			d.p.session.statsRefresher.notifyMutation(d.p.txn, d.tableDesc.ID, d.run.numRows)
+		}
 		return false, err
 	}
 
@@ -163,6 +173,7 @@ func (d *deleteNode) Next(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	d.run.numRows++
 
 	resultRow, err := d.rh.cookResultRow(rowVals)
 	if err != nil {
@@ -173,6 +184,19 @@ func (d *deleteNode) Next(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// fastPathScan returns the scanNode producing the rows to delete if they can
+// be deleted without scanning them (see canDeleteWithoutScan), or nil.
+func (d *deleteNode) fastPathScan(ctx context.Context) *scanNode {
+	maybeScan := d.run.rows
+	if sel, ok := maybeScan.(*renderNode); ok {
+		maybeScan = sel.source.plan
+	}
+	if scan, ok := maybeScan.(*scanNode); ok && canDeleteWithoutScan(ctx, d.n, scan, &d.tw) {
+		return scan
+	}
+	return nil
+}
+
 // Determine if the deletion of `rows` can be done without actually scanning them,
 // i.e. if we do not need to know their values for filtering expressions or a
 // RETURNING clause or for updating secondary indexes.
@@ -213,6 +237,7 @@ func (d *deleteNode) fastDelete(ctx context.Context, scan *scanNode) error {
 		return err
 	}
 	d.rh.rowCount += rowCount
+	d.p.session.statsRefresher.notifyMutation(d.p.txn, d.tableDesc.ID, rowCount)
 	return nil
 }
 
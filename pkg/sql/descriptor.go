@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
@@ -95,6 +96,12 @@ func (p *planner) createDescriptor(
 			return false, sqlbase.NewDatabaseAlreadyExistsError(plainKey.Name())
 		case "table", "view":
 			return false, sqlbase.NewRelationAlreadyExistsError(plainKey.Name())
+		case "type":
+			return false, pgerror.NewErrorf(pgerror.CodeDuplicateObjectError,
+				"type %q already exists", plainKey.Name())
+		case "function":
+			return false, pgerror.NewErrorf(pgerror.CodeDuplicateFunctionError,
+				"function %q already exists", plainKey.Name())
 		default:
 			return false, descriptorAlreadyExistsErr{descriptor, plainKey.Name()}
 		}
@@ -205,6 +212,24 @@ func getDescriptor(
 			return false, err
 		}
 		*t = *database
+	case *sqlbase.TypeDescriptor:
+		typ := desc.GetType()
+		if typ == nil {
+			return false, errors.Errorf("%q is not a type", plainKey.Name())
+		}
+		if err := typ.Validate(); err != nil {
+			return false, err
+		}
+		*t = *typ
+	case *sqlbase.FunctionDescriptor:
+		fn := desc.GetFunction()
+		if fn == nil {
+			return false, errors.Errorf("%q is not a function", plainKey.Name())
+		}
+		if err := fn.Validate(); err != nil {
+			return false, err
+		}
+		*t = *fn
 	}
 	return true, nil
 }
@@ -228,6 +253,10 @@ func getAllDescriptors(ctx context.Context, txn *client.Txn) ([]sqlbase.Descript
 			descs[i] = desc.GetTable()
 		case *sqlbase.Descriptor_Database:
 			descs[i] = desc.GetDatabase()
+		case *sqlbase.Descriptor_Type:
+			descs[i] = desc.GetType()
+		case *sqlbase.Descriptor_Function:
+			descs[i] = desc.GetFunction()
 		default:
 			return nil, errors.Errorf("Descriptor.Union has unexpected type %T", t)
 		}
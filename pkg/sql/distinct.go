@@ -52,8 +52,8 @@ func (p *planner) Distinct(n *parser.SelectClause) *distinctNode {
 		return nil
 	}
 	d := &distinctNode{p: p}
-	d.prefixMemAcc = p.session.TxnState.OpenAccount()
-	d.suffixMemAcc = p.session.TxnState.OpenAccount()
+	d.prefixMemAcc = p.openAccount()
+	d.suffixMemAcc = p.openAccount()
 	return d
 }
 
@@ -92,8 +92,8 @@ func (n *distinctNode) addSuffixSeen(
 
 func (n *distinctNode) Next(ctx context.Context) (bool, error) {
 
-	prefixMemAcc := n.prefixMemAcc.Wtxn(n.p.session)
-	suffixMemAcc := n.suffixMemAcc.Wtxn(n.p.session)
+	prefixMemAcc := n.prefixMemAcc.Wplanner(n.p)
+	suffixMemAcc := n.suffixMemAcc.Wplanner(n.p)
 
 	for {
 		next, err := n.plan.Next(ctx)
@@ -180,7 +180,7 @@ func (n *distinctNode) encodeValues(values parser.Datums) ([]byte, []byte, error
 func (n *distinctNode) Close(ctx context.Context) {
 	n.plan.Close(ctx)
 	n.prefixSeen = nil
-	n.prefixMemAcc.Wtxn(n.p.session).Close(ctx)
+	n.prefixMemAcc.Wplanner(n.p).Close(ctx)
 	n.suffixSeen = nil
-	n.suffixMemAcc.Wtxn(n.p.session).Close(ctx)
+	n.suffixMemAcc.Wplanner(n.p).Close(ctx)
 }
@@ -209,8 +209,6 @@ func newQueryNotSupportedErrorf(format string, args ...interface{}) error {
 	return &queryNotSupportedError{msg: fmt.Sprintf(format, args...)}
 }
 
-var mutationsNotSupportedError = newQueryNotSupportedError("mutations not supported")
-
 // checkSupportForNode returns a distRecommendation (as described above) or an
 // error if the plan subtree is not supported by DistSQL.
 // TODO(radu): add tests for this.
@@ -247,7 +245,8 @@ func (dsp *distSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		return rec, nil
 
 	case *joinNode:
-		if n.joinType != joinTypeInner {
+		lookupScan := lookupJoinScan(n)
+		if n.joinType != joinTypeInner && lookupScan == nil {
 			return 0, newQueryNotSupportedError("only inner join supported")
 		}
 		if err := dsp.checkExpr(n.pred.onCond); err != nil {
@@ -257,6 +256,11 @@ func (dsp *distSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		if err != nil {
 			return 0, err
 		}
+		if lookupScan != nil {
+			// The right side is read by the lookup joiners, which run where the
+			// left side is produced.
+			return recLeft, nil
+		}
 		recRight, err := dsp.checkSupportForNode(n.right.plan)
 		if err != nil {
 			return 0, err
@@ -315,6 +319,38 @@ func (dsp *distSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 		// Distribute aggregations if possible.
 		return rec.compose(shouldDistribute), nil
 
+	case *windowNode:
+		for _, f := range n.funcs {
+			if _, err := windowFnSpec(f); err != nil {
+				return 0, err
+			}
+		}
+		for i, e := range n.windowRender {
+			if e == nil {
+				continue
+			}
+			if typ := n.values.columns[i].Typ; typ.FamilyEqual(parser.TypeTuple) ||
+				typ.FamilyEqual(parser.TypeStringArray) ||
+				typ.FamilyEqual(parser.TypeIntArray) {
+				return 0, newQueryNotSupportedErrorf("unsupported render type %s", typ)
+			}
+			if err := dsp.checkExpr(e); err != nil {
+				return 0, err
+			}
+		}
+		rec, err := dsp.checkSupportForNode(n.plan)
+		if err != nil {
+			return 0, err
+		}
+		// Distribute window functions if their partitions can be computed in
+		// parallel.
+		for _, f := range n.funcs {
+			if len(f.partitionIdxs) > 0 {
+				return rec.compose(shouldDistribute), nil
+			}
+		}
+		return rec, nil
+
 	case *limitNode:
 		if err := dsp.checkExpr(n.countExpr); err != nil {
 			return 0, err
@@ -327,15 +363,255 @@ func (dsp *distSQLPlanner) checkSupportForNode(node planNode) (distRecommendatio
 	case *distinctNode:
 		return dsp.checkSupportForNode(n.plan)
 
-	case *insertNode, *updateNode, *deleteNode:
-		// This is a potential hot path.
-		return 0, mutationsNotSupportedError
+	case *unionNode:
+		leftColumns := planColumns(n.left)
+		rightColumns := planColumns(n.right)
+		for i := range leftColumns {
+			// The processors require the two sides to have the same column types.
+			leftType := sqlbase.DatumTypeToColumnType(leftColumns[i].Typ)
+			rightType := sqlbase.DatumTypeToColumnType(rightColumns[i].Typ)
+			if leftType.Kind != rightType.Kind {
+				return 0, newQueryNotSupportedErrorf(
+					"set operation on types %s and %s", leftColumns[i].Typ, rightColumns[i].Typ,
+				)
+			}
+		}
+		leftRec, err := dsp.checkSupportForNode(n.left)
+		if err != nil {
+			return 0, err
+		}
+		rightRec, err := dsp.checkSupportForNode(n.right)
+		if err != nil {
+			return 0, err
+		}
+		return leftRec.compose(rightRec), nil
+
+	case *valuesNode:
+		if n.n == nil {
+			// The rows of valuesNodes that don't come from a VALUES clause are
+			// populated by the local execution engine.
+			return 0, newQueryNotSupportedErrorf("unsupported node %T", node)
+		}
+		for _, col := range n.columns {
+			if typ := col.Typ; typ.FamilyEqual(parser.TypeTuple) ||
+				typ.FamilyEqual(parser.TypeStringArray) ||
+				typ.FamilyEqual(parser.TypeIntArray) {
+				return 0, newQueryNotSupportedErrorf("unsupported VALUES type %s", typ)
+			}
+		}
+		for _, tuple := range n.tuples {
+			for _, e := range tuple {
+				if err := dsp.checkExpr(e); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return canDistribute, nil
+
+	case *insertNode:
+		return dsp.checkSupportForInsert(n)
+
+	case *updateNode:
+		return dsp.checkSupportForUpdate(n)
+
+	case *deleteNode:
+		return dsp.checkSupportForDelete(n)
 
 	default:
 		return 0, newQueryNotSupportedErrorf("unsupported node %T", node)
 	}
 }
 
+// checkSupportForMutation checks the conditions that all the mutations need to
+// satisfy in order to be run by table writers: the table writers don't fire
+// triggers, check foreign keys and CHECK constraints, or write system tables.
+func (dsp *distSQLPlanner) checkSupportForMutation(
+	en *editNodeBase, fkCheck sqlbase.FKCheck, checkExprs []parser.TypedExpr,
+) error {
+	desc := en.tableDesc
+	if sqlbase.IsSystemConfigID(desc.ID) {
+		return newQueryNotSupportedError("mutation of a system table not supported")
+	}
+	if en.triggers != nil {
+		return newQueryNotSupportedError("mutation of a table with triggers not supported")
+	}
+	if len(sqlbase.TablesNeededForFKs(*desc, fkCheck)) > 0 {
+		return newQueryNotSupportedError("mutation of a table with foreign keys not supported")
+	}
+	if len(checkExprs) > 0 {
+		return newQueryNotSupportedError(
+			"mutation of a table with CHECK constraints not supported",
+		)
+	}
+	for i, e := range en.rh.exprs {
+		if typ := en.rh.columns[i].Typ; typ.FamilyEqual(parser.TypeTuple) ||
+			typ.FamilyEqual(parser.TypeStringArray) ||
+			typ.FamilyEqual(parser.TypeIntArray) {
+			return newQueryNotSupportedErrorf("unsupported RETURNING type %s", typ)
+		}
+		if err := dsp.checkExpr(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSupportForInsert checks that an INSERT can be run by table writers
+// placed on the nodes that produce the rows to insert.
+func (dsp *distSQLPlanner) checkSupportForInsert(n *insertNode) (distRecommendation, error) {
+	ti, ok := n.tw.(*tableInserter)
+	if !ok {
+		return 0, newQueryNotSupportedError("INSERT with ON CONFLICT not supported")
+	}
+	if ti.policyCheck != nil {
+		return 0, newQueryNotSupportedError("mutation of a table with policies not supported")
+	}
+	if n.computedCols != nil {
+		return 0, newQueryNotSupportedError(
+			"mutation of a table with computed columns not supported",
+		)
+	}
+	if err := dsp.checkSupportForMutation(
+		&n.editNodeBase, sqlbase.CheckInserts, n.checkHelper.exprs,
+	); err != nil {
+		return 0, err
+	}
+	if readsTable(n.run.rows, n.tableDesc.ID) {
+		// The table readers could see the rows inserted by the table writers.
+		return 0, newQueryNotSupportedError("INSERT reading its own table not supported")
+	}
+	rec, err := dsp.checkSupportForNode(n.run.rows)
+	if err != nil {
+		return 0, err
+	}
+	srcCols := planColumns(n.run.rows)
+	for i := range srcCols {
+		if err := checkWriteType(srcCols[i].Typ, &n.insertCols[i]); err != nil {
+			return 0, err
+		}
+	}
+	if n.defaultExprs != nil {
+		for _, e := range n.defaultExprs[len(srcCols):len(n.insertCols)] {
+			if err := dsp.checkExpr(e); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return rec, nil
+}
+
+// checkSupportForUpdate checks that an UPDATE can be run by table writers
+// placed on the nodes that read the rows to update.
+func (dsp *distSQLPlanner) checkSupportForUpdate(n *updateNode) (distRecommendation, error) {
+	if n.tw.policyCheck != nil {
+		return 0, newQueryNotSupportedError("mutation of a table with policies not supported")
+	}
+	if n.computedCols != nil {
+		return 0, newQueryNotSupportedError(
+			"mutation of a table with computed columns not supported",
+		)
+	}
+	if err := dsp.checkSupportForMutation(
+		&n.editNodeBase, sqlbase.CheckUpdates, n.checkHelper.exprs,
+	); err != nil {
+		return 0, err
+	}
+	for i := range n.updateCols {
+		if n.tableDesc.PrimaryIndex.ContainsColumnID(n.updateCols[i].ID) {
+			// The updated rows move to new keys, where the table readers could
+			// see them again.
+			return 0, newQueryNotSupportedError("UPDATE of primary key columns not supported")
+		}
+	}
+	if index := scannedIndexWithColumns(n.run.rows, n.tableDesc.ID, n.updateCols); index != nil {
+		// The table writers write the updated rows while the table readers are
+		// still scanning the index, so the readers could see the rows again at
+		// their new position and update them once more.
+		return 0, newQueryNotSupportedErrorf(
+			"UPDATE of columns of the scanned index %s not supported", index.Name,
+		)
+	}
+	rec, err := dsp.checkSupportForNode(n.run.rows)
+	if err != nil {
+		return 0, err
+	}
+	srcCols := planColumns(n.run.rows)
+	for _, slot := range n.sourceSlots {
+		s, ok := slot.(scalarSlot)
+		if !ok {
+			return 0, newQueryNotSupportedError("UPDATE of tuples of columns not supported")
+		}
+		if err := checkWriteType(srcCols[s.sourceIndex].Typ, &s.column); err != nil {
+			return 0, err
+		}
+	}
+	return rec, nil
+}
+
+// checkSupportForDelete checks that a DELETE can be run by table writers
+// placed on the nodes that read the rows to delete.
+func (dsp *distSQLPlanner) checkSupportForDelete(n *deleteNode) (distRecommendation, error) {
+	if n.fastPathScan(context.TODO()) != nil {
+		// The fast path deletes whole spans without reading them, which is
+		// more efficient than reading the rows in order to delete them.
+		return 0, newQueryNotSupportedError("DELETE without scan not supported")
+	}
+	if err := dsp.checkSupportForMutation(&n.editNodeBase, sqlbase.CheckDeletes, nil); err != nil {
+		return 0, err
+	}
+	return dsp.checkSupportForNode(n.run.rows)
+}
+
+// checkWriteType verifies that the values of type typ can be written in the
+// given column by a table writer.
+func checkWriteType(typ parser.Type, col *sqlbase.ColumnDescriptor) error {
+	if sqlbase.DatumTypeToColumnType(typ).Kind != col.Type.Kind {
+		return newQueryNotSupportedErrorf(
+			"writing values of type %s in column %s of type %s not supported",
+			typ, col.Name, col.Type.SQLString(),
+		)
+	}
+	return nil
+}
+
+// readsTable returns true if the plan contains a scan of the given table.
+func readsTable(plan planNode, tableID sqlbase.ID) bool {
+	found := false
+	_ = walkPlan(context.TODO(), plan, planObserver{
+		enterNode: func(_ context.Context, _ string, p planNode) bool {
+			if scan, ok := p.(*scanNode); ok && scan.desc.ID == tableID {
+				found = true
+			}
+			return !found
+		},
+	})
+	return found
+}
+
+// scannedIndexWithColumns returns an index of the given table which is
+// scanned by the plan and has one of the given columns as a key column, or
+// nil if there is none.
+func scannedIndexWithColumns(
+	plan planNode, tableID sqlbase.ID, cols []sqlbase.ColumnDescriptor,
+) *sqlbase.IndexDescriptor {
+	var index *sqlbase.IndexDescriptor
+	_ = walkPlan(context.TODO(), plan, planObserver{
+		enterNode: func(_ context.Context, _ string, p planNode) bool {
+			if scan, ok := p.(*scanNode); ok && scan.desc.ID == tableID {
+				for _, col := range cols {
+					for _, id := range scan.index.ColumnIDs {
+						if id == col.ID {
+							index = scan.index
+						}
+					}
+				}
+			}
+			return index == nil
+		},
+	})
+	return index
+}
+
 // planningCtx contains data used and updated throughout the planning process of
 // a single query.
 type planningCtx struct {
@@ -345,6 +621,16 @@ type planningCtx struct {
 	// physicalPlan we generate with this context.
 	// Nodes that fail a health check have empty addresses.
 	nodeAddresses map[roachpb.NodeID]string
+	// collectStats is set if the flows of the plan collect execution
+	// statistics, which are sent to the distSQLReceiver (see EXPLAIN ANALYZE).
+	collectStats bool
+	// writesAnchor is set if the plan contains table writers; it is the key
+	// at which the transaction record is anchored if the transaction hasn't
+	// written anything yet.
+	writesAnchor roachpb.Key
+	// vectorize is set if the processors supporting it run with vectorized
+	// operators (see the vectorize session variable).
+	vectorize bool
 }
 
 // physicalPlan is a partial physical plan which corresponds to a planNode
@@ -368,6 +654,10 @@ type physicalPlan struct {
 	// and indexJoinNode where not all columns in the table are actually used in
 	// the plan.
 	planToStreamColMap []int
+
+	// rowCounts is set if the result streams contain row counts (produced by
+	// table writers) instead of rows.
+	rowCounts bool
 }
 
 // orderingTerminated is used when
@@ -743,6 +1033,74 @@ func (dsp *distSQLPlanner) CreateBackfiller(
 	return p, nil
 }
 
+// createPlanForCreateStats generates a plan which computes statistics on the
+// given columns of a table: the primary index is read by table readers, each
+// feeding a sampler on the same node, and the samples and sketches are merged
+// by a sample aggregator on this node. The plan is finalized.
+func (dsp *distSQLPlanner) createPlanForCreateStats(
+	planCtx *planningCtx, desc *sqlbase.TableDescriptor, columns []sqlbase.ColumnDescriptor,
+) (physicalPlan, error) {
+	scan := &scanNode{desc: *desc}
+	if err := scan.initDescDefaults(publicColumns, nil); err != nil {
+		return physicalPlan{}, err
+	}
+	scan.spans = []roachpb.Span{scan.desc.PrimaryIndexSpan()}
+
+	outCols := make([]uint32, len(columns))
+	columnIDs := make([]uint32, len(columns))
+	for i := range columns {
+		idx, ok := scan.colIdxMap[columns[i].ID]
+		if !ok {
+			return physicalPlan{}, errors.Errorf("unknown column %q", columns[i].Name)
+		}
+		outCols[i] = uint32(idx)
+		columnIDs[i] = uint32(columns[i].ID)
+	}
+	p, err := dsp.createTableReaders(planCtx, scan, outCols)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+
+	// The samplers output the sampled columns followed by the rank, column
+	// index, row count, NULL count and sketch columns (see SamplerSpec).
+	samplerTypes := append([]sqlbase.ColumnType(nil), p.ResultTypes...)
+	samplerTypes = append(samplerTypes,
+		sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT},
+		sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT},
+		sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT},
+		sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT},
+		sqlbase.ColumnType{Kind: sqlbase.ColumnType_BYTES},
+	)
+	p.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{Sampler: &distsqlrun.SamplerSpec{
+			SampleSize: histogramSamples,
+			SketchSize: distinctSketchSize,
+		}},
+		distsqlrun.PostProcessSpec{},
+		samplerTypes,
+		distsqlrun.Ordering{},
+	)
+
+	aggTypes := make([]sqlbase.ColumnType, len(sampleAggregatorColumns))
+	for i := range sampleAggregatorColumns {
+		aggTypes[i] = sqlbase.DatumTypeToColumnType(sampleAggregatorColumns[i].Typ)
+	}
+	p.AddSingleGroupStage(
+		dsp.nodeDesc.NodeID,
+		distsqlrun.ProcessorCoreUnion{SampleAggregator: &distsqlrun.SampleAggregatorSpec{
+			SampleSize:          histogramSamples,
+			ColumnIDs:           columnIDs,
+			MaxHistogramBuckets: histogramBuckets,
+		}},
+		distsqlrun.PostProcessSpec{},
+		aggTypes,
+	)
+	p.planToStreamColMap = identityMap(p.planToStreamColMap, len(aggTypes))
+
+	dsp.FinalizePlan(planCtx, &p)
+	return p, nil
+}
+
 // selectRenders takes a physicalPlan that produces the results corresponding to
 // the select data source (a n.source) and updates it to produce results
 // corresponding to the render node itself. An evaluator stage is added if the
@@ -1169,6 +1527,174 @@ func (dsp *distSQLPlanner) addAggregators(
 	return nil
 }
 
+// windowFnSpec converts the function of a window function application to a
+// WindowerSpec_WindowFn. The argument and ordering columns are not set.
+func windowFnSpec(f *windowFuncHolder) (distsqlrun.WindowerSpec_WindowFn, error) {
+	var spec distsqlrun.WindowerSpec_WindowFn
+	if f.expr.Type == parser.DistinctFuncType || f.expr.Filter != nil {
+		return spec, newQueryNotSupportedErrorf("window function %s not supported", f.expr)
+	}
+	// Convert the function to the enum value with the same string
+	// representation.
+	funcStr := strings.ToUpper(f.expr.Func.FunctionReference.String())
+	if f.expr.GetAggregateConstructor() != nil {
+		funcIdx, ok := distsqlrun.AggregatorSpec_Func_value[funcStr]
+		if !ok || distsqlrun.AggregatorSpec_Func(funcIdx) == distsqlrun.AggregatorSpec_IDENT {
+			return spec, newQueryNotSupportedErrorf("aggregate %s not supported over a window", funcStr)
+		}
+		spec.AggregateFunc = distsqlrun.AggregatorSpec_Func(funcIdx).Enum()
+	} else {
+		funcIdx, ok := distsqlrun.WindowerSpec_WindowFunc_value[funcStr]
+		if !ok {
+			return spec, newQueryNotSupportedErrorf("window function %s not supported", funcStr)
+		}
+		spec.WindowFunc = distsqlrun.WindowerSpec_WindowFunc(funcIdx).Enum()
+	}
+	return spec, nil
+}
+
+// addWindowers adds the stages of windowers computing the window functions of
+// a windowNode, followed by the rendering of the windowNode's columns.
+//
+// The window functions with the same PARTITION BY columns are computed by the
+// same stage. The input rows of a stage are hash-routed on the partition
+// columns, so each partition is computed by a single windower; window
+// functions without PARTITION BY are computed on a single node.
+func (dsp *distSQLPlanner) addWindowers(p *physicalPlan, n *windowNode) error {
+	// fnStreamCols holds the stream column of the result of each window
+	// function.
+	fnStreamCols := make([]int, len(n.funcs))
+	remaining := n.funcs
+	for len(remaining) > 0 {
+		partitionIdxs := remaining[0].partitionIdxs
+		spec := distsqlrun.WindowerSpec{PartitionBy: make([]uint32, len(partitionIdxs))}
+		for i, idx := range partitionIdxs {
+			spec.PartitionBy[i] = uint32(p.planToStreamColMap[idx])
+		}
+
+		// The windowers output their input columns followed by the results of
+		// their window functions.
+		outTypes := append([]sqlbase.ColumnType(nil), p.ResultTypes...)
+		var rest []*windowFuncHolder
+		for _, f := range remaining {
+			if !samePartitioning(f.partitionIdxs, partitionIdxs) {
+				rest = append(rest, f)
+				continue
+			}
+			fnSpec, err := windowFnSpec(f)
+			if err != nil {
+				return err
+			}
+			fnSpec.ArgCols = make([]uint32, f.argCount)
+			argTypes := make([]sqlbase.ColumnType, f.argCount)
+			for i := range fnSpec.ArgCols {
+				streamCol := p.planToStreamColMap[f.argIdxStart+i]
+				fnSpec.ArgCols[i] = uint32(streamCol)
+				argTypes[i] = p.ResultTypes[streamCol]
+			}
+			fnSpec.Ordering = dsp.convertOrdering(f.columnOrdering, p.planToStreamColMap)
+			_, retType, err := distsqlrun.GetWindowFunctionInfo(fnSpec, argTypes...)
+			if err != nil {
+				return err
+			}
+			fnStreamCols[f.funcIdx] = len(outTypes)
+			outTypes = append(outTypes, retType)
+			spec.WindowFns = append(spec.WindowFns, fnSpec)
+		}
+		dsp.addWindowerStage(p, &spec, outTypes)
+		remaining = rest
+	}
+
+	// Render the columns of the windowNode; the variables of the render
+	// expressions refer to the columns of the wrapped plan followed by the
+	// results of the window functions.
+	indexVarMap := append(append([]int(nil), p.planToStreamColMap...), fnStreamCols...)
+	renders := n.distSQLRenders()
+	p.AddRendering(renders, indexVarMap, getTypesForPlanResult(n, nil))
+	p.planToStreamColMap = identityMap(p.planToStreamColMap, len(renders))
+	return nil
+}
+
+// samePartitioning returns true if two window functions have the same
+// PARTITION BY columns.
+func samePartitioning(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addWindowerStage adds a stage of windowers to the plan. If the windowers
+// have partition columns and the previous stage has multiple result routers,
+// the rows are hash-routed on the partition columns to one windower on the
+// node of each result router; otherwise, a single windower is used.
+func (dsp *distSQLPlanner) addWindowerStage(
+	p *physicalPlan, spec *distsqlrun.WindowerSpec, outTypes []sqlbase.ColumnType,
+) {
+	core := distsqlrun.ProcessorCoreUnion{Windower: spec}
+	if len(spec.PartitionBy) == 0 || len(p.ResultRouters) == 1 {
+		// If the previous stage was all on a single node, put the windower
+		// there. Otherwise, bring the results back on this node.
+		node := p.Processors[p.ResultRouters[0]].Node
+		for _, resultProc := range p.ResultRouters[1:] {
+			if p.Processors[resultProc].Node != node {
+				node = dsp.nodeDesc.NodeID
+				break
+			}
+		}
+		p.AddSingleGroupStage(node, core, distsqlrun.PostProcessSpec{}, outTypes)
+		return
+	}
+
+	// Set up the output routers from the previous stage.
+	for _, resultProc := range p.ResultRouters {
+		p.Processors[resultProc].Spec.Output[0] = distsqlrun.OutputRouterSpec{
+			Type:        distsqlrun.OutputRouterSpec_BY_HASH,
+			HashColumns: spec.PartitionBy,
+		}
+	}
+
+	// We have one windower for each result router, like for the final stage of
+	// aggregation.
+	stageID := p.NewStageID()
+	pIdxStart := distsqlplan.ProcessorIdx(len(p.Processors))
+	for _, resultProc := range p.ResultRouters {
+		proc := distsqlplan.Processor{
+			Node: p.Processors[resultProc].Node,
+			Spec: distsqlrun.ProcessorSpec{
+				Input: []distsqlrun.InputSyncSpec{{
+					// The other fields will be filled in by mergeResultStreams.
+					ColumnTypes: p.ResultTypes,
+				}},
+				Core: core,
+				Output: []distsqlrun.OutputRouterSpec{{
+					Type: distsqlrun.OutputRouterSpec_PASS_THROUGH,
+				}},
+				StageID: stageID,
+			},
+		}
+		p.AddProcessor(proc)
+	}
+
+	// Connect the streams.
+	for bucket := 0; bucket < len(p.ResultRouters); bucket++ {
+		pIdx := pIdxStart + distsqlplan.ProcessorIdx(bucket)
+		p.MergeResultStreams(p.ResultRouters, bucket, distsqlrun.Ordering{}, pIdx, 0)
+	}
+
+	// Set the new result routers.
+	for i := 0; i < len(p.ResultRouters); i++ {
+		p.ResultRouters[i] = pIdxStart + distsqlplan.ProcessorIdx(i)
+	}
+	p.ResultTypes = outTypes
+	p.SetMergeOrdering(orderingTerminated)
+}
+
 func (dsp *distSQLPlanner) createPlanForIndexJoin(
 	planCtx *planningCtx, n *indexJoinNode,
 ) (physicalPlan, error) {
@@ -1263,6 +1789,157 @@ func getTypesForPlanResult(node planNode, planToStreamColMap []int) []sqlbase.Co
 	return types
 }
 
+// isSmallPlan returns true if a plan is expected to produce few rows: it
+// reads a constrained part of an index or it has a limit.
+func isSmallPlan(plan planNode) bool {
+	switch n := plan.(type) {
+	case *scanNode:
+		if n.hardLimit != 0 || n.softLimit != 0 {
+			return true
+		}
+		return !(len(n.spans) == 1 && n.spans[0].Equal(n.desc.IndexSpan(n.index.ID)))
+	case *indexJoinNode:
+		return isSmallPlan(n.index)
+	case *filterNode:
+		return isSmallPlan(n.source.plan)
+	case *renderNode:
+		return isSmallPlan(n.source.plan)
+	case *limitNode:
+		return true
+	}
+	return false
+}
+
+// lookupJoinScan returns the scanNode on the right side of a join if the join
+// is better planned as a lookup join, or nil otherwise. A lookup join is used
+// when:
+//  - the join is an inner or left outer join;
+//  - the left side is expected to produce few rows;
+//  - the right side is an unfiltered scan of a whole non-interleaved index;
+//  - the equality columns of the right side are a prefix of that index, with
+//    the same types as the equality columns of the left side.
+func lookupJoinScan(n *joinNode) *scanNode {
+	if n.joinType != joinTypeInner && n.joinType != joinTypeLeftOuter {
+		return nil
+	}
+	numEq := len(n.pred.leftEqualityIndices)
+	if numEq == 0 || !isSmallPlan(n.left.plan) {
+		return nil
+	}
+	scan, ok := n.right.plan.(*scanNode)
+	if !ok || scan.filter != nil || scan.hardLimit != 0 || scan.scanVisibility != publicColumns {
+		return nil
+	}
+	// The columns of the right side must be the columns of the table, which are
+	// the columns looked up by the lookup joiner.
+	if len(scan.cols) != len(scan.desc.Columns) {
+		return nil
+	}
+	if len(scan.spans) != 1 || !scan.spans[0].Equal(scan.desc.IndexSpan(scan.index.ID)) {
+		return nil
+	}
+	if len(scan.index.Interleave.Ancestors) > 0 || len(scan.index.ColumnIDs) < numEq {
+		return nil
+	}
+	leftCols := planColumns(n.left.plan)
+	for i, rightCol := range n.pred.rightEqualityIndices {
+		found := false
+		for _, id := range scan.index.ColumnIDs[:numEq] {
+			if scan.cols[rightCol].ID == id {
+				found = true
+				break
+			}
+		}
+		leftTyp := leftCols[n.pred.leftEqualityIndices[i]].Typ
+		if !found || !leftTyp.Equivalent(scan.resultColumns[rightCol].Typ) {
+			return nil
+		}
+	}
+	return scan
+}
+
+// createPlanForLookupJoin plans a join as a lookup join: a lookup joiner is
+// added after each result router of the left side and looks up the rows of the
+// right side in the index scanned by rightScan. See lookupJoinScan.
+func (dsp *distSQLPlanner) createPlanForLookupJoin(
+	planCtx *planningCtx, n *joinNode, rightScan *scanNode,
+) (physicalPlan, error) {
+	p, err := dsp.createPlanForNode(planCtx, n.left.plan)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+	tableSpec, _, err := initTableReaderSpec(rightScan)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+	spec := distsqlrun.LookupJoinerSpec{
+		Table:    rightScan.desc,
+		IndexIdx: tableSpec.IndexIdx,
+	}
+	switch n.joinType {
+	case joinTypeInner:
+		spec.Type = distsqlrun.JoinType_INNER
+	case joinTypeLeftOuter:
+		spec.Type = distsqlrun.JoinType_LEFT_OUTER
+	default:
+		panic(fmt.Sprintf("invalid lookup join type %d", n.joinType))
+	}
+
+	// The lookup columns are the left equality columns, in the order of the
+	// index columns they are matched with.
+	numEq := len(n.pred.leftEqualityIndices)
+	spec.LookupColumns = make([]uint32, numEq)
+	for i, id := range rightScan.index.ColumnIDs[:numEq] {
+		for j, rightCol := range n.pred.rightEqualityIndices {
+			if rightScan.cols[rightCol].ID == id {
+				leftCol := n.pred.leftEqualityIndices[j]
+				spec.LookupColumns[i] = uint32(p.planToStreamColMap[leftCol])
+				break
+			}
+		}
+	}
+
+	// The internal columns of the lookup joiner are the left stream columns
+	// followed by all the columns of the table; the columns of the scan are
+	// the columns of the table.
+	numLeftStreamCols := len(p.ResultTypes)
+	joinColMap := make([]int, 0, len(n.columns))
+	for i := 0; i < n.pred.numMergedEqualityColumns; i++ {
+		// For inner and left outer joins, the merged columns are equal to the
+		// left equality columns.
+		joinColMap = append(joinColMap, p.planToStreamColMap[n.pred.leftEqualityIndices[i]])
+	}
+	for i := 0; i < n.pred.numLeftCols; i++ {
+		joinColMap = append(joinColMap, p.planToStreamColMap[i])
+	}
+	for i := 0; i < n.pred.numRightCols; i++ {
+		joinColMap = append(joinColMap, numLeftStreamCols+i)
+	}
+	if n.pred.onCond != nil {
+		spec.OnExpr = distsqlplan.MakeExpression(n.pred.onCond, joinColMap)
+	}
+
+	post := distsqlrun.PostProcessSpec{
+		Projection: true,
+	}
+	joinToStreamColMap := makePlanToStreamColMap(len(n.columns))
+	for joinCol := range n.columns {
+		if !n.columns[joinCol].Omitted {
+			joinToStreamColMap[joinCol] = len(post.OutputColumns)
+			post.OutputColumns = append(post.OutputColumns, uint32(joinColMap[joinCol]))
+		}
+	}
+
+	p.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{LookupJoiner: &spec},
+		post,
+		getTypesForPlanResult(n, joinToStreamColMap),
+		orderingTerminated,
+	)
+	p.planToStreamColMap = joinToStreamColMap
+	return p, nil
+}
+
 func (dsp *distSQLPlanner) createPlanForJoin(
 	planCtx *planningCtx, n *joinNode,
 ) (physicalPlan, error) {
@@ -1285,6 +1962,14 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 	//    joiner.
 	//
 	//  - The routers of the joiner processors are the result routers of the plan.
+	//
+	// If the left side produces few rows and the right side is a scan of an
+	// index on the equality columns, we instead look up the matching rows of
+	// the right side for each left row (see createPlanForLookupJoin).
+
+	if rightScan := lookupJoinScan(n); rightScan != nil {
+		return dsp.createPlanForLookupJoin(planCtx, n, rightScan)
+	}
 
 	leftPlan, err := dsp.createPlanForNode(planCtx, n.left.plan)
 	if err != nil {
@@ -1418,6 +2103,24 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 		joinerSpec.OnExpr = distsqlplan.MakeExpression(n.pred.onCond, joinColMap)
 	}
 
+	// If the inputs are ordered on the equality columns, the planner chose a
+	// merge join; the joiners then read their inputs through ordered
+	// synchronizers.
+	var core distsqlrun.ProcessorCoreUnion
+	var leftOrdering, rightOrdering distsqlrun.Ordering
+	if len(n.mergeJoinOrdering) > 0 {
+		leftOrdering = distsqlEqualityOrdering(n.mergeJoinOrdering, joinerSpec.LeftEqColumns)
+		rightOrdering = distsqlEqualityOrdering(n.mergeJoinOrdering, joinerSpec.RightEqColumns)
+		core.MergeJoiner = &distsqlrun.MergeJoinerSpec{
+			LeftOrdering:  leftOrdering,
+			RightOrdering: rightOrdering,
+			OnExpr:        joinerSpec.OnExpr,
+			Type:          joinerSpec.Type,
+		}
+	} else {
+		core.HashJoiner = &joinerSpec
+	}
+
 	pIdxStart := distsqlplan.ProcessorIdx(len(p.Processors))
 	stageID := p.NewStageID()
 
@@ -1429,7 +2132,7 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 					{ColumnTypes: leftTypes},
 					{ColumnTypes: rightTypes},
 				},
-				Core:    distsqlrun.ProcessorCoreUnion{HashJoiner: &joinerSpec},
+				Core:    core,
 				Post:    post,
 				Output:  []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
 				StageID: stageID,
@@ -1449,7 +2152,7 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 						{ColumnTypes: leftTypes},
 						{ColumnTypes: rightTypes},
 					},
-					Core:    distsqlrun.ProcessorCoreUnion{HashJoiner: &joinerSpec},
+					Core:    core,
 					Post:    post,
 					Output:  []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
 					StageID: stageID,
@@ -1480,11 +2183,11 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 	for bucket := 0; bucket < len(nodes); bucket++ {
 		pIdx := pIdxStart + distsqlplan.ProcessorIdx(bucket)
 
-		// Connect left routers to the processor's first input. Currently the join
-		// node doesn't care about the orderings of the left and right results.
-		p.MergeResultStreams(leftRouters, bucket, distsqlrun.Ordering{}, pIdx, 0)
+		// Connect left routers to the processor's first input. The orderings are
+		// empty unless a merge joiner is used.
+		p.MergeResultStreams(leftRouters, bucket, leftOrdering, pIdx, 0)
 		// Connect right routers to the processor's second input.
-		p.MergeResultStreams(rightRouters, bucket, distsqlrun.Ordering{}, pIdx, 1)
+		p.MergeResultStreams(rightRouters, bucket, rightOrdering, pIdx, 1)
 
 		p.ResultRouters = append(p.ResultRouters, pIdx)
 	}
@@ -1495,6 +2198,25 @@ func (dsp *distSQLPlanner) createPlanForJoin(
 	return p, nil
 }
 
+// distsqlEqualityOrdering converts the ordering of a merge join, whose column
+// indices refer to equality columns, to an ordering of the stream columns of
+// one of its inputs.
+func distsqlEqualityOrdering(
+	mergeJoinOrdering sqlbase.ColumnOrdering, eqCols []uint32,
+) distsqlrun.Ordering {
+	ordering := distsqlrun.Ordering{
+		Columns: make([]distsqlrun.Ordering_Column, len(mergeJoinOrdering)),
+	}
+	for i, c := range mergeJoinOrdering {
+		ordering.Columns[i].ColIdx = eqCols[c.ColIdx]
+		ordering.Columns[i].Direction = distsqlrun.Ordering_Column_ASC
+		if c.Direction == encoding.Descending {
+			ordering.Columns[i].Direction = distsqlrun.Ordering_Column_DESC
+		}
+	}
+	return ordering
+}
+
 func (dsp *distSQLPlanner) createPlanForNode(
 	planCtx *planningCtx, node planNode,
 ) (physicalPlan, error) {
@@ -1528,6 +2250,18 @@ func (dsp *distSQLPlanner) createPlanForNode(
 
 		return plan, nil
 
+	case *windowNode:
+		plan, err := dsp.createPlanForNode(planCtx, n.plan)
+		if err != nil {
+			return physicalPlan{}, err
+		}
+
+		if err := dsp.addWindowers(&plan, n); err != nil {
+			return physicalPlan{}, err
+		}
+
+		return plan, nil
+
 	case *sortNode:
 		plan, err := dsp.createPlanForNode(planCtx, n.plan)
 		if err != nil {
@@ -1564,6 +2298,15 @@ func (dsp *distSQLPlanner) createPlanForNode(
 	case *distinctNode:
 		return dsp.createPlanForDistinct(planCtx, n)
 
+	case *unionNode:
+		return dsp.createPlanForSetOp(planCtx, n)
+
+	case *valuesNode:
+		return dsp.createPlanForValues(planCtx, n)
+
+	case *insertNode, *updateNode, *deleteNode:
+		return dsp.createPlanForMutation(planCtx, n)
+
 	default:
 		panic(fmt.Sprintf("unsupported node type %T", n))
 	}
@@ -1612,6 +2355,370 @@ func (dsp *distSQLPlanner) createPlanForDistinct(
 	return plan, nil
 }
 
+// createPlanForSetOp creates a physical plan for a UNION, INTERSECT or EXCEPT
+// operation.
+//
+// UNION ALL simply merges the result streams of the two sides. The other
+// operations are computed by processors that receive the rows of both sides;
+// when there are several of them, the rows are hash-routed on all the columns
+// so that equal rows are handled by the same processor. If both sides are
+// ordered on the same columns, the ordering is maintained.
+func (dsp *distSQLPlanner) createPlanForSetOp(
+	planCtx *planningCtx, n *unionNode,
+) (physicalPlan, error) {
+	leftPlan, err := dsp.createPlanForNode(planCtx, n.left)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+	rightPlan, err := dsp.createPlanForNode(planCtx, n.right)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+
+	// The processors compare whole rows, so the streams of both sides are
+	// projected onto the columns of the unionNode, in order.
+	numCols := len(planColumns(n))
+	commonOrdering := commonOrderingPrefix(
+		planOrdering(n.left).ordering, planOrdering(n.right).ordering,
+	)
+	for _, side := range []*physicalPlan{&leftPlan, &rightPlan} {
+		if err := dsp.projectOntoPlanColumns(side, numCols, commonOrdering); err != nil {
+			return physicalPlan{}, err
+		}
+	}
+	ordering := dsp.convertOrdering(commonOrdering, leftPlan.planToStreamColMap)
+
+	var p physicalPlan
+	var leftRouters, rightRouters []distsqlplan.ProcessorIdx
+	p.PhysicalPlan, leftRouters, rightRouters = distsqlplan.MergePlans(
+		&leftPlan.PhysicalPlan, &rightPlan.PhysicalPlan,
+	)
+	p.ResultTypes = leftPlan.ResultTypes
+	p.planToStreamColMap = leftPlan.planToStreamColMap
+
+	allCols := make([]uint32, numCols)
+	for i := range allCols {
+		allCols[i] = uint32(i)
+	}
+	orderedCols := make([]uint32, len(ordering.Columns))
+	for i, c := range ordering.Columns {
+		orderedCols[i] = c.ColIdx
+	}
+
+	var opType distsqlrun.AlgebraicSetOpSpec_SetOpType
+	switch n.emit.(type) {
+	case nil:
+		// UNION ALL.
+		p.ResultRouters = append(leftRouters, rightRouters...)
+		p.SetMergeOrdering(ordering)
+		// The last stages of the two sides can have different post-processing
+		// specs; add a stage that makes the result streams uniform.
+		p.AddNoGroupingStage(
+			distsqlrun.ProcessorCoreUnion{Noop: &distsqlrun.NoopCoreSpec{}},
+			distsqlrun.PostProcessSpec{},
+			p.ResultTypes,
+			ordering,
+		)
+		return p, nil
+	case unionNodeEmitDistinct:
+		dsp.addSetOpStage(&p, [][]distsqlplan.ProcessorIdx{append(leftRouters, rightRouters...)},
+			distsqlrun.ProcessorCoreUnion{Distinct: &distsqlrun.DistinctSpec{
+				OrderedColumns:  orderedCols,
+				DistinctColumns: allCols,
+			}},
+			ordering,
+		)
+		return p, nil
+	case intersectNodeEmitAll:
+		opType = distsqlrun.AlgebraicSetOpSpec_Intersect_all
+	case intersectNodeEmitDistinct:
+		opType = distsqlrun.AlgebraicSetOpSpec_Intersect
+	case exceptNodeEmitAll:
+		opType = distsqlrun.AlgebraicSetOpSpec_Except_all
+	case exceptNodeEmitDistinct:
+		opType = distsqlrun.AlgebraicSetOpSpec_Except
+	default:
+		panic(fmt.Sprintf("unsupported set operation %T", n.emit))
+	}
+	dsp.addSetOpStage(&p, [][]distsqlplan.ProcessorIdx{leftRouters, rightRouters},
+		distsqlrun.ProcessorCoreUnion{SetOp: &distsqlrun.AlgebraicSetOpSpec{
+			Ordering: ordering,
+			OpType:   opType,
+		}},
+		ordering,
+	)
+	return p, nil
+}
+
+// commonOrderingPrefix returns the longest common prefix of two orderings.
+func commonOrderingPrefix(a, b sqlbase.ColumnOrdering) sqlbase.ColumnOrdering {
+	i := 0
+	for ; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			break
+		}
+	}
+	return a[:i]
+}
+
+// projectOntoPlanColumns projects the result streams of a plan onto the first
+// numCols planNode columns, in order, after setting the merge ordering of the
+// plan to the given ordering (a prefix of the ordering of the planNode).
+func (dsp *distSQLPlanner) projectOntoPlanColumns(
+	p *physicalPlan, numCols int, planOrdering sqlbase.ColumnOrdering,
+) error {
+	columns := make([]uint32, numCols)
+	for i := range columns {
+		streamCol := p.planToStreamColMap[i]
+		if streamCol == -1 {
+			return errors.Errorf("column %d not produced by the plan", i)
+		}
+		columns[i] = uint32(streamCol)
+	}
+	p.SetMergeOrdering(dsp.convertOrdering(planOrdering, p.planToStreamColMap))
+	p.AddProjection(columns)
+	p.planToStreamColMap = identityMap(p.planToStreamColMap, numCols)
+	return nil
+}
+
+// addSetOpStage adds a stage of processors with the given core, one on each
+// node that produces rows for one of the inputs. Each input of the processors
+// is fed by a set of result routers; when there are several processors, the
+// routers hash the rows on all the columns. The inputs must be ordered
+// according to the given ordering, which is maintained by the processors.
+func (dsp *distSQLPlanner) addSetOpStage(
+	p *physicalPlan,
+	inputs [][]distsqlplan.ProcessorIdx,
+	core distsqlrun.ProcessorCoreUnion,
+	ordering distsqlrun.Ordering,
+) {
+	var nodes []roachpb.NodeID
+	seen := make(map[roachpb.NodeID]struct{})
+	for _, routers := range inputs {
+		for _, pIdx := range routers {
+			n := p.Processors[pIdx].Node
+			if _, ok := seen[n]; !ok {
+				seen[n] = struct{}{}
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	if len(nodes) > 1 {
+		hashCols := make([]uint32, len(p.ResultTypes))
+		for i := range hashCols {
+			hashCols[i] = uint32(i)
+		}
+		for _, routers := range inputs {
+			for _, pIdx := range routers {
+				p.Processors[pIdx].Spec.Output[0] = distsqlrun.OutputRouterSpec{
+					Type:        distsqlrun.OutputRouterSpec_BY_HASH,
+					HashColumns: hashCols,
+				}
+			}
+		}
+	}
+
+	stageID := p.NewStageID()
+	p.ResultRouters = make([]distsqlplan.ProcessorIdx, len(nodes))
+	for bucket, node := range nodes {
+		proc := distsqlplan.Processor{
+			Node: node,
+			Spec: distsqlrun.ProcessorSpec{
+				Input:   make([]distsqlrun.InputSyncSpec, len(inputs)),
+				Core:    core,
+				Output:  []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
+				StageID: stageID,
+			},
+		}
+		for i := range proc.Spec.Input {
+			proc.Spec.Input[i].ColumnTypes = p.ResultTypes
+		}
+		pIdx := p.AddProcessor(proc)
+		for i, routers := range inputs {
+			p.MergeResultStreams(routers, bucket, ordering, pIdx, i)
+		}
+		p.ResultRouters[bucket] = pIdx
+	}
+	p.SetMergeOrdering(ordering)
+}
+
+// createPlanForValues creates a physical plan for a VALUES clause: a single
+// processor on the gateway outputs the rows, which are evaluated during
+// planning.
+func (dsp *distSQLPlanner) createPlanForValues(
+	planCtx *planningCtx, n *valuesNode,
+) (physicalPlan, error) {
+	types := getTypesForPlanResult(n, nil)
+	spec := distsqlrun.ValuesCoreSpec{
+		Columns: make([]distsqlrun.DatumInfo, len(types)),
+	}
+	for i, t := range types {
+		spec.Columns[i].Encoding = sqlbase.DatumEncoding_VALUE
+		spec.Columns[i].Type = t
+	}
+
+	if err := n.Start(planCtx.ctx); err != nil {
+		return physicalPlan{}, err
+	}
+	defer n.Close(planCtx.ctx)
+
+	var a sqlbase.DatumAlloc
+	spec.RawBytes = make([][]byte, n.rows.Len())
+	for i := range spec.RawBytes {
+		var buf []byte
+		for j, d := range n.rows.At(i) {
+			var err error
+			buf, err = sqlbase.DatumToEncDatum(types[j], d).Encode(
+				&a, sqlbase.DatumEncoding_VALUE, buf,
+			)
+			if err != nil {
+				return physicalPlan{}, err
+			}
+		}
+		spec.RawBytes[i] = buf
+	}
+
+	var p physicalPlan
+	pIdx := p.AddProcessor(distsqlplan.Processor{
+		Node: dsp.nodeDesc.NodeID,
+		Spec: distsqlrun.ProcessorSpec{
+			Core:    distsqlrun.ProcessorCoreUnion{Values: &spec},
+			Output:  []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
+			StageID: p.NewStageID(),
+		},
+	})
+	p.ResultRouters = []distsqlplan.ProcessorIdx{pIdx}
+	p.ResultTypes = types
+	p.planToStreamColMap = identityMap(nil, len(types))
+	return p, nil
+}
+
+// createPlanForMutation plans the source of an INSERT, UPDATE or DELETE and
+// adds table writers after each of its result routers, so that the writes are
+// applied on the nodes that produce the rows.
+func (dsp *distSQLPlanner) createPlanForMutation(
+	planCtx *planningCtx, node planNode,
+) (physicalPlan, error) {
+	var en *editNodeBase
+	var source planNode
+	var spec distsqlrun.TableWriterSpec
+	switch n := node.(type) {
+	case *insertNode:
+		en, source = &n.editNodeBase, n.run.rows
+		spec.Type = distsqlrun.TableWriterSpec_INSERT
+		spec.InsertCols = columnIDs(n.insertCols)
+	case *updateNode:
+		en, source = &n.editNodeBase, n.run.rows
+		spec.Type = distsqlrun.TableWriterSpec_UPDATE
+		spec.FetchCols = columnIDs(n.tw.ru.FetchCols)
+		spec.UpdateCols = columnIDs(n.tw.ru.UpdateCols)
+	case *deleteNode:
+		en, source = &n.editNodeBase, n.run.rows
+		spec.Type = distsqlrun.TableWriterSpec_DELETE
+		spec.FetchCols = columnIDs(n.tw.rd.FetchCols)
+	default:
+		panic(fmt.Sprintf("unsupported mutation node %T", node))
+	}
+	spec.Table = *en.tableDesc
+	spec.Returning = en.rh.exprs != nil
+
+	plan, err := dsp.createPlanForNode(planCtx, source)
+	if err != nil {
+		return physicalPlan{}, err
+	}
+
+	// Arrange the source columns in the layout expected by the table writers.
+	switch n := node.(type) {
+	case *insertNode:
+		// The source may produce fewer columns than the inserted ones; the
+		// remaining columns get their default values.
+		srcCols := planColumns(source)
+		srcTypes := make([]sqlbase.ColumnType, len(srcCols))
+		for i := range srcCols {
+			srcTypes[i] = sqlbase.DatumTypeToColumnType(srcCols[i].Typ)
+		}
+		h := distsqlplan.MakeTypeIndexedVarHelper(srcTypes)
+		exprs := make([]parser.TypedExpr, len(n.insertCols))
+		types := make([]sqlbase.ColumnType, len(n.insertCols))
+		for i := range n.insertCols {
+			switch {
+			case i < len(srcCols):
+				exprs[i] = h.IndexedVar(i)
+			case n.defaultExprs == nil:
+				exprs[i] = parser.DNull
+			default:
+				exprs[i] = n.defaultExprs[i]
+			}
+			types[i] = n.insertCols[i].Type
+		}
+		plan.AddRendering(exprs, plan.planToStreamColMap, types)
+
+	case *updateNode:
+		fetchCols := n.tw.ru.FetchCols
+		cols := make([]uint32, 0, len(fetchCols)+len(n.tw.ru.UpdateCols))
+		for i := range fetchCols {
+			cols = append(cols, uint32(plan.planToStreamColMap[i]))
+		}
+		for _, slot := range n.sourceSlots {
+			cols = append(cols, uint32(plan.planToStreamColMap[slot.(scalarSlot).sourceIndex]))
+		}
+		plan.AddProjection(cols)
+
+	case *deleteNode:
+		fetchCols := n.tw.rd.FetchCols
+		cols := make([]uint32, len(fetchCols))
+		for i := range fetchCols {
+			cols[i] = uint32(plan.planToStreamColMap[i])
+		}
+		plan.AddProjection(cols)
+	}
+
+	var outTypes []sqlbase.ColumnType
+	if spec.Returning {
+		outTypes = make([]sqlbase.ColumnType, 0, len(en.tableDesc.Columns))
+		if spec.Type == distsqlrun.TableWriterSpec_INSERT {
+			for i := range en.tableDesc.Columns {
+				outTypes = append(outTypes, en.tableDesc.Columns[i].Type)
+			}
+		} else {
+			// The table writers output the new values of the fetched columns.
+			outTypes = append(outTypes, plan.ResultTypes[:len(spec.FetchCols)]...)
+		}
+	} else {
+		outTypes = []sqlbase.ColumnType{{Kind: sqlbase.ColumnType_INT}}
+	}
+	plan.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{TableWriter: &spec},
+		distsqlrun.PostProcessSpec{},
+		outTypes,
+		distsqlrun.Ordering{},
+	)
+	planCtx.writesAnchor = sqlbase.MakeIndexKeyPrefix(en.tableDesc, en.tableDesc.PrimaryIndex.ID)
+
+	if !spec.Returning {
+		plan.rowCounts = true
+		plan.planToStreamColMap = nil
+		return plan, nil
+	}
+	// The RETURNING expressions refer to the table columns, which come first
+	// in the output of the table writers.
+	plan.AddRendering(
+		en.rh.exprs, identityMap(nil, len(outTypes)), getTypesForPlanResult(node, nil),
+	)
+	plan.planToStreamColMap = identityMap(plan.planToStreamColMap, len(en.rh.exprs))
+	return plan, nil
+}
+
+// columnIDs returns the IDs of the given columns.
+func columnIDs(cols []sqlbase.ColumnDescriptor) []uint32 {
+	ids := make([]uint32, len(cols))
+	for i := range cols {
+		ids[i] = uint32(cols[i].ID)
+	}
+	return ids
+}
+
 func (dsp *distSQLPlanner) NewPlanningCtx(ctx context.Context, txn *client.Txn) planningCtx {
 	planCtx := planningCtx{
 		ctx:           ctx,
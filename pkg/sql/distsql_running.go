@@ -122,6 +122,7 @@ func (dsp *distSQLPlanner) Run(
 	log.VEvent(ctx, 1, "running DistSQL plan")
 
 	recv.resultToStreamColMap = plan.planToStreamColMap
+	recv.rowCounts = plan.rowCounts
 	thisNodeID := dsp.nodeDesc.NodeID
 
 	// DistSQL needs to initialize the Transaction proto before we put it in the
@@ -130,6 +131,14 @@ func (dsp *distSQLPlanner) Run(
 	// which normally does this init).
 	txn.EnsureProto()
 
+	if planCtx.writesAnchor != nil {
+		// The plan contains table writers; the transaction record needs to exist
+		// before they start writing on other nodes.
+		if err := txn.PrepareForRemoteWrites(ctx, planCtx.writesAnchor); err != nil {
+			return err
+		}
+	}
+
 	evalCtxProto := distsqlrun.MakeEvalContext(evalCtx)
 	for _, s := range evalCtx.SearchPath {
 		evalCtxProto.SearchPath = append(evalCtxProto.SearchPath, s)
@@ -147,10 +156,12 @@ func (dsp *distSQLPlanner) Run(
 			continue
 		}
 		req := &distsqlrun.SetupFlowRequest{
-			Version:     distsqlrun.Version,
-			Txn:         *txn.Proto(),
-			Flow:        flowSpec,
-			EvalContext: evalCtxProto,
+			Version:      distsqlrun.Version,
+			Txn:          *txn.Proto(),
+			Flow:         flowSpec,
+			EvalContext:  evalCtxProto,
+			CollectStats: planCtx.collectStats,
+			Vectorize:    planCtx.vectorize,
 		}
 		runReq := runnerRequest{
 			ctx:         ctx,
@@ -186,10 +197,12 @@ func (dsp *distSQLPlanner) Run(
 
 	// Set up the flow on this node.
 	localReq := distsqlrun.SetupFlowRequest{
-		Version:     distsqlrun.Version,
-		Txn:         *txn.Proto(),
-		Flow:        flows[thisNodeID],
-		EvalContext: evalCtxProto,
+		Version:      distsqlrun.Version,
+		Txn:          *txn.Proto(),
+		Flow:         flows[thisNodeID],
+		EvalContext:  evalCtxProto,
+		CollectStats: planCtx.collectStats,
+		Vectorize:    planCtx.vectorize,
 	}
 	ctx, flow, err := dsp.distSQLSrv.SetupSyncFlow(ctx, &localReq, recv)
 	if err != nil {
@@ -220,6 +233,9 @@ type distSQLReceiver struct {
 	resultToStreamColMap []int
 	// numRows counts the number of rows we received when rows is nil.
 	numRows int64
+	// rowCounts is set if the rows in the stream are row counts (produced by
+	// table writers) that need to be added up into numRows.
+	rowCounts bool
 
 	// err represents the error that we received either from a producer or
 	// internally in the operation of the distSQLReceiver. If set, this will
@@ -245,6 +261,10 @@ type distSQLReceiver struct {
 	// A handler for clock signals arriving from remote nodes. This should update
 	// this node's clock.
 	updateClock func(observedTs hlc.Timestamp)
+
+	// stats accumulates the execution statistics of the processors, when the
+	// flows collect them.
+	stats []distsqlrun.ProcessorStats
 }
 
 var _ distsqlrun.RowReceiver = &distSQLReceiver{}
@@ -285,9 +305,8 @@ func (r *distSQLReceiver) Push(
 				if retryErr, ok := meta.Err.(*roachpb.UnhandledRetryableError); ok {
 					// Update the txn in response to remote errors. In the non-DistSQL
 					// world, the TxnCoordSender does this, and the client.Txn updates
-					// itself in non-error cases. Those updates are not necessary if we're
-					// just doing reads. Once DistSQL starts performing writes, we'll need
-					// to perform such updates too.
+					// itself in non-error cases. The writes performed by table writers
+					// are reported separately, through the Writes metadata.
 					r.txn.UpdateStateOnRemoteRetryableErr(r.ctx, retryErr.PErr)
 					// Update the clock with information from the error. On non-DistSQL
 					// code paths, the DistSender does this.
@@ -306,6 +325,18 @@ func (r *distSQLReceiver) Push(
 				r.err = err
 			}
 		}
+		if meta.Stats != nil {
+			r.stats = append(r.stats, *meta.Stats)
+		}
+		if meta.Writes != nil && r.txn != nil {
+			// Inform the TxnCoordSender of the intents written remotely, so that
+			// they are resolved when the transaction finishes.
+			if err := r.txn.AugmentRemoteWrites(
+				r.ctx, meta.Writes.Txn, meta.Writes.IntentSpans,
+			); err != nil && r.err == nil {
+				r.err = err
+			}
+		}
 		return r.status
 	}
 	if r.err != nil {
@@ -316,6 +347,16 @@ func (r *distSQLReceiver) Push(
 		return r.status
 	}
 
+	if r.rowCounts {
+		// The row contains the number of rows affected by a table writer.
+		if err := row[0].EnsureDecoded(&r.alloc); err != nil {
+			r.err = err
+			r.status = distsqlrun.ConsumerClosed
+			return r.status
+		}
+		r.numRows += int64(parser.MustBeDInt(row[0].Datum))
+		return r.status
+	}
 	if r.rows == nil {
 		// We only need the row count.
 		r.numRows++
@@ -381,15 +422,18 @@ func (r *distSQLReceiver) updateCaches(ctx context.Context, ranges []roachpb.Ran
 // assumes that the tree is supported (see CheckSupport).
 //
 // Note that errors that happen while actually running the flow are reported to
-// recv, not returned by this function.
+// recv, not returned by this function. If vectorize is set, the processors
+// supporting it run with vectorized operators.
 func (dsp *distSQLPlanner) PlanAndRun(
 	ctx context.Context,
 	txn *client.Txn,
 	tree planNode,
 	recv *distSQLReceiver,
 	evalCtx parser.EvalContext,
+	vectorize bool,
 ) error {
 	planCtx := dsp.NewPlanningCtx(ctx, txn)
+	planCtx.vectorize = vectorize
 
 	log.VEvent(ctx, 1, "creating DistSQL plan")
 
@@ -34,6 +34,11 @@ import (
 // SpanResolver resolves key spans to their respective ranges and lease holders.
 // Used for planning physical execution of distributed SQL queries.
 //
+// Reads are planned on the lease holders even when they are historical. A
+// follower could only serve a read if it knew that no write at or below the
+// read timestamp can still commit on its range. That requires closed
+// timestamps, which the storage layer doesn't provide.
+//
 // Sample usage for resolving a bunch of spans:
 //
 // func resolveSpans(
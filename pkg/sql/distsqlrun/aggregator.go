@@ -17,6 +17,7 @@
 package distsqlrun
 
 import (
+	"bytes"
 	"strings"
 	"sync"
 	"unsafe"
@@ -24,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/pkg/errors"
@@ -84,19 +86,34 @@ func GetAggregateInfo(
 //
 // aggregator's output schema is comprised of what is specified by the
 // accompanying SELECT expressions.
+//
+// If the buckets don't fit in memory and the flow has temporary storage, the
+// rows which would need more memory are spilled to disk, sorted on the
+// grouping columns. Once the input is exhausted, the buckets without spilled
+// rows are emitted, releasing their memory, and the spilled rows are
+// aggregated one bucket at a time. The state of a single bucket, e.g. the
+// values seen by a DISTINCT aggregation, must still fit in memory.
 type aggregator struct {
 	flowCtx     *FlowCtx
 	input       RowSource
 	funcs       []*aggregateFuncHolder
 	outputTypes []sqlbase.ColumnType
 	datumAlloc  sqlbase.DatumAlloc
+	outputRow   sqlbase.EncDatumRow
 
 	bucketsAcc mon.BoundAccount
 
 	groupCols    columns
 	aggregations []AggregatorSpec_Aggregation
 
-	buckets map[string]struct{} // The set of bucket keys.
+	// buckets maps the bucket keys to the memory used by the buckets.
+	buckets map[string]int64
+
+	// spilledRows holds the input rows which didn't fit in memory. It is nil
+	// unless the aggregation spilled to disk. spilledBuckets is the set of
+	// buckets in memory which have rows on disk.
+	spilledRows    *diskRowContainer
+	spilledBuckets map[string]struct{}
 
 	out procOutputHelper
 }
@@ -115,9 +132,10 @@ func newAggregator(
 		input:        input,
 		groupCols:    spec.GroupCols,
 		aggregations: spec.Aggregations,
-		buckets:      make(map[string]struct{}),
+		buckets:      make(map[string]int64),
 		funcs:        make([]*aggregateFuncHolder, len(spec.Aggregations)),
 		outputTypes:  make([]sqlbase.ColumnType, len(spec.Aggregations)),
+		outputRow:    make(sqlbase.EncDatumRow, len(spec.Aggregations)),
 		bucketsAcc:   flowCtx.evalCtx.Mon.MakeBoundAccount(),
 	}
 
@@ -154,7 +172,7 @@ func newAggregator(
 
 		ag.funcs[i] = ag.newAggregateFuncHolder(aggConstructor)
 		if aggInfo.Distinct {
-			ag.funcs[i].seen = make(map[string]struct{})
+			ag.funcs[i].seen = make(map[string]map[string]struct{})
 		}
 
 		ag.outputTypes[i] = retType
@@ -178,6 +196,9 @@ func (ag *aggregator) Run(ctx context.Context, wg *sync.WaitGroup) {
 				aggFunc.Close(ctx)
 			}
 		}
+		if ag.spilledRows != nil {
+			ag.spilledRows.Close(ctx)
+		}
 	}()
 
 	ctx = log.WithLogTag(ctx, "Agg", nil)
@@ -196,40 +217,53 @@ func (ag *aggregator) Run(ctx context.Context, wg *sync.WaitGroup) {
 
 	log.VEvent(ctx, 1, "accumulation complete")
 
-	// Queries like `SELECT MAX(n) FROM t` expect a row of NULLs if nothing was
-	// aggregated.
-	if len(ag.buckets) < 1 && len(ag.groupCols) == 0 {
-		ag.buckets[""] = struct{}{}
+	if ag.spilledRows != nil {
+		// The buckets without rows on disk are complete; emitting them first
+		// releases the memory needed to aggregate the rows on disk.
+		for bucket := range ag.buckets {
+			if _, ok := ag.spilledBuckets[bucket]; !ok {
+				if !ag.emitBucket(ctx, bucket) {
+					return
+				}
+				ag.deleteBucket(ctx, bucket)
+			}
+		}
+		if !ag.aggregateSpilledRows(ctx) {
+			return
+		}
+	} else if len(ag.buckets) < 1 && len(ag.groupCols) == 0 {
+		// Queries like `SELECT MAX(n) FROM t` expect a row of NULLs if nothing was
+		// aggregated.
+		ag.buckets[""] = 0
 	}
 
 	// Render the results.
-	var consumerDone bool
-	row := make(sqlbase.EncDatumRow, len(ag.funcs))
 	for bucket := range ag.buckets {
-		for i, f := range ag.funcs {
-			result, err := f.get(bucket)
-			if err != nil {
-				DrainAndClose(ctx, ag.out.output, err, ag.input)
-				return
-			}
-			if result == nil {
-				// Special case useful when this is a local stage of a distributed
-				// aggregation.
-				result = parser.DNull
-			}
-			row[i] = sqlbase.DatumToEncDatum(ag.outputTypes[i], result)
+		if !ag.emitBucket(ctx, bucket) {
+			return
 		}
+	}
+	ag.out.close()
+}
 
-		consumerDone = !emitHelper(ctx, &ag.out, row, ProducerMetadata{})
-		if consumerDone {
-			break
+// emitBucket renders the results of a bucket and pushes them to the output.
+// It returns false if the output has been closed, because the consumer
+// doesn't need more rows or because of an error.
+func (ag *aggregator) emitBucket(ctx context.Context, bucket string) bool {
+	for i, f := range ag.funcs {
+		result, err := f.get(bucket)
+		if err != nil {
+			DrainAndClose(ctx, ag.out.output, err, ag.input)
+			return false
 		}
+		if result == nil {
+			// Special case useful when this is a local stage of a distributed
+			// aggregation.
+			result = parser.DNull
+		}
+		ag.outputRow[i] = sqlbase.DatumToEncDatum(ag.outputTypes[i], result)
 	}
-	// If the consumer has been found to be done, emitHelper() already closed the
-	// output.
-	if !consumerDone {
-		ag.out.close()
-	}
+	return emitHelper(ctx, &ag.out, ag.outputRow, ProducerMetadata{})
 }
 
 // accumulateRows reads and accumulates all input rows.
@@ -277,45 +311,204 @@ func (ag *aggregator) accumulateRows(ctx context.Context) (err error) {
 		if err != nil {
 			return err
 		}
+		scratch = encoded[:0]
 
-		if err := ag.bucketsAcc.Grow(ctx, int64(len(encoded))); err != nil {
+		// Once spilling, only the buckets already in memory are accumulated
+		// there, as long as they don't need more memory.
+		_, inMemory := ag.buckets[string(encoded)]
+		if ag.spilledRows == nil || inMemory {
+			err := ag.accumulateRow(ctx, encoded, row)
+			if err == nil {
+				continue
+			}
+			if ag.flowCtx.tempStorage == nil || !isMemoryBudgetError(err) {
+				return err
+			}
+		}
+		if err := ag.spillRow(ctx, encoded, row); err != nil {
 			return err
 		}
+	}
+}
 
-		ag.buckets[string(encoded)] = struct{}{}
-		// Feed the func holders for this bucket the non-grouping datums.
-		for i, a := range ag.aggregations {
-			if a.FilterColIdx != nil {
-				if err := row[*a.FilterColIdx].EnsureDecoded(&ag.datumAlloc); err != nil {
-					return err
-				}
-				if row[*a.FilterColIdx].Datum != parser.DBoolTrue {
-					// This row doesn't contribute to this aggregation.
-					continue
-				}
+// arg returns the datum of row fed to an aggregation, and false if the row
+// doesn't contribute to the aggregation.
+func (ag *aggregator) arg(
+	a AggregatorSpec_Aggregation, row sqlbase.EncDatumRow,
+) (parser.Datum, bool, error) {
+	if a.FilterColIdx != nil {
+		if err := row[*a.FilterColIdx].EnsureDecoded(&ag.datumAlloc); err != nil {
+			return nil, false, err
+		}
+		if row[*a.FilterColIdx].Datum != parser.DBoolTrue {
+			return nil, false, nil
+		}
+	}
+	var value parser.Datum
+	if len(a.ColIdx) != 0 {
+		c := a.ColIdx[0]
+		if err := row[c].EnsureDecoded(&ag.datumAlloc); err != nil {
+			return nil, false, err
+		}
+		value = row[c].Datum
+	}
+	return value, true, nil
+}
+
+// accumulateRow feeds the func holders of a bucket the non-grouping datums of
+// a row. The memory this requires is reserved before any func holder is fed,
+// so if it is refused, the row isn't accumulated at all.
+func (ag *aggregator) accumulateRow(
+	ctx context.Context, bucket []byte, row sqlbase.EncDatumRow,
+) error {
+	var usage int64
+	_, exists := ag.buckets[string(bucket)]
+	if !exists {
+		// TODO(radu): we should account for the size of the AggregateFuncs
+		// (this needs to be done in each aggregate constructor).
+		// TODO(radu): this model of each func having a map of buckets (one per
+		// group) for each func plus a global map is very wasteful. We should have a
+		// single map that stores all the AggregateFuncs.
+		usage += int64(len(bucket)) * int64(1+len(ag.funcs))
+		usage += sizeOfAggregateFunc * int64(len(ag.funcs))
+	}
+	for i, a := range ag.aggregations {
+		value, ok, err := ag.arg(a, row)
+		if err != nil {
+			return err
+		}
+		if ok {
+			funcUsage, err := ag.funcs[i].usage(bucket, value)
+			if err != nil {
+				return err
 			}
-			var value parser.Datum
-			if len(a.ColIdx) != 0 {
-				c := a.ColIdx[0]
-				if err := row[c].EnsureDecoded(&ag.datumAlloc); err != nil {
-					return err
-				}
-				value = row[c].Datum
+			usage += funcUsage
+		}
+	}
+	if err := ag.bucketsAcc.Grow(ctx, usage); err != nil {
+		return err
+	}
+
+	ag.buckets[string(bucket)] += usage
+	if !exists {
+		for _, f := range ag.funcs {
+			f.buckets[string(bucket)] = f.create(&ag.flowCtx.evalCtx)
+		}
+	}
+	for i, a := range ag.aggregations {
+		value, ok, err := ag.arg(a, row)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// This row doesn't contribute to this aggregation.
+			continue
+		}
+		if err := ag.funcs[i].add(ctx, bucket, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spillRow stores a row which doesn't fit in memory on disk, sorted on the
+// grouping columns.
+func (ag *aggregator) spillRow(ctx context.Context, bucket []byte, row sqlbase.EncDatumRow) error {
+	if ag.spilledRows == nil {
+		log.VEventf(ctx, 1, "spilling to disk after accumulating %d buckets", len(ag.buckets))
+		ordering := make(sqlbase.ColumnOrdering, len(ag.groupCols))
+		for i, c := range ag.groupCols {
+			ordering[i] = sqlbase.ColumnOrderInfo{ColIdx: int(c), Direction: encoding.Ascending}
+		}
+		d := makeDiskRowContainer(
+			ag.flowCtx.tempStorage, ag.flowCtx.tempStorageUsage, ordering, ag.input.Types(),
+		)
+		ag.spilledRows = &d
+		ag.spilledBuckets = make(map[string]struct{})
+	}
+	if _, ok := ag.buckets[string(bucket)]; ok {
+		ag.spilledBuckets[string(bucket)] = struct{}{}
+	}
+	return ag.spilledRows.AddRow(ctx, row)
+}
+
+// deleteBucket releases the state and the memory of a bucket which has been
+// emitted.
+func (ag *aggregator) deleteBucket(ctx context.Context, bucket string) {
+	for _, f := range ag.funcs {
+		f.deleteBucket(ctx, bucket)
+	}
+	ag.bucketsAcc.Shrink(ctx, ag.buckets[bucket])
+	delete(ag.buckets, bucket)
+}
+
+// aggregateSpilledRows aggregates the rows spilled to disk, which come out
+// grouped on the grouping columns, into the state the buckets accumulated in
+// memory, if any. Each bucket is emitted and released as soon as its last row
+// has been aggregated.
+// It returns false if the output has been closed.
+func (ag *aggregator) aggregateSpilledRows(ctx context.Context) bool {
+	// Rows whose grouping columns have the same key encoding can still have
+	// different value encodings, e.g. decimals 1.0 and 1.00, which belong to
+	// different buckets. The buckets of a key are all emitted once the key
+	// changes.
+	var key, keyScratch, bucket []byte
+	var pending []string
+	emitPending := func() bool {
+		for _, b := range pending {
+			if !ag.emitBucket(ctx, b) {
+				return false
 			}
-			if err := ag.funcs[i].add(ctx, encoded, value); err != nil {
-				return err
+			ag.deleteBucket(ctx, b)
+		}
+		pending = pending[:0]
+		return true
+	}
+
+	outputOpen := true
+	if err := ag.spilledRows.Iterate(func(row sqlbase.EncDatumRow) (bool, error) {
+		var err error
+		keyScratch, err = ag.encodeKey(keyScratch[:0], row)
+		if err != nil {
+			return true, err
+		}
+		if !bytes.Equal(keyScratch, key) {
+			if outputOpen = emitPending(); !outputOpen {
+				return true, nil
 			}
+			key = append(key[:0], keyScratch...)
 		}
-		scratch = encoded[:0]
+
+		bucket, err = ag.encode(bucket[:0], row)
+		if err != nil {
+			return true, err
+		}
+		isPending := false
+		for _, b := range pending {
+			if b == string(bucket) {
+				isPending = true
+				break
+			}
+		}
+		if !isPending {
+			pending = append(pending, string(bucket))
+		}
+		return false, ag.accumulateRow(ctx, bucket, row)
+	}); err != nil {
+		DrainAndClose(ctx, ag.out.output, err, ag.input)
+		return false
 	}
+	return outputOpen && emitPending()
 }
 
 type aggregateFuncHolder struct {
-	create        func(*parser.EvalContext) parser.AggregateFunc
-	group         *aggregator
-	buckets       map[string]parser.AggregateFunc
-	seen          map[string]struct{}
-	bucketsMemAcc *mon.BoundAccount
+	create  func(*parser.EvalContext) parser.AggregateFunc
+	group   *aggregator
+	buckets map[string]parser.AggregateFunc
+	// seen holds the encodings of the values aggregated for each bucket; it is
+	// only set for DISTINCT aggregations.
+	seen    map[string]map[string]struct{}
+	scratch []byte
 }
 
 const sizeOfAggregateFunc = int64(unsafe.Sizeof(parser.AggregateFunc(nil)))
@@ -324,46 +517,68 @@ func (ag *aggregator) newAggregateFuncHolder(
 	create func(*parser.EvalContext) parser.AggregateFunc,
 ) *aggregateFuncHolder {
 	return &aggregateFuncHolder{
-		create:        create,
-		group:         ag,
-		buckets:       make(map[string]parser.AggregateFunc),
-		bucketsMemAcc: &ag.bucketsAcc,
+		create:  create,
+		group:   ag,
+		buckets: make(map[string]parser.AggregateFunc),
 	}
 }
 
+// usage returns the amount of memory add requires to aggregate d to an
+// existing bucket.
+func (a *aggregateFuncHolder) usage(bucket []byte, d parser.Datum) (int64, error) {
+	if a.seen == nil {
+		return 0, nil
+	}
+	encoded, err := sqlbase.EncodeDatum(a.scratch[:0], d)
+	if err != nil {
+		return 0, err
+	}
+	a.scratch = encoded
+	seen, ok := a.seen[string(bucket)]
+	if _, dup := seen[string(encoded)]; dup {
+		// The value is skipped.
+		return 0, nil
+	}
+	usage := int64(len(encoded))
+	if !ok {
+		usage += int64(len(bucket))
+	}
+	return usage, nil
+}
+
+// add aggregates d to an existing bucket. The memory this requires, as
+// computed by usage, must have been accounted for.
 func (a *aggregateFuncHolder) add(ctx context.Context, bucket []byte, d parser.Datum) error {
 	if a.seen != nil {
-		encoded, err := sqlbase.EncodeDatum(bucket, d)
+		encoded, err := sqlbase.EncodeDatum(a.scratch[:0], d)
 		if err != nil {
 			return err
 		}
-		if _, ok := a.seen[string(encoded)]; ok {
+		a.scratch = encoded
+		seen, ok := a.seen[string(bucket)]
+		if !ok {
+			seen = make(map[string]struct{})
+			a.seen[string(bucket)] = seen
+		}
+		if _, ok := seen[string(encoded)]; ok {
 			// skip
 			return nil
 		}
-		if err := a.bucketsMemAcc.Grow(ctx, int64(len(encoded))); err != nil {
-			return err
-		}
-		a.seen[string(encoded)] = struct{}{}
+		seen[string(encoded)] = struct{}{}
 	}
 
-	impl, ok := a.buckets[string(bucket)]
-	if !ok {
-		// TODO(radu): we should account for the size of impl (this needs to be done
-		// in each aggregate constructor).
-		impl = a.create(&a.group.flowCtx.evalCtx)
-		usage := int64(len(bucket))
-		usage += sizeOfAggregateFunc
-		// TODO(radu): this model of each func having a map of buckets (one per
-		// group) for each func plus a global map is very wasteful. We should have a
-		// single map that stores all the AggregateFuncs.
-		if err := a.bucketsMemAcc.Grow(ctx, usage); err != nil {
-			return err
-		}
-		a.buckets[string(bucket)] = impl
-	}
+	return a.buckets[string(bucket)].Add(ctx, d)
+}
 
-	return impl.Add(ctx, d)
+// deleteBucket closes and removes the aggregation of a bucket.
+func (a *aggregateFuncHolder) deleteBucket(ctx context.Context, bucket string) {
+	if impl, ok := a.buckets[bucket]; ok {
+		impl.Close(ctx)
+		delete(a.buckets, bucket)
+	}
+	if a.seen != nil {
+		delete(a.seen, bucket)
+	}
 }
 
 func (a *aggregateFuncHolder) get(bucket string) (parser.Datum, error) {
@@ -388,3 +603,18 @@ func (ag *aggregator) encode(
 	}
 	return appendTo, nil
 }
+
+// encodeKey returns the key encoding of the grouping columns, on which the
+// rows spilled to disk are sorted.
+func (ag *aggregator) encodeKey(appendTo []byte, row sqlbase.EncDatumRow) ([]byte, error) {
+	for _, colIdx := range ag.groupCols {
+		var err error
+		appendTo, err = row[colIdx].Encode(
+			&ag.datumAlloc, sqlbase.DatumEncoding_ASCENDING_KEY, appendTo,
+		)
+		if err != nil {
+			return appendTo, err
+		}
+	}
+	return appendTo, nil
+}
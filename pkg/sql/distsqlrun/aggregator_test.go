@@ -23,8 +23,10 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -359,3 +361,81 @@ func TestAggregator(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregatorSpilling(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	tempEngine := engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */)
+	defer tempEngine.Close()
+
+	// The budget holds about a third of the buckets.
+	const numRows = 2000
+	const numBuckets = 500
+	const budget = 10 * 1024
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	types := []sqlbase.ColumnType{columnTypeInt, columnTypeInt}
+	input := make(sqlbase.EncDatumRows, numRows)
+	for i := range input {
+		input[i] = sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(i%numBuckets))),
+			sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(i%7))),
+		}
+	}
+
+	// SELECT @1, COUNT(@2), SUM(DISTINCT @2) GROUP BY @1.
+	spec := AggregatorSpec{
+		GroupCols: []uint32{0},
+		Aggregations: []AggregatorSpec_Aggregation{
+			{Func: AggregatorSpec_IDENT, ColIdx: []uint32{0}},
+			{Func: AggregatorSpec_COUNT, ColIdx: []uint32{1}},
+			{Func: AggregatorSpec_SUM, ColIdx: []uint32{1}, Distinct: true},
+		},
+	}
+
+	run := func(spill bool) []string {
+		in := NewRowBuffer(types, input, RowBufferArgs{})
+		out := &RowBuffer{}
+		evalCtx := parser.MakeTestingEvalContext()
+		defer evalCtx.Stop(ctx)
+		flowCtx := FlowCtx{evalCtx: evalCtx}
+		if spill {
+			monitor := makeTestingBudgetMonitor(ctx, budget)
+			defer monitor.Stop(ctx)
+			flowCtx.evalCtx.Mon = &monitor
+			flowCtx.tempStorage = tempEngine
+		}
+
+		ag, err := newAggregator(&flowCtx, &spec, in, &PostProcessSpec{}, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ag.Run(ctx, nil)
+		if spilled := ag.spilledRows != nil; spilled != spill {
+			t.Fatalf("expected spilled=%t, got %t", spill, spilled)
+		}
+
+		var rows []string
+		for {
+			row, meta := out.Next()
+			if !meta.Empty() {
+				t.Fatalf("unexpected metadata: %v", meta)
+			}
+			if row == nil {
+				break
+			}
+			rows = append(rows, row.String())
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	expected := run(false /* spill */)
+	if len(expected) != numBuckets {
+		t.Fatalf("expected %d rows, got %d", numBuckets, len(expected))
+	}
+	if rows := run(true /* spill */); strings.Join(rows, "") != strings.Join(expected, "") {
+		t.Errorf("invalid results; expected:\n   %s\ngot:\n   %s", expected, rows)
+	}
+}
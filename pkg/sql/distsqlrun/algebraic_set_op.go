@@ -27,8 +27,8 @@ import (
 	"golang.org/x/net/context"
 )
 
-// algebraicSetOp is a processor for the algebraic set operations,
-// currently just EXCEPT ALL.
+// algebraicSetOp is a processor for the algebraic set operations EXCEPT and
+// INTERSECT.
 type algebraicSetOp struct {
 	leftSource, rightSource RowSource
 	opType                  AlgebraicSetOpSpec_SetOpType
@@ -51,10 +51,12 @@ func newAlgebraicSetOp(
 		rightSource: rightSource,
 		ordering:    spec.Ordering,
 		opType:      spec.OpType,
+		datumAlloc:  &sqlbase.DatumAlloc{},
 	}
 
 	switch spec.OpType {
-	case AlgebraicSetOpSpec_Except_all:
+	case AlgebraicSetOpSpec_Except_all, AlgebraicSetOpSpec_Intersect_all,
+		AlgebraicSetOpSpec_Except, AlgebraicSetOpSpec_Intersect:
 		break
 	default:
 		return nil, errors.Errorf("cannot create algebraicSetOp for unsupported algebraicSetOpType %v", e.opType)
@@ -88,33 +90,56 @@ func (e *algebraicSetOp) Run(ctx context.Context, wg *sync.WaitGroup) {
 		defer wg.Done()
 	}
 
-	ctx = log.WithLogTag(ctx, "ExceptAll", nil)
-	ctx, span := tracing.ChildSpan(ctx, "exceptAll")
+	ctx = log.WithLogTag(ctx, e.opType.String(), nil)
+	ctx, span := tracing.ChildSpan(ctx, "algebraic set op")
 	defer tracing.FinishSpan(span)
 
-	log.VEventf(ctx, 2, "starting exceptAll set process")
-	defer log.VEventf(ctx, 2, "exiting exceptAll")
+	log.VEventf(ctx, 2, "starting %s set process", e.opType)
+	defer log.VEventf(ctx, 2, "exiting %s", e.opType)
 
 	defer e.leftSource.ConsumerDone()
 	defer e.rightSource.ConsumerDone()
 
+	if err := e.setOp(ctx); err != nil {
+		e.out.output.Push(nil, ProducerMetadata{Err: err})
+	}
+	e.leftSource.ConsumerClosed()
+	e.rightSource.ConsumerClosed()
+	e.out.close()
+}
+
+// emitLeft decides whether a left row is emitted, given the number of
+// occurrences of the row in the right stream that haven't been matched yet.
+// It returns the new number of unmatched occurrences; the distinct variants
+// set it to -1 once the row has been handled, so that its duplicates are not
+// emitted.
+func (e *algebraicSetOp) emitLeft(rightCount int) (emit bool, newCount int) {
 	switch e.opType {
 	case AlgebraicSetOpSpec_Except_all:
-		if err := e.exceptAll(ctx); err != nil {
-			e.out.output.Push(nil, ProducerMetadata{Err: err})
+		if rightCount > 0 {
+			return false, rightCount - 1
 		}
-
+		return true, rightCount
+	case AlgebraicSetOpSpec_Intersect_all:
+		if rightCount > 0 {
+			return true, rightCount - 1
+		}
+		return false, rightCount
+	case AlgebraicSetOpSpec_Except:
+		return rightCount == 0, -1
+	case AlgebraicSetOpSpec_Intersect:
+		return rightCount > 0, -1
 	default:
 		panic(fmt.Sprintf("cannot run unsupported algebraicSetOp %v", e.opType))
 	}
-	e.leftSource.ConsumerClosed()
-	e.rightSource.ConsumerClosed()
-	e.out.close()
 }
 
-// exceptAll pushes all rows in the left stream that are not present in the
-// right stream. It does not remove duplicates.
-func (e *algebraicSetOp) exceptAll(ctx context.Context) error {
+// setOp pushes the rows of the left stream that are emitted according to the
+// rows of the right stream: EXCEPT ALL pushes the left rows that don't have a
+// matching right row, each right row matching at most one left row, while
+// INTERSECT ALL pushes the left rows that have one. EXCEPT and INTERSECT
+// additionally remove duplicates.
+func (e *algebraicSetOp) setOp(ctx context.Context) error {
 	leftGroup := makeStreamGroupAccumulator(
 		MakeNoMetadataRowSource(e.leftSource, e.out.output),
 		convertToColumnOrdering(e.ordering),
@@ -134,100 +159,68 @@ func (e *algebraicSetOp) exceptAll(ctx context.Context) error {
 		return err
 	}
 
-	// We iterate in lockstep through the groups of rows given equalilty under
-	// the common source ordering. Whenever we find a left group without a match
-	// on the right, it's easy - we output the full group. Whenever we find a
-	// group on the right without a match on the left, we ignore it. Whenever
-	// we find matching groups, we generate a hashMap of the right group and
-	// check the left group against the hashMap.
+	allCols := make(columns, len(e.leftSource.Types()))
+	for i := range allCols {
+		allCols[i] = uint32(i)
+	}
+	var scratch []byte
+
+	// We iterate in lockstep through the groups of rows given equality under
+	// the common source ordering. Whenever we find a group on the right without
+	// a match on the left, we ignore it. Whenever we find a left group, we
+	// generate a map of the number of occurrences of each row of the matching
+	// right group (if any) and check the left group against the map.
 	// TODO(arjun): if groups are large and we have a limit, we might want to
 	// stream through the leftGroup instead of accumulating it all.
-	for {
-		// If we exhause all left rows, we are done.
-		if len(leftRows) == 0 {
-			return nil
-		}
-		// If we exhause all right rows, we can emit all left rows.
-		if len(rightRows) == 0 {
-			break
-		}
-
-		cmp, err := CompareEncDatumRowForMerge(leftRows[0], rightRows[0],
-			convertToColumnOrdering(e.ordering), convertToColumnOrdering(e.ordering),
-			e.datumAlloc,
-		)
-		if err != nil {
-			return err
-		}
-		if cmp == 0 {
-			var scratch []byte
-			rightMap := make(map[string]struct{}, len(rightRows))
-			allRightCols := make(columns, len(e.rightSource.Types()))
-			for i := range e.rightSource.Types() {
-				allRightCols[i] = uint32(i)
-			}
-			for _, encDatumRow := range rightRows {
-				encoded, _, err := encodeColumnsOfRow(e.datumAlloc, scratch, encDatumRow, allRightCols, true /* encodeNull */)
-				if err != nil {
-					return err
-				}
-				scratch = encoded[:0]
-				rightMap[string(encoded)] = struct{}{}
-			}
-			for _, encDatumRow := range leftRows {
-				encoded, _, err := encodeColumnsOfRow(e.datumAlloc, scratch, encDatumRow, allRightCols, true /* encodeNull */)
-				if err != nil {
-					return err
-				}
-				scratch = encoded[:0]
-				if _, ok := rightMap[string(encoded)]; !ok {
-					status, err := e.out.emitRow(ctx, encDatumRow)
-					if status == ConsumerClosed {
-						return nil
-					}
-					if err != nil {
-						return err
-					}
-				}
-			}
-			leftRows, err = leftGroup.advanceGroup()
+	for len(leftRows) > 0 {
+		cmp := -1
+		if len(rightRows) > 0 {
+			cmp, err = CompareEncDatumRowForMerge(leftRows[0], rightRows[0],
+				convertToColumnOrdering(e.ordering), convertToColumnOrdering(e.ordering),
+				e.datumAlloc,
+			)
 			if err != nil {
 				return err
 			}
+		}
+		if cmp > 0 {
 			rightRows, err = rightGroup.advanceGroup()
 			if err != nil {
 				return err
 			}
+			continue
 		}
-		if cmp < 0 {
-			for _, encDatumRow := range leftRows {
-				status, err := e.out.emitRow(ctx, encDatumRow)
-				if status == ConsumerClosed {
-					return nil
-				}
+
+		rightCounts := make(map[string]int)
+		if cmp == 0 {
+			for _, encDatumRow := range rightRows {
+				encoded, _, err := encodeColumnsOfRow(
+					e.datumAlloc, scratch, encDatumRow, allCols, true, /* encodeNull */
+				)
 				if err != nil {
 					return err
 				}
+				scratch = encoded[:0]
+				rightCounts[string(encoded)]++
 			}
-			leftRows, err = leftGroup.advanceGroup()
+			rightRows, err = rightGroup.advanceGroup()
 			if err != nil {
 				return err
 			}
 		}
-		if cmp > 0 {
-			rightRows, err = rightGroup.advanceGroup()
-			if len(rightRows) == 0 {
-				break
-			}
+		for _, encDatumRow := range leftRows {
+			encoded, _, err := encodeColumnsOfRow(
+				e.datumAlloc, scratch, encDatumRow, allCols, true, /* encodeNull */
+			)
 			if err != nil {
 				return err
 			}
-		}
-	}
-
-	if len(rightRows) == 0 {
-		// Emit all accumulated left rows.
-		for _, encDatumRow := range leftRows {
+			scratch = encoded[:0]
+			emit, newCount := e.emitLeft(rightCounts[string(encoded)])
+			rightCounts[string(encoded)] = newCount
+			if !emit {
+				continue
+			}
 			status, err := e.out.emitRow(ctx, encDatumRow)
 			if status == ConsumerClosed {
 				return nil
@@ -236,27 +229,14 @@ func (e *algebraicSetOp) exceptAll(ctx context.Context) error {
 				return err
 			}
 		}
-
-		// Emit all remaining rows.
-		for {
-			leftRows, err = leftGroup.advanceGroup()
-			// Emit all left rows until completion/error.
-			if err != nil || len(leftRows) == 0 {
-				return err
-			}
-			for _, row := range leftRows {
-				status, err := e.out.emitRow(ctx, row)
-				if status == ConsumerClosed {
-					return nil
-				}
-				if err != nil {
-					return err
-				}
-			}
+		leftRows, err = leftGroup.advanceGroup()
+		if err != nil {
+			return err
 		}
 	}
+
 	if !leftGroup.srcConsumed {
-		return errors.Errorf("exceptAll finished but leftGroup not consumed")
+		return errors.Errorf("%s finished but leftGroup not consumed", e.opType)
 	}
 	return nil
 }
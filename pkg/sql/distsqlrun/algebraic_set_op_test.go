@@ -199,3 +199,62 @@ func TestExceptAll(t *testing.T) {
 		}
 	}
 }
+
+func TestAlgebraicSetOpTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	v := initTestData().v
+	ordered := Ordering{
+		Columns: []Ordering_Column{{ColIdx: 0, Direction: Ordering_Column_ASC}},
+	}
+	unorderedLeft := sqlbase.EncDatumRows{{v[1]}, {v[1]}, {v[1]}, {v[2]}, {v[2]}, {v[3]}}
+	unorderedRight := sqlbase.EncDatumRows{{v[1]}, {v[3]}, {v[1]}, {v[4]}}
+	orderedLeft := sqlbase.EncDatumRows{{v[1]}, {v[1]}, {v[2]}, {v[3]}, {v[3]}}
+	orderedRight := sqlbase.EncDatumRows{{v[1]}, {v[3]}, {v[3]}, {v[3]}, {v[5]}}
+
+	testCases := []testCase{
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Except_all},
+			inputLeft:  unorderedLeft,
+			inputRight: unorderedRight,
+			expected:   sqlbase.EncDatumRows{{v[1]}, {v[2]}, {v[2]}},
+		},
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Intersect_all},
+			inputLeft:  unorderedLeft,
+			inputRight: unorderedRight,
+			expected:   sqlbase.EncDatumRows{{v[1]}, {v[1]}, {v[3]}},
+		},
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Except},
+			inputLeft:  unorderedLeft,
+			inputRight: unorderedRight,
+			expected:   sqlbase.EncDatumRows{{v[2]}},
+		},
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Intersect},
+			inputLeft:  unorderedLeft,
+			inputRight: unorderedRight,
+			expected:   sqlbase.EncDatumRows{{v[1]}, {v[3]}},
+		},
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Intersect_all, Ordering: ordered},
+			inputLeft:  orderedLeft,
+			inputRight: orderedRight,
+			expected:   sqlbase.EncDatumRows{{v[1]}, {v[3]}, {v[3]}},
+		},
+		{
+			spec:       AlgebraicSetOpSpec{OpType: AlgebraicSetOpSpec_Except, Ordering: ordered},
+			inputLeft:  orderedLeft,
+			inputRight: orderedRight,
+			expected:   sqlbase.EncDatumRows{{v[2]}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec.OpType.String(), func(t *testing.T) {
+			if _, err := runProcessors(tc); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
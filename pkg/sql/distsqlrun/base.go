@@ -262,11 +262,18 @@ type ProducerMetadata struct {
 	Ranges []roachpb.RangeInfo
 	// TODO(vivek): change to type Error
 	Err error
+	// Stats are the execution statistics of a processor, sent when the flow
+	// collects statistics.
+	Stats *ProcessorStats
+	// Writes describe the intents written by a processor on behalf of the
+	// flow's transaction; they need to be forwarded to the gateway's
+	// TxnCoordSender.
+	Writes *RemoteWrites
 }
 
 // Empty returns true if none of the fields in metadata are populated.
 func (meta ProducerMetadata) Empty() bool {
-	return meta.Ranges == nil && meta.Err == nil
+	return meta.Ranges == nil && meta.Err == nil && meta.Stats == nil && meta.Writes == nil
 }
 
 // RowChannel is a thin layer over a RowChannelMsg channel, which can be used to
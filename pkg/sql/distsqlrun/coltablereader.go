@@ -0,0 +1,142 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// colTableReader is the vectorized version of the tableReader: it reads the
+// rows of a table and produces batches with one column per column of the
+// table. Only the needed columns are set.
+type colTableReader struct {
+	flowCtx *FlowCtx
+	ctx     context.Context
+
+	spans     roachpb.Spans
+	limitHint int64
+	fetcher   sqlbase.RowFetcher
+
+	types  []exec.T
+	needed []bool
+	batch  exec.Batch
+}
+
+var _ exec.Operator = &colTableReader{}
+var _ metadataSource = &colTableReader{}
+
+// newColTableReader creates a colTableReader. The columns which are not
+// needed can be of any type; they get a placeholder type in the batches.
+func newColTableReader(
+	flowCtx *FlowCtx, spec *TableReaderSpec, post *PostProcessSpec, needed []bool,
+) (*colTableReader, error) {
+	if flowCtx.nodeID == 0 {
+		return nil, errors.Errorf("attempting to create a colTableReader with uninitialized NodeID")
+	}
+	tr := &colTableReader{
+		flowCtx:   flowCtx,
+		limitHint: tableReaderLimitHint(spec, post),
+		types:     make([]exec.T, len(spec.Table.Columns)),
+		needed:    needed,
+	}
+	for i, col := range spec.Table.Columns {
+		tr.types[i] = exec.Bool
+		if needed[i] {
+			tr.types[i] = exec.FromColumnType(col.Type)
+			if tr.types[i] == exec.Unhandled {
+				return nil, errors.Errorf("unhandled type %s", col.Type.SQLString())
+			}
+		}
+	}
+
+	desc := spec.Table
+	if _, _, err := initRowFetcher(
+		&tr.fetcher, &desc, int(spec.IndexIdx), spec.Reverse, needed,
+	); err != nil {
+		return nil, err
+	}
+
+	tr.spans = make(roachpb.Spans, len(spec.Spans))
+	for i, s := range spec.Spans {
+		tr.spans[i] = s.Span
+	}
+	return tr, nil
+}
+
+// start is part of the metadataSource interface.
+func (tr *colTableReader) start(ctx context.Context) {
+	tr.ctx = ctx
+}
+
+// Init is part of the exec.Operator interface.
+func (tr *colTableReader) Init() {
+	tr.batch = exec.NewMemBatch(tr.types)
+	txn := tr.flowCtx.setupTxn()
+	if err := tr.fetcher.StartScan(
+		tr.ctx, txn, tr.spans, true /* limit batches */, tr.limitHint,
+	); err != nil {
+		exec.PanicError(err)
+	}
+}
+
+// Next is part of the exec.Operator interface.
+func (tr *colTableReader) Next() exec.Batch {
+	tr.batch.SetSelection(false)
+	for j := range tr.types {
+		tr.batch.ColVec(j).UnsetNulls()
+	}
+	n := 0
+	for n < exec.BatchSize {
+		// TODO(radu,andrei,knz): set the traceKV flag when requested by the session.
+		row, err := tr.fetcher.NextRowDecoded(tr.ctx, false /* traceKV */)
+		if err != nil {
+			exec.PanicError(err)
+		}
+		if row == nil {
+			break
+		}
+		for j, d := range row {
+			if tr.needed[j] {
+				setVecDatum(tr.batch.ColVec(j), n, d)
+			}
+		}
+		n++
+	}
+	tr.batch.SetLength(uint16(n))
+	return tr.batch
+}
+
+// drainMeta is part of the metadataSource interface. Like the tableReader,
+// the colTableReader sends information about the non-local ranges it read
+// once it is done.
+func (tr *colTableReader) drainMeta(done bool) []ProducerMetadata {
+	if !done || tr.ctx == nil {
+		return nil
+	}
+	if ranges := misplannedRanges(tr.ctx, &tr.fetcher, tr.flowCtx.nodeID); len(ranges) != 0 {
+		return []ProducerMetadata{{Ranges: ranges}}
+	}
+	return nil
+}
+
+// kvStats is part of the kvStatsReporter interface.
+func (tr *colTableReader) kvStats() (requests int64, bytesRead int64) {
+	return tr.fetcher.KVStats()
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// columnarizer is a vectorized operator reading the rows of a RowSource and
+// converting them into batches. It allows the vectorized operators to consume
+// the output of row processors. The metadata read from the input is buffered
+// until the materializer pushes it to its output.
+type columnarizer struct {
+	input RowSource
+	types []exec.T
+
+	batch exec.Batch
+	meta  []ProducerMetadata
+	da    sqlbase.DatumAlloc
+}
+
+var _ exec.Operator = &columnarizer{}
+var _ metadataSource = &columnarizer{}
+
+// newColumnarizer creates a columnarizer; it returns an error if the types
+// of the input can't be represented in column vectors.
+func newColumnarizer(input RowSource) (*columnarizer, error) {
+	types, err := exec.FromColumnTypes(input.Types())
+	if err != nil {
+		return nil, err
+	}
+	return &columnarizer{input: input, types: types}, nil
+}
+
+// Init is part of the exec.Operator interface.
+func (c *columnarizer) Init() {
+	c.batch = exec.NewMemBatch(c.types)
+}
+
+// Next is part of the exec.Operator interface.
+func (c *columnarizer) Next() exec.Batch {
+	c.batch.SetSelection(false)
+	// Only the input columns are set; operators can append columns to the
+	// batch.
+	for j := range c.types {
+		c.batch.ColVec(j).UnsetNulls()
+	}
+	n := 0
+	for n < exec.BatchSize {
+		row, meta := c.input.Next()
+		if !meta.Empty() {
+			c.meta = append(c.meta, meta)
+			continue
+		}
+		if row == nil {
+			break
+		}
+		for j := range c.types {
+			if err := row[j].EnsureDecoded(&c.da); err != nil {
+				exec.PanicError(err)
+			}
+			setVecDatum(c.batch.ColVec(j), n, row[j].Datum)
+		}
+		n++
+	}
+	c.batch.SetLength(uint16(n))
+	return c.batch
+}
+
+// start is part of the metadataSource interface.
+func (c *columnarizer) start(context.Context) {}
+
+// drainMeta is part of the metadataSource interface.
+func (c *columnarizer) drainMeta(bool) []ProducerMetadata {
+	meta := c.meta
+	c.meta = nil
+	return meta
+}
+
+// setVecDatum sets the value at position i of a column vector to a datum of
+// the corresponding type.
+func setVecDatum(vec exec.ColVec, i int, d parser.Datum) {
+	if d == parser.DNull {
+		vec.SetNull(i)
+		return
+	}
+	switch t := parser.UnwrapDatum(d).(type) {
+	case *parser.DBool:
+		vec.Bool()[i] = bool(*t)
+	case *parser.DString:
+		vec.Bytes()[i] = []byte(*t)
+	case *parser.DBytes:
+		vec.Bytes()[i] = []byte(*t)
+	case *parser.DDecimal:
+		vec.Decimal()[i].Set(&t.Decimal)
+	case *parser.DInt:
+		vec.Int64()[i] = int64(*t)
+	case *parser.DFloat:
+		vec.Float64()[i] = float64(*t)
+	default:
+		panic(fmt.Sprintf("unhandled datum %s of type %T", d, d))
+	}
+}
+
+// vecDatum returns the value at position i of a column vector as a datum of
+// the given column type.
+func vecDatum(
+	vec exec.ColVec, i int, typ sqlbase.ColumnType, da *sqlbase.DatumAlloc,
+) parser.Datum {
+	if vec.NullAt(i) {
+		return parser.DNull
+	}
+	switch typ.Kind {
+	case sqlbase.ColumnType_BOOL:
+		return parser.MakeDBool(parser.DBool(vec.Bool()[i]))
+	case sqlbase.ColumnType_STRING:
+		return da.NewDString(parser.DString(vec.Bytes()[i]))
+	case sqlbase.ColumnType_NAME:
+		return da.NewDName(parser.DString(vec.Bytes()[i]))
+	case sqlbase.ColumnType_BYTES:
+		return da.NewDBytes(parser.DBytes(vec.Bytes()[i]))
+	case sqlbase.ColumnType_DECIMAL:
+		d := da.NewDDecimal(parser.DDecimal{})
+		d.Set(&vec.Decimal()[i])
+		return d
+	case sqlbase.ColumnType_INT:
+		return da.NewDInt(parser.DInt(vec.Int64()[i]))
+	case sqlbase.ColumnType_FLOAT:
+		return da.NewDFloat(parser.DFloat(vec.Float64()[i]))
+	default:
+		panic(fmt.Sprintf("unhandled type %s", typ.SQLString()))
+	}
+}
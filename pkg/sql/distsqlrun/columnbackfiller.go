@@ -35,6 +35,9 @@ type columnBackfiller struct {
 	// updateCols is a slice of all column descriptors that are being modified.
 	updateCols  []sqlbase.ColumnDescriptor
 	updateExprs []parser.TypedExpr
+	// computedCols holds the expressions of the computed columns being added,
+	// which are part of updateExprs and are evaluated on the fetched rows.
+	computedCols *sqlbase.ComputedColumns
 }
 
 var _ processor = &columnBackfiller{}
@@ -86,7 +89,7 @@ func (cb *columnBackfiller) init() error {
 				case sqlbase.DescriptorMutation_ADD:
 					desc := *m.GetColumn()
 					cb.added = append(cb.added, desc)
-					if desc.DefaultExpr == nil && !desc.Nullable {
+					if desc.DefaultExpr == nil && !desc.IsComputed() && !desc.Nullable {
 						addingNonNullableColumn = true
 					}
 				case sqlbase.DescriptorMutation_DROP:
@@ -100,12 +103,28 @@ func (cb *columnBackfiller) init() error {
 		return err
 	}
 
+	colIdxMap = make(map[sqlbase.ColumnID]int, len(desc.Columns))
+	for i, c := range desc.Columns {
+		colIdxMap[c.ID] = i
+	}
+	// The values of the computed columns are computed from the fetched
+	// values of the other columns.
+	cb.computedCols, err = sqlbase.MakeComputedColumns(&desc, cb.added, colIdxMap)
+	if err != nil {
+		return err
+	}
+
 	cb.updateCols = append(cb.added, cb.dropped...)
-	if len(cb.dropped) > 0 || addingNonNullableColumn || len(defaultExprs) > 0 {
-		// Populate default values.
+	if len(cb.dropped) > 0 || addingNonNullableColumn || len(defaultExprs) > 0 ||
+		cb.computedCols != nil {
+		// Populate default and computed values.
 		cb.updateExprs = make([]parser.TypedExpr, len(cb.updateCols))
+		computedIdx := 0
 		for j := range cb.added {
-			if defaultExprs == nil || defaultExprs[j] == nil {
+			if cb.added[j].IsComputed() {
+				cb.updateExprs[j] = cb.computedCols.Exprs[computedIdx]
+				computedIdx++
+			} else if defaultExprs == nil || defaultExprs[j] == nil {
 				cb.updateExprs[j] = parser.DNull
 			} else {
 				cb.updateExprs[j] = defaultExprs[j]
@@ -122,10 +141,6 @@ func (cb *columnBackfiller) init() error {
 		valNeededForCol[i] = true
 	}
 
-	colIdxMap = make(map[sqlbase.ColumnID]int, len(desc.Columns))
-	for i, c := range desc.Columns {
-		colIdxMap[c.ID] = i
-	}
 	return cb.fetcher.Init(
 		&desc, colIdxMap, &desc.PrimaryIndex, false, false, desc.Columns, valNeededForCol, false,
 	)
@@ -208,6 +223,9 @@ func (cb *columnBackfiller) runChunk(
 			if row == nil {
 				break
 			}
+			if cb.computedCols != nil {
+				cb.computedCols.LoadRow(row)
+			}
 			// Evaluate the new values. This must be done separately for
 			// each row so as to handle impure functions correctly.
 			for j, e := range cb.updateExprs {
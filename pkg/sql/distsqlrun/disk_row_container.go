@@ -0,0 +1,253 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// tempStorageLimit is the maximum amount of data the diskRowContainers of a
+// node may store in its temporary engine.
+var tempStorageLimit = settings.RegisterByteSizeSetting(
+	"sql.distsql.temp_storage.limit",
+	"maximum amount of temporary disk storage the DistSQL processors of each node may use "+
+		"to store rows that do not fit in memory",
+	32<<30, /* 32 GiB */
+)
+
+// tempStorageUsage tracks the amount of data stored in the temporary engine by
+// the diskRowContainers of a node, not counting the engine's own overhead. A
+// nil *tempStorageUsage doesn't enforce any limit.
+type tempStorageUsage struct {
+	// bytes is accessed atomically.
+	bytes int64
+}
+
+// grow reserves n bytes, or returns an error if that would exceed
+// tempStorageLimit.
+func (u *tempStorageUsage) grow(n int64) error {
+	if u == nil {
+		return nil
+	}
+	if total := atomic.AddInt64(&u.bytes, n); total > tempStorageLimit.Get() {
+		atomic.AddInt64(&u.bytes, -n)
+		return pgerror.NewErrorf(pgerror.CodeDiskFullError,
+			"temporary storage limit exceeded: %d bytes requested, %d bytes in use, limit %d bytes",
+			n, total-n, tempStorageLimit.Get())
+	}
+	return nil
+}
+
+func (u *tempStorageUsage) shrink(n int64) {
+	if u != nil {
+		atomic.AddInt64(&u.bytes, -n)
+	}
+}
+
+// diskRowContainerBatchSize is the amount of data a diskRowContainer buffers
+// before writing it to the engine.
+const diskRowContainerBatchSize = 1 << 20
+
+// lastDiskRowContainerID is used to generate the key prefixes of
+// diskRowContainers.
+var lastDiskRowContainerID uint64
+
+// diskRowContainer stores rows in a temporary engine. Each row is stored under
+// a key made of the ordering columns of the row, so iterating over the
+// container returns the rows in the container's ordering: sorting is left to
+// the engine, which merges sorted runs of keys on disk.
+type diskRowContainer struct {
+	engine engine.Engine
+	batch  engine.Batch
+	// batchBytes is the amount of data written to batch since it was last
+	// committed.
+	batchBytes int
+	// usage is charged for the rows of the container; bytes is the amount
+	// charged.
+	usage *tempStorageUsage
+	bytes int64
+
+	// prefix is the prefix of all the keys of the container. It is unique among
+	// the containers sharing an engine.
+	prefix   roachpb.Key
+	types    []sqlbase.ColumnType
+	ordering sqlbase.ColumnOrdering
+
+	// rowID is appended to the keys so that rows which are equal on the
+	// ordering columns don't overwrite each other. It also makes rows that are
+	// equal on the ordering columns come out in insertion order.
+	rowID uint64
+
+	keyScratch   []byte
+	valueScratch []byte
+	scratchRow   sqlbase.EncDatumRow
+	datumAlloc   sqlbase.DatumAlloc
+}
+
+func makeDiskRowContainer(
+	e engine.Engine,
+	usage *tempStorageUsage,
+	ordering sqlbase.ColumnOrdering,
+	types []sqlbase.ColumnType,
+) diskRowContainer {
+	id := atomic.AddUint64(&lastDiskRowContainerID, 1)
+	return diskRowContainer{
+		engine:     e,
+		batch:      e.NewWriteOnlyBatch(),
+		usage:      usage,
+		prefix:     roachpb.Key(encoding.EncodeUvarintAscending(nil, id)),
+		types:      types,
+		ordering:   ordering,
+		scratchRow: make(sqlbase.EncDatumRow, len(types)),
+	}
+}
+
+// AddRow adds a row to the container.
+func (d *diskRowContainer) AddRow(ctx context.Context, row sqlbase.EncDatumRow) error {
+	if len(row) != len(d.types) {
+		log.Fatalf(ctx, "invalid row length %d, expected %d", len(row), len(d.types))
+	}
+	key := append(d.keyScratch[:0], d.prefix...)
+	for _, o := range d.ordering {
+		enc := sqlbase.DatumEncoding_ASCENDING_KEY
+		if o.Direction == encoding.Descending {
+			enc = sqlbase.DatumEncoding_DESCENDING_KEY
+		}
+		var err error
+		key, err = row[o.ColIdx].Encode(&d.datumAlloc, enc, key)
+		if err != nil {
+			return err
+		}
+	}
+	d.rowID++
+	key = encoding.EncodeUvarintAscending(key, d.rowID)
+	d.keyScratch = key
+
+	value := d.valueScratch[:0]
+	for i := range row {
+		var err error
+		value, err = row[i].Encode(&d.datumAlloc, sqlbase.DatumEncoding_VALUE, value)
+		if err != nil {
+			return err
+		}
+	}
+	d.valueScratch = value
+
+	size := int64(len(key) + len(value))
+	if err := d.usage.grow(size); err != nil {
+		return err
+	}
+	d.bytes += size
+	if err := d.batch.Put(engine.MakeMVCCMetadataKey(key), value); err != nil {
+		return err
+	}
+	d.batchBytes += len(key) + len(value)
+	if d.batchBytes >= diskRowContainerBatchSize {
+		return d.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows to the engine.
+func (d *diskRowContainer) flush() error {
+	if d.batchBytes == 0 {
+		return nil
+	}
+	err := d.batch.Commit(false /* sync */)
+	d.batch.Close()
+	d.batch = d.engine.NewWriteOnlyBatch()
+	d.batchBytes = 0
+	return err
+}
+
+// Len returns the number of rows in the container.
+func (d *diskRowContainer) Len() int {
+	return int(d.rowID)
+}
+
+// Iterate calls fn on each row of the container, in the container's ordering,
+// until fn returns stop or an error. The row passed to fn is only valid until
+// fn returns.
+func (d *diskRowContainer) Iterate(fn func(sqlbase.EncDatumRow) (stop bool, _ error)) error {
+	if err := d.flush(); err != nil {
+		return err
+	}
+	it := d.engine.NewIterator(false /* prefix */)
+	defer it.Close()
+
+	end := engine.MakeMVCCMetadataKey(d.prefix.PrefixEnd())
+	for it.Seek(engine.MakeMVCCMetadataKey(d.prefix)); ; it.Next() {
+		if ok, err := it.Valid(); err != nil || !ok {
+			return err
+		}
+		if !it.UnsafeKey().Less(end) {
+			return nil
+		}
+		// The datums are decoded lazily from the value, so it cannot be the
+		// iterator's buffer.
+		value := it.Value()
+		for i := range d.scratchRow {
+			var err error
+			d.scratchRow[i], value, err = sqlbase.EncDatumFromBuffer(
+				d.types[i], sqlbase.DatumEncoding_VALUE, value,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		if stop, err := fn(d.scratchRow); stop || err != nil {
+			return err
+		}
+	}
+}
+
+// Clear removes all the rows from the container.
+func (d *diskRowContainer) Clear(ctx context.Context) {
+	d.batch.Close()
+	d.batch = d.engine.NewWriteOnlyBatch()
+	d.batchBytes = 0
+	if err := d.engine.ClearRange(
+		engine.MakeMVCCMetadataKey(d.prefix), engine.MakeMVCCMetadataKey(d.prefix.PrefixEnd()),
+	); err != nil {
+		log.Warningf(ctx, "error clearing disk row container: %s", err)
+	}
+	d.usage.shrink(d.bytes)
+	d.bytes = 0
+	d.rowID = 0
+}
+
+// Close removes the rows from the engine and releases the container's
+// resources.
+func (d *diskRowContainer) Close(ctx context.Context) {
+	d.Clear(ctx)
+	d.batch.Close()
+}
+
+// isMemoryBudgetError returns true if err was caused by a memory monitor
+// refusing an allocation.
+func isMemoryBudgetError(err error) bool {
+	pgErr, ok := pgerror.GetPGCause(err)
+	return ok && pgErr.Code == pgerror.CodeOutOfMemoryError
+}
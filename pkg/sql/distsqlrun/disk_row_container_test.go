@@ -0,0 +1,170 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// makeTestingBudgetMonitor returns a started memory monitor that refuses
+// allocations beyond budget bytes.
+func makeTestingBudgetMonitor(ctx context.Context, budget int64) mon.MemoryMonitor {
+	monitor := mon.MakeMonitor(
+		"test-budget-monitor",
+		nil,           /* curCount */
+		nil,           /* maxHist */
+		-1,            /* increment */
+		math.MaxInt64, /* noteworthy */
+	)
+	monitor.Start(ctx, nil, mon.MakeStandaloneBudget(budget))
+	return monitor
+}
+
+func TestDiskRowContainer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	tempEngine := engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */)
+	defer tempEngine.Close()
+
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(ctx)
+
+	rng, _ := randutil.NewPseudoRand()
+
+	const numRows = 1000
+	const numCols = 3
+	for run := 0; run < 10; run++ {
+		types := make([]sqlbase.ColumnType, numCols)
+		for i := range types {
+			types[i] = sqlbase.RandColumnType(rng)
+		}
+		// Order on a random subset of the columns; the first run checks that
+		// rows come out in insertion order when there is no ordering.
+		var ordering sqlbase.ColumnOrdering
+		if run > 0 {
+			for _, colIdx := range rng.Perm(numCols)[:1+rng.Intn(numCols)] {
+				dir := encoding.Ascending
+				if rng.Intn(2) == 0 {
+					dir = encoding.Descending
+				}
+				ordering = append(ordering, sqlbase.ColumnOrderInfo{ColIdx: colIdx, Direction: dir})
+			}
+		}
+
+		t.Run(fmt.Sprintf("%v/%v", types, ordering), func(t *testing.T) {
+			d := makeDiskRowContainer(tempEngine, nil /* usage */, ordering, types)
+			defer d.Close(ctx)
+
+			var expected []string
+			for i := 0; i < numRows; i++ {
+				row := make(sqlbase.EncDatumRow, numCols)
+				for j := range row {
+					row[j] = sqlbase.DatumToEncDatum(types[j], sqlbase.RandDatum(rng, types[j], true))
+				}
+				if err := d.AddRow(ctx, row); err != nil {
+					t.Fatal(err)
+				}
+				expected = append(expected, row.String())
+			}
+			if d.Len() != numRows {
+				t.Fatalf("expected %d rows, got %d", numRows, d.Len())
+			}
+
+			var alloc sqlbase.DatumAlloc
+			var prev sqlbase.EncDatumRow
+			var rows []string
+			if err := d.Iterate(func(row sqlbase.EncDatumRow) (bool, error) {
+				if prev != nil {
+					if cmp, err := prev.Compare(&alloc, ordering, &evalCtx, row); err != nil {
+						return false, err
+					} else if cmp > 0 {
+						return false, fmt.Errorf("rows out of order: %s before %s", prev, row)
+					}
+				}
+				prev = append(prev[:0], row...)
+				rows = append(rows, row.String())
+				return false, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if ordering != nil {
+				sort.Strings(expected)
+				sort.Strings(rows)
+			}
+			if fmt.Sprint(rows) != fmt.Sprint(expected) {
+				t.Fatalf("expected rows:\n%s\ngot:\n%s", expected, rows)
+			}
+
+			d.Clear(ctx)
+			if err := d.Iterate(func(row sqlbase.EncDatumRow) (bool, error) {
+				return false, fmt.Errorf("unexpected row %s after Clear", row)
+			}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDiskRowContainerLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	tempEngine := engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */)
+	defer tempEngine.Close()
+	defer settings.TestingSetByteSize(&tempStorageLimit, 1000)()
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	types := []sqlbase.ColumnType{columnTypeInt}
+	row := sqlbase.EncDatumRow{sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(1))}
+
+	var usage tempStorageUsage
+	d := makeDiskRowContainer(tempEngine, &usage, nil /* ordering */, types)
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = d.AddRow(ctx, row)
+	}
+	if pgErr, ok := pgerror.GetPGCause(err); !ok || pgErr.Code != pgerror.CodeDiskFullError {
+		t.Fatalf("expected a disk full error, got %v", err)
+	}
+
+	// The storage is released when the container is closed.
+	d.Close(ctx)
+	if usage.bytes != 0 {
+		t.Fatalf("expected no storage in use, got %d bytes", usage.bytes)
+	}
+	d = makeDiskRowContainer(tempEngine, &usage, nil /* ordering */, types)
+	defer d.Close(ctx)
+	if err := d.AddRow(ctx, row); err != nil {
+		t.Fatal(err)
+	}
+}
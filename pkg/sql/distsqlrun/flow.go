@@ -27,7 +27,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -68,6 +70,18 @@ type FlowCtx struct {
 	// run.
 	nodeID       roachpb.NodeID
 	testingKnobs TestingKnobs
+	// collectStats is set if the processors collect execution statistics and
+	// send them as metadata (see statsCollector).
+	collectStats bool
+	// vectorize is set if the processors run with vectorized operators when
+	// they support them (see newVectorizedProcessor).
+	vectorize bool
+	// tempStorage is used by processors to store rows that do not fit in
+	// memory. It can be nil, in which case they don't fall back to disk.
+	tempStorage engine.Engine
+	// tempStorageUsage limits the amount of data stored in tempStorage by the
+	// flows of the node.
+	tempStorageUsage *tempStorageUsage
 }
 
 func (flowCtx *FlowCtx) setupTxn() *client.Txn {
@@ -94,6 +108,8 @@ type Flow struct {
 	flowRegistry *flowRegistry
 	processors   []processor
 	outboxes     []*outbox
+	// statsCollectors wrap the processors when the flow collects statistics.
+	statsCollectors []*statsCollector
 	// syncFlowConsumer is a special outbox which instead of sending rows to
 	// another host, returns them directly (as a result to a SetupSyncFlow RPC,
 	// or to the local host).
@@ -113,6 +129,12 @@ type Flow struct {
 
 	doneFn func()
 
+	// ctxCancel cancels the context of the flow, which is done once
+	// ctxDone is closed. The context of a flow is canceled when its query is
+	// canceled, and when the consumer of one of its outboxes goes away.
+	ctxCancel context.CancelFunc
+	ctxDone   <-chan struct{}
+
 	status flowStatus
 }
 
@@ -178,6 +200,7 @@ func (f *Flow) setupOutboundStream(spec StreamEndpointSpec) (RowReceiver, error)
 
 	case StreamEndpointSpec_REMOTE:
 		outbox := newOutbox(&f.FlowCtx, spec.TargetAddr, f.id, sid)
+		outbox.flowCtxCancel = f.ctxCancel
 		f.outboxes = append(f.outboxes, outbox)
 		return outbox, nil
 
@@ -219,7 +242,9 @@ func checkNumInOut(inputs []RowSource, outputs []RowReceiver, numIn, numOut int)
 	return nil
 }
 
-func (f *Flow) makeProcessor(ps *ProcessorSpec, inputs []RowSource) (processor, error) {
+func (f *Flow) makeProcessor(
+	ctx context.Context, pIdx int, ps *ProcessorSpec, inputs []RowSource,
+) (processor, error) {
 	if len(ps.Output) != 1 {
 		return nil, errors.Errorf("only single-output processors supported")
 	}
@@ -231,7 +256,32 @@ func (f *Flow) makeProcessor(ps *ProcessorSpec, inputs []RowSource) (processor,
 			return nil, err
 		}
 	}
-	return newProcessor(&f.FlowCtx, &ps.Core, &ps.Post, inputs, outputs)
+	if !f.collectStats {
+		return newProcessor(&f.FlowCtx, &ps.Core, &ps.Post, inputs, outputs)
+	}
+
+	// The processor gets its own memory monitor, under the flow's monitor, to
+	// measure its peak memory usage.
+	c := &statsCollector{output: outputs[0]}
+	c.stats.NodeID = f.nodeID
+	c.stats.ProcessorIdx = int32(pIdx)
+	c.mon = mon.MakeMonitor(
+		"processor", nil /* curCount */, nil /* maxHist */, -1, /* use default block size */
+		noteworthyMemoryUsageBytes,
+	)
+	c.mon.Start(ctx, f.evalCtx.Mon, mon.BoundAccount{})
+	f.statsCollectors = append(f.statsCollectors, c)
+	procCtx := new(FlowCtx)
+	*procCtx = f.FlowCtx
+	procCtx.evalCtx.Mon = &c.mon
+	outputs[0] = c
+
+	var err error
+	c.proc, err = newProcessor(procCtx, &ps.Core, &ps.Post, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 func (f *Flow) setup(ctx context.Context, spec *FlowSpec) error {
@@ -290,7 +340,7 @@ func (f *Flow) setup(ctx context.Context, spec *FlowSpec) error {
 
 	for i := range spec.Processors {
 		var err error
-		f.processors[i], err = f.makeProcessor(&spec.Processors[i], inputSyncs[i])
+		f.processors[i], err = f.makeProcessor(ctx, i, &spec.Processors[i], inputSyncs[i])
 		if err != nil {
 			return err
 		}
@@ -333,6 +383,9 @@ func (f *Flow) Cleanup(ctx context.Context) {
 	if f.status == FlowFinished {
 		panic("flow cleanup called twice")
 	}
+	for _, c := range f.statsCollectors {
+		c.mon.Stop(ctx)
+	}
 	// This closes the account and monitor opened in ServerImpl.setupFlow.
 	f.evalCtx.ActiveMemAcc.Close(ctx)
 	f.evalCtx.Stop(ctx)
@@ -345,6 +398,7 @@ func (f *Flow) Cleanup(ctx context.Context) {
 		f.flowRegistry.UnregisterFlow(f.id)
 	}
 	f.status = FlowFinished
+	f.ctxCancel()
 	f.doneFn()
 	f.doneFn = nil
 }
@@ -146,6 +146,59 @@ func (hj *HashJoinerSpec) summary() (string, []string) {
 	return "HashJoiner", details
 }
 
+func (lj *LookupJoinerSpec) summary() (string, []string) {
+	index := "primary"
+	if lj.IndexIdx > 0 {
+		index = lj.Table.Indexes[lj.IndexIdx-1].Name
+	}
+	details := []string{
+		fmt.Sprintf("%s@%s", index, lj.Table.Name),
+		fmt.Sprintf("lookup(%s)", colListStr(lj.LookupColumns)),
+	}
+	if lj.OnExpr.Expr != "" {
+		details = append(details, fmt.Sprintf("ON %s", lj.OnExpr.Expr))
+	}
+	return "LookupJoiner", details
+}
+
+func (w *WindowerSpec) summary() (string, []string) {
+	details := make([]string, 0, len(w.WindowFns)+1)
+	if len(w.PartitionBy) > 0 {
+		details = append(details, fmt.Sprintf("PARTITION BY %s", colListStr(w.PartitionBy)))
+	}
+	for _, fn := range w.WindowFns {
+		var name string
+		if fn.AggregateFunc != nil {
+			name = fn.AggregateFunc.String()
+		} else if fn.WindowFunc != nil {
+			name = fn.WindowFunc.String()
+		}
+		detail := fmt.Sprintf("%s(%s)", name, colListStr(fn.ArgCols))
+		if len(fn.Ordering.Columns) > 0 {
+			detail += fmt.Sprintf(" ORDER BY %s", fn.Ordering.diagramString())
+		}
+		details = append(details, detail)
+	}
+	return "Windower", details
+}
+
+func (tw *TableWriterSpec) summary() (string, []string) {
+	var detail string
+	switch tw.Type {
+	case TableWriterSpec_INSERT:
+		detail = fmt.Sprintf("INSERT INTO %s", tw.Table.Name)
+	case TableWriterSpec_UPDATE:
+		detail = fmt.Sprintf("UPDATE %s", tw.Table.Name)
+	case TableWriterSpec_DELETE:
+		detail = fmt.Sprintf("DELETE FROM %s", tw.Table.Name)
+	}
+	details := []string{detail}
+	if tw.Returning {
+		details = append(details, "RETURNING")
+	}
+	return "TableWriter", details
+}
+
 func (s *SorterSpec) summary() (string, []string) {
 	details := []string{s.OutputOrdering.diagramString()}
 	if s.OrderingMatchLen != 0 {
@@ -172,6 +225,30 @@ func (d *DistinctSpec) summary() (string, []string) {
 	return "Distinct", details
 }
 
+func (s *AlgebraicSetOpSpec) summary() (string, []string) {
+	details := []string{s.OpType.String()}
+	if len(s.Ordering.Columns) > 0 {
+		details = append(details, s.Ordering.diagramString())
+	}
+	return "AlgebraicSetOp", details
+}
+
+func (s *SamplerSpec) summary() (string, []string) {
+	details := []string{
+		fmt.Sprintf("SampleSize: %d", s.SampleSize),
+		fmt.Sprintf("SketchSize: %d", s.SketchSize),
+	}
+	return "Sampler", details
+}
+
+func (s *SampleAggregatorSpec) summary() (string, []string) {
+	details := []string{
+		fmt.Sprintf("SampleSize: %d", s.SampleSize),
+		fmt.Sprintf("ColumnIDs: %s", colListStr(s.ColumnIDs)),
+	}
+	return "SampleAggregator", details
+}
+
 func (is *InputSyncSpec) summary() (string, []string) {
 	switch is.Type {
 	case InputSyncSpec_UNORDERED:
@@ -261,7 +338,13 @@ type diagramData struct {
 	Edges      []diagramEdge      `json:"edges"`
 }
 
-func generateDiagramData(flows []FlowSpec, nodeNames []string) (diagramData, error) {
+// generateDiagramData generates the diagram of the flows, one per node. If
+// procStats is not nil, procStats[n] maps the index of each processor of
+// flows[n] to its execution statistics, which are added to the details of the
+// processor.
+func generateDiagramData(
+	flows []FlowSpec, nodeNames []string, procStats []map[int32]*ProcessorStats,
+) (diagramData, error) {
 	d := diagramData{NodeNames: nodeNames}
 
 	// inPorts maps streams to their "destination" attachment point. Only DestProc
@@ -271,10 +354,15 @@ func generateDiagramData(flows []FlowSpec, nodeNames []string) (diagramData, err
 
 	pIdx := 0
 	for n := range flows {
-		for _, p := range flows[n].Processors {
+		for i, p := range flows[n].Processors {
 			proc := diagramProcessor{NodeIdx: n}
 			proc.Core.Title, proc.Core.Details = p.Core.GetValue().(diagramCellType).summary()
 			proc.Core.Details = append(proc.Core.Details, p.Post.summary()...)
+			if procStats != nil {
+				if stats, ok := procStats[n][int32(i)]; ok {
+					proc.Core.Details = append(proc.Core.Details, stats.summary()...)
+				}
+			}
 
 			// We need explicit synchronizers if we have multiple inputs, or if the
 			// one input has multiple input streams.
@@ -373,6 +461,12 @@ func generateDiagramData(flows []FlowSpec, nodeNames []string) (diagramData, err
 // be one FlowSpec per node. The function assumes that StreamIDs are unique
 // across all flows.
 func GeneratePlanDiagram(flows map[roachpb.NodeID]FlowSpec, w io.Writer) error {
+	return generatePlanDiagram(flows, nil /* stats */, w)
+}
+
+func generatePlanDiagram(
+	flows map[roachpb.NodeID]FlowSpec, stats []ProcessorStats, w io.Writer,
+) error {
 	// We sort the flows by node because we want the diagram data to be
 	// deterministic.
 	nodeIDs := make([]int, 0, len(flows))
@@ -383,14 +477,31 @@ func GeneratePlanDiagram(flows map[roachpb.NodeID]FlowSpec, w io.Writer) error {
 
 	flowSlice := make([]FlowSpec, len(nodeIDs))
 	nodeNames := make([]string, len(nodeIDs))
+	nodeIdx := make(map[roachpb.NodeID]int, len(nodeIDs))
 	for i, nVal := range nodeIDs {
 		n := roachpb.NodeID(nVal)
 
 		flowSlice[i] = flows[n]
 		nodeNames[i] = n.String()
+		nodeIdx[n] = i
 	}
 
-	d, err := generateDiagramData(flowSlice, nodeNames)
+	var procStats []map[int32]*ProcessorStats
+	if stats != nil {
+		procStats = make([]map[int32]*ProcessorStats, len(nodeIDs))
+		for i := range stats {
+			n, ok := nodeIdx[stats[i].NodeID]
+			if !ok {
+				continue
+			}
+			if procStats[n] == nil {
+				procStats[n] = make(map[int32]*ProcessorStats)
+			}
+			procStats[n][stats[i].ProcessorIdx] = &stats[i]
+		}
+	}
+
+	d, err := generateDiagramData(flowSlice, nodeNames, procStats)
 	if err != nil {
 		return err
 	}
@@ -401,8 +512,17 @@ func GeneratePlanDiagram(flows map[roachpb.NodeID]FlowSpec, w io.Writer) error {
 // URL which encodes the diagram. There should be one FlowSpec per node. The
 // function assumes that StreamIDs are unique across all flows.
 func GeneratePlanDiagramWithURL(flows map[roachpb.NodeID]FlowSpec) (string, url.URL, error) {
+	return GenerateAnalyzedPlanDiagramWithURL(flows, nil /* stats */)
+}
+
+// GenerateAnalyzedPlanDiagramWithURL is like GeneratePlanDiagramWithURL, but
+// the details of each processor include the execution statistics collected
+// for it, if any (see EXPLAIN ANALYZE).
+func GenerateAnalyzedPlanDiagramWithURL(
+	flows map[roachpb.NodeID]FlowSpec, stats []ProcessorStats,
+) (string, url.URL, error) {
 	var json, compressed bytes.Buffer
-	if err := GeneratePlanDiagram(flows, &json); err != nil {
+	if err := generatePlanDiagram(flows, stats, &json); err != nil {
 		return "", url.URL{}, err
 	}
 	jsonStr := json.String()
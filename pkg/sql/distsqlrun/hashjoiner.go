@@ -18,6 +18,7 @@
 package distsqlrun
 
 import (
+	"hash/crc32"
 	"sync"
 	"unsafe"
 
@@ -44,6 +45,10 @@ type bucket struct {
 // (see hashJoiner).
 const hashJoinerInitialBufferSize = 4 * 1024 * 1024
 
+// hashJoinerNumPartitions is the number of partitions each stream is split into
+// when the join falls back to disk (see hashJoiner.joinOnDisk).
+const hashJoinerNumPartitions = 16
+
 const sizeOfBucket = int64(unsafe.Sizeof(bucket{}))
 const sizeOfRowIdx = int64(unsafe.Sizeof(int(0)))
 
@@ -62,6 +67,9 @@ const sizeOfRowIdx = int64(unsafe.Sizeof(int(0)))
 //  3. Probe phase: in this phase we process all the rows from the other stream
 //     and look for matching rows from the stored stream using the map.
 //
+// If the stored stream doesn't fit in memory and the flow has temporary
+// storage, the join is finished on disk as a grace hash join (see joinOnDisk).
+//
 // There is no guarantee on the output ordering.
 type hashJoiner struct {
 	joinerBase
@@ -146,7 +154,16 @@ func (h *hashJoiner) Run(ctx context.Context, wg *sync.WaitGroup) {
 	defer h.rows[rightSide].Close(ctx)
 	defer h.bucketsAcc.Close(ctx)
 
-	if earlyExit, err := h.bufferPhase(ctx); earlyExit || err != nil {
+	earlyExit, err := h.bufferPhase(ctx)
+	if err != nil && h.canJoinOnDisk(err) {
+		// Neither stream has been read to the end.
+		h.storedSide = rightSide
+		earlyExit, err = h.joinOnDisk(ctx, false /* storedSideDone */)
+		if !earlyExit && err == nil {
+			return
+		}
+	}
+	if earlyExit || err != nil {
 		if err != nil {
 			// We got an error. We still want to drain. Any error encountered while
 			// draining will be swallowed, and the original error will be forwarded to
@@ -164,24 +181,17 @@ func (h *hashJoiner) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 
 	if err := h.buildPhase(ctx); err != nil {
-		log.Infof(ctx, "build phase error %s", err)
-		DrainAndClose(ctx, h.out.output, err /* cause */, srcToClose)
-		return
-	}
-
-	// Allocate seen slices to produce results for unmatched stored rows,
-	// for FULL OUTER AND LEFT/RIGHT OUTER (depending on which stream we store).
-	if shouldEmitUnmatchedRow(h.storedSide, h.joinType) {
-		for k, bucket := range h.buckets {
-			if err := h.bucketsAcc.Grow(
-				ctx, int64(sizeOfBoolSlice+uintptr(len(bucket.rows))*sizeOfBool),
-			); err != nil {
-				DrainAndClose(ctx, h.out.output, err, srcToClose)
+		if h.canJoinOnDisk(err) {
+			earlyExit, err = h.joinOnDisk(ctx, true /* storedSideDone */)
+			if !earlyExit && err == nil {
 				return
 			}
-			bucket.seen = make([]bool, len(bucket.rows))
-			h.buckets[k] = bucket
 		}
+		if err != nil {
+			log.Infof(ctx, "build phase error %s", err)
+		}
+		DrainAndClose(ctx, h.out.output, err /* cause */, srcToClose)
+		return
 	}
 	log.VEventf(ctx, 1, "build phase complete")
 	if earlyExit, err := h.probePhase(ctx); earlyExit || err != nil {
@@ -341,6 +351,20 @@ func (h *hashJoiner) buildPhase(ctx context.Context) error {
 		b.rows = append(b.rows, rowIdx)
 		h.buckets[string(encoded)] = b
 	}
+
+	// Allocate seen slices to produce results for unmatched stored rows,
+	// for FULL OUTER AND LEFT/RIGHT OUTER (depending on which stream we store).
+	if shouldEmitUnmatchedRow(h.storedSide, h.joinType) {
+		for k, bucket := range h.buckets {
+			if err := h.bucketsAcc.Grow(
+				ctx, int64(sizeOfBoolSlice+uintptr(len(bucket.rows))*sizeOfBool),
+			); err != nil {
+				return err
+			}
+			bucket.seen = make([]bool, len(bucket.rows))
+			h.buckets[k] = bucket
+		}
+	}
 	return nil
 }
 
@@ -436,23 +460,152 @@ func (h *hashJoiner) probePhase(ctx context.Context) (earlyExit bool, _ error) {
 		}
 	}
 
-	if shouldEmitUnmatchedRow(h.storedSide, h.joinType) {
-		// Produce results for unmatched rows, for FULL OUTER AND LEFT/RIGHT OUTER
-		// (depending on which stream we use).
-		storedRows := &h.rows[h.storedSide]
-		for _, b := range h.buckets {
-			for i, seen := range b.seen {
-				if !seen && !h.maybeEmitUnmatchedRow(ctx, storedRows.EncRow(b.rows[i]), h.storedSide) {
-					return true, nil
+	if !h.emitUnmatchedStoredRows(ctx) {
+		return true, nil
+	}
+
+	h.out.close()
+	return false, nil
+}
+
+// emitUnmatchedStoredRows produces results for the stored rows that didn't
+// match any row of the other stream, for FULL OUTER AND LEFT/RIGHT OUTER
+// (depending on which stream we store). It returns false if the consumer
+// doesn't need more rows.
+func (h *hashJoiner) emitUnmatchedStoredRows(ctx context.Context) bool {
+	if !shouldEmitUnmatchedRow(h.storedSide, h.joinType) {
+		return true
+	}
+	storedRows := &h.rows[h.storedSide]
+	for _, b := range h.buckets {
+		for i, seen := range b.seen {
+			if !seen && !h.maybeEmitUnmatchedRow(ctx, storedRows.EncRow(b.rows[i]), h.storedSide) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canJoinOnDisk returns whether the error encountered while buffering or
+// building can be handled by finishing the join on disk.
+func (h *hashJoiner) canJoinOnDisk(err error) bool {
+	return h.flowCtx.tempStorage != nil && isMemoryBudgetError(err)
+}
+
+// joinOnDisk finishes a join whose stored stream doesn't fit in memory using a
+// grace hash join: the rows of both streams are split into partitions, by
+// hashing their equality columns, that are stored in diskRowContainers. Rows
+// that match are always in the same pair of partitions, so each pair of
+// partitions is then joined in memory on its own, using the build and probe
+// phases. The stored partitions are assumed to fit in memory; if one doesn't,
+// the join fails with a memory budget error.
+//
+// The rows buffered in memory so far are moved to the partitions, and both
+// streams are read to the end (except for the stored stream if storedSideDone
+// is set, in which case all its rows were buffered).
+//
+// In error or earlyExit cases it is the caller's responsibility to drain the
+// input streams and close the output stream.
+func (h *hashJoiner) joinOnDisk(
+	ctx context.Context, storedSideDone bool,
+) (earlyExit bool, _ error) {
+	log.VEventf(ctx, 1, "joining on disk after buffering %d and %d rows",
+		h.rows[leftSide].Len(), h.rows[rightSide].Len())
+
+	var partitions [2][]diskRowContainer
+	for side := range partitions {
+		partitions[side] = make([]diskRowContainer, hashJoinerNumPartitions)
+		for i := range partitions[side] {
+			partitions[side][i] = makeDiskRowContainer(
+				h.flowCtx.tempStorage, h.flowCtx.tempStorageUsage,
+				nil /* ordering */, h.rows[side].types,
+			)
+			defer partitions[side][i].Close(ctx)
+		}
+	}
+
+	for side := range h.rows {
+		rows := &h.rows[side]
+		for i := 0; i < rows.Len(); i++ {
+			if err := h.partitionRow(ctx, partitions[side], rows.EncRow(i), joinSide(side)); err != nil {
+				return false, err
+			}
+		}
+		rows.Clear(ctx)
+	}
+	h.buckets = make(map[string]bucket)
+	h.bucketsAcc.Clear(ctx)
+
+	srcs := [2]RowSource{h.leftSource, h.rightSource}
+	for side, src := range srcs {
+		if joinSide(side) == h.storedSide && storedSideDone {
+			continue
+		}
+		for {
+			row, earlyExit, err := h.receiveRow(ctx, src, joinSide(side))
+			if row == nil {
+				if earlyExit || err != nil {
+					return earlyExit, err
 				}
+				break
+			}
+			if err := h.partitionRow(ctx, partitions[side], row, joinSide(side)); err != nil {
+				return false, err
 			}
 		}
 	}
 
+	storedRows := &h.rows[h.storedSide]
+	for i := 0; i < hashJoinerNumPartitions; i++ {
+		if err := partitions[h.storedSide][i].Iterate(func(row sqlbase.EncDatumRow) (bool, error) {
+			return false, storedRows.AddRow(ctx, row)
+		}); err != nil {
+			return false, err
+		}
+		if err := h.buildPhase(ctx); err != nil {
+			return false, err
+		}
+
+		var earlyExit bool
+		if err := partitions[otherSide(h.storedSide)][i].Iterate(
+			func(row sqlbase.EncDatumRow) (bool, error) {
+				var err error
+				earlyExit, err = h.probeRow(ctx, row)
+				return earlyExit, err
+			},
+		); earlyExit || err != nil {
+			return earlyExit, err
+		}
+		if !h.emitUnmatchedStoredRows(ctx) {
+			return true, nil
+		}
+
+		h.buckets = make(map[string]bucket)
+		h.bucketsAcc.Clear(ctx)
+		storedRows.Clear(ctx)
+	}
+
 	h.out.close()
 	return false, nil
 }
 
+// partitionRow adds a row to the partition of the given stream its equality
+// columns hash to.
+func (h *hashJoiner) partitionRow(
+	ctx context.Context, partitions []diskRowContainer, row sqlbase.EncDatumRow, side joinSide,
+) error {
+	encoded, _, err := encodeColumnsOfRow(
+		&h.datumAlloc, h.scratch, row, h.eqCols[side], true, /* encodeNull */
+	)
+	if err != nil {
+		return err
+	}
+	h.scratch = encoded[:0]
+	p := crc32.Update(0, crc32Table, encoded) % uint32(len(partitions))
+	return partitions[p].AddRow(ctx, row)
+}
+
 // encodeColumnsOfRow returns the encoding for the grouping columns. This is
 // then used as our group key to determine which bucket to add to.
 // If the row contains any NULLs and encodeNull is false, hasNull is true and
@@ -24,8 +24,10 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/pkg/errors"
@@ -539,6 +541,77 @@ func TestHashJoiner(t *testing.T) {
 	}
 }
 
+// TestHashJoinerOnDisk verifies that the hashJoiner falls back to a grace hash
+// join on disk once the stored stream doesn't fit in its memory budget.
+func TestHashJoinerOnDisk(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	tempEngine := engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */)
+	defer tempEngine.Close()
+
+	// The budget holds a fraction of the stored stream, but each of its
+	// partitions fits comfortably.
+	const numRows = 1600
+	const budget = 24 * 1024
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	intEncDatum := func(i int) sqlbase.EncDatum {
+		return sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(i)))
+	}
+	null := sqlbase.EncDatum{Datum: parser.DNull}
+
+	// The left stream has the values [0, numRows), the right stream has the
+	// values [numRows/2, 3*numRows/2).
+	var inputs [2]sqlbase.EncDatumRows
+	var expected sqlbase.EncDatumRows
+	for i := 0; i < 3*numRows/2; i++ {
+		left, right := null, null
+		if i < numRows {
+			left = intEncDatum(i)
+			inputs[leftSide] = append(inputs[leftSide], sqlbase.EncDatumRow{left})
+		}
+		if i >= numRows/2 {
+			right = intEncDatum(i)
+			inputs[rightSide] = append(inputs[rightSide], sqlbase.EncDatumRow{right})
+		}
+		expected = append(expected, sqlbase.EncDatumRow{left, right})
+	}
+
+	spec := HashJoinerSpec{
+		LeftEqColumns:  []uint32{0},
+		RightEqColumns: []uint32{0},
+		Type:           JoinType_FULL_OUTER,
+	}
+	types := []sqlbase.ColumnType{columnTypeInt}
+	leftInput := NewRowBuffer(types, inputs[leftSide], RowBufferArgs{})
+	rightInput := NewRowBuffer(types, inputs[rightSide], RowBufferArgs{})
+	out := &RowBuffer{}
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(ctx)
+	monitor := makeTestingBudgetMonitor(ctx, budget)
+	defer monitor.Stop(ctx)
+	flowCtx := FlowCtx{evalCtx: evalCtx, tempStorage: tempEngine}
+	flowCtx.evalCtx.Mon = &monitor
+
+	post := PostProcessSpec{Projection: true, OutputColumns: []uint32{0, 1}}
+	h, err := newHashJoiner(&flowCtx, &spec, leftInput, rightInput, &post, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Disable initial buffering. We always store the right stream in this case.
+	h.initialBufferSize = 0
+
+	h.Run(ctx, nil)
+
+	if !out.ProducerClosed {
+		t.Fatalf("output RowReceiver not closed")
+	}
+	if err := checkExpectedRows(expected, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func checkExpectedRows(expectedRows sqlbase.EncDatumRows, results *RowBuffer) error {
 	var expected []string
 	for _, row := range expectedRows {
@@ -46,14 +46,28 @@ func (jb *joinerBase) init(
 ) error {
 	jb.leftSource = leftSource
 	jb.rightSource = rightSource
+	return jb.initWithTypes(
+		flowCtx, leftSource.Types(), rightSource.Types(), jType, onExpr, post, output,
+	)
+}
+
+// initWithTypes is like init, for joiners which don't read the right side of
+// the join from a RowSource.
+func (jb *joinerBase) initWithTypes(
+	flowCtx *FlowCtx,
+	leftTypes []sqlbase.ColumnType,
+	rightTypes []sqlbase.ColumnType,
+	jType JoinType,
+	onExpr Expression,
+	post *PostProcessSpec,
+	output RowReceiver,
+) error {
 	jb.joinType = joinType(jType)
 
-	leftTypes := leftSource.Types()
 	jb.emptyLeft = make(sqlbase.EncDatumRow, len(leftTypes))
 	for i := range jb.emptyLeft {
 		jb.emptyLeft[i].Datum = parser.DNull
 	}
-	rightTypes := rightSource.Types()
 	jb.emptyRight = make(sqlbase.EncDatumRow, len(rightTypes))
 	for i := range jb.emptyRight {
 		jb.emptyRight[i].Datum = parser.DNull
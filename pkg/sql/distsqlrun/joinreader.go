@@ -46,6 +46,7 @@ type joinReader struct {
 }
 
 var _ processor = &joinReader{}
+var _ kvStatsReporter = &joinReader{}
 
 func newJoinReader(
 	flowCtx *FlowCtx,
@@ -187,6 +188,11 @@ func (jr *joinReader) mainLoop(ctx context.Context) error {
 	}
 }
 
+// kvStats is part of the kvStatsReporter interface.
+func (jr *joinReader) kvStats() (requests int64, bytesRead int64) {
+	return jr.fetcher.KVStats()
+}
+
 // Run is part of the processor interface.
 func (jr *joinReader) Run(ctx context.Context, wg *sync.WaitGroup) {
 	if wg != nil {
@@ -0,0 +1,332 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// lookupJoiner performs an index nested-loop join between its input and a
+// table: the input rows are read in batches and, for each batch, the rows of
+// the table index that match the lookup columns of the input rows are
+// retrieved with a single scan.
+//
+// The output rows are not in the order of the input rows.
+type lookupJoiner struct {
+	joinerBase
+
+	flowCtx *FlowCtx
+
+	desc  sqlbase.TableDescriptor
+	index *sqlbase.IndexDescriptor
+	// lookupCols are the input columns matched with the first columns of the
+	// index.
+	lookupCols columns
+	// indexColIdx are the positions, in the rows returned by the fetcher, of
+	// the first len(lookupCols) columns of the index.
+	indexColIdx []int
+
+	fetcher sqlbase.RowFetcher
+
+	// rowAlloc is used to copy the input rows of a batch.
+	rowAlloc sqlbase.EncDatumRowAlloc
+	alloc    sqlbase.DatumAlloc
+}
+
+var _ processor = &lookupJoiner{}
+var _ kvStatsReporter = &lookupJoiner{}
+
+func newLookupJoiner(
+	flowCtx *FlowCtx,
+	spec *LookupJoinerSpec,
+	input RowSource,
+	post *PostProcessSpec,
+	output RowReceiver,
+) (*lookupJoiner, error) {
+	switch spec.Type {
+	case JoinType_INNER, JoinType_LEFT_OUTER:
+	default:
+		return nil, errors.Errorf("lookup join of type %s not supported", spec.Type)
+	}
+
+	lj := &lookupJoiner{
+		flowCtx:    flowCtx,
+		desc:       spec.Table,
+		lookupCols: columns(spec.LookupColumns),
+	}
+	lj.leftSource = input
+
+	tableTypes := make([]sqlbase.ColumnType, len(spec.Table.Columns))
+	for i := range tableTypes {
+		tableTypes[i] = spec.Table.Columns[i].Type
+	}
+	inputTypes := input.Types()
+	if err := lj.joinerBase.initWithTypes(
+		flowCtx, inputTypes, tableTypes, spec.Type, spec.OnExpr, post, output,
+	); err != nil {
+		return nil, err
+	}
+
+	if spec.IndexIdx > uint32(len(spec.Table.Indexes)) {
+		return nil, errors.Errorf("invalid indexIdx %d", spec.IndexIdx)
+	}
+	index := &lj.desc.PrimaryIndex
+	if spec.IndexIdx > 0 {
+		index = &lj.desc.Indexes[spec.IndexIdx-1]
+	}
+	if len(index.Interleave.Ancestors) > 0 {
+		return nil, errors.Errorf("lookup join with interleaved index %s not supported", index.Name)
+	}
+	if len(lj.lookupCols) == 0 || len(lj.lookupCols) > len(index.ColumnIDs) {
+		return nil, errors.Errorf(
+			"%d lookup columns for index %s with %d columns",
+			len(lj.lookupCols), index.Name, len(index.ColumnIDs),
+		)
+	}
+
+	// The fetcher must decode the table columns used by the ON condition and
+	// the post-processing stage, as well as the index columns which are
+	// matched with the input rows.
+	outNeeded := lj.out.neededColumns()
+	neededCols := make([]bool, len(tableTypes))
+	for i := range neededCols {
+		internalIdx := len(inputTypes) + i
+		neededCols[i] = outNeeded[internalIdx] ||
+			(lj.onCond.expr != nil && lj.onCond.vars.IndexedVarUsed(internalIdx))
+	}
+	lj.indexColIdx = make([]int, len(lj.lookupCols))
+	for i, id := range index.ColumnIDs[:len(lj.lookupCols)] {
+		idx := -1
+		for j := range lj.desc.Columns {
+			if lj.desc.Columns[j].ID == id {
+				idx = j
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, errors.Errorf("column %d of index %s not found", id, index.Name)
+		}
+		lookupType := inputTypes[lj.lookupCols[i]]
+		if !lookupType.ToDatumType().Equivalent(tableTypes[idx].ToDatumType()) {
+			return nil, errors.Errorf(
+				"lookup column %d has type %s, index column %s has type %s",
+				lj.lookupCols[i], lookupType.SQLString(), lj.desc.Columns[idx].Name,
+				tableTypes[idx].SQLString(),
+			)
+		}
+		lj.indexColIdx[i] = idx
+		neededCols[idx] = true
+	}
+
+	var err error
+	lj.index, _, err = initRowFetcher(
+		&lj.fetcher, &lj.desc, int(spec.IndexIdx), false /* reverse */, neededCols,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return lj, nil
+}
+
+// encodeLookupKey appends to key the encoding of the given values of the
+// first columns of the index, in the directions of the index columns.
+func (lj *lookupJoiner) encodeLookupKey(
+	key roachpb.Key, row sqlbase.EncDatumRow, colIdx []int,
+) (roachpb.Key, error) {
+	for i, idx := range colIdx {
+		enc := sqlbase.DatumEncoding_ASCENDING_KEY
+		if lj.index.ColumnDirections[i] == sqlbase.IndexDescriptor_DESC {
+			enc = sqlbase.DatumEncoding_DESCENDING_KEY
+		}
+		var err error
+		key, err = row[idx].Encode(&lj.alloc, enc, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// hasNullLookupValue returns true if one of the lookup columns of an input
+// row is NULL; such a row can't match any row of the table.
+func (lj *lookupJoiner) hasNullLookupValue(row sqlbase.EncDatumRow) bool {
+	for _, c := range lj.lookupCols {
+		if row[c].IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+// mainLoop runs the mainLoop and returns any error.
+//
+// If no error is returned, the input has been drained and the output has been
+// closed. If an error is returned, the input hasn't been drained; the caller
+// should drain and close the output. The caller should also pass the returned
+// error to the consumer.
+func (lj *lookupJoiner) mainLoop(ctx context.Context) error {
+	indexKeyPrefix := sqlbase.MakeIndexKeyPrefix(&lj.desc, lj.index.ID)
+	lookupColIdx := make([]int, len(lj.lookupCols))
+	for i, c := range lj.lookupCols {
+		lookupColIdx[i] = int(c)
+	}
+
+	batch := make([]sqlbase.EncDatumRow, 0, joinReaderBatchSize)
+	matched := make([]bool, 0, joinReaderBatchSize)
+	// rowsByKey maps the lookup keys of the batch to the input rows that have
+	// that key.
+	rowsByKey := make(map[string][]int, joinReaderBatchSize)
+	spans := make(roachpb.Spans, 0, joinReaderBatchSize)
+	var keyScratch roachpb.Key
+
+	txn := lj.flowCtx.setupTxn()
+
+	log.VEventf(ctx, 1, "starting")
+	if log.V(1) {
+		defer log.Infof(ctx, "exiting")
+	}
+
+	for {
+		batch = batch[:0]
+		spans = spans[:0]
+		for k := range rowsByKey {
+			delete(rowsByKey, k)
+		}
+		for len(batch) < joinReaderBatchSize {
+			row, meta := lj.leftSource.Next()
+			if !meta.Empty() {
+				if meta.Err != nil {
+					return meta.Err
+				}
+				if !emitHelper(ctx, &lj.out, nil /* row */, meta, lj.leftSource) {
+					return nil
+				}
+				continue
+			}
+			if row == nil {
+				break
+			}
+			rowIdx := len(batch)
+			batch = append(batch, lj.rowAlloc.CopyRow(row))
+			if lj.hasNullLookupValue(row) {
+				continue
+			}
+
+			key, err := lj.encodeLookupKey(
+				append(roachpb.Key(nil), indexKeyPrefix...), row, lookupColIdx,
+			)
+			if err != nil {
+				return err
+			}
+			rows, ok := rowsByKey[string(key)]
+			if !ok {
+				spans = append(spans, roachpb.Span{Key: key, EndKey: key.PrefixEnd()})
+			}
+			rowsByKey[string(key)] = append(rows, rowIdx)
+		}
+		if len(batch) == 0 {
+			lj.out.close()
+			return nil
+		}
+		matched = matched[:len(batch)]
+		for i := range matched {
+			matched[i] = false
+		}
+
+		if len(spans) > 0 {
+			sort.Sort(spans)
+			if err := lj.fetcher.StartScan(
+				ctx, txn, spans, false /* no batch limits */, 0,
+			); err != nil {
+				log.Errorf(ctx, "scan error: %s", err)
+				return err
+			}
+		}
+		for len(spans) > 0 {
+			// TODO(radu,andrei,knz): set the traceKV flag when requested by the session.
+			fetcherRow, err := lj.fetcher.NextRow(ctx, false /* traceKV */)
+			if err != nil {
+				return err
+			}
+			if fetcherRow == nil {
+				// Done with this batch.
+				break
+			}
+
+			keyScratch, err = lj.encodeLookupKey(
+				append(keyScratch[:0], indexKeyPrefix...), fetcherRow, lj.indexColIdx,
+			)
+			if err != nil {
+				return err
+			}
+			for _, rowIdx := range rowsByKey[string(keyScratch)] {
+				renderedRow, err := lj.render(batch[rowIdx], fetcherRow)
+				if err != nil {
+					return err
+				}
+				if renderedRow == nil {
+					continue
+				}
+				matched[rowIdx] = true
+				if !emitHelper(ctx, &lj.out, renderedRow, ProducerMetadata{}, lj.leftSource) {
+					return nil
+				}
+			}
+		}
+
+		for i, row := range batch {
+			if !matched[i] && !lj.maybeEmitUnmatchedRow(ctx, row, leftSide) {
+				DrainAndClose(ctx, lj.out.output, nil /* cause */, lj.leftSource)
+				return nil
+			}
+		}
+
+		if len(batch) != joinReaderBatchSize {
+			// This was the last batch.
+			lj.out.close()
+			return nil
+		}
+	}
+}
+
+// kvStats is part of the kvStatsReporter interface.
+func (lj *lookupJoiner) kvStats() (requests int64, bytesRead int64) {
+	return lj.fetcher.KVStats()
+}
+
+// Run is part of the processor interface.
+func (lj *lookupJoiner) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTagInt(ctx, "LookupJoiner", int(lj.desc.ID))
+	ctx, span := tracing.ChildSpan(ctx, "lookup joiner")
+	defer tracing.FinishSpan(span)
+
+	err := lj.mainLoop(ctx)
+	if err != nil {
+		DrainAndClose(ctx, lj.out.output, err /* cause */, lj.leftSource)
+	}
+}
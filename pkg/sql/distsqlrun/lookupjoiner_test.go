@@ -0,0 +1,161 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestLookupJoiner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	// Create a table where each row is:
+	//
+	//  |     a    |     b    |         sum         |         s           |
+	//  |-----------------------------------------------------------------|
+	//  | rowId/10 | rowId%10 | rowId/10 + rowId%10 | IntToEnglish(rowId) |
+
+	aFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row / 10))
+	}
+	bFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row % 10))
+	}
+	sumFn := func(row int) parser.Datum {
+		return parser.NewDInt(parser.DInt(row/10 + row%10))
+	}
+
+	sqlutils.CreateTable(t, sqlDB, "t",
+		"a INT, b INT, sum INT, s STRING, PRIMARY KEY (a,b), INDEX bs (b,s)",
+		99,
+		sqlutils.ToRowFn(aFn, bFn, sumFn, sqlutils.RowEnglishFn))
+
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	intType := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	dInt := func(i int) parser.Datum {
+		return parser.NewDInt(parser.DInt(i))
+	}
+
+	// The internal columns of the lookup joiner are the input column followed
+	// by the table columns: @1 is the input column, @2 to @5 are a, b, sum and
+	// s.
+	testCases := []struct {
+		name     string
+		spec     LookupJoinerSpec
+		post     PostProcessSpec
+		input    []parser.Datum
+		expected string
+	}{
+		{
+			// Look up a prefix of the primary key.
+			name: "InnerPrimary",
+			spec: LookupJoinerSpec{
+				IndexIdx:      0,
+				LookupColumns: []uint32{0},
+				OnExpr:        Expression{Expr: "@3 < 2"},
+				Type:          JoinType_INNER,
+			},
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{0, 2},
+			},
+			input:    []parser.Datum{dInt(3), dInt(1), parser.DNull, dInt(100), dInt(3)},
+			expected: "[[1 0] [1 1] [3 0] [3 0] [3 1] [3 1]]",
+		},
+		{
+			// Look up rows in the secondary index; the rows without matches are
+			// output with NULLs.
+			name: "LeftOuterSecondary",
+			spec: LookupJoinerSpec{
+				IndexIdx:      1,
+				LookupColumns: []uint32{0},
+				OnExpr:        Expression{Expr: "@2 = 1"},
+				Type:          JoinType_LEFT_OUTER,
+			},
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{0, 4},
+			},
+			input:    []parser.Datum{dInt(5), parser.DNull, dInt(11)},
+			expected: "[[5 'one-five'] [NULL NULL] [11 NULL]]",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(context.Background())
+			flowCtx := FlowCtx{
+				evalCtx:  evalCtx,
+				txnProto: &roachpb.Transaction{},
+				// Pass a DB without a TxnCoordSender.
+				remoteTxnDB: client.NewDB(s.DistSender(), s.Clock()),
+			}
+
+			rows := make(sqlbase.EncDatumRows, len(c.input))
+			for i, d := range c.input {
+				rows[i] = sqlbase.EncDatumRow{sqlbase.DatumToEncDatum(intType, d)}
+			}
+			in := NewRowBuffer([]sqlbase.ColumnType{intType}, rows, RowBufferArgs{})
+			out := &RowBuffer{}
+
+			spec := c.spec
+			spec.Table = *td
+			lj, err := newLookupJoiner(&flowCtx, &spec, in, &c.post, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lj.Run(context.Background(), nil)
+
+			if !in.Done {
+				t.Fatal("lookupJoiner didn't consume all the rows")
+			}
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+
+			var res sqlbase.EncDatumRows
+			for {
+				row, meta := out.Next()
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				res = append(res, row)
+			}
+
+			if result := res.String(); result != c.expected {
+				t.Errorf("invalid results: %s, expected %s", result, c.expected)
+			}
+		})
+	}
+}
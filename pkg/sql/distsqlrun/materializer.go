@@ -0,0 +1,181 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// metadataSource is implemented by the vectorized operators which read from
+// outside of the operator tree (the KV layer or row inputs) and need the
+// context of the processor running them or produce metadata.
+type metadataSource interface {
+	// start is called before the operators are initialized.
+	start(ctx context.Context)
+	// drainMeta returns the metadata produced since the last call. done is set
+	// on the last call, once the operators are no longer used.
+	drainMeta(done bool) []ProducerMetadata
+}
+
+// materializer is the processor running a tree of vectorized operators. It
+// converts the batches produced by the root operator into rows, and pushes
+// them to its output along with the metadata of the operators.
+type materializer struct {
+	flowCtx *FlowCtx
+	input   exec.Operator
+	// types are the types of the columns of the batches produced by input.
+	types []sqlbase.ColumnType
+	// inputs are the row inputs of the operators, which are drained when the
+	// materializer stops early.
+	inputs          []RowSource
+	metadataSources []metadataSource
+
+	out procOutputHelper
+
+	row sqlbase.EncDatumRow
+	da  sqlbase.DatumAlloc
+}
+
+var _ processor = &materializer{}
+var _ kvStatsReporter = &materializer{}
+
+// newMaterializer creates a materializer. Only the offset and limit of the
+// post-processing spec are applied; filters and projections must have been
+// compiled into the operators.
+func newMaterializer(
+	flowCtx *FlowCtx,
+	input exec.Operator,
+	types []sqlbase.ColumnType,
+	inputs []RowSource,
+	metadataSources []metadataSource,
+	post *PostProcessSpec,
+	output RowReceiver,
+) (*materializer, error) {
+	m := &materializer{
+		flowCtx:         flowCtx,
+		input:           input,
+		types:           types,
+		inputs:          inputs,
+		metadataSources: metadataSources,
+		row:             make(sqlbase.EncDatumRow, len(types)),
+	}
+	if err := m.out.init(
+		&PostProcessSpec{Offset: post.Offset, Limit: post.Limit}, types, &flowCtx.evalCtx, output,
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Run is part of the processor interface.
+func (m *materializer) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx, span := tracing.ChildSpan(ctx, "materializer")
+	defer tracing.FinishSpan(span)
+
+	log.VEventf(ctx, 1, "starting")
+	if log.V(1) {
+		defer log.Infof(ctx, "exiting")
+	}
+
+	for _, s := range m.metadataSources {
+		s.start(ctx)
+	}
+	status := NeedMoreRows
+	err := exec.CatchError(func() {
+		m.input.Init()
+		for status == NeedMoreRows {
+			batch := m.input.Next()
+			status = m.pushMetadata(false /* done */)
+			n := batch.Length()
+			if n == 0 {
+				return
+			}
+			sel := batch.Selection()
+			for k := 0; k < int(n) && status == NeedMoreRows; k++ {
+				i := k
+				if sel != nil {
+					i = int(sel[k])
+				}
+				for j, typ := range m.types {
+					m.row[j] = sqlbase.DatumToEncDatum(typ, vecDatum(batch.ColVec(j), i, typ, &m.da))
+				}
+				var err error
+				status, err = m.out.emitRow(ctx, m.row)
+				if err != nil {
+					exec.PanicError(err)
+				}
+			}
+		}
+	})
+
+	if err != nil {
+		m.pushMetadata(true /* done */)
+		DrainAndClose(ctx, m.out.output, err, m.inputs...)
+		return
+	}
+	switch status {
+	case NeedMoreRows:
+		// The operators are exhausted.
+		m.pushMetadata(true /* done */)
+		m.out.close()
+	case DrainRequested:
+		log.VEventf(ctx, 1, "no more rows required. drain requested.")
+		m.pushMetadata(true /* done */)
+		DrainAndClose(ctx, m.out.output, nil /* cause */, m.inputs...)
+	case ConsumerClosed:
+		log.VEventf(ctx, 1, "no more rows required. Consumer shut down.")
+		for _, input := range m.inputs {
+			input.ConsumerClosed()
+		}
+		m.out.close()
+	}
+}
+
+// pushMetadata pushes the metadata produced by the operators to the output,
+// and returns the status of the consumer.
+func (m *materializer) pushMetadata(done bool) ConsumerStatus {
+	status := NeedMoreRows
+	for _, s := range m.metadataSources {
+		for _, meta := range s.drainMeta(done) {
+			if r := m.out.output.Push(nil /* row */, meta); r != NeedMoreRows {
+				status = r
+			}
+		}
+	}
+	return status
+}
+
+// kvStats is part of the kvStatsReporter interface.
+func (m *materializer) kvStats() (requests int64, bytesRead int64) {
+	for _, s := range m.metadataSources {
+		if r, ok := s.(kvStatsReporter); ok {
+			req, b := r.kvStats()
+			requests += req
+			bytesRead += b
+		}
+	}
+	return requests, bytesRead
+}
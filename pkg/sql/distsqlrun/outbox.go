@@ -57,6 +57,11 @@ type outbox struct {
 	// numRows is the number of rows that have been accumulated in the encoder.
 	numRows int
 
+	// flowCtxCancel cancels the context of the flow. It is called when the
+	// consumer closes the stream with an error, e.g. because its query was
+	// canceled, so that the processors of the flow stop promptly.
+	flowCtxCancel context.CancelFunc
+
 	err error
 	wg  *sync.WaitGroup
 }
@@ -242,6 +247,9 @@ func (m *outbox) mainLoop(ctx context.Context) error {
 				// the stream is not used any more.
 				m.stream = nil
 				m.syncFlowStream = nil
+				if m.flowCtxCancel != nil {
+					m.flowCtxCancel()
+				}
 				return drainSignal.err
 			}
 			drainCh = nil
@@ -336,6 +336,13 @@ func newProcessor(
 	inputs []RowSource,
 	outputs []RowReceiver,
 ) (processor, error) {
+	if flowCtx.vectorize {
+		// Fall back to the row processors if the core, the types or the
+		// expressions are not supported by the vectorized operators.
+		if p, err := newVectorizedProcessor(flowCtx, core, post, inputs, outputs); err == nil {
+			return p, nil
+		}
+	}
 	if core.Noop != nil {
 		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
 			return nil, err
@@ -409,6 +416,36 @@ func newProcessor(
 		}
 		return newAlgebraicSetOp(flowCtx, core.SetOp, inputs[0], inputs[1], post, outputs[0])
 	}
+	if core.Sampler != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newSampler(flowCtx, core.Sampler, inputs[0], post, outputs[0])
+	}
+	if core.SampleAggregator != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newSampleAggregator(flowCtx, core.SampleAggregator, inputs[0], post, outputs[0])
+	}
+	if core.LookupJoiner != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newLookupJoiner(flowCtx, core.LookupJoiner, inputs[0], post, outputs[0])
+	}
+	if core.Windower != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newWindower(flowCtx, core.Windower, inputs[0], post, outputs[0])
+	}
+	if core.TableWriter != nil {
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		return newTableWriter(flowCtx, core.TableWriter, inputs[0], post, outputs[0])
+	}
 	return nil, errors.Errorf("unsupported processor core %s", core)
 }
 
@@ -0,0 +1,207 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+var sampleAggregatorColumnTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_INT},   // column ID
+	{Kind: sqlbase.ColumnType_INT},   // number of rows
+	{Kind: sqlbase.ColumnType_INT},   // number of distinct values
+	{Kind: sqlbase.ColumnType_INT},   // number of NULLs
+	{Kind: sqlbase.ColumnType_BYTES}, // histogram
+}
+
+// sampleAggregator is a processor which aggregates the outputs of samplers
+// and computes the statistics of each column. See SampleAggregatorSpec.
+type sampleAggregator struct {
+	flowCtx    *FlowCtx
+	input      RowSource
+	inTypes    []sqlbase.ColumnType
+	columnIDs  []uint32
+	sampleSize int
+	maxBuckets int
+	datumAlloc sqlbase.DatumAlloc
+	out        procOutputHelper
+}
+
+var _ processor = &sampleAggregator{}
+
+func newSampleAggregator(
+	flowCtx *FlowCtx,
+	spec *SampleAggregatorSpec,
+	input RowSource,
+	post *PostProcessSpec,
+	output RowReceiver,
+) (*sampleAggregator, error) {
+	inTypes := input.Types()
+	if numCols := len(inTypes) - numSamplerColumns; numCols != len(spec.ColumnIDs) {
+		return nil, errors.Errorf("expected %d column IDs, got %d", numCols, len(spec.ColumnIDs))
+	}
+	if spec.SampleSize == 0 {
+		return nil, errors.Errorf("invalid sample size %d", spec.SampleSize)
+	}
+	if spec.MaxHistogramBuckets == 0 {
+		return nil, errors.Errorf("invalid maximum number of buckets %d", spec.MaxHistogramBuckets)
+	}
+	s := &sampleAggregator{
+		flowCtx:    flowCtx,
+		input:      input,
+		inTypes:    inTypes,
+		columnIDs:  spec.ColumnIDs,
+		sampleSize: int(spec.SampleSize),
+		maxBuckets: int(spec.MaxHistogramBuckets),
+	}
+	if err := s.out.init(post, sampleAggregatorColumnTypes, &flowCtx.evalCtx, output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run is part of the processor interface.
+func (s *sampleAggregator) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTag(ctx, "SampleAggregator", nil)
+	ctx, span := tracing.ChildSpan(ctx, "sample aggregator")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting sample aggregator")
+		defer log.Infof(ctx, "exiting sample aggregator")
+	}
+
+	numCols := len(s.columnIDs)
+	rankCol := numCols
+	sample := makeRowSample(s.sampleSize)
+	sketches := make([]*stats.DistinctSketch, numCols)
+	numRows := make([]int64, numCols)
+	numNulls := make([]int64, numCols)
+
+	for {
+		row, meta := s.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				DrainAndClose(ctx, s.out.output, meta.Err, s.input)
+				return
+			}
+			if !emitHelper(ctx, &s.out, nil /* row */, meta, s.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+		for i := rankCol; i < len(row); i++ {
+			if err := row[i].EnsureDecoded(&s.datumAlloc); err != nil {
+				DrainAndClose(ctx, s.out.output, err, s.input)
+				return
+			}
+		}
+		if row[rankCol].Datum != parser.DNull {
+			// This is a sample row.
+			sample.add(row[:numCols], int64(*row[rankCol].Datum.(*parser.DInt)))
+			continue
+		}
+		// This is a sketch row.
+		col := int(*row[rankCol+1].Datum.(*parser.DInt))
+		if col < 0 || col >= numCols {
+			DrainAndClose(ctx, s.out.output, errors.Errorf("invalid column index %d", col), s.input)
+			return
+		}
+		numRows[col] += int64(*row[rankCol+2].Datum.(*parser.DInt))
+		numNulls[col] += int64(*row[rankCol+3].Datum.(*parser.DInt))
+		var sketch stats.DistinctSketch
+		if err := sketch.UnmarshalBinary([]byte(*row[rankCol+4].Datum.(*parser.DBytes))); err != nil {
+			DrainAndClose(ctx, s.out.output, err, s.input)
+			return
+		}
+		if sketches[col] == nil {
+			sketches[col] = &sketch
+		} else {
+			sketches[col].Merge(&sketch)
+		}
+	}
+
+	outRow := make(sqlbase.EncDatumRow, len(sampleAggregatorColumnTypes))
+	for i := 0; i < numCols; i++ {
+		var distinctCount int64
+		if sketches[i] != nil {
+			distinctCount = sketches[i].Estimate()
+		}
+		histogram, err := s.histogram(i, sample.rows, numRows[i]-numNulls[i])
+		if err != nil {
+			DrainAndClose(ctx, s.out.output, err)
+			return
+		}
+		outRow[0] = sqlbase.DatumToEncDatum(sampleAggregatorColumnTypes[0], parser.NewDInt(parser.DInt(s.columnIDs[i])))
+		outRow[1] = sqlbase.DatumToEncDatum(sampleAggregatorColumnTypes[1], parser.NewDInt(parser.DInt(numRows[i])))
+		outRow[2] = sqlbase.DatumToEncDatum(sampleAggregatorColumnTypes[2], parser.NewDInt(parser.DInt(distinctCount)))
+		outRow[3] = sqlbase.DatumToEncDatum(sampleAggregatorColumnTypes[3], parser.NewDInt(parser.DInt(numNulls[i])))
+		outRow[4] = sqlbase.DatumToEncDatum(sampleAggregatorColumnTypes[4], histogram)
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}) {
+			return
+		}
+	}
+	s.out.close()
+}
+
+// histogram builds the histogram of the given column from the non-NULL
+// values of the sample. It returns the marshaled histogram, or NULL if the
+// histogram is empty or not supported for the type of the column.
+func (s *sampleAggregator) histogram(
+	col int, rows []sampledRow, numRows int64,
+) (parser.Datum, error) {
+	if !stats.HistogramSupported(s.inTypes[col].ToDatumType()) {
+		return parser.DNull, nil
+	}
+	values := make(parser.Datums, 0, len(rows))
+	for _, r := range rows {
+		ed := &r.row[col]
+		if err := ed.EnsureDecoded(&s.datumAlloc); err != nil {
+			return nil, err
+		}
+		if ed.Datum != parser.DNull {
+			values = append(values, ed.Datum)
+		}
+	}
+	h, err := stats.EquiDepthHistogram(&s.flowCtx.evalCtx, values, numRows, s.maxBuckets)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Buckets) == 0 {
+		return parser.DNull, nil
+	}
+	encoded, err := h.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return parser.NewDBytes(parser.DBytes(encoded)), nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// numSamplerColumns is the number of columns output by a sampler in addition
+// to the input columns; see SamplerSpec.
+const numSamplerColumns = 5
+
+var samplerColumnTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_INT},   // rank
+	{Kind: sqlbase.ColumnType_INT},   // column index
+	{Kind: sqlbase.ColumnType_INT},   // number of rows
+	{Kind: sqlbase.ColumnType_INT},   // number of NULLs
+	{Kind: sqlbase.ColumnType_BYTES}, // sketch
+}
+
+// sampler is a processor which computes a random sample of its input rows,
+// along with a distinct count sketch and the number of NULLs of each column.
+// See SamplerSpec.
+type sampler struct {
+	flowCtx    *FlowCtx
+	input      RowSource
+	sampleSize int
+	sketchSize int
+	rng        *rand.Rand
+	datumAlloc sqlbase.DatumAlloc
+	out        procOutputHelper
+}
+
+var _ processor = &sampler{}
+
+func newSampler(
+	flowCtx *FlowCtx, spec *SamplerSpec, input RowSource, post *PostProcessSpec, output RowReceiver,
+) (*sampler, error) {
+	if spec.SampleSize == 0 {
+		return nil, errors.Errorf("invalid sample size %d", spec.SampleSize)
+	}
+	if spec.SketchSize == 0 {
+		return nil, errors.Errorf("invalid sketch size %d", spec.SketchSize)
+	}
+	s := &sampler{
+		flowCtx:    flowCtx,
+		input:      input,
+		sampleSize: int(spec.SampleSize),
+		sketchSize: int(spec.SketchSize),
+		rng:        rand.New(rand.NewSource(rand.Int63())),
+	}
+	types := append(append([]sqlbase.ColumnType(nil), input.Types()...), samplerColumnTypes...)
+	if err := s.out.init(post, types, &flowCtx.evalCtx, output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run is part of the processor interface.
+func (s *sampler) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTag(ctx, "Sampler", nil)
+	ctx, span := tracing.ChildSpan(ctx, "sampler")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting sampler")
+		defer log.Infof(ctx, "exiting sampler")
+	}
+
+	numCols := len(s.input.Types())
+	sample := makeRowSample(s.sampleSize)
+	sketches := make([]*stats.DistinctSketch, numCols)
+	for i := range sketches {
+		sketches[i] = stats.NewDistinctSketch(s.sketchSize)
+	}
+	numNulls := make([]int64, numCols)
+	var numRows int64
+
+	var scratch []byte
+	for {
+		row, meta := s.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				DrainAndClose(ctx, s.out.output, meta.Err, s.input)
+				return
+			}
+			if !emitHelper(ctx, &s.out, nil /* row */, meta, s.input) {
+				// No cleanup required; emitHelper() took care of it.
+				return
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+		numRows++
+
+		for i := range row {
+			if row[i].IsNull() {
+				numNulls[i]++
+				continue
+			}
+			// The value encoding is used because, unlike the key encoding, it
+			// is supported by all the column types. Equal values have equal
+			// encodings.
+			var err error
+			scratch, err = row[i].Encode(&s.datumAlloc, sqlbase.DatumEncoding_VALUE, scratch[:0])
+			if err != nil {
+				DrainAndClose(ctx, s.out.output, err, s.input)
+				return
+			}
+			sketches[i].Add(scratch)
+		}
+		sample.add(row, s.rng.Int63())
+	}
+
+	// Emit the sample rows.
+	outRow := make(sqlbase.EncDatumRow, numCols+numSamplerColumns)
+	for _, r := range sample.rows {
+		copy(outRow, r.row)
+		outRow[numCols] = sqlbase.DatumToEncDatum(samplerColumnTypes[0], parser.NewDInt(parser.DInt(r.rank)))
+		for i := 1; i < numSamplerColumns; i++ {
+			outRow[numCols+i] = sqlbase.DatumToEncDatum(samplerColumnTypes[i], parser.DNull)
+		}
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}) {
+			return
+		}
+	}
+
+	// Emit one row per column with the counts and the sketch.
+	for i := 0; i < numCols; i++ {
+		sketch, err := sketches[i].MarshalBinary()
+		if err != nil {
+			DrainAndClose(ctx, s.out.output, err)
+			return
+		}
+		for j := 0; j < numCols; j++ {
+			outRow[j] = sqlbase.DatumToEncDatum(s.input.Types()[j], parser.DNull)
+		}
+		outRow[numCols] = sqlbase.DatumToEncDatum(samplerColumnTypes[0], parser.DNull)
+		outRow[numCols+1] = sqlbase.DatumToEncDatum(samplerColumnTypes[1], parser.NewDInt(parser.DInt(i)))
+		outRow[numCols+2] = sqlbase.DatumToEncDatum(samplerColumnTypes[2], parser.NewDInt(parser.DInt(numRows)))
+		outRow[numCols+3] = sqlbase.DatumToEncDatum(samplerColumnTypes[3], parser.NewDInt(parser.DInt(numNulls[i])))
+		outRow[numCols+4] = sqlbase.DatumToEncDatum(samplerColumnTypes[4], parser.NewDBytes(parser.DBytes(sketch)))
+		if !emitHelper(ctx, &s.out, outRow, ProducerMetadata{}) {
+			return
+		}
+	}
+	s.out.close()
+}
+
+// sampledRow is a row of a rowSample, with its random rank.
+type sampledRow struct {
+	row  sqlbase.EncDatumRow
+	rank int64
+}
+
+// rowSample keeps the rows with the smallest ranks among the rows which are
+// added to it. If the ranks are random, the result is a uniform random sample
+// of the rows. Samples computed on disjoint sets of rows can be combined by
+// adding the rows of one sample, with their ranks, to the other.
+type rowSample struct {
+	size int
+	// rows is a max-heap of the sampled rows, ordered by rank.
+	rows []sampledRow
+}
+
+var _ heap.Interface = &rowSample{}
+
+func makeRowSample(size int) rowSample {
+	return rowSample{size: size, rows: make([]sampledRow, 0, size)}
+}
+
+// add adds a row to the sample, if its rank is small enough. The row is copied.
+func (rs *rowSample) add(row sqlbase.EncDatumRow, rank int64) {
+	if len(rs.rows) < rs.size {
+		heap.Push(rs, sampledRow{row: append(sqlbase.EncDatumRow(nil), row...), rank: rank})
+		return
+	}
+	if rank < rs.rows[0].rank {
+		rs.rows[0].row = append(rs.rows[0].row[:0], row...)
+		rs.rows[0].rank = rank
+		heap.Fix(rs, 0)
+	}
+}
+
+func (rs *rowSample) Len() int           { return len(rs.rows) }
+func (rs *rowSample) Less(i, j int) bool { return rs.rows[i].rank > rs.rows[j].rank }
+func (rs *rowSample) Swap(i, j int)      { rs.rows[i], rs.rows[j] = rs.rows[j], rs.rows[i] }
+
+func (rs *rowSample) Push(x interface{}) { rs.rows = append(rs.rows, x.(sampledRow)) }
+
+func (rs *rowSample) Pop() interface{} {
+	n := len(rs.rows)
+	x := rs.rows[n-1]
+	rs.rows = rs.rows[:n-1]
+	return x
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func readRows(t *testing.T, out *RowBuffer) sqlbase.EncDatumRows {
+	if !out.ProducerClosed {
+		t.Fatalf("output RowReceiver not closed")
+	}
+	var res sqlbase.EncDatumRows
+	for {
+		row, meta := out.Next()
+		if !meta.Empty() {
+			t.Fatalf("unexpected metadata: %v", meta)
+		}
+		if row == nil {
+			return res
+		}
+		res = append(res, row)
+	}
+}
+
+// TestSampler runs two samplers on disjoint sets of rows and aggregates their
+// results.
+func TestSampler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numRows = 1000
+	const sampleSize = 50
+	intType := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	types := []sqlbase.ColumnType{intType, intType}
+
+	// The first column has distinct values; the second column has 10 values,
+	// one of which is NULL.
+	var inputs [2]sqlbase.EncDatumRows
+	for i := 0; i < numRows; i++ {
+		second := parser.Datum(parser.DNull)
+		if i%10 != 0 {
+			second = parser.NewDInt(parser.DInt(i % 10))
+		}
+		inputs[i%2] = append(inputs[i%2], sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(i))),
+			sqlbase.DatumToEncDatum(intType, second),
+		})
+	}
+
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(context.Background())
+	flowCtx := FlowCtx{evalCtx: evalCtx}
+
+	var samplerOutput sqlbase.EncDatumRows
+	for _, input := range inputs {
+		in := NewRowBuffer(types, input, RowBufferArgs{})
+		out := &RowBuffer{}
+		spec := SamplerSpec{SampleSize: sampleSize, SketchSize: 2000}
+		s, err := newSampler(&flowCtx, &spec, in, &PostProcessSpec{}, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Run(context.Background(), nil)
+		rows := readRows(t, out)
+		// There must be a full sample, followed by a row per column.
+		if len(rows) != sampleSize+len(types) {
+			t.Fatalf("expected %d rows, got %d", sampleSize+len(types), len(rows))
+		}
+		samplerOutput = append(samplerOutput, rows...)
+	}
+
+	in := NewRowBuffer(append(types, samplerColumnTypes...), samplerOutput, RowBufferArgs{})
+	out := &RowBuffer{}
+	spec := SampleAggregatorSpec{SampleSize: sampleSize, ColumnIDs: []uint32{1, 2}, MaxHistogramBuckets: 4}
+	agg, err := newSampleAggregator(&flowCtx, &spec, in, &PostProcessSpec{}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg.Run(context.Background(), nil)
+	rows := readRows(t, out)
+
+	expected := []struct {
+		columnID, rowCount, distinctCount, nullCount int64
+	}{
+		{1, numRows, numRows, 0},
+		{2, numRows, 9, numRows / 10},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(rows))
+	}
+	var alloc sqlbase.DatumAlloc
+	for i, exp := range expected {
+		row := rows[i]
+		for j := range row {
+			if err := row[j].EnsureDecoded(&alloc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for j, v := range []int64{exp.columnID, exp.rowCount, exp.distinctCount, exp.nullCount} {
+			if res := int64(*row[j].Datum.(*parser.DInt)); res != v {
+				t.Errorf("column %d: expected %d in position %d, got %d", exp.columnID, v, j, res)
+			}
+		}
+		var h stats.HistogramData
+		if err := h.Unmarshal([]byte(*row[4].Datum.(*parser.DBytes))); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(h.Buckets); n < 1 || n > 4 {
+			t.Errorf("column %d: invalid number of buckets %d", exp.columnID, n)
+		}
+	}
+}
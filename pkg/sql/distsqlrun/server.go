@@ -28,9 +28,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
@@ -69,6 +71,16 @@ const MinAcceptedVersion = 4
 
 var noteworthyMemoryUsageBytes = envutil.EnvOrDefaultInt64("COCKROACH_NOTEWORTHY_DISTSQL_MEMORY_USAGE", 10*1024)
 
+// QueryMemoryLimit caps the memory used by a single query on any one
+// node. It applies to the statement monitors of the gateway's sessions as
+// well as to the monitor of every DistSQL flow.
+var QueryMemoryLimit = settings.RegisterByteSizeSetting(
+	"sql.memory.query_limit",
+	"maximum amount of memory a single query may use on each node; "+
+		"zero disables the limit",
+	0,
+)
+
 // ServerConfig encompasses the configuration required to create a
 // DistSQLServer.
 type ServerConfig struct {
@@ -90,6 +102,9 @@ type ServerConfig struct {
 	Hist                *metric.Histogram
 	// NodeID is the id of the node on which this Server is running.
 	NodeID *base.NodeIDContainer
+	// TempStorage is the engine processors use to store rows that do not fit
+	// in memory. If nil, processors fail once they exceed their memory budget.
+	TempStorage engine.Engine
 }
 
 // ServerImpl implements the server for the distributed SQL APIs.
@@ -99,6 +114,8 @@ type ServerImpl struct {
 	flowScheduler *flowScheduler
 	memMonitor    mon.MemoryMonitor
 	regexpCache   *parser.RegexpCache
+	// tempStorageUsage is shared by the flows of the server.
+	tempStorageUsage tempStorageUsage
 }
 
 var _ DistSQLServer = &ServerImpl{}
@@ -158,6 +175,7 @@ func (ds *ServerImpl) setupFlow(
 	monitor := mon.MakeMonitor("flow",
 		ds.Counter, ds.Hist, -1 /* use default block size */, noteworthyMemoryUsageBytes)
 	monitor.Start(ctx, &ds.memMonitor, mon.BoundAccount{})
+	monitor.SetLimit(QueryMemoryLimit.Get())
 	acc := monitor.MakeBoundAccount()
 
 	location, err := sqlbase.TimeZoneStringToLocation(req.EvalContext.Location)
@@ -186,23 +204,30 @@ func (ds *ServerImpl) setupFlow(
 	// TODO(radu): we should sanity check some of these fields (especially
 	// txnProto).
 	flowCtx := FlowCtx{
-		AmbientContext: ds.AmbientContext,
-		id:             req.Flow.FlowID,
-		evalCtx:        evalCtx,
-		rpcCtx:         ds.RPCContext,
-		txnProto:       &req.Txn,
-		clientDB:       ds.DB,
-		remoteTxnDB:    ds.FlowDB,
-		testingKnobs:   ds.TestingKnobs,
-		nodeID:         nodeID,
+		AmbientContext:   ds.AmbientContext,
+		id:               req.Flow.FlowID,
+		evalCtx:          evalCtx,
+		rpcCtx:           ds.RPCContext,
+		txnProto:         &req.Txn,
+		clientDB:         ds.DB,
+		remoteTxnDB:      ds.FlowDB,
+		testingKnobs:     ds.TestingKnobs,
+		nodeID:           nodeID,
+		collectStats:     req.CollectStats,
+		vectorize:        req.Vectorize,
+		tempStorage:      ds.TempStorage,
+		tempStorageUsage: &ds.tempStorageUsage,
 	}
 
 	ctx = flowCtx.AnnotateCtx(ctx)
 
 	f := newFlow(flowCtx, ds.flowRegistry, syncFlowConsumer)
 	flowCtx.AddLogTagStr("f", f.id.Short())
+	ctx, f.ctxCancel = context.WithCancel(ctx)
+	f.ctxDone = ctx.Done()
 	if err := f.setup(ctx, &req.Flow); err != nil {
 		log.Errorf(ctx, "error setting up flow: %s", err)
+		f.ctxCancel()
 		tracing.FinishSpan(sp)
 		ctx = opentracing.ContextWithSpan(ctx, nil)
 		return ctx, nil, err
@@ -243,6 +268,7 @@ func (ds *ServerImpl) RunSyncFlow(stream DistSQL_RunSyncFlowServer) error {
 		return err
 	}
 	mbox.setFlowCtx(&f.FlowCtx)
+	mbox.flowCtxCancel = f.ctxCancel
 
 	if err := ds.Stopper.RunTask(ctx, "distsqlrun.ServerImpl: sync flow", func(ctx context.Context) {
 		f.waitGroup.Add(1)
@@ -303,9 +329,30 @@ func (ds *ServerImpl) flowStreamInt(ctx context.Context, stream DistSQL_FlowStre
 	if err != nil {
 		return err
 	}
-	defer cleanup()
 	log.VEventf(ctx, 1, "connected inbound stream %s/%d", flowID.Short(), streamID)
-	return ProcessInboundStream(f.AnnotateCtx(ctx), stream, msg, receiver)
+
+	// The stream is processed asynchronously so that the RPC can return as
+	// soon as the flow is canceled. This closes the stream, which in turn
+	// cancels the flow of the producer on the other node. The blocked Recv
+	// then returns an error and the stream is cleaned up. The cleanup marks
+	// the inbound stream as finished in the wait group of the flow, so the
+	// flow isn't torn down before the task is done with the stream.
+	errCh := make(chan error, 1)
+	if err := ds.Stopper.RunAsyncTask(
+		ctx, "distsqlrun.ServerImpl: inbound stream", func(ctx context.Context) {
+			defer cleanup()
+			errCh <- ProcessInboundStream(f.AnnotateCtx(ctx), stream, msg, receiver)
+		},
+	); err != nil {
+		cleanup()
+		return err
+	}
+	select {
+	case err := <-errCh:
+		return err
+	case <-f.ctxDone:
+		return errors.Errorf("flow %s canceled", flowID.Short())
+	}
 }
 
 // FlowStream is part of the DistSQLServer interface.
@@ -119,3 +119,47 @@ func (s *sorter) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 	DrainAndClose(ctx, s.out.output, sortErr, s.rawInput)
 }
+
+// canSortOnDisk returns whether the error encountered when adding a row to the
+// in-memory container can be handled by finishing the sort on disk.
+func (s *sorter) canSortOnDisk(err error) bool {
+	return s.flowCtx.tempStorage != nil && isMemoryBudgetError(err)
+}
+
+// sortOnDisk finishes a sort whose rows don't fit in memory. The rows buffered
+// in memory, the row that didn't fit and the rest of the input are written to a
+// diskRowContainer, which returns them sorted; the in-memory container is
+// emptied to release its memory.
+func (s *sorter) sortOnDisk(
+	ctx context.Context, rows *rowContainer, row sqlbase.EncDatumRow,
+) error {
+	log.VEventf(ctx, 1, "sorting on disk after buffering %d rows", rows.Len())
+
+	d := makeDiskRowContainer(
+		s.flowCtx.tempStorage, s.flowCtx.tempStorageUsage, s.ordering, s.rawInput.Types(),
+	)
+	defer d.Close(ctx)
+	for i := 0; i < rows.Len(); i++ {
+		if err := d.AddRow(ctx, rows.EncRow(i)); err != nil {
+			return err
+		}
+	}
+	rows.Clear(ctx)
+
+	for row != nil {
+		if err := d.AddRow(ctx, row); err != nil {
+			return err
+		}
+		var err error
+		row, err = s.input.NextRow()
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Iterate(func(row sqlbase.EncDatumRow) (bool, error) {
+		// Push the row to the output; stop if they don't need more rows.
+		consumerStatus, err := s.out.emitRow(ctx, row)
+		return err != nil || consumerStatus != NeedMoreRows, err
+	})
+}
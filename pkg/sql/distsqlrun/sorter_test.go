@@ -17,12 +17,17 @@
 package distsqlrun
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 
 	"golang.org/x/net/context"
 )
@@ -213,3 +218,81 @@ func TestSorter(t *testing.T) {
 		})
 	}
 }
+
+// TestSorterOnDisk verifies that the sorter finishes the sort on disk once the
+// rows don't fit in its memory budget.
+func TestSorterOnDisk(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	tempEngine := engine.NewInMem(roachpb.Attributes{}, 0 /* cacheSize */)
+	defer tempEngine.Close()
+
+	rng, _ := randutil.NewPseudoRand()
+
+	// The budget holds about a fifth of the rows.
+	const numRows = 2000
+	const budget = 10 * 1024
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	types := []sqlbase.ColumnType{columnTypeInt}
+	input := make(sqlbase.EncDatumRows, numRows)
+	values := make([]int, numRows)
+	for i := range input {
+		values[i] = rng.Intn(numRows)
+		input[i] = sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(values[i]))),
+		}
+	}
+	sort.Ints(values)
+
+	spec := SorterSpec{
+		OutputOrdering: convertToSpecOrdering(
+			sqlbase.ColumnOrdering{{ColIdx: 0, Direction: encoding.Ascending}}),
+	}
+	for _, limit := range []int{0, numRows / 2} {
+		t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+			in := NewRowBuffer(types, input, RowBufferArgs{})
+			out := &RowBuffer{}
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(ctx)
+			monitor := makeTestingBudgetMonitor(ctx, budget)
+			defer monitor.Stop(ctx)
+			flowCtx := FlowCtx{evalCtx: evalCtx, tempStorage: tempEngine}
+			flowCtx.evalCtx.Mon = &monitor
+
+			post := PostProcessSpec{Limit: uint64(limit)}
+			s, err := newSorter(&flowCtx, &spec, in, &post, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx, nil)
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+
+			expected := values
+			if limit != 0 {
+				expected = values[:limit]
+			}
+			var alloc sqlbase.DatumAlloc
+			var results []int
+			for {
+				row, meta := out.Next()
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				if err := row[0].EnsureDecoded(&alloc); err != nil {
+					t.Fatal(err)
+				}
+				results = append(results, int(*row[0].Datum.(*parser.DInt)))
+			}
+			if fmt.Sprint(results) != fmt.Sprint(expected) {
+				t.Errorf("invalid results; expected:\n   %v\ngot:\n   %v", expected, results)
+			}
+		})
+	}
+}
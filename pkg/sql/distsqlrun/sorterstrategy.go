@@ -58,6 +58,8 @@ func newSortAllStrategy(rows rowContainer) sorterStrategy {
 //  - loads all rows into memory;
 //  - runs sort.Sort to sort rows in place;
 //  - sends each row out to the output stream.
+// If the rows don't fit in memory, the sort is finished on disk (see
+// sorter.sortOnDisk).
 func (ss *sortAllStrategy) Execute(ctx context.Context, s *sorter) error {
 	defer ss.rows.Close(ctx)
 	for {
@@ -69,7 +71,10 @@ func (ss *sortAllStrategy) Execute(ctx context.Context, s *sorter) error {
 			break
 		}
 		if err := ss.rows.AddRow(ctx, row); err != nil {
-			return err
+			if !s.canSortOnDisk(err) {
+				return err
+			}
+			return s.sortOnDisk(ctx, &ss.rows, row)
 		}
 	}
 	ss.rows.Sort()
@@ -136,7 +141,12 @@ func (ss *sortTopKStrategy) Execute(ctx context.Context, s *sorter) error {
 		if int64(ss.rows.Len()) < ss.k {
 			// Accumulate up to k values.
 			if err := ss.rows.AddRow(ctx, row); err != nil {
-				return err
+				if !s.canSortOnDisk(err) {
+					return err
+				}
+				// The consumer stops asking for rows once it has received k of
+				// them.
+				return s.sortOnDisk(ctx, &ss.rows, row)
 			}
 		} else {
 			if !heapCreated {
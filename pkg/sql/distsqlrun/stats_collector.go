@@ -0,0 +1,101 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// kvStatsReporter is implemented by the processors which read from the KV
+// layer.
+type kvStatsReporter interface {
+	// kvStats returns the number of KV batch requests issued by the processor
+	// and the number of bytes of keys and values they read.
+	kvStats() (requests int64, bytesRead int64)
+}
+
+// statsCollector wraps a processor and its output to collect the execution
+// statistics of the processor, when the flow is set up with
+// SetupFlowRequest.CollectStats. The statistics are pushed to the output as
+// metadata right before the processor calls ProducerDone.
+type statsCollector struct {
+	proc   processor
+	output RowReceiver
+
+	// mon accounts for the memory allocated by the processor; the processor's
+	// FlowCtx uses it as its evalCtx.Mon.
+	mon mon.MemoryMonitor
+
+	startTime time.Time
+	// rowsProduced is accessed atomically.
+	rowsProduced int64
+	stats        ProcessorStats
+}
+
+var _ processor = &statsCollector{}
+var _ RowReceiver = &statsCollector{}
+
+// Run is part of the processor interface.
+func (c *statsCollector) Run(ctx context.Context, wg *sync.WaitGroup) {
+	c.startTime = timeutil.Now()
+	c.proc.Run(ctx, wg)
+}
+
+// Push is part of the RowReceiver interface.
+func (c *statsCollector) Push(row sqlbase.EncDatumRow, meta ProducerMetadata) ConsumerStatus {
+	if row != nil {
+		atomic.AddInt64(&c.rowsProduced, 1)
+	}
+	return c.output.Push(row, meta)
+}
+
+// ProducerDone is part of the RowReceiver interface.
+func (c *statsCollector) ProducerDone() {
+	c.stats.RowsProduced = atomic.LoadInt64(&c.rowsProduced)
+	c.stats.WallTime = timeutil.Since(c.startTime)
+	c.stats.PeakMemory = c.mon.MaximumBytes()
+	if r, ok := c.proc.(kvStatsReporter); ok {
+		c.stats.KVRequests, c.stats.KVBytesRead = r.kvStats()
+	}
+	stats := c.stats
+	_ = c.output.Push(nil /* row */, ProducerMetadata{Stats: &stats})
+	c.output.ProducerDone()
+}
+
+// summary returns the lines describing the statistics in a flow diagram.
+func (s *ProcessorStats) summary() []string {
+	res := []string{
+		fmt.Sprintf("rows: %d", s.RowsProduced),
+		fmt.Sprintf("time: %s", s.WallTime),
+	}
+	if s.KVRequests > 0 {
+		res = append(res, fmt.Sprintf("KV requests: %d (%s)",
+			s.KVRequests, humanizeutil.IBytes(s.KVBytesRead)))
+	}
+	if s.PeakMemory > 0 {
+		res = append(res, fmt.Sprintf("max memory: %s", humanizeutil.IBytes(s.PeakMemory)))
+	}
+	return res
+}
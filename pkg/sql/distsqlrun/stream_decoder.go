@@ -107,6 +107,10 @@ func (sd *StreamDecoder) AddMessage(msg *ProducerMessage) error {
 				meta.Ranges = rangeInfo.RangeInfo
 			} else if pErr := md.GetError(); pErr != nil {
 				meta.Err = pErr.ErrorDetail()
+			} else if stats := md.GetProcessorStats(); stats != nil {
+				meta.Stats = stats
+			} else if writes := md.GetRemoteWrites(); writes != nil {
+				meta.Writes = writes
 			}
 			sd.metadata = append(sd.metadata, meta)
 		}
@@ -82,6 +82,14 @@ func (se *StreamEncoder) AddMetadata(meta ProducerMetadata) {
 				RangeInfo: meta.Ranges,
 			},
 		}
+	} else if meta.Stats != nil {
+		enc.Value = &RemoteProducerMetadata_ProcessorStats{
+			ProcessorStats: meta.Stats,
+		}
+	} else if meta.Writes != nil {
+		enc.Value = &RemoteProducerMetadata_RemoteWrites{
+			RemoteWrites: meta.Writes,
+		}
 	} else {
 		enc.Value = &RemoteProducerMetadata_Error{
 			Error: NewError(meta.Err),
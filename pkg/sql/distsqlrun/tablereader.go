@@ -46,6 +46,7 @@ type tableReader struct {
 }
 
 var _ processor = &tableReader{}
+var _ kvStatsReporter = &tableReader{}
 
 // newTableReader creates a tableReader.
 func newTableReader(
@@ -59,13 +60,41 @@ func newTableReader(
 		tableID: spec.Table.ID,
 	}
 
+	tr.limitHint = tableReaderLimitHint(spec, post)
+
+	types := make([]sqlbase.ColumnType, len(spec.Table.Columns))
+	for i := range types {
+		types[i] = spec.Table.Columns[i].Type
+	}
+	if err := tr.out.init(post, types, &flowCtx.evalCtx, output); err != nil {
+		return nil, err
+	}
+
+	desc := spec.Table
+	if _, _, err := initRowFetcher(
+		&tr.fetcher, &desc, int(spec.IndexIdx), spec.Reverse, tr.out.neededColumns(),
+	); err != nil {
+		return nil, err
+	}
+
+	tr.spans = make(roachpb.Spans, len(spec.Spans))
+	for i, s := range spec.Spans {
+		tr.spans[i] = s.Span
+	}
+
+	return tr, nil
+}
+
+// tableReaderLimitHint returns the number of rows a table reader should ask the KV layer
+// for, taking into account the limits of its spec and post-processing.
+func tableReaderLimitHint(spec *TableReaderSpec, post *PostProcessSpec) (limitHint int64) {
 	// We ignore any limits that are higher than this value to avoid any
 	// overflows.
 	const overflowProtection = 1000000000
 	if post.Limit != 0 && post.Limit <= overflowProtection {
 		// In this case the procOutputHelper will tell us to stop once we emit
 		// enough rows.
-		tr.limitHint = int64(post.Limit)
+		limitHint = int64(post.Limit)
 	} else if spec.LimitHint != 0 && spec.LimitHint <= overflowProtection {
 		// If it turns out that limiHint rows are sufficient for our consumer, we
 		// want to avoid asking for another batch. Currently, the only way for us to
@@ -84,35 +113,14 @@ func newTableReader(
 		// reasoning goes out the door.
 		//
 		// TODO(radu, andrei): work on a real mechanism for limits.
-		tr.limitHint = spec.LimitHint + rowChannelBufSize + 1
+		limitHint = spec.LimitHint + rowChannelBufSize + 1
 	}
 
 	if post.Filter.Expr != "" {
 		// We have a filter so we will likely need to read more rows.
-		tr.limitHint *= 2
+		limitHint *= 2
 	}
-
-	types := make([]sqlbase.ColumnType, len(spec.Table.Columns))
-	for i := range types {
-		types[i] = spec.Table.Columns[i].Type
-	}
-	if err := tr.out.init(post, types, &flowCtx.evalCtx, output); err != nil {
-		return nil, err
-	}
-
-	desc := spec.Table
-	if _, _, err := initRowFetcher(
-		&tr.fetcher, &desc, int(spec.IndexIdx), spec.Reverse, tr.out.neededColumns(),
-	); err != nil {
-		return nil, err
-	}
-
-	tr.spans = make(roachpb.Spans, len(spec.Spans))
-	for i, s := range spec.Spans {
-		tr.spans[i] = s.Span
-	}
-
-	return tr, nil
+	return limitHint
 }
 
 func initRowFetcher(
@@ -148,14 +156,28 @@ func initRowFetcher(
 	return index, isSecondaryIndex, nil
 }
 
+// kvStats is part of the kvStatsReporter interface.
+func (tr *tableReader) kvStats() (requests int64, bytesRead int64) {
+	return tr.fetcher.KVStats()
+}
+
 // sendMisplannedRangesMetadata sends information about the non-local ranges
 // that were read by this tableReader. This should be called after the fetcher
 // was used to read everything this tableReader was supposed to read.
 func (tr *tableReader) sendMisplannedRangesMetadata(ctx context.Context) {
-	rangeInfos := tr.fetcher.GetRangeInfo()
+	if ranges := misplannedRanges(ctx, &tr.fetcher, tr.flowCtx.nodeID); len(ranges) != 0 {
+		tr.out.output.Push(nil /* row */, ProducerMetadata{Ranges: ranges})
+	}
+}
+
+// misplannedRanges returns the ranges read by the fetcher whose lease holder
+// is not the given node.
+func misplannedRanges(
+	ctx context.Context, fetcher *sqlbase.RowFetcher, nodeID roachpb.NodeID,
+) []roachpb.RangeInfo {
 	var misplannedRanges []roachpb.RangeInfo
-	for _, ri := range rangeInfos {
-		if ri.Lease.Replica.NodeID != tr.flowCtx.nodeID {
+	for _, ri := range fetcher.GetRangeInfo() {
+		if ri.Lease.Replica.NodeID != nodeID {
 			misplannedRanges = append(misplannedRanges, ri)
 		}
 	}
@@ -168,8 +190,8 @@ func (tr *tableReader) sendMisplannedRangesMetadata(ctx context.Context) {
 		}
 		log.VEventf(ctx, 2, "tableReader pushing metadata about misplanned ranges: %s",
 			msg)
-		tr.out.output.Push(nil /* row */, ProducerMetadata{Ranges: misplannedRanges})
 	}
+	return misplannedRanges
 }
 
 // Run is part of the processor interface.
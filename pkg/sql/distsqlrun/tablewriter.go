@@ -0,0 +1,400 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// tableWriterBatchSize is the number of input rows whose writes are
+// accumulated in a batch before the batch is sent.
+const tableWriterBatchSize = 1000
+
+var tableWriterCountType = sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+
+// tableWriter is a processor which inserts, updates or deletes rows of a
+// table in the flow's transaction. See TableWriterSpec.
+//
+// Once it has started writing, a tableWriter processes all its input rows
+// even if its consumer doesn't need any more rows: a mutation is never
+// partially applied.
+type tableWriter struct {
+	flowCtx *FlowCtx
+	input   RowSource
+	out     procOutputHelper
+
+	typ        TableWriterSpec_Type
+	desc       sqlbase.TableDescriptor
+	returning  bool
+	insertCols []sqlbase.ColumnDescriptor
+	fetchCols  []sqlbase.ColumnDescriptor
+	updateCols []sqlbase.ColumnDescriptor
+	// rowTypes are the types of the rows produced by the tableWriter, before
+	// post-processing.
+	rowTypes []sqlbase.ColumnType
+	// insertColIdx maps the columns of the table to their position in
+	// insertCols, or -1 if they are not inserted. Used by INSERT.
+	insertColIdx []int
+
+	// Only one of these is initialized, depending on typ.
+	ri sqlbase.RowInserter
+	ru sqlbase.RowUpdater
+	rd sqlbase.RowDeleter
+
+	// numRows is the number of rows written so far.
+	numRows int64
+	// returnedRows are the output rows for the writes of the current batch;
+	// they are emitted once the batch has been run. Used if returning is set.
+	returnedRows sqlbase.EncDatumRows
+	// draining is set once the consumer doesn't need more rows.
+	draining bool
+
+	datumAlloc sqlbase.DatumAlloc
+	values     parser.Datums
+}
+
+var _ processor = &tableWriter{}
+
+func newTableWriter(
+	flowCtx *FlowCtx,
+	spec *TableWriterSpec,
+	input RowSource,
+	post *PostProcessSpec,
+	output RowReceiver,
+) (*tableWriter, error) {
+	tw := &tableWriter{
+		flowCtx:   flowCtx,
+		input:     input,
+		typ:       spec.Type,
+		desc:      spec.Table,
+		returning: spec.Returning,
+	}
+	var err error
+	if tw.insertCols, err = tw.columnsByID(spec.InsertCols); err != nil {
+		return nil, err
+	}
+	if tw.fetchCols, err = tw.columnsByID(spec.FetchCols); err != nil {
+		return nil, err
+	}
+	if tw.updateCols, err = tw.columnsByID(spec.UpdateCols); err != nil {
+		return nil, err
+	}
+
+	var inputCols []sqlbase.ColumnDescriptor
+	var outputCols []sqlbase.ColumnDescriptor
+	switch tw.typ {
+	case TableWriterSpec_INSERT:
+		if len(tw.insertCols) == 0 {
+			return nil, errors.Errorf("no columns to insert into table %s", tw.desc.Name)
+		}
+		inputCols = tw.insertCols
+		outputCols = tw.desc.Columns
+		tw.insertColIdx = make([]int, len(tw.desc.Columns))
+		for i := range tw.desc.Columns {
+			tw.insertColIdx[i] = -1
+			for j := range tw.insertCols {
+				if tw.insertCols[j].ID == tw.desc.Columns[i].ID {
+					tw.insertColIdx[i] = j
+					break
+				}
+			}
+		}
+	case TableWriterSpec_UPDATE:
+		if len(tw.updateCols) == 0 {
+			return nil, errors.Errorf("no columns to update in table %s", tw.desc.Name)
+		}
+		inputCols = append(append(inputCols, tw.fetchCols...), tw.updateCols...)
+		outputCols = tw.fetchCols
+	case TableWriterSpec_DELETE:
+		inputCols = tw.fetchCols
+		outputCols = tw.fetchCols
+	default:
+		return nil, errors.Errorf("unsupported table writer type %s", tw.typ)
+	}
+
+	inputTypes := input.Types()
+	if len(inputTypes) != len(inputCols) {
+		return nil, errors.Errorf(
+			"table writer expects %d input columns, got %d", len(inputCols), len(inputTypes),
+		)
+	}
+	for i := range inputTypes {
+		if !inputTypes[i].ToDatumType().Equivalent(inputCols[i].Type.ToDatumType()) {
+			return nil, errors.Errorf(
+				"input column %d has type %s, column %s has type %s",
+				i, inputTypes[i].SQLString(), inputCols[i].Name, inputCols[i].Type.SQLString(),
+			)
+		}
+	}
+	tw.values = make(parser.Datums, len(inputCols))
+
+	if tw.returning {
+		tw.rowTypes = make([]sqlbase.ColumnType, len(outputCols))
+		for i := range outputCols {
+			tw.rowTypes[i] = outputCols[i].Type
+		}
+	} else {
+		tw.rowTypes = []sqlbase.ColumnType{tableWriterCountType}
+	}
+	if err := tw.out.init(post, tw.rowTypes, &flowCtx.evalCtx, output); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+// columnsByID returns the descriptors of the columns of the table with the
+// given IDs.
+func (tw *tableWriter) columnsByID(ids []uint32) ([]sqlbase.ColumnDescriptor, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cols := make([]sqlbase.ColumnDescriptor, len(ids))
+	for i, id := range ids {
+		col, err := tw.desc.FindColumnByID(sqlbase.ColumnID(id))
+		if err != nil {
+			return nil, err
+		}
+		cols[i] = *col
+	}
+	return cols, nil
+}
+
+// initRowWriter initializes the row writer for the type of the tableWriter.
+// Foreign keys are not checked: the tableWriter is not used for tables that
+// have foreign keys.
+func (tw *tableWriter) initRowWriter(txn *client.Txn) error {
+	var err error
+	switch tw.typ {
+	case TableWriterSpec_INSERT:
+		tw.ri, err = sqlbase.MakeRowInserter(
+			txn, &tw.desc, nil /* fkTables */, tw.insertCols, sqlbase.SkipFKs,
+		)
+	case TableWriterSpec_UPDATE:
+		tw.ru, err = sqlbase.MakeRowUpdater(
+			txn, &tw.desc, nil /* fkTables */, tw.updateCols, tw.fetchCols,
+			sqlbase.RowUpdaterDefault,
+		)
+		if err == nil && len(tw.ru.FetchCols) != len(tw.fetchCols) {
+			err = errors.Errorf(
+				"updating table %s requires %d columns, got %d",
+				tw.desc.Name, len(tw.ru.FetchCols), len(tw.fetchCols),
+			)
+		}
+	case TableWriterSpec_DELETE:
+		tw.rd, err = sqlbase.MakeRowDeleter(
+			txn, &tw.desc, nil /* fkTables */, tw.fetchCols, sqlbase.SkipFKs,
+		)
+		if err == nil && len(tw.rd.FetchCols) != len(tw.fetchCols) {
+			err = errors.Errorf(
+				"deleting from table %s requires %d columns, got %d",
+				tw.desc.Name, len(tw.rd.FetchCols), len(tw.fetchCols),
+			)
+		}
+	}
+	return err
+}
+
+// writeRow adds to the batch the writes for an input row. If returning is
+// set, the output row is added to returnedRows.
+func (tw *tableWriter) writeRow(
+	ctx context.Context, b *client.Batch, row sqlbase.EncDatumRow,
+) error {
+	for i := range row {
+		if err := row[i].EnsureDecoded(&tw.datumAlloc); err != nil {
+			return err
+		}
+		tw.values[i] = row[i].Datum
+	}
+
+	var outValues parser.Datums
+	switch tw.typ {
+	case TableWriterSpec_INSERT:
+		// Check to see if NULL is being inserted into any non-nullable column.
+		for i, col := range tw.desc.Columns {
+			if !col.Nullable {
+				if idx := tw.insertColIdx[i]; idx == -1 || tw.values[idx] == parser.DNull {
+					return sqlbase.NewNonNullViolationError(col.Name)
+				}
+			}
+		}
+		for i := range tw.values {
+			if err := sqlbase.CheckValueWidth(tw.insertCols[i], tw.values[i]); err != nil {
+				return err
+			}
+		}
+		if err := tw.ri.InsertRow(
+			ctx, b, tw.values, false /* ignoreConflicts */, false, /* traceKV */
+		); err != nil {
+			return err
+		}
+		if tw.returning {
+			outValues = make(parser.Datums, len(tw.desc.Columns))
+			for i, idx := range tw.insertColIdx {
+				if idx == -1 {
+					outValues[i] = parser.DNull
+				} else {
+					outValues[i] = tw.values[idx]
+				}
+			}
+		}
+
+	case TableWriterSpec_UPDATE:
+		oldValues := tw.values[:len(tw.fetchCols)]
+		updateValues := tw.values[len(tw.fetchCols):]
+		for i, col := range tw.updateCols {
+			if err := sqlbase.CheckValueWidth(col, updateValues[i]); err != nil {
+				return err
+			}
+			if !col.Nullable && updateValues[i] == parser.DNull {
+				return sqlbase.NewNonNullViolationError(col.Name)
+			}
+		}
+		newValues, err := tw.ru.UpdateRow(ctx, b, oldValues, updateValues, false /* traceKV */)
+		if err != nil {
+			return err
+		}
+		outValues = newValues
+
+	case TableWriterSpec_DELETE:
+		if err := tw.rd.DeleteRow(ctx, b, tw.values, false /* traceKV */); err != nil {
+			return err
+		}
+		outValues = tw.values
+	}
+	tw.numRows++
+
+	if tw.returning {
+		outRow := make(sqlbase.EncDatumRow, len(outValues))
+		for i := range outValues {
+			outRow[i] = sqlbase.DatumToEncDatum(tw.rowTypes[i], outValues[i])
+		}
+		tw.returnedRows = append(tw.returnedRows, outRow)
+	}
+	return nil
+}
+
+// flush runs the batch and emits the output rows for its writes.
+func (tw *tableWriter) flush(ctx context.Context, txn *client.Txn, b *client.Batch) error {
+	if err := txn.Run(ctx, b); err != nil {
+		return sqlbase.ConvertBatchError(&tw.desc, b)
+	}
+	for _, row := range tw.returnedRows {
+		if tw.draining {
+			break
+		}
+		status, err := tw.out.emitRow(ctx, row)
+		if err != nil {
+			return err
+		}
+		if status != NeedMoreRows {
+			tw.draining = true
+		}
+	}
+	tw.returnedRows = tw.returnedRows[:0]
+	return nil
+}
+
+// mainLoop writes all the input rows and returns any error.
+//
+// If no error is returned, the input has been drained and all the writes have
+// been run; the output hasn't been closed.
+func (tw *tableWriter) mainLoop(ctx context.Context, txn *client.Txn) error {
+	if err := tw.initRowWriter(txn); err != nil {
+		return err
+	}
+
+	b := txn.NewBatch()
+	batchRows := 0
+	for {
+		row, meta := tw.input.Next()
+		if !meta.Empty() {
+			if meta.Err != nil {
+				return meta.Err
+			}
+			// The metadata is forwarded even if the consumer is draining.
+			_ = tw.out.output.Push(nil /* row */, meta)
+			continue
+		}
+		if row == nil {
+			break
+		}
+		if err := tw.writeRow(ctx, b, row); err != nil {
+			return err
+		}
+		batchRows++
+		if batchRows == tableWriterBatchSize {
+			if err := tw.flush(ctx, txn, b); err != nil {
+				return err
+			}
+			b = txn.NewBatch()
+			batchRows = 0
+		}
+	}
+	if batchRows > 0 {
+		return tw.flush(ctx, txn, b)
+	}
+	return nil
+}
+
+// Run is part of the processor interface.
+func (tw *tableWriter) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTagInt(ctx, "TableWriter", int(tw.desc.ID))
+	ctx, span := tracing.ChildSpan(ctx, "table writer")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting table writer (%s)", tw.typ)
+		defer log.Infof(ctx, "exiting table writer")
+	}
+
+	txn := tw.flowCtx.setupTxn()
+	txn.TrackIntents()
+	err := tw.mainLoop(ctx, txn)
+
+	// The intents are reported even if an error occurred, so that the gateway
+	// can resolve them when the transaction is rolled back.
+	if intents := txn.Intents(); len(intents) > 0 {
+		_ = tw.out.output.Push(nil /* row */, ProducerMetadata{
+			Writes: &RemoteWrites{Txn: *txn.Proto(), IntentSpans: intents},
+		})
+	}
+	if err != nil {
+		DrainAndClose(ctx, tw.out.output, err, tw.input)
+		return
+	}
+
+	if !tw.returning && !tw.draining {
+		countRow := sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(tableWriterCountType, parser.NewDInt(parser.DInt(tw.numRows))),
+		}
+		if !emitHelper(ctx, &tw.out, countRow, ProducerMetadata{}) {
+			return
+		}
+	}
+	tw.out.close()
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestTableWriter runs table writers in a transaction that is committed by
+// the TxnCoordSender of the test server, which is informed of the writes
+// through the RemoteWrites metadata.
+func TestTableWriter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlutils.CreateTable(t, sqlDB, "t",
+		"a INT PRIMARY KEY, b INT",
+		3,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(2)))
+	r := sqlutils.MakeSQLRunner(t, sqlDB)
+
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+	a, b := uint32(td.Columns[0].ID), uint32(td.Columns[1].ID)
+
+	intType := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	dInt := func(i int) parser.Datum {
+		return parser.NewDInt(parser.DInt(i))
+	}
+
+	// The test cases run in order, on the same table.
+	testCases := []struct {
+		name     string
+		spec     TableWriterSpec
+		input    [][]parser.Datum
+		expected string
+		// table is the content of the table once the transaction is committed.
+		table [][]string
+	}{
+		{
+			name: "Insert",
+			spec: TableWriterSpec{
+				Type:       TableWriterSpec_INSERT,
+				InsertCols: []uint32{a, b},
+			},
+			input:    [][]parser.Datum{{dInt(4), dInt(7)}, {dInt(5), parser.DNull}},
+			expected: "[[2]]",
+			table:    [][]string{{"1", "1"}, {"2", "0"}, {"3", "1"}, {"4", "7"}, {"5", "NULL"}},
+		},
+		{
+			name: "InsertReturning",
+			spec: TableWriterSpec{
+				Type:       TableWriterSpec_INSERT,
+				InsertCols: []uint32{a},
+				Returning:  true,
+			},
+			input:    [][]parser.Datum{{dInt(6)}},
+			expected: "[[6 NULL]]",
+			table: [][]string{
+				{"1", "1"}, {"2", "0"}, {"3", "1"}, {"4", "7"}, {"5", "NULL"}, {"6", "NULL"},
+			},
+		},
+		{
+			name: "UpdateReturning",
+			spec: TableWriterSpec{
+				Type:       TableWriterSpec_UPDATE,
+				FetchCols:  []uint32{a, b},
+				UpdateCols: []uint32{b},
+				Returning:  true,
+			},
+			input:    [][]parser.Datum{{dInt(1), dInt(1), dInt(10)}, {dInt(3), dInt(1), dInt(30)}},
+			expected: "[[1 10] [3 30]]",
+			table: [][]string{
+				{"1", "10"}, {"2", "0"}, {"3", "30"}, {"4", "7"}, {"5", "NULL"}, {"6", "NULL"},
+			},
+		},
+		{
+			name: "Delete",
+			spec: TableWriterSpec{
+				Type:      TableWriterSpec_DELETE,
+				FetchCols: []uint32{a},
+			},
+			input:    [][]parser.Datum{{dInt(2)}, {dInt(5)}, {dInt(6)}},
+			expected: "[[3]]",
+			table:    [][]string{{"1", "10"}, {"3", "30"}, {"4", "7"}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			txn := client.NewTxn(kvDB)
+			if err := txn.PrepareForRemoteWrites(
+				ctx, keys.MakeTablePrefix(uint32(td.ID)),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(ctx)
+			flowCtx := FlowCtx{
+				evalCtx:  evalCtx,
+				txnProto: txn.Proto(),
+				// Pass a DB without a TxnCoordSender.
+				remoteTxnDB: client.NewDB(s.DistSender(), s.Clock()),
+			}
+
+			var types []sqlbase.ColumnType
+			rows := make(sqlbase.EncDatumRows, len(c.input))
+			for i, vals := range c.input {
+				types = make([]sqlbase.ColumnType, len(vals))
+				rows[i] = make(sqlbase.EncDatumRow, len(vals))
+				for j, d := range vals {
+					types[j] = intType
+					rows[i][j] = sqlbase.DatumToEncDatum(intType, d)
+				}
+			}
+			in := NewRowBuffer(types, rows, RowBufferArgs{})
+			out := &RowBuffer{}
+
+			spec := c.spec
+			spec.Table = *td
+			tw, err := newTableWriter(&flowCtx, &spec, in, &PostProcessSpec{}, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tw.Run(ctx, nil)
+
+			if !in.Done {
+				t.Fatal("tableWriter didn't consume all the rows")
+			}
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+
+			var res sqlbase.EncDatumRows
+			var writes *RemoteWrites
+			for {
+				row, meta := out.Next()
+				if meta.Writes != nil {
+					writes = meta.Writes
+					continue
+				}
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				res = append(res, row)
+			}
+			if result := res.String(); result != c.expected {
+				t.Errorf("invalid results: %s, expected %s", result, c.expected)
+			}
+
+			if writes == nil || len(writes.IntentSpans) == 0 {
+				t.Fatalf("expected the writes to be reported, got %v", writes)
+			}
+			if err := txn.AugmentRemoteWrites(ctx, writes.Txn, writes.IntentSpans); err != nil {
+				t.Fatal(err)
+			}
+			if err := txn.CommitOrCleanup(ctx); err != nil {
+				t.Fatal(err)
+			}
+			r.CheckQueryResults(`SELECT * FROM test.t`, c.table)
+		})
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// newVectorizedProcessor creates a processor running the vectorized operators
+// equivalent to the given core and post-processing stage: a tree of operators
+// whose row inputs are converted to batches by columnarizers, and whose output
+// batches are converted back to rows by a materializer.
+//
+// An error is returned if the core, its types or the post-processing
+// expressions are not supported by the vectorized operators; the caller then
+// falls back to the row processors.
+func newVectorizedProcessor(
+	flowCtx *FlowCtx,
+	core *ProcessorCoreUnion,
+	post *PostProcessSpec,
+	inputs []RowSource,
+	outputs []RowReceiver,
+) (processor, error) {
+	var b vecPlanBuilder
+	var op exec.Operator
+	var err error
+	switch {
+	case core.Noop != nil:
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		op, err = b.columnarize(inputs[0])
+
+	case core.TableReader != nil:
+		if err := checkNumInOut(inputs, outputs, 0, 1); err != nil {
+			return nil, err
+		}
+		op, err = b.tableReader(flowCtx, core.TableReader, post)
+
+	case core.Aggregator != nil:
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		op, err = b.aggregator(core.Aggregator, inputs[0])
+
+	case core.HashJoiner != nil:
+		if err := checkNumInOut(inputs, outputs, 2, 1); err != nil {
+			return nil, err
+		}
+		op, err = b.hashJoiner(core.HashJoiner, inputs[0], inputs[1])
+
+	case core.Sorter != nil:
+		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
+			return nil, err
+		}
+		op, err = b.sorter(core.Sorter, inputs[0])
+
+	default:
+		return nil, errors.Errorf("unsupported processor core %s", core)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	op, err = b.postProcess(flowCtx, op, post)
+	if err != nil {
+		return nil, err
+	}
+	return newMaterializer(flowCtx, op, b.types, inputs, b.metadataSources, post, outputs[0])
+}
+
+// vecPlanBuilder builds a tree of vectorized operators. It keeps track of the
+// types of the columns of the batches produced by the last operator built.
+type vecPlanBuilder struct {
+	types           []sqlbase.ColumnType
+	vecTypes        []exec.T
+	metadataSources []metadataSource
+}
+
+// setTypes sets the column types of the last operator built.
+func (b *vecPlanBuilder) setTypes(types []sqlbase.ColumnType) error {
+	vecTypes, err := exec.FromColumnTypes(types)
+	if err != nil {
+		return err
+	}
+	b.types = types
+	b.vecTypes = vecTypes
+	return nil
+}
+
+func (b *vecPlanBuilder) columnarize(input RowSource) (exec.Operator, error) {
+	c, err := newColumnarizer(input)
+	if err != nil {
+		return nil, err
+	}
+	b.types = input.Types()
+	b.vecTypes = c.types
+	b.metadataSources = append(b.metadataSources, c)
+	return c, nil
+}
+
+func (b *vecPlanBuilder) tableReader(
+	flowCtx *FlowCtx, spec *TableReaderSpec, post *PostProcessSpec,
+) (exec.Operator, error) {
+	types := make([]sqlbase.ColumnType, len(spec.Table.Columns))
+	for i := range types {
+		types[i] = spec.Table.Columns[i].Type
+	}
+	// Use a procOutputHelper to figure out which columns are needed by the
+	// post-processing stage.
+	var h procOutputHelper
+	if err := h.init(post, types, &flowCtx.evalCtx, nil /* output */); err != nil {
+		return nil, err
+	}
+	tr, err := newColTableReader(flowCtx, spec, post, h.neededColumns())
+	if err != nil {
+		return nil, err
+	}
+	b.types = types
+	b.vecTypes = tr.types
+	b.metadataSources = append(b.metadataSources, tr)
+	return tr, nil
+}
+
+// vecAggFuncs maps the aggregate functions supported by the vectorized hash
+// aggregator to their AggregatorSpec equivalents.
+var vecAggFuncs = map[AggregatorSpec_Func]exec.AggFunc{
+	AggregatorSpec_IDENT:      exec.AggAnyNotNull,
+	AggregatorSpec_COUNT_ROWS: exec.AggCountRows,
+	AggregatorSpec_COUNT:      exec.AggCount,
+	AggregatorSpec_SUM:        exec.AggSum,
+	AggregatorSpec_SUM_INT:    exec.AggSumInt,
+	AggregatorSpec_MIN:        exec.AggMin,
+	AggregatorSpec_MAX:        exec.AggMax,
+}
+
+func (b *vecPlanBuilder) aggregator(spec *AggregatorSpec, input RowSource) (exec.Operator, error) {
+	op, err := b.columnarize(input)
+	if err != nil {
+		return nil, err
+	}
+	inputTypes := b.types
+	aggs := make([]exec.AggSpec, len(spec.Aggregations))
+	outTypes := make([]sqlbase.ColumnType, len(spec.Aggregations))
+	for i, agg := range spec.Aggregations {
+		f, ok := vecAggFuncs[agg.Func]
+		if !ok || agg.Distinct || agg.FilterColIdx != nil {
+			return nil, errors.Errorf("unsupported aggregation %s", &agg)
+		}
+		argTypes := make([]sqlbase.ColumnType, len(agg.ColIdx))
+		for j, c := range agg.ColIdx {
+			if int(c) >= len(inputTypes) {
+				return nil, errors.Errorf("ColIdx out of range (%d)", agg.ColIdx)
+			}
+			argTypes[j] = inputTypes[c]
+		}
+		_, outTypes[i], err = GetAggregateInfo(agg.Func, argTypes...)
+		if err != nil {
+			return nil, err
+		}
+		aggs[i].Func = f
+		if len(agg.ColIdx) > 0 {
+			aggs[i].ColIdx = int(agg.ColIdx[0])
+		}
+	}
+	groupCols := make([]int, len(spec.GroupCols))
+	for i, c := range spec.GroupCols {
+		groupCols[i] = int(c)
+	}
+	op, err = exec.NewHashAggregator(op, b.vecTypes, groupCols, aggs)
+	if err != nil {
+		return nil, err
+	}
+	// Check that the vectorized aggregations return the same types as the row
+	// ones.
+	for i := range aggs {
+		var inType exec.T
+		if aggs[i].Func != exec.AggCountRows {
+			inType = b.vecTypes[aggs[i].ColIdx]
+		}
+		t, err := exec.AggOutputType(aggs[i].Func, inType)
+		if err != nil {
+			return nil, err
+		}
+		if t != exec.FromColumnType(outTypes[i]) {
+			return nil, errors.Errorf(
+				"mismatched result type for aggregation %s", &spec.Aggregations[i],
+			)
+		}
+	}
+	return op, b.setTypes(outTypes)
+}
+
+func (b *vecPlanBuilder) hashJoiner(
+	spec *HashJoinerSpec, left, right RowSource,
+) (exec.Operator, error) {
+	if spec.Type != JoinType_INNER || spec.OnExpr.Expr != "" {
+		return nil, errors.Errorf("unsupported hash join %s", spec)
+	}
+	leftOp, err := b.columnarize(left)
+	if err != nil {
+		return nil, err
+	}
+	leftTypes, leftVecTypes := b.types, b.vecTypes
+	rightOp, err := b.columnarize(right)
+	if err != nil {
+		return nil, err
+	}
+	rightTypes, rightVecTypes := b.types, b.vecTypes
+
+	leftEq := make([]int, len(spec.LeftEqColumns))
+	for i, c := range spec.LeftEqColumns {
+		leftEq[i] = int(c)
+	}
+	rightEq := make([]int, len(spec.RightEqColumns))
+	for i, c := range spec.RightEqColumns {
+		rightEq[i] = int(c)
+	}
+	op, err := exec.NewHashJoiner(leftOp, rightOp, leftVecTypes, rightVecTypes, leftEq, rightEq)
+	if err != nil {
+		return nil, err
+	}
+	b.types = append(append([]sqlbase.ColumnType(nil), leftTypes...), rightTypes...)
+	b.vecTypes = append(append([]exec.T(nil), leftVecTypes...), rightVecTypes...)
+	return op, nil
+}
+
+func (b *vecPlanBuilder) sorter(spec *SorterSpec, input RowSource) (exec.Operator, error) {
+	op, err := b.columnarize(input)
+	if err != nil {
+		return nil, err
+	}
+	ordering := make([]exec.OrderingCol, len(spec.OutputOrdering.Columns))
+	for i, c := range spec.OutputOrdering.Columns {
+		ordering[i] = exec.OrderingCol{
+			ColIdx: int(c.ColIdx),
+			Desc:   c.Direction == Ordering_Column_DESC,
+		}
+	}
+	return exec.NewSorter(op, b.vecTypes, ordering), nil
+}
+
+// postProcess adds the operators implementing the filter and the projection
+// or rendering of a post-processing stage. The offset and limit are applied
+// by the materializer.
+func (b *vecPlanBuilder) postProcess(
+	flowCtx *FlowCtx, op exec.Operator, post *PostProcessSpec,
+) (exec.Operator, error) {
+	if post.Filter.Expr != "" {
+		var eh exprHelper
+		if err := eh.init(post.Filter, b.types, &flowCtx.evalCtx); err != nil {
+			return nil, err
+		}
+		var err error
+		if op, err = b.filter(op, eh.expr); err != nil {
+			return nil, err
+		}
+	}
+
+	if post.Projection {
+		projection := make([]int, len(post.OutputColumns))
+		types := make([]sqlbase.ColumnType, len(post.OutputColumns))
+		for i, c := range post.OutputColumns {
+			if int(c) >= len(b.types) {
+				return nil, errors.Errorf("invalid output column %d (only %d available)", c, len(b.types))
+			}
+			projection[i] = int(c)
+			types[i] = b.types[c]
+		}
+		return exec.NewSimpleProjectOp(op, projection), b.setTypes(types)
+	}
+
+	if len(post.RenderExprs) > 0 {
+		projection := make([]int, len(post.RenderExprs))
+		types := make([]sqlbase.ColumnType, len(post.RenderExprs))
+		// The renders append columns to the batches; copy the types before
+		// appending to them.
+		inputTypes := b.types
+		b.types = append([]sqlbase.ColumnType(nil), b.types...)
+		b.vecTypes = append([]exec.T(nil), b.vecTypes...)
+		for i, expr := range post.RenderExprs {
+			var eh exprHelper
+			if err := eh.init(expr, inputTypes, &flowCtx.evalCtx); err != nil {
+				return nil, err
+			}
+			var err error
+			if op, projection[i], err = b.render(op, eh.expr); err != nil {
+				return nil, err
+			}
+			types[i] = sqlbase.DatumTypeToColumnType(eh.expr.ResolvedType())
+		}
+		return exec.NewSimpleProjectOp(op, projection), b.setTypes(types)
+	}
+	return op, nil
+}
+
+// vecCmpOps maps the comparison operators supported by the vectorized
+// operators, along with the operator to use if the operands are swapped.
+var vecCmpOps = map[parser.ComparisonOperator][2]exec.CmpOp{
+	parser.EQ: {exec.EQ, exec.EQ},
+	parser.NE: {exec.NE, exec.NE},
+	parser.LT: {exec.LT, exec.GT},
+	parser.LE: {exec.LE, exec.GE},
+	parser.GT: {exec.GT, exec.LT},
+	parser.GE: {exec.GE, exec.LE},
+}
+
+// filter adds the operators filtering rows on a boolean expression, made of
+// conjunctions of comparisons between columns and constants.
+func (b *vecPlanBuilder) filter(op exec.Operator, expr parser.TypedExpr) (exec.Operator, error) {
+	switch t := expr.(type) {
+	case *parser.ParenExpr:
+		return b.filter(op, t.TypedInnerExpr())
+
+	case *parser.AndExpr:
+		op, err := b.filter(op, t.TypedLeft())
+		if err != nil {
+			return nil, err
+		}
+		return b.filter(op, t.TypedRight())
+
+	case *parser.ComparisonExpr:
+		ops, ok := vecCmpOps[t.Operator]
+		if !ok {
+			break
+		}
+		left, right := stripParens(t.TypedLeft()), stripParens(t.TypedRight())
+		if _, ok := left.(*parser.IndexedVar); !ok {
+			// Put the column on the left.
+			left, right = right, left
+			ops[0] = ops[1]
+		}
+		l, ok := left.(*parser.IndexedVar)
+		if !ok {
+			break
+		}
+		typ := b.vecTypes[l.Idx]
+		switch r := right.(type) {
+		case *parser.IndexedVar:
+			if b.vecTypes[r.Idx] != typ {
+				break
+			}
+			return exec.NewSelColOp(op, l.Idx, ops[0], r.Idx), nil
+		case parser.Datum:
+			c, err := vecConst(r, typ)
+			if err != nil {
+				return nil, err
+			}
+			return exec.NewSelConstOp(op, l.Idx, ops[0], c), nil
+		}
+	}
+	return nil, errors.Errorf("unsupported filter expression %s", expr)
+}
+
+// vecBinOps maps the arithmetic operators supported by the vectorized
+// operators.
+var vecBinOps = map[parser.BinaryOperator]exec.BinOp{
+	parser.Plus:  exec.Plus,
+	parser.Minus: exec.Minus,
+	parser.Mult:  exec.Mult,
+}
+
+// render adds the operators computing an expression made of columns and of
+// arithmetic operations, and returns the column holding its results.
+func (b *vecPlanBuilder) render(
+	op exec.Operator, expr parser.TypedExpr,
+) (exec.Operator, int, error) {
+	switch t := expr.(type) {
+	case *parser.ParenExpr:
+		return b.render(op, t.TypedInnerExpr())
+
+	case *parser.IndexedVar:
+		return op, t.Idx, nil
+
+	case *parser.BinaryExpr:
+		binOp, ok := vecBinOps[t.Operator]
+		if !ok {
+			break
+		}
+		typ := exec.FromColumnType(sqlbase.DatumTypeToColumnType(t.ResolvedType()))
+		var operands [2]exec.Operand
+		for i, e := range []parser.TypedExpr{stripParens(t.TypedLeft()), stripParens(t.TypedRight())} {
+			if d, ok := e.(parser.Datum); ok {
+				c, err := vecConst(d, typ)
+				if err != nil {
+					return nil, 0, err
+				}
+				operands[i] = exec.ConstOperand(c)
+				continue
+			}
+			var col int
+			var err error
+			if op, col, err = b.render(op, e); err != nil {
+				return nil, 0, err
+			}
+			if b.vecTypes[col] != typ {
+				return nil, 0, errors.Errorf("unsupported expression %s", expr)
+			}
+			operands[i] = exec.ColOperand(col)
+		}
+		if operands[0].ColIdx < 0 && operands[1].ColIdx < 0 {
+			break
+		}
+		outIdx := len(b.vecTypes)
+		op, err := exec.NewProjOp(op, typ, binOp, operands[0], operands[1], outIdx)
+		if err != nil {
+			return nil, 0, err
+		}
+		b.types = append(b.types, sqlbase.DatumTypeToColumnType(t.ResolvedType()))
+		b.vecTypes = append(b.vecTypes, typ)
+		return op, outIdx, nil
+	}
+	return nil, 0, errors.Errorf("unsupported expression %s", expr)
+}
+
+func stripParens(expr parser.TypedExpr) parser.TypedExpr {
+	for {
+		p, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.TypedInnerExpr()
+	}
+}
+
+// vecConst converts a constant to the Go type of the values of the given
+// physical type.
+func vecConst(d parser.Datum, typ exec.T) (interface{}, error) {
+	if d == parser.DNull {
+		return nil, errors.Errorf("unsupported NULL constant")
+	}
+	if exec.FromColumnType(sqlbase.DatumTypeToColumnType(d.ResolvedType())) == typ {
+		switch t := parser.UnwrapDatum(d).(type) {
+		case *parser.DBool:
+			return bool(*t), nil
+		case *parser.DString:
+			return []byte(*t), nil
+		case *parser.DBytes:
+			return []byte(*t), nil
+		case *parser.DDecimal:
+			return t.Decimal, nil
+		case *parser.DInt:
+			return int64(*t), nil
+		case *parser.DFloat:
+			return float64(*t), nil
+		}
+	}
+	return nil, errors.Errorf("unsupported constant %s of type %s", d, d.ResolvedType())
+}
@@ -0,0 +1,287 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// TestVectorizedProcessors checks that the vectorized processors produce the
+// same results as the row processors.
+func TestVectorizedProcessors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	columnTypeDecimal := sqlbase.ColumnType{Kind: sqlbase.ColumnType_DECIMAL}
+	columnTypeString := sqlbase.ColumnType{Kind: sqlbase.ColumnType_STRING}
+	types := []sqlbase.ColumnType{columnTypeInt, columnTypeInt, columnTypeDecimal, columnTypeString}
+
+	// Generate more rows than fit in a batch, with few distinct values and
+	// some NULLs.
+	randRows := func(n int) sqlbase.EncDatumRows {
+		rows := make(sqlbase.EncDatumRows, n)
+		for i := range rows {
+			rows[i] = make(sqlbase.EncDatumRow, len(types))
+			for j, typ := range types {
+				var d parser.Datum = parser.DNull
+				if v := rng.Intn(11); v < 10 {
+					switch typ.Kind {
+					case sqlbase.ColumnType_INT:
+						d = parser.NewDInt(parser.DInt(v))
+					case sqlbase.ColumnType_DECIMAL:
+						dd := &parser.DDecimal{}
+						dd.SetCoefficient(int64(v))
+						dd.Exponent = -1
+						d = dd
+					case sqlbase.ColumnType_STRING:
+						d = parser.NewDString(fmt.Sprintf("s%d", v))
+					}
+				}
+				rows[i][j] = sqlbase.DatumToEncDatum(typ, d)
+			}
+		}
+		return rows
+	}
+
+	testCases := []struct {
+		name      string
+		core      ProcessorCoreUnion
+		post      PostProcessSpec
+		numInputs int
+		ordered   bool
+	}{
+		{
+			name: "noop-filter-render",
+			core: ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+			post: PostProcessSpec{
+				Filter:      Expression{Expr: "@1 < 5 AND @2 != @1 AND @4 >= 's3'"},
+				RenderExprs: []Expression{{Expr: "@1 * 2 + @2"}, {Expr: "@3 - 0.5"}, {Expr: "@4"}},
+			},
+			numInputs: 1,
+		},
+		{
+			name: "noop-projection-limit",
+			core: ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+			post: PostProcessSpec{
+				Filter:        Expression{Expr: "3 > @2"},
+				Projection:    true,
+				OutputColumns: []uint32{3, 0},
+				Offset:        10,
+				Limit:         100,
+			},
+			numInputs: 1,
+			ordered:   true,
+		},
+		{
+			name: "aggregator",
+			core: ProcessorCoreUnion{Aggregator: &AggregatorSpec{
+				GroupCols: []uint32{0, 3},
+				Aggregations: []AggregatorSpec_Aggregation{
+					{Func: AggregatorSpec_IDENT, ColIdx: []uint32{0}},
+					{Func: AggregatorSpec_IDENT, ColIdx: []uint32{3}},
+					{Func: AggregatorSpec_COUNT_ROWS},
+					{Func: AggregatorSpec_COUNT, ColIdx: []uint32{1}},
+					{Func: AggregatorSpec_SUM, ColIdx: []uint32{1}},
+					{Func: AggregatorSpec_SUM, ColIdx: []uint32{2}},
+					{Func: AggregatorSpec_MIN, ColIdx: []uint32{2}},
+					{Func: AggregatorSpec_MAX, ColIdx: []uint32{1}},
+				},
+			}},
+			post: PostProcessSpec{
+				Filter: Expression{Expr: "@3 > 10"},
+			},
+			numInputs: 1,
+		},
+		{
+			name: "scalar-aggregator",
+			core: ProcessorCoreUnion{Aggregator: &AggregatorSpec{
+				Aggregations: []AggregatorSpec_Aggregation{
+					{Func: AggregatorSpec_SUM_INT, ColIdx: []uint32{0}},
+					{Func: AggregatorSpec_MAX, ColIdx: []uint32{3}},
+				},
+			}},
+			numInputs: 1,
+		},
+		{
+			name: "hash-joiner",
+			core: ProcessorCoreUnion{HashJoiner: &HashJoinerSpec{
+				LeftEqColumns:  []uint32{0, 3},
+				RightEqColumns: []uint32{1, 3},
+			}},
+			post: PostProcessSpec{
+				Filter:        Expression{Expr: "@2 <= @6"},
+				Projection:    true,
+				OutputColumns: []uint32{0, 1, 4, 5, 7},
+			},
+			numInputs: 2,
+		},
+		{
+			name: "sorter",
+			core: ProcessorCoreUnion{Sorter: &SorterSpec{
+				OutputOrdering: convertToSpecOrdering(sqlbase.ColumnOrdering{
+					{ColIdx: 3, Direction: encoding.Descending},
+					{ColIdx: 2, Direction: encoding.Ascending},
+					{ColIdx: 0, Direction: encoding.Ascending},
+					{ColIdx: 1, Direction: encoding.Descending},
+				}),
+			}},
+			numInputs: 1,
+			ordered:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputRows := make([]sqlbase.EncDatumRows, tc.numInputs)
+			for i := range inputRows {
+				inputRows[i] = randRows(1000 + rng.Intn(3000))
+			}
+
+			var results [2][]string
+			for i, vectorize := range []bool{false, true} {
+				inputs := make([]RowSource, tc.numInputs)
+				for j := range inputs {
+					inputs[j] = NewRowBuffer(types, inputRows[j], RowBufferArgs{})
+				}
+				out := &RowBuffer{}
+				evalCtx := parser.MakeTestingEvalContext()
+				defer evalCtx.Stop(context.Background())
+				flowCtx := FlowCtx{evalCtx: evalCtx, vectorize: vectorize}
+
+				var p processor
+				var err error
+				if vectorize {
+					p, err = newVectorizedProcessor(
+						&flowCtx, &tc.core, &tc.post, inputs, []RowReceiver{out},
+					)
+					if _, ok := p.(*materializer); err == nil && !ok {
+						t.Fatalf("expected a materializer, got %T", p)
+					}
+				} else {
+					p, err = newProcessor(&flowCtx, &tc.core, &tc.post, inputs, []RowReceiver{out})
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.Run(context.Background(), nil)
+				if !out.ProducerClosed {
+					t.Fatalf("output RowReceiver not closed")
+				}
+				for {
+					row, meta := out.Next()
+					if !meta.Empty() {
+						t.Fatalf("unexpected metadata: %v", meta)
+					}
+					if row == nil {
+						break
+					}
+					results[i] = append(results[i], row.String())
+				}
+				if !tc.ordered {
+					sort.Strings(results[i])
+				}
+			}
+			if len(results[0]) == 0 {
+				t.Fatal("no results")
+			}
+			expected, actual := strings.Join(results[0], "\n"), strings.Join(results[1], "\n")
+			if expected != actual {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+			}
+		})
+	}
+}
+
+// TestVectorizedProcessorsFallback checks that the processors that can't run
+// with vectorized operators are reported as such.
+func TestVectorizedProcessorsFallback(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	columnTypeDate := sqlbase.ColumnType{Kind: sqlbase.ColumnType_DATE}
+
+	testCases := []struct {
+		name  string
+		types []sqlbase.ColumnType
+		core  ProcessorCoreUnion
+		post  PostProcessSpec
+	}{
+		{
+			name:  "unhandled-type",
+			types: []sqlbase.ColumnType{columnTypeInt, columnTypeDate},
+			core:  ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+		},
+		{
+			name:  "unhandled-filter",
+			types: []sqlbase.ColumnType{columnTypeInt},
+			core:  ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+			post:  PostProcessSpec{Filter: Expression{Expr: "@1 IS NULL"}},
+		},
+		{
+			name:  "unhandled-render",
+			types: []sqlbase.ColumnType{columnTypeInt},
+			core:  ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+			post:  PostProcessSpec{RenderExprs: []Expression{{Expr: "@1 // 2"}}},
+		},
+		{
+			name:  "unhandled-aggregation",
+			types: []sqlbase.ColumnType{columnTypeInt},
+			core: ProcessorCoreUnion{Aggregator: &AggregatorSpec{
+				Aggregations: []AggregatorSpec_Aggregation{
+					{Func: AggregatorSpec_AVG, ColIdx: []uint32{0}},
+				},
+			}},
+		},
+		{
+			name:  "distinct",
+			types: []sqlbase.ColumnType{columnTypeInt},
+			core:  ProcessorCoreUnion{Distinct: &DistinctSpec{DistinctColumns: []uint32{0}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(context.Background())
+			flowCtx := FlowCtx{evalCtx: evalCtx, vectorize: true}
+			inputs := []RowSource{NewRowBuffer(tc.types, nil /* rows */, RowBufferArgs{})}
+			if _, err := newVectorizedProcessor(
+				&flowCtx, &tc.core, &tc.post, inputs, []RowReceiver{&RowBuffer{}},
+			); err == nil {
+				t.Fatal("expected an error")
+			}
+			// The row processors are used instead.
+			p, err := newProcessor(&flowCtx, &tc.core, &tc.post, inputs, []RowReceiver{&RowBuffer{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := p.(*materializer); ok {
+				t.Fatalf("unexpected materializer")
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// GetWindowFunctionInfo returns the constructor and the return type of the
+// window function fn applied to arguments of the given types. fn is either a
+// window function or an aggregate function applied over a window.
+func GetWindowFunctionInfo(
+	fn WindowerSpec_WindowFn, inputTypes ...sqlbase.ColumnType,
+) (
+	windowConstructor func(*parser.EvalContext) parser.WindowFunc,
+	returnType sqlbase.ColumnType,
+	err error,
+) {
+	var name string
+	var builtins []parser.Builtin
+	switch {
+	case fn.AggregateFunc != nil && fn.WindowFunc != nil:
+		return nil, sqlbase.ColumnType{}, errors.Errorf(
+			"both aggregate and window function set for window function",
+		)
+	case fn.AggregateFunc != nil:
+		if *fn.AggregateFunc == AggregatorSpec_IDENT {
+			return nil, sqlbase.ColumnType{}, errors.Errorf(
+				"ident aggregate can't be used as a window function",
+			)
+		}
+		name = fn.AggregateFunc.String()
+		builtins = parser.Aggregates[strings.ToLower(name)]
+	case fn.WindowFunc != nil:
+		name = fn.WindowFunc.String()
+		builtins = parser.Builtins[strings.ToLower(name)]
+	default:
+		return nil, sqlbase.ColumnType{}, errors.Errorf("no function set for window function")
+	}
+
+	datumTypes := make([]parser.Type, len(inputTypes))
+	for i := range inputTypes {
+		datumTypes[i] = inputTypes[i].ToDatumType()
+	}
+	for _, b := range builtins {
+		types := b.Types.Types()
+		if len(types) != len(inputTypes) {
+			continue
+		}
+		match := true
+		for i, t := range types {
+			if !datumTypes[i].Equivalent(t) {
+				match = false
+				break
+			}
+		}
+		if match {
+			// Found!
+			constructWindow := func(evalCtx *parser.EvalContext) parser.WindowFunc {
+				return b.WindowFunc(datumTypes, evalCtx)
+			}
+			return constructWindow, sqlbase.DatumTypeToColumnType(b.FixedReturnType()), nil
+		}
+	}
+	return nil, sqlbase.ColumnType{}, errors.Errorf(
+		"no builtin window function for %s on %v", name, inputTypes,
+	)
+}
+
+// windowFn is a window function computed by a windower.
+type windowFn struct {
+	create   func(*parser.EvalContext) parser.WindowFunc
+	argCols  columns
+	ordering sqlbase.ColumnOrdering
+}
+
+// windower is the processor core type that computes window functions. It
+// buffers all its input rows, splits them into partitions and computes each
+// window function over each partition, in the partition's ordering for that
+// function. Each input row is output followed by the results of the window
+// functions for that row.
+//
+// Since a partition is never split between windowers, the window functions of
+// a query can be computed in parallel by several windowers as long as their
+// inputs are hash-routed on the partition columns.
+type windower struct {
+	flowCtx *FlowCtx
+	// input is a row source without metadata; the metadata is directed straight
+	// to out.output.
+	input NoMetadataRowSource
+	// rawInput is the true input, not wrapped in a NoMetadataRowSource.
+	rawInput RowSource
+	out      procOutputHelper
+
+	partitionBy columns
+	windowFns   []windowFn
+	outputTypes []sqlbase.ColumnType
+
+	// acc accounts for the memory used by the partitions and by the results of
+	// the window functions; the input rows are accounted for by their
+	// container.
+	acc mon.BoundAccount
+
+	datumAlloc sqlbase.DatumAlloc
+}
+
+var _ processor = &windower{}
+
+func newWindower(
+	flowCtx *FlowCtx, spec *WindowerSpec, input RowSource, post *PostProcessSpec, output RowReceiver,
+) (*windower, error) {
+	inputTypes := input.Types()
+	w := &windower{
+		flowCtx:     flowCtx,
+		input:       MakeNoMetadataRowSource(input, output),
+		rawInput:    input,
+		partitionBy: columns(spec.PartitionBy),
+		windowFns:   make([]windowFn, len(spec.WindowFns)),
+		acc:         flowCtx.evalCtx.Mon.MakeBoundAccount(),
+	}
+	w.outputTypes = make([]sqlbase.ColumnType, 0, len(inputTypes)+len(spec.WindowFns))
+	w.outputTypes = append(w.outputTypes, inputTypes...)
+	for _, c := range w.partitionBy {
+		if c >= uint32(len(inputTypes)) {
+			return nil, errors.Errorf("partition column %d out of range", c)
+		}
+	}
+
+	for i, fn := range spec.WindowFns {
+		argTypes := make([]sqlbase.ColumnType, len(fn.ArgCols))
+		for j, c := range fn.ArgCols {
+			if c >= uint32(len(inputTypes)) {
+				return nil, errors.Errorf("argument column %d out of range", c)
+			}
+			argTypes[j] = inputTypes[c]
+		}
+		constructor, retType, err := GetWindowFunctionInfo(fn, argTypes...)
+		if err != nil {
+			return nil, err
+		}
+		ordering := convertToColumnOrdering(fn.Ordering)
+		for _, o := range ordering {
+			if o.ColIdx >= len(inputTypes) {
+				return nil, errors.Errorf("ordering column %d out of range", o.ColIdx)
+			}
+		}
+		w.windowFns[i] = windowFn{
+			create:   constructor,
+			argCols:  columns(fn.ArgCols),
+			ordering: ordering,
+		}
+		w.outputTypes = append(w.outputTypes, retType)
+	}
+
+	if err := w.out.init(post, w.outputTypes, &flowCtx.evalCtx, output); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run is part of the processor interface.
+func (w *windower) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+
+	ctx = log.WithLogTag(ctx, "Windower", nil)
+	ctx, span := tracing.ChildSpan(ctx, "windower")
+	defer tracing.FinishSpan(span)
+
+	if log.V(2) {
+		log.Infof(ctx, "starting windower run")
+		defer log.Infof(ctx, "exiting windower run")
+	}
+
+	err := w.mainLoop(ctx)
+	if err != nil {
+		log.Errorf(ctx, "error computing window functions: %s", err)
+	}
+	DrainAndClose(ctx, w.out.output, err, w.rawInput)
+}
+
+// mainLoop buffers the input rows, computes the window functions and outputs
+// the results. It returns once either all the rows have been output or the
+// consumer indicated that no more rows are needed. In any case, the caller is
+// responsible for draining and closing the producer and the consumer.
+func (w *windower) mainLoop(ctx context.Context) error {
+	defer w.acc.Close(ctx)
+
+	rows := makeRowContainer(nil /* ordering */, w.rawInput.Types(), &w.flowCtx.evalCtx)
+	defer rows.Close(ctx)
+	for {
+		row, err := w.input.NextRow()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		if err := rows.AddRow(ctx, row); err != nil {
+			return err
+		}
+	}
+	log.VEventf(ctx, 1, "buffered %d rows", rows.Len())
+
+	partitions, err := w.partition(ctx, &rows)
+	if err != nil {
+		return err
+	}
+
+	// results holds, for each input row, the results of the window functions.
+	numFns := len(w.windowFns)
+	if err := w.acc.Grow(
+		ctx, int64(uintptr(rows.Len()*numFns)*unsafe.Sizeof(parser.Datum(nil))),
+	); err != nil {
+		return err
+	}
+	results := make([]parser.Datum, rows.Len()*numFns)
+	for fnIdx := range w.windowFns {
+		for _, partition := range partitions {
+			if err := w.computeWindowFn(ctx, &rows, partition, fnIdx, results); err != nil {
+				return err
+			}
+		}
+	}
+
+	outRow := make(sqlbase.EncDatumRow, len(w.outputTypes))
+	for i := 0; i < rows.Len(); i++ {
+		inputLen := copy(outRow, rows.EncRow(i))
+		for j, res := range results[i*numFns : (i+1)*numFns] {
+			outRow[inputLen+j] = sqlbase.DatumToEncDatum(w.outputTypes[inputLen+j], res)
+		}
+		// Push the row to the output; stop if they don't need more rows.
+		consumerStatus, err := w.out.emitRow(ctx, outRow)
+		if err != nil || consumerStatus != NeedMoreRows {
+			return err
+		}
+	}
+	return nil
+}
+
+// partition splits the rows into partitions according to the partition
+// columns. Each partition is a list of row indexes, in the order of the rows.
+func (w *windower) partition(ctx context.Context, rows *rowContainer) ([][]int, error) {
+	const sizeOfRowIdx = int64(unsafe.Sizeof(int(0)))
+	if len(w.partitionBy) == 0 {
+		// All the rows are in the same partition.
+		if err := w.acc.Grow(ctx, int64(rows.Len())*sizeOfRowIdx); err != nil {
+			return nil, err
+		}
+		partition := make([]int, rows.Len())
+		for i := range partition {
+			partition[i] = i
+		}
+		return [][]int{partition}, nil
+	}
+
+	var partitions [][]int
+	partitionIdx := make(map[string]int)
+	var scratch []byte
+	for i := 0; i < rows.Len(); i++ {
+		encoded, _, err := encodeColumnsOfRow(
+			&w.datumAlloc, scratch, rows.EncRow(i), w.partitionBy, true, /* encodeNull */
+		)
+		if err != nil {
+			return nil, err
+		}
+		idx, ok := partitionIdx[string(encoded)]
+		if !ok {
+			if err := w.acc.Grow(ctx, int64(len(encoded))); err != nil {
+				return nil, err
+			}
+			idx = len(partitions)
+			partitionIdx[string(encoded)] = idx
+			partitions = append(partitions, nil)
+		}
+		if err := w.acc.Grow(ctx, sizeOfRowIdx); err != nil {
+			return nil, err
+		}
+		partitions[idx] = append(partitions[idx], i)
+		scratch = encoded[:0]
+	}
+	return partitions, nil
+}
+
+// computeWindowFn computes the fnIdx-th window function over a partition and
+// stores the result for each row in results.
+func (w *windower) computeWindowFn(
+	ctx context.Context, rows *rowContainer, partition []int, fnIdx int, results []parser.Datum,
+) error {
+	fn := &w.windowFns[fnIdx]
+	evalCtx := &w.flowCtx.evalCtx
+
+	// The window frame only sees the arguments of the function.
+	numArgs := len(fn.argCols)
+	sz := uintptr(len(partition)) * (unsafe.Sizeof(parser.IndexedRow{}) +
+		uintptr(numArgs)*unsafe.Sizeof(parser.Datum(nil)))
+	if err := w.acc.Grow(ctx, int64(sz)); err != nil {
+		return err
+	}
+	frameRows := make([]parser.IndexedRow, len(partition))
+	args := make(parser.Datums, len(partition)*numArgs)
+	for i, rowIdx := range partition {
+		row := rows.At(rowIdx)
+		rowArgs := args[i*numArgs : (i+1)*numArgs]
+		for j, c := range fn.argCols {
+			rowArgs[j] = row[c]
+		}
+		frameRows[i] = parser.IndexedRow{Idx: rowIdx, Row: rowArgs}
+	}
+
+	// Rows that are equal on the ordering columns are peers; without an
+	// ordering, all the rows of the partition are peers.
+	peers := func(i, j int) bool {
+		return sqlbase.CompareDatums(
+			fn.ordering, evalCtx, rows.At(frameRows[i].Idx), rows.At(frameRows[j].Idx),
+		) == 0
+	}
+	// The sort is stable so that window functions with the same ordering see
+	// the rows in the same order, even when the ordering doesn't determine the
+	// order of the rows.
+	if len(fn.ordering) > 0 {
+		sort.SliceStable(frameRows, func(i, j int) bool {
+			return sqlbase.CompareDatums(
+				fn.ordering, evalCtx, rows.At(frameRows[i].Idx), rows.At(frameRows[j].Idx),
+			) < 0
+		})
+	}
+
+	builtin := fn.create(evalCtx)
+	defer builtin.Close(ctx, evalCtx)
+
+	frame := parser.WindowFrame{
+		Rows:        frameRows,
+		ArgIdxStart: 0,
+		ArgCount:    numArgs,
+		RowIdx:      0,
+	}
+	numFns := len(w.windowFns)
+	for frame.RowIdx < len(frameRows) {
+		// Compute the size of the current peer group.
+		frame.FirstPeerIdx = frame.RowIdx
+		frame.PeerRowCount = 1
+		for ; frame.FirstPeerIdx+frame.PeerRowCount < len(frameRows); frame.PeerRowCount++ {
+			cur := frame.FirstPeerIdx + frame.PeerRowCount
+			if !peers(cur, cur-1) {
+				break
+			}
+		}
+
+		// Perform calculations on each row in the current peer group.
+		for ; frame.RowIdx < frame.FirstPeerIdx+frame.PeerRowCount; frame.RowIdx++ {
+			res, err := builtin.Compute(ctx, evalCtx, frame)
+			if err != nil {
+				return err
+			}
+			// This may overestimate, because WindowFuncs may perform internal caching.
+			if err := w.acc.Grow(ctx, int64(res.Size())); err != nil {
+				return err
+			}
+			results[frameRows[frame.RowIdx].Idx*numFns+fnIdx] = res
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestWindower(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	v := [6]sqlbase.EncDatum{}
+	for i := range v {
+		v[i] = sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(i)))
+	}
+	input := sqlbase.EncDatumRows{
+		{v[1], v[2]},
+		{v[2], v[5]},
+		{v[1], v[1]},
+		{v[1], v[2]},
+		{v[2], v[3]},
+	}
+	byB := func(dir encoding.Direction) Ordering {
+		return convertToSpecOrdering(sqlbase.ColumnOrdering{{ColIdx: 1, Direction: dir}})
+	}
+
+	testCases := []struct {
+		name     string
+		spec     WindowerSpec
+		post     PostProcessSpec
+		expected string
+	}{
+		{
+			// SELECT a, b, row_number() OVER w, sum(b) OVER w, lag(b) OVER w
+			//   WINDOW w AS (PARTITION BY a ORDER BY b)
+			name: "Partitioned",
+			spec: WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []WindowerSpec_WindowFn{
+					{
+						WindowFunc: WindowerSpec_ROW_NUMBER.Enum(),
+						Ordering:   byB(encoding.Ascending),
+					},
+					{
+						AggregateFunc: AggregatorSpec_SUM.Enum(),
+						ArgCols:       []uint32{1},
+						Ordering:      byB(encoding.Ascending),
+					},
+					{
+						WindowFunc: WindowerSpec_LAG.Enum(),
+						ArgCols:    []uint32{1},
+						Ordering:   byB(encoding.Ascending),
+					},
+				},
+			},
+			expected: "[[1 2 2 5 1] [2 5 2 8 3] [1 1 1 1 NULL] [1 2 3 5 2] [2 3 1 3 NULL]]",
+		},
+		{
+			// SELECT b, rank() OVER (ORDER BY b DESC)
+			name: "Unpartitioned",
+			spec: WindowerSpec{
+				WindowFns: []WindowerSpec_WindowFn{
+					{
+						WindowFunc: WindowerSpec_RANK.Enum(),
+						Ordering:   byB(encoding.Descending),
+					},
+				},
+			},
+			post: PostProcessSpec{
+				Projection:    true,
+				OutputColumns: []uint32{1, 2},
+			},
+			expected: "[[2 3] [5 1] [1 5] [2 3] [3 2]]",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			types := []sqlbase.ColumnType{columnTypeInt, columnTypeInt}
+			in := NewRowBuffer(types, input, RowBufferArgs{})
+			out := &RowBuffer{}
+			evalCtx := parser.MakeTestingEvalContext()
+			defer evalCtx.Stop(context.Background())
+			flowCtx := FlowCtx{
+				evalCtx: evalCtx,
+			}
+
+			w, err := newWindower(&flowCtx, &c.spec, in, &c.post, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Run(context.Background(), nil)
+			if !out.ProducerClosed {
+				t.Fatalf("output RowReceiver not closed")
+			}
+
+			var res sqlbase.EncDatumRows
+			for {
+				row, meta := out.Next()
+				if !meta.Empty() {
+					t.Fatalf("unexpected metadata: %v", meta)
+				}
+				if row == nil {
+					break
+				}
+				res = append(res, row)
+			}
+
+			if result := res.String(); result != c.expected {
+				t.Errorf("invalid results: %s, expected %s", result, c.expected)
+			}
+		})
+	}
+}
+
+// TestWindowerMemoryBudget verifies that the windower reports an error once
+// the rows it buffers don't fit in its memory budget.
+func TestWindowerMemoryBudget(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	const numRows = 1000
+	const budget = 10 * 1024
+
+	columnTypeInt := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	types := []sqlbase.ColumnType{columnTypeInt}
+	input := make(sqlbase.EncDatumRows, numRows)
+	for i := range input {
+		input[i] = sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(columnTypeInt, parser.NewDInt(parser.DInt(i))),
+		}
+	}
+
+	in := NewRowBuffer(types, input, RowBufferArgs{})
+	out := &RowBuffer{}
+	evalCtx := parser.MakeTestingEvalContext()
+	defer evalCtx.Stop(ctx)
+	monitor := makeTestingBudgetMonitor(ctx, budget)
+	defer monitor.Stop(ctx)
+	flowCtx := FlowCtx{evalCtx: evalCtx}
+	flowCtx.evalCtx.Mon = &monitor
+
+	spec := WindowerSpec{
+		WindowFns: []WindowerSpec_WindowFn{{WindowFunc: WindowerSpec_ROW_NUMBER.Enum()}},
+	}
+	w, err := newWindower(&flowCtx, &spec, in, &PostProcessSpec{}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Run(ctx, nil)
+	if !out.ProducerClosed {
+		t.Fatalf("output RowReceiver not closed")
+	}
+
+	var budgetErr error
+	for {
+		row, meta := out.Next()
+		if meta.Err != nil {
+			budgetErr = meta.Err
+		}
+		if row == nil && meta.Empty() {
+			break
+		}
+	}
+	if !isMemoryBudgetError(budgetErr) {
+		t.Fatalf("expected a memory budget error, got %v", budgetErr)
+	}
+}
@@ -37,6 +37,8 @@ type dropDatabaseNode struct {
 	n      *parser.DropDatabase
 	dbDesc *sqlbase.DatabaseDescriptor
 	td     []*sqlbase.TableDescriptor
+	types  []*sqlbase.TypeDescriptor
+	fns    []*sqlbase.FunctionDescriptor
 }
 
 // DropDatabase drops a database.
@@ -69,10 +71,25 @@ func (p *planner) DropDatabase(ctx context.Context, n *parser.DropDatabase) (pla
 		return nil, err
 	}
 
-	tbNames, err := getTableNames(ctx, p.txn, p.getVirtualTabler(), dbDesc)
+	objects, err := getDatabaseObjects(ctx, p.txn, dbDesc)
 	if err != nil {
 		return nil, err
 	}
+	tbNames := objects.tableNames
+
+	// The functions of the database may only be called by the views and
+	// functions of the database, which are dropped along with it.
+	for _, fnDesc := range objects.functions {
+		refs, err := p.liveFunctionReferences(ctx, fnDesc)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if ref.parentID != dbDesc.ID {
+				return nil, newDependentFunctionError(fnDesc.Name, ref)
+			}
+		}
+	}
 
 	td := make([]*sqlbase.TableDescriptor, len(tbNames))
 	for i := range tbNames {
@@ -100,7 +117,9 @@ func (p *planner) DropDatabase(ctx context.Context, n *parser.DropDatabase) (pla
 		return nil, err
 	}
 
-	return &dropDatabaseNode{n: n, p: p, dbDesc: dbDesc, td: td}, nil
+	return &dropDatabaseNode{
+		n: n, p: p, dbDesc: dbDesc, td: td, types: objects.types, fns: objects.functions,
+	}, nil
 }
 
 // filterCascadedTables takes a list of table descriptors and removes any
@@ -174,6 +193,18 @@ func (n *dropDatabaseNode) Start(ctx context.Context) error {
 	b.Del(nameKey)
 	// Delete the zone config entry for this database.
 	b.Del(zoneKey)
+	// The types of the database are only referenced by its tables, which
+	// were all dropped above.
+	for _, typeDesc := range n.types {
+		b.Del(sqlbase.MakeNameMetadataKey(typeDesc.ParentID, typeDesc.Name))
+		b.Del(sqlbase.MakeDescMetadataKey(typeDesc.ID))
+	}
+	// Likewise, the functions of the database are only called by its views
+	// and functions.
+	for _, fnDesc := range n.fns {
+		b.Del(sqlbase.MakeNameMetadataKey(fnDesc.ParentID, fnDesc.Name))
+		b.Del(sqlbase.MakeDescMetadataKey(fnDesc.ID))
+	}
 
 	n.p.session.setTestingVerifyMetadata(func(systemConfig config.SystemConfig) error {
 		for _, key := range [...]roachpb.Key{descKey, nameKey, zoneKey} {
@@ -188,6 +219,10 @@ func (n *dropDatabaseNode) Start(ctx context.Context) error {
 		return err
 	}
 
+	if err := n.p.deleteComments(ctx, n.dbDesc.ID); err != nil {
+		return err
+	}
+
 	// Log Drop Database event. This is an auditable log event and is recorded
 	// in the same transaction as the table descriptor update.
 	if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
@@ -362,6 +397,15 @@ func (p *planner) dropIndexByName(
 	if !found {
 		return fmt.Errorf("index %q in the middle of being added, try again later", idxName)
 	}
+	if idx.IsSharded() {
+		dropShardColumn(tableDesc, &idx)
+	}
+
+	if err := p.deleteComment(ctx, commentKey{
+		typ: indexCommentType, objID: tableDesc.ID, subID: uint32(idx.ID),
+	}); err != nil {
+		return err
+	}
 
 	if err := tableDesc.Validate(ctx, p.txn); err != nil {
 		return err
@@ -838,6 +882,14 @@ func (p *planner) dropTableImpl(
 		return droppedViews, err
 	}
 
+	if err := p.deleteComments(ctx, tableDesc.ID); err != nil {
+		return droppedViews, err
+	}
+
+	if err := p.deleteTableStats(ctx, tableDesc.ID); err != nil {
+		return droppedViews, err
+	}
+
 	p.session.setTestingVerifyMetadata(func(systemConfig config.SystemConfig) error {
 		return verifyDropTableMetadata(systemConfig, tableDesc.ID, "table")
 	})
@@ -1125,6 +1177,19 @@ func (n *dropUserNode) Start(ctx context.Context) error {
 			return errors.Errorf("user %s does not exist", normalizedUsername)
 		}
 
+		// Remove the session variable defaults configured for the user with
+		// ALTER USER ... SET.
+		if _, err := internalExecutor.ExecuteStatementInTransaction(
+			ctx,
+			"drop-user-settings",
+			n.p.txn,
+			"DELETE FROM system.user_settings WHERE username=$1",
+			normalizedUsername,
+		); err != nil {
+			return err
+		}
+		n.p.session.userDefaultsCache.invalidate(n.p.txn, normalizedUsername)
+
 		numDeleted += rowsAffected
 	}
 
@@ -1154,3 +1219,258 @@ func (p *planner) DropUser(ctx context.Context, n *parser.DropUser) (planNode, e
 
 	return &dropUserNode{p: p, n: n}, nil
 }
+
+type dropTypeNode struct {
+	p     *planner
+	n     *parser.DropType
+	types []*sqlbase.TypeDescriptor
+}
+
+// DropType drops a user-defined type.
+// Privileges: DROP on type.
+//   Notes: postgres allows only the type owner to DROP a type.
+func (p *planner) DropType(ctx context.Context, n *parser.DropType) (planNode, error) {
+	types := make([]*sqlbase.TypeDescriptor, 0, len(n.Names))
+	for _, name := range n.Names {
+		tn, err := name.NormalizeTableName()
+		if err != nil {
+			return nil, err
+		}
+		if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+			return nil, err
+		}
+
+		typeDesc, err := getTypeDesc(ctx, p.txn, p.getVirtualTabler(), tn)
+		if err != nil {
+			return nil, err
+		}
+		if typeDesc == nil {
+			if n.IfExists {
+				continue
+			}
+			return nil, newUndefinedTypeError(name.String())
+		}
+		if err := p.CheckPrivilege(typeDesc, privilege.DROP); err != nil {
+			return nil, err
+		}
+
+		tables, err := p.liveTypeReferences(ctx, typeDesc)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) > 0 {
+			if n.DropBehavior == parser.DropCascade {
+				return nil, pgerror.Unimplemented(
+					"drop type cascade", "dropping the columns which use a type is not supported")
+			}
+			return nil, pgerror.NewErrorf(pgerror.CodeDependentObjectsStillExistError,
+				"cannot drop type %q because table %q depends on it", typeDesc.Name, tables[0].Name)
+		}
+		types = append(types, typeDesc)
+	}
+
+	if len(types) == 0 {
+		return &emptyNode{}, nil
+	}
+	return &dropTypeNode{p: p, n: n, types: types}, nil
+}
+
+func (n *dropTypeNode) Start(ctx context.Context) error {
+	for _, typeDesc := range n.types {
+		nameKey := sqlbase.MakeNameMetadataKey(typeDesc.ParentID, typeDesc.Name)
+		descKey := sqlbase.MakeDescMetadataKey(typeDesc.ID)
+
+		b := &client.Batch{}
+		if log.V(2) {
+			log.Infof(ctx, "Del %s", descKey)
+			log.Infof(ctx, "Del %s", nameKey)
+		}
+		b.Del(descKey)
+		b.Del(nameKey)
+
+		n.p.session.setTestingVerifyMetadata(func(systemConfig config.SystemConfig) error {
+			for _, key := range [...]roachpb.Key{descKey, nameKey} {
+				if err := expectDeleted(systemConfig, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		if err := n.p.txn.Run(ctx, b); err != nil {
+			return err
+		}
+
+		// Log Drop Type event. This is an auditable log event and is recorded
+		// in the same transaction as the type descriptor update.
+		if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+			ctx,
+			n.p.txn,
+			EventLogDropType,
+			int32(typeDesc.ID),
+			int32(n.p.evalCtx.NodeID),
+			struct {
+				TypeName  string
+				Statement string
+				User      string
+			}{typeDesc.Name, n.n.String(), n.p.session.User},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*dropTypeNode) Next(context.Context) (bool, error) { return false, nil }
+func (*dropTypeNode) Close(context.Context)              {}
+
+func (*dropTypeNode) Values() parser.Datums      { return parser.Datums{} }
+func (*dropTypeNode) DebugValues() debugValues   { return debugValues{} }
+func (*dropTypeNode) MarkDebug(mode explainMode) {}
+
+type dropFunctionNode struct {
+	p   *planner
+	n   *parser.DropFunction
+	fns []*sqlbase.FunctionDescriptor
+}
+
+// DropFunction drops a user-defined function.
+// Privileges: DROP on function.
+//   Notes: postgres allows only the function owner to DROP a function.
+func (p *planner) DropFunction(ctx context.Context, n *parser.DropFunction) (planNode, error) {
+	fns := make([]*sqlbase.FunctionDescriptor, 0, len(n.Functions))
+	dropped := make(map[sqlbase.ID]bool, len(n.Functions))
+	for _, fo := range n.Functions {
+		tn, err := fo.Name.NormalizeTableName()
+		if err != nil {
+			return nil, err
+		}
+		if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+			return nil, err
+		}
+
+		fnDesc, err := getFunctionDesc(ctx, p.txn, p.getVirtualTabler(), tn)
+		if err != nil {
+			return nil, err
+		}
+		if fnDesc != nil && fo.ArgTypes != nil {
+			match, err := functionSignatureMatches(fnDesc, fo.ArgTypes)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				fnDesc = nil
+			}
+		}
+		if fnDesc == nil {
+			if n.IfExists {
+				continue
+			}
+			return nil, newUndefinedFunctionError(fo.Name.String())
+		}
+		if err := p.CheckPrivilege(fnDesc, privilege.DROP); err != nil {
+			return nil, err
+		}
+		fns = append(fns, fnDesc)
+		dropped[fnDesc.ID] = true
+	}
+
+	for _, fnDesc := range fns {
+		refs, err := p.liveFunctionReferences(ctx, fnDesc)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if dropped[ref.id] {
+				continue
+			}
+			if n.DropBehavior == parser.DropCascade {
+				return nil, pgerror.Unimplemented(
+					"drop function cascade", "dropping the objects which call a function is not supported")
+			}
+			return nil, newDependentFunctionError(fnDesc.Name, ref)
+		}
+	}
+
+	if len(fns) == 0 {
+		return &emptyNode{}, nil
+	}
+	return &dropFunctionNode{p: p, n: n, fns: fns}, nil
+}
+
+// functionSignatureMatches returns whether the function has parameters of
+// the given types.
+func functionSignatureMatches(
+	fnDesc *sqlbase.FunctionDescriptor, argTypes []parser.ColumnType,
+) (bool, error) {
+	if len(argTypes) != len(fnDesc.Params) {
+		return false, nil
+	}
+	for i, t := range argTypes {
+		colType, err := makeFunctionColumnType(t)
+		if err != nil {
+			return false, err
+		}
+		if !colType.ToDatumType().Equivalent(fnDesc.Params[i].Type.ToDatumType()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (n *dropFunctionNode) Start(ctx context.Context) error {
+	for _, fnDesc := range n.fns {
+		nameKey := sqlbase.MakeNameMetadataKey(fnDesc.ParentID, fnDesc.Name)
+		descKey := sqlbase.MakeDescMetadataKey(fnDesc.ID)
+
+		b := &client.Batch{}
+		if log.V(2) {
+			log.Infof(ctx, "Del %s", descKey)
+			log.Infof(ctx, "Del %s", nameKey)
+		}
+		b.Del(descKey)
+		b.Del(nameKey)
+
+		n.p.session.setTestingVerifyMetadata(func(systemConfig config.SystemConfig) error {
+			for _, key := range [...]roachpb.Key{descKey, nameKey} {
+				if err := expectDeleted(systemConfig, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		if err := n.p.txn.Run(ctx, b); err != nil {
+			return err
+		}
+
+		if err := n.p.removeFunctionReferences(ctx, fnDesc.DependsOn, fnDesc.ID); err != nil {
+			return err
+		}
+
+		// Log Drop Function event. This is an auditable log event and is
+		// recorded in the same transaction as the function descriptor update.
+		if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+			ctx,
+			n.p.txn,
+			EventLogDropFunction,
+			int32(fnDesc.ID),
+			int32(n.p.evalCtx.NodeID),
+			struct {
+				FunctionName string
+				Statement    string
+				User         string
+			}{fnDesc.Name, n.n.String(), n.p.session.User},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*dropFunctionNode) Next(context.Context) (bool, error) { return false, nil }
+func (*dropFunctionNode) Close(context.Context)              {}
+
+func (*dropFunctionNode) Values() parser.Datums      { return parser.Datums{} }
+func (*dropFunctionNode) DebugValues() debugValues   { return debugValues{} }
+func (*dropFunctionNode) MarkDebug(mode explainMode) {}
@@ -0,0 +1,564 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"math"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// This file implements the cost model used to choose indexes and join
+// orders when the tables of a query have statistics (see CREATE
+// STATISTICS). Costs are expressed in rows: reading a row from an index
+// costs 1, and every row processed by a node above the scans costs
+// approximately 1 as well.
+
+const (
+	// defaultSelectivity is the fraction of rows assumed to pass a filter
+	// whose selectivity cannot be estimated from the statistics.
+	defaultSelectivity = 1.0 / 3
+	// indexJoinCostFactor is the cost of looking up a row in the primary
+	// index during an index join, relative to scanning a row.
+	indexJoinCostFactor = 4
+)
+
+// planEstimate is the estimated number of rows produced by a plan and the
+// estimated cost of producing them.
+type planEstimate struct {
+	rows float64
+	cost float64
+}
+
+// selectivity returns the fraction of the rows of the column's table for
+// which the comparison "col <op> d" is true.
+func (s *tableStatistic) selectivity(
+	evalCtx *parser.EvalContext, op parser.ComparisonOperator, d parser.Datum,
+) float64 {
+	if s.rowCount == 0 {
+		return 0
+	}
+	nullFraction := float64(s.nullCount) / float64(s.rowCount)
+	switch op {
+	case parser.Is, parser.IsNotDistinctFrom:
+		if d == parser.DNull {
+			return nullFraction
+		}
+		return s.equalSelectivity(evalCtx, d)
+	case parser.IsNot, parser.IsDistinctFrom:
+		if d == parser.DNull {
+			return 1 - nullFraction
+		}
+		return 1 - s.equalSelectivity(evalCtx, d)
+	}
+	if d == parser.DNull {
+		// Comparisons with NULL are never true.
+		return 0
+	}
+	switch op {
+	case parser.EQ:
+		return s.equalSelectivity(evalCtx, d)
+	case parser.NE:
+		return clampSelectivity(1 - nullFraction - s.equalSelectivity(evalCtx, d))
+	case parser.In, parser.NotIn:
+		tuple, ok := d.(*parser.DTuple)
+		if !ok {
+			break
+		}
+		sel := 0.0
+		for _, e := range tuple.D {
+			sel += s.equalSelectivity(evalCtx, e)
+		}
+		sel = clampSelectivity(math.Min(sel, 1-nullFraction))
+		if op == parser.NotIn {
+			return 1 - nullFraction - sel
+		}
+		return sel
+	case parser.LT, parser.LE:
+		if less, ok := s.lessFraction(evalCtx, d, op == parser.LE); ok {
+			return less * (1 - nullFraction)
+		}
+	case parser.GT, parser.GE:
+		if less, ok := s.lessFraction(evalCtx, d, op == parser.GT); ok {
+			return (1 - less) * (1 - nullFraction)
+		}
+	}
+	return defaultSelectivity
+}
+
+// equalSelectivity returns the fraction of the rows equal to d.
+func (s *tableStatistic) equalSelectivity(evalCtx *parser.EvalContext, d parser.Datum) float64 {
+	if d == parser.DNull || s.rowCount == 0 {
+		return 0
+	}
+	nonNullFraction := float64(s.rowCount-s.nullCount) / float64(s.rowCount)
+	if total := s.histogramRows(); total > 0 && s.comparable(d) {
+		for i, b := range s.histogram.Buckets {
+			if c := s.upperBounds[i].Compare(evalCtx, d); c == 0 {
+				// The value is common enough to be the upper bound of a bucket.
+				return float64(b.NumEq) / total * nonNullFraction
+			} else if c > 0 {
+				break
+			}
+		}
+	}
+	return nonNullFraction / s.distinct()
+}
+
+// lessFraction uses the histogram to estimate the fraction of the non-NULL
+// values which are less than d, or less or equal if inclusive is set. It
+// returns false if the column has no usable histogram.
+func (s *tableStatistic) lessFraction(
+	evalCtx *parser.EvalContext, d parser.Datum, inclusive bool,
+) (float64, bool) {
+	total := s.histogramRows()
+	if total == 0 || !s.comparable(d) {
+		return 0, false
+	}
+	less := 0.0
+	for i, b := range s.histogram.Buckets {
+		c := s.upperBounds[i].Compare(evalCtx, d)
+		if c < 0 {
+			less += float64(b.NumRange + b.NumEq)
+			continue
+		}
+		if c == 0 {
+			less += float64(b.NumRange)
+			if inclusive {
+				less += float64(b.NumEq)
+			}
+		} else {
+			// The value falls in the range of this bucket; assume it is in the
+			// middle.
+			less += float64(b.NumRange) / 2
+		}
+		break
+	}
+	return less / total, true
+}
+
+// histogramRows returns the number of rows represented by the histogram.
+func (s *tableStatistic) histogramRows() float64 {
+	if s.histogram == nil {
+		return 0
+	}
+	total := 0.0
+	for _, b := range s.histogram.Buckets {
+		total += float64(b.NumRange + b.NumEq)
+	}
+	return total
+}
+
+// comparable returns whether d can be compared to the histogram bounds.
+func (s *tableStatistic) comparable(d parser.Datum) bool {
+	return len(s.upperBounds) > 0 && d.ResolvedType().Equivalent(s.upperBounds[0].ResolvedType())
+}
+
+// distinct returns the number of distinct non-NULL values of the column.
+func (s *tableStatistic) distinct() float64 {
+	if s.distinctCount < 1 {
+		return 1
+	}
+	return float64(s.distinctCount)
+}
+
+func clampSelectivity(sel float64) float64 {
+	return math.Max(0, math.Min(1, sel))
+}
+
+// scanEstimator estimates the selectivity of the filters and index
+// constraints of a scanNode using the statistics of its table.
+type scanEstimator struct {
+	evalCtx *parser.EvalContext
+	scan    *scanNode
+	stats   *tableStats
+}
+
+// columnStat returns the statistic of the scan column with the given index,
+// or nil if there is none.
+func (e *scanEstimator) columnStat(colIdx int) *tableStatistic {
+	if colIdx < 0 || colIdx >= len(e.scan.cols) {
+		return nil
+	}
+	return e.stats.columns[e.scan.cols[colIdx].ID]
+}
+
+// selectivity returns the fraction of the rows of the table for which expr
+// is true. Conjuncts and disjuncts are assumed to be independent.
+func (e *scanEstimator) selectivity(expr parser.Expr) float64 {
+	switch t := expr.(type) {
+	case nil:
+		return 1
+	case *parser.DBool:
+		if *t {
+			return 1
+		}
+		return 0
+	case *parser.ParenExpr:
+		return e.selectivity(t.Expr)
+	case *parser.AndExpr:
+		return e.selectivity(t.Left) * e.selectivity(t.Right)
+	case *parser.OrExpr:
+		l, r := e.selectivity(t.Left), e.selectivity(t.Right)
+		return l + r - l*r
+	case *parser.NotExpr:
+		return 1 - e.selectivity(t.Expr)
+	case *parser.ComparisonExpr:
+		v, ok := t.Left.(*parser.IndexedVar)
+		if !ok {
+			break
+		}
+		stat := e.columnStat(v.Idx)
+		if stat == nil {
+			break
+		}
+		switch r := t.Right.(type) {
+		case parser.Datum:
+			return stat.selectivity(e.evalCtx, t.Operator, r)
+		case *parser.IndexedVar:
+			if other := e.columnStat(r.Idx); other != nil && t.Operator == parser.EQ {
+				return 1 / math.Max(stat.distinct(), other.distinct())
+			}
+		}
+	}
+	return defaultSelectivity
+}
+
+// constraintsSelectivity returns the fraction of the rows of the table read
+// by an index scan restricted by the given constraints.
+func (e *scanEstimator) constraintsSelectivity(constraints orIndexConstraints) float64 {
+	if len(constraints) == 0 {
+		return 1
+	}
+	sel := 0.0
+	for _, cset := range constraints {
+		setSel := 1.0
+		for _, c := range cset {
+			switch {
+			case c.tupleMap != nil:
+				setSel *= math.Pow(defaultSelectivity, float64(c.numColumns()))
+			case c.start == c.end:
+				setSel *= e.selectivity(c.start)
+			case c.start != nil && c.end != nil:
+				// Both bounds restrict the same column; the rows satisfying
+				// neither bound are excluded from both estimates.
+				start, end := e.selectivity(c.start), e.selectivity(c.end)
+				if between := start + end - 1; between > 0 {
+					setSel *= between
+				} else {
+					setSel *= start * end
+				}
+			default:
+				setSel *= e.selectivity(c.start) * e.selectivity(c.end)
+			}
+		}
+		sel += setSel
+	}
+	return clampSelectivity(sel)
+}
+
+// estimateScan estimates the rows produced by a scanNode. The index
+// constraints chosen by index selection, if any, determine the rows read;
+// the remaining filter determines the rows produced.
+func (p *planner) estimateScan(ctx context.Context, n *scanNode) (planEstimate, bool) {
+	ts := p.session.tableStats.get(ctx, &n.desc)
+	if ts == nil {
+		return planEstimate{}, false
+	}
+	e := scanEstimator{evalCtx: &p.evalCtx, scan: n, stats: ts}
+	scanned := float64(ts.rowCount)
+	if n.scanRowsEstimated {
+		scanned = n.estimatedScanRows
+	}
+	rows := scanned * e.selectivity(n.filter)
+	if n.hardLimit > 0 {
+		rows = math.Min(rows, float64(n.hardLimit))
+	}
+	return planEstimate{rows: rows, cost: scanned}, true
+}
+
+// estimatePlan estimates the number of rows produced by a plan and its
+// cost. It returns false if the plan reads from a table without
+// statistics, or contains nodes the cost model doesn't know about.
+func (p *planner) estimatePlan(ctx context.Context, plan planNode) (planEstimate, bool) {
+	switch n := plan.(type) {
+	case *scanNode:
+		return p.estimateScan(ctx, n)
+
+	case *indexJoinNode:
+		index, ok := p.estimateScan(ctx, n.index)
+		if !ok {
+			return planEstimate{}, false
+		}
+		ts := p.session.tableStats.get(ctx, &n.table.desc)
+		if ts == nil {
+			return planEstimate{}, false
+		}
+		// The filter of the table side applies to the rows looked up.
+		e := scanEstimator{evalCtx: &p.evalCtx, scan: n.table, stats: ts}
+		return planEstimate{
+			rows: index.rows * e.selectivity(n.table.filter),
+			cost: index.cost + index.rows*indexJoinCostFactor,
+		}, true
+
+	case *analyzeNode:
+		return p.estimatePlan(ctx, n.plan)
+
+	case *renderNode:
+		return p.estimatePlan(ctx, n.source.plan)
+
+	case *filterNode:
+		est, ok := p.estimatePlan(ctx, n.source.plan)
+		est.rows *= defaultSelectivity
+		return est, ok
+
+	case *joinNode:
+		return p.estimateJoin(ctx, n)
+
+	case *limitNode:
+		est, ok := p.estimatePlan(ctx, n.plan)
+		if n.countExpr != nil {
+			if count, isInt := parser.AsDInt(n.countExpr); isInt {
+				est.rows = math.Min(est.rows, float64(count))
+			}
+		}
+		return est, ok
+
+	case *sortNode:
+		est, ok := p.estimatePlan(ctx, n.plan)
+		if n.needSort && est.rows > 1 {
+			est.cost += est.rows * math.Log2(est.rows)
+		}
+		return est, ok
+
+	case *groupNode:
+		est, ok := p.estimatePlan(ctx, n.plan)
+		if !ok {
+			return est, false
+		}
+		est.cost += est.rows
+		groups := 1.0
+		for i := 0; i < n.numGroupCols; i++ {
+			distinct, ok := p.estimateDistinct(ctx, n.plan, i)
+			if !ok {
+				distinct = est.rows * defaultSelectivity
+			}
+			groups *= distinct
+		}
+		est.rows = math.Min(est.rows, math.Max(groups, 1))
+		return est, true
+
+	case *distinctNode:
+		est, ok := p.estimatePlan(ctx, n.plan)
+		est.cost += est.rows
+		return est, ok
+
+	case *windowNode:
+		est, ok := p.estimatePlan(ctx, n.plan)
+		est.cost += est.rows
+		return est, ok
+
+	case *ordinalityNode:
+		return p.estimatePlan(ctx, n.source)
+
+	case *unionNode:
+		left, ok := p.estimatePlan(ctx, n.left)
+		if !ok {
+			return planEstimate{}, false
+		}
+		right, ok := p.estimatePlan(ctx, n.right)
+		if !ok {
+			return planEstimate{}, false
+		}
+		return planEstimate{rows: left.rows + right.rows, cost: left.cost + right.cost}, true
+
+	case *valuesNode:
+		rows := float64(len(n.tuples))
+		if n.rows != nil && n.rows.Len() > 0 {
+			rows = float64(n.rows.Len())
+		}
+		return planEstimate{rows: rows, cost: rows}, true
+
+	case *emptyNode:
+		if n.results {
+			return planEstimate{rows: 1}, true
+		}
+		return planEstimate{}, true
+	}
+	return planEstimate{}, false
+}
+
+// estimateJoin estimates the rows produced by a hash join: the build side
+// (the right operand) is read into a hash table which is probed with the
+// rows of the left operand.
+func (p *planner) estimateJoin(ctx context.Context, n *joinNode) (planEstimate, bool) {
+	left, ok := p.estimatePlan(ctx, n.left.plan)
+	if !ok {
+		return planEstimate{}, false
+	}
+	right, ok := p.estimatePlan(ctx, n.right.plan)
+	if !ok {
+		return planEstimate{}, false
+	}
+	sel := 1.0
+	for i := range n.pred.leftEqualityIndices {
+		sel *= p.equalitySelectivity(
+			ctx, n.left.plan, n.pred.leftEqualityIndices[i], left.rows,
+			n.right.plan, n.pred.rightEqualityIndices[i], right.rows,
+		)
+	}
+	if !isFilterTrue(n.pred.onCond) {
+		sel *= defaultSelectivity
+	}
+	rows := left.rows * right.rows * sel
+	switch n.joinType {
+	case joinTypeLeftOuter:
+		rows = math.Max(rows, left.rows)
+	case joinTypeRightOuter:
+		rows = math.Max(rows, right.rows)
+	case joinTypeFullOuter:
+		rows = math.Max(rows, left.rows+right.rows)
+	}
+	return planEstimate{
+		rows: rows,
+		cost: left.cost + right.cost + left.rows + right.rows + rows,
+	}, true
+}
+
+// chooseMergeJoinOrdering returns the ordering of the equality columns on
+// which a join is executed as a merge join, or nil if it is executed as a hash
+// join. Both algorithms read each input once, but a merge join streams both
+// inputs instead of loading one of them in memory, so it is chosen whenever
+// the statistics allow estimating the join and both inputs are already
+// ordered on all the equality columns.
+func (p *planner) chooseMergeJoinOrdering(ctx context.Context, n *joinNode) sqlbase.ColumnOrdering {
+	numEq := len(n.pred.leftEqualityIndices)
+	if numEq == 0 || n.joinType != joinTypeInner {
+		return nil
+	}
+	if _, ok := p.estimateJoin(ctx, n); !ok {
+		return nil
+	}
+	leftOrdering := planOrdering(n.left.plan).ordering
+	rightOrdering := planOrdering(n.right.plan).ordering
+	if len(leftOrdering) < numEq || len(rightOrdering) < numEq {
+		return nil
+	}
+	ordering := make(sqlbase.ColumnOrdering, 0, numEq)
+	for i := 0; i < numEq; i++ {
+		l, r := leftOrdering[i], rightOrdering[i]
+		if l.Direction != r.Direction {
+			return nil
+		}
+		eqIdx := -1
+		for j := range n.pred.leftEqualityIndices {
+			if n.pred.leftEqualityIndices[j] == l.ColIdx && n.pred.rightEqualityIndices[j] == r.ColIdx {
+				eqIdx = j
+				break
+			}
+		}
+		if eqIdx < 0 {
+			return nil
+		}
+		ordering = append(ordering, sqlbase.ColumnOrderInfo{ColIdx: eqIdx, Direction: l.Direction})
+	}
+	return ordering
+}
+
+// equalitySelectivity returns the fraction of the pairs of rows of two plans
+// for which the given columns are equal. The values of the column with the
+// fewest distinct values are assumed to be a subset of those of the other
+// column.
+func (p *planner) equalitySelectivity(
+	ctx context.Context,
+	left planNode,
+	leftCol int,
+	leftRows float64,
+	right planNode,
+	rightCol int,
+	rightRows float64,
+) float64 {
+	leftDistinct, ok := p.estimateDistinct(ctx, left, leftCol)
+	if !ok {
+		// Assume the column is a key.
+		leftDistinct = leftRows
+	}
+	rightDistinct, ok := p.estimateDistinct(ctx, right, rightCol)
+	if !ok {
+		rightDistinct = rightRows
+	}
+	return 1 / math.Max(1, math.Max(leftDistinct, rightDistinct))
+}
+
+// estimateDistinct estimates the number of distinct values of a column
+// produced by a plan, by tracing the column back to a table column. It
+// returns false if the column is computed or comes from a table without
+// statistics.
+func (p *planner) estimateDistinct(ctx context.Context, plan planNode, col int) (float64, bool) {
+	switch n := plan.(type) {
+	case *scanNode:
+		ts := p.session.tableStats.get(ctx, &n.desc)
+		if ts == nil || col >= len(n.cols) {
+			return 0, false
+		}
+		stat, ok := ts.columns[n.cols[col].ID]
+		if !ok {
+			return 0, false
+		}
+		distinct := stat.distinct()
+		// A filter can't produce more distinct values than rows.
+		if est, ok := p.estimateScan(ctx, n); ok {
+			distinct = math.Min(distinct, math.Max(est.rows, 1))
+		}
+		return distinct, true
+
+	case *indexJoinNode:
+		return p.estimateDistinct(ctx, n.table, col)
+
+	case *renderNode:
+		if col >= len(n.render) {
+			return 0, false
+		}
+		if v, ok := n.render[col].(*parser.IndexedVar); ok {
+			return p.estimateDistinct(ctx, n.source.plan, v.Idx)
+		}
+
+	case *filterNode:
+		return p.estimateDistinct(ctx, n.source.plan, col)
+
+	case *sortNode:
+		return p.estimateDistinct(ctx, n.plan, col)
+
+	case *limitNode:
+		return p.estimateDistinct(ctx, n.plan, col)
+
+	case *distinctNode:
+		return p.estimateDistinct(ctx, n.plan, col)
+
+	case *joinNode:
+		if col < n.pred.numMergedEqualityColumns {
+			return p.estimateDistinct(ctx, n.left.plan, n.pred.leftEqualityIndices[col])
+		}
+		col -= n.pred.numMergedEqualityColumns
+		if col < n.pred.numLeftCols {
+			return p.estimateDistinct(ctx, n.left.plan, col)
+		}
+		return p.estimateDistinct(ctx, n.right.plan, col-n.pred.numLeftCols)
+	}
+	return 0, false
+}
@@ -55,6 +55,29 @@ const (
 	// EventLogDropView is recorded when a view is dropped.
 	EventLogDropView EventLogType = "drop_view"
 
+	// EventLogCreatePolicy is recorded when a row-level security policy is
+	// created.
+	EventLogCreatePolicy EventLogType = "create_policy"
+	// EventLogDropPolicy is recorded when a row-level security policy is
+	// dropped.
+	EventLogDropPolicy EventLogType = "drop_policy"
+
+	// EventLogCreateTrigger is recorded when a trigger is created.
+	EventLogCreateTrigger EventLogType = "create_trigger"
+	// EventLogDropTrigger is recorded when a trigger is dropped.
+	EventLogDropTrigger EventLogType = "drop_trigger"
+
+	// EventLogCreateType is recorded when a type is created.
+	EventLogCreateType EventLogType = "create_type"
+	// EventLogDropType is recorded when a type is dropped.
+	EventLogDropType EventLogType = "drop_type"
+	// EventLogAlterType is recorded when a type is altered.
+	EventLogAlterType EventLogType = "alter_type"
+	// EventLogCreateFunction is recorded when a function is created.
+	EventLogCreateFunction EventLogType = "create_function"
+	// EventLogDropFunction is recorded when a function is dropped.
+	EventLogDropFunction EventLogType = "drop_function"
+
 	// EventLogReverseSchemaChange is recorded when an in-progress schema change
 	// encounters a problem and is reversed.
 	EventLogReverseSchemaChange EventLogType = "reverse_schema_change"
@@ -67,6 +90,13 @@ const (
 	// EventLogNodeRestart is recorded when an existing node rejoins the cluster
 	// after being offline.
 	EventLogNodeRestart EventLogType = "node_restart"
+
+	// EventLogCancelSession is recorded when a session is canceled with
+	// CANCEL SESSION.
+	EventLogCancelSession EventLogType = "cancel_session"
+	// EventLogRejectConnection is recorded when a SQL connection is denied by
+	// the host-based authentication rules, or fails to authenticate.
+	EventLogRejectConnection EventLogType = "reject_connection"
 )
 
 // An EventLogger exposes methods used to record events to the event table.
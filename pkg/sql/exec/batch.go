@@ -0,0 +1,145 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+// BatchSize is the maximum number of rows in a batch.
+const BatchSize = 1024
+
+// Batch is the unit of data passed between operators: a set of column
+// vectors holding the values of up to BatchSize rows.
+//
+// A batch can have a selection vector, which lists the positions of its rows
+// in the column vectors; this allows operators like filters to drop rows
+// without copying any values. The operators producing batches disable the
+// selection vector when they fill a batch.
+type Batch interface {
+	// Length returns the number of rows in the batch. If the batch has a
+	// selection vector, this is the number of positions it lists.
+	Length() uint16
+	// SetLength sets the number of rows in the batch.
+	SetLength(n uint16)
+	// Width returns the number of columns in the batch.
+	Width() int
+	// ColVec returns the i-th column vector.
+	ColVec(i int) ColVec
+	// ColVecs returns all the column vectors.
+	ColVecs() []ColVec
+	// Selection returns the selection vector, or nil if the rows of the batch
+	// are the first Length() positions of the column vectors. The selection
+	// vector has capacity for BatchSize positions; only the first Length()
+	// are meaningful.
+	Selection() []uint16
+	// SetSelection enables or disables the selection vector.
+	SetSelection(bool)
+	// AppendCol adds a column vector of type t to the batch.
+	AppendCol(t T)
+}
+
+// memBatch is a Batch whose column vectors are memColumns.
+type memBatch struct {
+	n      uint16
+	b      []ColVec
+	sel    []uint16
+	useSel bool
+}
+
+var _ Batch = &memBatch{}
+
+// NewMemBatch returns a batch with column vectors of the given types.
+func NewMemBatch(types []T) Batch {
+	b := &memBatch{b: make([]ColVec, len(types))}
+	for i, t := range types {
+		b.b[i] = NewMemColumn(t, BatchSize)
+	}
+	return b
+}
+
+// Length is part of the Batch interface.
+func (m *memBatch) Length() uint16 {
+	return m.n
+}
+
+// SetLength is part of the Batch interface.
+func (m *memBatch) SetLength(n uint16) {
+	m.n = n
+}
+
+// Width is part of the Batch interface.
+func (m *memBatch) Width() int {
+	return len(m.b)
+}
+
+// ColVec is part of the Batch interface.
+func (m *memBatch) ColVec(i int) ColVec {
+	return m.b[i]
+}
+
+// ColVecs is part of the Batch interface.
+func (m *memBatch) ColVecs() []ColVec {
+	return m.b
+}
+
+// Selection is part of the Batch interface.
+func (m *memBatch) Selection() []uint16 {
+	if !m.useSel {
+		return nil
+	}
+	return m.sel
+}
+
+// SetSelection is part of the Batch interface.
+func (m *memBatch) SetSelection(b bool) {
+	m.useSel = b
+	if b && m.sel == nil {
+		m.sel = make([]uint16, BatchSize)
+	}
+}
+
+// AppendCol is part of the Batch interface.
+func (m *memBatch) AppendCol(t T) {
+	m.b = append(m.b, NewMemColumn(t, BatchSize))
+}
+
+// selection returns the selection vector of the batch, enabling it (with the
+// positions of all the rows) if the batch doesn't have one.
+func selection(batch Batch) []uint16 {
+	if sel := batch.Selection(); sel != nil {
+		return sel
+	}
+	n := batch.Length()
+	batch.SetSelection(true)
+	sel := batch.Selection()
+	for i := uint16(0); i < n; i++ {
+		sel[i] = i
+	}
+	return sel
+}
+
+// rowPositions returns the positions of the rows of the batch in its column
+// vectors, reusing buf.
+func rowPositions(batch Batch, buf []int) []int {
+	buf = buf[:0]
+	n := batch.Length()
+	if sel := batch.Selection(); sel != nil {
+		for _, i := range sel[:n] {
+			buf = append(buf, int(i))
+		}
+		return buf
+	}
+	for i := 0; i < int(n); i++ {
+		buf = append(buf, i)
+	}
+	return buf
+}
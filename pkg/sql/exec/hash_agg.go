@@ -0,0 +1,452 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+)
+
+// AggFunc is an aggregate function supported by the hash aggregator.
+type AggFunc int
+
+const (
+	// AggAnyNotNull returns any non-NULL value of the group, or NULL if all the
+	// values are NULL. It is used to output grouping columns.
+	AggAnyNotNull AggFunc = iota
+	// AggCountRows counts the rows of the group.
+	AggCountRows
+	// AggCount counts the non-NULL values of the group.
+	AggCount
+	// AggSum sums the values of the group. The sum of ints is a decimal.
+	AggSum
+	// AggSumInt sums ints into an int, without checking for overflows.
+	AggSumInt
+	// AggMin returns the smallest value of the group.
+	AggMin
+	// AggMax returns the largest value of the group.
+	AggMax
+)
+
+// AggSpec describes an aggregation computed by the hash aggregator.
+type AggSpec struct {
+	Func AggFunc
+	// ColIdx is the input column aggregated (ignored by AggCountRows).
+	ColIdx int
+}
+
+// AggOutputType returns the type of the results of the aggregate function f
+// on values of type t.
+func AggOutputType(f AggFunc, t T) (T, error) {
+	switch f {
+	case AggAnyNotNull, AggMin, AggMax:
+		if t == Unhandled {
+			return Unhandled, errors.Errorf("unhandled type %s", t)
+		}
+		return t, nil
+	case AggCountRows, AggCount:
+		return Int64, nil
+	case AggSum:
+		switch t {
+		case Int64, Decimal:
+			return Decimal, nil
+		case Float64:
+			return Float64, nil
+		}
+	case AggSumInt:
+		if t == Int64 {
+			return Int64, nil
+		}
+	default:
+		return Unhandled, errors.Errorf("unknown aggregate function %d", f)
+	}
+	return Unhandled, errors.Errorf("unhandled type %s for aggregate function %d", t, f)
+}
+
+// aggState holds the state of one aggregation for all the groups.
+type aggState struct {
+	spec    AggSpec
+	inType  T
+	outType T
+
+	// seen indicates, for each group, whether any non-NULL value was
+	// aggregated.
+	seen []bool
+	// vals holds the current value of AggAnyNotNull, AggMin and AggMax for
+	// each group.
+	vals *memColumn
+	// counts holds the counts of AggCountRows and AggCount.
+	counts []int64
+	// ints holds the sums of ints. For AggSum, a sum which overflows continues
+	// in decs and its overflow flag is set.
+	ints     []int64
+	overflow []bool
+	// decs holds the sums of decimals.
+	decs []apd.Decimal
+	// floats holds the sums of floats.
+	floats []float64
+}
+
+// hashAggregator groups the rows of its input on the values of some columns,
+// and computes aggregations for each group. It consumes all of its input
+// before outputting one row per group, with one column per aggregation.
+type hashAggregator struct {
+	input      Operator
+	inputTypes []T
+	groupCols  []int
+	aggs       []aggState
+
+	// groups maps the encoded grouping columns to group IDs.
+	groups    map[string]int
+	numGroups int
+	keyBuf    []byte
+	groupIDs  []int
+	positions []int
+	idxs      []int
+	scratch   []int
+
+	built   bool
+	emitted int
+	output  Batch
+}
+
+var _ Operator = &hashAggregator{}
+
+// NewHashAggregator returns an operator computing aggregations over the
+// groups of rows with the same values in groupCols. With no grouping columns
+// (scalar aggregation), a row is output even if the input is empty.
+func NewHashAggregator(
+	input Operator, inputTypes []T, groupCols []int, aggs []AggSpec,
+) (Operator, error) {
+	a := &hashAggregator{
+		input:      input,
+		inputTypes: inputTypes,
+		groupCols:  groupCols,
+		aggs:       make([]aggState, len(aggs)),
+		groups:     make(map[string]int),
+	}
+	outTypes := make([]T, len(aggs))
+	for i, spec := range aggs {
+		inType := Int64
+		if spec.Func != AggCountRows {
+			if spec.ColIdx < 0 || spec.ColIdx >= len(inputTypes) {
+				return nil, errors.Errorf("invalid column %d", spec.ColIdx)
+			}
+			inType = inputTypes[spec.ColIdx]
+		}
+		outType, err := AggOutputType(spec.Func, inType)
+		if err != nil {
+			return nil, err
+		}
+		a.aggs[i] = aggState{spec: spec, inType: inType, outType: outType}
+		if spec.Func == AggAnyNotNull || spec.Func == AggMin || spec.Func == AggMax {
+			a.aggs[i].vals = NewMemColumn(inType, 0).(*memColumn)
+		}
+		outTypes[i] = outType
+	}
+	a.output = NewMemBatch(outTypes)
+	return a, nil
+}
+
+// Init is part of the Operator interface.
+func (a *hashAggregator) Init() {
+	a.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (a *hashAggregator) Next() Batch {
+	if !a.built {
+		a.build()
+		a.built = true
+	}
+	start := a.emitted
+	end := start + BatchSize
+	if end > a.numGroups {
+		end = a.numGroups
+	}
+	a.emitted = end
+	a.output.SetSelection(false)
+	a.output.SetLength(uint16(end - start))
+	if start == end {
+		return a.output
+	}
+	a.idxs = a.idxs[:0]
+	for g := start; g < end; g++ {
+		a.idxs = append(a.idxs, g)
+	}
+	for i := range a.aggs {
+		a.emit(&a.aggs[i], a.output.ColVec(i), start, end)
+	}
+	return a.output
+}
+
+// build consumes the input, aggregating each batch.
+func (a *hashAggregator) build() {
+	for {
+		batch := a.input.Next()
+		if batch.Length() == 0 {
+			break
+		}
+		a.positions = rowPositions(batch, a.positions)
+		a.computeGroupIDs(batch)
+		for i := range a.aggs {
+			a.aggregate(&a.aggs[i], batch)
+		}
+	}
+	if a.numGroups == 0 && len(a.groupCols) == 0 {
+		// Scalar aggregations output a row even with no input.
+		a.newGroup()
+	}
+	for i := range a.aggs {
+		if vals := a.aggs[i].vals; vals != nil {
+			vals.ensureLen(a.numGroups)
+		}
+	}
+}
+
+// computeGroupIDs sets groupIDs to the group of each row of the batch,
+// creating the groups seen for the first time.
+func (a *hashAggregator) computeGroupIDs(batch Batch) {
+	a.groupIDs = a.groupIDs[:0]
+	for _, i := range a.positions {
+		a.keyBuf = a.keyBuf[:0]
+		for _, c := range a.groupCols {
+			a.keyBuf = encodeKey(a.keyBuf, batch.ColVec(c), i)
+		}
+		g, ok := a.groups[string(a.keyBuf)]
+		if !ok {
+			g = a.newGroup()
+			a.groups[string(a.keyBuf)] = g
+		}
+		a.groupIDs = append(a.groupIDs, g)
+	}
+}
+
+// encodeKey appends an encoding of the value at position i of the vector to
+// buf, such that values are encoded identically iff they are equal.
+func encodeKey(buf []byte, vec ColVec, i int) []byte {
+	if vec.NullAt(i) {
+		return encoding.EncodeNullAscending(buf)
+	}
+	switch vec.Type() {
+	case Bool:
+		v := int64(0)
+		if vec.Bool()[i] {
+			v = 1
+		}
+		return encoding.EncodeVarintAscending(buf, v)
+	case Bytes:
+		return encoding.EncodeBytesAscending(buf, vec.Bytes()[i])
+	case Decimal:
+		return encoding.EncodeDecimalAscending(buf, &vec.Decimal()[i])
+	case Int64:
+		return encoding.EncodeVarintAscending(buf, vec.Int64()[i])
+	case Float64:
+		f := vec.Float64()[i]
+		if f == 0 {
+			// Normalize -0 to 0.
+			f = 0
+		}
+		return encoding.EncodeFloatAscending(buf, f)
+	default:
+		panic(fmt.Sprintf("unhandled type %s", vec.Type()))
+	}
+}
+
+// newGroup adds the state of a new group to all the aggregations, and returns
+// its ID.
+func (a *hashAggregator) newGroup() int {
+	g := a.numGroups
+	a.numGroups++
+	for i := range a.aggs {
+		s := &a.aggs[i]
+		s.seen = append(s.seen, false)
+		switch s.spec.Func {
+		case AggCountRows, AggCount:
+			s.counts = append(s.counts, 0)
+		case AggSum, AggSumInt:
+			switch s.inType {
+			case Int64:
+				s.ints = append(s.ints, 0)
+				if s.spec.Func == AggSum {
+					s.overflow = append(s.overflow, false)
+					s.decs = append(s.decs, apd.Decimal{})
+				}
+			case Decimal:
+				s.decs = append(s.decs, apd.Decimal{})
+			case Float64:
+				s.floats = append(s.floats, 0)
+			}
+		}
+	}
+	return g
+}
+
+// aggregate adds the rows of the batch to the state of an aggregation.
+func (a *hashAggregator) aggregate(s *aggState, batch Batch) {
+	if s.spec.Func == AggCountRows {
+		for _, g := range a.groupIDs {
+			s.counts[g]++
+		}
+		return
+	}
+	vec := batch.ColVec(s.spec.ColIdx)
+	hasNulls := vec.HasNulls()
+
+	switch s.spec.Func {
+	case AggCount:
+		for k, i := range a.positions {
+			if !(hasNulls && vec.NullAt(i)) {
+				s.counts[a.groupIDs[k]]++
+			}
+		}
+
+	case AggSum, AggSumInt:
+		switch s.inType {
+		case Int64:
+			col := vec.Int64()
+			for k, i := range a.positions {
+				if hasNulls && vec.NullAt(i) {
+					continue
+				}
+				g := a.groupIDs[k]
+				s.seen[g] = true
+				if s.spec.Func == AggSumInt {
+					s.ints[g] += col[i]
+				} else {
+					s.addInt(g, col[i])
+				}
+			}
+		case Decimal:
+			col := vec.Decimal()
+			for k, i := range a.positions {
+				if hasNulls && vec.NullAt(i) {
+					continue
+				}
+				g := a.groupIDs[k]
+				s.seen[g] = true
+				if _, err := parser.ExactCtx.Add(&s.decs[g], &s.decs[g], &col[i]); err != nil {
+					PanicError(err)
+				}
+			}
+		case Float64:
+			col := vec.Float64()
+			for k, i := range a.positions {
+				if hasNulls && vec.NullAt(i) {
+					continue
+				}
+				g := a.groupIDs[k]
+				s.seen[g] = true
+				s.floats[g] += col[i]
+			}
+		}
+
+	case AggAnyNotNull, AggMin, AggMax:
+		for k, i := range a.positions {
+			if hasNulls && vec.NullAt(i) {
+				continue
+			}
+			g := a.groupIDs[k]
+			if s.seen[g] {
+				if s.spec.Func == AggAnyNotNull {
+					continue
+				}
+				c := compareAt(vec, i, s.vals, g)
+				if (s.spec.Func == AggMin && c >= 0) || (s.spec.Func == AggMax && c <= 0) {
+					continue
+				}
+			}
+			s.seen[g] = true
+			a.scratch = append(a.scratch[:0], i)
+			s.vals.Gather(vec, a.scratch, g)
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown aggregate function %d", s.spec.Func))
+	}
+}
+
+// addInt adds an int to the decimal sum of a group, using the int sum until
+// it overflows.
+func (s *aggState) addInt(g int, v int64) {
+	if !s.overflow[g] {
+		sum := s.ints[g]
+		if (v < 0 && sum < math.MinInt64-v) || (v > 0 && sum > math.MaxInt64-v) {
+			s.overflow[g] = true
+			setDecimal(&s.decs[g], sum)
+		} else {
+			s.ints[g] = sum + v
+			return
+		}
+	}
+	var tmp apd.Decimal
+	setDecimal(&tmp, v)
+	if _, err := parser.ExactCtx.Add(&s.decs[g], &s.decs[g], &tmp); err != nil {
+		PanicError(err)
+	}
+}
+
+// setDecimal sets d to the value of an int.
+func setDecimal(d *apd.Decimal, v int64) {
+	d.SetCoefficient(v)
+	d.Exponent = 0
+}
+
+// emit writes the results of an aggregation for the groups [start, end) to
+// the output vector.
+func (a *hashAggregator) emit(s *aggState, out ColVec, start, end int) {
+	switch s.spec.Func {
+	case AggAnyNotNull, AggMin, AggMax:
+		out.Gather(s.vals, a.idxs, 0)
+		for k, g := range a.idxs {
+			if !s.seen[g] {
+				out.SetNull(k)
+			}
+		}
+		return
+	case AggCountRows, AggCount:
+		out.UnsetNulls()
+		copy(out.Int64(), s.counts[start:end])
+		return
+	}
+
+	out.UnsetNulls()
+	for k, g := range a.idxs {
+		if !s.seen[g] {
+			out.SetNull(k)
+			continue
+		}
+		switch s.inType {
+		case Int64:
+			if s.spec.Func == AggSumInt {
+				out.Int64()[k] = s.ints[g]
+			} else if s.overflow[g] {
+				out.Decimal()[k].Set(&s.decs[g])
+			} else {
+				setDecimal(&out.Decimal()[k], s.ints[g])
+			}
+		case Decimal:
+			out.Decimal()[k].Set(&s.decs[g])
+		case Float64:
+			out.Float64()[k] = s.floats[g]
+		}
+	}
+}
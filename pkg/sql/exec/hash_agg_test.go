@@ -0,0 +1,169 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestHashAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		types     []T
+		input     tuples
+		groupCols []int
+		aggs      []AggSpec
+		expected  tuples
+	}{
+		{
+			// Grouped aggregations, with NULLs in the group and aggregated
+			// columns.
+			types: []T{Int64, Int64},
+			input: tuples{
+				{1, 10}, {2, 20}, {1, nil}, {nil, 5}, {2, 1}, {nil, nil}, {3, nil},
+			},
+			groupCols: []int{0},
+			aggs: []AggSpec{
+				{Func: AggAnyNotNull, ColIdx: 0},
+				{Func: AggCountRows},
+				{Func: AggCount, ColIdx: 1},
+				{Func: AggSum, ColIdx: 1},
+				{Func: AggSumInt, ColIdx: 1},
+				{Func: AggMin, ColIdx: 1},
+				{Func: AggMax, ColIdx: 1},
+			},
+			expected: tuples{
+				{1, 2, 1, "10", 10, 10, 10},
+				{2, 2, 2, "21", 21, 1, 20},
+				{nil, 2, 1, "5", 5, 5, 5},
+				{3, 1, 0, nil, nil, nil, nil},
+			},
+		},
+		{
+			// Scalar aggregations of an empty input.
+			types:     []T{Int64},
+			input:     tuples{},
+			groupCols: nil,
+			aggs: []AggSpec{
+				{Func: AggCountRows},
+				{Func: AggSum, ColIdx: 0},
+				{Func: AggMax, ColIdx: 0},
+			},
+			expected: tuples{{0, nil, nil}},
+		},
+		{
+			// Grouped aggregations of an empty input.
+			types:     []T{Int64},
+			input:     tuples{},
+			groupCols: []int{0},
+			aggs:      []AggSpec{{Func: AggCountRows}},
+			expected:  tuples{},
+		},
+		{
+			// SUM of ints overflowing into a decimal.
+			types:     []T{Int64},
+			input:     tuples{{math.MaxInt64}, {math.MaxInt64}, {-1}},
+			groupCols: nil,
+			aggs:      []AggSpec{{Func: AggSum, ColIdx: 0}},
+			expected:  tuples{{"18446744073709551613"}},
+		},
+		{
+			// Grouping on several columns of various types.
+			types: []T{Bytes, Float64, Decimal},
+			input: tuples{
+				{"a", 1.0, "1.5"}, {"a", 2.0, "2"}, {"b", 1.0, "3.25"}, {"a", 1.0, "0.50"},
+				{"b", 1.0, nil},
+			},
+			groupCols: []int{0, 1},
+			aggs: []AggSpec{
+				{Func: AggAnyNotNull, ColIdx: 0},
+				{Func: AggAnyNotNull, ColIdx: 1},
+				{Func: AggSum, ColIdx: 2},
+				{Func: AggMin, ColIdx: 2},
+			},
+			expected: tuples{
+				{"a", 1.0, "2.00", "0.50"},
+				{"a", 2.0, "2", "2"},
+				{"b", 1.0, "3.25", "3.25"},
+			},
+		},
+		{
+			// Grouping on decimals equal in value but not in representation,
+			// and on -0 and 0.
+			types:     []T{Decimal, Float64},
+			input:     tuples{{"1.0", 0.0}, {"1.00", math.Copysign(0, -1)}, {"1", 0.0}},
+			groupCols: []int{0, 1},
+			aggs:      []AggSpec{{Func: AggCountRows}},
+			expected:  tuples{{3}},
+		},
+	}
+
+	for _, tc := range testCases {
+		runTests(t, tc.types, tc.input, func(t *testing.T, input Operator) {
+			op, err := NewHashAggregator(input, tc.types, tc.groupCols, tc.aggs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := collect(op)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTuplesEqual(t, tc.expected, res, true)
+		})
+	}
+}
+
+func TestHashAggregatorManyGroups(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// Use more groups than fit in a batch.
+	const numGroups = 3*BatchSize + 1
+	var input, expected tuples
+	for i := 0; i < numGroups; i++ {
+		input = append(input, tuple{i, i}, tuple{i, 1})
+		expected = append(expected, tuple{i, 2, i + 1})
+	}
+	op, err := NewHashAggregator(
+		newOpTestInput([]T{Int64, Int64}, input, BatchSize, false),
+		[]T{Int64, Int64},
+		[]int{0},
+		[]AggSpec{
+			{Func: AggAnyNotNull, ColIdx: 0}, {Func: AggCount, ColIdx: 1}, {Func: AggSumInt, ColIdx: 1},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := collect(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTuplesEqual(t, expected, res, true)
+}
+
+func TestAggOutputType(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if _, err := AggOutputType(AggSumInt, Float64); err == nil {
+		t.Fatal("expected an error")
+	}
+	if typ, err := AggOutputType(AggSum, Int64); err != nil || typ != Decimal {
+		t.Fatalf("expected Decimal, got %s (%v)", typ, err)
+	}
+}
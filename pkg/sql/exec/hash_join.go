@@ -0,0 +1,198 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import "github.com/pkg/errors"
+
+// hashJoiner computes the inner equality join of two inputs. It consumes all
+// of its right input to build a hash table, which is then probed with the
+// rows of the left input. The output rows are made of the columns of the left
+// input followed by the columns of the right input.
+type hashJoiner struct {
+	left, right           Operator
+	leftTypes, rightTypes []T
+	leftEq, rightEq       []int
+
+	// buildVecs holds the rows of the right input with no NULL equality
+	// column.
+	buildVecs    []*memColumn
+	numBuildRows int
+	// head maps the encoded equality columns to the last right row with
+	// these values; next links each right row to the previous one with the
+	// same values, or -1.
+	head   map[string]int
+	next   []int
+	keyBuf []byte
+	built  bool
+
+	// probeBatch is the left batch being probed; probeK is the index in
+	// probePositions of the row being probed, and chain the next right row
+	// to match with it, or -1 if it wasn't looked up yet.
+	probeBatch     Batch
+	probePositions []int
+	probeK         int
+	chain          int
+	done           bool
+
+	positions           []int
+	leftIdxs, rightIdxs []int
+	output              Batch
+}
+
+var _ Operator = &hashJoiner{}
+
+// NewHashJoiner returns an operator computing the inner join of the left and
+// right inputs on the equality of the columns leftEq and rightEq, which must
+// be of the same types. Rows with a NULL equality column never match.
+func NewHashJoiner(
+	left, right Operator, leftTypes, rightTypes []T, leftEq, rightEq []int,
+) (Operator, error) {
+	if len(leftEq) != len(rightEq) {
+		return nil, errors.Errorf("mismatched equality columns %v and %v", leftEq, rightEq)
+	}
+	for i := range leftEq {
+		if lt, rt := leftTypes[leftEq[i]], rightTypes[rightEq[i]]; lt != rt {
+			return nil, errors.Errorf("mismatched equality column types %s and %s", lt, rt)
+		}
+	}
+	h := &hashJoiner{
+		left:       left,
+		right:      right,
+		leftTypes:  leftTypes,
+		rightTypes: rightTypes,
+		leftEq:     leftEq,
+		rightEq:    rightEq,
+		buildVecs:  make([]*memColumn, len(rightTypes)),
+		head:       make(map[string]int),
+		output:     NewMemBatch(append(append([]T(nil), leftTypes...), rightTypes...)),
+	}
+	for i, t := range rightTypes {
+		h.buildVecs[i] = NewMemColumn(t, 0).(*memColumn)
+	}
+	return h, nil
+}
+
+// Init is part of the Operator interface.
+func (h *hashJoiner) Init() {
+	h.left.Init()
+	h.right.Init()
+}
+
+// Next is part of the Operator interface.
+func (h *hashJoiner) Next() Batch {
+	if !h.built {
+		h.build()
+		h.built = true
+	}
+	h.leftIdxs = h.leftIdxs[:0]
+	h.rightIdxs = h.rightIdxs[:0]
+	for !h.done && len(h.leftIdxs) < BatchSize {
+		if h.probeK == len(h.probePositions) {
+			if len(h.leftIdxs) > 0 {
+				// The output refers to the current left batch; it must be
+				// returned before getting the next one.
+				break
+			}
+			h.probeBatch = h.left.Next()
+			if h.probeBatch.Length() == 0 {
+				h.done = true
+				break
+			}
+			h.probePositions = rowPositions(h.probeBatch, h.probePositions)
+			h.probeK = 0
+			h.chain = -1
+		}
+		i := h.probePositions[h.probeK]
+		if h.chain == -1 {
+			key, ok := h.encodeKey(h.probeBatch, h.leftEq, i)
+			if !ok {
+				h.probeK++
+				continue
+			}
+			r, found := h.head[string(key)]
+			if !found {
+				h.probeK++
+				continue
+			}
+			h.chain = r
+		}
+		for h.chain != -1 && len(h.leftIdxs) < BatchSize {
+			h.leftIdxs = append(h.leftIdxs, i)
+			h.rightIdxs = append(h.rightIdxs, h.chain)
+			h.chain = h.next[h.chain]
+		}
+		if h.chain == -1 {
+			h.probeK++
+		}
+	}
+
+	h.output.SetSelection(false)
+	h.output.SetLength(uint16(len(h.leftIdxs)))
+	if len(h.leftIdxs) == 0 {
+		return h.output
+	}
+	for j := range h.leftTypes {
+		h.output.ColVec(j).Gather(h.probeBatch.ColVec(j), h.leftIdxs, 0)
+	}
+	for j := range h.rightTypes {
+		h.output.ColVec(len(h.leftTypes)+j).Gather(h.buildVecs[j], h.rightIdxs, 0)
+	}
+	return h.output
+}
+
+// build consumes the right input and builds the hash table.
+func (h *hashJoiner) build() {
+	for {
+		batch := h.right.Next()
+		if batch.Length() == 0 {
+			return
+		}
+		h.positions = rowPositions(batch, h.positions)
+		// Only keep the rows with no NULL equality column.
+		kept := h.positions[:0]
+		for _, i := range h.positions {
+			key, ok := h.encodeKey(batch, h.rightEq, i)
+			if !ok {
+				continue
+			}
+			r := h.numBuildRows + len(kept)
+			prev, found := h.head[string(key)]
+			if !found {
+				prev = -1
+			}
+			h.head[string(key)] = r
+			h.next = append(h.next, prev)
+			kept = append(kept, i)
+		}
+		for j, vec := range h.buildVecs {
+			vec.Gather(batch.ColVec(j), kept, h.numBuildRows)
+		}
+		h.numBuildRows += len(kept)
+	}
+}
+
+// encodeKey encodes the equality columns eqCols of the row at position i of
+// the batch. It returns false if any of them is NULL.
+func (h *hashJoiner) encodeKey(batch Batch, eqCols []int, i int) ([]byte, bool) {
+	h.keyBuf = h.keyBuf[:0]
+	for _, c := range eqCols {
+		vec := batch.ColVec(c)
+		if vec.NullAt(i) {
+			return nil, false
+		}
+		h.keyBuf = encodeKey(h.keyBuf, vec, i)
+	}
+	return h.keyBuf, true
+}
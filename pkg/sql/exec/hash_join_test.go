@@ -0,0 +1,133 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestHashJoiner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		leftTypes, rightTypes []T
+		left, right           tuples
+		leftEq, rightEq       []int
+		expected              tuples
+	}{
+		{
+			// Duplicate keys on both sides, and NULL keys.
+			leftTypes:  []T{Int64, Bytes},
+			rightTypes: []T{Int64, Int64},
+			left:       tuples{{1, "a"}, {2, "b"}, {1, "c"}, {nil, "d"}, {4, "e"}},
+			right:      tuples{{10, 1}, {20, 2}, {30, 1}, {40, nil}, {50, 3}},
+			leftEq:     []int{0},
+			rightEq:    []int{1},
+			expected: tuples{
+				{1, "a", 10, 1}, {1, "a", 30, 1},
+				{2, "b", 20, 2},
+				{1, "c", 10, 1}, {1, "c", 30, 1},
+			},
+		},
+		{
+			// Several equality columns.
+			leftTypes:  []T{Int64, Bytes},
+			rightTypes: []T{Bytes, Int64},
+			left:       tuples{{1, "a"}, {1, "b"}, {2, "a"}},
+			right:      tuples{{"a", 1}, {"a", 2}, {"b", 2}},
+			leftEq:     []int{0, 1},
+			rightEq:    []int{1, 0},
+			expected:   tuples{{1, "a", "a", 1}, {2, "a", "a", 2}},
+		},
+		{
+			// Empty right input.
+			leftTypes:  []T{Int64},
+			rightTypes: []T{Int64},
+			left:       tuples{{1}, {2}},
+			right:      tuples{},
+			leftEq:     []int{0},
+			rightEq:    []int{0},
+			expected:   tuples{},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, batchSize := range []int{1, 3, BatchSize} {
+			for _, useSel := range []bool{false, true} {
+				t.Run(fmt.Sprintf("batchSize=%d/sel=%t", batchSize, useSel), func(t *testing.T) {
+					op, err := NewHashJoiner(
+						newOpTestInput(tc.leftTypes, tc.left, batchSize, useSel),
+						newOpTestInput(tc.rightTypes, tc.right, batchSize, useSel),
+						tc.leftTypes, tc.rightTypes, tc.leftEq, tc.rightEq,
+					)
+					if err != nil {
+						t.Fatal(err)
+					}
+					res, err := collect(op)
+					if err != nil {
+						t.Fatal(err)
+					}
+					assertTuplesEqual(t, tc.expected, res, true)
+				})
+			}
+		}
+	}
+}
+
+func TestHashJoinerManyMatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// Each left row matches more rows than fit in a batch.
+	const numRight = BatchSize + 10
+	left := tuples{{1}, {2}, {1}}
+	var right, expected tuples
+	for i := 0; i < numRight; i++ {
+		right = append(right, tuple{1, i})
+	}
+	// Both left rows with key 1 match all the right rows.
+	for k := 0; k < 2; k++ {
+		for i := 0; i < numRight; i++ {
+			expected = append(expected, tuple{1, 1, i})
+		}
+	}
+	op, err := NewHashJoiner(
+		newOpTestInput([]T{Int64}, left, BatchSize, false),
+		newOpTestInput([]T{Int64, Int64}, right, BatchSize, false),
+		[]T{Int64}, []T{Int64, Int64}, []int{0}, []int{0},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := collect(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTuplesEqual(t, expected, res, true)
+}
+
+func TestHashJoinerMismatchedTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if _, err := NewHashJoiner(
+		newOpTestInput([]T{Int64}, nil, 1, false),
+		newOpTestInput([]T{Decimal}, nil, 1, false),
+		[]T{Int64}, []T{Decimal}, []int{0}, []int{0},
+	); err == nil {
+		t.Fatal("expected an error")
+	}
+}
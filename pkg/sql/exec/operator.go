@@ -0,0 +1,56 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+// Operator is a vectorized operator: it produces batches of rows, usually by
+// processing the batches produced by its input operators.
+//
+// Operators don't return errors; they raise them with PanicError, and whoever
+// drives the operators recovers them with CatchError.
+type Operator interface {
+	// Init initializes the operator and its inputs. It is called once, before
+	// any call to Next.
+	Init()
+	// Next returns the next batch of rows; a batch of length zero indicates
+	// that the operator is exhausted. The batch belongs to the operator and is
+	// only valid until the next call to Next.
+	Next() Batch
+}
+
+// execError is an error raised by an operator.
+type execError struct {
+	err error
+}
+
+// PanicError raises an error from within an operator.
+func PanicError(err error) {
+	panic(execError{err: err})
+}
+
+// CatchError runs f, which drives some operators, and returns the error
+// raised by any of them. Other panics are propagated.
+func CatchError(f func()) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(execError)
+			if !ok {
+				panic(r)
+			}
+			retErr = e.err
+		}
+	}()
+	f()
+	return nil
+}
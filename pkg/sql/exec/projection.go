@@ -0,0 +1,338 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+var errIntOutOfRange = errors.New("integer out of range")
+
+// BinOp is an arithmetic operator.
+type BinOp int
+
+const (
+	// Plus is +.
+	Plus BinOp = iota
+	// Minus is -.
+	Minus
+	// Mult is *.
+	Mult
+)
+
+// Operand is an operand of an arithmetic operator: either a column of the
+// input batches or a constant.
+type Operand struct {
+	// ColIdx is the index of the column, or -1 for a constant.
+	ColIdx int
+	// Const is the constant, of the Go type of the values of the operator's
+	// type (apd.Decimal, int64 or float64).
+	Const interface{}
+}
+
+// ColOperand returns an Operand for the column colIdx.
+func ColOperand(colIdx int) Operand {
+	return Operand{ColIdx: colIdx}
+}
+
+// ConstOperand returns an Operand for a constant.
+func ConstOperand(c interface{}) Operand {
+	return Operand{ColIdx: -1, Const: c}
+}
+
+// projOp computes an arithmetic operation on each row of its input batches,
+// and stores the result in the output column, which is appended to the
+// batches. The result is NULL if either operand is NULL.
+type projOp struct {
+	input       Operator
+	t           T
+	op          BinOp
+	left, right Operand
+	outIdx      int
+}
+
+var _ Operator = &projOp{}
+
+// NewProjOp returns an operator computing the operation op on operands of
+// type t (which must be Decimal, Int64 or Float64). The results are stored in
+// the column outIdx of the batches, which is appended to the batches if they
+// don't already have it.
+func NewProjOp(input Operator, t T, op BinOp, left, right Operand, outIdx int) (Operator, error) {
+	switch t {
+	case Decimal, Int64, Float64:
+	default:
+		return nil, errors.Errorf("unhandled type %s for arithmetic", t)
+	}
+	for _, o := range []Operand{left, right} {
+		if o.ColIdx >= 0 {
+			continue
+		}
+		var ok bool
+		switch t {
+		case Decimal:
+			_, ok = o.Const.(apd.Decimal)
+		case Int64:
+			_, ok = o.Const.(int64)
+		case Float64:
+			_, ok = o.Const.(float64)
+		}
+		if !ok {
+			return nil, errors.Errorf("invalid constant %v of type %T for %s", o.Const, o.Const, t)
+		}
+	}
+	return &projOp{input: input, t: t, op: op, left: left, right: right, outIdx: outIdx}, nil
+}
+
+// Init is part of the Operator interface.
+func (p *projOp) Init() {
+	p.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (p *projOp) Next() Batch {
+	batch := p.input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return batch
+	}
+	if batch.Width() == p.outIdx {
+		batch.AppendCol(p.t)
+	}
+	out := batch.ColVec(p.outIdx)
+	out.UnsetNulls()
+	var leftVec, rightVec ColVec
+	if p.left.ColIdx >= 0 {
+		leftVec = batch.ColVec(p.left.ColIdx)
+	}
+	if p.right.ColIdx >= 0 {
+		rightVec = batch.ColVec(p.right.ColIdx)
+	}
+	sel := batch.Selection()
+	nulls := (leftVec != nil && leftVec.HasNulls()) || (rightVec != nil && rightVec.HasNulls())
+
+	switch p.t {
+	case Decimal:
+		outCol := out.Decimal()
+		lConst, _ := p.left.Const.(apd.Decimal)
+		rConst, _ := p.right.Const.(apd.Decimal)
+		for k := 0; k < int(n); k++ {
+			i := k
+			if sel != nil {
+				i = int(sel[k])
+			}
+			if nulls && p.setNull(out, leftVec, rightVec, i) {
+				continue
+			}
+			a, b := &lConst, &rConst
+			if leftVec != nil {
+				a = &leftVec.Decimal()[i]
+			}
+			if rightVec != nil {
+				b = &rightVec.Decimal()[i]
+			}
+			evalDecimal(p.op, &outCol[i], a, b)
+		}
+	case Int64:
+		outCol := out.Int64()
+		var lCol, rCol []int64
+		lConst, _ := p.left.Const.(int64)
+		rConst, _ := p.right.Const.(int64)
+		if leftVec != nil {
+			lCol = leftVec.Int64()
+		}
+		if rightVec != nil {
+			rCol = rightVec.Int64()
+		}
+		for k := 0; k < int(n); k++ {
+			i := k
+			if sel != nil {
+				i = int(sel[k])
+			}
+			if nulls && p.setNull(out, leftVec, rightVec, i) {
+				continue
+			}
+			a, b := lConst, rConst
+			if lCol != nil {
+				a = lCol[i]
+			}
+			if rCol != nil {
+				b = rCol[i]
+			}
+			outCol[i] = evalInt64(p.op, a, b)
+		}
+	case Float64:
+		outCol := out.Float64()
+		var lCol, rCol []float64
+		lConst, _ := p.left.Const.(float64)
+		rConst, _ := p.right.Const.(float64)
+		if leftVec != nil {
+			lCol = leftVec.Float64()
+		}
+		if rightVec != nil {
+			rCol = rightVec.Float64()
+		}
+		for k := 0; k < int(n); k++ {
+			i := k
+			if sel != nil {
+				i = int(sel[k])
+			}
+			if nulls && p.setNull(out, leftVec, rightVec, i) {
+				continue
+			}
+			a, b := lConst, rConst
+			if lCol != nil {
+				a = lCol[i]
+			}
+			if rCol != nil {
+				b = rCol[i]
+			}
+			outCol[i] = evalFloat64(p.op, a, b)
+		}
+	}
+	return batch
+}
+
+// setNull marks the result at position i as NULL if either operand is NULL,
+// and returns whether it did so.
+func (p *projOp) setNull(out, leftVec, rightVec ColVec, i int) bool {
+	if (leftVec != nil && leftVec.NullAt(i)) || (rightVec != nil && rightVec.NullAt(i)) {
+		out.SetNull(i)
+		return true
+	}
+	return false
+}
+
+// evalInt64 computes an operation on ints, checking for overflows like the
+// corresponding parser.BinOps.
+func evalInt64(op BinOp, a, b int64) int64 {
+	switch op {
+	case Plus:
+		if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+			PanicError(errIntOutOfRange)
+		}
+		return a + b
+	case Minus:
+		if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+			PanicError(errIntOutOfRange)
+		}
+		return a - b
+	case Mult:
+		c := a * b
+		if a == 0 || b == 0 || a == 1 || b == 1 {
+			return c
+		}
+		if a == math.MinInt64 || b == math.MinInt64 || c/b != a {
+			PanicError(errIntOutOfRange)
+		}
+		return c
+	default:
+		panic(fmt.Sprintf("unknown operator %d", op))
+	}
+}
+
+func evalFloat64(op BinOp, a, b float64) float64 {
+	switch op {
+	case Plus:
+		return a + b
+	case Minus:
+		return a - b
+	case Mult:
+		return a * b
+	default:
+		panic(fmt.Sprintf("unknown operator %d", op))
+	}
+}
+
+func evalDecimal(op BinOp, res, a, b *apd.Decimal) {
+	var err error
+	switch op {
+	case Plus:
+		_, err = parser.ExactCtx.Add(res, a, b)
+	case Minus:
+		_, err = parser.ExactCtx.Sub(res, a, b)
+	case Mult:
+		_, err = parser.ExactCtx.Mul(res, a, b)
+	default:
+		panic(fmt.Sprintf("unknown operator %d", op))
+	}
+	if err != nil {
+		PanicError(err)
+	}
+}
+
+// simpleProjectOp exposes a subset of the columns of its input batches, in
+// a given order, without copying them.
+type simpleProjectOp struct {
+	input Operator
+	batch projectingBatch
+}
+
+var _ Operator = &simpleProjectOp{}
+
+// NewSimpleProjectOp returns an operator whose batches are made of the
+// columns of the input batches listed in projection.
+func NewSimpleProjectOp(input Operator, projection []int) Operator {
+	return &simpleProjectOp{input: input, batch: projectingBatch{projection: projection}}
+}
+
+// Init is part of the Operator interface.
+func (p *simpleProjectOp) Init() {
+	p.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (p *simpleProjectOp) Next() Batch {
+	p.batch.Batch = p.input.Next()
+	return &p.batch
+}
+
+// projectingBatch is a Batch made of a subset of the columns of another
+// Batch.
+type projectingBatch struct {
+	Batch
+	projection []int
+	vecs       []ColVec
+}
+
+// Width is part of the Batch interface.
+func (b *projectingBatch) Width() int {
+	return len(b.projection)
+}
+
+// ColVec is part of the Batch interface.
+func (b *projectingBatch) ColVec(i int) ColVec {
+	return b.Batch.ColVec(b.projection[i])
+}
+
+// ColVecs is part of the Batch interface.
+func (b *projectingBatch) ColVecs() []ColVec {
+	b.vecs = b.vecs[:0]
+	for _, i := range b.projection {
+		b.vecs = append(b.vecs, b.Batch.ColVec(i))
+	}
+	return b.vecs
+}
+
+// AppendCol is part of the Batch interface.
+func (b *projectingBatch) AppendCol(t T) {
+	b.Batch.AppendCol(t)
+	b.projection = append(b.projection, b.Batch.Width()-1)
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestProjOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		t           T
+		input       tuples
+		op          BinOp
+		left, right Operand
+		expected    tuples
+		expectedErr string
+	}{
+		{
+			t:        Int64,
+			input:    tuples{{1, 2}, {nil, 2}, {3, nil}, {-4, 5}},
+			op:       Plus,
+			left:     ColOperand(0),
+			right:    ColOperand(1),
+			expected: tuples{{1, 2, 3}, {nil, 2, nil}, {3, nil, nil}, {-4, 5, 1}},
+		},
+		{
+			t:        Int64,
+			input:    tuples{{1}, {nil}, {7}},
+			op:       Minus,
+			left:     ConstOperand(int64(10)),
+			right:    ColOperand(0),
+			expected: tuples{{1, 9}, {nil, nil}, {7, 3}},
+		},
+		{
+			t:           Int64,
+			input:       tuples{{1}, {math.MaxInt64 / 2}},
+			op:          Mult,
+			left:        ColOperand(0),
+			right:       ConstOperand(int64(3)),
+			expectedErr: "integer out of range",
+		},
+		{
+			t:        Float64,
+			input:    tuples{{1.5, 2.0}, {nil, 1.0}},
+			op:       Mult,
+			left:     ColOperand(0),
+			right:    ColOperand(1),
+			expected: tuples{{1.5, 2.0, 3.0}, {nil, 1.0, nil}},
+		},
+		{
+			t:        Decimal,
+			input:    tuples{{"1.5"}, {"-2.25"}, {nil}},
+			op:       Plus,
+			left:     ColOperand(0),
+			right:    ConstOperand(mustDecimal("1")),
+			expected: tuples{{"1.5", "2.5"}, {"-2.25", "-1.25"}, {nil, nil}},
+		},
+	}
+
+	for _, tc := range testCases {
+		types := make([]T, len(tc.input[0]))
+		for i := range types {
+			types[i] = tc.t
+		}
+		runTests(t, types, tc.input, func(t *testing.T, input Operator) {
+			op, err := NewProjOp(input, tc.t, tc.op, tc.left, tc.right, len(types))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := collect(op)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTuplesEqual(t, tc.expected, res, false)
+		})
+	}
+}
+
+func TestProjOpInvalidConst(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	input := newOpTestInput([]T{Int64}, nil, 1, false)
+	if _, err := NewProjOp(input, Int64, Plus, ColOperand(0), ConstOperand(1.5), 1); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestSimpleProjectOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	input := tuples{{1, 2, 3}, {4, nil, 6}}
+	runTests(t, []T{Int64, Int64, Int64}, input, func(t *testing.T, input Operator) {
+		res, err := collect(NewSimpleProjectOp(input, []int{2, 0}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertTuplesEqual(t, tuples{{3, 1}, {6, 4}}, res, false)
+	})
+}
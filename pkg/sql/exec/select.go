@@ -0,0 +1,289 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/cockroachdb/apd"
+)
+
+// CmpOp is a comparison operator.
+type CmpOp int
+
+const (
+	// EQ is =.
+	EQ CmpOp = iota
+	// NE is !=.
+	NE
+	// LT is <.
+	LT
+	// LE is <=.
+	LE
+	// GT is >.
+	GT
+	// GE is >=.
+	GE
+)
+
+// accepted returns, for each result of a three-way comparison (-1, 0 and 1,
+// at indexes 0, 1 and 2), whether it satisfies the operator.
+func (op CmpOp) accepted() [3]bool {
+	switch op {
+	case EQ:
+		return [3]bool{false, true, false}
+	case NE:
+		return [3]bool{true, false, true}
+	case LT:
+		return [3]bool{true, false, false}
+	case LE:
+		return [3]bool{true, true, false}
+	case GT:
+		return [3]bool{false, false, true}
+	case GE:
+		return [3]bool{false, true, true}
+	default:
+		panic(fmt.Sprintf("unknown comparison operator %d", op))
+	}
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareFloat64 compares floats like DFloat.Compare: NaN is equal to itself
+// and smaller than any other value.
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case a == b:
+		return 0
+	case math.IsNaN(a):
+		if math.IsNaN(b) {
+			return 0
+		}
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareDecimal(a, b *apd.Decimal) int {
+	return a.Cmp(b)
+}
+
+// compareAt compares the (non-NULL) values at positions i and j of two
+// vectors of the same type.
+func compareAt(a ColVec, i int, b ColVec, j int) int {
+	switch a.Type() {
+	case Bool:
+		return compareBool(a.Bool()[i], b.Bool()[j])
+	case Bytes:
+		return bytes.Compare(a.Bytes()[i], b.Bytes()[j])
+	case Decimal:
+		return compareDecimal(&a.Decimal()[i], &b.Decimal()[j])
+	case Int64:
+		return compareInt64(a.Int64()[i], b.Int64()[j])
+	case Float64:
+		return compareFloat64(a.Float64()[i], b.Float64()[j])
+	default:
+		panic(fmt.Sprintf("unhandled type %s", a.Type()))
+	}
+}
+
+// selConstOp filters the rows of its input on the comparison of a column
+// with a constant. Rows where the column is NULL are filtered out.
+type selConstOp struct {
+	input    Operator
+	colIdx   int
+	accepted [3]bool
+	constArg interface{}
+}
+
+var _ Operator = &selConstOp{}
+
+// NewSelConstOp returns an operator which only lets through the rows whose
+// value in column colIdx compares to constArg according to op. The type of
+// constArg is the Go type of the values of the column (bool, []byte,
+// apd.Decimal, int64 or float64).
+func NewSelConstOp(input Operator, colIdx int, op CmpOp, constArg interface{}) Operator {
+	return &selConstOp{
+		input:    input,
+		colIdx:   colIdx,
+		accepted: op.accepted(),
+		constArg: constArg,
+	}
+}
+
+// Init is part of the Operator interface.
+func (p *selConstOp) Init() {
+	p.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (p *selConstOp) Next() Batch {
+	for {
+		batch := p.input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(p.colIdx)
+		hasNulls := vec.HasNulls()
+		sel := selection(batch)
+		var idx uint16
+		switch c := p.constArg.(type) {
+		case bool:
+			col := vec.Bool()
+			for _, i := range sel[:n] {
+				if !(hasNulls && vec.NullAt(int(i))) && p.accepted[compareBool(col[i], c)+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		case []byte:
+			col := vec.Bytes()
+			for _, i := range sel[:n] {
+				if !(hasNulls && vec.NullAt(int(i))) && p.accepted[bytes.Compare(col[i], c)+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		case apd.Decimal:
+			col := vec.Decimal()
+			for _, i := range sel[:n] {
+				if !(hasNulls && vec.NullAt(int(i))) && p.accepted[compareDecimal(&col[i], &c)+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		case int64:
+			col := vec.Int64()
+			for _, i := range sel[:n] {
+				if !(hasNulls && vec.NullAt(int(i))) && p.accepted[compareInt64(col[i], c)+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		case float64:
+			col := vec.Float64()
+			for _, i := range sel[:n] {
+				if !(hasNulls && vec.NullAt(int(i))) && p.accepted[compareFloat64(col[i], c)+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		default:
+			panic(fmt.Sprintf("unhandled constant %T", p.constArg))
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+// selColOp filters the rows of its input on the comparison of two columns of
+// the same type. Rows where either column is NULL are filtered out.
+type selColOp struct {
+	input    Operator
+	leftIdx  int
+	rightIdx int
+	accepted [3]bool
+}
+
+var _ Operator = &selColOp{}
+
+// NewSelColOp returns an operator which only lets through the rows whose
+// values in columns leftIdx and rightIdx compare according to op.
+func NewSelColOp(input Operator, leftIdx int, op CmpOp, rightIdx int) Operator {
+	return &selColOp{
+		input:    input,
+		leftIdx:  leftIdx,
+		rightIdx: rightIdx,
+		accepted: op.accepted(),
+	}
+}
+
+// Init is part of the Operator interface.
+func (p *selColOp) Init() {
+	p.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (p *selColOp) Next() Batch {
+	for {
+		batch := p.input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		left, right := batch.ColVec(p.leftIdx), batch.ColVec(p.rightIdx)
+		hasNulls := left.HasNulls() || right.HasNulls()
+		sel := selection(batch)
+		var idx uint16
+		switch left.Type() {
+		case Int64:
+			// Special case the most common type.
+			l, r := left.Int64(), right.Int64()
+			for _, i := range sel[:n] {
+				if hasNulls && (left.NullAt(int(i)) || right.NullAt(int(i))) {
+					continue
+				}
+				if p.accepted[compareInt64(l[i], r[i])+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		default:
+			for _, i := range sel[:n] {
+				if hasNulls && (left.NullAt(int(i)) || right.NullAt(int(i))) {
+					continue
+				}
+				if p.accepted[compareAt(left, int(i), right, int(i))+1] {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestSelConstOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		t        T
+		input    tuples
+		op       CmpOp
+		constArg interface{}
+		expected tuples
+	}{
+		{
+			t:        Int64,
+			input:    tuples{{1}, {nil}, {3}, {2}, {5}},
+			op:       LT,
+			constArg: int64(3),
+			expected: tuples{{1}, {2}},
+		},
+		{
+			t:        Int64,
+			input:    tuples{{1}, {nil}, {3}, {2}, {3}},
+			op:       EQ,
+			constArg: int64(3),
+			expected: tuples{{3}, {3}},
+		},
+		{
+			t:        Bytes,
+			input:    tuples{{"a"}, {"b"}, {nil}, {"c"}},
+			op:       NE,
+			constArg: []byte("b"),
+			expected: tuples{{"a"}, {"c"}},
+		},
+		{
+			t:        Decimal,
+			input:    tuples{{"1.5"}, {"2.50"}, {"2.5"}, {"3"}},
+			op:       GE,
+			constArg: mustDecimal("2.5"),
+			expected: tuples{{"2.50"}, {"2.5"}, {"3"}},
+		},
+		{
+			t:        Float64,
+			input:    tuples{{math.NaN()}, {-1.0}, {nil}, {2.0}},
+			op:       LE,
+			constArg: 0.0,
+			expected: tuples{{math.NaN()}, {-1.0}},
+		},
+		{
+			t:        Bool,
+			input:    tuples{{true}, {false}, {nil}, {true}},
+			op:       GT,
+			constArg: false,
+			expected: tuples{{true}, {true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		runTests(t, []T{tc.t}, tc.input, func(t *testing.T, input Operator) {
+			res, err := collect(NewSelConstOp(input, 0, tc.op, tc.constArg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.t == Float64 {
+				// NaN isn't equal to itself; compare the strings.
+				if a, e := len(res), len(tc.expected); a != e {
+					t.Fatalf("expected %v, got %v", tc.expected, res)
+				}
+				for i := range res {
+					if a, e := res[i][0].(float64), tc.expected[i][0].(float64); !(a == e ||
+						(math.IsNaN(a) && math.IsNaN(e))) {
+						t.Fatalf("expected %v, got %v", tc.expected, res)
+					}
+				}
+				return
+			}
+			assertTuplesEqual(t, tc.expected, res, false)
+		})
+	}
+}
+
+func TestSelColOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		t        T
+		input    tuples
+		op       CmpOp
+		expected tuples
+	}{
+		{
+			t:        Int64,
+			input:    tuples{{1, 2}, {2, 2}, {nil, 1}, {3, nil}, {3, 1}},
+			op:       GE,
+			expected: tuples{{2, 2}, {3, 1}},
+		},
+		{
+			t:        Bytes,
+			input:    tuples{{"a", "b"}, {"b", "b"}, {nil, nil}, {"c", "b"}},
+			op:       EQ,
+			expected: tuples{{"b", "b"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		runTests(t, []T{tc.t, tc.t}, tc.input, func(t *testing.T, input Operator) {
+			res, err := collect(NewSelColOp(input, 0, tc.op, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTuplesEqual(t, tc.expected, res, false)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import "sort"
+
+// OrderingCol is a column of a sort ordering.
+type OrderingCol struct {
+	ColIdx int
+	Desc   bool
+}
+
+// sortOp sorts its input. It consumes all of its input before outputting the
+// sorted rows. NULLs sort before other values in ascending order, and after
+// them in descending order.
+type sortOp struct {
+	input    Operator
+	types    []T
+	ordering []OrderingCol
+
+	vecs    []*memColumn
+	numRows int
+	// order is the permutation of the buffered rows in sorted order.
+	order  []int
+	sorted bool
+
+	emitted int
+	output  Batch
+}
+
+var _ Operator = &sortOp{}
+
+// NewSorter returns an operator sorting its input, whose columns are of the
+// given types, on the ordering columns.
+func NewSorter(input Operator, types []T, ordering []OrderingCol) Operator {
+	s := &sortOp{
+		input:    input,
+		types:    types,
+		ordering: ordering,
+		vecs:     make([]*memColumn, len(types)),
+		output:   NewMemBatch(types),
+	}
+	for i, t := range types {
+		s.vecs[i] = NewMemColumn(t, 0).(*memColumn)
+	}
+	return s
+}
+
+// Init is part of the Operator interface.
+func (s *sortOp) Init() {
+	s.input.Init()
+}
+
+// Next is part of the Operator interface.
+func (s *sortOp) Next() Batch {
+	if !s.sorted {
+		s.sort()
+		s.sorted = true
+	}
+	start := s.emitted
+	end := start + BatchSize
+	if end > s.numRows {
+		end = s.numRows
+	}
+	s.emitted = end
+	s.output.SetSelection(false)
+	s.output.SetLength(uint16(end - start))
+	if start == end {
+		return s.output
+	}
+	for j, vec := range s.vecs {
+		s.output.ColVec(j).Gather(vec, s.order[start:end], 0)
+	}
+	return s.output
+}
+
+// sort buffers the input and sorts it.
+func (s *sortOp) sort() {
+	for {
+		batch := s.input.Next()
+		n := batch.Length()
+		if n == 0 {
+			break
+		}
+		sel := batch.Selection()
+		for j, vec := range s.vecs {
+			vec.Append(batch.ColVec(j), s.numRows, n, sel)
+		}
+		s.numRows += int(n)
+	}
+	s.order = make([]int, s.numRows)
+	for i := range s.order {
+		s.order[i] = i
+	}
+	sort.Slice(s.order, func(a, b int) bool {
+		return s.compare(s.order[a], s.order[b]) < 0
+	})
+}
+
+// compare compares the buffered rows i and j on the ordering columns.
+func (s *sortOp) compare(i, j int) int {
+	for _, o := range s.ordering {
+		vec := s.vecs[o.ColIdx]
+		var c int
+		switch iNull, jNull := vec.NullAt(i), vec.NullAt(j); {
+		case iNull && jNull:
+			continue
+		case iNull:
+			c = -1
+		case jNull:
+			c = 1
+		default:
+			c = compareAt(vec, i, vec, j)
+		}
+		if c != 0 {
+			if o.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestSorter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		types    []T
+		input    tuples
+		ordering []OrderingCol
+		expected tuples
+	}{
+		{
+			types:    []T{Int64, Bytes},
+			input:    tuples{{3, "a"}, {nil, "b"}, {1, "c"}, {2, "d"}},
+			ordering: []OrderingCol{{ColIdx: 0}},
+			expected: tuples{{nil, "b"}, {1, "c"}, {2, "d"}, {3, "a"}},
+		},
+		{
+			types:    []T{Int64, Bytes},
+			input:    tuples{{3, "a"}, {nil, "b"}, {1, "c"}, {2, "d"}},
+			ordering: []OrderingCol{{ColIdx: 0, Desc: true}},
+			expected: tuples{{3, "a"}, {2, "d"}, {1, "c"}, {nil, "b"}},
+		},
+		{
+			types: []T{Int64, Decimal, Float64},
+			input: tuples{
+				{1, "2.5", 1.0}, {2, "1", 2.0}, {1, nil, 3.0}, {1, "2.5", 4.0}, {2, "1.5", 5.0},
+			},
+			ordering: []OrderingCol{{ColIdx: 0}, {ColIdx: 1, Desc: true}, {ColIdx: 2, Desc: true}},
+			expected: tuples{
+				{1, "2.5", 4.0}, {1, "2.5", 1.0}, {1, nil, 3.0}, {2, "1.5", 5.0}, {2, "1", 2.0},
+			},
+		},
+		{
+			types:    []T{Bool},
+			input:    tuples{},
+			ordering: []OrderingCol{{ColIdx: 0}},
+			expected: tuples{},
+		},
+	}
+
+	for _, tc := range testCases {
+		runTests(t, tc.types, tc.input, func(t *testing.T, input Operator) {
+			res, err := collect(NewSorter(input, tc.types, tc.ordering))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTuplesEqual(t, tc.expected, res, false)
+		})
+	}
+}
+
+func TestSorterManyRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numRows = 2*BatchSize + 7
+	var input, expected tuples
+	for i := 0; i < numRows; i++ {
+		input = append(input, tuple{numRows - 1 - i})
+		expected = append(expected, tuple{i})
+	}
+	res, err := collect(NewSorter(
+		newOpTestInput([]T{Int64}, input, BatchSize/2, true),
+		[]T{Int64},
+		[]OrderingCol{{ColIdx: 0}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTuplesEqual(t, expected, res, false)
+}
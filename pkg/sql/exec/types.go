@@ -0,0 +1,92 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// T is the physical type of the values of a column vector. Several SQL
+// column types can share the same physical type (e.g. STRING and BYTES).
+type T int
+
+const (
+	// Bool is a column of bools.
+	Bool T = iota
+	// Bytes is a column of byte slices.
+	Bytes
+	// Decimal is a column of apd.Decimals.
+	Decimal
+	// Int64 is a column of int64s.
+	Int64
+	// Float64 is a column of float64s.
+	Float64
+	// Unhandled is the physical type of the column types that can't be
+	// represented in column vectors.
+	Unhandled
+)
+
+func (t T) String() string {
+	switch t {
+	case Bool:
+		return "Bool"
+	case Bytes:
+		return "Bytes"
+	case Decimal:
+		return "Decimal"
+	case Int64:
+		return "Int64"
+	case Float64:
+		return "Float64"
+	case Unhandled:
+		return "Unhandled"
+	default:
+		return fmt.Sprintf("T(%d)", int(t))
+	}
+}
+
+// FromColumnType returns the physical type of the values of a column type.
+func FromColumnType(ct sqlbase.ColumnType) T {
+	switch ct.Kind {
+	case sqlbase.ColumnType_BOOL:
+		return Bool
+	case sqlbase.ColumnType_BYTES, sqlbase.ColumnType_STRING, sqlbase.ColumnType_NAME:
+		return Bytes
+	case sqlbase.ColumnType_DECIMAL:
+		return Decimal
+	case sqlbase.ColumnType_INT:
+		return Int64
+	case sqlbase.ColumnType_FLOAT:
+		return Float64
+	}
+	return Unhandled
+}
+
+// FromColumnTypes calls FromColumnType on each column type, returning an
+// error if any of them can't be represented in column vectors.
+func FromColumnTypes(cts []sqlbase.ColumnType) ([]T, error) {
+	typs := make([]T, len(cts))
+	for i := range cts {
+		typs[i] = FromColumnType(cts[i])
+		if typs[i] == Unhandled {
+			return nil, errors.Errorf("unhandled type %s", cts[i].SQLString())
+		}
+	}
+	return typs, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/apd"
+)
+
+// tuple is a row used in tests: each value is nil for NULL, or of the Go type
+// of the column (with decimals written as strings).
+type tuple []interface{}
+
+type tuples []tuple
+
+// opTestInput is an operator producing the tuples it is given, in batches of
+// batchSize rows. If useSel is set, the batches have a selection vector
+// skipping every other position.
+type opTestInput struct {
+	types     []T
+	tuples    tuples
+	batchSize int
+	useSel    bool
+
+	batch Batch
+}
+
+var _ Operator = &opTestInput{}
+
+func newOpTestInput(types []T, tups tuples, batchSize int, useSel bool) *opTestInput {
+	return &opTestInput{types: types, tuples: tups, batchSize: batchSize, useSel: useSel}
+}
+
+func (s *opTestInput) Init() {
+	s.batch = NewMemBatch(s.types)
+}
+
+func (s *opTestInput) Next() Batch {
+	n := s.batchSize
+	if n > len(s.tuples) {
+		n = len(s.tuples)
+	}
+	tups := s.tuples[:n]
+	s.tuples = s.tuples[n:]
+
+	s.batch.SetSelection(s.useSel)
+	sel := s.batch.Selection()
+	// Only set the input columns; operators can append columns to the batch.
+	for j := range s.types {
+		vec := s.batch.ColVec(j)
+		vec.UnsetNulls()
+		for k, tup := range tups {
+			i := k
+			if sel != nil {
+				i = 2*k + 1
+				sel[k] = uint16(i)
+			}
+			setValue(vec, i, tup[j])
+		}
+	}
+	s.batch.SetLength(uint16(n))
+	return s.batch
+}
+
+// setValue sets the value at position i of the vector.
+func setValue(vec ColVec, i int, v interface{}) {
+	if v == nil {
+		vec.SetNull(i)
+		return
+	}
+	switch vec.Type() {
+	case Bool:
+		vec.Bool()[i] = v.(bool)
+	case Bytes:
+		vec.Bytes()[i] = []byte(v.(string))
+	case Decimal:
+		if _, _, err := vec.Decimal()[i].SetString(v.(string)); err != nil {
+			panic(err)
+		}
+	case Int64:
+		vec.Int64()[i] = int64(v.(int))
+	case Float64:
+		vec.Float64()[i] = v.(float64)
+	}
+}
+
+// getValue returns the value at position i of the vector, as a tuple value.
+func getValue(vec ColVec, i int) interface{} {
+	if vec.NullAt(i) {
+		return nil
+	}
+	switch vec.Type() {
+	case Bool:
+		return vec.Bool()[i]
+	case Bytes:
+		return string(vec.Bytes()[i])
+	case Decimal:
+		return vec.Decimal()[i].String()
+	case Int64:
+		return int(vec.Int64()[i])
+	case Float64:
+		return vec.Float64()[i]
+	}
+	panic(fmt.Sprintf("unhandled type %s", vec.Type()))
+}
+
+// collect runs an operator and returns all the rows it outputs.
+func collect(op Operator) (tuples, error) {
+	var res tuples
+	err := CatchError(func() {
+		op.Init()
+		for {
+			batch := op.Next()
+			n := batch.Length()
+			if n == 0 {
+				return
+			}
+			sel := batch.Selection()
+			for k := 0; k < int(n); k++ {
+				i := k
+				if sel != nil {
+					i = int(sel[k])
+				}
+				tup := make(tuple, batch.Width())
+				for j := range tup {
+					tup[j] = getValue(batch.ColVec(j), i)
+				}
+				res = append(res, tup)
+			}
+		}
+	})
+	return res, err
+}
+
+// runTests runs f with the input tuples in batches of several sizes, with and
+// without selection vectors.
+func runTests(t *testing.T, types []T, tups tuples, f func(t *testing.T, input Operator)) {
+	for _, batchSize := range []int{1, 3, BatchSize / 2} {
+		for _, useSel := range []bool{false, true} {
+			t.Run(fmt.Sprintf("batchSize=%d/sel=%t", batchSize, useSel), func(t *testing.T) {
+				f(t, newOpTestInput(types, tups, batchSize, useSel))
+			})
+		}
+	}
+}
+
+// assertTuplesEqual checks that two sets of tuples are equal, ignoring their
+// order if unordered is set.
+func assertTuplesEqual(t *testing.T, expected, actual tuples, unordered bool) {
+	if unordered {
+		sortTuples(expected)
+		sortTuples(actual)
+	}
+	if len(expected) == 0 && len(actual) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func sortTuples(tups tuples) {
+	sort.Slice(tups, func(i, j int) bool {
+		return fmt.Sprint(tups[i]) < fmt.Sprint(tups[j])
+	})
+}
+
+func mustDecimal(s string) apd.Decimal {
+	var d apd.Decimal
+	if _, _, err := d.SetString(s); err != nil {
+		panic(err)
+	}
+	return d
+}
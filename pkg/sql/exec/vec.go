@@ -0,0 +1,305 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/apd"
+)
+
+// Nulls describes which values of a column vector are NULL.
+type Nulls interface {
+	// HasNulls returns true if any value of the vector may be NULL.
+	HasNulls() bool
+	// NullAt returns true if the value at position i is NULL.
+	NullAt(i int) bool
+	// SetNull marks the value at position i as NULL.
+	SetNull(i int)
+	// UnsetNulls marks all the values as not NULL.
+	UnsetNulls()
+}
+
+// ColVec is a column vector: a slice of values of a physical type, along with
+// the positions of the NULL values. Only the accessor corresponding to the
+// type of the vector can be used.
+//
+// The byte slices of Bytes vectors are never modified in place, so they can
+// be shared between vectors; the Decimals are copied.
+type ColVec interface {
+	Nulls
+
+	// Type returns the physical type of the values.
+	Type() T
+	// Len returns the number of positions in the vector.
+	Len() int
+
+	Bool() []bool
+	Bytes() [][]byte
+	Decimal() []apd.Decimal
+	Int64() []int64
+	Float64() []float64
+
+	// Append copies n values of src at positions destIdx and after, growing
+	// the vector as needed. The values are at the positions listed in sel, or
+	// the first n positions of src if sel is nil.
+	Append(src ColVec, destIdx int, n uint16, sel []uint16)
+	// Gather copies the values of src at positions srcIdxs at positions
+	// destIdx and after, growing the vector as needed.
+	Gather(src ColVec, srcIdxs []int, destIdx int)
+}
+
+// memColumn is a ColVec stored in Go slices.
+type memColumn struct {
+	t   T
+	col interface{}
+	// nulls is only written to once a NULL is set; hasNulls indicates whether
+	// it may contain any NULL.
+	nulls    []bool
+	hasNulls bool
+}
+
+var _ ColVec = &memColumn{}
+
+// NewMemColumn returns a column vector of type t with n positions.
+func NewMemColumn(t T, n int) ColVec {
+	m := &memColumn{t: t, nulls: make([]bool, n)}
+	switch t {
+	case Bool:
+		m.col = make([]bool, n)
+	case Bytes:
+		m.col = make([][]byte, n)
+	case Decimal:
+		m.col = make([]apd.Decimal, n)
+	case Int64:
+		m.col = make([]int64, n)
+	case Float64:
+		m.col = make([]float64, n)
+	default:
+		panic(fmt.Sprintf("unhandled type %s", t))
+	}
+	return m
+}
+
+// Type is part of the ColVec interface.
+func (m *memColumn) Type() T {
+	return m.t
+}
+
+// Len is part of the ColVec interface.
+func (m *memColumn) Len() int {
+	return len(m.nulls)
+}
+
+// Bool is part of the ColVec interface.
+func (m *memColumn) Bool() []bool {
+	return m.col.([]bool)
+}
+
+// Bytes is part of the ColVec interface.
+func (m *memColumn) Bytes() [][]byte {
+	return m.col.([][]byte)
+}
+
+// Decimal is part of the ColVec interface.
+func (m *memColumn) Decimal() []apd.Decimal {
+	return m.col.([]apd.Decimal)
+}
+
+// Int64 is part of the ColVec interface.
+func (m *memColumn) Int64() []int64 {
+	return m.col.([]int64)
+}
+
+// Float64 is part of the ColVec interface.
+func (m *memColumn) Float64() []float64 {
+	return m.col.([]float64)
+}
+
+// HasNulls is part of the Nulls interface.
+func (m *memColumn) HasNulls() bool {
+	return m.hasNulls
+}
+
+// NullAt is part of the Nulls interface.
+func (m *memColumn) NullAt(i int) bool {
+	return m.hasNulls && m.nulls[i]
+}
+
+// SetNull is part of the Nulls interface.
+func (m *memColumn) SetNull(i int) {
+	m.nulls[i] = true
+	m.hasNulls = true
+}
+
+// UnsetNulls is part of the Nulls interface.
+func (m *memColumn) UnsetNulls() {
+	if !m.hasNulls {
+		return
+	}
+	for i := range m.nulls {
+		m.nulls[i] = false
+	}
+	m.hasNulls = false
+}
+
+// ensureLen grows the vector so that it has at least n positions.
+func (m *memColumn) ensureLen(n int) {
+	if n <= len(m.nulls) {
+		return
+	}
+	newLen := 2 * len(m.nulls)
+	if newLen < n {
+		newLen = n
+	}
+	grown := NewMemColumn(m.t, newLen).(*memColumn)
+	switch m.t {
+	case Bool:
+		copy(grown.col.([]bool), m.col.([]bool))
+	case Bytes:
+		copy(grown.col.([][]byte), m.col.([][]byte))
+	case Decimal:
+		dst := grown.col.([]apd.Decimal)
+		for i, d := range m.col.([]apd.Decimal) {
+			dst[i].Set(&d)
+		}
+	case Int64:
+		copy(grown.col.([]int64), m.col.([]int64))
+	case Float64:
+		copy(grown.col.([]float64), m.col.([]float64))
+	}
+	copy(grown.nulls, m.nulls)
+	m.col = grown.col
+	m.nulls = grown.nulls
+}
+
+// Append is part of the ColVec interface.
+func (m *memColumn) Append(src ColVec, destIdx int, n uint16, sel []uint16) {
+	m.ensureLen(destIdx + int(n))
+	if sel == nil {
+		switch m.t {
+		case Bool:
+			copy(m.Bool()[destIdx:], src.Bool()[:n])
+		case Bytes:
+			copy(m.Bytes()[destIdx:], src.Bytes()[:n])
+		case Decimal:
+			dst, s := m.Decimal()[destIdx:], src.Decimal()
+			for i := range s[:n] {
+				dst[i].Set(&s[i])
+			}
+		case Int64:
+			copy(m.Int64()[destIdx:], src.Int64()[:n])
+		case Float64:
+			copy(m.Float64()[destIdx:], src.Float64()[:n])
+		}
+	} else {
+		sel = sel[:n]
+		switch m.t {
+		case Bool:
+			dst, s := m.Bool()[destIdx:], src.Bool()
+			for i, j := range sel {
+				dst[i] = s[j]
+			}
+		case Bytes:
+			dst, s := m.Bytes()[destIdx:], src.Bytes()
+			for i, j := range sel {
+				dst[i] = s[j]
+			}
+		case Decimal:
+			dst, s := m.Decimal()[destIdx:], src.Decimal()
+			for i, j := range sel {
+				dst[i].Set(&s[j])
+			}
+		case Int64:
+			dst, s := m.Int64()[destIdx:], src.Int64()
+			for i, j := range sel {
+				dst[i] = s[j]
+			}
+		case Float64:
+			dst, s := m.Float64()[destIdx:], src.Float64()
+			for i, j := range sel {
+				dst[i] = s[j]
+			}
+		}
+	}
+
+	if !src.HasNulls() {
+		if m.hasNulls {
+			for i := destIdx; i < destIdx+int(n); i++ {
+				m.nulls[i] = false
+			}
+		}
+		return
+	}
+	for i := 0; i < int(n); i++ {
+		srcIdx := i
+		if sel != nil {
+			srcIdx = int(sel[i])
+		}
+		if src.NullAt(srcIdx) {
+			m.SetNull(destIdx + i)
+		} else {
+			m.nulls[destIdx+i] = false
+		}
+	}
+}
+
+// Gather is part of the ColVec interface.
+func (m *memColumn) Gather(src ColVec, srcIdxs []int, destIdx int) {
+	m.ensureLen(destIdx + len(srcIdxs))
+	switch m.t {
+	case Bool:
+		dst, s := m.Bool()[destIdx:], src.Bool()
+		for i, j := range srcIdxs {
+			dst[i] = s[j]
+		}
+	case Bytes:
+		dst, s := m.Bytes()[destIdx:], src.Bytes()
+		for i, j := range srcIdxs {
+			dst[i] = s[j]
+		}
+	case Decimal:
+		dst, s := m.Decimal()[destIdx:], src.Decimal()
+		for i, j := range srcIdxs {
+			dst[i].Set(&s[j])
+		}
+	case Int64:
+		dst, s := m.Int64()[destIdx:], src.Int64()
+		for i, j := range srcIdxs {
+			dst[i] = s[j]
+		}
+	case Float64:
+		dst, s := m.Float64()[destIdx:], src.Float64()
+		for i, j := range srcIdxs {
+			dst[i] = s[j]
+		}
+	}
+
+	if !src.HasNulls() {
+		if m.hasNulls {
+			for i := range srcIdxs {
+				m.nulls[destIdx+i] = false
+			}
+		}
+		return
+	}
+	for i, j := range srcIdxs {
+		if src.NullAt(j) {
+			m.SetNull(destIdx + i)
+		} else {
+			m.nulls[destIdx+i] = false
+		}
+	}
+}
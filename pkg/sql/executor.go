@@ -173,6 +173,11 @@ type Result struct {
 	// the result set of the result.
 	// TODO(nvanbenschoten): Can this be streamed from the planNode?
 	Rows *sqlbase.RowContainer
+	// CopyOptions will be populated if the statement type is "CopyIn" or
+	// "CopyOut", as well as Columns. For "CopyOut", Rows is populated too,
+	// unless the rows were streamed to the client through the session's
+	// CopyOutWriter, in which case RowsAffected is their number.
+	CopyOptions CopyOptions
 }
 
 // Close ensures that the resources claimed by the result are released.
@@ -227,6 +232,21 @@ type Executor struct {
 	// Application-level SQL statistics
 	sqlStats sqlStats
 
+	// Refresher of the table statistics.
+	statsRefresher statsRefresher
+
+	// Writer of the rejected connections to the event log.
+	rejectedConnLogger rejectedConnLogger
+
+	// Cache of the table statistics used by the planner.
+	tableStats tableStatsCache
+
+	// Cache of the index selection decisions of prepared statements.
+	indexSelectionCache indexSelectionCache
+
+	// Cache of the session variable defaults of the users.
+	userDefaultsCache userDefaultsCache
+
 	// Attempts to use unimplemented features.
 	unimplementedErrors struct {
 		syncutil.Mutex
@@ -305,6 +325,11 @@ type ExecutorTestingKnobs struct {
 	// If OverrideDistSQLMode is set, it is used instead of the cluster setting.
 	OverrideDistSQLMode *settings.EnumSetting
 
+	// DisableAutomaticStats disables the automatic refresh of table
+	// statistics, which makes the plans of tests depend on the timing of
+	// background jobs.
+	DisableAutomaticStats bool
+
 	// DistSQLPlannerKnobs are testing knobs for distSQLPlanner.
 	DistSQLPlannerKnobs DistSQLPlannerTestingKnobs
 }
@@ -320,7 +345,7 @@ type DistSQLPlannerTestingKnobs struct {
 // NewExecutor creates an Executor and registers a callback on the
 // system config.
 func NewExecutor(cfg ExecutorConfig, stopper *stop.Stopper) *Executor {
-	return &Executor{
+	e := &Executor{
 		cfg:     cfg,
 		stopper: stopper,
 		reCache: parser.NewRegexpCache(512),
@@ -348,6 +373,12 @@ func NewExecutor(cfg ExecutorConfig, stopper *stop.Stopper) *Executor {
 		QueryCount:  metric.NewCounter(MetaQuery),
 		sqlStats:    sqlStats{apps: make(map[string]*appStats)},
 	}
+	e.statsRefresher.init(e)
+	e.tableStats.init(e)
+	e.indexSelectionCache.init()
+	e.userDefaultsCache.init()
+	e.rejectedConnLogger.init()
+	return e
 }
 
 // Start starts workers for the executor and initializes the distSQLPlanner.
@@ -381,6 +412,7 @@ func (e *Executor) Start(
 			}
 		}
 	})
+	e.rejectedConnLogger.start(ctx, e)
 
 	ctx = log.WithLogTag(ctx, "startup", nil)
 	startupSession := NewSession(ctx, SessionArgs{}, e, nil, startupMemMetrics)
@@ -398,6 +430,25 @@ func (e *Executor) SetDistSQLSpanResolver(spanResolver distsqlplan.SpanResolver)
 	e.distSQLPlanner.setSpanResolver(spanResolver)
 }
 
+// CancelQueriesByKey cancels the queries running on the session with the
+// given pgwire cancel key. The high 32 bits of the key are the ID of the node
+// of the session; if it isn't this node, the cancellation is forwarded to it.
+// It returns whether the session was found.
+func (e *Executor) CancelQueriesByKey(ctx context.Context, cancelKey uint64) (bool, error) {
+	nodeID := roachpb.NodeID(cancelKey >> 32)
+	if nodeID == e.cfg.NodeID.Get() {
+		return e.cfg.SessionRegistry.CancelQueriesByKey(cancelKey), nil
+	}
+	response, err := e.cfg.StatusServer.CancelQuery(ctx, &serverpb.CancelQueryRequest{
+		NodeID:    strconv.Itoa(int(nodeID)),
+		CancelKey: cancelKey,
+	})
+	if err != nil {
+		return false, err
+	}
+	return response.Canceled, nil
+}
+
 // AnnotateCtx is a convenience wrapper; see AmbientContext.
 func (e *Executor) AnnotateCtx(ctx context.Context) context.Context {
 	return e.cfg.AmbientCtx.AnnotateCtx(ctx)
@@ -569,6 +620,56 @@ func (e *Executor) ExecutePreparedStatement(
 	return e.execPrepared(session, stmt, pinfo)
 }
 
+// OpenPortal starts the execution of a portal whose rows are going to be
+// fetched a few at a time with FetchPortalRows. The query of the portal
+// stays open in a cursor until the portal is released or the transaction
+// ends. It returns false if the portal can't be executed this way: only
+// SELECT statements in transaction blocks can. The error of the statement,
+// if any, is returned in the results.
+func (e *Executor) OpenPortal(
+	session *Session, portal *PreparedPortal, pinfo *parser.PlaceholderInfo,
+) (StatementResults, bool) {
+	sel, ok := portal.Stmt.Statement.(*parser.Select)
+	if !ok || session.TxnState.State != Open {
+		return StatementResults{}, false
+	}
+	defer session.maybeRecover("executing", portal.Stmt.Str)
+
+	now := timeutil.Now()
+	session.phaseTimes[sessionStartParse] = now
+	session.phaseTimes[sessionEndParse] = now
+
+	stmts := StatementList{{
+		AST:           &parser.DeclareCursor{Select: sel},
+		ExpectedTypes: portal.Stmt.Columns,
+		prepared:      portal.Stmt,
+		portal:        portal,
+	}}
+	return e.execParsed(session, stmts, pinfo, copyMsgNone), true
+}
+
+// FetchPortalRows fetches up to limit rows, or all the remaining rows if
+// limit is zero, from a portal opened by OpenPortal.
+func (e *Executor) FetchPortalRows(
+	session *Session, portal *PreparedPortal, limit int,
+) StatementResults {
+	defer session.maybeRecover("executing", portal.Stmt.Str)
+
+	now := timeutil.Now()
+	session.phaseTimes[sessionStartParse] = now
+	session.phaseTimes[sessionEndParse] = now
+
+	count := int64(limit)
+	if limit == 0 {
+		count = parser.FetchAll
+	}
+	stmts := StatementList{{
+		AST:    &parser.FetchCursor{Count: count},
+		portal: portal,
+	}}
+	return e.execParsed(session, stmts, nil, copyMsgNone)
+}
+
 // execPrepared executes a prepared statement. It returns an error if there
 // is more than 1 result or the returned types differ from the prepared
 // return types.
@@ -580,6 +681,7 @@ func (e *Executor) execPrepared(
 		stmts = StatementList{{
 			AST:           stmt.Statement,
 			ExpectedTypes: stmt.Columns,
+			prepared:      stmt,
 		}}
 	}
 	// Send the Request for SQL execution and set the application-level error
@@ -599,6 +701,14 @@ func (e *Executor) CopyData(session *Session, data string) StatementResults {
 	return e.execRequest(session, data, nil, copyMsgData)
 }
 
+// CopyRows adds rows that were decoded by the caller, as is the case for
+// the binary format, to the COPY buffer and executes if there are enough
+// rows.
+func (e *Executor) CopyRows(session *Session, rows []parser.Datums) StatementResults {
+	session.copyFrom.decoded = append(session.copyFrom.decoded, rows...)
+	return e.execRequest(session, "", nil, copyMsgData)
+}
+
 // CopyDone executes the buffered COPY data.
 func (e *Executor) CopyDone(session *Session) StatementResults {
 	return e.execRequest(session, "", nil, copyMsgDone)
@@ -679,6 +789,11 @@ func (e *Executor) execParsed(
 		res.Empty = true
 		return res
 	}
+	if _, ok := stmts[0].AST.(*parser.CopyTo); ok && session.CopyOutWriter != nil {
+		// The rows of a COPY TO statement can be streamed to the client as
+		// they are computed, unless other results need to be sent first.
+		stmts[0].streamCopyOut = true
+	}
 
 	for len(stmts) > 0 {
 		// Each iteration consumes a transaction's worth of statements.
@@ -763,6 +878,9 @@ func (e *Executor) execParsed(
 			results, remainingStmts, err = runTxnAttempt(
 				e, session, stmtsToExec, pinfo, origState, opt,
 				avoidCachedDescriptors, automaticRetryCount)
+			// The transaction can't be retried automatically anymore if
+			// results were already streamed to the client.
+			opt.AutoRetry = txnState.autoRetry
 
 			// TODO(andrei): Until #7881 fixed.
 			if err == nil && txnState.State == Aborted {
@@ -1023,9 +1141,17 @@ func (e *Executor) execStmtsInCurrentTxn(
 
 		txnState.schemaChangers.curStatementIdx = i
 
-		stmt.queryHandle = session.addActiveQuery(stmt)
+		stmt.queryHandle = session.addActiveQuery(e.generateID(), stmt)
 		defer session.removeActiveQuery(stmt.queryHandle)
 
+		// Cancel the statement if it runs for longer than the session's
+		// statement timeout.
+		var timeoutTimer *time.Timer
+		if timeout := session.StatementTimeout; timeout > 0 {
+			handle := stmt.queryHandle
+			timeoutTimer = time.AfterFunc(timeout, func() { session.timeoutQuery(handle) })
+		}
+
 		var stmtStrBefore string
 		// TODO(nvanbenschoten): Constant literals can change their representation (1.0000 -> 1) when type checking,
 		// so we need to reconsider how this works.
@@ -1046,9 +1172,13 @@ func (e *Executor) execStmtsInCurrentTxn(
 		} else {
 			switch txnState.State {
 			case Open:
-				res, err = e.execStmtInOpenTxn(
-					session, stmt, pinfo, implicitTxn, txnBeginning && (i == 0), /* firstInTxn */
-					avoidCachedDescriptors, automaticRetryCount)
+				// Hold the statement back while the node's SQL memory is nearly
+				// exhausted.
+				if err = session.admitQuery(txnState.Ctx, stmt); err == nil {
+					res, err = e.execStmtInOpenTxn(
+						session, stmt, pinfo, implicitTxn, txnBeginning && (i == 0), /* firstInTxn */
+						avoidCachedDescriptors, automaticRetryCount)
+				}
 			case Aborted, RestartWait:
 				res, err = e.execStmtInAbortedTxn(session, stmt)
 			case CommitWait:
@@ -1064,6 +1194,9 @@ func (e *Executor) execStmtsInCurrentTxn(
 				}
 			}
 		}
+		if timeoutTimer != nil {
+			timeoutTimer.Stop()
+		}
 		if filter := e.cfg.TestingKnobs.StatementFilter; filter != nil {
 			filter(session.Ctx(), stmt.String(), &res)
 		}
@@ -1153,6 +1286,8 @@ func (e *Executor) execStmtInAbortedTxn(session *Session, stmt Statement) (Resul
 		if txnState.State == RestartWait {
 			// Reset the state. Txn is Open again.
 			txnState.State = Open
+			// Cursors don't survive the restart of their transaction.
+			session.closeCursors(session.Ctx())
 			// TODO(andrei/cdo): add a counter for user-directed retries.
 			return Result{}, nil
 		}
@@ -1258,6 +1393,13 @@ func (e *Executor) execStmtInOpenTxn(
 	}
 
 	defer func() {
+		if txnState.State == Open {
+			if cancelErr := session.queryCancelError(stmt.queryHandle); cancelErr != nil {
+				// The context of the txn was canceled: whatever the outcome of the
+				// statement, the txn can't be used any more.
+				err = cancelErr
+			}
+		}
 		if err != nil {
 			if txnState.State != Open {
 				panic(fmt.Sprintf("unexpected txnState when cleaning up: %v", txnState.State))
@@ -1281,6 +1423,8 @@ func (e *Executor) execStmtInOpenTxn(
 		return Result{}, errNoTransactionInProgress
 	}
 
+	var copyTo *parser.CopyTo
+	var copyOpts CopyOptions
 	switch s := stmt.AST.(type) {
 	case *parser.BeginTransaction:
 		if !firstInTxn {
@@ -1325,6 +1469,10 @@ func (e *Executor) execStmtInOpenTxn(
 		if err == nil && txnState.mu.txn.CommandCount() > 0 {
 			txnState.mu.txn.Proto().Restart(0, 0, hlc.Timestamp{})
 		}
+		if err == nil {
+			// Cursors don't survive the restart of their transaction.
+			session.closeCursors(session.Ctx())
+		}
 		return Result{}, err
 	case *parser.Prepare:
 		name := s.Name.String()
@@ -1390,6 +1538,52 @@ func (e *Executor) execStmtInOpenTxn(
 			}
 		}
 		return Result{PGTag: s.StatementTag()}, nil
+
+	case *parser.DeclareCursor:
+		if implicitTxn {
+			return Result{}, pgerror.NewError(pgerror.CodeNoActiveSQLTransactionError,
+				"DECLARE CURSOR can only be used in transaction blocks")
+		}
+		name := string(s.Name)
+		// A cursor may already exist if the transaction is being retried
+		// automatically, in which case it is replaced.
+		if stmt.portal == nil && automaticRetryCount == 0 && session.cursors.exists(name) {
+			return Result{}, pgerror.NewErrorf(pgerror.CodeDuplicateCursorError,
+				"cursor %q already exists", name)
+		}
+		c, err := e.declareCursor(session, stmt, s.Select, pinfo, avoidCachedDescriptors)
+		if err != nil {
+			return Result{}, err
+		}
+		if stmt.portal != nil {
+			stmt.portal.ReleaseResults(session.Ctx())
+			stmt.portal.cursor = c
+		} else {
+			session.cursors.add(session.Ctx(), name, c)
+		}
+		return Result{PGTag: s.StatementTag(), Type: s.StatementType()}, nil
+
+	case *parser.FetchCursor:
+		return session.execFetch(stmt, s)
+
+	case *parser.CopyTo:
+		var err error
+		if copyOpts, err = makeCopyOptions(s.Options); err != nil {
+			return Result{}, err
+		}
+		if stmt.streamCopyOut {
+			return e.execCopyOut(session, stmt, s, copyOpts, pinfo, avoidCachedDescriptors)
+		}
+		// The query is executed below like any other statement; its result set
+		// is then sent to the client in the requested format.
+		copyTo = s
+		stmt.AST = copyToSelect(s)
+
+	case *parser.CloseCursor:
+		if err := session.cursors.close(session.Ctx(), string(s.Name)); err != nil {
+			return Result{}, err
+		}
+		return Result{PGTag: s.StatementTag()}, nil
 	}
 
 	var p *planner
@@ -1403,6 +1597,12 @@ func (e *Executor) execStmtInOpenTxn(
 		// session.
 		p = &session.planner
 		session.resetPlanner(p, e, txnState.mu.txn)
+		// The memory used by the statement is tracked by a monitor of its own,
+		// which enforces the per-query limit. Statements executed in parallel
+		// outlive this function, and draw from the transaction monitor instead.
+		stmtMon := session.startStmtMonitor()
+		defer stmtMon.Stop(session.Ctx())
+		p.evalCtx.Mon = stmtMon
 	}
 	p.evalCtx.SetTxnTimestamp(txnState.sqlTimestamp)
 	p.evalCtx.SetStmtTimestamp(e.cfg.Clock.PhysicalTime())
@@ -1444,11 +1644,16 @@ func (e *Executor) execStmtInOpenTxn(
 		return Result{}, err
 	}
 
+	if copyTo != nil {
+		result.PGTag, result.Type = copyTo.StatementTag(), copyTo.StatementType()
+		result.CopyOptions = copyOpts
+	}
+
 	tResult := &traceResult{tag: result.PGTag, count: -1}
 	switch result.Type {
 	case parser.RowsAffected:
 		tResult.count = result.RowsAffected
-	case parser.Rows:
+	case parser.Rows, parser.CopyOut:
 		tResult.count = result.Rows.Len()
 	}
 	sessionEventf(session, "%s done", tResult)
@@ -1537,7 +1742,8 @@ func (e *Executor) execDistSQL(planner *planner, tree planNode, result *Result)
 	if err != nil {
 		return err
 	}
-	err = e.distSQLPlanner.PlanAndRun(ctx, planner.txn, tree, &recv, planner.evalCtx)
+	vectorize := planner.session.VectorizeMode == VectorizeOn
+	err = e.distSQLPlanner.PlanAndRun(ctx, planner.txn, tree, &recv, planner.evalCtx, vectorize)
 	if err != nil {
 		return err
 	}
@@ -1547,6 +1753,24 @@ func (e *Executor) execDistSQL(planner *planner, tree planNode, result *Result)
 	if result.Type == parser.RowsAffected {
 		result.RowsAffected = int(recv.numRows)
 	}
+	// Mutations run by table writers don't go through the mutation planNodes,
+	// which otherwise inform the stats refresher of the modified rows.
+	var mutated *sqlbase.TableDescriptor
+	switch n := tree.(type) {
+	case *insertNode:
+		mutated = n.tableDesc
+	case *updateNode:
+		mutated = n.tableDesc
+	case *deleteNode:
+		mutated = n.tableDesc
+	}
+	if mutated != nil {
+		numRows := result.RowsAffected
+		if result.Type == parser.Rows {
+			numRows = result.Rows.Len()
+		}
+		planner.session.statsRefresher.notifyMutation(planner.txn, mutated.ID, numRows)
+	}
 	return nil
 }
 
@@ -1670,6 +1894,10 @@ func makeRes(stmt Statement, planner *planner, plan planNode) (Result, error) {
 			planner.session.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(result.Columns), 0,
 		)
 	}
+	if n, ok := plan.(*copyNode); ok {
+		result.Columns = n.resultColumns
+		result.CopyOptions = n.opts
+	}
 	return result, nil
 }
 
@@ -1682,7 +1910,12 @@ func (e *Executor) execStmt(
 	session := planner.session
 
 	planner.phaseTimes[plannerStartLogicalPlan] = timeutil.Now()
+	planner.indexSelection = session.indexSelectionCache.start(stmt, session)
 	plan, err := planner.makePlan(session.Ctx(), stmt)
+	if err == nil {
+		planner.indexSelection.finish()
+	}
+	planner.indexSelection = nil
 	planner.phaseTimes[plannerEndLogicalPlan] = timeutil.Now()
 	if err != nil {
 		return Result{}, err
@@ -1724,6 +1957,12 @@ func (e *Executor) execStmt(
 	return result, nil
 }
 
+// generateID generates a unique ID for a query or a session based on the
+// node's ID and its current HLC timestamp.
+func (e *Executor) generateID() ClusterWideID {
+	return GenerateClusterWideID(e.cfg.Clock.Now(), e.cfg.NodeID.Get())
+}
+
 // execStmtInParallel executes the statement asynchronously and returns mocked out
 // results. These mocked out results will be the "zero value" of the statement's
 // result type:
@@ -124,11 +124,23 @@ func doExpandPlan(
 		n.source.plan, err = doExpandPlan(ctx, p, params, n.source.plan)
 
 	case *joinNode:
+		var reordered planNode
+		reordered, err = p.reorderJoins(ctx, n)
+		if err != nil {
+			return plan, err
+		}
+		if reordered != plan {
+			return doExpandPlan(ctx, p, params, reordered)
+		}
 		n.left.plan, err = doExpandPlan(ctx, p, noParams, n.left.plan)
 		if err != nil {
 			return plan, err
 		}
 		n.right.plan, err = doExpandPlan(ctx, p, noParams, n.right.plan)
+		if err != nil {
+			return plan, err
+		}
+		n.mergeJoinOrdering = p.chooseMergeJoinOrdering(ctx, n)
 
 	case *ordinalityNode:
 		// There may be too many columns in the required ordering. Filter them.
@@ -224,14 +236,29 @@ func doExpandPlan(
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *alterTypeNode:
+	case *alterUserSetNode:
+	case *alterUserSetPasswordNode:
+	case *cancelQueryNode:
+	case *cancelSessionNode:
+	case *commentNode:
 	case *copyNode:
 	case *createDatabaseNode:
+	case *createFunctionNode:
 	case *createIndexNode:
+	case *createPolicyNode:
+	case *createStatsNode:
+	case *createTriggerNode:
+	case *createTypeNode:
 	case *createUserNode:
 	case *dropDatabaseNode:
+	case *dropFunctionNode:
 	case *dropIndexNode:
+	case *dropPolicyNode:
+	case *dropTriggerNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropTypeNode:
 	case *dropUserNode:
 	case *emptyNode:
 	case *hookFnNode:
@@ -416,8 +443,19 @@ func simplifyOrderings(plan planNode, usefulOrdering sqlbase.ColumnOrdering) pla
 		n.source.plan = simplifyOrderings(n.source.plan, usefulOrdering)
 
 	case *joinNode:
-		n.left.plan = simplifyOrderings(n.left.plan, nil)
-		n.right.plan = simplifyOrderings(n.right.plan, nil)
+		// A merge join needs the orderings of its inputs on the equality
+		// columns.
+		var leftOrdering, rightOrdering sqlbase.ColumnOrdering
+		for _, c := range n.mergeJoinOrdering {
+			leftOrdering = append(leftOrdering, sqlbase.ColumnOrderInfo{
+				ColIdx: n.pred.leftEqualityIndices[c.ColIdx], Direction: c.Direction,
+			})
+			rightOrdering = append(rightOrdering, sqlbase.ColumnOrderInfo{
+				ColIdx: n.pred.rightEqualityIndices[c.ColIdx], Direction: c.Direction,
+			})
+		}
+		n.left.plan = simplifyOrderings(n.left.plan, leftOrdering)
+		n.right.plan = simplifyOrderings(n.right.plan, rightOrdering)
 
 	case *ordinalityNode:
 		// The ordinality node either passes through the source ordering, or if
@@ -509,14 +547,29 @@ func simplifyOrderings(plan planNode, usefulOrdering sqlbase.ColumnOrdering) pla
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *alterTypeNode:
+	case *alterUserSetNode:
+	case *alterUserSetPasswordNode:
+	case *cancelQueryNode:
+	case *cancelSessionNode:
+	case *commentNode:
 	case *copyNode:
 	case *createDatabaseNode:
+	case *createFunctionNode:
 	case *createIndexNode:
+	case *createPolicyNode:
+	case *createStatsNode:
+	case *createTriggerNode:
+	case *createTypeNode:
 	case *createUserNode:
 	case *dropDatabaseNode:
+	case *dropFunctionNode:
 	case *dropIndexNode:
+	case *dropPolicyNode:
+	case *dropTriggerNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropTypeNode:
 	case *dropUserNode:
 	case *emptyNode:
 	case *hookFnNode:
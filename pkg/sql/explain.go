@@ -56,6 +56,7 @@ func (p *planner) Explain(ctx context.Context, n *parser.Explain) (planNode, err
 	optimized := true
 	expanded := true
 	normalizeExprs := true
+	analyze := false
 	explainer := explainer{
 		showMetadata: false,
 		showExprs:    false,
@@ -94,6 +95,12 @@ func (p *planner) Explain(ctx context.Context, n *parser.Explain) (planNode, err
 			case "qualify":
 				explainer.qualifyNames = true
 
+			case "costs":
+				explainer.showCosts = true
+
+			case "analyze":
+				analyze = true
+
 			case "verbose":
 				// VERBOSE implies EXPRS.
 				explainer.showExprs = true
@@ -128,6 +135,14 @@ func (p *planner) Explain(ctx context.Context, n *parser.Explain) (planNode, err
 	if mode == explainNone {
 		mode = explainPlan
 	}
+	if analyze {
+		if mode == explainDebug {
+			return nil, fmt.Errorf("cannot use EXPLAIN ANALYZE with the DEBUG mode")
+		}
+		if !expanded {
+			return nil, fmt.Errorf("cannot use EXPLAIN ANALYZE with NOEXPAND")
+		}
+	}
 
 	p.evalCtx.SkipNormalize = !normalizeExprs
 
@@ -141,15 +156,18 @@ func (p *planner) Explain(ctx context.Context, n *parser.Explain) (planNode, err
 
 	case explainDistSQL:
 		return &explainDistSQLNode{
+			p:              p,
 			plan:           plan,
 			distSQLPlanner: p.session.distSQLPlanner,
 			txn:            p.txn,
+			analyze:        analyze,
 		}, nil
 
 	case explainPlan:
 		// We may want to show placeholder types, so ensure no values
 		// are missing.
 		p.semaCtx.Placeholders.FillUnassigned()
+		explainer.analyze = analyze
 		return p.makeExplainPlanNode(explainer, expanded, optimized, plan), nil
 
 	default:
@@ -241,12 +259,17 @@ func (*explainDebugNode) DebugValues() debugValues { return debugValues{} }
 // explainDistSQLNode is a planNode that wraps a plan and returns
 // information related to running that plan under DistSQL.
 type explainDistSQLNode struct {
+	p              *planner
 	plan           planNode
 	distSQLPlanner *distSQLPlanner
 
 	// txn is the current transaction (used for the fake span resolver).
 	txn *client.Txn
 
+	// analyze is set for EXPLAIN (DISTSQL, ANALYZE): the plan is run, and the
+	// diagram shows the execution statistics of each processor.
+	analyze bool
+
 	// The single row returned by the node.
 	values parser.Datums
 
@@ -280,7 +303,14 @@ func (n *explainDistSQLNode) Start(ctx context.Context) error {
 	}
 	n.distSQLPlanner.FinalizePlan(&planCtx, &plan)
 	flows := plan.GenerateFlowSpecs()
-	planJSON, planURL, err := distsqlrun.GeneratePlanDiagramWithURL(flows)
+
+	var stats []distsqlrun.ProcessorStats
+	if n.analyze {
+		if stats, err = n.runAnalyze(ctx, &planCtx, &plan); err != nil {
+			return err
+		}
+	}
+	planJSON, planURL, err := distsqlrun.GenerateAnalyzedPlanDiagramWithURL(flows, stats)
 	if err != nil {
 		return err
 	}
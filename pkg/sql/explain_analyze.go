@@ -0,0 +1,258 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// planNodeStats are the execution statistics of a planNode collected by
+// EXPLAIN ANALYZE.
+type planNodeStats struct {
+	// rows is the number of rows produced by the node.
+	rows int64
+	// kvRequests and kvBytesRead are the number of KV batch requests issued by
+	// the node and the size of the keys and values they read.
+	kvRequests  int64
+	kvBytesRead int64
+	// wallTime is the time spent in the Start() and Next() methods of the
+	// node, including the time spent in its sources.
+	wallTime time.Duration
+	// peakMemory is the maximum number of bytes allocated by the node to
+	// buffer rows, as accounted by the session's memory monitors.
+	peakMemory int64
+}
+
+// memoryUsageReporter is implemented by the planNodes which buffer rows in
+// memory.
+type memoryUsageReporter interface {
+	// memoryUsage returns the number of bytes currently allocated by the node.
+	memoryUsage() int64
+}
+
+// kvStatsReporter is implemented by the planNodes which read from the KV
+// layer.
+type kvStatsReporter interface {
+	// kvStats returns the number of KV batch requests issued by the node and
+	// the number of bytes of keys and values they read.
+	kvStats() (requests int64, bytesRead int64)
+}
+
+// analyzeNode wraps a planNode to collect its execution statistics for
+// EXPLAIN ANALYZE.
+type analyzeNode struct {
+	plan  planNode
+	stats *planNodeStats
+}
+
+func (n *analyzeNode) Values() parser.Datums      { return n.plan.Values() }
+func (n *analyzeNode) DebugValues() debugValues   { return n.plan.DebugValues() }
+func (n *analyzeNode) MarkDebug(mode explainMode) { n.plan.MarkDebug(mode) }
+func (n *analyzeNode) Close(ctx context.Context)  { n.plan.Close(ctx) }
+
+func (n *analyzeNode) Start(ctx context.Context) error {
+	start := timeutil.Now()
+	err := n.plan.Start(ctx)
+	n.stats.wallTime += timeutil.Since(start)
+	n.sampleMemory()
+	return err
+}
+
+func (n *analyzeNode) Next(ctx context.Context) (bool, error) {
+	start := timeutil.Now()
+	next, err := n.plan.Next(ctx)
+	n.stats.wallTime += timeutil.Since(start)
+	if next {
+		n.stats.rows++
+	}
+	n.sampleMemory()
+	return next, err
+}
+
+func (n *analyzeNode) sampleMemory() {
+	if r, ok := n.plan.(memoryUsageReporter); ok {
+		if mem := r.memoryUsage(); mem > n.stats.peakMemory {
+			n.stats.peakMemory = mem
+		}
+	}
+}
+
+// instrumentPlan wraps the given plan, and the sources of the relational
+// operators below it, in analyzeNodes. The statistics of each wrapped node
+// are recorded in stats.
+//
+// The sources of the other nodes are left alone: some of them (e.g. the
+// table scans of an index join, or the source of a DELETE) are expected to
+// have a specific type.
+func instrumentPlan(plan planNode, stats map[planNode]*planNodeStats) planNode {
+	switch n := plan.(type) {
+	case *renderNode:
+		n.source.plan = instrumentPlan(n.source.plan, stats)
+	case *filterNode:
+		n.source.plan = instrumentPlan(n.source.plan, stats)
+	case *joinNode:
+		n.left.plan = instrumentPlan(n.left.plan, stats)
+		n.right.plan = instrumentPlan(n.right.plan, stats)
+	case *unionNode:
+		n.left = instrumentPlan(n.left, stats)
+		n.right = instrumentPlan(n.right, stats)
+	case *limitNode:
+		n.plan = instrumentPlan(n.plan, stats)
+	case *sortNode:
+		n.plan = instrumentPlan(n.plan, stats)
+	case *groupNode:
+		n.plan = instrumentPlan(n.plan, stats)
+	case *distinctNode:
+		n.plan = instrumentPlan(n.plan, stats)
+	case *windowNode:
+		n.plan = instrumentPlan(n.plan, stats)
+	case *ordinalityNode:
+		n.source = instrumentPlan(n.source, stats)
+	}
+	s := &planNodeStats{}
+	stats[plan] = s
+	return &analyzeNode{plan: plan, stats: s}
+}
+
+// runAnalyze instruments the plan being explained and runs it to
+// completion, discarding its results, to collect the execution statistics
+// shown by EXPLAIN ANALYZE.
+func (e *explainPlanNode) runAnalyze(ctx context.Context) error {
+	e.explainer.stats = make(map[planNode]*planNodeStats)
+	e.plan = instrumentPlan(e.plan, e.explainer.stats)
+	if err := e.plan.Start(ctx); err != nil {
+		return err
+	}
+	// Trigger limit propagation, like planner.startPlan() does.
+	setUnlimited(e.plan)
+	for {
+		next, err := e.plan.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !next {
+			break
+		}
+	}
+	for plan, stats := range e.explainer.stats {
+		if r, ok := plan.(kvStatsReporter); ok {
+			stats.kvRequests, stats.kvBytesRead = r.kvStats()
+		}
+	}
+	return nil
+}
+
+// runAnalyze runs the physical plan, discarding its results, and returns
+// the execution statistics collected by its processors.
+func (n *explainDistSQLNode) runAnalyze(
+	ctx context.Context, planCtx *planningCtx, plan *physicalPlan,
+) ([]distsqlrun.ProcessorStats, error) {
+	p := n.p
+	planCtx.collectStats = true
+	planCtx.vectorize = p.session.VectorizeMode == VectorizeOn
+	recv, err := makeDistSQLReceiver(
+		ctx, nil, /* sink */
+		p.ExecCfg().RangeDescriptorCache, p.ExecCfg().LeaseHolderCache,
+		p.txn,
+		func(ts hlc.Timestamp) {
+			_ = p.ExecCfg().Clock.Update(ts)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.distSQLPlanner.Run(planCtx, p.txn, plan, &recv, p.evalCtx); err != nil {
+		return nil, err
+	}
+	if recv.err != nil {
+		return nil, recv.err
+	}
+	return recv.stats, nil
+}
+
+// kvStats is part of the kvStatsReporter interface.
+func (n *scanNode) kvStats() (requests int64, bytesRead int64) {
+	return n.fetcher.KVStats()
+}
+
+// kvStats is part of the kvStatsReporter interface.
+func (n *indexJoinNode) kvStats() (requests int64, bytesRead int64) {
+	indexRequests, indexBytes := n.index.kvStats()
+	tableRequests, tableBytes := n.table.kvStats()
+	return indexRequests + tableRequests, indexBytes + tableBytes
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *valuesNode) memoryUsage() int64 {
+	if n.rows == nil {
+		return 0
+	}
+	return n.rows.MemUsage()
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *sortNode) memoryUsage() int64 {
+	switch s := n.sortStrategy.(type) {
+	case *sortAllStrategy:
+		return s.vNode.memoryUsage()
+	case *iterativeSortStrategy:
+		return s.vNode.memoryUsage()
+	case *sortTopKStrategy:
+		return s.vNode.memoryUsage()
+	}
+	return 0
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *joinNode) memoryUsage() int64 {
+	mem := n.bucketsMemAcc.acc.CurrentlyAllocated()
+	if n.buckets.rowContainer != nil {
+		mem += n.buckets.rowContainer.MemUsage()
+	}
+	if n.buffer != nil {
+		mem += n.buffer.MemUsage()
+	}
+	return mem
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *groupNode) memoryUsage() int64 {
+	var mem int64
+	for _, f := range n.funcs {
+		mem += f.bucketsMemAcc.acc.CurrentlyAllocated()
+	}
+	return mem
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *distinctNode) memoryUsage() int64 {
+	return n.prefixMemAcc.acc.CurrentlyAllocated() + n.suffixMemAcc.acc.CurrentlyAllocated()
+}
+
+// memoryUsage is part of the memoryUsageReporter interface.
+func (n *windowNode) memoryUsage() int64 {
+	mem := n.windowsAcc.acc.CurrentlyAllocated() + n.values.memoryUsage()
+	if n.wrappedRenderVals != nil {
+		mem += n.wrappedRenderVals.MemUsage()
+	}
+	return mem
+}
@@ -18,11 +18,13 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 )
 
 // explainer represents the run-time state of the EXPLAIN logic.
@@ -32,6 +34,18 @@ type explainer struct {
 	// nodes.
 	showMetadata bool
 
+	// showCosts indicates whether the output has separate columns for the
+	// number of rows and the cost estimated for each node using the table
+	// statistics.
+	showCosts bool
+
+	// analyze indicates whether the plan is run, and the output has separate
+	// columns for the statistics collected while running each node.
+	analyze bool
+
+	// stats are the statistics collected for each node when analyze is set.
+	stats map[planNode]*planNodeStats
+
 	// showExprs indicates whether the plan prints expressions
 	// embedded inside the node.
 	showExprs bool
@@ -85,6 +99,24 @@ func (p *planner) makeExplainPlanNode(
 		// Ordering indicates the known ordering of the data from this source.
 		columns = append(columns, sqlbase.ResultColumn{Name: "Ordering", Typ: parser.TypeString})
 	}
+	if explainer.showCosts {
+		// Rows is the estimated number of rows produced by the node.
+		columns = append(columns, sqlbase.ResultColumn{Name: "Rows", Typ: parser.TypeString})
+		// Cost is the estimated cost of the node and its sources.
+		columns = append(columns, sqlbase.ResultColumn{Name: "Cost", Typ: parser.TypeString})
+	}
+	if explainer.analyze {
+		// Actual Rows is the number of rows produced by the node.
+		columns = append(columns, sqlbase.ResultColumn{Name: "Actual Rows", Typ: parser.TypeString})
+		// KV Requests is the number of KV batch requests issued by the node.
+		columns = append(columns, sqlbase.ResultColumn{Name: "KV Requests", Typ: parser.TypeString})
+		// KV Bytes is the size of the keys and values read by the node.
+		columns = append(columns, sqlbase.ResultColumn{Name: "KV Bytes", Typ: parser.TypeString})
+		// Wall Time is the time spent running the node and its sources.
+		columns = append(columns, sqlbase.ResultColumn{Name: "Wall Time", Typ: parser.TypeString})
+		// Peak Memory is the maximum memory used by the node to buffer rows.
+		columns = append(columns, sqlbase.ResultColumn{Name: "Peak Memory", Typ: parser.TypeString})
+	}
 
 	explainer.fmtFlags = parser.FmtExpr(
 		parser.FmtSimple, explainer.showTypes, explainer.symbolicVars, explainer.qualifyNames,
@@ -128,6 +160,33 @@ func (p *planner) populateExplain(
 				row = append(row, emptyString, emptyString)
 			}
 		}
+		if e.showCosts {
+			// The estimate is empty for attributes, and for the nodes which
+			// read from tables without statistics.
+			rows, cost := emptyString, emptyString
+			if plan != nil {
+				if est, ok := p.estimatePlan(ctx, plan); ok {
+					rows = parser.NewDString(strconv.FormatFloat(est.rows, 'f', 0, 64))
+					cost = parser.NewDString(strconv.FormatFloat(est.cost, 'f', 2, 64))
+				}
+			}
+			row = append(row, rows, cost)
+		}
+		if e.analyze {
+			// The statistics are empty for attributes, and for the nodes which
+			// were not instrumented.
+			if stats, ok := e.stats[plan]; ok {
+				row = append(row,
+					parser.NewDString(strconv.FormatInt(stats.rows, 10)),
+					parser.NewDString(strconv.FormatInt(stats.kvRequests, 10)),
+					parser.NewDString(humanizeutil.IBytes(stats.kvBytesRead)),
+					parser.NewDString(stats.wallTime.String()),
+					parser.NewDString(humanizeutil.IBytes(stats.peakMemory)),
+				)
+			} else {
+				row = append(row, emptyString, emptyString, emptyString, emptyString, emptyString)
+			}
+		}
 		if _, err := v.rows.AddRow(ctx, row); err != nil {
 			e.err = err
 		}
@@ -280,9 +339,15 @@ func (e *explainPlanNode) DebugValues() debugValues               { return debug
 func (e *explainPlanNode) MarkDebug(mode explainMode)             {}
 
 func (e *explainPlanNode) Start(ctx context.Context) error {
-	// Note that we don't call start on e.plan. That's on purpose, Start() can
-	// have side effects. And it's supposed to not be needed for the way in which
-	// we're going to use e.plan.
+	if e.explainer.analyze {
+		// EXPLAIN ANALYZE runs the plan to collect its statistics.
+		if err := e.runAnalyze(ctx); err != nil {
+			return err
+		}
+	}
+	// Otherwise, note that we don't call start on e.plan. That's on purpose,
+	// Start() can have side effects. And it's supposed to not be needed for the
+	// way in which we're going to use e.plan.
 	return e.p.populateExplain(ctx, &e.explainer, e.results, e.plan)
 }
 
@@ -0,0 +1,294 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+var _ parser.FunctionResolver = &planner{}
+
+// getFunctionDesc returns the descriptor of the user-defined function with
+// the given name, or nil if there is no such function.
+func getFunctionDesc(
+	ctx context.Context, txn *client.Txn, vt VirtualTabler, tn *parser.TableName,
+) (*sqlbase.FunctionDescriptor, error) {
+	dbDesc, err := MustGetDatabaseDesc(ctx, txn, vt, tn.Database())
+	if err != nil {
+		return nil, err
+	}
+	return lookupFunctionDesc(ctx, txn, dbDesc.ID, tn.Table())
+}
+
+// lookupFunctionDesc returns the descriptor of the function with the given
+// name in the database with ID parentID, or nil if the name does not refer
+// to a function.
+func lookupFunctionDesc(
+	ctx context.Context, txn *client.Txn, parentID sqlbase.ID, name string,
+) (*sqlbase.FunctionDescriptor, error) {
+	gr, err := txn.Get(ctx, tableKey{parentID: parentID, name: name}.Key())
+	if err != nil {
+		return nil, err
+	}
+	if !gr.Exists() {
+		return nil, nil
+	}
+	desc := &sqlbase.Descriptor{}
+	if err := txn.GetProto(ctx, sqlbase.MakeDescMetadataKey(sqlbase.ID(gr.ValueInt())), desc); err != nil {
+		return nil, err
+	}
+	fnDesc := desc.GetFunction()
+	if fnDesc == nil {
+		return nil, nil
+	}
+	if err := fnDesc.Validate(); err != nil {
+		return nil, err
+	}
+	return fnDesc, nil
+}
+
+func newUndefinedFunctionError(name string) error {
+	return pgerror.NewErrorf(pgerror.CodeUndefinedFunctionError, "function %q does not exist", name)
+}
+
+// ResolveUserDefinedFunction implements the parser.FunctionResolver
+// interface. Unqualified names are looked up in the current database, then
+// in the databases of the search path.
+func (p *planner) ResolveUserDefinedFunction(
+	name parser.UnresolvedName,
+) (*parser.UserDefinedFunction, error) {
+	if p.txn == nil {
+		return nil, nil
+	}
+	tn, err := name.NormalizeTableName()
+	if err != nil {
+		// Not a valid function name; let the caller report the original
+		// error.
+		return nil, nil
+	}
+
+	var candidates []string
+	if tn.DatabaseName != "" {
+		candidates = []string{tn.Database()}
+	} else {
+		if p.session.Database != "" {
+			candidates = append(candidates, p.session.Database)
+		}
+		candidates = append(candidates, p.session.SearchPath...)
+	}
+
+	ctx := p.session.Ctx()
+	vt := p.getVirtualTabler()
+	for _, db := range candidates {
+		// Virtual schemas do not contain functions.
+		if _, ok := vt.getVirtualSchemaEntry(db); ok {
+			continue
+		}
+		dbDesc, err := getDatabaseDesc(ctx, p.txn, vt, db)
+		if err != nil {
+			return nil, err
+		}
+		if dbDesc == nil {
+			continue
+		}
+		desc, err := lookupFunctionDesc(ctx, p.txn, dbDesc.ID, tn.Table())
+		if err != nil {
+			return nil, err
+		}
+		if desc == nil {
+			continue
+		}
+		qualified := parser.TableName{DatabaseName: parser.Name(db), TableName: tn.TableName}
+		return p.makeUserDefinedFunction(desc, qualified.String())
+	}
+	return nil, nil
+}
+
+// makeUserDefinedFunction converts a function descriptor to the form used
+// during type checking, and records the dependency on the function if the
+// planner is tracking dependencies.
+func (p *planner) makeUserDefinedFunction(
+	desc *sqlbase.FunctionDescriptor, qualifiedName string,
+) (*parser.UserDefinedFunction, error) {
+	if desc.ReturnsTrigger {
+		return nil, pgerror.NewError(pgerror.CodeFeatureNotSupportedError,
+			"trigger functions can only be called as triggers")
+	}
+	params := make(parser.ArgTypes, len(desc.Params))
+	paramNames := make([]string, len(desc.Params))
+	for i, param := range desc.Params {
+		params[i].Name = param.Name
+		params[i].Typ = param.Type.ToDatumType()
+		paramNames[i] = param.Name
+	}
+	body, err := parser.ParseFunctionBody(desc.Body, paramNames)
+	if err != nil {
+		return nil, err
+	}
+	if p.functionDeps != nil {
+		p.functionDeps[desc.ID] = struct{}{}
+	}
+	return &parser.UserDefinedFunction{
+		ID:         uint32(desc.ID),
+		Name:       qualifiedName,
+		Params:     params,
+		ReturnType: desc.ReturnType.ToDatumType(),
+		Volatility: desc.Volatility.ToParser(),
+		Body:       body,
+	}, nil
+}
+
+// addFunctionReferences records, in the descriptors of the functions with
+// the given IDs, that the view or function with ID refID calls them.
+func (p *planner) addFunctionReferences(
+	ctx context.Context, fnIDs []sqlbase.ID, refID sqlbase.ID,
+) error {
+	for _, id := range fnIDs {
+		fnDesc, err := sqlbase.GetFunctionDescFromID(ctx, p.txn, id)
+		if err != nil {
+			return err
+		}
+		if fnDesc.HasReference(refID) {
+			continue
+		}
+		fnDesc.AddReference(refID)
+		if err := p.writeFunctionDesc(ctx, fnDesc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeFunctionReferences forgets, in the descriptors of the functions with
+// the given IDs, that the view or function with ID refID calls them.
+// Functions which no longer exist are skipped.
+func (p *planner) removeFunctionReferences(
+	ctx context.Context, fnIDs []sqlbase.ID, refID sqlbase.ID,
+) error {
+	for _, id := range fnIDs {
+		fnDesc, err := sqlbase.GetFunctionDescFromID(ctx, p.txn, id)
+		if err == sqlbase.ErrDescriptorNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fnDesc.RemoveReference(refID)
+		if err := p.writeFunctionDesc(ctx, fnDesc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFunctionDesc writes the function descriptor in the current
+// transaction.
+func (p *planner) writeFunctionDesc(ctx context.Context, fnDesc *sqlbase.FunctionDescriptor) error {
+	if err := fnDesc.Validate(); err != nil {
+		return err
+	}
+	p.session.setTestingVerifyMetadata(nil)
+	return p.txn.Put(
+		ctx, sqlbase.MakeDescMetadataKey(fnDesc.GetID()), sqlbase.WrapDescriptor(fnDesc),
+	)
+}
+
+// functionReference describes a view or function which calls a
+// user-defined function.
+type functionReference struct {
+	id       sqlbase.ID
+	kind     string
+	name     string
+	parentID sqlbase.ID
+}
+
+// liveFunctionReferences returns the views and functions which still call the
+// function. References from objects which have since been dropped are
+// ignored.
+func (p *planner) liveFunctionReferences(
+	ctx context.Context, fnDesc *sqlbase.FunctionDescriptor,
+) ([]functionReference, error) {
+	var refs []functionReference
+	for _, id := range fnDesc.ReferencingDescriptorIDs {
+		desc := &sqlbase.Descriptor{}
+		if err := p.txn.GetProto(ctx, sqlbase.MakeDescMetadataKey(id), desc); err != nil {
+			return nil, err
+		}
+		if table := desc.GetTable(); table != nil {
+			if !table.Dropped() {
+				refs = append(refs, functionReference{
+					id: id, kind: table.TypeName(), name: table.Name, parentID: table.ParentID,
+				})
+			}
+		} else if fn := desc.GetFunction(); fn != nil {
+			refs = append(refs, functionReference{
+				id: id, kind: fn.TypeName(), name: fn.Name, parentID: fn.ParentID,
+			})
+		}
+	}
+	return refs, nil
+}
+
+func newDependentFunctionError(fnName string, ref functionReference) error {
+	return pgerror.NewErrorf(pgerror.CodeDependentObjectsStillExistError,
+		"cannot drop function %q because %s %q depends on it", fnName, ref.kind, ref.name)
+}
+
+// collectFunctionDeps runs fn while recording the user-defined functions
+// which are resolved, and returns their IDs.
+func (p *planner) collectFunctionDeps(fn func() error) ([]sqlbase.ID, error) {
+	prev := p.functionDeps
+	p.functionDeps = make(map[sqlbase.ID]struct{})
+	defer func() { p.functionDeps = prev }()
+	if err := fn(); err != nil {
+		return nil, err
+	}
+	ids := make([]sqlbase.ID, 0, len(p.functionDeps))
+	for id := range p.functionDeps {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// makeFunctionColumnType converts the type of a parameter or of the result
+// of a user-defined function to the form stored in its descriptor.
+func makeFunctionColumnType(t parser.ColumnType) (sqlbase.ColumnType, error) {
+	switch ct := t.(type) {
+	case *parser.UserDefinedColType:
+		return sqlbase.ColumnType{}, pgerror.Unimplemented("udf enum",
+			fmt.Sprintf("user-defined type %s cannot be used in functions", t))
+	case *parser.ArrayColType:
+		if _, ok := ct.ParamType.(*parser.IntColType); !ok {
+			return sqlbase.ColumnType{}, pgerror.Unimplemented("udf array",
+				fmt.Sprintf("arrays of type %s are unsupported", ct.ParamType))
+		}
+	}
+	datumType := parser.CastTargetToDatumType(t)
+	if _, ok := t.(*parser.OidColType); ok && datumType != parser.TypeOid {
+		// The OID wrapper types have no column type.
+		return sqlbase.ColumnType{}, pgerror.Unimplemented("udf type",
+			fmt.Sprintf("type %s cannot be used in functions", t))
+	}
+	return sqlbase.DatumTypeToColumnType(datumType), nil
+}
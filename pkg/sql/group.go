@@ -389,7 +389,7 @@ func (n *groupNode) Next(ctx context.Context) (bool, error) {
 				value = values[f.argRenderIdx]
 			}
 
-			if err := f.add(ctx, n.planner.session, bucket, value); err != nil {
+			if err := f.add(ctx, n.planner, bucket, value); err != nil {
 				return false, err
 			}
 		}
@@ -441,7 +441,7 @@ func (n *groupNode) setupOutput() {
 func (n *groupNode) Close(ctx context.Context) {
 	n.plan.Close(ctx)
 	for _, f := range n.funcs {
-		f.close(ctx, n.planner.session)
+		f.close(ctx, n.planner)
 	}
 	n.buckets = nil
 }
@@ -537,7 +537,7 @@ func (v *extractAggregatesVisitor) addAggregation(f *aggregateFuncHolder) *parse
 	// We care about the name of the groupNode columns as an optimization: we want
 	// them to match the post-render node's columns if the post-render expressions
 	// are trivial (so the renderNode can be elided).
-	colName, err := getRenderColName(v.planner.session.SearchPath, parser.SelectExpr{Expr: f.expr})
+	colName, err := getRenderColName(&v.planner.semaCtx, parser.SelectExpr{Expr: f.expr})
 	if err != nil {
 		colName = fmt.Sprintf("agg%d", renderIdx)
 	} else if strings.ToLower(colName) == "count_rows()" {
@@ -704,7 +704,7 @@ func (n *groupNode) newAggregateFuncHolder(
 		group:          n,
 		identAggregate: identAggregate,
 		buckets:        make(map[string]parser.AggregateFunc),
-		bucketsMemAcc:  n.planner.session.TxnState.OpenAccount(),
+		bucketsMemAcc:  n.planner.openAccount(),
 	}
 	return res
 }
@@ -719,7 +719,7 @@ func (a *aggregateFuncHolder) setDistinct() {
 	a.seen = make(map[string]struct{})
 }
 
-func (a *aggregateFuncHolder) close(ctx context.Context, s *Session) {
+func (a *aggregateFuncHolder) close(ctx context.Context, p *planner) {
 	for _, aggFunc := range a.buckets {
 		aggFunc.Close(ctx)
 	}
@@ -728,13 +728,13 @@ func (a *aggregateFuncHolder) close(ctx context.Context, s *Session) {
 	a.seen = nil
 	a.group = nil
 
-	a.bucketsMemAcc.Wtxn(s).Close(ctx)
+	a.bucketsMemAcc.Wplanner(p).Close(ctx)
 }
 
 // add accumulates one more value for a particular bucket into an aggregation
 // function.
 func (a *aggregateFuncHolder) add(
-	ctx context.Context, s *Session, bucket []byte, d parser.Datum,
+	ctx context.Context, p *planner, bucket []byte, d parser.Datum,
 ) error {
 	// NB: the compiler *should* optimize `myMap[string(myBytes)]`. See:
 	// https://github.com/golang/go/commit/f5f5a8b6209f84961687d993b93ea0d397f5d5bf
@@ -748,7 +748,7 @@ func (a *aggregateFuncHolder) add(
 			// skip
 			return nil
 		}
-		if err := a.bucketsMemAcc.Wtxn(s).Grow(ctx, int64(len(encoded))); err != nil {
+		if err := a.bucketsMemAcc.Wplanner(p).Grow(ctx, int64(len(encoded))); err != nil {
 			return err
 		}
 		a.seen[string(encoded)] = struct{}{}
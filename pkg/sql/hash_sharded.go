@@ -0,0 +1,123 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// maxShardBuckets is the maximum bucket count of a hash sharded index. Each
+// bucket is pre-split into its own range.
+const maxShardBuckets = 2048
+
+// errComputedColumnWrite is returned when a statement assigns a value to a
+// computed column, such as the shard column of a hash sharded index.
+func errComputedColumnWrite(name string) error {
+	return fmt.Errorf("cannot write directly to computed column %q", name)
+}
+
+// evalShardBucketCount evaluates the BUCKET_COUNT of a USING HASH clause.
+func evalShardBucketCount(sharded *parser.ShardedIndexDef) (int32, error) {
+	typedExpr, err := parser.TypeCheckAndRequire(
+		sharded.ShardBuckets, nil, parser.TypeInt, "BUCKET_COUNT")
+	if err != nil {
+		return 0, err
+	}
+	d, err := typedExpr.Eval(nil)
+	if err != nil {
+		return 0, err
+	}
+	if d == parser.DNull {
+		return 0, errors.New("BUCKET_COUNT must not be NULL")
+	}
+	buckets := int64(parser.MustBeDInt(d))
+	if buckets < 2 || buckets > maxShardBuckets {
+		return 0, fmt.Errorf("BUCKET_COUNT must be between 2 and %d, got %d", maxShardBuckets, buckets)
+	}
+	return int32(buckets), nil
+}
+
+// setupShardedIndex shards idx, whose columns are filled in, in the bucket
+// count given by the USING HASH clause. The shard column is prepended to the
+// columns of the index; if desc does not have it yet, it is created with
+// addColumn.
+func setupShardedIndex(
+	desc *sqlbase.TableDescriptor,
+	idx *sqlbase.IndexDescriptor,
+	sharded *parser.ShardedIndexDef,
+	addColumn func(sqlbase.ColumnDescriptor),
+) error {
+	buckets, err := evalShardBucketCount(sharded)
+	if err != nil {
+		return err
+	}
+	shardCol := sqlbase.MakeShardColumn(idx.ColumnNames, buckets)
+	existing, _, err := desc.FindColumnByName(parser.Name(shardCol.Name))
+	if err == nil {
+		// Indexes on the same columns with the same bucket count share their
+		// shard column.
+		if !existing.IsComputed() || *existing.ComputeExpr != *shardCol.ComputeExpr {
+			return fmt.Errorf("column %q already exists", shardCol.Name)
+		}
+	} else {
+		addColumn(shardCol)
+	}
+	idx.ShardBuckets = buckets
+	idx.ColumnNames = append([]string{shardCol.Name}, idx.ColumnNames...)
+	idx.ColumnDirections = append(
+		[]sqlbase.IndexDescriptor_Direction{sqlbase.IndexDescriptor_ASC}, idx.ColumnDirections...)
+	return nil
+}
+
+// splitShardedIndex pre-splits a sharded index into one range per bucket, so
+// that the writes to the index are spread across the nodes right away.
+func (p *planner) splitShardedIndex(
+	ctx context.Context, desc *sqlbase.TableDescriptor, idx *sqlbase.IndexDescriptor,
+) error {
+	for i := int32(0); i < idx.ShardBuckets; i++ {
+		rowKey, err := getRowKey(desc, idx, []parser.Datum{parser.NewDInt(parser.DInt(i))})
+		if err != nil {
+			return err
+		}
+		if err := p.session.execCfg.DB.AdminSplit(ctx, rowKey, rowKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropShardColumn drops the shard column of a hash sharded index being
+// dropped, unless another index uses it.
+func dropShardColumn(tableDesc *sqlbase.TableDescriptor, idx *sqlbase.IndexDescriptor) {
+	shardColID := idx.ColumnIDs[0]
+	for _, other := range tableDesc.AllNonDropIndexes() {
+		if other.ContainsColumnID(shardColID) {
+			return
+		}
+	}
+	for i := range tableDesc.Columns {
+		if col := tableDesc.Columns[i]; col.ID == shardColID {
+			tableDesc.AddColumnMutation(col, sqlbase.DescriptorMutation_DROP)
+			tableDesc.Columns = append(tableDesc.Columns[:i], tableDesc.Columns[i+1:]...)
+			return
+		}
+	}
+}
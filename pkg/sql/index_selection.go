@@ -19,6 +19,7 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
 
 	"golang.org/x/net/context"
@@ -169,6 +170,15 @@ func (p *planner) selectIndex(
 		}
 	}
 
+	// If the table has statistics, the cost of each index is derived from the
+	// estimated number of rows it reads instead.
+	if ts := p.session.tableStats.get(ctx, &s.desc); ts != nil {
+		e := scanEstimator{evalCtx: &p.evalCtx, scan: s, stats: ts}
+		for _, c := range candidates {
+			c.estimateCost(&e)
+		}
+	}
+
 	if s.noIndexJoin {
 		// Eliminate non-covering indexes. We do this after the check above for
 		// constant false filter.
@@ -201,8 +211,8 @@ func (p *planner) selectIndex(
 
 	if log.V(2) {
 		for i, c := range candidates {
-			log.Infof(ctx, "%d: selectIndex(%s): cost=%v constraints=%s reverse=%t",
-				i, c.index.Name, c.cost, c.constraints, c.reverse)
+			log.Infof(ctx, "%d: selectIndex(%s): cost=%v rows=%v constraints=%s reverse=%t",
+				i, c.index.Name, c.cost, c.estimatedRows, c.constraints, c.reverse)
 		}
 	}
 
@@ -211,6 +221,10 @@ func (p *planner) selectIndex(
 	c := candidates[0]
 	s.index = c.index
 	s.specifiedIndex = nil
+	if s.cachedScan != nil {
+		s.cachedScan.indexID = c.index.ID
+		s.cachedScan.indexName = c.index.Name
+	}
 	s.isSecondaryIndex = (c.index != &s.desc.PrimaryIndex)
 	var err error
 	s.spans, err = makeSpans(c.constraints, c.desc, c.index)
@@ -252,6 +266,7 @@ func (p *planner) selectIndex(
 		// original table filter.
 		plan, s = s.p.makeIndexJoin(s, c.exactPrefix)
 	}
+	s.estimatedScanRows, s.scanRowsEstimated = c.estimatedRows, c.rowsEstimated
 
 	if log.V(3) {
 		log.Infof(ctx, "%s: filter=%v", c.index.Name, s.filter)
@@ -342,11 +357,26 @@ type indexInfo struct {
 	covering    bool // Does the index cover the required IndexedVars?
 	reverse     bool
 	exactPrefix int
+
+	// estimatedRows is the number of rows of the index estimated to be read,
+	// if rowsEstimated is set.
+	estimatedRows float64
+	rowsEstimated bool
+
+	// shardVar refers to the shard column of a hash sharded index, if the
+	// scan has a filter.
+	shardVar *parser.IndexedVar
 }
 
 func (v *indexInfo) init(s *scanNode) {
 	v.covering = v.isCoveringIndex(s)
 
+	if v.index.IsSharded() && s.filter != nil {
+		if idx, ok := s.colIdxMap[v.index.ColumnIDs[0]]; ok {
+			v.shardVar = s.filterVars.IndexedVar(idx)
+		}
+	}
+
 	// The base cost is the number of keys per row.
 	if v.index == &v.desc.PrimaryIndex {
 		// The primary index contains 1 key per column plus the sentinel key per
@@ -366,9 +396,16 @@ func (v *indexInfo) init(s *scanNode) {
 // analyzeExprs examines the range map to determine the cost of using the
 // index.
 func (v *indexInfo) analyzeExprs(exprs []parser.TypedExprs) {
+	if v.shardVar != nil {
+		exprs = v.shardExprs(exprs)
+	}
 	if err := v.makeOrConstraints(exprs); err != nil {
 		panic(err)
 	}
+	if v.shardVar != nil && !v.constrainsBeyondShard() {
+		// The constraints on the buckets alone do not restrict the scan.
+		v.constraints = nil
+	}
 
 	// Count the number of elements used to limit the start and end keys. We then
 	// boost the cost by what fraction of the index keys are being used. The
@@ -400,6 +437,61 @@ func (v *indexInfo) analyzeExprs(exprs []parser.TypedExprs) {
 	}
 }
 
+// shardExprs constrains the shard column of a hash sharded index to all the
+// buckets in each disjunction. The rows matching the constraints on the
+// columns which follow the shard column can be in any bucket: the spans
+// derived from these constraints are thus fanned out across the buckets.
+func (v *indexInfo) shardExprs(exprs []parser.TypedExprs) []parser.TypedExprs {
+	buckets := parser.NewDTupleWithLen(int(v.index.ShardBuckets))
+	for i := range buckets.D {
+		buckets.D[i] = parser.NewDInt(parser.DInt(i))
+	}
+	inBuckets := parser.NewTypedComparisonExpr(parser.In, v.shardVar, buckets.SetSorted())
+	res := make([]parser.TypedExprs, len(exprs))
+	for i, e := range exprs {
+		res[i] = append(parser.TypedExprs{inBuckets}, e...)
+	}
+	return res
+}
+
+// constrainsBeyondShard returns whether each disjunction of the constraints
+// of a hash sharded index constrains other columns than the shard column.
+func (v *indexInfo) constrainsBeyondShard() bool {
+	if len(v.constraints) == 0 {
+		return false
+	}
+	for _, cset := range v.constraints {
+		numCols := 0
+		for _, c := range cset {
+			numCols += c.numColumns()
+		}
+		if numCols <= 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// estimateCost replaces the heuristic cost computed by init and
+// analyzeExprs by the estimated cost of reading the rows selected by the
+// constraints: the number of keys read, plus the lookups in the primary index
+// for a non-covering index.
+func (v *indexInfo) estimateCost(e *scanEstimator) {
+	v.estimatedRows = float64(e.stats.rowCount) * e.constraintsSelectivity(v.constraints)
+	v.rowsEstimated = true
+
+	rows := math.Max(v.estimatedRows, 1)
+	primaryKeysPerRow := float64(1 + len(v.desc.Columns) - len(v.desc.PrimaryIndex.ColumnIDs))
+	if v.index == &v.desc.PrimaryIndex {
+		v.cost = rows * primaryKeysPerRow
+	} else {
+		v.cost = rows
+		if !v.covering {
+			v.cost += rows * indexJoinCostFactor * primaryKeysPerRow
+		}
+	}
+}
+
 // analyzeOrdering analyzes the ordering provided by the index and determines
 // if it matches the ordering requested by the query. Non-matching orderings
 // increase the cost of using the index.
@@ -0,0 +1,319 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/biogo/store/llrb"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/cache"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+var indexSelectionCacheEnabled = settings.RegisterBoolSetting(
+	"sql.index_selection_cache.enabled",
+	"cache the index selection decisions of the prepared statements", true)
+
+var indexSelectionCacheSize = settings.RegisterIntSetting(
+	"sql.index_selection_cache.size",
+	"maximum number of prepared statements whose index selection decisions are cached on each node",
+	1000)
+
+// indexSelectionKey identifies the decisions cached for a prepared
+// statement. Besides the statement, they depend on how its names are
+// resolved, and on the user: row-level security policies add filters to the
+// scans of some users.
+type indexSelectionKey struct {
+	sql        string
+	database   string
+	searchPath string
+	user       string
+}
+
+// Compare implements the llrb.Comparable interface for indexSelectionKey, so
+// that it can be used as a key for util.OrderedCache.
+func (k indexSelectionKey) Compare(b llrb.Comparable) int {
+	o := b.(indexSelectionKey)
+	if c := strings.Compare(k.sql, o.sql); c != 0 {
+		return c
+	}
+	if c := strings.Compare(k.database, o.database); c != 0 {
+		return c
+	}
+	if c := strings.Compare(k.searchPath, o.searchPath); c != 0 {
+		return c
+	}
+	return strings.Compare(k.user, o.user)
+}
+
+// cachedScan records the index chosen for a scan of a prepared statement,
+// and the version of the table descriptor it was chosen for.
+type cachedScan struct {
+	tableID sqlbase.ID
+	version sqlbase.DescriptorVersion
+	// indexID is 0 if index selection did not choose an index, e.g. because
+	// the scan has no filter or was found to be empty.
+	indexID sqlbase.IndexID
+
+	// tableName and indexName are only used by
+	// crdb_internal.node_index_selection_cache.
+	tableName string
+	indexName string
+}
+
+// cachedIndexSelection holds the index selection decisions made while
+// planning a prepared statement. Unlike the planNodes, which embed the
+// values of the placeholders and can only run once, these decisions only
+// depend on the statement and on the descriptors of the tables it accesses:
+// they can be reused by every execution of the statement until the
+// descriptors change.
+//
+// A cachedIndexSelection is immutable once it is added to the
+// indexSelectionCache, except for hits.
+type cachedIndexSelection struct {
+	// scans lists the scanNodes of the plan in the order in which they were
+	// initialized, which only depends on the statement.
+	scans []*cachedScan
+	// hits is the number of executions which reused the decisions for all
+	// their scans. It is accessed atomically.
+	hits int64
+}
+
+// indexSelectionCache caches the index selection decisions of the prepared
+// statements executed on this node. For the scans of a statement found in
+// the cache, the candidate indexes are reduced to the one recorded in the
+// cache, which saves the analysis and costing of the other indexes. The rest
+// of the planning of the statement, including the computation of the spans
+// of the chosen index, still runs on every execution: the plans themselves
+// embed the values of the placeholders and are not cached.
+//
+// Cached decisions are invalidated as soon as a table they access is leased
+// at a different descriptor version than the one they were made for, and
+// when this node changes the schema or the statistics of one of its tables.
+type indexSelectionCache struct {
+	mu struct {
+		syncutil.Mutex
+		cache *cache.OrderedCache
+
+		hits          int64
+		misses        int64
+		invalidations int64
+	}
+}
+
+func (c *indexSelectionCache) init() {
+	c.mu.cache = cache.NewOrderedCache(cache.Config{
+		Policy: cache.CacheLRU,
+		ShouldEvict: func(n int, _, _ interface{}) bool {
+			return int64(n) > indexSelectionCacheSize.Get()
+		},
+	})
+}
+
+// start looks up the decisions cached for the given prepared statement. The
+// returned indexSelectionState either reuses the cached decisions or records
+// new ones; it is nil if the statement's decisions cannot be cached. The
+// lookup is only counted as a hit or a miss by finish, once it is known
+// whether the cached decisions were still valid.
+func (c *indexSelectionCache) start(stmt Statement, session *Session) *indexSelectionState {
+	if c == nil || stmt.prepared == nil || !indexSelectionCacheEnabled.Get() {
+		return nil
+	}
+	s := &indexSelectionState{
+		c: c,
+		key: indexSelectionKey{
+			sql:        stmt.prepared.Str,
+			database:   session.Database,
+			searchPath: strings.Join(session.SearchPath, ","),
+			user:       session.User,
+		},
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.mu.cache.Get(s.key); ok {
+		s.cached = v.(*cachedIndexSelection)
+	} else {
+		s.recorded = &cachedIndexSelection{}
+	}
+	return s
+}
+
+// invalidate removes the decisions which involve the given table once txn
+// commits.
+func (c *indexSelectionCache) invalidate(txn *client.Txn, tableID sqlbase.ID) {
+	if c == nil {
+		return
+	}
+	txn.AddCommitTrigger(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		var keys []indexSelectionKey
+		c.mu.cache.Do(func(k, v interface{}) {
+			for _, s := range v.(*cachedIndexSelection).scans {
+				if s.tableID == tableID {
+					keys = append(keys, k.(indexSelectionKey))
+					return
+				}
+			}
+		})
+		for _, k := range keys {
+			c.mu.cache.Del(k)
+		}
+		c.mu.invalidations += int64(len(keys))
+	})
+}
+
+// indexSelectionState is the state of the index selection cache for the
+// statement being planned.
+type indexSelectionState struct {
+	c   *indexSelectionCache
+	key indexSelectionKey
+
+	// cached are the decisions which are reused, if any. It is reset if they
+	// are found to be stale.
+	cached *cachedIndexSelection
+	// recorded are the decisions being recorded, if none were cached.
+	recorded *cachedIndexSelection
+	// numScans is the number of scanNodes initialized so far.
+	numScans int
+}
+
+// initScan is called once the table descriptor of a scanNode is known. If
+// the decisions are cached, the index recorded for the scan is specified as
+// the only candidate for index selection. Otherwise, the scan is recorded, and
+// the index chosen for it is recorded by selectIndex.
+func (s *indexSelectionState) initScan(n *scanNode) {
+	if s == nil {
+		return
+	}
+	i := s.numScans
+	s.numScans++
+	if s.cached != nil {
+		if i >= len(s.cached.scans) {
+			s.evict()
+			return
+		}
+		cs := s.cached.scans[i]
+		if cs.tableID != n.desc.ID || cs.version != n.desc.Version {
+			s.evict()
+			return
+		}
+		if cs.indexID == 0 || n.specifiedIndex != nil {
+			return
+		}
+		index, err := n.desc.FindIndexByID(cs.indexID)
+		if err != nil {
+			s.evict()
+			return
+		}
+		n.specifiedIndex = index
+		return
+	}
+	if s.recorded != nil {
+		n.cachedScan = &cachedScan{
+			tableID:   n.desc.ID,
+			version:   n.desc.Version,
+			tableName: n.desc.Name,
+		}
+		s.recorded.scans = append(s.recorded.scans, n.cachedScan)
+	}
+}
+
+// evict removes the stale cached decisions from the cache. Index selection
+// considers all the candidate indexes of the statement's scans.
+func (s *indexSelectionState) evict() {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	if v, ok := s.c.mu.cache.Get(s.key); ok && v.(*cachedIndexSelection) == s.cached {
+		s.c.mu.cache.Del(s.key)
+		s.c.mu.invalidations++
+	}
+	s.cached = nil
+}
+
+// finish is called once the statement was planned successfully. If the
+// cached decisions were reused for all the scans of the statement, the lookup
+// is counted as a hit. Otherwise it is counted as a miss, and the recorded
+// decisions, if any, are added to the cache.
+func (s *indexSelectionState) finish() {
+	if s == nil {
+		return
+	}
+	if s.cached != nil && s.numScans != len(s.cached.scans) {
+		s.evict()
+	}
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	if s.cached != nil {
+		s.c.mu.hits++
+		atomic.AddInt64(&s.cached.hits, 1)
+		return
+	}
+	s.c.mu.misses++
+	if s.recorded != nil {
+		s.c.mu.cache.Add(s.key, s.recorded)
+	}
+}
+
+// indexSelectionCacheStats are the statistics of the index selection cache
+// reported by crdb_internal.node_index_selection_cache_statistics.
+type indexSelectionCacheStats struct {
+	entries       int
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+func (c *indexSelectionCache) stats() indexSelectionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return indexSelectionCacheStats{
+		entries:       c.mu.cache.Len(),
+		hits:          c.mu.hits,
+		misses:        c.mu.misses,
+		invalidations: c.mu.invalidations,
+	}
+}
+
+// indexSelectionCacheEntry describes the cached decisions of a statement in
+// crdb_internal.node_index_selection_cache.
+type indexSelectionCacheEntry struct {
+	key     indexSelectionKey
+	indexes []string
+	hits    int64
+}
+
+// entries returns the cached decisions, ordered by statement.
+func (c *indexSelectionCache) entries() []indexSelectionCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var res []indexSelectionCacheEntry
+	c.mu.cache.Do(func(k, v interface{}) {
+		sel := v.(*cachedIndexSelection)
+		e := indexSelectionCacheEntry{key: k.(indexSelectionKey), hits: atomic.LoadInt64(&sel.hits)}
+		for _, s := range sel.scans {
+			if s.indexID != 0 {
+				e.indexes = append(e.indexes, s.tableName+"@"+s.indexName)
+			}
+		}
+		res = append(res, e)
+	})
+	return res
+}
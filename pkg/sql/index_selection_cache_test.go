@@ -0,0 +1,81 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestIndexSelectionCacheHits checks that lookups are only counted as hits
+// when the cached decisions are reused, and not when they are found to be
+// stale while planning.
+func TestIndexSelectionCacheHits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var c indexSelectionCache
+	c.init()
+	stmt := Statement{prepared: &PreparedStatement{Str: "SELECT k FROM kv WHERE v = $1"}}
+	session := &Session{Database: "test", User: "root"}
+	desc := sqlbase.TableDescriptor{
+		ID:           51,
+		Name:         "kv",
+		Version:      1,
+		PrimaryIndex: sqlbase.IndexDescriptor{ID: 1, Name: "primary"},
+		Indexes:      []sqlbase.IndexDescriptor{{ID: 2, Name: "v_idx"}},
+	}
+
+	// plan simulates the planning of the statement, with a single scan of
+	// the table, and returns the index specified for the scan by the cache.
+	plan := func(desc sqlbase.TableDescriptor) *sqlbase.IndexDescriptor {
+		s := c.start(stmt, session)
+		n := &scanNode{desc: desc}
+		s.initScan(n)
+		if n.cachedScan != nil {
+			n.cachedScan.indexID = 2
+			n.cachedScan.indexName = "v_idx"
+		}
+		s.finish()
+		return n.specifiedIndex
+	}
+	expectStats := func(expected indexSelectionCacheStats) {
+		if s := c.stats(); s != expected {
+			t.Fatalf("expected %+v, got %+v", expected, s)
+		}
+	}
+
+	if index := plan(desc); index != nil {
+		t.Fatalf("expected no specified index on a miss, got %s", index.Name)
+	}
+	expectStats(indexSelectionCacheStats{entries: 1, misses: 1})
+
+	if index := plan(desc); index == nil || index.ID != 2 {
+		t.Fatalf("expected the cached index to be specified, got %v", index)
+	}
+	expectStats(indexSelectionCacheStats{entries: 1, hits: 1, misses: 1})
+
+	// A new version of the descriptor makes the decisions stale: they are
+	// evicted, and the lookup is a miss.
+	desc.Version++
+	if index := plan(desc); index != nil {
+		t.Fatalf("expected no specified index for a stale entry, got %s", index.Name)
+	}
+	expectStats(indexSelectionCacheStats{misses: 2, hits: 1, invalidations: 1})
+	if entries := c.entries(); len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+}
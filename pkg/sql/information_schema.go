@@ -112,10 +112,15 @@ CREATE TABLE information_schema.columns (
 	CHARACTER_OCTET_LENGTH INT,
 	NUMERIC_PRECISION INT,
 	NUMERIC_SCALE INT,
-	DATETIME_PRECISION INT
+	DATETIME_PRECISION INT,
+	COLUMN_COMMENT STRING
 );
 `,
 	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
 		return forEachTableDesc(ctx, p, func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
 			// Table descriptors already holds columns in-order.
 			visible := 0
@@ -135,6 +140,9 @@ CREATE TABLE information_schema.columns (
 					numericPrecision(column.Type),              // numeric_precision
 					numericScale(column.Type),                  // numeric_scale
 					datetimePrecision(column.Type),             // datetime_precision
+					commentDatum(comments, commentKey{
+						typ: columnCommentType, objID: table.ID, subID: uint32(column.ID),
+					}), // column_comment
 				)
 			})
 		})
@@ -303,9 +311,14 @@ CREATE TABLE information_schema.statistics (
 	CARDINALITY INT NOT NULL DEFAULT 0,
 	DIRECTION STRING NOT NULL DEFAULT '',
 	STORING BOOL NOT NULL DEFAULT FALSE,
-	IMPLICIT BOOL NOT NULL DEFAULT FALSE
+	IMPLICIT BOOL NOT NULL DEFAULT FALSE,
+	INDEX_COMMENT STRING
 );`,
 	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
 		return forEachTableDesc(ctx, p, func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
 			appendRow := func(index *sqlbase.IndexDescriptor, colName string, sequence int,
 				direction parser.Datum, isStored, isImplicit bool,
@@ -324,6 +337,9 @@ CREATE TABLE information_schema.statistics (
 					direction,                                     // direction
 					parser.MakeDBool(parser.DBool(isStored)),   // storing
 					parser.MakeDBool(parser.DBool(isImplicit)), // implicit
+					commentDatum(comments, commentKey{
+						typ: indexCommentType, objID: table.ID, subID: uint32(index.ID),
+					}), // index_comment
 				)
 			}
 
@@ -491,9 +507,14 @@ CREATE TABLE information_schema.tables (
 	TABLE_SCHEMA STRING NOT NULL DEFAULT '',
 	TABLE_NAME STRING NOT NULL DEFAULT '',
 	TABLE_TYPE STRING NOT NULL DEFAULT '',
-	VERSION INT
+	VERSION INT,
+	TABLE_COMMENT STRING
 );`,
 	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
 		return forEachTableDesc(ctx, p, func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
 			tableType := tableTypeBaseTable
 			if isVirtualDescriptor(table) {
@@ -501,12 +522,14 @@ CREATE TABLE information_schema.tables (
 			} else if table.IsView() {
 				tableType = tableTypeView
 			}
+			tableComment := commentDatum(comments, commentKey{typ: tableCommentType, objID: table.ID})
 			return addRow(
 				defString,                     // table_catalog
 				parser.NewDString(db.Name),    // table_schema
 				parser.NewDString(table.Name), // table_name
 				tableType,                     // table_type
 				parser.NewDInt(parser.DInt(table.Version)), // version
+				tableComment, // table_comment
 			)
 		})
 	},
@@ -591,6 +614,52 @@ func forEachDatabaseDesc(
 	return nil
 }
 
+// forEachTypeDesc retrieves all user-defined type descriptors and iterates
+// through them in lexicographical order with respect primarily to database
+// name and secondarily to type name. For each type, the function will call fn
+// with its respective database and type descriptor.
+func forEachTypeDesc(
+	ctx context.Context,
+	p *planner,
+	fn func(*sqlbase.DatabaseDescriptor, *sqlbase.TypeDescriptor) error,
+) error {
+	descs, err := getAllDescriptors(ctx, p.txn)
+	if err != nil {
+		return err
+	}
+	dbs := make(map[sqlbase.ID]*sqlbase.DatabaseDescriptor)
+	var types []*sqlbase.TypeDescriptor
+	for _, desc := range descs {
+		switch d := desc.(type) {
+		case *sqlbase.DatabaseDescriptor:
+			dbs[d.ID] = d
+		case *sqlbase.TypeDescriptor:
+			types = append(types, d)
+		}
+	}
+	for _, typ := range types {
+		if _, ok := dbs[typ.ParentID]; !ok {
+			return errors.Errorf("no database with ID %d found", typ.ParentID)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if a, b := dbs[types[i].ParentID].Name, dbs[types[j].ParentID].Name; a != b {
+			return a < b
+		}
+		return types[i].Name < types[j].Name
+	})
+	for _, typ := range types {
+		db := dbs[typ.ParentID]
+		if !userCanSeeDatabase(db, p.session.User) {
+			continue
+		}
+		if err := fn(db, typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // forEachTableDesc retrieves all table descriptors from the current database
 // and all system databases and iterates through them in lexicographical order
 // with respect primarily to database name and secondarily to table name. For
@@ -31,6 +31,7 @@ type insertNode struct {
 	// The following fields are populated during makePlan.
 	editNodeBase
 	defaultExprs []parser.TypedExpr
+	computedCols *sqlbase.ComputedColumns
 	n            *parser.Insert
 	checkHelper  checkHelper
 
@@ -136,10 +137,34 @@ func (p *planner) Insert(
 	if err != nil {
 		return nil, err
 	}
+	computedCols, err := sqlbase.MakeComputedColumns(en.tableDesc, ri.InsertCols, ri.InsertColIDtoRowIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	insertPolicyCheck, err := p.makePolicyChecker(
+		ctx, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_INSERT, false, /* existingRows */
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	en.triggers, err = p.makeTriggerRunner(ctx, en.tableDesc, upsertTriggerEvents(n.OnConflict)...)
+	if err != nil {
+		return nil, err
+	}
+	// The AFTER triggers fire once the batch has been run, so the transaction
+	// cannot be committed along with it.
+	autoCommit := p.autoCommit && en.triggers == nil
 
 	var tw tableWriter
 	if n.OnConflict == nil {
-		tw = &tableInserter{ri: ri, autoCommit: p.autoCommit}
+		tw = &tableInserter{
+			ri:          ri,
+			autoCommit:  autoCommit,
+			policyCheck: insertPolicyCheck,
+			triggers:    en.triggers,
+		}
 	} else {
 		updateExprs, conflictIndex, err := upsertExprsAndIndex(en.tableDesc, *n.OnConflict, ri.InsertCols)
 		if err != nil {
@@ -151,9 +176,11 @@ func (p *planner) Insert(
 			// conflict index, which means do nothing on any conflict. Support this if
 			// someone needs it.
 			tw = &tableUpserter{
-				ri:            ri,
-				autoCommit:    p.autoCommit,
-				conflictIndex: *conflictIndex,
+				ri:                ri,
+				autoCommit:        autoCommit,
+				conflictIndex:     *conflictIndex,
+				insertPolicyCheck: insertPolicyCheck,
+				triggers:          en.triggers,
 			}
 		} else {
 			names, err := p.namesForExprs(updateExprs)
@@ -173,6 +200,9 @@ func (p *planner) Insert(
 				if err != nil {
 					return nil, err
 				}
+				if col.IsComputed() {
+					return nil, errComputedColumnWrite(col.Name)
+				}
 				updateCols[i] = col
 			}
 
@@ -181,19 +211,43 @@ func (p *planner) Insert(
 			if err != nil {
 				return nil, err
 			}
+			// The computed columns which depend on the updated columns are
+			// updated too. Their values are computed after the update
+			// expressions are evaluated.
+			updateCols, err = sqlbase.AddDependentComputedColumns(en.tableDesc, updateCols)
+			if err != nil {
+				return nil, err
+			}
 
 			fkTables := sqlbase.TablesNeededForFKs(*en.tableDesc, sqlbase.CheckUpdates)
 			if err := p.fillFKTableMap(ctx, fkTables); err != nil {
 				return nil, err
 			}
+			existingPolicyCheck, err := p.makePolicyChecker(
+				ctx, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_UPDATE, true, /* existingRows */
+			)
+			if err != nil {
+				return nil, err
+			}
+			updatePolicyCheck, err := p.makePolicyChecker(
+				ctx, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_UPDATE, false, /* existingRows */
+			)
+			if err != nil {
+				return nil, err
+			}
 			tw = &tableUpserter{
-				ri:            ri,
-				autoCommit:    p.autoCommit,
-				fkTables:      fkTables,
-				updateCols:    updateCols,
-				conflictIndex: *conflictIndex,
-				evaler:        helper,
-				isUpsertAlias: n.OnConflict.IsUpsertAlias(),
+				ri:                  ri,
+				autoCommit:          autoCommit,
+				fkTables:            fkTables,
+				updateCols:          updateCols,
+				conflictIndex:       *conflictIndex,
+				evaler:              helper,
+				evalCtx:             &p.evalCtx,
+				isUpsertAlias:       n.OnConflict.IsUpsertAlias(),
+				insertPolicyCheck:   insertPolicyCheck,
+				existingPolicyCheck: existingPolicyCheck,
+				updatePolicyCheck:   updatePolicyCheck,
+				triggers:            en.triggers,
 			}
 		}
 	}
@@ -202,6 +256,7 @@ func (p *planner) Insert(
 		n:                     n,
 		editNodeBase:          en,
 		defaultExprs:          defaultExprs,
+		computedCols:          computedCols,
 		insertCols:            ri.InsertCols,
 		insertColIDtoRowIndex: ri.InsertColIDtoRowIndex,
 		tw: tw,
@@ -260,6 +315,9 @@ func (n *insertNode) Next(ctx context.Context) (bool, error) {
 			// We're done. Finish the batch.
 			err = n.tw.finalize(ctx, n.p.session.Tracing.KVTracingEnabled())
 		}
+		if err == nil {
+			n.p.session.statsRefresher.notifyMutation(n.p.txn, n.tableDesc.ID, n.run.numRows)
+		}
 		return false, err
 	}
 
@@ -267,7 +325,7 @@ func (n *insertNode) Next(ctx context.Context) (bool, error) {
 		return true, nil
 	}
 
-	rowVals, err := GenerateInsertRow(n.defaultExprs, n.insertColIDtoRowIndex, n.insertCols, n.p.evalCtx, n.tableDesc, n.run.rows.Values())
+	rowVals, err := GenerateInsertRow(n.defaultExprs, n.computedCols, n.insertColIDtoRowIndex, n.insertCols, n.p.evalCtx, n.tableDesc, n.run.rows.Values())
 	if err != nil {
 		return false, err
 	}
@@ -283,6 +341,7 @@ func (n *insertNode) Next(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	n.run.numRows++
 
 	for i, val := range rowVals {
 		if n.run.rowTemplate != nil {
@@ -300,9 +359,11 @@ func (n *insertNode) Next(ctx context.Context) (bool, error) {
 }
 
 // GenerateInsertRow prepares a row tuple for insertion. It fills in default
-// expressions, verifies non-nullable columns, and checks column widths.
+// expressions, computes the computed columns, verifies non-nullable columns,
+// and checks column widths.
 func GenerateInsertRow(
 	defaultExprs []parser.TypedExpr,
+	computedCols *sqlbase.ComputedColumns,
 	insertColIDtoRowIndex map[sqlbase.ColumnID]int,
 	insertCols []sqlbase.ColumnDescriptor,
 	evalCtx parser.EvalContext,
@@ -313,8 +374,9 @@ func GenerateInsertRow(
 	// inserted into. Generate default values for those columns using the
 	// default expressions.
 
-	if len(rowVals) < len(insertCols) {
-		// It's not cool to append to the slice returned by a node; make a copy.
+	if len(rowVals) < len(insertCols) || computedCols != nil {
+		// It's not cool to append to or modify the slice returned by a node;
+		// make a copy.
 		oldVals := rowVals
 		rowVals = make(parser.Datums, len(insertCols))
 		copy(rowVals, oldVals)
@@ -332,6 +394,10 @@ func GenerateInsertRow(
 		}
 	}
 
+	if err := computedCols.Compute(&evalCtx, rowVals); err != nil {
+		return nil, err
+	}
+
 	// Check to see if NULL is being inserted into any non-nullable column.
 	for _, col := range tableDesc.Columns {
 		if !col.Nullable {
@@ -377,6 +443,9 @@ func (p *planner) processColumns(
 		if err != nil {
 			return nil, err
 		}
+		if col.IsComputed() {
+			return nil, errComputedColumnWrite(col.Name)
+		}
 
 		if _, ok := colIDSet[col.ID]; ok {
 			return nil, fmt.Errorf("multiple assignments to the same column %q", n)
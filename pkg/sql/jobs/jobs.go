@@ -107,6 +107,8 @@ func GetJobLogger(
 			jl.Job.Details = *d.Restore
 		case *JobPayload_SchemaChange:
 			jl.Job.Details = *d.SchemaChange
+		case *JobPayload_CreateStats:
+			jl.Job.Details = *d.CreateStats
 		default:
 			return errors.Errorf("JobLogger: unsupported job details type %T", d)
 		}
@@ -161,6 +163,8 @@ func (jl *JobLogger) Created(ctx context.Context) error {
 		payload.Details = &JobPayload_Restore{Restore: &d}
 	case SchemaChangeJobDetails:
 		payload.Details = &JobPayload_SchemaChange{SchemaChange: &d}
+	case CreateStatsJobDetails:
+		payload.Details = &JobPayload_CreateStats{CreateStats: &d}
 	default:
 		return errors.Errorf("JobLogger: unsupported job details type %T", d)
 	}
@@ -322,6 +326,7 @@ const (
 	JobTypeBackup       string = "BACKUP"
 	JobTypeRestore      string = "RESTORE"
 	JobTypeSchemaChange string = "SCHEMA CHANGE"
+	JobTypeCreateStats  string = "CREATE STATISTICS"
 )
 
 // Typ returns the payload's job type.
@@ -333,6 +338,8 @@ func (jp *JobPayload) Typ() string {
 		return JobTypeRestore
 	case *JobPayload_SchemaChange:
 		return JobTypeSchemaChange
+	case *JobPayload_CreateStats:
+		return JobTypeCreateStats
 	default:
 		panic("JobPayload.Typ called on a payload with an unknown details type")
 	}
@@ -363,3 +370,4 @@ func jobTimestamp(ts time.Time) int64 {
 var _ JobDetails = BackupJobDetails{}
 var _ JobDetails = RestoreJobDetails{}
 var _ JobDetails = SchemaChangeJobDetails{}
+var _ JobDetails = CreateStatsJobDetails{}
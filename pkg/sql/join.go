@@ -167,6 +167,18 @@ type joinNode struct {
 	// finishedOutput indicates that we've finished writing all of the rows for
 	// this join and that we can quit as soon as our buffer is empty.
 	finishedOutput bool
+
+	// joinOrderChosen is set once reorderJoins has considered the tree of
+	// inner joins containing this node, so that it is not reordered again.
+	joinOrderChosen bool
+
+	// mergeJoinOrdering is set during expandPlan when both inputs are ordered
+	// on all the equality columns and the cost model chose a merge join. The
+	// column indices refer to equality columns: a ColIdx of i refers to left
+	// column pred.leftEqualityIndices[i] and right column
+	// pred.rightEqualityIndices[i]. DistSQL then uses a merge joiner instead
+	// of a hash joiner.
+	mergeJoinOrdering sqlbase.ColumnOrdering
 }
 
 // commonColumns returns the names of columns common on the
@@ -266,15 +278,15 @@ func (p *planner) makeJoin(
 
 	n.buffer = &RowBuffer{
 		RowContainer: sqlbase.NewRowContainer(
-			p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(planColumns(n)), 0,
+			p.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(planColumns(n)), 0,
 		),
 	}
 
-	n.bucketsMemAcc = p.session.TxnState.OpenAccount()
+	n.bucketsMemAcc = p.openAccount()
 	n.buckets = buckets{
 		buckets: make(map[string]*bucket),
 		rowContainer: sqlbase.NewRowContainer(
-			p.session.TxnState.makeBoundAccount(),
+			p.makeBoundAccount(),
 			sqlbase.ColTypeInfoFromResCols(planColumns(n.right.plan)),
 			0,
 		),
@@ -337,7 +349,7 @@ func (n *joinNode) Start(ctx context.Context) error {
 func (n *joinNode) hashJoinStart(ctx context.Context) error {
 	var scratch []byte
 	// Load all the rows from the right side and build our hashmap.
-	acc := n.bucketsMemAcc.Wtxn(n.planner.session)
+	acc := n.bucketsMemAcc.Wplanner(n.planner)
 	for {
 		hasRow, err := n.right.plan.Next(ctx)
 		if err != nil {
@@ -581,7 +593,7 @@ func (n *joinNode) Close(ctx context.Context) {
 	n.buffer.Close(ctx)
 	n.buffer = nil
 	n.buckets.Close(ctx)
-	n.bucketsMemAcc.Wtxn(n.planner.session).Close(ctx)
+	n.bucketsMemAcc.Wplanner(n.planner).Close(ctx)
 
 	n.right.plan.Close(ctx)
 	n.left.plan.Close(ctx)
@@ -0,0 +1,326 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// maxReorderedJoinSources is the maximum number of sources of a tree of
+// inner joins which is reordered.
+const maxReorderedJoinSources = 64
+
+// joinGraph is a tree of inner joins flattened into the sources it joins and
+// the conjuncts of its join conditions.
+type joinGraph struct {
+	p *planner
+
+	// columns are the result columns of the original tree.
+	columns    sqlbase.ResultColumns
+	ivarHelper parser.IndexedVarHelper
+
+	sources []planDataSource
+	// offsets[i] is the index of the first column of sources[i] in the
+	// results of the original tree.
+	offsets []int
+	// estimates[i] is the estimate of sources[i].
+	estimates []planEstimate
+
+	conds []joinGraphCond
+}
+
+// joinGraphCond is a conjunct of the join conditions of a joinGraph. Its
+// IndexedVars refer to the results of the original tree.
+type joinGraphCond struct {
+	expr parser.TypedExpr
+	// sources is the set of the sources referenced by expr, as a bitmap.
+	sources uint64
+	// If the conjunct is an equality between two columns, leftCol and
+	// rightCol are these columns; otherwise they are -1.
+	leftCol, rightCol int
+}
+
+var _ parser.IndexedVarContainer = &joinGraph{}
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (g *joinGraph) IndexedVarEval(idx int, ctx *parser.EvalContext) (parser.Datum, error) {
+	panic("join graph conditions are not evaluated")
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (g *joinGraph) IndexedVarResolvedType(idx int) parser.Type {
+	return g.columns[idx].Typ
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (g *joinGraph) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	parser.FormatNode(buf, f, parser.Name(g.columns[idx].Name))
+}
+
+// flatten adds the sources and join conditions of the tree rooted at src,
+// whose columns start at the given offset in the results of the original
+// tree.
+func (g *joinGraph) flatten(src planDataSource, offset int) {
+	n, ok := src.plan.(*joinNode)
+	if !ok || n.joinType != joinTypeInner || n.pred.numMergedEqualityColumns > 0 ||
+		len(g.sources)+2 > maxReorderedJoinSources {
+		g.sources = append(g.sources, src)
+		g.offsets = append(g.offsets, offset)
+		return
+	}
+	n.joinOrderChosen = true
+	numLeft := n.pred.numLeftCols
+	g.flatten(n.left, offset)
+	g.flatten(n.right, offset+numLeft)
+
+	for i := range n.pred.leftEqualityIndices {
+		l := offset + n.pred.leftEqualityIndices[i]
+		r := offset + numLeft + n.pred.rightEqualityIndices[i]
+		g.addCond(parser.NewTypedComparisonExpr(
+			parser.EQ, g.ivarHelper.IndexedVar(l), g.ivarHelper.IndexedVar(r),
+		))
+	}
+	if !isFilterTrue(n.pred.onCond) {
+		for _, e := range splitAndExpr(&g.p.evalCtx, n.pred.onCond, nil) {
+			g.addCond(exprConvertVars(e, func(expr parser.VariableExpr) (bool, parser.Expr) {
+				if iv, ok := expr.(*parser.IndexedVar); ok {
+					return true, g.ivarHelper.IndexedVar(offset + iv.Idx)
+				}
+				return true, expr
+			}))
+		}
+	}
+}
+
+// sourceOf returns the index of the source of a column of the original tree.
+func (g *joinGraph) sourceOf(col int) int {
+	for i := len(g.offsets) - 1; i > 0; i-- {
+		if col >= g.offsets[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+func (g *joinGraph) addCond(expr parser.TypedExpr) {
+	c := joinGraphCond{expr: expr, leftCol: -1, rightCol: -1}
+	_ = exprConvertVars(expr, func(v parser.VariableExpr) (bool, parser.Expr) {
+		if iv, ok := v.(*parser.IndexedVar); ok {
+			c.sources |= 1 << uint(g.sourceOf(iv.Idx))
+		}
+		return true, v
+	})
+	if cmp, ok := expr.(*parser.ComparisonExpr); ok && cmp.Operator == parser.EQ {
+		l, lok := cmp.Left.(*parser.IndexedVar)
+		r, rok := cmp.Right.(*parser.IndexedVar)
+		if lok && rok {
+			c.leftCol, c.rightCol = l.Idx, r.Idx
+		}
+	}
+	g.conds = append(g.conds, c)
+}
+
+// distinct estimates the number of distinct values of a column of the
+// original tree.
+func (g *joinGraph) distinct(ctx context.Context, col int) float64 {
+	src := g.sourceOf(col)
+	if d, ok := g.p.estimateDistinct(ctx, g.sources[src].plan, col-g.offsets[src]); ok {
+		return d
+	}
+	// Assume the column is a key.
+	return g.estimates[src].rows
+}
+
+// joinSelectivity returns the selectivity of the conditions which become
+// applicable when the sources in the set joined are joined with the source
+// src, and whether there is any such condition.
+func (g *joinGraph) joinSelectivity(ctx context.Context, joined uint64, src int) (float64, bool) {
+	sel := 1.0
+	connected := false
+	srcBit := uint64(1) << uint(src)
+	for _, c := range g.conds {
+		if c.sources&srcBit == 0 || c.sources&joined == 0 || c.sources&^(joined|srcBit) != 0 {
+			continue
+		}
+		connected = true
+		if c.leftCol >= 0 {
+			sel /= math.Max(1, math.Max(g.distinct(ctx, c.leftCol), g.distinct(ctx, c.rightCol)))
+		} else {
+			sel *= defaultSelectivity
+		}
+	}
+	return sel, connected
+}
+
+// reorderJoins chooses the order in which a tree of inner joins is executed
+// using the table statistics. The order is chosen greedily: the first two
+// sources joined are the connected pair producing the fewest rows, and each
+// following source is the one, among those connected by a join condition to
+// the sources already joined, which produces the fewest rows. At each step,
+// the operand with the fewest rows becomes the right operand, which is
+// loaded in the hash table.
+//
+// The plan is returned unchanged if a source has no statistics, or if the
+// order in which the joins are written is estimated to be cheaper. Otherwise,
+// the reordered joins are wrapped in a renderNode which restores the order of
+// the columns of the original tree.
+//
+// reorderJoins runs after filter propagation, so the join conditions
+// include the filters which apply to several sources.
+func (p *planner) reorderJoins(ctx context.Context, n *joinNode) (planNode, error) {
+	if n.joinOrderChosen || n.joinType != joinTypeInner || p.session.tableStats == nil {
+		return n, nil
+	}
+	orig, ok := p.estimateJoin(ctx, n)
+	if !ok {
+		return n, nil
+	}
+
+	g := &joinGraph{p: p, columns: n.columns}
+	g.ivarHelper = parser.MakeIndexedVarHelper(g, len(n.columns))
+	g.flatten(planDataSource{plan: n}, 0)
+	if len(g.sources) < 3 {
+		// With two sources, the hash join already reads the right operand
+		// into the hash table; there is no order to choose.
+		return n, nil
+	}
+	g.estimates = make([]planEstimate, len(g.sources))
+	for i := range g.sources {
+		if g.estimates[i], ok = p.estimatePlan(ctx, g.sources[i].plan); !ok {
+			return n, nil
+		}
+	}
+
+	// Choose the first pair of sources.
+	first, second := -1, -1
+	var rows float64
+	for i := range g.sources {
+		for j := i + 1; j < len(g.sources); j++ {
+			sel, connected := g.joinSelectivity(ctx, 1<<uint(i), j)
+			r := g.estimates[i].rows * g.estimates[j].rows * sel
+			if connected && (first < 0 || r < rows) {
+				first, second, rows = i, j, r
+			}
+		}
+	}
+	if first < 0 {
+		// The sources are not connected by any join condition.
+		return n, nil
+	}
+
+	// steps lists the sources in the order in which they are joined.
+	steps := []int{first, second}
+	joined := uint64(1)<<uint(first) | uint64(1)<<uint(second)
+	cost := g.estimates[first].cost + g.estimates[second].cost +
+		g.estimates[first].rows + g.estimates[second].rows + rows
+	stepRows := []float64{g.estimates[first].rows, rows}
+	for len(steps) < len(g.sources) {
+		next, nextConnected := -1, false
+		var nextRows float64
+		for i := range g.sources {
+			if joined&(1<<uint(i)) != 0 {
+				continue
+			}
+			sel, connected := g.joinSelectivity(ctx, joined, i)
+			r := rows * g.estimates[i].rows * sel
+			if next < 0 || (connected && !nextConnected) ||
+				(connected == nextConnected && r < nextRows) {
+				next, nextConnected, nextRows = i, connected, r
+			}
+		}
+		cost += g.estimates[next].cost + rows + g.estimates[next].rows + nextRows
+		rows = nextRows
+		steps = append(steps, next)
+		stepRows = append(stepRows, rows)
+		joined |= 1 << uint(next)
+	}
+
+	if cost >= orig.cost {
+		return n, nil
+	}
+	if log.V(2) {
+		log.Infof(ctx, "reordering joins: sources %v, cost %.2f (was %.2f)", steps, cost, orig.cost)
+	}
+
+	// Build the new tree of joins. order lists the sources in the order of
+	// the columns of the tree.
+	tree := g.sources[steps[0]]
+	order := []int{steps[0]}
+	for i, src := range steps[1:] {
+		left, right := tree, g.sources[src]
+		if g.estimates[src].rows > stepRows[i] {
+			left, right = right, left
+			order = append([]int{src}, order...)
+		} else {
+			order = append(order, src)
+		}
+		var err error
+		if tree, err = p.makeJoin(ctx, "CROSS JOIN", left, right, nil); err != nil {
+			return nil, err
+		}
+		tree.plan.(*joinNode).joinOrderChosen = true
+	}
+	top := tree.plan.(*joinNode)
+
+	// newPos maps the columns of the original tree to the columns of the new
+	// one.
+	newPos := make([]int, len(n.columns))
+	newOffset := 0
+	for _, src := range order {
+		numCols := len(g.sources[src].info.sourceColumns)
+		for i := 0; i < numCols; i++ {
+			newPos[g.offsets[src]+i] = newOffset + i
+		}
+		newOffset += numCols
+	}
+	if newOffset != len(n.columns) {
+		panic(fmt.Sprintf("reordered joins have %d columns, expected %d", newOffset, len(n.columns)))
+	}
+
+	// Attach the join conditions to the new tree; filter propagation pushes
+	// each of them down to the lowest join where it applies.
+	var filter parser.TypedExpr
+	for _, c := range g.conds {
+		filter = mergeConj(filter, exprConvertVars(c.expr,
+			func(expr parser.VariableExpr) (bool, parser.Expr) {
+				if iv, ok := expr.(*parser.IndexedVar); ok {
+					return true, top.pred.iVarHelper.IndexedVar(newPos[iv.Idx])
+				}
+				return true, expr
+			}))
+	}
+	if _, remainder, err := p.addJoinFilter(ctx, top, filter); err != nil {
+		return nil, err
+	} else if !isFilterTrue(remainder) {
+		panic(fmt.Sprintf("inner join did not absorb filter %s", remainder))
+	}
+
+	r := &renderNode{planner: p, source: tree}
+	r.ivarHelper = parser.MakeIndexedVarHelper(r, len(newPos))
+	r.sourceInfo = multiSourceInfo{tree.info}
+	for i, col := range n.columns {
+		r.addRenderColumn(r.ivarHelper.IndexedVar(newPos[i]), col)
+	}
+	r.numOriginalCols = len(r.columns)
+	return r, nil
+}
@@ -1284,7 +1284,7 @@ func (m *LeaseManager) RefreshLeases(s *stop.Stopper, db *client.DB, gossip *gos
 									table.ID, table.Name, err)
 							}
 						}
-					case *sqlbase.Descriptor_Database:
+					case *sqlbase.Descriptor_Database, *sqlbase.Descriptor_Type, *sqlbase.Descriptor_Function:
 						// Ignore.
 					}
 				}
@@ -152,6 +152,8 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 		if n.expanded {
 			setUnlimited(n.plan)
 		}
+	case *analyzeNode:
+		applyLimit(n.plan, numRows, soft)
 
 	case *delayedNode:
 		if n.plan != nil {
@@ -166,14 +168,29 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *alterTypeNode:
+	case *alterUserSetNode:
+	case *alterUserSetPasswordNode:
+	case *cancelQueryNode:
+	case *cancelSessionNode:
+	case *commentNode:
 	case *copyNode:
 	case *createDatabaseNode:
+	case *createFunctionNode:
 	case *createIndexNode:
+	case *createPolicyNode:
+	case *createStatsNode:
+	case *createTriggerNode:
+	case *createTypeNode:
 	case *createUserNode:
 	case *dropDatabaseNode:
+	case *dropFunctionNode:
 	case *dropIndexNode:
+	case *dropPolicyNode:
+	case *dropTriggerNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropTypeNode:
 	case *dropUserNode:
 	case *emptyNode:
 	case *hookFnNode:
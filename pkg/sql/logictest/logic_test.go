@@ -658,6 +658,7 @@ func (t *logicTest) setup(
 					WaitForGossipUpdate:   true,
 					CheckStmtStringChange: true,
 					OverrideDistSQLMode:   distSQLOverride,
+					DisableAutomaticStats: true,
 				},
 			},
 		},
@@ -0,0 +1,224 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// MemoryGroupBy determines how sessions are grouped for the purpose of
+// the per-group memory limit.
+type MemoryGroupBy int64
+
+const (
+	// MemoryGroupByUser groups sessions by their SQL user.
+	MemoryGroupByUser MemoryGroupBy = iota
+	// MemoryGroupByApplicationName groups sessions by the application name
+	// they connected with.
+	MemoryGroupByApplicationName
+)
+
+var memoryGroupBy = settings.RegisterEnumSetting(
+	"sql.memory.group_by",
+	"how sessions are grouped to enforce sql.memory.group_limit",
+	"user",
+	map[int64]string{
+		int64(MemoryGroupByUser):            "user",
+		int64(MemoryGroupByApplicationName): "application_name",
+	},
+)
+
+var memoryGroupLimit = settings.RegisterByteSizeSetting(
+	"sql.memory.group_limit",
+	"maximum amount of memory all the sessions of a user (or application name, "+
+		"see sql.memory.group_by) may use on each node; zero disables the limit",
+	0,
+)
+
+var memoryAdmissionThreshold = settings.RegisterValidatedFloatSetting(
+	"sql.memory.admission.threshold",
+	"fraction of the node's SQL memory budget in use beyond which new queries wait "+
+		"for memory to be released before starting; 1 disables admission control",
+	0.9,
+	func(v float64) error {
+		if v <= 0 || v > 1 {
+			return errors.Errorf("admission threshold must be in (0, 1], got %f", v)
+		}
+		return nil
+	},
+)
+
+var memoryAdmissionMaxWait = settings.RegisterNonNegativeDurationSetting(
+	"sql.memory.admission.max_wait",
+	"maximum duration a query waits for SQL memory to become available before it is rejected; "+
+		"zero rejects queries immediately",
+	10*time.Second,
+)
+
+// MemoryBudgets layers the per-group and per-query memory limits on top
+// of the node-wide SQL memory pool, and holds back new queries while
+// the node-wide budget is nearly exhausted.
+//
+// Sessions are grouped by user or application name (see
+// sql.memory.group_by). Every group has a monitor drawing from the
+// pool, whose limit is sql.memory.group_limit; the root monitor of each
+// session in the group draws from the group's monitor. The group of a
+// session is determined again before each of its statements, so that
+// changes of its application name or of sql.memory.group_by move the
+// session to its new group, and changes of sql.memory.group_limit apply
+// to the groups that are already running.
+type MemoryBudgets struct {
+	pool      *mon.MemoryMonitor
+	admission *mon.AdmissionQueue
+
+	mu struct {
+		syncutil.Mutex
+		groups map[string]*memoryGroup
+	}
+}
+
+// memoryGroup is the monitor shared by the sessions of a user or an
+// application.
+type memoryGroup struct {
+	key  string
+	mon  mon.MemoryMonitor
+	refs int
+}
+
+// MakeMemoryBudgets creates a MemoryBudgets whose group monitors draw
+// from pool. The admission queue, if not nil, is consulted before every
+// query.
+func MakeMemoryBudgets(pool *mon.MemoryMonitor, admission *mon.AdmissionQueue) *MemoryBudgets {
+	b := &MemoryBudgets{
+		pool:      pool,
+		admission: admission,
+	}
+	b.mu.groups = make(map[string]*memoryGroup)
+	return b
+}
+
+// acquireGroup returns the monitor of the given group, starting it if
+// this is the first session of the group. The current value of
+// sql.memory.group_limit is applied to the monitor, see also
+// refreshLimit().
+func (b *MemoryBudgets) acquireGroup(ctx context.Context, key string) *memoryGroup {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.mu.groups[key]
+	if !ok {
+		g = &memoryGroup{key: key}
+		// The usage of the group is already accounted for by the pool's
+		// metrics.
+		g.mon = mon.MakeMonitor("group "+key,
+			nil, /* curCount */
+			nil, /* maxHist */
+			-1, math.MaxInt64)
+		g.mon.Start(ctx, b.pool, mon.BoundAccount{})
+		b.mu.groups[key] = g
+	}
+	g.refreshLimit()
+	g.refs++
+	return g
+}
+
+// refreshLimit applies the current value of sql.memory.group_limit to the
+// group's monitor.
+func (g *memoryGroup) refreshLimit() {
+	g.mon.SetLimit(memoryGroupLimit.Get())
+}
+
+// releaseGroup releases a reference to the given group, stopping its
+// monitor if this was the last session of the group.
+func (b *MemoryBudgets) releaseGroup(ctx context.Context, g *memoryGroup, emergency bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g.refs--
+	if g.refs > 0 {
+		return
+	}
+	if emergency {
+		g.mon.EmergencyStop(ctx)
+	} else {
+		g.mon.Stop(ctx)
+	}
+	delete(b.mu.groups, g.key)
+}
+
+// admit blocks until the node-wide SQL memory budget has room for a new
+// query. onWait is called if the query has to wait.
+func (b *MemoryBudgets) admit(ctx context.Context, onWait func()) error {
+	if b.admission == nil {
+		return nil
+	}
+	return b.admission.Admit(
+		ctx, memoryAdmissionThreshold.Get(), memoryAdmissionMaxWait.Get(), onWait)
+}
+
+// memGroupKey returns the key of the session's memory group, as
+// determined by sql.memory.group_by.
+func (s *Session) memGroupKey() string {
+	if MemoryGroupBy(memoryGroupBy.Get()) == MemoryGroupByApplicationName {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.mu.ApplicationName
+	}
+	return s.User
+}
+
+// StartGroupMonitor starts the session's monitors below the monitor of
+// the session's memory group.
+func (s *Session) StartGroupMonitor(budgets *MemoryBudgets, reserved mon.BoundAccount) {
+	s.memBudgets = budgets
+	s.memGroup = budgets.acquireGroup(s.context, s.memGroupKey())
+	s.StartMonitor(&s.memGroup.mon, reserved)
+}
+
+// refreshMemGroup moves the session's root monitor, along with the memory
+// it holds, to the monitor of the group the session currently belongs
+// to. An error is returned if the new group cannot accommodate the
+// session's memory, in which case the session remains in its group.
+func (s *Session) refreshMemGroup(ctx context.Context) error {
+	key := s.memGroupKey()
+	if key == s.memGroup.key {
+		s.memGroup.refreshLimit()
+		return nil
+	}
+	g := s.memBudgets.acquireGroup(ctx, key)
+	if err := s.mon.SetPool(ctx, &g.mon); err != nil {
+		s.memBudgets.releaseGroup(ctx, g, false /* emergency */)
+		return err
+	}
+	s.memBudgets.releaseGroup(ctx, s.memGroup, false /* emergency */)
+	s.memGroup = g
+	return nil
+}
+
+// releaseMemGroup releases the session's memory group, if any. It must
+// be called after the session's root monitor has been stopped.
+func (s *Session) releaseMemGroup(emergency bool) {
+	if s.memGroup == nil {
+		return
+	}
+	s.memBudgets.releaseGroup(s.context, s.memGroup, emergency)
+	s.memGroup = nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestMemoryLimits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	conn, cleanup := openSingleConn(t, s)
+	defer cleanup()
+
+	// Sorting the series requires several megabytes, much more than the
+	// limits set below.
+	const query = `
+SELECT COUNT(*) FROM (SELECT * FROM generate_series(1, 100000) AS g ORDER BY g DESC)`
+	runQuery := func(expectedErr string) error {
+		var count int
+		err := conn.QueryRow(query).Scan(&count)
+		if expectedErr == "" {
+			return err
+		}
+		if !testutils.IsError(err, expectedErr) {
+			return errors.Errorf("expected error %q, got %v", expectedErr, err)
+		}
+		return nil
+	}
+	set := func(setting, value, expectedErr string) {
+		if _, err := db.Exec(
+			fmt.Sprintf("SET CLUSTER SETTING %s = '%s'", setting, value),
+		); err != nil {
+			t.Fatal(err)
+		}
+		testutils.SucceedsSoon(t, func() error { return runQuery(expectedErr) })
+	}
+
+	// The per-query limit is enforced by the monitor of each statement.
+	set("sql.memory.query_limit", "1MiB", "stmt: memory budget exceeded")
+	set("sql.memory.query_limit", "0", "")
+
+	// Changes of the per-group limit apply to the groups of the sessions that
+	// are already running.
+	set("sql.memory.group_limit", "1MiB", "memory budget exceeded")
+	set("sql.memory.group_limit", "0", "")
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mon
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"golang.org/x/net/context"
+)
+
+var (
+	metaAdmissionWaiting = metric.Metadata{
+		Name: "sql.mem.admission.waiting",
+		Help: "Number of queries waiting for SQL memory to become available"}
+	metaAdmissionDelayed = metric.Metadata{
+		Name: "sql.mem.admission.delayed",
+		Help: "Number of queries that had to wait for SQL memory to become available"}
+	metaAdmissionRejected = metric.Metadata{
+		Name: "sql.mem.admission.rejected",
+		Help: "Number of queries rejected after waiting too long for SQL memory"}
+	metaAdmissionWaitLatency = metric.Metadata{
+		Name: "sql.mem.admission.wait.latency",
+		Help: "Latency of waiting for SQL memory to become available"}
+)
+
+// AdmissionMetrics are the metrics exported by an AdmissionQueue.
+type AdmissionMetrics struct {
+	Waiting     *metric.Gauge
+	Delayed     *metric.Counter
+	Rejected    *metric.Counter
+	WaitLatency *metric.Histogram
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (AdmissionMetrics) MetricStruct() {}
+
+var _ metric.Struct = AdmissionMetrics{}
+
+// MakeAdmissionMetrics instantiates the metrics for an AdmissionQueue.
+func MakeAdmissionMetrics(histogramWindow time.Duration) AdmissionMetrics {
+	return AdmissionMetrics{
+		Waiting:     metric.NewGauge(metaAdmissionWaiting),
+		Delayed:     metric.NewCounter(metaAdmissionDelayed),
+		Rejected:    metric.NewCounter(metaAdmissionRejected),
+		WaitLatency: metric.NewLatency(metaAdmissionWaitLatency, histogramWindow),
+	}
+}
+
+// admissionRetryOptions determines how often waiters re-check the
+// usage of the pool.
+var admissionRetryOptions = retry.Options{
+	InitialBackoff: 5 * time.Millisecond,
+	MaxBackoff:     250 * time.Millisecond,
+	Multiplier:     2,
+}
+
+// AdmissionQueue holds back new work while the usage of a standalone
+// memory monitor is close to its capacity. Rather than failing
+// immediately with a memory error when the node is under memory
+// pressure, queries wait in FIFO order until enough memory has been
+// released by other queries, or until a maximum wait has elapsed.
+//
+// The queue does not reserve any memory on behalf of admitted work:
+// admission only ensures that work starts when the pool has headroom.
+type AdmissionQueue struct {
+	pool    *MemoryMonitor
+	metrics AdmissionMetrics
+
+	mu struct {
+		syncutil.Mutex
+		// waiters is the list of pending Admit calls, in arrival order.
+		waiters list.List
+	}
+}
+
+// MakeAdmissionQueue creates an AdmissionQueue on the given pool. The
+// pool must be a standalone monitor (started without a pool), whose
+// capacity is its pre-reserved budget.
+func MakeAdmissionQueue(pool *MemoryMonitor, metrics AdmissionMetrics) *AdmissionQueue {
+	if pool.pool != nil {
+		panic(pool.name + ": admission queues require a standalone monitor")
+	}
+	return &AdmissionQueue{pool: pool, metrics: metrics}
+}
+
+// Metrics returns the metrics of the admission queue.
+func (q *AdmissionQueue) Metrics() AdmissionMetrics {
+	return q.metrics
+}
+
+// hasHeadroom returns whether the usage of the pool is below the given
+// fraction of its capacity.
+func (q *AdmissionQueue) hasHeadroom(threshold float64) bool {
+	capacity := q.pool.reserved.curAllocated
+	return float64(q.pool.AllocatedBytes()) < threshold*float64(capacity)
+}
+
+// Admit blocks until the pool's usage falls below threshold (a fraction
+// of its capacity) and all the callers that started waiting earlier have
+// been admitted. If threshold is 1 or greater, or if the pool already
+// has headroom and nobody is waiting, Admit returns immediately.
+//
+// If the caller must wait, onWait is called once before blocking. An
+// error is returned if the caller is still waiting after maxWait (a
+// non-positive maxWait disables waiting and rejects immediately), or if
+// the context is canceled.
+func (q *AdmissionQueue) Admit(
+	ctx context.Context, threshold float64, maxWait time.Duration, onWait func(),
+) error {
+	if threshold >= 1 {
+		return nil
+	}
+	q.mu.Lock()
+	if q.mu.waiters.Len() == 0 && q.hasHeadroom(threshold) {
+		q.mu.Unlock()
+		return nil
+	}
+	if maxWait <= 0 {
+		q.mu.Unlock()
+		q.metrics.Rejected.Inc(1)
+		return q.newAdmissionError(threshold)
+	}
+	e := q.mu.waiters.PushBack(struct{}{})
+	q.mu.Unlock()
+
+	q.metrics.Delayed.Inc(1)
+	q.metrics.Waiting.Inc(1)
+	start := timeutil.Now()
+	defer func() {
+		q.metrics.Waiting.Dec(1)
+		q.metrics.WaitLatency.RecordValue(timeutil.Since(start).Nanoseconds())
+	}()
+	if onWait != nil {
+		onWait()
+	}
+
+	timer := timeutil.NewTimer()
+	defer timer.Stop()
+	timer.Reset(maxWait)
+	for r := retry.Start(admissionRetryOptions); ; {
+		q.mu.Lock()
+		if q.mu.waiters.Front() == e && q.hasHeadroom(threshold) {
+			q.mu.waiters.Remove(e)
+			q.mu.Unlock()
+			return nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-r.NextCh():
+			continue
+		case <-timer.C:
+			timer.Read = true
+			q.metrics.Rejected.Inc(1)
+			err := q.newAdmissionError(threshold)
+			q.remove(e)
+			return err
+		case <-ctx.Done():
+			q.remove(e)
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *AdmissionQueue) remove(e *list.Element) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.waiters.Remove(e)
+}
+
+func (q *AdmissionQueue) newAdmissionError(threshold float64) error {
+	return pgerror.NewErrorf(pgerror.CodeOutOfMemoryError,
+		"%s: memory budget nearly exhausted (%s of %s in use, admission threshold %.0f%%); "+
+			"query not admitted",
+		q.pool.name,
+		humanizeutil.IBytes(q.pool.AllocatedBytes()),
+		humanizeutil.IBytes(q.pool.reserved.curAllocated),
+		threshold*100)
+}
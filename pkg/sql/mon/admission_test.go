@@ -0,0 +1,78 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"golang.org/x/net/context"
+)
+
+func TestAdmissionQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	pool := MakeMonitor("pool", nil, nil, 1, 1000)
+	pool.Start(ctx, nil, MakeStandaloneBudget(100))
+	defer pool.Stop(ctx)
+
+	q := MakeAdmissionQueue(&pool, MakeAdmissionMetrics(time.Minute))
+
+	// The pool has headroom: admission is immediate.
+	if err := q.Admit(ctx, 0.9, time.Minute, func() {
+		t.Error("unexpected wait")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	acc := pool.MakeBoundAccount()
+	if err := acc.Grow(ctx, 95); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool is above the threshold and memory is not released in time.
+	err := q.Admit(ctx, 0.9, 10*time.Millisecond, nil)
+	if pgErr, ok := pgerror.GetPGCause(err); !ok || pgErr.Code != pgerror.CodeOutOfMemoryError {
+		t.Fatalf("expected out of memory error, got %v", err)
+	}
+	if r := q.metrics.Rejected.Count(); r != 1 {
+		t.Fatalf("expected 1 rejection, got %d", r)
+	}
+
+	// A waiter is admitted once memory is released.
+	waiting := make(chan struct{})
+	errCh := make(chan error)
+	go func() {
+		errCh <- q.Admit(ctx, 0.9, time.Minute, func() { close(waiting) })
+	}()
+	<-waiting
+	if w := q.metrics.Waiting.Value(); w != 1 {
+		t.Fatalf("expected 1 waiter, got %d", w)
+	}
+	pool.ShrinkAccount(ctx, &acc.MemoryAccount, 50)
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if d := q.metrics.Delayed.Count(); d != 2 {
+		t.Fatalf("expected 2 delayed queries, got %d", d)
+	}
+	if w := q.metrics.Waiting.Value(); w != 0 {
+		t.Fatalf("expected no waiters, got %d", w)
+	}
+	acc.Close(ctx)
+}
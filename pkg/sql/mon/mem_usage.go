@@ -190,6 +190,12 @@ type MemoryMonitor struct {
 		// curBudget represents the budget allocated at the pool on behalf
 		// of this monitor.
 		curBudget MemoryAccount
+
+		// limit, if non-zero, caps curAllocated independently of the
+		// budget that the pool is able to provide. This is used to
+		// carve a bounded share out of a larger pool (e.g. per query or
+		// per user) without pre-reserving it.
+		limit int64
 	}
 
 	// name identifies this monitor in logging messages.
@@ -507,11 +513,19 @@ func (b *BoundAccount) Grow(ctx context.Context, x int64) error {
 	return b.mon.GrowAccount(ctx, &b.MemoryAccount, x)
 }
 
+// Shrink is an accessor for b.mon.ShrinkAccount.
+func (b *BoundAccount) Shrink(ctx context.Context, delta int64) {
+	b.mon.ShrinkAccount(ctx, &b.MemoryAccount, delta)
+}
+
 // reserveMemory declares an allocation to this monitor. An error is
 // returned if the allocation is denied.
 func (mm *MemoryMonitor) reserveMemory(ctx context.Context, x int64) error {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
+	if mm.mu.limit > 0 && mm.mu.curAllocated > mm.mu.limit-x {
+		return newMemoryError(mm.name, x, mm.mu.limit)
+	}
 	if mm.mu.curAllocated > mm.mu.curBudget.curAllocated+mm.reserved.curAllocated-x {
 		if err := mm.increaseBudget(ctx, x); err != nil {
 			return err
@@ -626,6 +640,51 @@ func (mm *MemoryMonitor) adjustBudget(ctx context.Context) {
 	}
 }
 
+// SetLimit caps the total amount of memory that can be allocated at
+// this monitor, regardless of how much the pool could provide. A limit
+// of 0 or lower removes the cap. Allocations already registered are not
+// affected; the limit only applies to subsequent growth.
+func (mm *MemoryMonitor) SetLimit(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.mu.limit = limit
+}
+
+// SetPool moves the monitor, along with the budget it holds, to another
+// pool. The monitor remains in its current pool if the new pool cannot
+// provide the budget.
+func (mm *MemoryMonitor) SetPool(ctx context.Context, pool *MemoryMonitor) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	var budget MemoryAccount
+	if err := pool.OpenAndInitAccount(ctx, &budget, mm.mu.curBudget.curAllocated); err != nil {
+		return err
+	}
+	mm.pool.ClearAccount(ctx, &mm.mu.curBudget)
+	mm.pool = pool
+	mm.mu.curBudget = budget
+	return nil
+}
+
+// AllocatedBytes returns the number of bytes currently allocated at
+// this monitor.
+func (mm *MemoryMonitor) AllocatedBytes() int64 {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.mu.curAllocated
+}
+
+// MaximumBytes returns the maximum number of bytes that were allocated by
+// this monitor at any time since it was started.
+func (mm *MemoryMonitor) MaximumBytes() int64 {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.mu.maxAllocated
+}
+
 // GetCurrentAllocationForTesting returns the number of bytes that have
 // currently been allocated in the MemoryMonitor. Intended for use in testing.
 func (mm *MemoryMonitor) GetCurrentAllocationForTesting() int64 {
@@ -301,3 +301,87 @@ func TestMemoryMonitor(t *testing.T) {
 
 	m.Stop(ctx)
 }
+
+func TestMemoryMonitorLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	pool := MakeMonitor("pool", nil, nil, 1, 1000)
+	pool.Start(ctx, nil, MakeStandaloneBudget(100))
+
+	m := MakeMonitor("test", nil, nil, 1, 1000)
+	m.Start(ctx, &pool, BoundAccount{})
+	m.SetLimit(30)
+
+	if err := m.reserveMemory(ctx, 20); err != nil {
+		t.Fatalf("monitor refused small allocation: %v", err)
+	}
+	if err := m.reserveMemory(ctx, 11); err == nil {
+		t.Fatalf("monitor accepted allocation beyond its limit")
+	}
+	if err := m.reserveMemory(ctx, 10); err != nil {
+		t.Fatalf("monitor refused allocation up to its limit: %v", err)
+	}
+
+	// Removing the limit falls back to the pool's capacity.
+	m.SetLimit(0)
+	if err := m.reserveMemory(ctx, 70); err != nil {
+		t.Fatalf("monitor refused allocation after limit removal: %v", err)
+	}
+	if err := m.reserveMemory(ctx, 1); err == nil {
+		t.Fatalf("monitor accepted allocation beyond the pool capacity")
+	}
+	if a := m.AllocatedBytes(); a != 100 {
+		t.Fatalf("incorrect current allocation: got %d, expected %d", a, 100)
+	}
+
+	m.releaseMemory(ctx, 100)
+	m.Stop(ctx)
+	pool.Stop(ctx)
+}
+
+func TestMemoryMonitorSetPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	pool1 := MakeMonitor("pool1", nil, nil, 1, 1000)
+	pool1.Start(ctx, nil, MakeStandaloneBudget(100))
+	pool2 := MakeMonitor("pool2", nil, nil, 1, 1000)
+	pool2.Start(ctx, nil, MakeStandaloneBudget(100))
+	pool2.SetLimit(50)
+
+	m := MakeMonitor("test", nil, nil, 1, 1000)
+	m.Start(ctx, &pool1, BoundAccount{})
+	if err := m.reserveMemory(ctx, 60); err != nil {
+		t.Fatalf("monitor refused small allocation: %v", err)
+	}
+
+	// The budget held by the monitor doesn't fit in the limit of pool2.
+	if err := m.SetPool(ctx, &pool2); err == nil {
+		t.Fatalf("monitor moved to a pool without enough budget")
+	}
+	if a := pool1.AllocatedBytes(); a != 60 {
+		t.Fatalf("incorrect allocation of the first pool: got %d, expected %d", a, 60)
+	}
+
+	m.releaseMemory(ctx, 20)
+	if err := m.SetPool(ctx, &pool2); err != nil {
+		t.Fatalf("monitor refused to move: %v", err)
+	}
+	if a := pool1.AllocatedBytes(); a != 0 {
+		t.Fatalf("incorrect allocation of the first pool: got %d, expected %d", a, 0)
+	}
+	if a := pool2.AllocatedBytes(); a != 40 {
+		t.Fatalf("incorrect allocation of the second pool: got %d, expected %d", a, 40)
+	}
+
+	// Subsequent allocations are requested from the new pool.
+	if err := m.reserveMemory(ctx, 11); err == nil {
+		t.Fatalf("monitor accepted allocation beyond the limit of its new pool")
+	}
+
+	m.releaseMemory(ctx, 40)
+	m.Stop(ctx)
+	pool1.Stop(ctx)
+	pool2.Stop(ctx)
+}
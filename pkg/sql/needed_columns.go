@@ -186,15 +186,30 @@ func setNeededColumns(plan planNode, needed []bool) {
 		setNeededColumns(n.rows, allColumns(n.rows))
 
 	case *alterTableNode:
+	case *alterTypeNode:
+	case *alterUserSetNode:
+	case *alterUserSetPasswordNode:
+	case *cancelQueryNode:
+	case *cancelSessionNode:
+	case *commentNode:
 	case *copyNode:
 	case *createDatabaseNode:
+	case *createFunctionNode:
 	case *createIndexNode:
+	case *createPolicyNode:
+	case *createStatsNode:
+	case *createTriggerNode:
+	case *createTypeNode:
 	case *createUserNode:
 	case *delayedNode:
 	case *dropDatabaseNode:
+	case *dropFunctionNode:
 	case *dropIndexNode:
+	case *dropPolicyNode:
+	case *dropTriggerNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *dropTypeNode:
 	case *dropUserNode:
 	case *emptyNode:
 	case *hookFnNode:
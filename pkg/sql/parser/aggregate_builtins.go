@@ -1068,7 +1068,9 @@ func (v *IsAggregateVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
 		}
 		fd, err := t.Func.Resolve(v.searchPath)
 		if err != nil {
-			return false, expr
+			// The name may refer to a user-defined function, which is never
+			// an aggregate but whose arguments can contain aggregates.
+			return true, expr
 		}
 		if _, ok := Aggregates[fd.Name]; ok {
 			v.Aggregated = true
@@ -0,0 +1,53 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "bytes"
+
+// AlterTypeAddValue represents an ALTER TYPE ... ADD VALUE statement.
+type AlterTypeAddValue struct {
+	Type        NormalizableTableName
+	IfNotExists bool
+	NewVal      string
+	// Placement is nil when the new value is appended after all existing
+	// values.
+	Placement *AlterTypeAddValuePlacement
+}
+
+// AlterTypeAddValuePlacement represents the optional BEFORE/AFTER clause
+// of an ALTER TYPE ... ADD VALUE statement.
+type AlterTypeAddValuePlacement struct {
+	Before      bool
+	ExistingVal string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTypeAddValue) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER TYPE ")
+	FormatNode(buf, f, node.Type)
+	buf.WriteString(" ADD VALUE ")
+	if node.IfNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	encodeSQLStringWithFlags(buf, node.NewVal, f)
+	if node.Placement != nil {
+		if node.Placement.Before {
+			buf.WriteString(" BEFORE ")
+		} else {
+			buf.WriteString(" AFTER ")
+		}
+		encodeSQLStringWithFlags(buf, node.Placement.ExistingVal, f)
+	}
+}
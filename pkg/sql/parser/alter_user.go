@@ -0,0 +1,61 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "bytes"
+
+// AlterUserSet represents an ALTER USER ... SET or ALTER USER ... RESET
+// statement, which configures the default value of a session variable for
+// the sessions of a user.
+type AlterUserSet struct {
+	Name    Name
+	VarName UnresolvedName
+	// Values is nil when the user's default is removed, with RESET or with
+	// SET ... TO DEFAULT.
+	Values Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterUserSet) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER USER ")
+	FormatNode(buf, f, node.Name)
+	if node.Values == nil {
+		buf.WriteString(" RESET ")
+		FormatNode(buf, f, node.VarName)
+		return
+	}
+	buf.WriteString(" SET ")
+	FormatNode(buf, f, node.VarName)
+	buf.WriteString(" = ")
+	FormatNode(buf, f, node.Values)
+}
+
+// AlterUserSetPassword represents an ALTER USER ... WITH PASSWORD statement.
+type AlterUserSetPassword struct {
+	Name     Name
+	Password string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterUserSetPassword) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ALTER USER ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" WITH PASSWORD ")
+	if f.showPasswords {
+		encodeSQLString(buf, node.Password)
+	} else {
+		buf.WriteString("*****")
+	}
+}
@@ -1607,6 +1607,40 @@ var Builtins = map[string][]Builtin{
 		},
 	},
 
+	"current_user": {
+		Builtin{
+			Types:            ArgTypes{},
+			ReturnType:       fixedReturnType(TypeString),
+			category:         categorySystemInfo,
+			distsqlBlacklist: true,
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				if len(ctx.User) == 0 {
+					return DNull, nil
+				}
+				return NewDString(ctx.User), nil
+			},
+			Info: "Returns the user of the current session.",
+		},
+	},
+
+	"session_user": {
+		Builtin{
+			Types:            ArgTypes{},
+			ReturnType:       fixedReturnType(TypeString),
+			category:         categorySystemInfo,
+			distsqlBlacklist: true,
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				if len(ctx.User) == 0 {
+					return DNull, nil
+				}
+				return NewDString(ctx.User), nil
+			},
+			Info: "Returns the user of the current session. This function is " +
+				"provided for compatibility with PostgreSQL and is equivalent to " +
+				"current_user().",
+		},
+	},
+
 	// For now, schemas are the same as databases. So, current_schemas
 	// returns the current database (if one has been set by the user)
 	// and the session's database search path.
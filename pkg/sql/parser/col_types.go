@@ -57,6 +57,7 @@ func (*CollatedStringColType) columnType() {}
 func (*ArrayColType) columnType()          {}
 func (*VectorColType) columnType()         {}
 func (*OidColType) columnType()            {}
+func (*UserDefinedColType) columnType()    {}
 
 // All ColumnTypes also implement CastTargetType.
 func (*BoolColType) castTargetType()           {}
@@ -75,6 +76,7 @@ func (*CollatedStringColType) castTargetType() {}
 func (*ArrayColType) castTargetType()          {}
 func (*VectorColType) castTargetType()         {}
 func (*OidColType) castTargetType()            {}
+func (*UserDefinedColType) castTargetType()    {}
 
 // Pre-allocated immutable boolean column types.
 var (
@@ -467,6 +469,19 @@ func oidTypeToColType(t Type) *OidColType {
 	}
 }
 
+// UserDefinedColType represents a reference to a user-defined type, such as
+// an ENUM created with CREATE TYPE. The referenced type is resolved during
+// semantic analysis (see SemaContext.TypeResolver), after which Typ is set.
+type UserDefinedColType struct {
+	Name NormalizableTableName
+	Typ  Type
+}
+
+// Format implements the NodeFormatter interface.
+func (node *UserDefinedColType) Format(buf *bytes.Buffer, f FmtFlags) {
+	FormatNode(buf, f, &node.Name)
+}
+
 func (node *BoolColType) String() string           { return AsString(node) }
 func (node *IntColType) String() string            { return AsString(node) }
 func (node *FloatColType) String() string          { return AsString(node) }
@@ -483,6 +498,7 @@ func (node *CollatedStringColType) String() string { return AsString(node) }
 func (node *ArrayColType) String() string          { return AsString(node) }
 func (node *VectorColType) String() string         { return AsString(node) }
 func (node *OidColType) String() string            { return AsString(node) }
+func (node *UserDefinedColType) String() string    { return AsString(node) }
 
 // DatumTypeToColumnType produces a SQL column type equivalent to the
 // given Datum type. Used to generate CastExpr nodes during
@@ -533,6 +549,13 @@ func DatumTypeToColumnType(t Type) (ColumnType, error) {
 		return arrayOf(elemTyp, Exprs(nil))
 	case tOidWrapper:
 		return DatumTypeToColumnType(typ.Type)
+	case *TEnum:
+		if typ.TypeID != 0 {
+			return &UserDefinedColType{
+				Name: NormalizableTableName{TableNameReference: UnresolvedName{Name(typ.Name)}},
+				Typ:  typ,
+			}, nil
+		}
 	}
 
 	return nil, errors.Errorf("value type %s cannot be used for table columns", t)
@@ -574,6 +597,11 @@ func CastTargetToDatumType(t CastTargetType) Type {
 		return TypeIntVector
 	case *OidColType:
 		return oidColTypeToType(ct)
+	case *UserDefinedColType:
+		if ct.Typ == nil {
+			panic(errors.Errorf("unresolved type %s", ct))
+		}
+		return ct.Typ
 	default:
 		panic(errors.Errorf("unexpected CastTarget %T", t))
 	}
@@ -0,0 +1,82 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "bytes"
+
+// CommentOnDatabase represents a COMMENT ON DATABASE statement.
+type CommentOnDatabase struct {
+	Name Name
+	// Comment is nil when the comment is being removed.
+	Comment *string
+}
+
+// Format implements the NodeFormatter interface.
+func (n *CommentOnDatabase) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("COMMENT ON DATABASE ")
+	FormatNode(buf, f, n.Name)
+	formatComment(buf, f, n.Comment)
+}
+
+// CommentOnTable represents a COMMENT ON TABLE statement.
+type CommentOnTable struct {
+	Table NormalizableTableName
+	// Comment is nil when the comment is being removed.
+	Comment *string
+}
+
+// Format implements the NodeFormatter interface.
+func (n *CommentOnTable) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("COMMENT ON TABLE ")
+	FormatNode(buf, f, n.Table)
+	formatComment(buf, f, n.Comment)
+}
+
+// CommentOnColumn represents a COMMENT ON COLUMN statement.
+type CommentOnColumn struct {
+	*ColumnItem
+	// Comment is nil when the comment is being removed.
+	Comment *string
+}
+
+// Format implements the NodeFormatter interface.
+func (n *CommentOnColumn) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("COMMENT ON COLUMN ")
+	FormatNode(buf, f, n.ColumnItem)
+	formatComment(buf, f, n.Comment)
+}
+
+// CommentOnIndex represents a COMMENT ON INDEX statement.
+type CommentOnIndex struct {
+	Index *TableNameWithIndex
+	// Comment is nil when the comment is being removed.
+	Comment *string
+}
+
+// Format implements the NodeFormatter interface.
+func (n *CommentOnIndex) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("COMMENT ON INDEX ")
+	FormatNode(buf, f, n.Index)
+	formatComment(buf, f, n.Comment)
+}
+
+func formatComment(buf *bytes.Buffer, f FmtFlags, comment *string) {
+	buf.WriteString(" IS ")
+	if comment == nil {
+		buf.WriteString("NULL")
+		return
+	}
+	encodeSQLStringWithFlags(buf, *comment, f)
+}
@@ -369,6 +369,7 @@ var (
 		TypeTimestampTZ,
 		TypeInterval,
 		TypeUUID,
+		TypeEnum,
 	}
 	strValAvailBytesString = []Type{TypeBytes, TypeString, TypeUUID}
 	strValAvailBytes       = []Type{TypeBytes, TypeUUID}
@@ -441,6 +442,13 @@ func (expr *StrVal) ResolveAsType(ctx *SemaContext, typ Type) (Datum, error) {
 		}
 		return ParseDUuidFromString(expr.s)
 	default:
+		if t, ok := UnwrapType(typ).(*TEnum); ok {
+			if t.IsAmbiguous() {
+				// Without the type's members the label cannot be resolved.
+				return nil, makeParseError(expr.s, typ, nil)
+			}
+			return NewDEnumFromLogicalRep(t, expr.s)
+		}
 		return nil, fmt.Errorf("could not resolve %T %v into a %T", expr, expr, typ)
 	}
 }
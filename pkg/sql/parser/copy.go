@@ -23,6 +23,7 @@ type CopyFrom struct {
 	Table   NormalizableTableName
 	Columns UnresolvedNames
 	Stdin   bool
+	Options KVOptions
 }
 
 // Format implements the NodeFormatter interface.
@@ -38,4 +39,53 @@ func (node *CopyFrom) Format(buf *bytes.Buffer, f FmtFlags) {
 	if node.Stdin {
 		buf.WriteString("STDIN")
 	}
+	formatCopyOptions(buf, f, node.Options)
+}
+
+// CopyTo represents a COPY TO statement. Either Table, with optional
+// Columns, or Select is set.
+type CopyTo struct {
+	Table   NormalizableTableName
+	Columns UnresolvedNames
+	Select  *Select
+	Options KVOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CopyTo) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("COPY ")
+	if node.Select != nil {
+		buf.WriteByte('(')
+		FormatNode(buf, f, node.Select)
+		buf.WriteByte(')')
+	} else {
+		FormatNode(buf, f, node.Table)
+		if len(node.Columns) > 0 {
+			buf.WriteString(" (")
+			FormatNode(buf, f, node.Columns)
+			buf.WriteString(")")
+		}
+	}
+	buf.WriteString(" TO STDOUT")
+	formatCopyOptions(buf, f, node.Options)
+}
+
+// formatCopyOptions formats the WITH clause of a COPY statement. Unlike
+// the options of BACKUP and RESTORE, the option names are identifiers.
+func formatCopyOptions(buf *bytes.Buffer, f FmtFlags, opts KVOptions) {
+	if len(opts) == 0 {
+		return
+	}
+	buf.WriteString(" WITH (")
+	for i, o := range opts {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, Name(o.Key))
+		if o.Value != "" {
+			buf.WriteByte(' ')
+			encodeSQLStringWithFlags(buf, o.Value, f)
+		}
+	}
+	buf.WriteByte(')')
 }
@@ -102,6 +102,7 @@ type CreateIndex struct {
 	Unique      bool
 	IfNotExists bool
 	Columns     IndexElemList
+	Sharded     *ShardedIndexDef
 	// Extra columns to be stored together with the indexed ones as an optimization
 	// for improved reading performance.
 	Storing    NameList
@@ -127,6 +128,9 @@ func (node *CreateIndex) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString(" (")
 	FormatNode(buf, f, node.Columns)
 	buf.WriteByte(')')
+	if node.Sharded != nil {
+		FormatNode(buf, f, node.Sharded)
+	}
 	if len(node.Storing) > 0 {
 		buf.WriteString(" STORING (")
 		FormatNode(buf, f, node.Storing)
@@ -433,6 +437,7 @@ type ColumnFamilyConstraint struct {
 type IndexTableDef struct {
 	Name       Name
 	Columns    IndexElemList
+	Sharded    *ShardedIndexDef
 	Storing    NameList
 	Interleave *InterleaveDef
 }
@@ -451,6 +456,9 @@ func (node *IndexTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteByte('(')
 	FormatNode(buf, f, node.Columns)
 	buf.WriteByte(')')
+	if node.Sharded != nil {
+		FormatNode(buf, f, node.Sharded)
+	}
 	if node.Storing != nil {
 		buf.WriteString(" STORING (")
 		FormatNode(buf, f, node.Storing)
@@ -494,6 +502,9 @@ func (node *UniqueConstraintTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteByte('(')
 	FormatNode(buf, f, node.Columns)
 	buf.WriteByte(')')
+	if node.Sharded != nil {
+		FormatNode(buf, f, node.Sharded)
+	}
 	if node.Storing != nil {
 		buf.WriteString(" STORING (")
 		FormatNode(buf, f, node.Storing)
@@ -588,6 +599,18 @@ func (node *FamilyTableDef) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteByte(')')
 }
 
+// ShardedIndexDef represents a hash sharding definition within a CREATE
+// TABLE or CREATE INDEX statement.
+type ShardedIndexDef struct {
+	ShardBuckets Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShardedIndexDef) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString(" USING HASH WITH BUCKET_COUNT = ")
+	FormatNode(buf, f, node.ShardBuckets)
+}
+
 // InterleaveDef represents an interleave definition within a CREATE TABLE
 // or CREATE INDEX statement.
 type InterleaveDef struct {
@@ -702,3 +725,309 @@ func (node *CreateView) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString(" AS ")
 	FormatNode(buf, f, node.AsSource)
 }
+
+// CreateType represents a CREATE TYPE statement.
+type CreateType struct {
+	Name       NormalizableTableName
+	EnumLabels []string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateType) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE TYPE ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" AS ENUM (")
+	for i, label := range node.EnumLabels {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		encodeSQLStringWithFlags(buf, label, f)
+	}
+	buf.WriteByte(')')
+}
+
+// Volatility indicates whether the result of a function depends only on its
+// arguments.
+type Volatility int
+
+// Volatility values
+const (
+	UnspecifiedVolatility Volatility = iota
+	// Volatile functions can return different results for the same
+	// arguments, even within a single statement.
+	Volatile
+	// Stable functions return the same result for the same arguments within
+	// a single statement.
+	Stable
+	// Immutable functions always return the same result for the same
+	// arguments.
+	Immutable
+)
+
+var volatilityNames = [...]string{
+	UnspecifiedVolatility: "UNSPECIFIED",
+	Volatile:              "VOLATILE",
+	Stable:                "STABLE",
+	Immutable:             "IMMUTABLE",
+}
+
+func (v Volatility) String() string {
+	if v < 0 || v > Volatility(len(volatilityNames)-1) {
+		return fmt.Sprintf("Volatility(%d)", v)
+	}
+	return volatilityNames[v]
+}
+
+// FunctionOptions holds the options of a CREATE FUNCTION statement.
+type FunctionOptions struct {
+	Volatility Volatility
+}
+
+// Format implements the NodeFormatter interface.
+func (node *FunctionOptions) Format(buf *bytes.Buffer, f FmtFlags) {
+	if node.Volatility != UnspecifiedVolatility {
+		fmt.Fprintf(buf, " %s", node.Volatility)
+	}
+}
+
+func (node *FunctionOptions) merge(other FunctionOptions) error {
+	if other.Volatility != UnspecifiedVolatility {
+		if node.Volatility != UnspecifiedVolatility {
+			return errors.New("conflicting or redundant volatility options")
+		}
+		node.Volatility = other.Volatility
+	}
+	return nil
+}
+
+// FunctionParam is a parameter in a CREATE FUNCTION statement.
+type FunctionParam struct {
+	Name Name
+	Type ColumnType
+}
+
+// Format implements the NodeFormatter interface.
+func (node *FunctionParam) Format(buf *bytes.Buffer, f FmtFlags) {
+	FormatNode(buf, f, node.Name)
+	buf.WriteByte(' ')
+	FormatNode(buf, f, node.Type)
+}
+
+// FunctionParams is a list of parameters in a CREATE FUNCTION statement.
+type FunctionParams []FunctionParam
+
+// Format implements the NodeFormatter interface.
+func (node FunctionParams) Format(buf *bytes.Buffer, f FmtFlags) {
+	for i := range node {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, &node[i])
+	}
+}
+
+// CreateFunction represents a CREATE FUNCTION statement. Only functions
+// written in SQL are supported.
+type CreateFunction struct {
+	Name       NormalizableTableName
+	Params     FunctionParams
+	ReturnType ColumnType
+	// ReturnsTrigger is set for RETURNS TRIGGER, in which case ReturnType is
+	// nil.
+	ReturnsTrigger bool
+	Options        FunctionOptions
+	Body           string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateFunction) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE FUNCTION ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteByte('(')
+	FormatNode(buf, f, node.Params)
+	buf.WriteString(") RETURNS ")
+	if node.ReturnsTrigger {
+		buf.WriteString("TRIGGER")
+	} else {
+		FormatNode(buf, f, node.ReturnType)
+	}
+	FormatNode(buf, f, &node.Options)
+	buf.WriteString(" LANGUAGE SQL AS ")
+	encodeSQLStringWithFlags(buf, node.Body, f)
+}
+
+// PolicyCommand is the command to which a row-level security policy
+// applies.
+type PolicyCommand int
+
+// The values for PolicyCommand.
+const (
+	PolicyAll PolicyCommand = iota
+	PolicySelect
+	PolicyInsert
+	PolicyUpdate
+	PolicyDelete
+)
+
+var policyCommandName = [...]string{
+	PolicyAll:    "ALL",
+	PolicySelect: "SELECT",
+	PolicyInsert: "INSERT",
+	PolicyUpdate: "UPDATE",
+	PolicyDelete: "DELETE",
+}
+
+func (c PolicyCommand) String() string {
+	return policyCommandName[c]
+}
+
+// CreatePolicy represents a CREATE POLICY statement.
+type CreatePolicy struct {
+	Name    Name
+	Table   NormalizableTableName
+	Command PolicyCommand
+	// Roles is the list of users to which the policy applies. An empty list
+	// means all users.
+	Roles     NameList
+	Using     Expr
+	WithCheck Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreatePolicy) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE POLICY ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" ON ")
+	FormatNode(buf, f, node.Table)
+	if node.Command != PolicyAll {
+		buf.WriteString(" FOR ")
+		buf.WriteString(node.Command.String())
+	}
+	if len(node.Roles) > 0 {
+		buf.WriteString(" TO ")
+		FormatNode(buf, f, node.Roles)
+	}
+	if node.Using != nil {
+		buf.WriteString(" USING (")
+		FormatNode(buf, f, node.Using)
+		buf.WriteByte(')')
+	}
+	if node.WithCheck != nil {
+		buf.WriteString(" WITH CHECK (")
+		FormatNode(buf, f, node.WithCheck)
+		buf.WriteByte(')')
+	}
+}
+
+// TriggerTiming is the point, relative to the triggering statement or row,
+// at which a trigger fires.
+type TriggerTiming int
+
+// The values for TriggerTiming.
+const (
+	TriggerBefore TriggerTiming = iota
+	TriggerAfter
+)
+
+var triggerTimingName = [...]string{
+	TriggerBefore: "BEFORE",
+	TriggerAfter:  "AFTER",
+}
+
+func (t TriggerTiming) String() string {
+	return triggerTimingName[t]
+}
+
+// TriggerEvent is a kind of statement which fires a trigger.
+type TriggerEvent int
+
+// The values for TriggerEvent.
+const (
+	TriggerInsert TriggerEvent = iota
+	TriggerUpdate
+	TriggerDelete
+)
+
+var triggerEventName = [...]string{
+	TriggerInsert: "INSERT",
+	TriggerUpdate: "UPDATE",
+	TriggerDelete: "DELETE",
+}
+
+func (e TriggerEvent) String() string {
+	return triggerEventName[e]
+}
+
+// TriggerEvents is a list of events in a CREATE TRIGGER statement.
+type TriggerEvents []TriggerEvent
+
+// Format implements the NodeFormatter interface.
+func (node TriggerEvents) Format(buf *bytes.Buffer, f FmtFlags) {
+	for i, e := range node {
+		if i > 0 {
+			buf.WriteString(" OR ")
+		}
+		buf.WriteString(e.String())
+	}
+}
+
+// CreateTrigger represents a CREATE TRIGGER statement.
+type CreateTrigger struct {
+	Name       Name
+	Timing     TriggerTiming
+	Events     TriggerEvents
+	Table      NormalizableTableName
+	ForEachRow bool
+	Func       NormalizableTableName
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateTrigger) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE TRIGGER ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteByte(' ')
+	buf.WriteString(node.Timing.String())
+	buf.WriteByte(' ')
+	FormatNode(buf, f, node.Events)
+	buf.WriteString(" ON ")
+	FormatNode(buf, f, node.Table)
+	if node.ForEachRow {
+		buf.WriteString(" FOR EACH ROW")
+	} else {
+		buf.WriteString(" FOR EACH STATEMENT")
+	}
+	buf.WriteString(" EXECUTE PROCEDURE ")
+	FormatNode(buf, f, node.Func)
+	buf.WriteString("()")
+}
+
+// CreateStats represents a CREATE STATISTICS statement.
+type CreateStats struct {
+	Name        Name
+	ColumnNames NameList
+	Table       NormalizableTableName
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateStats) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE STATISTICS ")
+	FormatNode(buf, f, node.Name)
+	if len(node.ColumnNames) > 0 {
+		buf.WriteString(" ON ")
+		FormatNode(buf, f, node.ColumnNames)
+	}
+	buf.WriteString(" FROM ")
+	FormatNode(buf, f, node.Table)
+}
+
+// Analyze represents an ANALYZE statement, which collects statistics on all
+// the columns of a table.
+type Analyze struct {
+	Table NormalizableTableName
+}
+
+// Format implements the NodeFormatter interface.
+func (node *Analyze) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("ANALYZE ")
+	FormatNode(buf, f, node.Table)
+}
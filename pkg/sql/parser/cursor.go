@@ -0,0 +1,81 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+)
+
+// DeclareCursor represents a DECLARE ... CURSOR statement.
+type DeclareCursor struct {
+	Name   Name
+	Select *Select
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DeclareCursor) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DECLARE ")
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" CURSOR FOR ")
+	FormatNode(buf, f, node.Select)
+}
+
+// FetchAll is the Count of a FETCH or MOVE statement that retrieves all the
+// remaining rows of a cursor.
+const FetchAll = math.MaxInt64
+
+// FetchCursor represents a FETCH or MOVE statement. Only forward fetches are
+// supported: NEXT and FORWARD are normalized to a Count of 1, and ALL and
+// FORWARD ALL to FetchAll.
+type FetchCursor struct {
+	Name  Name
+	Count int64
+	// Move is set for MOVE, which positions the cursor without returning
+	// rows.
+	Move bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *FetchCursor) Format(buf *bytes.Buffer, f FmtFlags) {
+	if node.Move {
+		buf.WriteString("MOVE ")
+	} else {
+		buf.WriteString("FETCH ")
+	}
+	if node.Count == FetchAll {
+		buf.WriteString("ALL")
+	} else {
+		buf.WriteString(strconv.FormatInt(node.Count, 10))
+	}
+	buf.WriteString(" FROM ")
+	FormatNode(buf, f, node.Name)
+}
+
+// CloseCursor represents a CLOSE statement.
+type CloseCursor struct {
+	Name Name // empty for ALL
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CloseCursor) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CLOSE ")
+	if node.Name == "" {
+		buf.WriteString("ALL")
+	} else {
+		FormatNode(buf, f, node.Name)
+	}
+}
@@ -36,6 +36,7 @@ import (
 
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -889,6 +890,117 @@ func (d *DCollatedString) IsComposite() bool {
 	return true
 }
 
+// DEnum is the Datum for values of a user-defined ENUM type. The struct
+// members are intended to be immutable.
+type DEnum struct {
+	Typ *TEnum
+	// PhysicalRep is the order-preserving representation of the value; it is
+	// what is stored on disk and what values are compared by.
+	PhysicalRep []byte
+	// LogicalRep is the label of the value.
+	LogicalRep string
+}
+
+// NewDEnumFromLogicalRep returns the value of typ with the given label.
+func NewDEnumFromLogicalRep(typ *TEnum, s string) (*DEnum, error) {
+	idx := typ.memberByLogicalRep(s)
+	if idx < 0 {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidTextRepresentationError,
+			"invalid input value for enum %s: %q", typ.Name, s)
+	}
+	return newDEnumFromMember(typ, idx), nil
+}
+
+// NewDEnumFromPhysicalRep returns the value of typ with the given physical
+// representation.
+func NewDEnumFromPhysicalRep(typ *TEnum, b []byte) (*DEnum, error) {
+	idx := typ.memberByPhysicalRep(b)
+	if idx < 0 {
+		return nil, errors.Errorf("could not find %v in enum %s", b, typ.Name)
+	}
+	return newDEnumFromMember(typ, idx), nil
+}
+
+func newDEnumFromMember(typ *TEnum, idx int) *DEnum {
+	m := &typ.Members[idx]
+	return &DEnum{Typ: typ, PhysicalRep: m.PhysicalRep, LogicalRep: m.LogicalRep}
+}
+
+// AmbiguousFormat implements the Datum interface.
+func (*DEnum) AmbiguousFormat() bool { return false }
+
+// Format implements the NodeFormatter interface.
+func (d *DEnum) Format(buf *bytes.Buffer, f FmtFlags) {
+	encodeSQLStringWithFlags(buf, d.LogicalRep, f)
+}
+
+// ResolvedType implements the TypedExpr interface.
+func (d *DEnum) ResolvedType() Type {
+	return d.Typ
+}
+
+// Compare implements the Datum interface.
+func (d *DEnum) Compare(ctx *EvalContext, other Datum) int {
+	if other == DNull {
+		// NULL is less than any non-NULL value.
+		return 1
+	}
+	v, ok := UnwrapDatum(other).(*DEnum)
+	if !ok || !d.Typ.Equivalent(v.Typ) {
+		panic(makeUnsupportedComparisonMessage(d, other))
+	}
+	return bytes.Compare(d.PhysicalRep, v.PhysicalRep)
+}
+
+// Prev implements the Datum interface.
+func (d *DEnum) Prev() (Datum, bool) {
+	idx := d.Typ.memberByPhysicalRep(d.PhysicalRep)
+	if idx <= 0 {
+		return nil, false
+	}
+	return newDEnumFromMember(d.Typ, idx-1), true
+}
+
+// Next implements the Datum interface.
+func (d *DEnum) Next() (Datum, bool) {
+	idx := d.Typ.memberByPhysicalRep(d.PhysicalRep)
+	if idx < 0 || idx == len(d.Typ.Members)-1 {
+		return nil, false
+	}
+	return newDEnumFromMember(d.Typ, idx+1), true
+}
+
+// IsMax implements the Datum interface.
+func (d *DEnum) IsMax() bool {
+	return d.Typ.memberByPhysicalRep(d.PhysicalRep) == len(d.Typ.Members)-1
+}
+
+// IsMin implements the Datum interface.
+func (d *DEnum) IsMin() bool {
+	return d.Typ.memberByPhysicalRep(d.PhysicalRep) == 0
+}
+
+// min implements the Datum interface.
+func (d *DEnum) min() (Datum, bool) {
+	if len(d.Typ.Members) == 0 {
+		return nil, false
+	}
+	return newDEnumFromMember(d.Typ, 0), true
+}
+
+// max implements the Datum interface.
+func (d *DEnum) max() (Datum, bool) {
+	if len(d.Typ.Members) == 0 {
+		return nil, false
+	}
+	return newDEnumFromMember(d.Typ, len(d.Typ.Members)-1), true
+}
+
+// Size implements the Datum interface.
+func (d *DEnum) Size() uintptr {
+	return unsafe.Sizeof(*d) + uintptr(len(d.PhysicalRep)) + uintptr(len(d.LogicalRep))
+}
+
 // DBytes is the bytes Datum. The underlying type is a string because we want
 // the immutability, but this may contain arbitrary bytes.
 type DBytes string
@@ -133,3 +133,114 @@ func (node *DropUser) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 	FormatNode(buf, f, node.Names)
 }
+
+// DropType represents a DROP TYPE statement.
+type DropType struct {
+	Names        TableNameReferences
+	IfExists     bool
+	DropBehavior DropBehavior
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropType) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DROP TYPE ")
+	if node.IfExists {
+		buf.WriteString("IF EXISTS ")
+	}
+	FormatNode(buf, f, node.Names)
+	if node.DropBehavior != DropDefault {
+		buf.WriteByte(' ')
+		buf.WriteString(node.DropBehavior.String())
+	}
+}
+
+// FuncObj names a function in a DROP FUNCTION statement. ArgTypes is nil if
+// the argument types were not specified.
+type FuncObj struct {
+	Name     NormalizableTableName
+	ArgTypes []ColumnType
+}
+
+// Format implements the NodeFormatter interface.
+func (node *FuncObj) Format(buf *bytes.Buffer, f FmtFlags) {
+	FormatNode(buf, f, node.Name)
+	if node.ArgTypes != nil {
+		buf.WriteByte('(')
+		for i, typ := range node.ArgTypes {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			FormatNode(buf, f, typ)
+		}
+		buf.WriteByte(')')
+	}
+}
+
+// FuncObjs is a list of functions in a DROP FUNCTION statement.
+type FuncObjs []FuncObj
+
+// Format implements the NodeFormatter interface.
+func (node FuncObjs) Format(buf *bytes.Buffer, f FmtFlags) {
+	for i := range node {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, &node[i])
+	}
+}
+
+// DropFunction represents a DROP FUNCTION statement.
+type DropFunction struct {
+	Functions    FuncObjs
+	IfExists     bool
+	DropBehavior DropBehavior
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropFunction) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DROP FUNCTION ")
+	if node.IfExists {
+		buf.WriteString("IF EXISTS ")
+	}
+	FormatNode(buf, f, node.Functions)
+	if node.DropBehavior != DropDefault {
+		buf.WriteByte(' ')
+		buf.WriteString(node.DropBehavior.String())
+	}
+}
+
+// DropPolicy represents a DROP POLICY statement.
+type DropPolicy struct {
+	Name     Name
+	Table    NormalizableTableName
+	IfExists bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropPolicy) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DROP POLICY ")
+	if node.IfExists {
+		buf.WriteString("IF EXISTS ")
+	}
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" ON ")
+	FormatNode(buf, f, node.Table)
+}
+
+// DropTrigger represents a DROP TRIGGER statement.
+type DropTrigger struct {
+	Name     Name
+	Table    NormalizableTableName
+	IfExists bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropTrigger) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DROP TRIGGER ")
+	if node.IfExists {
+		buf.WriteString("IF EXISTS ")
+	}
+	FormatNode(buf, f, node.Name)
+	buf.WriteString(" ON ")
+	FormatNode(buf, f, node.Table)
+}
@@ -1030,6 +1030,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeCollatedString,
 			fn:        cmpOpScalarEQFn,
 		},
+		CmpOp{
+			LeftType:  TypeEnum,
+			RightType: TypeEnum,
+			fn:        cmpOpScalarEQFn,
+		},
 		CmpOp{
 			LeftType:  TypeBytes,
 			RightType: TypeBytes,
@@ -1165,6 +1170,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeCollatedString,
 			fn:        cmpOpScalarLTFn,
 		},
+		CmpOp{
+			LeftType:  TypeEnum,
+			RightType: TypeEnum,
+			fn:        cmpOpScalarLTFn,
+		},
 		CmpOp{
 			LeftType:  TypeBytes,
 			RightType: TypeBytes,
@@ -1295,6 +1305,11 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 			RightType: TypeCollatedString,
 			fn:        cmpOpScalarLEFn,
 		},
+		CmpOp{
+			LeftType:  TypeEnum,
+			RightType: TypeEnum,
+			fn:        cmpOpScalarLEFn,
+		},
 		CmpOp{
 			LeftType:  TypeBytes,
 			RightType: TypeBytes,
@@ -1421,6 +1436,7 @@ var CmpOps = map[ComparisonOperator]cmpOpOverload{
 		makeEvalTupleIn(TypeDecimal),
 		makeEvalTupleIn(TypeString),
 		makeEvalTupleIn(TypeCollatedString),
+		makeEvalTupleIn(TypeEnum),
 		makeEvalTupleIn(TypeBytes),
 		makeEvalTupleIn(TypeDate),
 		makeEvalTupleIn(TypeTimestamp),
@@ -1729,6 +1745,8 @@ type EvalContext struct {
 	Location **time.Location
 	// Database is the database in the current Session.
 	Database string
+	// User is the user of the current Session.
+	User string
 	// SearchPath is the search path for databases used when encountering an
 	// unqualified table name. Names in the search path are normalized already.
 	// This must not be modified (this is shared from the session).
@@ -2268,6 +2286,8 @@ func (expr *CastExpr) Eval(ctx *EvalContext) (Datum, error) {
 			s = string(*t)
 		case *DCollatedString:
 			s = t.Contents
+		case *DEnum:
+			s = t.LogicalRep
 		case *DBytes:
 			if !utf8.ValidString(string(*t)) {
 				return nil, fmt.Errorf("invalid utf8: %q", string(*t))
@@ -2383,6 +2403,20 @@ func (expr *CastExpr) Eval(ctx *EvalContext) (Datum, error) {
 		case *DInterval:
 			return d, nil
 		}
+	case *UserDefinedColType:
+		if t, ok := typ.Typ.(*TEnum); ok {
+			switch v := d.(type) {
+			case *DString:
+				return NewDEnumFromLogicalRep(t, string(*v))
+			case *DCollatedString:
+				return NewDEnumFromLogicalRep(t, v.Contents)
+			case *DEnum:
+				if t.Equivalent(v.Typ) {
+					return d, nil
+				}
+			}
+		}
+
 	case *OidColType:
 		switch v := d.(type) {
 		case *DOid:
@@ -2446,6 +2480,10 @@ func (expr *CastExpr) Eval(ctx *EvalContext) (Datum, error) {
 				return queryOid(ctx, typ, NewDString(funcDef.Name))
 			case oidColTypeRegType:
 				colType, err := ParseType(s)
+				if _, ok := colType.(*UserDefinedColType); ok {
+					// User-defined types can only be found in pg_type.
+					err = errors.Errorf("unknown type %s", s)
+				}
 				if err == nil {
 					datumType := CastTargetToDatumType(colType)
 					return &DOid{kind: typ, DInt: DInt(datumType.Oid()), name: datumType.SQLName()}, nil
@@ -2896,6 +2934,11 @@ func (t *DCollatedString) Eval(_ *EvalContext) (Datum, error) {
 	return t, nil
 }
 
+// Eval implements the TypedExpr interface.
+func (t *DEnum) Eval(_ *EvalContext) (Datum, error) {
+	return t, nil
+}
+
 // Eval implements the TypedExpr interface.
 func (t *DTimestamp) Eval(_ *EvalContext) (Datum, error) {
 	return t, nil
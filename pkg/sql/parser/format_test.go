@@ -37,6 +37,10 @@ func TestFormatStatement(t *testing.T) {
 			`CREATE USER foo WITH PASSWORD *****`},
 		{`CREATE USER foo WITH PASSWORD 'bar'`, FmtSimpleWithPasswords,
 			`CREATE USER foo WITH PASSWORD 'bar'`},
+		{`ALTER USER foo PASSWORD 'bar'`, FmtSimple,
+			`ALTER USER foo WITH PASSWORD *****`},
+		{`ALTER USER foo PASSWORD 'bar'`, FmtSimpleWithPasswords,
+			`ALTER USER foo WITH PASSWORD 'bar'`},
 
 		{`CREATE TABLE foo (x INT)`, tableFormatter,
 			`CREATE TABLE xoxoxo (x INT)`},
@@ -23,7 +23,7 @@ import (
 )
 
 // FunctionDefinition implements a reference to the (possibly several)
-// overloads for a built-in function.
+// overloads for a built-in function, or to a user-defined function.
 type FunctionDefinition struct {
 	// Name is the short name of the function.
 	Name string
@@ -33,6 +33,9 @@ type FunctionDefinition struct {
 	HasOverloadsNeedingRepeatedEvaluation bool
 	// Definition is the set of overloads for this function name.
 	Definition []overloadImpl
+	// UserDefined is set if the name refers to a user-defined function rather
+	// than to a builtin.
+	UserDefined *UserDefinedFunction
 }
 
 func newFunctionDefinition(name string, def []Builtin) *FunctionDefinition {
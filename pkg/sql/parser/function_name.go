@@ -63,6 +63,36 @@ func (fn *ResolvableFunctionReference) Resolve(searchPath SearchPath) (*Function
 	}
 }
 
+// ResolveUserDefined is like Resolve, but if the name does not refer to a
+// builtin function it is looked up as a user-defined function using the
+// resolver. The builtins take precedence, so that user-defined functions
+// cannot change the meaning of existing queries.
+func (fn *ResolvableFunctionReference) ResolveUserDefined(
+	searchPath SearchPath, resolver FunctionResolver,
+) (*FunctionDefinition, error) {
+	fd, err := fn.Resolve(searchPath)
+	if err == nil || resolver == nil {
+		return fd, err
+	}
+	name, ok := fn.FunctionReference.(UnresolvedName)
+	if !ok {
+		return nil, err
+	}
+	udf, udfErr := resolver.ResolveUserDefinedFunction(name)
+	if udfErr != nil {
+		return nil, udfErr
+	}
+	if udf == nil {
+		return nil, err
+	}
+	fd, err = udf.definition()
+	if err != nil {
+		return nil, err
+	}
+	fn.FunctionReference = fd
+	return fd, nil
+}
+
 // wrapFunction creates a new ResolvableFunctionReference
 // holding a pre-resolved function. Helper for grammar rules.
 func wrapFunction(n string) ResolvableFunctionReference {
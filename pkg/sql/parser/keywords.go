@@ -5,6 +5,7 @@ package parser
 var keywords = map[string]int{
 	"ACTION":                    ACTION,
 	"ADD":                       ADD,
+	"AFTER":                     AFTER,
 	"ALL":                       ALL,
 	"ALTER":                     ALTER,
 	"ANALYSE":                   ANALYSE,
@@ -18,6 +19,7 @@ var keywords = map[string]int{
 	"ASYMMETRIC":                ASYMMETRIC,
 	"AT":                        AT,
 	"BACKUP":                    BACKUP,
+	"BEFORE":                    BEFORE,
 	"BEGIN":                     BEGIN,
 	"BETWEEN":                   BETWEEN,
 	"BIGINT":                    BIGINT,
@@ -27,9 +29,12 @@ var keywords = map[string]int{
 	"BOOL":                      BOOL,
 	"BOOLEAN":                   BOOLEAN,
 	"BOTH":                      BOTH,
+	"BUCKET_COUNT":              BUCKET_COUNT,
 	"BY":                        BY,
+	"BYPASSRLS":                 BYPASSRLS,
 	"BYTEA":                     BYTEA,
 	"BYTES":                     BYTES,
+	"CANCEL":                    CANCEL,
 	"CASCADE":                   CASCADE,
 	"CASE":                      CASE,
 	"CAST":                      CAST,
@@ -37,12 +42,14 @@ var keywords = map[string]int{
 	"CHARACTER":                 CHARACTER,
 	"CHARACTERISTICS":           CHARACTERISTICS,
 	"CHECK":                     CHECK,
+	"CLOSE":                     CLOSE,
 	"CLUSTER":                   CLUSTER,
 	"COALESCE":                  COALESCE,
 	"COLLATE":                   COLLATE,
 	"COLLATION":                 COLLATION,
 	"COLUMN":                    COLUMN,
 	"COLUMNS":                   COLUMNS,
+	"COMMENT":                   COMMENT,
 	"COMMIT":                    COMMIT,
 	"COMMITTED":                 COMMITTED,
 	"CONFLICT":                  CONFLICT,
@@ -60,6 +67,7 @@ var keywords = map[string]int{
 	"CURRENT_TIME":              CURRENT_TIME,
 	"CURRENT_TIMESTAMP":         CURRENT_TIMESTAMP,
 	"CURRENT_USER":              CURRENT_USER,
+	"CURSOR":                    CURSOR,
 	"CYCLE":                     CYCLE,
 	"DATA":                      DATA,
 	"DATABASE":                  DATABASE,
@@ -69,17 +77,22 @@ var keywords = map[string]int{
 	"DEALLOCATE":                DEALLOCATE,
 	"DEC":                       DEC,
 	"DECIMAL":                   DECIMAL,
+	"DECLARE":                   DECLARE,
 	"DEFAULT":                   DEFAULT,
 	"DEFERRABLE":                DEFERRABLE,
 	"DELETE":                    DELETE,
 	"DESC":                      DESC,
+	"DISABLE":                   DISABLE,
 	"DISTINCT":                  DISTINCT,
 	"DO":                        DO,
 	"DOUBLE":                    DOUBLE,
 	"DROP":                      DROP,
+	"EACH":                      EACH,
 	"ELSE":                      ELSE,
+	"ENABLE":                    ENABLE,
 	"ENCODING":                  ENCODING,
 	"END":                       END,
+	"ENUM":                      ENUM,
 	"EXCEPT":                    EXCEPT,
 	"EXECUTE":                   EXECUTE,
 	"EXISTS":                    EXISTS,
@@ -97,20 +110,25 @@ var keywords = map[string]int{
 	"FOR":                       FOR,
 	"FORCE_INDEX":               FORCE_INDEX,
 	"FOREIGN":                   FOREIGN,
+	"FORWARD":                   FORWARD,
 	"FROM":                      FROM,
 	"FULL":                      FULL,
+	"FUNCTION":                  FUNCTION,
 	"GRANT":                     GRANT,
 	"GRANTS":                    GRANTS,
 	"GREATEST":                  GREATEST,
 	"GROUP":                     GROUP,
 	"GROUPING":                  GROUPING,
+	"HASH":                      HASH,
 	"HAVING":                    HAVING,
 	"HELP":                      HELP,
 	"HIGH":                      HIGH,
+	"HISTOGRAM":                 HISTOGRAM,
 	"HOUR":                      HOUR,
 	"IF":                        IF,
 	"IFNULL":                    IFNULL,
 	"ILIKE":                     ILIKE,
+	"IMMUTABLE":                 IMMUTABLE,
 	"IN":                        IN,
 	"INCREMENTAL":               INCREMENTAL,
 	"INDEX":                     INDEX,
@@ -132,6 +150,7 @@ var keywords = map[string]int{
 	"JOIN":                      JOIN,
 	"KEY":                       KEY,
 	"KEYS":                      KEYS,
+	"LANGUAGE":                  LANGUAGE,
 	"LATERAL":                   LATERAL,
 	"LC_COLLATE":                LC_COLLATE,
 	"LC_CTYPE":                  LC_CTYPE,
@@ -148,6 +167,7 @@ var keywords = map[string]int{
 	"MATCH":                     MATCH,
 	"MINUTE":                    MINUTE,
 	"MONTH":                     MONTH,
+	"MOVE":                      MOVE,
 	"NAME":                      NAME,
 	"NAMES":                     NAMES,
 	"NAN":                       NAN,
@@ -182,13 +202,16 @@ var keywords = map[string]int{
 	"PARTITION":                 PARTITION,
 	"PASSWORD":                  PASSWORD,
 	"PLACING":                   PLACING,
+	"POLICY":                    POLICY,
 	"POSITION":                  POSITION,
 	"PRECEDING":                 PRECEDING,
 	"PRECISION":                 PRECISION,
 	"PREPARE":                   PREPARE,
 	"PRIMARY":                   PRIMARY,
 	"PRIORITY":                  PRIORITY,
+	"PROCEDURE":                 PROCEDURE,
 	"QUERIES":                   QUERIES,
+	"QUERY":                     QUERY,
 	"RANGE":                     RANGE,
 	"READ":                      READ,
 	"REAL":                      REAL,
@@ -207,6 +230,7 @@ var keywords = map[string]int{
 	"RESTORE":                   RESTORE,
 	"RESTRICT":                  RESTRICT,
 	"RETURNING":                 RETURNING,
+	"RETURNS":                   RETURNS,
 	"REVOKE":                    REVOKE,
 	"RIGHT":                     RIGHT,
 	"ROLLBACK":                  ROLLBACK,
@@ -217,6 +241,7 @@ var keywords = map[string]int{
 	"SCATTER":                   SCATTER,
 	"SEARCH":                    SEARCH,
 	"SECOND":                    SECOND,
+	"SECURITY":                  SECURITY,
 	"SELECT":                    SELECT,
 	"SERIAL":                    SERIAL,
 	"SERIALIZABLE":              SERIALIZABLE,
@@ -235,9 +260,13 @@ var keywords = map[string]int{
 	"SOME":                      SOME,
 	"SPLIT":                     SPLIT,
 	"SQL":                       SQL,
+	"STABLE":                    STABLE,
 	"START":                     START,
+	"STATEMENT":                 STATEMENT,
+	"STATISTICS":                STATISTICS,
 	"STATUS":                    STATUS,
 	"STDIN":                     STDIN,
+	"STDOUT":                    STDOUT,
 	"STORING":                   STORING,
 	"STRICT":                    STRICT,
 	"STRING":                    STRING,
@@ -259,6 +288,7 @@ var keywords = map[string]int{
 	"TRAILING":                  TRAILING,
 	"TRANSACTION":               TRANSACTION,
 	"TREAT":                     TREAT,
+	"TRIGGER":                   TRIGGER,
 	"TRIM":                      TRIM,
 	"TRUE":                      TRUE,
 	"TRUNCATE":                  TRUNCATE,
@@ -283,6 +313,7 @@ var keywords = map[string]int{
 	"VARIADIC":                  VARIADIC,
 	"VARYING":                   VARYING,
 	"VIEW":                      VIEW,
+	"VOLATILE":                  VOLATILE,
 	"WHEN":                      WHEN,
 	"WHERE":                     WHERE,
 	"WINDOW":                    WINDOW,
@@ -380,6 +380,16 @@ func typeCheckOverloadedExprs(
 			p := o.params()
 			for _, expr := range constExprs {
 				des := p.getAt(expr.i)
+				if des != nil && des.IsAmbiguous() && des.FamilyEqual(TypeEnum) {
+					// A constant can only become a value of a specific ENUM type, so
+					// borrow the concrete type from a resolved argument.
+					for _, resExpr := range resolvableExprs {
+						if t := typedExprs[resExpr.i].ResolvedType(); !t.IsAmbiguous() && des.Equivalent(t) {
+							des = t
+							break
+						}
+					}
+				}
 				typ, err := expr.e.TypeCheck(ctx, des)
 				if err != nil {
 					return true, nil, fmt.Errorf("error type checking constant value: %v", err)
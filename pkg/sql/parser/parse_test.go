@@ -82,6 +82,9 @@ func TestParse(t *testing.T) {
 		{`CREATE UNIQUE INDEX a ON b (c) STORING (d)`},
 		{`CREATE UNIQUE INDEX a ON b (c) INTERLEAVE IN PARENT d (e, f)`},
 		{`CREATE UNIQUE INDEX a ON b.c (d)`},
+		{`CREATE INDEX ON a (b) USING HASH WITH BUCKET_COUNT = 8`},
+		{`CREATE INDEX IF NOT EXISTS a ON b (c) USING HASH WITH BUCKET_COUNT = 4 STORING (d)`},
+		{`CREATE UNIQUE INDEX a ON b (c, d) USING HASH WITH BUCKET_COUNT = 16`},
 
 		{`CREATE TABLE a ()`},
 		{`CREATE TABLE a (b INT)`},
@@ -133,6 +136,9 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT, INDEX (b) STORING (c))`},
 		{`CREATE TABLE a (b INT, c TEXT, INDEX (b ASC, c DESC) STORING (c))`},
 		{`CREATE TABLE a (b INT, INDEX (b) INTERLEAVE IN PARENT c (d, e))`},
+		{`CREATE TABLE a (b INT, INDEX (b) USING HASH WITH BUCKET_COUNT = 8 STORING (c))`},
+		{`CREATE TABLE a (b INT, c TEXT, CONSTRAINT d UNIQUE (b) USING HASH WITH BUCKET_COUNT = 8)`},
+		{`CREATE TABLE a (b INT, CONSTRAINT c PRIMARY KEY (b) USING HASH WITH BUCKET_COUNT = 10)`},
 		{`CREATE TABLE a (b INT, FAMILY (b))`},
 		{`CREATE TABLE a (b INT, c STRING, FAMILY foo (b), FAMILY (c))`},
 		{`CREATE TABLE a (b INT) INTERLEAVE IN PARENT foo (c, d)`},
@@ -155,6 +161,35 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a AS SELECT * FROM b UNION VALUES ('one', 1) ORDER BY c LIMIT 5`},
 		{`CREATE TABLE IF NOT EXISTS a AS SELECT * FROM b UNION VALUES ('one', 1) ORDER BY c LIMIT 5`},
 		{`CREATE TABLE a (b STRING COLLATE "DE")`},
+		{`CREATE TABLE a (b mood)`},
+		{`CREATE TABLE a (b d.mood)`},
+
+		{`CREATE TYPE a AS ENUM ()`},
+		{`CREATE TYPE a AS ENUM ('x')`},
+		{`CREATE TYPE a.b AS ENUM ('x', 'y', 'z')`},
+
+		{`CREATE FUNCTION a() RETURNS INT LANGUAGE SQL AS 'SELECT 1'`},
+		{`CREATE FUNCTION a.b(x INT, y STRING) RETURNS STRING LANGUAGE SQL AS 'SELECT y || x::STRING'`},
+		{`CREATE FUNCTION a(x INT) RETURNS INT IMMUTABLE LANGUAGE SQL AS 'SELECT x + 1'`},
+		{`CREATE FUNCTION a(x DECIMAL) RETURNS BOOL STABLE LANGUAGE SQL AS 'SELECT x > (SELECT max(y) FROM b)'`},
+		{`CREATE POLICY a ON b`},
+		{`CREATE POLICY a ON b.c USING (d = current_user())`},
+		{`CREATE POLICY a ON b FOR SELECT TO c, d USING (e = 1)`},
+		{`CREATE POLICY a ON b FOR INSERT WITH CHECK (c > 0)`},
+		{`CREATE POLICY a ON b FOR UPDATE USING (c = 1) WITH CHECK (c = 1 OR c = 2)`},
+		{`CREATE POLICY a ON b FOR DELETE TO c USING (true)`},
+		{`CREATE FUNCTION a() RETURNS TRIGGER LANGUAGE SQL AS 'INSERT INTO b VALUES (new.c)'`},
+		{`CREATE TRIGGER a BEFORE INSERT ON b FOR EACH ROW EXECUTE PROCEDURE c()`},
+		{`CREATE TRIGGER a AFTER INSERT OR UPDATE OR DELETE ON b.c FOR EACH STATEMENT EXECUTE PROCEDURE d.e()`},
+		{`CREATE STATISTICS a FROM b`},
+		{`CREATE STATISTICS a ON col1 FROM b.c`},
+		{`CREATE STATISTICS a ON col1, col2 FROM t`},
+		{`ANALYZE a`},
+		{`ANALYZE a.b`},
+
+		{`CANCEL QUERY 'f6f87f0b8f1b0fd4000000000000001'`},
+		{`CANCEL QUERY $1`},
+		{`CANCEL SESSION 'f6f87f0b8f1b0fd4000000000000001'`},
 
 		{`CREATE VIEW a AS SELECT * FROM b`},
 		{`CREATE VIEW a AS SELECT b.* FROM b LIMIT 5`},
@@ -203,6 +238,20 @@ func TestParse(t *testing.T) {
 		{`DROP VIEW a.b CASCADE`},
 		{`DROP VIEW a, b CASCADE`},
 
+		{`DROP TYPE a`},
+		{`DROP TYPE a.b, c`},
+		{`DROP TYPE IF EXISTS a RESTRICT`},
+		{`DROP TYPE IF EXISTS a, b CASCADE`},
+
+		{`DROP FUNCTION a`},
+		{`DROP FUNCTION a(), b.c(INT, STRING)`},
+		{`DROP FUNCTION IF EXISTS a RESTRICT`},
+		{`DROP FUNCTION IF EXISTS a(INT), b CASCADE`},
+		{`DROP POLICY a ON b`},
+		{`DROP POLICY IF EXISTS a ON b.c`},
+		{`DROP TRIGGER a ON b`},
+		{`DROP TRIGGER IF EXISTS a ON b.c`},
+
 		{`DROP USER a`},
 		{`DROP USER a, b`},
 
@@ -210,6 +259,8 @@ func TestParse(t *testing.T) {
 		{`EXPLAIN EXPLAIN SELECT 1`},
 		{`EXPLAIN (DEBUG) SELECT 1`},
 		{`EXPLAIN (A, B, C) SELECT 1`},
+		{`EXPLAIN (ANALYZE) SELECT 1`},
+		{`EXPLAIN (DISTSQL, ANALYZE) SELECT 1`},
 		{`SELECT * FROM [EXPLAIN SELECT 1]`},
 		{`SELECT * FROM [SHOW TRANSACTION STATUS]`},
 
@@ -247,6 +298,9 @@ func TestParse(t *testing.T) {
 		{`SHOW TESTING_RANGES FROM INDEX t@i`},
 		{`SHOW TESTING_RANGES FROM INDEX d.i`},
 		{`SHOW TESTING_RANGES FROM INDEX i`},
+		{`SHOW STATISTICS FOR TABLE t`},
+		{`SHOW STATISTICS FOR TABLE d.t`},
+		{`SHOW HISTOGRAM 123`},
 		{`SHOW EXPERIMENTAL_FINGERPRINTS FROM TABLE d.t`},
 		{`SHOW EXPERIMENTAL_FINGERPRINTS FROM TABLE d.t AS OF SYSTEM TIME 'foo'`},
 
@@ -277,6 +331,15 @@ func TestParse(t *testing.T) {
 		{`DEALLOCATE a`},
 		{`DEALLOCATE ALL`},
 
+		{`DECLARE c CURSOR FOR SELECT * FROM t`},
+		{`DECLARE c CURSOR FOR SELECT a FROM t WHERE b = $1 ORDER BY a`},
+		{`FETCH 10 FROM c`},
+		{`FETCH ALL FROM c`},
+		{`MOVE 5 FROM c`},
+		{`MOVE ALL FROM c`},
+		{`CLOSE c`},
+		{`CLOSE ALL`},
+
 		// Tables are the default, but can also be specified with
 		// GRANT x ON TABLE y. However, the stringer does not output TABLE.
 		{`GRANT SELECT ON foo TO root`},
@@ -286,6 +349,7 @@ func TestParse(t *testing.T) {
 		{`GRANT SELECT, INSERT ON DATABASE bar TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO "test-user"`},
+		{`GRANT BYPASSRLS ON foo TO bar`},
 
 		// Tables are the default, but can also be specified with
 		// REVOKE x ON TABLE y. However, the stringer does not output TABLE.
@@ -396,6 +460,8 @@ func TestParse(t *testing.T) {
 		{`SELECT '1':::INT`},
 
 		{`SELECT '1'::INT`},
+		{`SELECT 'a'::mood`},
+		{`SELECT 'a':::d.mood`},
 		{`SELECT BOOL 'foo'`},
 		{`SELECT INT 'foo'`},
 		{`SELECT REAL 'foo'`},
@@ -612,6 +678,27 @@ func TestParse(t *testing.T) {
 		{`SELECT * FROM "0" JOIN "0" USING (id, "0")`}, // last "0" lost its quotes.
 
 		{`ALTER DATABASE a RENAME TO b`},
+		{`ALTER TYPE a ADD VALUE 'x'`},
+		{`ALTER TYPE a.b ADD VALUE IF NOT EXISTS 'x'`},
+		{`ALTER TYPE a ADD VALUE 'x' BEFORE 'y'`},
+		{`ALTER TYPE a ADD VALUE IF NOT EXISTS 'x' AFTER 'y'`},
+		{`ALTER USER foo SET statement_timeout = '10s'`},
+		{`ALTER USER foo SET idle_in_transaction_session_timeout = 1000`},
+		{`ALTER USER foo RESET statement_timeout`},
+
+		{`COMMENT ON DATABASE a IS 'b'`},
+		{`COMMENT ON DATABASE a IS NULL`},
+		{`COMMENT ON TABLE a IS 'b'`},
+		{`COMMENT ON TABLE a.b IS 'c'`},
+		{`COMMENT ON TABLE a IS NULL`},
+		{`COMMENT ON COLUMN a.b IS 'c'`},
+		{`COMMENT ON COLUMN a.b.c IS 'd'`},
+		{`COMMENT ON COLUMN a.b IS NULL`},
+		{`COMMENT ON INDEX a@b IS 'c'`},
+		{`COMMENT ON INDEX a.b@c IS 'd'`},
+		{`COMMENT ON INDEX a IS 'b'`},
+		{`COMMENT ON INDEX a@b IS NULL`},
+
 		{`ALTER TABLE a RENAME TO b`},
 		{`ALTER TABLE IF EXISTS a RENAME TO b`},
 		{`ALTER INDEX a@b RENAME TO b`},
@@ -646,6 +733,8 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE a DROP CONSTRAINT b CASCADE`},
 		{`ALTER TABLE a DROP CONSTRAINT IF EXISTS b RESTRICT`},
 		{`ALTER TABLE a VALIDATE CONSTRAINT a`},
+		{`ALTER TABLE a ENABLE ROW LEVEL SECURITY`},
+		{`ALTER TABLE a DISABLE ROW LEVEL SECURITY`},
 
 		{`ALTER TABLE a ALTER COLUMN b SET DEFAULT 42`},
 		{`ALTER TABLE a ALTER COLUMN b SET DEFAULT NULL`},
@@ -655,6 +744,11 @@ func TestParse(t *testing.T) {
 
 		{`COPY t FROM STDIN`},
 		{`COPY t (a, b, c) FROM STDIN`},
+		{`COPY t FROM STDIN WITH (format 'csv', header, delimiter ';', "null" 'x', quote '"')`},
+		{`COPY t FROM STDIN WITH (format 'binary')`},
+		{`COPY t TO STDOUT`},
+		{`COPY t (a, b) TO STDOUT WITH (format 'csv', header 'true')`},
+		{`COPY (SELECT a FROM t WHERE b > 1) TO STDOUT WITH (format 'text')`},
 
 		{`ALTER TABLE a SPLIT AT VALUES (1)`},
 		{`ALTER TABLE a SPLIT AT SELECT * FROM t`},
@@ -729,6 +823,13 @@ func TestParse2(t *testing.T) {
 			`CREATE TABLE a (b INT, CONSTRAINT foo UNIQUE (b) INTERLEAVE IN PARENT c (d))`},
 		{`CREATE INDEX ON a (b) COVERING (c)`, `CREATE INDEX ON a (b) STORING (c)`},
 
+		{`EXPLAIN ANALYZE SELECT 1`, `EXPLAIN (ANALYZE) SELECT 1`},
+
+		{`COPY t FROM STDIN (FORMAT csv, HEADER true, NULL 'x')`,
+			`COPY t FROM STDIN WITH (format 'csv', header 'true', "null" 'x')`},
+		{`COPY (TABLE t) TO STDOUT WITH (FORMAT binary)`,
+			`COPY (TABLE t) TO STDOUT WITH (format 'binary')`},
+
 		{`SELECT TIMESTAMP WITHOUT TIME ZONE 'foo'`, `SELECT TIMESTAMP 'foo'`},
 		{`SELECT CAST('foo' AS TIMESTAMP WITHOUT TIME ZONE)`, `SELECT CAST('foo' AS TIMESTAMP)`},
 
@@ -805,6 +906,10 @@ func TestParse2(t *testing.T) {
 			`SELECT current_timestamp()`},
 		{`SELECT CURRENT_DATE`,
 			`SELECT current_date()`},
+		{`SELECT CURRENT_USER`,
+			`SELECT current_user()`},
+		{`SELECT SESSION_USER`,
+			`SELECT session_user()`},
 		{`SELECT POSITION(a IN b)`,
 			`SELECT strpos(b, a)`},
 		{`SELECT TRIM(BOTH a FROM b)`,
@@ -878,6 +983,11 @@ func TestParse2(t *testing.T) {
 		{`SET TIME ZONE INTERVAL '-7h0m5s' HOUR TO MINUTE`,
 			`SET "time zone" = '-6h-59m'`},
 
+		{`ALTER USER foo SET statement_timeout TO '10s'`,
+			`ALTER USER foo SET statement_timeout = '10s'`},
+		{`ALTER USER foo SET statement_timeout TO DEFAULT`,
+			`ALTER USER foo RESET statement_timeout`},
+
 		// Special substring syntax
 		{`SELECT SUBSTRING('RoacH' from 2 for 3)`,
 			`SELECT substring('RoacH', 2, 3)`},
@@ -959,6 +1069,17 @@ func TestParse2(t *testing.T) {
 		{`DEALLOCATE PREPARE ALL`,
 			`DEALLOCATE ALL`},
 
+		{`FETCH c`, `FETCH 1 FROM c`},
+		{`FETCH IN c`, `FETCH 1 FROM c`},
+		{`FETCH NEXT c`, `FETCH 1 FROM c`},
+		{`FETCH NEXT FROM c`, `FETCH 1 FROM c`},
+		{`FETCH FORWARD IN c`, `FETCH 1 FROM c`},
+		{`FETCH FORWARD 100 c`, `FETCH 100 FROM c`},
+		{`FETCH FORWARD ALL FROM c`, `FETCH ALL FROM c`},
+		{`FETCH ALL c`, `FETCH ALL FROM c`},
+		{`MOVE NEXT IN c`, `MOVE 1 FROM c`},
+		{`MOVE FORWARD 3 FROM c`, `MOVE 3 FROM c`},
+
 		{`BACKUP DATABASE foo TO bar`,
 			`BACKUP DATABASE foo TO 'bar'`},
 		{`BACKUP DATABASE foo TO "bar.12" INCREMENTAL FROM "baz.34"`,
@@ -968,6 +1089,20 @@ func TestParse2(t *testing.T) {
 
 		{`SHOW ALL CLUSTER SETTINGS`, `SHOW CLUSTER SETTING all`},
 
+		{`CREATE FUNCTION a(x INT) RETURNS INT AS 'SELECT x' LANGUAGE SQL VOLATILE`,
+			`CREATE FUNCTION a(x INT) RETURNS INT VOLATILE LANGUAGE SQL AS 'SELECT x'`},
+		{`CREATE FUNCTION a() RETURNS INT STABLE AS 'SELECT 1'`,
+			`CREATE FUNCTION a() RETURNS INT STABLE LANGUAGE SQL AS 'SELECT 1'`},
+		{`CREATE FUNCTION a() RETURNS INT LANGUAGE sql AS 'SELECT 1'`,
+			`CREATE FUNCTION a() RETURNS INT LANGUAGE SQL AS 'SELECT 1'`},
+
+		{`CREATE POLICY a ON b FOR ALL USING (c = 1)`, `CREATE POLICY a ON b USING (c = 1)`},
+		{`CREATE TRIGGER a AFTER DELETE ON b EXECUTE FUNCTION c()`,
+			`CREATE TRIGGER a AFTER DELETE ON b FOR EACH STATEMENT EXECUTE PROCEDURE c()`},
+		{`CREATE TRIGGER a BEFORE UPDATE ON b FOR ROW EXECUTE PROCEDURE c()`,
+			`CREATE TRIGGER a BEFORE UPDATE ON b FOR EACH ROW EXECUTE PROCEDURE c()`},
+		{`ANALYSE a`, `ANALYZE a`},
+
 		{`SHOW SESSIONS`, `SHOW CLUSTER SESSIONS`},
 		{`SHOW QUERIES`, `SHOW CLUSTER QUERIES`},
 
@@ -1260,6 +1395,13 @@ SELECT ANNOTATE_TYPE(1.2+2.3, notatype)
 			`syntax error at or near "EOF"
 CREATE USER foo WITH PASSWORD
                              ^
+`,
+		},
+		{
+			`ALTER USER foo WITH PASSWORD`,
+			`syntax error at or near "EOF"
+ALTER USER foo WITH PASSWORD
+                            ^
 `,
 		},
 		{
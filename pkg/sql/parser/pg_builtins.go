@@ -236,30 +236,38 @@ var pgBuiltins = map[string][]Builtin{
 	},
 	"col_description": {
 		Builtin{
-			Types:      ArgTypes{{"table_oid", TypeOid}, {"column_number", TypeInt}},
-			ReturnType: fixedReturnType(TypeString),
-			fn: func(_ *EvalContext, _ Datums) (Datum, error) {
-				return DNull, nil
+			Types:            ArgTypes{{"table_oid", TypeOid}, {"column_number", TypeInt}},
+			distsqlBlacklist: true,
+			ReturnType:       fixedReturnType(TypeString),
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				return getPgObjDesc(ctx, "", args[0], args[1])
 			},
-			Info: notUsableInfo,
+			Info: "Returns the comment for a table column, which is specified by the OID " +
+				"of its table and its column number.",
 		},
 	},
 	"obj_description": {
 		Builtin{
-			Types:      ArgTypes{{"object_oid", TypeOid}},
-			ReturnType: fixedReturnType(TypeString),
-			fn: func(_ *EvalContext, _ Datums) (Datum, error) {
-				return DNull, nil
+			Types:            ArgTypes{{"object_oid", TypeOid}},
+			distsqlBlacklist: true,
+			ReturnType:       fixedReturnType(TypeString),
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				return getPgObjDesc(ctx, "", args[0], zeroSubID)
 			},
-			Info: notUsableInfo,
+			Info: "Returns the comment for a database object specified by its OID alone. " +
+				"This is deprecated since there is no guarantee that OIDs are unique " +
+				"across different system catalogs; therefore, the wrong comment might " +
+				"be returned.",
 		},
 		Builtin{
-			Types:      ArgTypes{{"object_oid", TypeOid}, {"catalog_name", TypeString}},
-			ReturnType: fixedReturnType(TypeString),
-			fn: func(_ *EvalContext, _ Datums) (Datum, error) {
-				return DNull, nil
+			Types:            ArgTypes{{"object_oid", TypeOid}, {"catalog_name", TypeString}},
+			distsqlBlacklist: true,
+			ReturnType:       fixedReturnType(TypeString),
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				return getPgObjDesc(ctx, string(MustBeDString(args[1])), args[0], zeroSubID)
 			},
-			Info: notUsableInfo,
+			Info: "Returns the comment for a database object specified by its OID and " +
+				"the name of the containing system catalog.",
 		},
 	},
 	"oid": {
@@ -274,12 +282,28 @@ var pgBuiltins = map[string][]Builtin{
 	},
 	"shobj_description": {
 		Builtin{
-			Types:      ArgTypes{{"object_oid", TypeOid}, {"catalog_name", TypeString}},
-			ReturnType: fixedReturnType(TypeString),
-			fn: func(_ *EvalContext, _ Datums) (Datum, error) {
-				return DNull, nil
+			Types:            ArgTypes{{"object_oid", TypeOid}, {"catalog_name", TypeString}},
+			distsqlBlacklist: true,
+			ReturnType:       fixedReturnType(TypeString),
+			fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+				r, err := ctx.Planner.QueryRow(ctx.Ctx(), `
+SELECT description
+  FROM pg_catalog.pg_shdescription
+  JOIN pg_catalog.pg_class ON pg_shdescription.classoid = pg_class.oid
+ WHERE pg_shdescription.objoid = $1 AND pg_class.relname = $2
+ LIMIT 1`, args[0], args[1])
+				if err != nil {
+					return nil, err
+				}
+				if len(r) == 0 {
+					return DNull, nil
+				}
+				return r[0], nil
 			},
-			Info: notUsableInfo,
+			Info: "Returns the comment for a shared database object specified by its OID " +
+				"and the name of the containing system catalog. This is just like " +
+				"obj_description except that it is used for retrieving comments on " +
+				"shared objects (e.g. databases).",
 		},
 	},
 	"pg_try_advisory_lock": {
@@ -323,3 +347,36 @@ var pgBuiltins = map[string][]Builtin{
 		},
 	},
 }
+
+var zeroSubID = NewDInt(0)
+
+// getPgObjDesc returns the description of the object with the given OID and
+// sub-ID in pg_catalog.pg_description, or NULL if there is none. If
+// catalogName is not empty, only descriptions of objects in that catalog are
+// considered.
+func getPgObjDesc(ctx *EvalContext, catalogName string, oid Datum, subID Datum) (Datum, error) {
+	query := `
+SELECT description
+  FROM pg_catalog.pg_description
+ WHERE objoid = $1 AND objsubid = $2
+ LIMIT 1`
+	args := []interface{}{oid, subID}
+	if catalogName != "" {
+		query = `
+SELECT description
+  FROM pg_catalog.pg_description
+  JOIN pg_catalog.pg_class ON pg_description.classoid = pg_class.oid
+ WHERE pg_description.objoid = $1 AND pg_description.objsubid = $2
+   AND pg_class.relname = $3
+ LIMIT 1`
+		args = append(args, catalogName)
+	}
+	r, err := ctx.Planner.QueryRow(ctx.Ctx(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(r) == 0 {
+		return DNull, nil
+	}
+	return r[0], nil
+}
@@ -22,7 +22,10 @@
 
 package parser
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+)
 
 // Show represents a SHOW statement.
 type Show struct {
@@ -244,6 +247,27 @@ func (node *ShowRanges) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 }
 
+// ShowStats represents a SHOW STATISTICS statement.
+type ShowStats struct {
+	Table NormalizableTableName
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowStats) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("SHOW STATISTICS FOR TABLE ")
+	FormatNode(buf, f, node.Table)
+}
+
+// ShowHistogram represents a SHOW HISTOGRAM statement.
+type ShowHistogram struct {
+	HistogramID int64
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowHistogram) Format(buf *bytes.Buffer, f FmtFlags) {
+	fmt.Fprintf(buf, "SHOW HISTOGRAM %d", node.HistogramID)
+}
+
 // ShowFingerprints represents a SHOW EXPERIMENTAL_FINGERPRINTS statement.
 type ShowFingerprints struct {
 	Table *NormalizableTableName
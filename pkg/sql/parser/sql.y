@@ -321,6 +321,9 @@ func (u *sqlSymUnion) validationBehavior() ValidationBehavior {
 func (u *sqlSymUnion) interleave() *InterleaveDef {
     return u.val.(*InterleaveDef)
 }
+func (u *sqlSymUnion) shardedIndexDef() *ShardedIndexDef {
+    return u.val.(*ShardedIndexDef)
+}
 func (u *sqlSymUnion) windowDef() *WindowDef {
     return u.val.(*WindowDef)
 }
@@ -348,6 +351,33 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 func (u *sqlSymUnion) transactionModes() TransactionModes {
     return u.val.(TransactionModes)
 }
+func (u *sqlSymUnion) functionParam() FunctionParam {
+    return u.val.(FunctionParam)
+}
+func (u *sqlSymUnion) functionParams() FunctionParams {
+    return u.val.(FunctionParams)
+}
+func (u *sqlSymUnion) functionOptions() FunctionOptions {
+    return u.val.(FunctionOptions)
+}
+func (u *sqlSymUnion) funcObj() FuncObj {
+    return u.val.(FuncObj)
+}
+func (u *sqlSymUnion) funcObjs() FuncObjs {
+    return u.val.(FuncObjs)
+}
+func (u *sqlSymUnion) policyCommand() PolicyCommand {
+    return u.val.(PolicyCommand)
+}
+func (u *sqlSymUnion) triggerTiming() TriggerTiming {
+    return u.val.(TriggerTiming)
+}
+func (u *sqlSymUnion) triggerEvent() TriggerEvent {
+    return u.val.(TriggerEvent)
+}
+func (u *sqlSymUnion) triggerEvents() TriggerEvents {
+    return u.val.(TriggerEvents)
+}
 
 %}
 
@@ -369,37 +399,37 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 // "Keyword category lists".
 
 // Ordinary key words in alphabetical order.
-%token <str>   ACTION ADD
+%token <str>   ACTION ADD AFTER
 %token <str>   ALL ALTER ANALYSE ANALYZE AND ANY ANNOTATE_TYPE ARRAY AS ASC
 %token <str>   ASYMMETRIC AT
 
-%token <str>   BACKUP BEGIN BETWEEN BIGINT BIGSERIAL BIT
-%token <str>   BLOB BOOL BOOLEAN BOTH BY BYTEA BYTES
+%token <str>   BACKUP BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BIT
+%token <str>   BLOB BOOL BOOLEAN BOTH BUCKET_COUNT BY BYPASSRLS BYTEA BYTES
 
-%token <str>   CASCADE CASE CAST CHAR
+%token <str>   CANCEL CASCADE CASE CAST CHAR
 %token <str>   CHARACTER CHARACTERISTICS CHECK
-%token <str>   CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMIT
+%token <str>   CLOSE CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMIT
 %token <str>   COMMITTED CONCAT CONFLICT CONSTRAINT CONSTRAINTS
 %token <str>   COPY COVERING CREATE
 %token <str>   CROSS CUBE CURRENT CURRENT_CATALOG CURRENT_DATE
 %token <str>   CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
-%token <str>   CURRENT_USER CYCLE
+%token <str>   CURRENT_USER CURSOR CYCLE
 
 %token <str>   DATA DATABASE DATABASES DATE DAY DEC DECIMAL DEFAULT
-%token <str>   DEALLOCATE DEFERRABLE DELETE DESC
-%token <str>   DISTINCT DO DOUBLE DROP
+%token <str>   DEALLOCATE DECLARE DEFERRABLE DELETE DESC
+%token <str>   DISABLE DISTINCT DO DOUBLE DROP
 
-%token <str>   ELSE ENCODING END ESCAPE EXCEPT
+%token <str>   EACH ELSE ENABLE ENCODING END ENUM ESCAPE EXCEPT
 %token <str>   EXISTS EXECUTE EXPERIMENTAL_FINGERPRINTS EXPLAIN EXTRACT EXTRACT_DURATION
 
 %token <str>   FALSE FAMILY FETCH FILTER FIRST FLOAT FLOORDIV FOLLOWING FOR
-%token <str>   FORCE_INDEX FOREIGN FROM FULL
+%token <str>   FORCE_INDEX FOREIGN FORWARD FROM FULL FUNCTION
 
 %token <str>   GRANT GRANTS GREATEST GROUP GROUPING
 
-%token <str>   HAVING HELP HIGH HOUR
+%token <str>   HASH HAVING HELP HIGH HISTOGRAM HOUR
 
-%token <str>   INCREMENTAL IF IFNULL ILIKE IN INTERLEAVE
+%token <str>   INCREMENTAL IF IFNULL ILIKE IMMUTABLE IN INTERLEAVE
 %token <str>   INDEX INDEXES INITIALLY
 %token <str>   INNER INSERT INT INT2VECTOR INT8 INT64 INTEGER
 %token <str>   INTERSECT INTERVAL INTO IS ISOLATION
@@ -408,11 +438,11 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %token <str>   KEY KEYS
 
-%token <str>   LATERAL LC_CTYPE LC_COLLATE
+%token <str>   LANGUAGE LATERAL LC_CTYPE LC_COLLATE
 %token <str>   LEADING LEAST LEFT LEVEL LIKE LIMIT LOCAL
 %token <str>   LOCALTIME LOCALTIMESTAMP LOW LSHIFT
 
-%token <str>   MATCH MINUTE MONTH
+%token <str>   MATCH MINUTE MONTH MOVE
 
 %token <str>   NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
 %token <str>   NOT NOTHING NULL NULLIF
@@ -421,31 +451,31 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %token <str>   OF OFF OFFSET OID ON ONLY OPTIONS OR
 %token <str>   ORDER ORDINALITY OUT OUTER OVER OVERLAPS OVERLAY
 
-%token <str>   PARENT PARTIAL PARTITION PASSWORD PLACING POSITION
-%token <str>   PRECEDING PRECISION PREPARE PRIMARY PRIORITY
+%token <str>   PARENT PARTIAL PARTITION PASSWORD PLACING POLICY POSITION
+%token <str>   PRECEDING PRECISION PREPARE PRIMARY PRIORITY PROCEDURE
 
-%token <str>   QUERIES
+%token <str>   QUERIES QUERY
 
 %token <str>   RANGE READ REAL RECURSIVE REF REFERENCES
 %token <str>   REGCLASS REGPROC REGPROCEDURE REGNAMESPACE REGTYPE
 %token <str>   RENAME REPEATABLE
-%token <str>   RELEASE RESET RESTORE RESTRICT RETURNING REVOKE RIGHT ROLLBACK ROLLUP
+%token <str>   RELEASE RESET RESTORE RESTRICT RETURNING RETURNS REVOKE RIGHT ROLLBACK ROLLUP
 %token <str>   ROW ROWS RSHIFT
 
-%token <str>   SAVEPOINT SCATTER SEARCH SECOND SELECT
+%token <str>   SAVEPOINT SCATTER SEARCH SECOND SECURITY SELECT
 %token <str>   SERIAL SERIALIZABLE SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str>   SHOW SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
-%token <str>   START STATUS STDIN STRICT STRING STORING SUBSTRING
+%token <str>   STABLE START STATEMENT STATISTICS STATUS STDIN STDOUT STRICT STRING STORING SUBSTRING
 %token <str>   SYMMETRIC SYSTEM
 
 %token <str>   TABLE TABLES TEMPLATE TESTING_RANGES TESTING_RELOCATE TEXT THEN
-%token <str>   TIME TIMESTAMP TIMESTAMPTZ TO TRAILING TRACE TRANSACTION TREAT TRIM TRUE
+%token <str>   TIME TIMESTAMP TIMESTAMPTZ TO TRAILING TRACE TRANSACTION TREAT TRIGGER TRIM TRUE
 %token <str>   TRUNCATE TYPE
 
 %token <str>   UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN
 %token <str>   UPDATE UPSERT USE USER USERS USING UUID
 
-%token <str>   VALID VALIDATE VALUE VALUES VARCHAR VARIADIC VIEW VARYING
+%token <str>   VALID VALIDATE VALUE VALUES VARCHAR VARIADIC VIEW VARYING VOLATILE
 
 %token <str>   WHEN WHERE WINDOW WITH WITHIN WITHOUT WRITE
 
@@ -476,14 +506,25 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> stmt
 
 %type <Statement> alter_table_stmt
+%type <Statement> alter_type_stmt
+%type <Statement> alter_user_stmt
+%type <Statement> analyze_stmt
 %type <Statement> backup_stmt
+%type <Statement> cancel_stmt
+%type <Statement> comment_stmt
 %type <Statement> copy_from_stmt
+%type <Statement> copy_to_stmt
 %type <Statement> create_stmt
 %type <Statement> create_database_stmt
 %type <Statement> create_index_stmt
 %type <Statement> create_table_stmt
 %type <Statement> create_table_as_stmt
+%type <Statement> create_function_stmt
+%type <Statement> create_policy_stmt
+%type <Statement> create_stats_stmt
+%type <Statement> create_trigger_stmt
 %type <Statement> create_user_stmt
+%type <Statement> create_type_stmt
 %type <Statement> create_view_stmt
 %type <Statement> delete_stmt
 %type <Statement> drop_stmt
@@ -493,6 +534,10 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement> preparable_stmt
 %type <Statement> execute_stmt
 %type <Statement> deallocate_stmt
+%type <Statement> declare_cursor_stmt
+%type <Statement> fetch_cursor_stmt
+%type <Statement> fetch_cursor_args
+%type <Statement> close_cursor_stmt
 %type <Statement> grant_stmt
 %type <Statement> insert_stmt
 %type <Statement> release_stmt
@@ -514,6 +559,9 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <[]string> opt_incremental
 %type <KVOption> kv_option
 %type <[]KVOption> kv_option_list opt_with_options
+%type <KVOption> copy_option
+%type <[]KVOption> copy_option_list opt_copy_options
+%type <str> copy_option_arg
 %type <str> opt_equal_value
 
 %type <*Select> select_no_parens
@@ -534,7 +582,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <ValidationBehavior> opt_validate_behavior
 
 %type <str> opt_template_clause opt_encoding_clause opt_lc_collate_clause opt_lc_ctype_clause
-%type <*string> opt_password
+%type <*string> opt_password comment_text
 
 %type <IsolationLevel> transaction_iso_level
 %type <UserPriority>  transaction_user_priority
@@ -562,6 +610,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %type <TableDefs> opt_table_elem_list table_elem_list
 %type <*InterleaveDef> opt_interleave
+%type <*ShardedIndexDef> opt_hash_sharded
 %type <empty> opt_all_clause
 %type <bool> distinct_clause
 %type <NameList> opt_column_list
@@ -621,6 +670,18 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 %type <Statement>  generic_set set_rest set_rest_more
 %type <Statement>  begin_transaction set_exprs_internal set_name
 %type <TransactionModes> transaction_mode_list transaction_mode
+%type <FunctionParam> func_param
+%type <FunctionParams> func_param_list opt_func_param_list
+%type <FunctionOptions> func_option func_option_list opt_func_option_list
+%type <FuncObj> func_obj
+%type <FuncObjs> func_obj_list
+%type <PolicyCommand> opt_policy_command
+%type <NameList> opt_policy_roles
+%type <Expr> opt_policy_using opt_policy_check
+%type <TriggerTiming> trigger_timing
+%type <TriggerEvent> trigger_event
+%type <TriggerEvents> trigger_event_list
+%type <bool> opt_trigger_for_each
 
 %type <NameList> opt_storing
 %type <*ColumnTableDef> column_def
@@ -662,6 +723,7 @@ func (u *sqlSymUnion) transactionModes() TransactionModes {
 
 %type <str> explain_option_name
 %type <[]string> explain_option_list
+%type <[]string> enum_val_list opt_enum_val_list
 
 %type <ColumnType> typename simple_typename const_typename
 %type <ColumnType> numeric opt_numeric_modifiers
@@ -810,8 +872,15 @@ stmt_list:
 
 stmt:
   alter_table_stmt
+| alter_type_stmt
+| alter_user_stmt
+| analyze_stmt
 | backup_stmt
+| cancel_stmt
+| close_cursor_stmt
+| comment_stmt
 | copy_from_stmt
+| copy_to_stmt
 | create_stmt
 | delete_stmt
 | drop_stmt
@@ -820,6 +889,8 @@ stmt:
 | prepare_stmt
 | execute_stmt
 | deallocate_stmt
+| declare_cursor_stmt
+| fetch_cursor_stmt
 | grant_stmt
 | insert_stmt
 | rename_stmt
@@ -855,6 +926,69 @@ alter_table_stmt:
     $$.val = &AlterTable{Table: $5.normalizableTableName(), IfExists: true, Cmds: $6.alterTableCmds()}
   }
 
+// ALTER TYPE <name> ADD VALUE [IF NOT EXISTS] <value> [BEFORE|AFTER <value>]
+alter_type_stmt:
+  ALTER TYPE any_name ADD VALUE SCONST
+  {
+    $$.val = &AlterTypeAddValue{Type: $3.normalizableTableName(), NewVal: $6}
+  }
+| ALTER TYPE any_name ADD VALUE SCONST BEFORE SCONST
+  {
+    $$.val = &AlterTypeAddValue{
+      Type: $3.normalizableTableName(),
+      NewVal: $6,
+      Placement: &AlterTypeAddValuePlacement{Before: true, ExistingVal: $8},
+    }
+  }
+| ALTER TYPE any_name ADD VALUE SCONST AFTER SCONST
+  {
+    $$.val = &AlterTypeAddValue{
+      Type: $3.normalizableTableName(),
+      NewVal: $6,
+      Placement: &AlterTypeAddValuePlacement{Before: false, ExistingVal: $8},
+    }
+  }
+| ALTER TYPE any_name ADD VALUE IF NOT EXISTS SCONST
+  {
+    $$.val = &AlterTypeAddValue{Type: $3.normalizableTableName(), NewVal: $9, IfNotExists: true}
+  }
+| ALTER TYPE any_name ADD VALUE IF NOT EXISTS SCONST BEFORE SCONST
+  {
+    $$.val = &AlterTypeAddValue{
+      Type: $3.normalizableTableName(),
+      NewVal: $9,
+      IfNotExists: true,
+      Placement: &AlterTypeAddValuePlacement{Before: true, ExistingVal: $11},
+    }
+  }
+| ALTER TYPE any_name ADD VALUE IF NOT EXISTS SCONST AFTER SCONST
+  {
+    $$.val = &AlterTypeAddValue{
+      Type: $3.normalizableTableName(),
+      NewVal: $9,
+      IfNotExists: true,
+      Placement: &AlterTypeAddValuePlacement{Before: false, ExistingVal: $11},
+    }
+  }
+
+// ALTER USER <name> SET <var> { TO | = } { <value> | DEFAULT }
+// ALTER USER <name> RESET <var>
+// ALTER USER <name> [WITH] PASSWORD <password>
+alter_user_stmt:
+  ALTER USER name SET generic_set
+  {
+    set := $5.stmt().(*Set)
+    $$.val = &AlterUserSet{Name: Name($3), VarName: set.Name.(UnresolvedName), Values: set.Values}
+  }
+| ALTER USER name RESET var_name
+  {
+    $$.val = &AlterUserSet{Name: Name($3), VarName: $5.unresolvedName()}
+  }
+| ALTER USER name opt_with PASSWORD SCONST
+  {
+    $$.val = &AlterUserSetPassword{Name: Name($3), Password: $6}
+  }
+
 alter_table_cmds:
   alter_table_cmd
   {
@@ -956,6 +1090,16 @@ alter_table_cmd:
       DropBehavior: $4.dropBehavior(),
     }
   }
+  // ALTER TABLE <name> ENABLE ROW LEVEL SECURITY
+| ENABLE ROW LEVEL SECURITY
+  {
+    $$.val = &AlterTableSetRowLevelSecurity{Enabled: true}
+  }
+  // ALTER TABLE <name> DISABLE ROW LEVEL SECURITY
+| DISABLE ROW LEVEL SECURITY
+  {
+    $$.val = &AlterTableSetRowLevelSecurity{Enabled: false}
+  }
 
 alter_column_default:
   SET DEFAULT a_expr
@@ -1072,26 +1216,129 @@ opt_with_options:
   }
 | /* EMPTY */ {}
 
+
+// COMMENT ON { DATABASE <name> | TABLE <name> | COLUMN <table>.<column> |
+// INDEX <table>@<index> } IS { <comment> | NULL }
+comment_stmt:
+  COMMENT ON DATABASE name IS comment_text
+  {
+    $$.val = &CommentOnDatabase{Name: Name($4), Comment: $6.strPtr()}
+  }
+| COMMENT ON TABLE qualified_name IS comment_text
+  {
+    $$.val = &CommentOnTable{Table: $4.normalizableTableName(), Comment: $6.strPtr()}
+  }
+| COMMENT ON COLUMN qualified_name IS comment_text
+  {
+    varName, err := $4.unresolvedName().NormalizeVarName()
+    if err != nil {
+      sqllex.Error(err.Error())
+      return 1
+    }
+    columnItem, ok := varName.(*ColumnItem)
+    if !ok {
+      sqllex.Error(fmt.Sprintf("invalid column name: %q", $4.unresolvedName()))
+      return 1
+    }
+    $$.val = &CommentOnColumn{ColumnItem: columnItem, Comment: $6.strPtr()}
+  }
+| COMMENT ON INDEX table_name_with_index IS comment_text
+  {
+    $$.val = &CommentOnIndex{Index: $4.tableWithIdx(), Comment: $6.strPtr()}
+  }
+
+comment_text:
+  SCONST
+  {
+    comment := $1
+    $$.val = &comment
+  }
+| NULL
+  {
+    $$.val = (*string)(nil)
+  }
+
 copy_from_stmt:
-  COPY qualified_name FROM STDIN
+  COPY qualified_name FROM STDIN opt_copy_options
   {
-    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Stdin: true}
+    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Stdin: true, Options: $5.kvOptions()}
   }
-| COPY qualified_name '(' ')' FROM STDIN
+| COPY qualified_name '(' ')' FROM STDIN opt_copy_options
   {
-    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Stdin: true}
+    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Stdin: true, Options: $7.kvOptions()}
   }
-| COPY qualified_name '(' qualified_name_list ')' FROM STDIN
+| COPY qualified_name '(' qualified_name_list ')' FROM STDIN opt_copy_options
   {
-    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Columns: $4.unresolvedNames(), Stdin: true}
+    $$.val = &CopyFrom{Table: $2.normalizableTableName(), Columns: $4.unresolvedNames(), Stdin: true, Options: $8.kvOptions()}
   }
 
-// CREATE [DATABASE|INDEX|TABLE|TABLE AS|VIEW]
+copy_to_stmt:
+  COPY qualified_name TO STDOUT opt_copy_options
+  {
+    $$.val = &CopyTo{Table: $2.normalizableTableName(), Options: $5.kvOptions()}
+  }
+| COPY qualified_name '(' qualified_name_list ')' TO STDOUT opt_copy_options
+  {
+    $$.val = &CopyTo{Table: $2.normalizableTableName(), Columns: $4.unresolvedNames(), Options: $8.kvOptions()}
+  }
+| COPY select_with_parens TO STDOUT opt_copy_options
+  {
+    $$.val = &CopyTo{Select: $2.slct(), Options: $5.kvOptions()}
+  }
+
+opt_copy_options:
+  opt_with '(' copy_option_list ')'
+  {
+    $$.val = $3.kvOptions()
+  }
+| /* EMPTY */ {}
+
+copy_option_list:
+  copy_option
+  {
+    $$.val = []KVOption{$1.kvOption()}
+  }
+| copy_option_list ',' copy_option
+  {
+    $$.val = append($1.kvOptions(), $3.kvOption())
+  }
+
+copy_option:
+  unrestricted_name copy_option_arg
+  {
+    $$.val = KVOption{Key: $1, Value: $2}
+  }
+
+copy_option_arg:
+  non_reserved_word_or_sconst
+| TRUE
+  {
+    $$ = "true"
+  }
+| FALSE
+  {
+    $$ = "false"
+  }
+| ON
+  {
+    $$ = "on"
+  }
+| /* EMPTY */
+  {
+    $$ = ""
+  }
+
+// CREATE [DATABASE|FUNCTION|INDEX|POLICY|STATISTICS|TABLE|TABLE AS|TRIGGER|TYPE|USER|VIEW]
 create_stmt:
   create_database_stmt
+| create_function_stmt
 | create_index_stmt
+| create_policy_stmt
+| create_stats_stmt
 | create_table_stmt
 | create_table_as_stmt
+| create_trigger_stmt
+| create_type_stmt
 | create_user_stmt
 | create_view_stmt
 
@@ -1144,6 +1391,38 @@ drop_stmt:
   {
     $$.val = &DropView{Names: $5.tableNameReferences(), IfExists: true, DropBehavior: $6.dropBehavior()}
   }
+| DROP TYPE table_name_list opt_drop_behavior
+  {
+    $$.val = &DropType{Names: $3.tableNameReferences(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP TYPE IF EXISTS table_name_list opt_drop_behavior
+  {
+    $$.val = &DropType{Names: $5.tableNameReferences(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP FUNCTION func_obj_list opt_drop_behavior
+  {
+    $$.val = &DropFunction{Functions: $3.funcObjs(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP FUNCTION IF EXISTS func_obj_list opt_drop_behavior
+  {
+    $$.val = &DropFunction{Functions: $5.funcObjs(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP POLICY name ON qualified_name
+  {
+    $$.val = &DropPolicy{Name: Name($3), Table: $5.normalizableTableName(), IfExists: false}
+  }
+| DROP POLICY IF EXISTS name ON qualified_name
+  {
+    $$.val = &DropPolicy{Name: Name($5), Table: $7.normalizableTableName(), IfExists: true}
+  }
+| DROP TRIGGER name ON qualified_name
+  {
+    $$.val = &DropTrigger{Name: Name($3), Table: $5.normalizableTableName(), IfExists: false}
+  }
+| DROP TRIGGER IF EXISTS name ON qualified_name
+  {
+    $$.val = &DropTrigger{Name: Name($5), Table: $7.normalizableTableName(), IfExists: true}
+  }
 | DROP USER name_list
   {
     $$.val = &DropUser{Names: $3.nameList(), IfExists: false}
@@ -1189,6 +1468,10 @@ explain_stmt:
   {
     $$.val = &Explain{Statement: $2.stmt()}
   }
+| EXPLAIN ANALYZE preparable_stmt
+  {
+    $$.val = &Explain{Options: []string{"analyze"}, Statement: $3.stmt()}
+  }
 | EXPLAIN '(' explain_option_list ')' preparable_stmt
   {
     $$.val = &Explain{Options: $3.strs(), Statement: $5.stmt()}
@@ -1224,6 +1507,10 @@ explain_option_list:
 
 explain_option_name:
   non_reserved_word
+| ANALYZE
+  {
+    $$ = "analyze"
+  }
 
 // PREPARE <plan_name> [(args, ...)] AS <query>
 prepare_stmt:
@@ -1291,6 +1578,87 @@ deallocate_stmt:
     $$.val = &Deallocate{}
   }
 
+// DECLARE <name> CURSOR FOR <select>
+declare_cursor_stmt:
+  DECLARE name CURSOR FOR select_stmt
+  {
+    $$.val = &DeclareCursor{Name: Name($2), Select: $5.slct()}
+  }
+
+// FETCH [<direction>] [FROM | IN] <name>
+// MOVE [<direction>] [FROM | IN] <name>
+//
+// Only forward directions are supported.
+fetch_cursor_stmt:
+  FETCH fetch_cursor_args
+  {
+    $$.val = $2.stmt()
+  }
+| MOVE fetch_cursor_args
+  {
+    fetch := $2.stmt().(*FetchCursor)
+    fetch.Move = true
+    $$.val = fetch
+  }
+
+fetch_cursor_args:
+  name
+  {
+    $$.val = &FetchCursor{Name: Name($1), Count: 1}
+  }
+| from_or_in name
+  {
+    $$.val = &FetchCursor{Name: Name($2), Count: 1}
+  }
+| NEXT opt_from_or_in name
+  {
+    $$.val = &FetchCursor{Name: Name($3), Count: 1}
+  }
+| FORWARD opt_from_or_in name
+  {
+    $$.val = &FetchCursor{Name: Name($3), Count: 1}
+  }
+| ALL opt_from_or_in name
+  {
+    $$.val = &FetchCursor{Name: Name($3), Count: FetchAll}
+  }
+| FORWARD ALL opt_from_or_in name
+  {
+    $$.val = &FetchCursor{Name: Name($4), Count: FetchAll}
+  }
+| ICONST opt_from_or_in name
+  {
+    count, err := $1.numVal().AsInt64()
+    if err != nil { sqllex.Error(err.Error()); return 1 }
+    $$.val = &FetchCursor{Name: Name($3), Count: count}
+  }
+| FORWARD ICONST opt_from_or_in name
+  {
+    count, err := $2.numVal().AsInt64()
+    if err != nil { sqllex.Error(err.Error()); return 1 }
+    $$.val = &FetchCursor{Name: Name($4), Count: count}
+  }
+
+from_or_in:
+  FROM {}
+| IN {}
+
+opt_from_or_in:
+  from_or_in {}
+| /* EMPTY */ {}
+
+// CLOSE <name>
+// CLOSE ALL
+close_cursor_stmt:
+  CLOSE name
+  {
+    $$.val = &CloseCursor{Name: Name($2)}
+  }
+| CLOSE ALL
+  {
+    $$.val = &CloseCursor{}
+  }
+
 // GRANT privileges ON targets TO grantee_list
 grant_stmt:
   GRANT privileges ON targets TO grantee_list
@@ -1368,6 +1736,10 @@ privilege:
   {
     $$.val = privilege.UPDATE
   }
+| BYPASSRLS
+  {
+    $$.val = privilege.BYPASSRLS
+  }
 
 // TODO(marc): this should not be 'name', but should instead be a
 // type just for usernames.
@@ -1687,6 +2059,16 @@ show_stmt:
   {
     $$.val = &ShowIndex{Table: $4.normalizableTableName()}
   }
+| SHOW STATISTICS FOR TABLE qualified_name
+  {
+    $$.val = &ShowStats{Table: $5.normalizableTableName()}
+  }
+| SHOW HISTOGRAM ICONST
+  {
+    id, err := $3.numVal().AsInt64()
+    if err != nil { sqllex.Error(err.Error()); return 1 }
+    $$.val = &ShowHistogram{HistogramID: id}
+  }
 | SHOW QUERIES
   {
     $$.val = &ShowQueries{Cluster: true}
@@ -2020,23 +2402,25 @@ col_qualification_elem:
  }
 
 index_def:
-  INDEX opt_name '(' index_params ')' opt_storing opt_interleave
+  INDEX opt_name '(' index_params ')' opt_hash_sharded opt_storing opt_interleave
   {
     $$.val = &IndexTableDef{
       Name:    Name($2),
       Columns: $4.idxElems(),
-      Storing: $6.nameList(),
-      Interleave: $7.interleave(),
+      Sharded: $6.shardedIndexDef(),
+      Storing: $7.nameList(),
+      Interleave: $8.interleave(),
     }
   }
-| UNIQUE INDEX opt_name '(' index_params ')' opt_storing opt_interleave
+| UNIQUE INDEX opt_name '(' index_params ')' opt_hash_sharded opt_storing opt_interleave
   {
     $$.val = &UniqueConstraintTableDef{
       IndexTableDef: IndexTableDef {
         Name:    Name($3),
         Columns: $5.idxElems(),
-        Storing: $7.nameList(),
-        Interleave: $8.interleave(),
+        Sharded: $7.shardedIndexDef(),
+        Storing: $8.nameList(),
+        Interleave: $9.interleave(),
       },
     }
   }
@@ -2071,21 +2455,23 @@ constraint_elem:
       Expr: $3.expr(),
     }
   }
-| UNIQUE '(' index_params ')' opt_storing opt_interleave
+| UNIQUE '(' index_params ')' opt_hash_sharded opt_storing opt_interleave
   {
     $$.val = &UniqueConstraintTableDef{
       IndexTableDef: IndexTableDef{
         Columns: $3.idxElems(),
-        Storing: $5.nameList(),
-        Interleave: $6.interleave(),
+        Sharded: $5.shardedIndexDef(),
+        Storing: $6.nameList(),
+        Interleave: $7.interleave(),
       },
     }
   }
-| PRIMARY KEY '(' index_params ')'
+| PRIMARY KEY '(' index_params ')' opt_hash_sharded
   {
     $$.val = &UniqueConstraintTableDef{
       IndexTableDef: IndexTableDef{
         Columns: $4.idxElems(),
+        Sharded: $6.shardedIndexDef(),
       },
       PrimaryKey:    true,
     }
@@ -2113,6 +2499,21 @@ storing:
 // is a list of <columnID, value> pairs which will allow both adding
 // and dropping columns without rewriting indexes that are storing the
 // adjusted column.
+// opt_hash_sharded splits the key space of an index in a fixed number of
+// buckets, so that sequential writes are spread across the ranges of the
+// buckets instead of all hitting the last range of the index.
+opt_hash_sharded:
+  USING HASH WITH BUCKET_COUNT '=' a_expr
+  {
+    $$.val = &ShardedIndexDef{
+      ShardBuckets: $6.expr(),
+    }
+  }
+| /* EMPTY */
+  {
+    $$.val = (*ShardedIndexDef)(nil)
+  }
+
 opt_storing:
   storing '(' name_list ')'
   {
@@ -2202,6 +2603,40 @@ opt_password:
     $$.val = (*string)(nil)
   }
 
+// CREATE STATISTICS name [ON column [, column ...]] FROM relname
+create_stats_stmt:
+  CREATE STATISTICS name FROM qualified_name
+  {
+    $$.val = &CreateStats{Name: Name($3), Table: $5.normalizableTableName()}
+  }
+| CREATE STATISTICS name ON name_list FROM qualified_name
+  {
+    $$.val = &CreateStats{Name: Name($3), ColumnNames: $5.nameList(), Table: $7.normalizableTableName()}
+  }
+
+// ANALYZE relname
+analyze_stmt:
+  ANALYZE qualified_name
+  {
+    $$.val = &Analyze{Table: $2.normalizableTableName()}
+  }
+| ANALYSE qualified_name
+  {
+    $$.val = &Analyze{Table: $2.normalizableTableName()}
+  }
+
+// CANCEL QUERY <query-id>
+// CANCEL SESSION <session-id>
+cancel_stmt:
+  CANCEL QUERY a_expr
+  {
+    $$.val = &CancelQuery{ID: $3.expr()}
+  }
+| CANCEL SESSION a_expr
+  {
+    $$.val = &CancelSession{ID: $3.expr()}
+  }
+
 // CREATE VIEW relname
 create_view_stmt:
   CREATE VIEW any_name opt_column_list AS select_stmt
@@ -2215,20 +2650,308 @@ create_view_stmt:
 
 // TODO(a-robinson): CREATE OR REPLACE VIEW support (#2971).
 
+// CREATE TYPE <name> AS ENUM (<value> [, <value> ...])
+create_type_stmt:
+  CREATE TYPE any_name AS ENUM '(' opt_enum_val_list ')'
+  {
+    $$.val = &CreateType{Name: $3.normalizableTableName(), EnumLabels: $7.strs()}
+  }
+
+opt_enum_val_list:
+  enum_val_list
+| /* EMPTY */
+  {
+    $$.val = []string(nil)
+  }
+
+enum_val_list:
+  SCONST
+  {
+    $$.val = []string{$1}
+  }
+| enum_val_list ',' SCONST
+  {
+    $$.val = append($1.strs(), $3)
+  }
+
+// CREATE POLICY <name> ON <table> [FOR ALL|SELECT|INSERT|UPDATE|DELETE]
+//   [TO <user> [, ...]] [USING (<expr>)] [WITH CHECK (<expr>)]
+create_policy_stmt:
+  CREATE POLICY name ON qualified_name opt_policy_command opt_policy_roles opt_policy_using opt_policy_check
+  {
+    $$.val = &CreatePolicy{
+      Name: Name($3),
+      Table: $5.normalizableTableName(),
+      Command: $6.policyCommand(),
+      Roles: $7.nameList(),
+      Using: $8.expr(),
+      WithCheck: $9.expr(),
+    }
+  }
+
+opt_policy_command:
+  FOR ALL
+  {
+    $$.val = PolicyAll
+  }
+| FOR SELECT
+  {
+    $$.val = PolicySelect
+  }
+| FOR INSERT
+  {
+    $$.val = PolicyInsert
+  }
+| FOR UPDATE
+  {
+    $$.val = PolicyUpdate
+  }
+| FOR DELETE
+  {
+    $$.val = PolicyDelete
+  }
+| /* EMPTY */
+  {
+    $$.val = PolicyAll
+  }
+
+opt_policy_roles:
+  TO name_list
+  {
+    $$.val = $2.nameList()
+  }
+| /* EMPTY */
+  {
+    $$.val = NameList(nil)
+  }
+
+opt_policy_using:
+  USING '(' a_expr ')'
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = Expr(nil)
+  }
+
+opt_policy_check:
+  WITH CHECK '(' a_expr ')'
+  {
+    $$.val = $4.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = Expr(nil)
+  }
+
+// CREATE FUNCTION <name> ( [<argname> <argtype> [, ...]] ) RETURNS <rettype>|TRIGGER
+//   [ LANGUAGE SQL | IMMUTABLE | STABLE | VOLATILE ] ... AS '<definition>'
+create_function_stmt:
+  CREATE FUNCTION any_name '(' opt_func_param_list ')' RETURNS typename opt_func_option_list AS SCONST opt_func_option_list
+  {
+    opts := $9.functionOptions()
+    if err := opts.merge($12.functionOptions()); err != nil {
+      sqllex.Error(err.Error())
+      return 1
+    }
+    $$.val = &CreateFunction{
+      Name: $3.normalizableTableName(),
+      Params: $5.functionParams(),
+      ReturnType: $8.colType(),
+      Options: opts,
+      Body: $11,
+    }
+  }
+| CREATE FUNCTION any_name '(' opt_func_param_list ')' RETURNS TRIGGER opt_func_option_list AS SCONST opt_func_option_list
+  {
+    opts := $9.functionOptions()
+    if err := opts.merge($12.functionOptions()); err != nil {
+      sqllex.Error(err.Error())
+      return 1
+    }
+    $$.val = &CreateFunction{
+      Name: $3.normalizableTableName(),
+      Params: $5.functionParams(),
+      ReturnsTrigger: true,
+      Options: opts,
+      Body: $11,
+    }
+  }
+
+// CREATE TRIGGER <name> BEFORE|AFTER <event> [OR <event> ...] ON <table>
+//   [FOR [EACH] ROW|STATEMENT] EXECUTE PROCEDURE|FUNCTION <function>()
+create_trigger_stmt:
+  CREATE TRIGGER name trigger_timing trigger_event_list ON qualified_name opt_trigger_for_each EXECUTE procedure_or_function any_name '(' ')'
+  {
+    $$.val = &CreateTrigger{
+      Name: Name($3),
+      Timing: $4.triggerTiming(),
+      Events: $5.triggerEvents(),
+      Table: $7.normalizableTableName(),
+      ForEachRow: $8.bool(),
+      Func: $11.normalizableTableName(),
+    }
+  }
+
+trigger_timing:
+  BEFORE
+  {
+    $$.val = TriggerBefore
+  }
+| AFTER
+  {
+    $$.val = TriggerAfter
+  }
+
+trigger_event_list:
+  trigger_event
+  {
+    $$.val = TriggerEvents{$1.triggerEvent()}
+  }
+| trigger_event_list OR trigger_event
+  {
+    $$.val = append($1.triggerEvents(), $3.triggerEvent())
+  }
+
+trigger_event:
+  INSERT
+  {
+    $$.val = TriggerInsert
+  }
+| UPDATE
+  {
+    $$.val = TriggerUpdate
+  }
+| DELETE
+  {
+    $$.val = TriggerDelete
+  }
+
+opt_trigger_for_each:
+  FOR opt_each ROW
+  {
+    $$.val = true
+  }
+| FOR opt_each STATEMENT
+  {
+    $$.val = false
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
+
+opt_each:
+  EACH {}
+| /* EMPTY */ {}
+
+procedure_or_function:
+  PROCEDURE {}
+| FUNCTION {}
+
+opt_func_param_list:
+  func_param_list
+| /* EMPTY */
+  {
+    $$.val = FunctionParams(nil)
+  }
+
+func_param_list:
+  func_param
+  {
+    $$.val = FunctionParams{$1.functionParam()}
+  }
+| func_param_list ',' func_param
+  {
+    $$.val = append($1.functionParams(), $3.functionParam())
+  }
+
+func_param:
+  name typename
+  {
+    $$.val = FunctionParam{Name: Name($1), Type: $2.colType()}
+  }
+
+opt_func_option_list:
+  func_option_list
+| /* EMPTY */
+  {
+    $$.val = FunctionOptions{}
+  }
+
+func_option_list:
+  func_option
+| func_option_list func_option
+  {
+    a := $1.functionOptions()
+    if err := a.merge($2.functionOptions()); err != nil {
+      sqllex.Error(err.Error())
+      return 1
+    }
+    $$.val = a
+  }
+
+func_option:
+  IMMUTABLE
+  {
+    $$.val = FunctionOptions{Volatility: Immutable}
+  }
+| STABLE
+  {
+    $$.val = FunctionOptions{Volatility: Stable}
+  }
+| VOLATILE
+  {
+    $$.val = FunctionOptions{Volatility: Volatile}
+  }
+| LANGUAGE name
+  {
+    if $2 != "sql" {
+      return unimplemented(sqllex, "function language " + $2)
+    }
+    $$.val = FunctionOptions{}
+  }
+
+func_obj_list:
+  func_obj
+  {
+    $$.val = FuncObjs{$1.funcObj()}
+  }
+| func_obj_list ',' func_obj
+  {
+    $$.val = append($1.funcObjs(), $3.funcObj())
+  }
+
+func_obj:
+  any_name
+  {
+    $$.val = FuncObj{Name: $1.normalizableTableName()}
+  }
+| any_name '(' ')'
+  {
+    $$.val = FuncObj{Name: $1.normalizableTableName(), ArgTypes: []ColumnType{}}
+  }
+| any_name '(' type_list ')'
+  {
+    $$.val = FuncObj{Name: $1.normalizableTableName(), ArgTypes: $3.colTypes()}
+  }
+
 // CREATE INDEX
 create_index_stmt:
-  CREATE opt_unique INDEX opt_name ON qualified_name '(' index_params ')' opt_storing opt_interleave
+  CREATE opt_unique INDEX opt_name ON qualified_name '(' index_params ')' opt_hash_sharded opt_storing opt_interleave
   {
     $$.val = &CreateIndex{
       Name:    Name($4),
       Table:   $6.normalizableTableName(),
       Unique:  $2.bool(),
       Columns: $8.idxElems(),
-      Storing: $10.nameList(),
-      Interleave: $11.interleave(),
+      Sharded: $10.shardedIndexDef(),
+      Storing: $11.nameList(),
+      Interleave: $12.interleave(),
     }
   }
-| CREATE opt_unique INDEX IF NOT EXISTS name ON qualified_name '(' index_params ')' opt_storing opt_interleave
+| CREATE opt_unique INDEX IF NOT EXISTS name ON qualified_name '(' index_params ')' opt_hash_sharded opt_storing opt_interleave
   {
     $$.val = &CreateIndex{
       Name:        Name($7),
@@ -2236,8 +2959,9 @@ create_index_stmt:
       Unique:      $2.bool(),
       IfNotExists: true,
       Columns:     $11.idxElems(),
-      Storing:     $13.nameList(),
-      Interleave: $14.interleave(),
+      Sharded:     $13.shardedIndexDef(),
+      Storing:     $14.nameList(),
+      Interleave: $15.interleave(),
     }
   }
 
@@ -3525,6 +4249,14 @@ simple_typename:
   {
     $$.val = int2vectorColType
   }
+| IDENT
+  {
+    $$.val = &UserDefinedColType{Name: NormalizableTableName{UnresolvedName{Name($1)}}}
+  }
+| IDENT '.' IDENT
+  {
+    $$.val = &UserDefinedColType{Name: NormalizableTableName{UnresolvedName{Name($1), Name($3)}}}
+  }
 
 // We have a separate const_typename to allow defaulting fixed-length types
 // such as CHAR() and BIT() to an unspecified length. SQL9x requires that these
@@ -4423,8 +5155,14 @@ func_expr_common_subexpr:
     $$.val = &FuncExpr{Func: wrapFunction($1)}
   }
 | CURRENT_ROLE { return unimplemented(sqllex, "current role") }
-| CURRENT_USER { return unimplemented(sqllex, "current user") }
-| SESSION_USER { return unimplemented(sqllex, "session user") }
+| CURRENT_USER
+  {
+    $$.val = &FuncExpr{Func: wrapFunction($1)}
+  }
+| SESSION_USER
+  {
+    $$.val = &FuncExpr{Func: wrapFunction($1)}
+  }
 | USER { return unimplemented(sqllex, "user") }
 | CAST '(' a_expr AS cast_target ')'
   {
@@ -5297,15 +6035,22 @@ unrestricted_name:
 unreserved_keyword:
   ACTION
 | ADD
+| AFTER
 | ALTER
 | AT
 | BACKUP
+| BEFORE
 | BEGIN
 | BLOB
+| BUCKET_COUNT
 | BY
+| BYPASSRLS
+| CANCEL
 | CASCADE
+| CLOSE
 | CLUSTER
 | COLUMNS
+| COMMENT
 | COMMIT
 | COMMITTED
 | CONFLICT
@@ -5314,16 +6059,22 @@ unreserved_keyword:
 | COVERING
 | CUBE
 | CURRENT
+| CURSOR
 | CYCLE
 | DATA
 | DATABASE
 | DATABASES
 | DAY
 | DEALLOCATE
+| DECLARE
 | DELETE
+| DISABLE
 | DOUBLE
 | DROP
+| EACH
+| ENABLE
 | ENCODING
+| ENUM
 | EXECUTE
 | EXPERIMENTAL_FINGERPRINTS
 | EXPLAIN
@@ -5331,10 +6082,15 @@ unreserved_keyword:
 | FIRST
 | FOLLOWING
 | FORCE_INDEX
+| FORWARD
+| FUNCTION
 | GRANTS
+| HASH
 | HELP
 | HIGH
+| HISTOGRAM
 | HOUR
+| IMMUTABLE
 | INCREMENTAL
 | INDEXES
 | INSERT
@@ -5343,6 +6099,7 @@ unreserved_keyword:
 | ISOLATION
 | KEY
 | KEYS
+| LANGUAGE
 | LC_COLLATE
 | LC_CTYPE
 | LEVEL
@@ -5351,6 +6108,7 @@ unreserved_keyword:
 | MATCH
 | MINUTE
 | MONTH
+| MOVE
 | NAMES
 | NAN
 | NEXT
@@ -5368,10 +6126,13 @@ unreserved_keyword:
 | PARTIAL
 | PARTITION
 | PASSWORD
+| POLICY
 | PRECEDING
 | PREPARE
 | PRIORITY
+| PROCEDURE
 | QUERIES
+| QUERY
 | RANGE
 | READ
 | RECURSIVE
@@ -5387,17 +6148,21 @@ unreserved_keyword:
 | RESET
 | RESTORE
 | RESTRICT
+| RETURNS
 | REVOKE
 | ROLLBACK
 | ROLLUP
 | ROWS
 | SETTING
 | SETTINGS
+| STATEMENT
+| STATISTICS
 | STATUS
 | SAVEPOINT
 | SCATTER
 | SEARCH
 | SECOND
+| SECURITY
 | SERIALIZABLE
 | SESSION
 | SESSIONS
@@ -5406,8 +6171,10 @@ unreserved_keyword:
 | SIMPLE
 | SNAPSHOT
 | SQL
+| STABLE
 | START
 | STDIN
+| STDOUT
 | STORING
 | STRICT
 | SPLIT
@@ -5419,6 +6186,7 @@ unreserved_keyword:
 | TEXT
 | TRACE
 | TRANSACTION
+| TRIGGER
 | TRUNCATE
 | TYPE
 | UNBOUNDED
@@ -5432,6 +6200,7 @@ unreserved_keyword:
 | VALIDATE
 | VALUE
 | VARYING
+| VOLATILE
 | WITHIN
 | WITHOUT
 | WRITE
@@ -79,6 +79,8 @@ var (
 	// TypeCollatedString is the type family of a DString. CANNOT be compared with
 	// ==.
 	TypeCollatedString Type = TCollatedString{}
+	// TypeEnum is the type family of a DEnum. CANNOT be compared with ==.
+	TypeEnum Type = &TEnum{}
 	// TypeBytes is the type of a DBytes. Can be compared with ==.
 	TypeBytes Type = tBytes{}
 	// TypeDate is the type of a DDate. Can be compared with ==.
@@ -331,6 +333,113 @@ func (t TCollatedString) IsAmbiguous() bool {
 	return t.Locale == ""
 }
 
+// UserDefinedTypeOidOffset is added to the descriptor ID of a user-defined
+// type to produce its Postgres object ID. It is chosen to be above the range
+// of OIDs used by builtin types.
+const UserDefinedTypeOidOffset = 100000
+
+// TypeIDToOid returns the Postgres object ID of the user-defined type with the
+// given descriptor ID.
+func TypeIDToOid(id uint32) oid.Oid {
+	return oid.Oid(UserDefinedTypeOidOffset + id)
+}
+
+// EnumMember is a single value of a user-defined ENUM type.
+type EnumMember struct {
+	// LogicalRep is the label of the value, as seen by clients.
+	LogicalRep string
+	// PhysicalRep is the order-preserving byte representation of the value
+	// that is stored on disk.
+	PhysicalRep []byte
+	// ReadOnly is set for values which have been added to the type but cannot
+	// be written yet, because not every node is guaranteed to know about them.
+	ReadOnly bool
+}
+
+// TEnum is the type of a user-defined ENUM. Its Members are kept in ascending
+// order of their physical representation, which is also their sort order.
+type TEnum struct {
+	// TypeID is the ID of the descriptor defining the type. The zero value is
+	// used for the type family.
+	TypeID  uint32
+	Name    string
+	Members []EnumMember
+}
+
+// String implements the fmt.Stringer interface.
+func (t *TEnum) String() string {
+	if t.TypeID == 0 {
+		return "enum"
+	}
+	return t.Name
+}
+
+// Equivalent implements the Type interface.
+func (t *TEnum) Equivalent(other Type) bool {
+	if other == TypeAny {
+		return true
+	}
+	u, ok := UnwrapType(other).(*TEnum)
+	if ok {
+		return t.TypeID == 0 || u.TypeID == 0 || t.TypeID == u.TypeID
+	}
+	return false
+}
+
+// FamilyEqual implements the Type interface.
+func (*TEnum) FamilyEqual(other Type) bool {
+	_, ok := UnwrapType(other).(*TEnum)
+	return ok
+}
+
+// Size implements the Type interface.
+func (*TEnum) Size() (uintptr, bool) {
+	return unsafe.Sizeof(DEnum{}), variableSize
+}
+
+// Oid implements the Type interface.
+func (t *TEnum) Oid() oid.Oid {
+	if t.TypeID == 0 {
+		return oid.T_anyenum
+	}
+	return TypeIDToOid(t.TypeID)
+}
+
+// SQLName implements the Type interface.
+func (t *TEnum) SQLName() string {
+	if t.TypeID == 0 {
+		return "anyenum"
+	}
+	return t.Name
+}
+
+// IsAmbiguous implements the Type interface.
+func (t *TEnum) IsAmbiguous() bool {
+	return t.TypeID == 0
+}
+
+// memberByLogicalRep returns the index of the member with the given label, or
+// -1 if there is none.
+func (t *TEnum) memberByLogicalRep(s string) int {
+	for i := range t.Members {
+		if t.Members[i].LogicalRep == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// memberByPhysicalRep returns the index of the member with the given physical
+// representation, or -1 if there is none.
+func (t *TEnum) memberByPhysicalRep(b []byte) int {
+	for i := range t.Members {
+		if bytes.Equal(t.Members[i].PhysicalRep, b) {
+			return i
+		}
+	}
+	return -1
+}
+
 type tBytes struct{}
 
 func (tBytes) String() string              { return "bytes" }
@@ -46,6 +46,47 @@ type SemaContext struct {
 	// the root user.
 	// TODO(knz): this attribute can be moved to EvalContext pending #15363.
 	privileged bool
+
+	// TypeResolver, if set, is used to resolve references to user-defined
+	// types. If it is not set, such references cause an error.
+	TypeResolver TypeResolver
+
+	// FunctionResolver, if set, is used to resolve references to
+	// user-defined functions. If it is not set, such references cause an
+	// error.
+	FunctionResolver FunctionResolver
+}
+
+// ResolveFunction resolves the function reference fn, using the
+// FunctionResolver of the context for names which do not refer to builtins.
+func (sc *SemaContext) ResolveFunction(fn *ResolvableFunctionReference) (*FunctionDefinition, error) {
+	if sc == nil {
+		return fn.Resolve(nil)
+	}
+	return fn.ResolveUserDefined(sc.SearchPath, sc.FunctionResolver)
+}
+
+// TypeResolver resolves the names of user-defined types.
+type TypeResolver interface {
+	// ResolveType returns the type with the given name.
+	ResolveType(name *NormalizableTableName) (Type, error)
+}
+
+// ResolveUserDefinedType resolves t using the TypeResolver of the context,
+// if it has not been resolved already.
+func (sc *SemaContext) ResolveUserDefinedType(t *UserDefinedColType) error {
+	if t.Typ != nil {
+		return nil
+	}
+	if sc == nil || sc.TypeResolver == nil {
+		return pgerror.NewErrorf(pgerror.CodeUndefinedObjectError, "type %q does not exist", t.Name)
+	}
+	typ, err := sc.TypeResolver.ResolveType(&t.Name)
+	if err != nil {
+		return err
+	}
+	t.Typ = typ
+	return nil
 }
 
 // MakeSemaContext initializes a simple SemaContext suitable
@@ -198,6 +239,11 @@ func (expr *CaseExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExpr, erro
 
 // TypeCheck implements the Expr interface.
 func (expr *CastExpr) TypeCheck(ctx *SemaContext, _ Type) (TypedExpr, error) {
+	if t, ok := expr.Type.(*UserDefinedColType); ok {
+		if err := ctx.ResolveUserDefinedType(t); err != nil {
+			return nil, err
+		}
+	}
 	returnType := expr.castType()
 
 	// The desired type provided to a CastExpr is ignored. Instead,
@@ -278,6 +324,11 @@ func (expr *IndirectionExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExp
 
 // TypeCheck implements the Expr interface.
 func (expr *AnnotateTypeExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExpr, error) {
+	if t, ok := expr.Type.(*UserDefinedColType); ok {
+		if err := ctx.ResolveUserDefinedType(t); err != nil {
+			return nil, err
+		}
+	}
 	annotType := expr.annotationType()
 	subExpr, err := typeCheckAndRequire(ctx, expr.Expr, annotType,
 		fmt.Sprintf("type annotation for %v as %s, found", expr.Expr, annotType))
@@ -363,15 +414,17 @@ func (expr *ExistsExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExpr, er
 
 // TypeCheck implements the Expr interface.
 func (expr *FuncExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExpr, error) {
-	var searchPath SearchPath
-	if ctx != nil {
-		searchPath = ctx.SearchPath
-	}
-	def, err := expr.Func.Resolve(searchPath)
+	def, err := ctx.ResolveFunction(&expr.Func)
 	if err != nil {
 		return nil, err
 	}
 
+	if def.UserDefined != nil {
+		if expr.Type != 0 || expr.Filter != nil || expr.WindowDef != nil {
+			return nil, fmt.Errorf("%s() is not an aggregate function", expr.Func)
+		}
+	}
+
 	typedSubExprs, fn, err := typeCheckOverloadedExprs(ctx, desired, def.Definition, expr.Exprs...)
 	if err != nil {
 		return nil, fmt.Errorf("%s(): %v", def.Name, err)
@@ -451,6 +504,19 @@ func (expr *FuncExpr) TypeCheck(ctx *SemaContext, desired Type) (TypedExpr, erro
 			"insufficient privilege to use %s", expr.Func)
 	}
 
+	if def.UserDefined != nil {
+		// Calls to user-defined functions are replaced by the body of the
+		// function where possible, so that they can be optimized along with
+		// the rest of the query.
+		inlined, err := def.UserDefined.inline(ctx, typedSubExprs)
+		if err != nil {
+			return nil, err
+		}
+		if inlined != nil {
+			return inlined, nil
+		}
+	}
+
 	for i, subExpr := range typedSubExprs {
 		expr.Exprs[i] = subExpr
 	}
@@ -762,6 +828,10 @@ func (d *DString) TypeCheck(_ *SemaContext, _ Type) (TypedExpr, error) { return
 // identity function for Datum.
 func (d *DCollatedString) TypeCheck(_ *SemaContext, _ Type) (TypedExpr, error) { return d, nil }
 
+// TypeCheck implements the Expr interface. It is implemented as an idempotent
+// identity function for Datum.
+func (d *DEnum) TypeCheck(_ *SemaContext, _ Type) (TypedExpr, error) { return d, nil }
+
 // TypeCheck implements the Expr interface. It is implemented as an idempotent
 // identity function for Datum.
 func (d *DBytes) TypeCheck(_ *SemaContext, _ Type) (TypedExpr, error) { return d, nil }
@@ -994,7 +1064,8 @@ func typeCheckComparisonOp(
 	}
 
 	if fn == nil ||
-		(leftReturn.FamilyEqual(TypeCollatedString) && !leftReturn.Equivalent(rightReturn)) {
+		(leftReturn.FamilyEqual(TypeCollatedString) && !leftReturn.Equivalent(rightReturn)) ||
+		(leftReturn.FamilyEqual(TypeEnum) && !leftReturn.Equivalent(rightReturn)) {
 		return nil, nil, CmpOp{},
 			fmt.Errorf(unsupportedCompErrFmtWithTypes, leftReturn, op, rightReturn)
 	}
@@ -0,0 +1,341 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import (
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// FunctionResolver resolves the names of user-defined functions.
+type FunctionResolver interface {
+	// ResolveUserDefinedFunction returns the user-defined function with the
+	// given name, or nil if there is no such function.
+	ResolveUserDefinedFunction(name UnresolvedName) (*UserDefinedFunction, error)
+}
+
+// UserDefinedFunction is a function created with CREATE FUNCTION.
+type UserDefinedFunction struct {
+	// ID is the ID of the descriptor of the function.
+	ID uint32
+	// Name is the name of the function, qualified with its database.
+	Name string
+	// Params holds the names and types of the parameters.
+	Params     ArgTypes
+	ReturnType Type
+	Volatility Volatility
+	// Body is the SELECT statement computing the result of the function, in
+	// which the parameters are referenced by the placeholders $1, $2, etc.
+	Body *Select
+}
+
+// ParseFunctionBody parses the body of a user-defined function. The body must
+// be a single SELECT statement. References to the parameters, either by name
+// or by position, are replaced by placeholders.
+func ParseFunctionBody(body string, paramNames []string) (*Select, error) {
+	stmt, err := ParseOne(body)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.(*Select)
+	if !ok {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidFunctionDefinitionError,
+			"function body must be a SELECT statement, not %s", stmt.StatementTag())
+	}
+	v := functionParamVisitor{params: make(map[string]int, len(paramNames))}
+	for i, name := range paramNames {
+		v.params[name] = i + 1
+	}
+	newStmt, _ := WalkStmt(&v, sel)
+	if v.err != nil {
+		return nil, v.err
+	}
+	return newStmt.(*Select), nil
+}
+
+// ParseTriggerFunctionBody parses the body of a trigger function, declared
+// with RETURNS TRIGGER. The body must be a single SELECT, INSERT, UPSERT,
+// UPDATE or DELETE statement.
+func ParseTriggerFunctionBody(body string) (Statement, error) {
+	stmt, err := ParseOne(body)
+	if err != nil {
+		return nil, err
+	}
+	switch stmt.(type) {
+	case *Select, *Insert, *Update, *Delete:
+		return stmt, nil
+	default:
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidFunctionDefinitionError,
+			"trigger function body must be a SELECT, INSERT, UPSERT, UPDATE or DELETE statement, not %s",
+			stmt.StatementTag())
+	}
+}
+
+// functionParamVisitor replaces the references to the parameters of a
+// function by placeholders.
+type functionParamVisitor struct {
+	// params maps the normalized parameter names to their positions.
+	params map[string]int
+	err    error
+}
+
+var _ Visitor = &functionParamVisitor{}
+
+func (v *functionParamVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
+	if v.err != nil {
+		return false, expr
+	}
+	switch t := expr.(type) {
+	case UnresolvedName:
+		if len(t) != 1 {
+			break
+		}
+		if name, ok := t[0].(Name); ok {
+			if pos, ok := v.params[name.Normalize()]; ok {
+				return false, NewPlaceholder(strconv.Itoa(pos))
+			}
+		}
+	case *Placeholder:
+		pos, err := strconv.Atoi(t.Name)
+		if err != nil || pos < 1 || pos > len(v.params) {
+			v.err = pgerror.NewErrorf(pgerror.CodeUndefinedParameterError,
+				"there is no parameter $%s", t.Name)
+			return false, expr
+		}
+	}
+	return true, expr
+}
+
+func (*functionParamVisitor) VisitPost(expr Expr) Expr { return expr }
+
+// definition returns the FunctionDefinition used to refer to the function
+// once it has been resolved.
+func (udf *UserDefinedFunction) definition() (*FunctionDefinition, error) {
+	// The body is run as a nested query, with the arguments as placeholders.
+	// The placeholders are cast to the parameter types so that the body is
+	// typed as it was when the function was created, even if some arguments
+	// are NULL.
+	v := functionCastVisitor{params: udf.Params}
+	body, _ := WalkStmt(&v, udf.Body)
+	if v.err != nil {
+		return nil, v.err
+	}
+	sql := AsStringWithFlags(body, FmtParsable)
+
+	builtin := Builtin{
+		Types:      udf.Params,
+		ReturnType: fixedReturnType(udf.ReturnType),
+		// The nested query cannot run while the enclosing statement is being
+		// planned, so the function must not be evaluated during
+		// normalization, even if it is immutable.
+		impure:           true,
+		distsqlBlacklist: true,
+		Info:             "User-defined function.",
+		fn: func(ctx *EvalContext, args Datums) (Datum, error) {
+			qargs := make([]interface{}, len(args))
+			for i, arg := range args {
+				qargs[i] = arg
+			}
+			row, err := ctx.Planner.QueryRow(ctx.Ctx(), sql, qargs...)
+			if err != nil {
+				return nil, err
+			}
+			if len(row) == 0 {
+				return DNull, nil
+			}
+			return row[0], nil
+		},
+	}
+	return &FunctionDefinition{
+		Name:        udf.Name,
+		Definition:  []overloadImpl{builtin},
+		UserDefined: udf,
+	}, nil
+}
+
+// functionCastVisitor casts the placeholders in the body of a function to
+// the types of the corresponding parameters.
+type functionCastVisitor struct {
+	params ArgTypes
+	err    error
+}
+
+var _ Visitor = &functionCastVisitor{}
+
+func (v *functionCastVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
+	if v.err != nil {
+		return false, expr
+	}
+	if t, ok := expr.(*Placeholder); ok {
+		pos, _ := strconv.Atoi(t.Name)
+		colType, err := DatumTypeToColumnType(v.params[pos-1].Typ)
+		if err != nil {
+			v.err = err
+			return false, expr
+		}
+		return false, &CastExpr{Expr: t, Type: colType, syntaxMode: castShort}
+	}
+	return true, expr
+}
+
+func (*functionCastVisitor) VisitPost(expr Expr) Expr { return expr }
+
+// inlinableExpr returns the expression computed by the body of the function
+// if calls to the function can be replaced by that expression, or nil
+// otherwise. This is the case if the body is a plain SELECT of a single
+// scalar expression without a FROM clause, and the function is not
+// volatile.
+func (udf *UserDefinedFunction) inlinableExpr(searchPath SearchPath) Expr {
+	if udf.Volatility == Volatile || udf.Body.OrderBy != nil || udf.Body.Limit != nil {
+		return nil
+	}
+	sc, ok := udf.Body.Select.(*SelectClause)
+	if !ok || sc.Distinct || len(sc.Exprs) != 1 || sc.Where != nil || sc.GroupBy != nil ||
+		sc.Having != nil || sc.Window != nil || (sc.From != nil && len(sc.From.Tables) > 0) {
+		return nil
+	}
+	expr := sc.Exprs[0].Expr
+	// Aggregate, window and set-returning functions would change the meaning
+	// of the enclosing query, and subqueries cannot refer to its columns.
+	inlinable := true
+	WalkExprConst(&inlinableExprVisitor{searchPath: searchPath, inlinable: &inlinable}, expr)
+	if !inlinable {
+		return nil
+	}
+	return expr
+}
+
+type inlinableExprVisitor struct {
+	searchPath SearchPath
+	inlinable  *bool
+}
+
+var _ Visitor = inlinableExprVisitor{}
+
+func (v inlinableExprVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
+	switch t := expr.(type) {
+	case *Subquery, UnqualifiedStar, *AllColumnsSelector:
+		*v.inlinable = false
+	case *FuncExpr:
+		if t.WindowDef != nil || t.Filter != nil {
+			*v.inlinable = false
+			break
+		}
+		// Names which do not resolve to builtins refer to user-defined
+		// functions, which are all scalar.
+		if fd, err := t.Func.Resolve(v.searchPath); err == nil {
+			_, isAggregate := Aggregates[fd.Name]
+			_, isWindow := windows[fd.Name]
+			_, isGenerator := Generators[fd.Name]
+			if isAggregate || isWindow || isGenerator {
+				*v.inlinable = false
+			}
+		}
+	}
+	return *v.inlinable, expr
+}
+
+func (inlinableExprVisitor) VisitPost(expr Expr) Expr { return expr }
+
+// inline returns the body of the function with the parameters replaced by
+// the typed arguments, or nil if the call cannot be inlined.
+func (udf *UserDefinedFunction) inline(ctx *SemaContext, args []TypedExpr) (TypedExpr, error) {
+	var searchPath SearchPath
+	if ctx != nil {
+		searchPath = ctx.SearchPath
+	}
+	body := udf.inlinableExpr(searchPath)
+	if body == nil {
+		return nil, nil
+	}
+
+	// Arguments which are not constants or variables must not be evaluated
+	// more than once.
+	uses := make([]int, len(args))
+	WalkExprConst(placeholderUseVisitor{uses: uses}, body)
+	for i, arg := range args {
+		if uses[i] > 1 && !isSimpleArgument(arg) {
+			return nil, nil
+		}
+	}
+
+	substituted := make([]Expr, len(args))
+	for i, arg := range args {
+		substituted[i] = arg
+		if typ := udf.Params[i].Typ; !arg.ResolvedType().Equivalent(typ) {
+			colType, err := DatumTypeToColumnType(typ)
+			if err != nil {
+				return nil, err
+			}
+			substituted[i] = &CastExpr{Expr: arg, Type: colType, syntaxMode: castShort}
+		}
+	}
+	inlined, _ := WalkExpr(placeholderSubstitutionVisitor{args: substituted}, body)
+
+	typed, err := inlined.TypeCheck(ctx, udf.ReturnType)
+	if err != nil {
+		return nil, err
+	}
+	if typ := typed.ResolvedType(); typ != TypeNull && !typ.Equivalent(udf.ReturnType) {
+		colType, err := DatumTypeToColumnType(udf.ReturnType)
+		if err != nil {
+			return nil, err
+		}
+		cast := &CastExpr{Expr: typed, Type: colType, syntaxMode: castShort}
+		return cast.TypeCheck(ctx, udf.ReturnType)
+	}
+	return typed, nil
+}
+
+func isSimpleArgument(arg TypedExpr) bool {
+	switch arg.(type) {
+	case Datum, *IndexedVar, *Placeholder:
+		return true
+	}
+	return false
+}
+
+type placeholderUseVisitor struct {
+	uses []int
+}
+
+var _ Visitor = placeholderUseVisitor{}
+
+func (v placeholderUseVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
+	if t, ok := expr.(*Placeholder); ok {
+		pos, _ := strconv.Atoi(t.Name)
+		v.uses[pos-1]++
+	}
+	return true, expr
+}
+
+func (placeholderUseVisitor) VisitPost(expr Expr) Expr { return expr }
+
+type placeholderSubstitutionVisitor struct {
+	args []Expr
+}
+
+var _ Visitor = placeholderSubstitutionVisitor{}
+
+func (v placeholderSubstitutionVisitor) VisitPre(expr Expr) (recurse bool, newExpr Expr) {
+	if t, ok := expr.(*Placeholder); ok {
+		pos, _ := strconv.Atoi(t.Name)
+		return false, v.args[pos-1]
+	}
+	return true, expr
+}
+
+func (placeholderSubstitutionVisitor) VisitPost(expr Expr) Expr { return expr }
@@ -74,6 +74,7 @@ var pgCatalog = virtualSchema{
 		pgCatalogRangeTable,
 		pgCatalogRolesTable,
 		pgCatalogSettingsTable,
+		pgCatalogShdescriptionTable,
 		pgCatalogTablesTable,
 		pgCatalogTypeTable,
 		pgCatalogViewsTable,
@@ -739,12 +740,80 @@ CREATE TABLE pg_catalog.pg_description (
 	description STRING
 );
 `,
-	populate: func(_ context.Context, p *planner, addRow func(...parser.Datum) error) error {
-		// Comments on database objects are not currently supported.
-		return nil
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		h := makeOidHasher()
+		pgClassTableOid, err := pgCatalogTableOid(ctx, p, h, "pg_class")
+		if err != nil {
+			return err
+		}
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
+		if len(comments) == 0 {
+			return nil
+		}
+		return forEachTableDesc(ctx, p, func(db *sqlbase.DatabaseDescriptor, table *sqlbase.TableDescriptor) error {
+			addComment := func(key commentKey, objOid *parser.DOid, objSubID int) error {
+				c, ok := comments[key]
+				if !ok {
+					return nil
+				}
+				return addRow(
+					objOid,                                // objoid
+					pgClassTableOid,                       // classoid
+					parser.NewDInt(parser.DInt(objSubID)), // objsubid
+					parser.NewDString(c),                  // description
+				)
+			}
+			tableOid := h.TableOid(db, table)
+			if err := addComment(
+				commentKey{typ: tableCommentType, objID: table.ID}, tableOid, 0,
+			); err != nil {
+				return err
+			}
+			// Columns are numbered as in pg_attribute.
+			colNum := 0
+			if err := forEachColumnInTable(table, func(column *sqlbase.ColumnDescriptor) error {
+				colNum++
+				return addComment(
+					commentKey{typ: columnCommentType, objID: table.ID, subID: uint32(column.ID)},
+					tableOid, colNum,
+				)
+			}); err != nil {
+				return err
+			}
+			return forEachIndexInTable(table, func(index *sqlbase.IndexDescriptor) error {
+				return addComment(
+					commentKey{typ: indexCommentType, objID: table.ID, subID: uint32(index.ID)},
+					h.IndexOid(db, table, index), 0,
+				)
+			})
+		})
 	},
 }
 
+// pgCatalogTableOid returns the OID of the pg_catalog table with the given
+// name, as found in pg_class.
+func pgCatalogTableOid(
+	ctx context.Context, p *planner, h oidHasher, name parser.Name,
+) (*parser.DOid, error) {
+	db, err := getDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), pgCatalogName)
+	if err != nil {
+		return nil, errors.New("could not find pg_catalog")
+	}
+	desc, err := getTableDesc(
+		ctx,
+		p.txn,
+		p.getVirtualTabler(),
+		&parser.TableName{DatabaseName: pgCatalogName, TableName: name},
+	)
+	if err != nil || desc == nil {
+		return nil, errors.Errorf("could not find pg_catalog.%s", name)
+	}
+	return h.TableOid(db, desc), nil
+}
+
 // See: https://www.postgresql.org/docs/9.6/static/catalog-pg-enum.html.
 var pgCatalogEnumTable = virtualSchemaTable{
 	schema: `
@@ -755,9 +824,24 @@ CREATE TABLE pg_catalog.pg_enum (
   enumlabel STRING
 );
 `,
-	populate: func(_ context.Context, p *planner, addRow func(...parser.Datum) error) error {
-		// Enum types are not currently supported.
-		return nil
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		h := makeOidHasher()
+		return forEachTypeDesc(ctx, p, func(
+			db *sqlbase.DatabaseDescriptor, typ *sqlbase.TypeDescriptor,
+		) error {
+			typOid := parser.NewDOid(parser.DInt(parser.TypeIDToOid(uint32(typ.ID))))
+			for i, m := range typ.EnumMembers {
+				if err := addRow(
+					h.EnumMemberOid(db, typ, m.LogicalRepresentation), // oid
+					typOid,                               // enumtypid
+					parser.NewDFloat(parser.DFloat(i+1)), // enumsortorder
+					parser.NewDString(m.LogicalRepresentation), // enumlabel
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 	},
 }
 
@@ -1300,6 +1384,42 @@ CREATE TABLE pg_catalog.pg_settings (
 	},
 }
 
+// See: https://www.postgresql.org/docs/9.6/static/catalog-pg-shdescription.html.
+var pgCatalogShdescriptionTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE pg_catalog.pg_shdescription (
+	objoid OID,
+	classoid OID,
+	description STRING
+);
+`,
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		h := makeOidHasher()
+		pgDatabaseTableOid, err := pgCatalogTableOid(ctx, p, h, "pg_database")
+		if err != nil {
+			return err
+		}
+		comments, err := p.getComments(ctx, 0 /* tableID */)
+		if err != nil {
+			return err
+		}
+		if len(comments) == 0 {
+			return nil
+		}
+		return forEachDatabaseDesc(ctx, p, func(db *sqlbase.DatabaseDescriptor) error {
+			c, ok := comments[commentKey{typ: databaseCommentType, objID: db.ID}]
+			if !ok {
+				return nil
+			}
+			return addRow(
+				h.DBOid(db),          // objoid
+				pgDatabaseTableOid,   // classoid
+				parser.NewDString(c), // description
+			)
+		})
+	},
+}
+
 // See: https://www.postgresql.org/docs/9.6/static/view-pg-tables.html.
 var pgCatalogTablesTable = virtualSchemaTable{
 	schema: `
@@ -1344,7 +1464,6 @@ var (
 	// Avoid unused warning for constants.
 	_ = typTypeComposite
 	_ = typTypeDomain
-	_ = typTypeEnum
 	_ = typTypePseudo
 	_ = typTypeRange
 
@@ -1368,7 +1487,6 @@ var (
 	// Avoid unused warning for constants.
 	_ = typCategoryArray
 	_ = typCategoryComposite
-	_ = typCategoryEnum
 	_ = typCategoryGeometric
 	_ = typCategoryNetworkAddr
 	_ = typCategoryPseudo
@@ -1416,7 +1534,7 @@ CREATE TABLE pg_catalog.pg_type (
 	typacl STRING
 );
 `,
-	populate: func(_ context.Context, p *planner, addRow func(...parser.Datum) error) error {
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
 		h := makeOidHasher()
 		for o, typ := range parser.OidToType {
 			cat := typCategory(typ)
@@ -1477,7 +1595,46 @@ CREATE TABLE pg_catalog.pg_type (
 				return err
 			}
 		}
-		return nil
+		return forEachTypeDesc(ctx, p, func(
+			db *sqlbase.DatabaseDescriptor, typ *sqlbase.TypeDescriptor,
+		) error {
+			return addRow(
+				parser.NewDOid(parser.DInt(parser.TypeIDToOid(uint32(typ.ID)))), // oid
+				parser.NewDName(typ.Name),   // typname
+				pgNamespaceForDB(db, h).Oid, // typnamespace
+				parser.DNull,                // typowner
+				negOneVal,                   // typlen
+				parser.MakeDBool(false),     // typbyval
+				typTypeEnum,                 // typtype
+				typCategoryEnum,             // typcategory
+				parser.MakeDBool(false),     // typispreferred
+				parser.MakeDBool(true),      // typisdefined
+				typDelim,                    // typdelim
+				oidZero,                     // typrelid
+				oidZero,                     // typelem
+				oidZero,                     // typarray
+
+				// regproc references
+				h.RegProc("enum_in"),   // typinput
+				h.RegProc("enum_out"),  // typoutput
+				h.RegProc("enum_recv"), // typreceive
+				h.RegProc("enum_send"), // typsend
+				oidZero,                // typmodin
+				oidZero,                // typmodout
+				oidZero,                // typanalyze
+
+				parser.DNull,            // typalign
+				parser.DNull,            // typstorage
+				parser.MakeDBool(false), // typnotnull
+				oidZero,                 // typbasetype
+				negOneVal,               // typtypmod
+				zeroVal,                 // typndims
+				oidZero,                 // typcollation
+				parser.DNull,            // typdefaultbin
+				parser.DNull,            // typdefault
+				parser.DNull,            // typacl
+			)
+		})
 	},
 }
 
@@ -1635,6 +1792,7 @@ const (
 	functionTypeTag
 	userTypeTag
 	collationTypeTag
+	enumMemberTypeTag
 )
 
 func (h oidHasher) writeTypeTag(tag oidTypeTag) {
@@ -1781,6 +1939,16 @@ func (h oidHasher) UserOid(username string) *parser.DOid {
 	return h.getOid()
 }
 
+func (h oidHasher) EnumMemberOid(
+	db *sqlbase.DatabaseDescriptor, typ *sqlbase.TypeDescriptor, label string,
+) *parser.DOid {
+	h.writeTypeTag(enumMemberTypeTag)
+	h.writeDB(db)
+	h.writeUInt32(uint32(typ.ID))
+	h.writeStr(label)
+	return h.getOid()
+}
+
 func (h oidHasher) CollationOid(collation string) *parser.DOid {
 	h.writeTypeTag(collationTypeTag)
 	h.writeStr(collation)
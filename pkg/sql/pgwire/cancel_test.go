@@ -0,0 +1,179 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// writePGMessage writes a pgwire message; typ is 0 for the untyped startup
+// and cancel messages.
+func writePGMessage(conn net.Conn, typ byte, body []byte) error {
+	var buf bytes.Buffer
+	if typ != 0 {
+		buf.WriteByte(typ)
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(body)+4))
+	buf.Write(length[:])
+	buf.Write(body)
+	_, err := conn.Write(buf.Bytes())
+	return err
+}
+
+func readPGMessage(rd *bufio.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(rd, header[:]); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	_, err := io.ReadFull(rd, body)
+	return header[0], body, err
+}
+
+// sendCancelRequest sends a CancelRequest for the given cancel key to the
+// server at addr.
+func sendCancelRequest(addr string, pid, secret uint32) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var body [12]byte
+	binary.BigEndian.PutUint32(body[0:], 80877102)
+	binary.BigEndian.PutUint32(body[4:], pid)
+	binary.BigEndian.PutUint32(body[8:], secret)
+	return writePGMessage(conn, 0, body[:])
+}
+
+// TestPGWireCancelRequestOtherNode verifies that a CancelRequest reaching
+// another node than the one of the session, as happens behind a load
+// balancer, cancels the queries of the session.
+func TestPGWireCancelRequestOtherNode(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tc := serverutils.StartTestCluster(t, 2, /* numNodes */
+		base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+			ServerArgs:      base.TestServerArgs{Insecure: true},
+		})
+	defer tc.Stopper().Stop(context.TODO())
+
+	db := tc.ServerConn(0)
+	if _, err := db.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.test (k INT PRIMARY KEY, v INT);
+INSERT INTO t.test VALUES (1, 1);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lay down an intent which blocks the reads of the row.
+	txn, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = txn.Rollback() }()
+	if _, err := txn.Exec("UPDATE t.test SET v = 2 WHERE k = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", tc.Server(0).ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	var startup bytes.Buffer
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], 196608)
+	startup.Write(version[:])
+	fmt.Fprintf(&startup, "user\x00root\x00database\x00t\x00\x00")
+	if err := writePGMessage(conn, 0, startup.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the cancel key from the BackendKeyData message.
+	var pid, secret uint32
+	for {
+		typ, body, err := readPGMessage(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ == 'K' {
+			pid, secret = binary.BigEndian.Uint32(body), binary.BigEndian.Uint32(body[4:])
+		}
+		if typ == 'Z' {
+			break
+		}
+	}
+
+	if err := writePGMessage(conn, 'Q', []byte("SELECT v FROM t.test WHERE k = 1\x00")); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			typ, body, err := readPGMessage(rd)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if typ == 'E' {
+				for _, field := range bytes.Split(body, []byte{0}) {
+					if len(field) > 0 && field[0] == 'C' {
+						errCh <- errors.New(string(field[1:]))
+						return
+					}
+				}
+			}
+			if typ == 'Z' {
+				errCh <- errors.New("the query was not canceled")
+				return
+			}
+		}
+	}()
+
+	// The query might not be running yet when the first requests arrive, so
+	// keep sending them until it is canceled.
+	for {
+		if err := sendCancelRequest(tc.Server(1).ServingAddr(), pid, secret); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-errCh:
+			if err.Error() != pgerror.CodeQueryCanceledError {
+				t.Fatalf("expected the query to be canceled, got %v", err)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
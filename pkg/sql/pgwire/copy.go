@@ -0,0 +1,275 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/lib/pq/oid"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// copyBinarySignature starts the header of COPY data in binary format.
+// The header continues with a 32-bit flags field and the 32-bit length of
+// the header extension area.
+var copyBinarySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// copyOut sends the rows of a COPY TO statement as COPY data, in the
+// format requested by the statement. If the rows were already streamed
+// during the execution of the statement, only the end of the COPY data is
+// sent.
+// See: https://www.postgresql.org/docs/current/static/protocol-flow.html#PROTOCOL-COPY
+func (c *v3Conn) copyOut(result sql.Result) error {
+	if result.Rows != nil {
+		if err := c.BeginCopyOut(result.Columns, result.CopyOptions); err != nil {
+			return err
+		}
+		for i := 0; i < result.Rows.Len(); i++ {
+			if err := c.SendCopyRow(result.Rows.At(i), result.CopyOptions); err != nil {
+				return err
+			}
+		}
+	}
+
+	if result.CopyOptions.Format == sql.CopyFormatBinary {
+		// The trailer is a field count of -1.
+		c.writeBuf.initMsg(serverMsgCopyData)
+		c.writeBuf.putInt16(-1)
+		if err := c.writeBuf.finishMsg(c.wr); err != nil {
+			return err
+		}
+	}
+	c.writeBuf.initMsg(serverMsgCopyDone)
+	return c.writeBuf.finishMsg(c.wr)
+}
+
+// BeginCopyOut implements the sql.CopyOutWriter interface. It switches the
+// connection to the COPY out mode and sends the header of the data, if any.
+func (c *v3Conn) BeginCopyOut(columns sqlbase.ResultColumns, opts sql.CopyOptions) error {
+	code := formatText
+	if opts.Format == sql.CopyFormatBinary {
+		code = formatBinary
+	}
+	c.writeBuf.initMsg(serverMsgCopyOutResponse)
+	c.writeBuf.writeByte(byte(code))
+	c.writeBuf.putInt16(int16(len(columns)))
+	for range columns {
+		c.writeBuf.putInt16(int16(code))
+	}
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Format == sql.CopyFormatBinary:
+		c.writeBuf.initMsg(serverMsgCopyData)
+		c.writeBuf.write(copyBinarySignature)
+		c.writeBuf.putInt32(0) // flags
+		c.writeBuf.putInt32(0) // header extension length
+		return c.writeBuf.finishMsg(c.wr)
+
+	case opts.Format == sql.CopyFormatCSV && opts.Header:
+		c.writeBuf.initMsg(serverMsgCopyData)
+		for i, col := range columns {
+			if i > 0 {
+				c.writeBuf.writeByte(opts.Delimiter)
+			}
+			writeCopyCSVField(&c.writeBuf, []byte(col.Name), opts)
+		}
+		c.writeBuf.writeByte('\n')
+		return c.writeBuf.finishMsg(c.wr)
+	}
+	return nil
+}
+
+// SendCopyRow implements the sql.CopyOutWriter interface. It sends a row
+// as a CopyData message.
+func (c *v3Conn) SendCopyRow(row parser.Datums, opts sql.CopyOptions) error {
+	c.writeBuf.initMsg(serverMsgCopyData)
+	if opts.Format == sql.CopyFormatBinary {
+		c.writeBuf.putInt16(int16(len(row)))
+		for _, d := range row {
+			c.writeBuf.writeBinaryDatum(d, c.session.Location)
+		}
+		return c.writeBuf.finishMsg(c.wr)
+	}
+	for j, d := range row {
+		if j > 0 {
+			c.writeBuf.writeByte(opts.Delimiter)
+		}
+		if d == parser.DNull {
+			c.writeBuf.writeString(opts.Null)
+			continue
+		}
+		field := &c.copyField
+		field.reset()
+		field.writeTextDatum(d, c.session.Location)
+		if field.err != nil {
+			c.writeBuf.setError(field.err)
+			break
+		}
+		text := field.wrapped.Bytes()[4:]
+		if opts.Format == sql.CopyFormatCSV {
+			writeCopyCSVField(&c.writeBuf, text, opts)
+		} else {
+			writeCopyTextField(&c.writeBuf, text, opts.Delimiter)
+		}
+	}
+	c.writeBuf.writeByte('\n')
+	return c.writeBuf.finishMsg(c.wr)
+}
+
+// copyTextEncodeMap maps the characters escaped in the text format to the
+// letter of their escape sequence.
+var copyTextEncodeMap = map[byte]byte{
+	'\b': 'b',
+	'\f': 'f',
+	'\n': 'n',
+	'\r': 'r',
+	'\t': 't',
+	'\v': 'v',
+	'\\': '\\',
+}
+
+// writeCopyTextField writes a field of COPY data in text format, escaping
+// backslashes, control characters and the delimiter. It is the inverse of
+// sql.decodeCopy.
+func writeCopyTextField(b *writeBuffer, text []byte, delimiter byte) {
+	for _, ch := range text {
+		if enc, ok := copyTextEncodeMap[ch]; ok {
+			b.writeByte('\\')
+			b.writeByte(enc)
+		} else if ch == delimiter {
+			b.writeByte('\\')
+			b.writeByte(ch)
+		} else {
+			b.writeByte(ch)
+		}
+	}
+}
+
+// writeCopyCSVField writes a field of COPY data in CSV format. The field
+// is quoted if it contains the delimiter, the quote character or a line
+// break, or if it could be mistaken for a NULL or the end-of-data marker.
+func writeCopyCSVField(b *writeBuffer, text []byte, opts sql.CopyOptions) {
+	needsQuotes := string(text) == opts.Null || string(text) == `\.` ||
+		bytes.IndexAny(text, string([]byte{opts.Delimiter, opts.Quote, '\n', '\r'})) >= 0
+	if !needsQuotes {
+		b.write(text)
+		return
+	}
+	b.writeByte(opts.Quote)
+	for _, ch := range text {
+		if ch == opts.Quote {
+			b.writeByte(ch)
+		}
+		b.writeByte(ch)
+	}
+	b.writeByte(opts.Quote)
+}
+
+// binaryCopyDecoder decodes the rows of COPY data in binary format. Since
+// a CopyData message can hold any part of the data, the decoder buffers
+// the data of incomplete rows until the following messages complete them.
+type binaryCopyDecoder struct {
+	oids       []oid.Oid
+	buf        bytes.Buffer
+	headerRead bool
+	// done is set once the trailer has been read.
+	done bool
+}
+
+func makeBinaryCopyDecoder(columns sqlbase.ResultColumns) binaryCopyDecoder {
+	d := binaryCopyDecoder{oids: make([]oid.Oid, len(columns))}
+	for i, col := range columns {
+		d.oids[i] = pgTypeForParserType(col.Typ).oid
+	}
+	return d
+}
+
+// decode appends data to the decoder's buffer and returns the rows that
+// are complete.
+func (d *binaryCopyDecoder) decode(data []byte) ([]parser.Datums, error) {
+	d.buf.Write(data)
+	var rows []parser.Datums
+	for !d.done {
+		b := d.buf.Bytes()
+		if !d.headerRead {
+			headerLen := len(copyBinarySignature) + 8
+			if len(b) < headerLen {
+				return rows, nil
+			}
+			if !bytes.Equal(b[:len(copyBinarySignature)], copyBinarySignature) {
+				return nil, pgerror.NewError(pgerror.CodeBadCopyFileFormatError,
+					"COPY file signature not recognized")
+			}
+			headerLen += int(binary.BigEndian.Uint32(b[headerLen-4:]))
+			if len(b) < headerLen {
+				return rows, nil
+			}
+			d.buf.Next(headerLen)
+			d.headerRead = true
+			continue
+		}
+
+		if len(b) < 2 {
+			return rows, nil
+		}
+		numFields := int(int16(binary.BigEndian.Uint16(b)))
+		if numFields == -1 {
+			d.buf.Next(2)
+			d.done = true
+			break
+		}
+		if numFields != len(d.oids) {
+			return nil, pgerror.NewErrorf(pgerror.CodeBadCopyFileFormatError,
+				"row field count is %d, expected %d", numFields, len(d.oids))
+		}
+		row := make(parser.Datums, numFields)
+		pos := 2
+		for i := range row {
+			if len(b) < pos+4 {
+				return rows, nil
+			}
+			fieldLen := int(int32(binary.BigEndian.Uint32(b[pos:])))
+			pos += 4
+			if fieldLen == -1 {
+				row[i] = parser.DNull
+				continue
+			}
+			if fieldLen < 0 {
+				return nil, pgerror.NewErrorf(pgerror.CodeBadCopyFileFormatError,
+					"invalid field size %d", fieldLen)
+			}
+			if len(b) < pos+fieldLen {
+				return rows, nil
+			}
+			datum, err := decodeOidDatum(d.oids[i], formatBinary, b[pos:pos+fieldLen])
+			if err != nil {
+				return nil, err
+			}
+			row[i] = datum
+			pos += fieldLen
+		}
+		d.buf.Next(pos)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestWriteCopyFields(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	csv := sql.CopyOptions{Format: sql.CopyFormatCSV, Delimiter: ',', Quote: '"'}
+	tests := []struct {
+		in   string
+		text string
+		csv  string
+	}{
+		{in: "abc", text: "abc", csv: "abc"},
+		{in: "", text: "", csv: `""`},
+		{in: "a\tb\\c\nd", text: `a\tb\\c\nd`, csv: "\"a\tb\\c\nd\""},
+		{in: `a,"b"`, text: `a,"b"`, csv: `"a,""b"""`},
+		{in: `\.`, text: `\\.`, csv: `"\."`},
+	}
+
+	for _, test := range tests {
+		var b writeBuffer
+		writeCopyTextField(&b, []byte(test.in), '\t')
+		if out := b.wrapped.String(); out != test.text {
+			t.Errorf("%q: got text %q, expected %q", test.in, out, test.text)
+		}
+		b.reset()
+		writeCopyCSVField(&b, []byte(test.in), csv)
+		if out := b.wrapped.String(); out != test.csv {
+			t.Errorf("%q: got CSV %q, expected %q", test.in, out, test.csv)
+		}
+	}
+}
+
+func TestBinaryCopyDecoder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	columns := sqlbase.ResultColumns{
+		{Typ: parser.TypeInt}, {Typ: parser.TypeString}, {Typ: parser.TypeTimestamp},
+	}
+	ts := parser.MakeDTimestamp(time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC), time.Microsecond)
+	rows := []parser.Datums{
+		{parser.NewDInt(1), parser.NewDString("a"), ts},
+		{parser.NewDInt(2), parser.DNull, parser.DNull},
+	}
+
+	var b writeBuffer
+	b.write(copyBinarySignature)
+	b.putInt32(0)
+	b.putInt32(4)
+	b.write([]byte("ext!"))
+	for _, row := range rows {
+		b.putInt16(int16(len(row)))
+		for _, d := range row {
+			b.writeBinaryDatum(d, time.UTC)
+		}
+	}
+	b.putInt16(-1)
+	if b.err != nil {
+		t.Fatal(b.err)
+	}
+	data := b.wrapped.Bytes()
+
+	// Feed the data one byte at a time, to exercise the buffering of
+	// incomplete rows.
+	d := makeBinaryCopyDecoder(columns)
+	var decoded []parser.Datums
+	for i := range data {
+		res, err := d.decode(data[i : i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, res...)
+	}
+	if !d.done {
+		t.Fatal("trailer not decoded")
+	}
+	if len(decoded) != len(rows) {
+		t.Fatalf("expected %d rows, got %d", len(rows), len(decoded))
+	}
+	evalCtx := &parser.EvalContext{}
+	for i, row := range rows {
+		for j, datum := range row {
+			if decoded[i][j].Compare(evalCtx, datum) != 0 {
+				t.Errorf("row %d, column %d: expected %s, got %s", i, j, datum, decoded[i][j])
+			}
+		}
+	}
+
+	d = makeBinaryCopyDecoder(columns)
+	if _, err := d.decode([]byte("PGCOPX\n\377\r\n\000\000\000\000\000\000\000\000\000")); err == nil {
+		t.Fatal("expected error for invalid signature")
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// hbaMethod is the authentication method required by a host-based
+// authentication rule.
+type hbaMethod int
+
+const (
+	// hbaMethodDefault is used when no rules are configured: clients
+	// authenticate with a certificate if they present one, and with a
+	// password otherwise.
+	hbaMethodDefault hbaMethod = iota
+	// hbaMethodCert requires a client certificate.
+	hbaMethodCert
+	// hbaMethodPassword requires a password, checked with SCRAM-SHA-256 or,
+	// for legacy password hashes, in cleartext if
+	// server.user_login.legacy_passwords.enabled is set.
+	hbaMethodPassword
+	// hbaMethodScram requires a password, checked with SCRAM-SHA-256 only.
+	hbaMethodScram
+	// hbaMethodReject denies the connection.
+	hbaMethodReject
+	// hbaMethodTrust accepts the connection without authentication. It is
+	// only allowed for loopback addresses, and not for root.
+	hbaMethodTrust
+)
+
+var hbaMethods = map[string]hbaMethod{
+	"cert":     hbaMethodCert,
+	"password": hbaMethodPassword,
+	"scram":    hbaMethodScram,
+	"reject":   hbaMethodReject,
+	"trust":    hbaMethodTrust,
+}
+
+// hbaRule is a rule of the host-based authentication configuration. A nil
+// list of databases or users, or a nil network, matches all connections.
+type hbaRule struct {
+	// sslOnly is set for hostssl rules, which only match TLS connections.
+	sslOnly   bool
+	databases []string
+	users     []string
+	network   *net.IPNet
+	method    hbaMethod
+	// text is the rule as written in the configuration, for reporting.
+	text string
+}
+
+// hbaConf is a host-based authentication configuration, similar to the
+// pg_hba.conf file of postgres. Rules are separated by newlines or
+// semicolons, and have the form "<type> <databases> <users> <address>
+// <method>". The type is host (any connection) or hostssl (TLS connections,
+// i.e. all connections to a secure node). The databases and users are
+// either "all" or comma-separated lists of names. The address is either
+// "all" or an IP address or CIDR network. The method is one of cert,
+// password, scram, reject or trust; trust is only allowed for loopback
+// addresses and for rules that don't apply to root.
+// Text after a # is ignored.
+//
+// The first rule matching a connection determines its authentication
+// method; connections that match no rule are rejected.
+type hbaConf struct {
+	rules []hbaRule
+}
+
+// parseHBAConf parses a host-based authentication configuration. An empty
+// configuration is returned as nil.
+func parseHBAConf(s string) (*hbaConf, error) {
+	var conf hbaConf
+	lines := strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ';' })
+	for _, line := range lines {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rule, err := parseHBARule(fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid host-based authentication rule %q",
+				strings.TrimSpace(line))
+		}
+		conf.rules = append(conf.rules, rule)
+	}
+	if len(conf.rules) == 0 {
+		return nil, nil
+	}
+	return &conf, nil
+}
+
+func parseHBARule(fields []string) (hbaRule, error) {
+	rule := hbaRule{text: strings.Join(fields, " ")}
+	if len(fields) != 5 {
+		return rule, errors.Errorf("expected 5 fields, found %d", len(fields))
+	}
+
+	switch fields[0] {
+	case "host":
+	case "hostssl":
+		rule.sslOnly = true
+	default:
+		return rule, errors.Errorf("unsupported connection type %q; expected host or hostssl",
+			fields[0])
+	}
+
+	rule.databases = parseHBANames(fields[1])
+	rule.users = parseHBANames(fields[2])
+
+	if fields[3] != "all" {
+		var err error
+		if strings.IndexByte(fields[3], '/') >= 0 {
+			_, rule.network, err = net.ParseCIDR(fields[3])
+		} else if ip := net.ParseIP(fields[3]); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			rule.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		} else {
+			err = errors.Errorf("invalid address %q", fields[3])
+		}
+		if err != nil {
+			return rule, err
+		}
+	}
+
+	method, ok := hbaMethods[fields[4]]
+	if !ok {
+		return rule, errors.Errorf(
+			"unknown authentication method %q; expected cert, password, scram, reject or trust",
+			fields[4])
+	}
+	rule.method = method
+	if method == hbaMethodTrust {
+		if !isLoopbackNetwork(rule.network) {
+			return rule, errors.New("the trust method is only allowed for loopback addresses")
+		}
+		// Rules for all users apply to root too.
+		if hbaNamesMatch(rule.users, security.RootUser) {
+			return rule, errors.Errorf("the trust method is not allowed for user %s",
+				security.RootUser)
+		}
+	}
+	return rule, nil
+}
+
+// parseHBANames parses a comma-separated list of database or user names,
+// returning nil for "all".
+func parseHBANames(field string) []string {
+	if field == "all" {
+		return nil
+	}
+	names := strings.Split(field, ",")
+	for i := range names {
+		names[i] = parser.Name(names[i]).Normalize()
+	}
+	return names
+}
+
+// isLoopbackNetwork returns whether all the addresses of a network are
+// loopback addresses.
+func isLoopbackNetwork(network *net.IPNet) bool {
+	if network == nil || !network.IP.IsLoopback() {
+		return false
+	}
+	ones, bits := network.Mask.Size()
+	if bits == 8*net.IPv4len {
+		return ones >= 8
+	}
+	return ones == bits
+}
+
+func hbaNamesMatch(names []string, name string) bool {
+	if names == nil {
+		return true
+	}
+	name = parser.Name(name).Normalize()
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the first rule matching a connection, or nil if no rule
+// matches. A nil address only matches rules for all addresses. isTLS
+// indicates whether the connection uses TLS.
+func (c *hbaConf) lookup(database, user string, addr net.IP, isTLS bool) *hbaRule {
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if rule.sslOnly && !isTLS {
+			continue
+		}
+		if !hbaNamesMatch(rule.databases, database) || !hbaNamesMatch(rule.users, user) {
+			continue
+		}
+		if rule.network != nil && (addr == nil || !rule.network.Contains(addr)) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+var hbaConfSetting = settings.RegisterValidatedStringSetting(
+	"server.host_based_authentication.configuration",
+	"host-based authentication rules for SQL connections, one per line, of the form "+
+		"'host <databases> <users> <address> <cert|password|scram|reject|trust>'; "+
+		"empty allows certificates and passwords from all addresses",
+	"",
+	func(s string) error {
+		_, err := parseHBAConf(s)
+		return err
+	},
+)
+
+// hbaConfCache holds the parsed value of hbaConfSetting, which is parsed
+// again when the setting changes.
+var hbaConfCache struct {
+	syncutil.Mutex
+	raw  string
+	conf *hbaConf
+}
+
+// currentHBAConf returns the host-based authentication configuration, or
+// nil if none is configured.
+func currentHBAConf() (*hbaConf, error) {
+	raw := hbaConfSetting.Get()
+	hbaConfCache.Lock()
+	defer hbaConfCache.Unlock()
+	if raw != hbaConfCache.raw {
+		// The setting is validated when it is set, but the validation may
+		// have changed since.
+		conf, err := parseHBAConf(raw)
+		if err != nil {
+			return nil, err
+		}
+		hbaConfCache.raw, hbaConfCache.conf = raw, conf
+	}
+	return hbaConfCache.conf, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestParseHBAConf(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, s := range []string{"", "  ", "# nothing\n\n", ";;"} {
+		conf, err := parseHBAConf(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conf != nil {
+			t.Errorf("%q: expected no configuration, got %+v", s, conf)
+		}
+	}
+
+	conf, err := parseHBAConf(`
+# Local administration.
+host all admin 127.0.0.1/32 trust
+hostssl Foo,bar all 10.0.0.0/8 password; host all all ::1 scram
+host all all all cert # everyone else`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"host all admin 127.0.0.1/32 trust",
+		"hostssl Foo,bar all 10.0.0.0/8 password",
+		"host all all ::1 scram",
+		"host all all all cert",
+	}
+	if len(conf.rules) != len(expected) {
+		t.Fatalf("expected %d rules, got %d", len(expected), len(conf.rules))
+	}
+	for i, rule := range conf.rules {
+		if rule.text != expected[i] {
+			t.Errorf("%d: expected %q, got %q", i, expected[i], rule.text)
+		}
+	}
+	if db := conf.rules[1].databases; len(db) != 2 || db[0] != "foo" || db[1] != "bar" {
+		t.Errorf("unexpected databases %v", db)
+	}
+	if conf.rules[0].sslOnly || !conf.rules[1].sslOnly {
+		t.Error("expected only the hostssl rule to require TLS")
+	}
+
+	errTests := []struct {
+		conf     string
+		expected string
+	}{
+		{"host all all password", "expected 5 fields, found 4"},
+		{"local all all all trust", `unsupported connection type "local"`},
+		{"host all all 10.0.0.1/33 cert", "invalid CIDR address"},
+		{"host all all foo cert", `invalid address "foo"`},
+		{"host all all all md5", `unknown authentication method "md5"`},
+		{"host all all all trust", "the trust method is only allowed for loopback addresses"},
+		{"host all all 10.0.0.1 trust", "the trust method is only allowed for loopback addresses"},
+		{"host all all 0.0.0.0/0 trust", "the trust method is only allowed for loopback addresses"},
+		{"host all all ::/0 trust", "the trust method is only allowed for loopback addresses"},
+		{"host all root 127.0.0.1 trust", "the trust method is not allowed for user root"},
+		{"host all foo,Root ::1 trust", "the trust method is not allowed for user root"},
+		{"host all all 127.0.0.1 trust", "the trust method is not allowed for user root"},
+		{"host all all all cert\nhost all all all", `rule "host all all all"`},
+	}
+	for _, test := range errTests {
+		if _, err := parseHBAConf(test.conf); !testutils.IsError(err, test.expected) {
+			t.Errorf("%q: expected error %q, got %v", test.conf, test.expected, err)
+		}
+	}
+}
+
+func TestHBAConfLookup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	conf, err := parseHBAConf(`
+host all admin 127.0.0.0/8 trust
+host all root,admin all cert
+host app all 10.1.0.0/16 reject
+host app,other all 10.0.0.0/8 scram
+hostssl all carl,dave 192.168.1.1 password
+host all all 192.168.1.1 reject`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		database string
+		user     string
+		addr     string
+		isTLS    bool
+		method   hbaMethod
+		noMatch  bool
+	}{
+		{"", "admin", "127.0.0.2", true, hbaMethodTrust, false},
+		{"", "root", "127.0.0.2", true, hbaMethodCert, false},
+		{"", "root", "10.0.0.1", true, hbaMethodCert, false},
+		{"", "root", "", true, hbaMethodCert, false},
+		{"app", "bob", "10.1.2.3", true, hbaMethodReject, false},
+		{"App", "bob", "10.2.2.3", true, hbaMethodScram, false},
+		{"other", "bob", "10.2.2.3", true, hbaMethodScram, false},
+		{"other", "bob", "10.2.2.3", false, hbaMethodScram, false},
+		{"app", "bob", "11.2.2.3", true, 0, true},
+		{"app", "bob", "", true, 0, true},
+		{"app", "Dave", "192.168.1.1", true, hbaMethodPassword, false},
+		// Connections without TLS skip the hostssl rules.
+		{"app", "Dave", "192.168.1.1", false, hbaMethodReject, false},
+		{"app", "dave", "192.168.1.2", true, 0, true},
+		{"app", "erin", "192.168.1.1", true, hbaMethodReject, false},
+	}
+	for _, tc := range testCases {
+		var addr net.IP
+		if tc.addr != "" {
+			addr = net.ParseIP(tc.addr)
+		}
+		rule := conf.lookup(tc.database, tc.user, addr, tc.isTLS)
+		if tc.noMatch {
+			if rule != nil {
+				t.Errorf("%+v: expected no rule, got %q", tc, rule.text)
+			}
+			continue
+		}
+		if rule == nil {
+			t.Errorf("%+v: expected a rule, got none", tc)
+		} else if rule.method != tc.method {
+			t.Errorf("%+v: expected method %d, got %d (%q)", tc, tc.method, rule.method, rule.text)
+		}
+	}
+}
@@ -137,6 +137,7 @@ const (
 	CodeSchemaAndDataStatementMixingNotSupportedError        = "25007"
 	CodeNoActiveSQLTransactionError                          = "25P01"
 	CodeInFailedSQLTransactionError                          = "25P02"
+	CodeIdleInTransactionSessionTimeoutError                 = "25P03"
 	// Class 26 - Invalid SQL Statement Name
 	CodeInvalidSQLStatementNameError = "26000"
 	// Class 27 - Triggered Data Change Violation
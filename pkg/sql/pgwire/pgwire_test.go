@@ -1556,23 +1556,12 @@ func TestPGWireAuth(t *testing.T) {
 		t.Run("UnicodeUserAuth", func(t *testing.T) {
 			// Try to perform authentication with unicodeUser and no password.
 			// This case is equivalent to supplying a wrong password.
-			host, port, err := net.SplitHostPort(s.ServingAddr())
-			if err != nil {
-				t.Fatal(err)
-			}
-			unicodeUserPgURL := url.URL{
-				Scheme:   "postgres",
-				User:     url.User(unicodeUser),
-				Host:     net.JoinHostPort(host, port),
-				RawQuery: "sslmode=require",
-			}
-			if err := trivialQuery(unicodeUserPgURL); !testutils.IsError(err, "pq: invalid password") {
+			if err := scramLogin(s.ServingAddr(), unicodeUser, ""); !testutils.IsError(err, "invalid password") {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
 			// Supply correct password.
-			unicodeUserPgURL.User = url.UserPassword(unicodeUser, "蟑♫螂")
-			if err := trivialQuery(unicodeUserPgURL); err != nil {
+			if err := scramLogin(s.ServingAddr(), unicodeUser, "蟑♫螂"); err != nil {
 				t.Fatal(err)
 			}
 		})
@@ -1594,18 +1583,145 @@ func TestPGWireAuth(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		// Remove certificates to default to password authentication.
-		testUserPgURL.RawQuery = "sslmode=require"
-
-		// Even though the correct password is supplied (empty string), this
+		// Without certificates, authentication defaults to passwords. Even
+		// though the correct password is supplied (empty string), this
 		// should fail because we do not support password authentication for
 		// users with empty passwords.
-		if err := trivialQuery(testUserPgURL); !testutils.IsError(err, "pq: invalid password") {
+		if err := scramLogin(s.ServingAddr(), server.TestUser, ""); !testutils.IsError(err, "invalid password") {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 }
 
+func TestPGWireHBA(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	rootPgURL, cleanupFn := sqlutils.PGUrl(
+		t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanupFn()
+	db, err := gosql.Open("postgres", rootPgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlDB := sqlutils.MakeSQLRunner(t, db)
+	sqlDB.Exec(`CREATE USER foo WITH PASSWORD 'secret'`)
+
+	// Invalid rules are rejected when the setting is set.
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.host_based_authentication.configuration = 'host all all all trust'`,
+	); !testutils.IsError(err, "only allowed for loopback addresses") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The test server listens on a loopback address.
+	sqlDB.Exec(`SET CLUSTER SETTING server.host_based_authentication.configuration = ` +
+		`e'host all root all cert\nhost all foo 127.0.0.0/8 trust'`)
+	testutils.SucceedsSoon(t, func() error {
+		return scramLogin(s.ServingAddr(), "foo", "wrong")
+	})
+	if err := trivialQuery(rootPgURL); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rules are reloaded when the setting changes.
+	sqlDB.Exec(`SET CLUSTER SETTING server.host_based_authentication.configuration = ` +
+		`e'host all root all cert\nhost all foo all reject'`)
+	testutils.SucceedsSoon(t, func() error {
+		err := scramLogin(s.ServingAddr(), "foo", "secret")
+		if !testutils.IsError(err, "connection rejected by host-based authentication rule") {
+			return errors.Errorf("unexpected error: %v", err)
+		}
+		return nil
+	})
+
+	// Users matching no rule are rejected too.
+	sqlDB.Exec(`CREATE USER bar WITH PASSWORD 'secret'`)
+	err = scramLogin(s.ServingAddr(), "bar", "secret")
+	if !testutils.IsError(err, "no host-based authentication rule matches the connection") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Denied connections are recorded in the event log, with the rule that
+	// rejected them if any.
+	testutils.SucceedsSoon(t, func() error {
+		var count int
+		sqlDB.QueryRow(
+			`SELECT COUNT(*) FROM system.eventlog WHERE eventType = 'reject_connection' ` +
+				`AND info LIKE '%"User":"foo"%' AND info LIKE '%"Rule":"host all foo all reject"%'`,
+		).Scan(&count)
+		if count == 0 {
+			return errors.New("expected reject_connection events for foo")
+		}
+		sqlDB.QueryRow(
+			`SELECT COUNT(*) FROM system.eventlog WHERE eventType = 'reject_connection' ` +
+				`AND info LIKE '%"User":"bar"%' AND info LIKE '%"Rule":""%'`,
+		).Scan(&count)
+		if count == 0 {
+			return errors.New("expected reject_connection events for bar")
+		}
+		return nil
+	})
+
+	// The trust method is not allowed for root.
+	if _, err := db.Exec(
+		`SET CLUSTER SETTING server.host_based_authentication.configuration = ` +
+			`'host all all 127.0.0.1 trust'`,
+	); !testutils.IsError(err, "not allowed for user root") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestPGWireHBAInsecure checks that the host-based authentication rules
+// apply to the connections without TLS of an insecure node.
+func TestPGWireHBAInsecure(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	defer s.Stopper().Stop(context.TODO())
+	sqlDB := sqlutils.MakeSQLRunner(t, db)
+	sqlDB.Exec(`CREATE USER foo`)
+
+	pgURL := func(user string) url.URL {
+		return url.URL{
+			Scheme:   "postgres",
+			User:     url.User(user),
+			Host:     s.ServingAddr(),
+			RawQuery: "sslmode=disable",
+		}
+	}
+	if err := trivialQuery(pgURL("foo")); err != nil {
+		t.Fatal(err)
+	}
+
+	// hostssl rules don't apply to connections without TLS.
+	sqlDB.Exec(`SET CLUSTER SETTING server.host_based_authentication.configuration = ` +
+		`e'host all root all cert\nhostssl all foo all password\nhost all foo all reject'`)
+	testutils.SucceedsSoon(t, func() error {
+		err := trivialQuery(pgURL("foo"))
+		if !testutils.IsError(err, "connection rejected by host-based authentication rule") {
+			return errors.Errorf("unexpected error: %v", err)
+		}
+		return nil
+	})
+	if err := trivialQuery(pgURL(security.RootUser)); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB.Exec(`SET CLUSTER SETTING server.host_based_authentication.configuration = ` +
+		`e'host all root all cert\nhostssl all all all password'`)
+	testutils.SucceedsSoon(t, func() error {
+		err := trivialQuery(pgURL("foo"))
+		if !testutils.IsError(err, "no host-based authentication rule matches the connection") {
+			return errors.Errorf("unexpected error: %v", err)
+		}
+		return nil
+	})
+}
+
 func TestPGWireResultChange(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
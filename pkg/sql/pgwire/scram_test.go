@@ -0,0 +1,288 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pgwire_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	gosql "database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// scramClient speaks just enough of the pgwire protocol to authenticate
+// with SCRAM-SHA-256, which lib/pq does not support.
+type scramClient struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+func (c *scramClient) writeMsg(typ byte, body []byte) error {
+	var buf bytes.Buffer
+	if typ != 0 {
+		buf.WriteByte(typ)
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(body)+4))
+	buf.Write(length[:])
+	buf.Write(body)
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+func (c *scramClient) readMsg() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.rd, header[:]); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	_, err := io.ReadFull(c.rd, body)
+	return header[0], body, err
+}
+
+func scramHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+// scramLogin connects to a server with TLS but no client certificate and
+// authenticates user with password through SCRAM-SHA-256.
+func scramLogin(addr, user, password string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	c := &scramClient{conn: conn, rd: bufio.NewReader(conn)}
+
+	// Request TLS.
+	sslRequest := make([]byte, 4)
+	binary.BigEndian.PutUint32(sslRequest, 80877103)
+	if err := c.writeMsg(0, sslRequest); err != nil {
+		return err
+	}
+	if b, err := c.rd.ReadByte(); err != nil {
+		return err
+	} else if b != 'S' {
+		return errors.Errorf("server refused TLS: %q", b)
+	}
+	c.conn = tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	c.rd = bufio.NewReader(c.conn)
+
+	var startup bytes.Buffer
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], 196608)
+	startup.Write(version[:])
+	fmt.Fprintf(&startup, "user\x00%s\x00\x00", user)
+	if err := c.writeMsg(0, startup.Bytes()); err != nil {
+		return err
+	}
+
+	const clientFirstBare = "n=,r=rOprNGfwEbeRWgbNEkqO"
+	var authMessage string
+	var serverKey []byte
+	for {
+		typ, body, err := c.readMsg()
+		if err != nil {
+			return err
+		}
+		if typ == 'E' {
+			for _, field := range bytes.Split(body, []byte{0}) {
+				if len(field) > 0 && field[0] == 'M' {
+					return errors.New(string(field[1:]))
+				}
+			}
+			return errors.New("unknown error")
+		}
+		if typ != 'R' {
+			return errors.Errorf("unexpected message %q", typ)
+		}
+
+		switch code, data := binary.BigEndian.Uint32(body), body[4:]; code {
+		case 0:
+			return nil
+
+		case 10:
+			if !bytes.HasPrefix(data, []byte(security.ScramMechanism+"\x00")) {
+				return errors.Errorf("unexpected SASL mechanisms %q", data)
+			}
+			var msg bytes.Buffer
+			msg.WriteString(security.ScramMechanism + "\x00")
+			var length [4]byte
+			binary.BigEndian.PutUint32(length[:], uint32(len("n,,"+clientFirstBare)))
+			msg.Write(length[:])
+			msg.WriteString("n,," + clientFirstBare)
+			if err := c.writeMsg('p', msg.Bytes()); err != nil {
+				return err
+			}
+
+		case 11:
+			serverFirst := string(data)
+			attrs := strings.Split(serverFirst, ",")
+			nonce := strings.TrimPrefix(attrs[0], "r=")
+			salt, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(attrs[1], "s="))
+			if err != nil {
+				return err
+			}
+			iterations, err := strconv.Atoi(strings.TrimPrefix(attrs[2], "i="))
+			if err != nil {
+				return err
+			}
+
+			// Compute SaltedPassword with PBKDF2, for a single block.
+			mac := hmac.New(sha256.New, []byte(password))
+			mac.Write(salt)
+			mac.Write([]byte{0, 0, 0, 1})
+			u := mac.Sum(nil)
+			saltedPassword := append([]byte(nil), u...)
+			for i := 1; i < iterations; i++ {
+				mac.Reset()
+				mac.Write(u)
+				u = mac.Sum(u[:0])
+				for j := range saltedPassword {
+					saltedPassword[j] ^= u[j]
+				}
+			}
+
+			clientKey := scramHMAC(saltedPassword, "Client Key")
+			storedKey := sha256.Sum256(clientKey)
+			serverKey = scramHMAC(saltedPassword, "Server Key")
+			withoutProof := "c=biws,r=" + nonce
+			authMessage = clientFirstBare + "," + serverFirst + "," + withoutProof
+			proof := scramHMAC(storedKey[:], authMessage)
+			for i := range proof {
+				proof[i] ^= clientKey[i]
+			}
+			clientFinal := withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)
+			if err := c.writeMsg('p', []byte(clientFinal)); err != nil {
+				return err
+			}
+
+		case 12:
+			expected := "v=" + base64.StdEncoding.EncodeToString(scramHMAC(serverKey, authMessage))
+			if string(data) != expected {
+				return errors.Errorf("invalid server signature %q", data)
+			}
+
+		default:
+			return errors.Errorf("unexpected authentication request %d", code)
+		}
+	}
+}
+
+func TestPGWireScramAuth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	rootPgURL, cleanupFn := sqlutils.PGUrl(t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	defer cleanupFn()
+	db, err := gosql.Open("postgres", rootPgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlDB := sqlutils.MakeSQLRunner(t, db)
+
+	sqlDB.Exec(`CREATE USER foo WITH PASSWORD 'secret'`)
+	if err := scramLogin(s.ServingAddr(), "foo", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	err = scramLogin(s.ServingAddr(), "foo", "wrong")
+	if !testutils.IsError(err, "invalid password") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sqlDB.Exec(`ALTER USER foo WITH PASSWORD 'other'`)
+	err = scramLogin(s.ServingAddr(), "foo", "secret")
+	if !testutils.IsError(err, "invalid password") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := scramLogin(s.ServingAddr(), "foo", "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Users whose password hash predates SCRAM authentication log in with a
+	// cleartext password once, after which their password is stored as a
+	// SCRAM verifier.
+	legacyHash, err := bcrypt.GenerateFromPassword(
+		sha256.New().Sum([]byte("secret")), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Exec(`INSERT INTO system.users VALUES ('legacy', $1), ('legacy2', $1)`, legacyHash)
+
+	host, port, err := net.SplitHostPort(s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPgURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword("legacy", "secret"),
+		Host:     net.JoinHostPort(host, port),
+		RawQuery: "sslmode=require",
+	}
+	if err := trivialQuery(legacyPgURL); err != nil {
+		t.Fatal(err)
+	}
+	var hashedPassword []byte
+	sqlDB.QueryRow(`SELECT "hashedPassword" FROM system.users WHERE username = 'legacy'`).
+		Scan(&hashedPassword)
+	if !bytes.HasPrefix(hashedPassword, []byte(security.ScramMechanism+"$")) {
+		t.Fatalf("expected a SCRAM verifier, got %q", hashedPassword)
+	}
+	if err := scramLogin(s.ServingAddr(), "legacy", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the cleartext fallback is disabled, the remaining legacy users
+	// must have their password set again. They get the same error as for
+	// a wrong password.
+	sqlDB.Exec(`SET CLUSTER SETTING server.user_login.legacy_passwords.enabled = false`)
+	testutils.SucceedsSoon(t, func() error {
+		err := scramLogin(s.ServingAddr(), "legacy2", "secret")
+		if !testutils.IsError(err, "invalid password") {
+			return errors.Errorf("unexpected error: %v", err)
+		}
+		return nil
+	})
+	sqlDB.Exec(`ALTER USER legacy2 WITH PASSWORD 'secret'`)
+	if err := scramLogin(s.ServingAddr(), "legacy2", "secret"); err != nil {
+		t.Fatal(err)
+	}
+}
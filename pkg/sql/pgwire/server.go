@@ -44,6 +44,15 @@ const (
 	// ErrDraining is returned when a client attempts to connect to a server
 	// which is not accepting client connections.
 	ErrDraining = "server is not accepting clients"
+
+	// ErrSessionCanceled is returned to a client whose session was canceled
+	// with CANCEL SESSION, before its connection is closed.
+	ErrSessionCanceled = "terminating connection due to administrator command"
+
+	// ErrIdleInTxnSessionTimeout is returned to a client whose session was
+	// idle in an open transaction for longer than its
+	// idle_in_transaction_session_timeout, before its connection is closed.
+	ErrIdleInTxnSessionTimeout = "terminating connection due to idle-in-transaction timeout"
 )
 
 // Fully-qualified names for metrics.
@@ -60,8 +69,9 @@ var (
 )
 
 const (
-	version30  = 196608
-	versionSSL = 80877103
+	version30     = 196608
+	versionCancel = 80877102
+	versionSSL    = 80877103
 )
 
 const (
@@ -110,6 +120,11 @@ type Server struct {
 
 	sqlMemoryPool mon.MemoryMonitor
 	connMonitor   mon.MemoryMonitor
+
+	// memBudgets enforces the per-user (or per-application) memory limits
+	// within sqlMemoryPool, and holds back queries while the node's SQL
+	// memory is nearly exhausted.
+	memBudgets *sql.MemoryBudgets
 }
 
 // ServerMetrics is the set of metrics for the pgwire server.
@@ -119,6 +134,7 @@ type ServerMetrics struct {
 	Conns          *metric.Counter
 	ConnMemMetrics sql.MemoryMetrics
 	SQLMemMetrics  sql.MemoryMetrics
+	Admission      mon.AdmissionMetrics
 
 	internalMemMetrics *sql.MemoryMetrics
 }
@@ -132,6 +148,7 @@ func makeServerMetrics(
 		BytesOutCount:      metric.NewCounter(MetaBytesOut),
 		ConnMemMetrics:     sql.MakeMemMetrics("conns", histogramWindow),
 		SQLMemMetrics:      sql.MakeMemMetrics("client", histogramWindow),
+		Admission:          mon.MakeAdmissionMetrics(histogramWindow),
 		internalMemMetrics: internalMemMetrics,
 	}
 }
@@ -166,6 +183,8 @@ func MakeServer(
 		server.metrics.SQLMemMetrics.MaxBytesHist,
 		0, noteworthySQLMemoryUsageBytes)
 	server.sqlMemoryPool.Start(context.Background(), parentMemoryMonitor, mon.BoundAccount{})
+	server.memBudgets = sql.MakeMemoryBudgets(&server.sqlMemoryPool,
+		mon.MakeAdmissionQueue(parentMemoryMonitor, server.metrics.Admission))
 
 	server.connMonitor = mon.MakeMonitor("conn",
 		server.metrics.ConnMemMetrics.CurBytesCount,
@@ -380,11 +399,17 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 		errSSLRequired = true
 	}
 
+	if version == versionCancel {
+		// CancelRequests are allowed without SSL: they can't do anything
+		// without the secret cancel key of a session.
+		return s.handleCancel(ctx, &buf)
+	}
+
 	if version == version30 {
 		// We make a connection before anything. If there is an error
 		// parsing the connection arguments, the connection will only be
 		// used to send a report of that error.
-		v3conn := makeV3Conn(conn, &s.metrics, &s.sqlMemoryPool, s.executor)
+		v3conn := makeV3Conn(conn, &s.metrics, s.memBudgets, s.executor)
 		defer v3conn.finish(ctx)
 
 		if v3conn.sessionArgs, err = parseOptions(ctx, buf.msg); err != nil {
@@ -420,8 +445,11 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 
 		err := v3conn.serve(ctx, s.IsDraining, acc)
 		// If the error that closed the connection is related to an
-		// administrative shutdown, relay that information to the client.
-		if pgErr, ok := pgerror.GetPGCause(err); ok && pgErr.Code == pgerror.CodeAdminShutdownError {
+		// administrative shutdown or to an idle-in-transaction timeout, relay
+		// that information to the client.
+		if pgErr, ok := pgerror.GetPGCause(err); ok &&
+			(pgErr.Code == pgerror.CodeAdminShutdownError ||
+				pgErr.Code == pgerror.CodeIdleInTransactionSessionTimeoutError) {
 			return v3conn.sendError(err)
 		}
 		return err
@@ -429,3 +457,28 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn) error {
 
 	return errors.Errorf("unknown protocol version %d", version)
 }
+
+// handleCancel handles a CancelRequest, which a client sends on a new
+// connection to cancel the queries running on one of its other connections,
+// identified by the cancel key the server sent in its BackendKeyData message.
+// As in Postgres, no response is sent back.
+func (s *Server) handleCancel(ctx context.Context, buf *readBuffer) error {
+	pid, err := buf.getUint32()
+	if err != nil {
+		return err
+	}
+	secret, err := buf.getUint32()
+	if err != nil {
+		return err
+	}
+	// The high 32 bits of the key are the ID of the node of the session; the
+	// request is forwarded there if the client reached another node, e.g.
+	// through a load balancer.
+	cancelKey := uint64(pid)<<32 | uint64(secret)
+	if found, err := s.executor.CancelQueriesByKey(ctx, cancelKey); err != nil {
+		log.Warningf(ctx, "failed to forward CancelRequest to node %d: %s", pid, err)
+	} else if !found {
+		log.Infof(ctx, "ignoring CancelRequest for unknown session of node %d", pid)
+	}
+	return nil
+}
@@ -142,6 +142,9 @@ func (b *writeBuffer) writeTextDatum(d parser.Datum, sessionLoc *time.Location)
 	case *parser.DCollatedString:
 		b.writeLengthPrefixedString(v.Contents)
 
+	case *parser.DEnum:
+		b.writeLengthPrefixedString(v.LogicalRep)
+
 	case *parser.DDate:
 		t := time.Unix(int64(*v)*secondsInDay, 0)
 		// Start at offset 4 because `putInt32` clobbers the first 4 bytes.
@@ -315,6 +318,9 @@ func (b *writeBuffer) writeBinaryDatum(d parser.Datum, sessionLoc *time.Location
 	case *parser.DCollatedString:
 		b.writeLengthPrefixedString(v.Contents)
 
+	case *parser.DEnum:
+		b.writeLengthPrefixedString(v.LogicalRep)
+
 	case *parser.DTimestamp:
 		b.putInt32(8)
 		b.putInt64(timeToPgBinary(v.Time, nil))
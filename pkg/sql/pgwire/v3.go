@@ -31,6 +31,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
@@ -64,10 +65,14 @@ const (
 	clientMsgTerminate   clientMessageType = 'X'
 
 	serverMsgAuth                 serverMessageType = 'R'
+	serverMsgBackendKeyData       serverMessageType = 'K'
 	serverMsgBindComplete         serverMessageType = '2'
 	serverMsgCommandComplete      serverMessageType = 'C'
 	serverMsgCloseComplete        serverMessageType = '3'
+	serverMsgCopyData             serverMessageType = 'd'
+	serverMsgCopyDone             serverMessageType = 'c'
 	serverMsgCopyInResponse       serverMessageType = 'G'
+	serverMsgCopyOutResponse      serverMessageType = 'H'
 	serverMsgDataRow              serverMessageType = 'D'
 	serverMsgEmptyQuery           serverMessageType = 'I'
 	serverMsgErrorResponse        serverMessageType = 'E'
@@ -75,6 +80,7 @@ const (
 	serverMsgParameterDescription serverMessageType = 't'
 	serverMsgParameterStatus      serverMessageType = 'S'
 	serverMsgParseComplete        serverMessageType = '1'
+	serverMsgPortalSuspended      serverMessageType = 's'
 	serverMsgReady                serverMessageType = 'Z'
 	serverMsgRowDescription       serverMessageType = 'T'
 )
@@ -105,6 +111,16 @@ const (
 const (
 	authOK                int32 = 0
 	authCleartextPassword int32 = 3
+	authSASL              int32 = 10
+	authSASLContinue      int32 = 11
+	authSASLFinal         int32 = 12
+)
+
+var legacyPasswordsEnabled = settings.RegisterBoolSetting(
+	"server.user_login.legacy_passwords.enabled",
+	"allow users whose password hash predates SCRAM-SHA-256 authentication to log in "+
+		"with a cleartext password, after which the password is stored as a SCRAM verifier",
+	true,
 )
 
 // preparedStatementMeta is pgwire-specific metadata which is attached to each
@@ -174,6 +190,9 @@ type v3Conn struct {
 	tagBuf      [64]byte
 	sessionArgs sql.SessionArgs
 	session     *sql.Session
+	// copyField holds the text representation of a field of COPY data,
+	// after its length prefix.
+	copyField writeBuffer
 
 	// The logic governing these guys is hairy, and is not sufficiently
 	// specified in documentation. Consult the sources before you modify:
@@ -186,22 +205,27 @@ type v3Conn struct {
 	// it gets extra data after an error happened during a COPY operation.
 	doNotSendReadyForQuery bool
 
+	// idleInTxnSince is the time at which the connection started waiting for
+	// the client's next query while inside a transaction. It is zero when the
+	// connection is not idle in a transaction.
+	idleInTxnSince time.Time
+
 	metrics *ServerMetrics
 
-	sqlMemoryPool *mon.MemoryMonitor
+	memBudgets *sql.MemoryBudgets
 }
 
 func makeV3Conn(
-	conn net.Conn, metrics *ServerMetrics, sqlMemoryPool *mon.MemoryMonitor, executor *sql.Executor,
+	conn net.Conn, metrics *ServerMetrics, memBudgets *sql.MemoryBudgets, executor *sql.Executor,
 ) v3Conn {
 	return v3Conn{
-		conn:          conn,
-		rd:            bufio.NewReader(conn),
-		wr:            bufio.NewWriter(conn),
-		writeBuf:      writeBuffer{bytecount: metrics.BytesOutCount},
-		metrics:       metrics,
-		executor:      executor,
-		sqlMemoryPool: sqlMemoryPool,
+		conn:       conn,
+		rd:         bufio.NewReader(conn),
+		wr:         bufio.NewWriter(conn),
+		writeBuf:   writeBuffer{bytecount: metrics.BytesOutCount},
+		metrics:    metrics,
+		executor:   executor,
+		memBudgets: memBudgets,
 	}
 }
 
@@ -274,7 +298,31 @@ var statusReportParams = map[string]string{
 // point the sql.Session does not exist yet! If need exists to access the
 // database to look up authentication data, use the internal executor.
 func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error {
-	if tlsConn, ok := c.conn.(*tls.Conn); ok {
+	tlsConn, isTLS := c.conn.(*tls.Conn)
+
+	// Find the authentication method required by the host-based
+	// authentication rules, if any are configured. The rules apply to all
+	// connections, including the connections without TLS of insecure nodes.
+	method := hbaMethodDefault
+	conf, err := currentHBAConf()
+	if err != nil {
+		return c.rejectConnection(ctx, nil, err)
+	}
+	var rule *hbaRule
+	if conf != nil {
+		rule = conf.lookup(c.sessionArgs.Database, c.sessionArgs.User, remoteIP(c.conn), isTLS)
+		if rule == nil {
+			return c.rejectConnection(ctx, nil,
+				errors.New("no host-based authentication rule matches the connection"))
+		}
+		if rule.method == hbaMethodReject {
+			return c.rejectConnection(ctx, rule,
+				errors.New("connection rejected by host-based authentication rule"))
+		}
+		method = rule.method
+	}
+
+	if isTLS {
 		var authenticationHook security.UserAuthHook
 
 		// Check that the requested user exists and retrieve the hashed
@@ -287,17 +335,45 @@ func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error
 		}
 
 		tlsState := tlsConn.ConnectionState()
+		var scram *security.ScramExchange
+		var legacyPassword string
 		// If no certificates are provided, default to password
 		// authentication.
-		if len(tlsState.PeerCertificates) == 0 {
-			password, err := c.sendAuthPasswordRequest()
-			if err != nil {
-				return c.sendError(err)
+		usePassword := len(tlsState.PeerCertificates) == 0
+		switch method {
+		case hbaMethodCert:
+			if usePassword {
+				return c.rejectConnection(ctx, rule,
+					errors.New("host-based authentication rule requires a client certificate"))
 			}
-			authenticationHook = security.UserAuthPasswordHook(
-				insecure, password, hashedPassword,
-			)
-		} else {
+		case hbaMethodPassword, hbaMethodScram:
+			usePassword = true
+		}
+
+		switch {
+		case method == hbaMethodTrust:
+			// The rule only applies to loopback addresses.
+			authenticationHook = func(string, bool) error { return nil }
+		case usePassword:
+			if security.IsLegacyPasswordHash(hashedPassword) &&
+				method != hbaMethodScram && legacyPasswordsEnabled.Get() {
+				// The password hash predates SCRAM support, and can only be
+				// checked against a cleartext password.
+				legacyPassword, err = c.sendAuthPasswordRequest()
+				if err != nil {
+					return c.sendError(err)
+				}
+				authenticationHook = security.UserAuthPasswordHook(
+					insecure, legacyPassword, hashedPassword,
+				)
+			} else {
+				scram, err = c.handleScramExchange(hashedPassword)
+				if err != nil {
+					return c.sendError(err)
+				}
+				authenticationHook = security.UserAuthScramHook(insecure, scram)
+			}
+		default:
 			// Normalize the username contained in the certificate.
 			tlsState.PeerCertificates[0].Subject.CommonName = parser.Name(
 				tlsState.PeerCertificates[0].Subject.CommonName,
@@ -310,7 +386,33 @@ func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error
 		}
 
 		if err := authenticationHook(c.sessionArgs.User, true /* public */); err != nil {
-			return c.sendError(err)
+			if scram != nil && security.IsLegacyPasswordHash(hashedPassword) {
+				// The client gets the same error as for a wrong password, to
+				// not reveal anything about the user.
+				log.Infof(ctx, "the password of user %s predates SCRAM-SHA-256 "+
+					"authentication and must be set again", c.sessionArgs.User)
+			}
+			return c.rejectConnection(ctx, rule, err)
+		}
+
+		if scram != nil {
+			c.writeBuf.initMsg(serverMsgAuth)
+			c.writeBuf.putInt32(authSASLFinal)
+			c.writeBuf.write(scram.ServerFinal())
+			if err := c.writeBuf.finishMsg(c.wr); err != nil {
+				return err
+			}
+		}
+		if legacyPassword != "" {
+			// Store the password as a SCRAM verifier, so that the next logins
+			// don't send it in cleartext.
+			if err := sql.UpgradeUserHashedPassword(
+				ctx, c.executor, c.metrics.internalMemMetrics,
+				c.sessionArgs.User, hashedPassword, legacyPassword,
+			); err != nil {
+				log.Warningf(ctx, "unable to upgrade the password hash of user %s: %v",
+					c.sessionArgs.User, err)
+			}
 		}
 	}
 
@@ -319,11 +421,43 @@ func (c *v3Conn) handleAuthentication(ctx context.Context, insecure bool) error
 	return c.writeBuf.finishMsg(c.wr)
 }
 
+// remoteIP returns the IP address of the client of a connection, or nil if
+// the connection is not over TCP.
+func remoteIP(conn net.Conn) net.IP {
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
+// rejectConnection records a denied connection attempt in the event log and
+// sends the reason to the client. The rule is the host-based authentication
+// rule applied to the connection, if any.
+func (c *v3Conn) rejectConnection(ctx context.Context, rule *hbaRule, reason error) error {
+	rec := sql.RejectedConnection{
+		User:       c.sessionArgs.User,
+		Database:   c.sessionArgs.Database,
+		RemoteAddr: c.conn.RemoteAddr().String(),
+		Reason:     reason.Error(),
+	}
+	if rule != nil {
+		rec.Rule = rule.text
+	}
+	c.executor.RecordRejectedConnection(ctx, rec)
+	return c.sendError(reason)
+}
+
 func (c *v3Conn) setupSession(ctx context.Context, reserved mon.BoundAccount) error {
 	c.session = sql.NewSession(
 		ctx, c.sessionArgs, c.executor, c.conn.RemoteAddr(), &c.metrics.SQLMemMetrics,
 	)
-	c.session.StartMonitor(c.sqlMemoryPool, reserved)
+	c.session.StartGroupMonitor(c.memBudgets, reserved)
+	c.session.CopyOutWriter = c
+	if err := c.session.ApplyUserDefaults(c.executor); err != nil {
+		// The session is still usable with the cluster defaults.
+		log.Warningf(ctx, "unable to apply the session defaults of user %s: %v",
+			c.sessionArgs.User, err)
+	}
 	return nil
 }
 
@@ -362,19 +496,34 @@ func (c *v3Conn) serve(ctx context.Context, draining func() bool, reserved mon.B
 		c.closeSession(ctx)
 	}()
 
+	// Send the key with which the client can cancel the queries running on
+	// this connection, by sending a CancelRequest on another connection.
+	cancelKey := c.session.CancelKey()
+	c.writeBuf.initMsg(serverMsgBackendKeyData)
+	c.writeBuf.putInt32(int32(cancelKey >> 32))
+	c.writeBuf.putInt32(int32(cancelKey))
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return err
+	}
+
 	// Once a session has been set up, the underlying net.Conn is switched to
-	// a conn that exits if the session's context is cancelled or if the server
-	// is draining and the session does not have an ongoing transaction.
+	// a conn that exits if the session's context is cancelled, if the session
+	// was canceled, if the server is draining and the session does not have
+	// an ongoing transaction or if the session has been idle in a transaction
+	// for too long.
 	c.conn = newReadTimeoutConn(c.conn, func() error {
 		if err := func() error {
 			if draining() && c.session.TxnState.State == sql.NoTxn {
 				return errors.New(ErrDraining)
 			}
+			if c.session.Canceled() {
+				return errors.New(ErrSessionCanceled)
+			}
 			return c.session.Ctx().Err()
 		}(); err != nil {
 			return newAdminShutdownErr(err)
 		}
-		return nil
+		return c.checkIdleInTxnTimeout()
 	})
 	c.rd = bufio.NewReader(c.conn)
 
@@ -412,9 +561,13 @@ func (c *v3Conn) serve(ctx context.Context, draining func() bool, reserved mon.B
 			if err := c.wr.Flush(); err != nil {
 				return err
 			}
+			if c.session.TxnState.State != sql.NoTxn {
+				c.idleInTxnSince = timeutil.Now()
+			}
 		}
 		c.doNotSendReadyForQuery = false
 		typ, n, err := c.readBuf.readTypedMsg(c.rd)
+		c.idleInTxnSince = time.Time{}
 		c.metrics.BytesInCount.Inc(int64(n))
 		if err != nil {
 			return err
@@ -484,6 +637,22 @@ func (c *v3Conn) serve(ctx context.Context, draining func() bool, reserved mon.B
 	}
 }
 
+// checkIdleInTxnTimeout returns an error if the connection has been waiting
+// for the client's next query inside a transaction for longer than the
+// session's idle_in_transaction_session_timeout. The connection is then
+// closed, which aborts the transaction.
+func (c *v3Conn) checkIdleInTxnTimeout() error {
+	timeout := c.session.IdleInTxnSessionTimeout
+	if timeout == 0 || c.idleInTxnSince.IsZero() {
+		return nil
+	}
+	if timeutil.Since(c.idleInTxnSince) > timeout {
+		return pgerror.NewError(
+			pgerror.CodeIdleInTransactionSessionTimeoutError, ErrIdleInTxnSessionTimeout)
+	}
+	return nil
+}
+
 // sendAuthPasswordRequest requests a cleartext password from the client and
 // returns it.
 func (c *v3Conn) sendAuthPasswordRequest() (string, error) {
@@ -492,21 +661,94 @@ func (c *v3Conn) sendAuthPasswordRequest() (string, error) {
 	if err := c.writeBuf.finishMsg(c.wr); err != nil {
 		return "", err
 	}
-	if err := c.wr.Flush(); err != nil {
+	if err := c.readAuthResponse(); err != nil {
 		return "", err
 	}
+	return c.readBuf.getString()
+}
+
+// readAuthResponse flushes the pending authentication request and reads the
+// client's response into c.readBuf.
+func (c *v3Conn) readAuthResponse() error {
+	if err := c.wr.Flush(); err != nil {
+		return err
+	}
 
 	typ, n, err := c.readBuf.readTypedMsg(c.rd)
 	c.metrics.BytesInCount.Inc(int64(n))
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	if typ != clientMsgPassword {
-		return "", errors.Errorf("invalid response to authentication request: %s", typ)
+		return errors.Errorf("invalid response to authentication request: %s", typ)
 	}
+	return nil
+}
 
-	return c.readBuf.getString()
+// handleScramExchange authenticates the client with the SCRAM-SHA-256 SASL
+// mechanism, up to the verification of the client's final message. The
+// server's final message is only sent once the authentication hook accepted
+// the client.
+// See: https://www.postgresql.org/docs/current/static/sasl-authentication.html
+func (c *v3Conn) handleScramExchange(hashedPassword []byte) (*security.ScramExchange, error) {
+	exchange, err := security.NewScramExchange(c.sessionArgs.User, hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// The list of mechanisms is terminated by an empty string.
+	c.writeBuf.initMsg(serverMsgAuth)
+	c.writeBuf.putInt32(authSASL)
+	c.writeBuf.writeTerminatedString(security.ScramMechanism)
+	c.writeBuf.nullTerminate()
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return nil, err
+	}
+
+	// The SASLInitialResponse message holds the mechanism selected by the
+	// client and the length-prefixed client-first-message.
+	if err := c.readAuthResponse(); err != nil {
+		return nil, err
+	}
+	mechanism, err := c.readBuf.getString()
+	if err != nil {
+		return nil, err
+	}
+	if mechanism != security.ScramMechanism {
+		return nil, errors.Errorf("unsupported SASL authentication mechanism %q", mechanism)
+	}
+	length, err := c.readBuf.getUint32()
+	if err != nil {
+		return nil, err
+	}
+	if int32(length) < 0 {
+		return nil, errors.New("missing SASL initial response")
+	}
+	clientFirst, err := c.readBuf.getBytes(int(length))
+	if err != nil {
+		return nil, err
+	}
+	serverFirst, err := exchange.ServerFirst(clientFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeBuf.initMsg(serverMsgAuth)
+	c.writeBuf.putInt32(authSASLContinue)
+	c.writeBuf.write(serverFirst)
+	if err := c.writeBuf.finishMsg(c.wr); err != nil {
+		return nil, err
+	}
+
+	// The SASLResponse message holds the client-final-message.
+	if err := c.readAuthResponse(); err != nil {
+		return nil, err
+	}
+	if err := exchange.VerifyClientFinal(c.readBuf.msg); err != nil {
+		return nil, err
+	}
+	return exchange, nil
 }
 
 func (c *v3Conn) handleSimpleQuery(buf *readBuffer) error {
@@ -517,7 +759,7 @@ func (c *v3Conn) handleSimpleQuery(buf *readBuffer) error {
 
 	tracing.AnnotateTrace()
 	results := c.executor.ExecuteStatements(c.session, query, nil)
-	return c.finishExecute(results, nil, true, 0)
+	return c.finishExecute(results, nil, true)
 }
 
 func (c *v3Conn) handleParse(buf *readBuffer) error {
@@ -829,8 +1071,13 @@ func (c *v3Conn) handleExecute(buf *readBuffer) error {
 		return err
 	}
 
-	stmt := portal.Stmt
 	portalMeta := portal.ProtocolMeta.(preparedPortalMeta)
+	if portal.Suspended() {
+		// A previous Execute message left the portal suspended.
+		return c.sendPortalRows(portal, portalMeta.outFormats, int(limit))
+	}
+
+	stmt := portal.Stmt
 	pinfo := &parser.PlaceholderInfo{
 		Types:  stmt.SQLTypes,
 		Values: portal.Qargs,
@@ -838,15 +1085,97 @@ func (c *v3Conn) handleExecute(buf *readBuffer) error {
 
 	tracing.AnnotateTrace()
 
+	if limit != 0 {
+		// The client asked for a limited number of rows: if possible, keep
+		// the query open and compute the rows as they are requested.
+		if results, ok := c.executor.OpenPortal(c.session, portal, pinfo); ok {
+			if len(results.ResultList) == 1 && results.ResultList[0].Err == nil {
+				results.Close(c.session.Ctx())
+				return c.sendPortalRows(portal, portalMeta.outFormats, int(limit))
+			}
+			return c.finishExecute(results, portalMeta.outFormats, false)
+		}
+	}
+
 	results, err := c.executor.ExecutePreparedStatement(c.session, stmt, pinfo)
 	if err != nil {
 		return c.sendError(err)
 	}
-	return c.finishExecute(results, portalMeta.outFormats, false, int(limit))
+	if limit != 0 && len(results.ResultList) == 1 {
+		if result := results.ResultList[0]; result.Err == nil && result.Type == parser.Rows &&
+			result.Rows.Len() > int(limit) {
+			// The client asked for fewer rows than the statement returned, and
+			// the query couldn't be kept open: keep the results in the portal
+			// until the following Execute messages consume them.
+			portal.Results = &results
+			portal.RowsSent = 0
+			c.session.FinishPlan()
+			return c.sendPortalRows(portal, portalMeta.outFormats, int(limit))
+		}
+	}
+	return c.finishExecute(results, portalMeta.outFormats, false)
+}
+
+// sendPortalRows sends up to limit rows of a suspended portal, or all its
+// remaining rows if limit is zero. If rows remain afterwards, the portal
+// stays suspended and PortalSuspended is sent; otherwise the portal is
+// released and the command is completed.
+func (c *v3Conn) sendPortalRows(
+	portal *sql.PreparedPortal, formatCodes []formatCode, limit int,
+) error {
+	if portal.Results == nil {
+		return c.fetchPortalRows(portal, formatCodes, limit)
+	}
+	result := portal.Results.ResultList[0]
+	start, end := portal.RowsSent, result.Rows.Len()
+	if limit != 0 && start+limit < end {
+		end = start + limit
+	}
+	if err := c.sendDataRows(result.Rows, start, end, formatCodes); err != nil {
+		return err
+	}
+	if end < result.Rows.Len() {
+		portal.RowsSent = end
+		c.writeBuf.initMsg(serverMsgPortalSuspended)
+		return c.writeBuf.finishMsg(c.wr)
+	}
+	portal.ReleaseResults(c.session.Ctx())
+
+	tag := append(c.tagBuf[:0], result.PGTag...)
+	tag = append(tag, ' ')
+	tag = strconv.AppendInt(tag, int64(end-start), 10)
+	return c.sendCommandComplete(tag)
+}
+
+// fetchPortalRows is the counterpart of sendPortalRows for the portals
+// whose query is kept open.
+func (c *v3Conn) fetchPortalRows(
+	portal *sql.PreparedPortal, formatCodes []formatCode, limit int,
+) error {
+	results := c.executor.FetchPortalRows(c.session, portal, limit)
+	defer results.Close(c.session.Ctx())
+	result := results.ResultList[0]
+	if result.Err != nil {
+		return c.sendError(result.Err)
+	}
+	n := result.Rows.Len()
+	if err := c.sendDataRows(result.Rows, 0, n, formatCodes); err != nil {
+		return err
+	}
+	if limit != 0 && n == limit {
+		c.writeBuf.initMsg(serverMsgPortalSuspended)
+		return c.writeBuf.finishMsg(c.wr)
+	}
+	portal.ReleaseResults(c.session.Ctx())
+
+	tag := append(c.tagBuf[:0], portal.Stmt.Statement.StatementTag()...)
+	tag = append(tag, ' ')
+	tag = strconv.AppendInt(tag, int64(n), 10)
+	return c.sendCommandComplete(tag)
 }
 
 func (c *v3Conn) finishExecute(
-	results sql.StatementResults, formatCodes []formatCode, sendDescription bool, limit int,
+	results sql.StatementResults, formatCodes []formatCode, sendDescription bool,
 ) error {
 	// Delay evaluation of c.session.Ctx().
 	defer func() {
@@ -860,7 +1189,7 @@ func (c *v3Conn) finishExecute(
 		c.writeBuf.initMsg(serverMsgEmptyQuery)
 		return c.writeBuf.finishMsg(c.wr)
 	}
-	return c.sendResponse(c.session.Ctx(), results.ResultList, formatCodes, sendDescription, limit)
+	return c.sendResponse(c.session.Ctx(), results.ResultList, formatCodes, sendDescription)
 }
 
 func (c *v3Conn) sendCommandComplete(tag []byte) error {
@@ -941,7 +1270,6 @@ func (c *v3Conn) sendResponse(
 	results sql.ResultList,
 	formatCodes []formatCode,
 	sendDescription bool,
-	limit int,
 ) error {
 	if len(results) == 0 {
 		return c.sendCommandComplete(nil)
@@ -953,12 +1281,6 @@ func (c *v3Conn) sendResponse(
 			}
 			break
 		}
-		if limit != 0 && result.Rows != nil && result.Rows.Len() > limit {
-			if err := c.sendInternalError(fmt.Sprintf("execute row count limits not supported: %d of %d", limit, result.Rows.Len())); err != nil {
-				return err
-			}
-			break
-		}
 
 		if result.PGTag == "INSERT" {
 			// From the postgres docs (49.5. Message Formats):
@@ -986,29 +1308,8 @@ func (c *v3Conn) sendResponse(
 				}
 			}
 
-			// Send DataRows.
-			nRows := result.Rows.Len()
-			for rowIdx := 0; rowIdx < nRows; rowIdx++ {
-				row := result.Rows.At(rowIdx)
-				c.writeBuf.initMsg(serverMsgDataRow)
-				c.writeBuf.putInt16(int16(len(row)))
-				for i, col := range row {
-					fmtCode := formatText
-					if formatCodes != nil {
-						fmtCode = formatCodes[i]
-					}
-					switch fmtCode {
-					case formatText:
-						c.writeBuf.writeTextDatum(col, c.session.Location)
-					case formatBinary:
-						c.writeBuf.writeBinaryDatum(col, c.session.Location)
-					default:
-						c.writeBuf.setError(errors.Errorf("unsupported format code %s", fmtCode))
-					}
-				}
-				if err := c.writeBuf.finishMsg(c.wr); err != nil {
-					return err
-				}
+			if err := c.sendDataRows(result.Rows, 0, result.Rows.Len(), formatCodes); err != nil {
+				return err
 			}
 
 			// Send CommandComplete.
@@ -1028,7 +1329,7 @@ func (c *v3Conn) sendResponse(
 			}
 
 		case parser.CopyIn:
-			rows, err := c.copyIn(ctx, result.Columns)
+			rows, err := c.copyIn(ctx, result.Columns, result.CopyOptions)
 			if err != nil {
 				return err
 			}
@@ -1040,6 +1341,22 @@ func (c *v3Conn) sendResponse(
 				return err
 			}
 
+		case parser.CopyOut:
+			if err := c.copyOut(result); err != nil {
+				return err
+			}
+
+			// Send CommandComplete.
+			rows := result.RowsAffected
+			if result.Rows != nil {
+				rows = result.Rows.Len()
+			}
+			tag = append(tag, ' ')
+			tag = strconv.AppendInt(tag, int64(rows), 10)
+			if err := c.sendCommandComplete(tag); err != nil {
+				return err
+			}
+
 		default:
 			panic(fmt.Sprintf("unexpected result type %v", result.Type))
 		}
@@ -1048,6 +1365,36 @@ func (c *v3Conn) sendResponse(
 	return nil
 }
 
+// sendDataRows sends the rows in [start, end) of the given container as
+// DataRow messages.
+func (c *v3Conn) sendDataRows(
+	rows *sqlbase.RowContainer, start, end int, formatCodes []formatCode,
+) error {
+	for rowIdx := start; rowIdx < end; rowIdx++ {
+		row := rows.At(rowIdx)
+		c.writeBuf.initMsg(serverMsgDataRow)
+		c.writeBuf.putInt16(int16(len(row)))
+		for i, col := range row {
+			fmtCode := formatText
+			if formatCodes != nil {
+				fmtCode = formatCodes[i]
+			}
+			switch fmtCode {
+			case formatText:
+				c.writeBuf.writeTextDatum(col, c.session.Location)
+			case formatBinary:
+				c.writeBuf.writeBinaryDatum(col, c.session.Location)
+			default:
+				c.writeBuf.setError(errors.Errorf("unsupported format code %s", fmtCode))
+			}
+		}
+		if err := c.writeBuf.finishMsg(c.wr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // sendRowDescription sends a row description over the wire for the given
 // slice of columns. canSendNoData indicates that the current state of the
 // connection allows for short circuiting by sending the NoData message if
@@ -1098,15 +1445,25 @@ func (c *v3Conn) sendRowDescription(
 
 // copyIn processes COPY IN data and returns the number of rows inserted.
 // See: https://www.postgresql.org/docs/current/static/protocol-flow.html#PROTOCOL-COPY
-func (c *v3Conn) copyIn(ctx context.Context, columns []sqlbase.ResultColumn) (int64, error) {
+func (c *v3Conn) copyIn(
+	ctx context.Context, columns []sqlbase.ResultColumn, opts sql.CopyOptions,
+) (int64, error) {
 	var rows int64
 	defer c.session.CopyEnd(ctx)
 
+	code := formatText
+	var decoder binaryCopyDecoder
+	if opts.Format == sql.CopyFormatBinary {
+		// Binary data is decoded here, where the binary encoding of datums is
+		// implemented, and handed over to the executor as rows.
+		code = formatBinary
+		decoder = makeBinaryCopyDecoder(columns)
+	}
 	c.writeBuf.initMsg(serverMsgCopyInResponse)
-	c.writeBuf.writeByte(byte(formatText))
+	c.writeBuf.writeByte(byte(code))
 	c.writeBuf.putInt16(int16(len(columns)))
 	for range columns {
-		c.writeBuf.putInt16(int16(formatText))
+		c.writeBuf.putInt16(int16(code))
 	}
 	if err := c.writeBuf.finishMsg(c.wr); err != nil {
 		return 0, err
@@ -1127,7 +1484,15 @@ func (c *v3Conn) copyIn(ctx context.Context, columns []sqlbase.ResultColumn) (in
 		case clientMsgCopyData:
 			// Note: sql.Executor gets its Context from c.session.context, which
 			// has been bound by v3Conn.setupSession().
-			sr = c.executor.CopyData(c.session, string(c.readBuf.msg))
+			if code == formatBinary {
+				decoded, err := decoder.decode(c.readBuf.msg)
+				if err != nil {
+					return rows, c.sendError(err)
+				}
+				sr = c.executor.CopyRows(c.session, decoded)
+			} else {
+				sr = c.executor.CopyData(c.session, string(c.readBuf.msg))
+			}
 
 		case clientMsgCopyDone:
 			// Note: sql.Executor gets its Context from c.session.context, which
@@ -25,6 +25,7 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/util"
@@ -41,13 +42,14 @@ func makeTestV3Conn(c net.Conn) v3Conn {
 	exec := sql.NewExecutor(
 		sql.ExecutorConfig{
 			AmbientCtx:              log.AmbientContext{Tracer: tracing.NewTracer()},
+			NodeID:                  &base.NodeIDContainer{},
 			HistogramWindowInterval: metric.TestSampleInterval,
 			TestingKnobs:            &sql.ExecutorTestingKnobs{},
 			SessionRegistry:         sql.MakeSessionRegistry(),
 		},
 		nil, /* stopper */
 	)
-	return makeV3Conn(c, &metrics, &mon, exec)
+	return makeV3Conn(c, &metrics, sql.MakeMemoryBudgets(&mon, nil /* admission */), exec)
 }
 
 // TestMaliciousInputs verifies that known malicious inputs sent to
@@ -147,22 +147,38 @@ type planNodeFastPath interface {
 }
 
 var _ planNode = &alterTableNode{}
+var _ planNode = &alterTypeNode{}
+var _ planNode = &alterUserSetNode{}
+var _ planNode = &alterUserSetPasswordNode{}
+var _ planNode = &cancelQueryNode{}
+var _ planNode = &cancelSessionNode{}
+var _ planNode = &commentNode{}
 var _ planNode = &copyNode{}
 var _ planNode = &createDatabaseNode{}
+var _ planNode = &createFunctionNode{}
 var _ planNode = &createIndexNode{}
+var _ planNode = &createPolicyNode{}
+var _ planNode = &createStatsNode{}
 var _ planNode = &createTableNode{}
+var _ planNode = &createTriggerNode{}
+var _ planNode = &createTypeNode{}
 var _ planNode = &createViewNode{}
 var _ planNode = &delayedNode{}
 var _ planNode = &deleteNode{}
 var _ planNode = &distinctNode{}
 var _ planNode = &dropDatabaseNode{}
+var _ planNode = &dropFunctionNode{}
 var _ planNode = &dropIndexNode{}
+var _ planNode = &dropPolicyNode{}
 var _ planNode = &dropTableNode{}
+var _ planNode = &dropTriggerNode{}
+var _ planNode = &dropTypeNode{}
 var _ planNode = &dropViewNode{}
 var _ planNode = &emptyNode{}
 var _ planNode = &explainDebugNode{}
 var _ planNode = &explainDistSQLNode{}
 var _ planNode = &explainPlanNode{}
+var _ planNode = &analyzeNode{}
 var _ planNode = &traceNode{}
 var _ planNode = &filterNode{}
 var _ planNode = &groupNode{}
@@ -274,18 +290,48 @@ func (p *planner) newPlan(
 	switch n := stmt.(type) {
 	case *parser.AlterTable:
 		return p.AlterTable(ctx, n)
+	case *parser.AlterTypeAddValue:
+		return p.AlterTypeAddValue(ctx, n)
+	case *parser.AlterUserSet:
+		return p.AlterUserSet(ctx, n)
+	case *parser.AlterUserSetPassword:
+		return p.AlterUserSetPassword(ctx, n)
+	case *parser.Analyze:
+		return p.Analyze(ctx, n)
 	case *parser.BeginTransaction:
 		return p.BeginTransaction(n)
+	case *parser.CancelQuery:
+		return p.CancelQuery(ctx, n)
+	case *parser.CancelSession:
+		return p.CancelSession(ctx, n)
+	case *parser.CommentOnColumn:
+		return p.CommentOnColumn(ctx, n)
+	case *parser.CommentOnDatabase:
+		return p.CommentOnDatabase(ctx, n)
+	case *parser.CommentOnIndex:
+		return p.CommentOnIndex(ctx, n)
+	case *parser.CommentOnTable:
+		return p.CommentOnTable(ctx, n)
 	case CopyDataBlock:
 		return p.CopyData(ctx, n)
 	case *parser.CopyFrom:
 		return p.CopyFrom(ctx, n)
 	case *parser.CreateDatabase:
 		return p.CreateDatabase(n)
+	case *parser.CreateFunction:
+		return p.CreateFunction(ctx, n)
 	case *parser.CreateIndex:
 		return p.CreateIndex(ctx, n)
+	case *parser.CreatePolicy:
+		return p.CreatePolicy(ctx, n)
+	case *parser.CreateStats:
+		return p.CreateStatistics(ctx, n)
 	case *parser.CreateTable:
 		return p.CreateTable(ctx, n)
+	case *parser.CreateTrigger:
+		return p.CreateTrigger(ctx, n)
+	case *parser.CreateType:
+		return p.CreateType(ctx, n)
 	case *parser.CreateUser:
 		return p.CreateUser(ctx, n)
 	case *parser.CreateView:
@@ -294,10 +340,18 @@ func (p *planner) newPlan(
 		return p.Delete(ctx, n, desiredTypes)
 	case *parser.DropDatabase:
 		return p.DropDatabase(ctx, n)
+	case *parser.DropFunction:
+		return p.DropFunction(ctx, n)
 	case *parser.DropIndex:
 		return p.DropIndex(ctx, n)
+	case *parser.DropPolicy:
+		return p.DropPolicy(ctx, n)
 	case *parser.DropTable:
 		return p.DropTable(ctx, n)
+	case *parser.DropTrigger:
+		return p.DropTrigger(ctx, n)
+	case *parser.DropType:
+		return p.DropType(ctx, n)
 	case *parser.DropView:
 		return p.DropView(ctx, n)
 	case *parser.DropUser:
@@ -356,6 +410,10 @@ func (p *planner) newPlan(
 		return p.ShowQueries(ctx, n)
 	case *parser.ShowSessions:
 		return p.ShowSessions(ctx, n)
+	case *parser.ShowStats:
+		return p.ShowStats(ctx, n)
+	case *parser.ShowHistogram:
+		return p.ShowHistogram(ctx, n)
 	case *parser.ShowTables:
 		return p.ShowTables(ctx, n)
 	case *parser.ShowTrace:
@@ -393,10 +451,39 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 	}
 
 	switch n := stmt.(type) {
+	case *parser.CancelQuery:
+		return p.CancelQuery(ctx, n)
+	case *parser.CancelSession:
+		return p.CancelSession(ctx, n)
+	case *parser.CopyTo:
+		// The query is prepared to type its placeholders, but its rows are
+		// sent as COPY data.
+		plan, err := p.prepare(ctx, copyToSelect(n))
+		if plan != nil {
+			plan.Close(ctx)
+		}
+		return nil, err
+	case *parser.DeclareCursor:
+		// The cursor's query is prepared to type its placeholders, but
+		// DECLARE itself returns no rows.
+		plan, err := p.prepare(ctx, n.Select)
+		if plan != nil {
+			plan.Close(ctx)
+		}
+		return nil, err
 	case *parser.Delete:
 		return p.Delete(ctx, n, nil)
 	case *parser.Explain:
 		return p.Explain(ctx, n)
+	case *parser.FetchCursor:
+		if n.Move {
+			return nil, nil
+		}
+		c, err := p.session.cursors.get(string(n.Name))
+		if err != nil {
+			return nil, err
+		}
+		return &valuesNode{p: p, columns: c.columns}, nil
 	case *parser.Help:
 		return p.Help(ctx, n)
 	case *parser.Insert:
@@ -425,6 +512,10 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 		return p.ShowQueries(ctx, n)
 	case *parser.ShowSessions:
 		return p.ShowSessions(ctx, n)
+	case *parser.ShowStats:
+		return p.ShowStats(ctx, n)
+	case *parser.ShowHistogram:
+		return p.ShowHistogram(ctx, n)
 	case *parser.ShowTables:
 		return p.ShowTables(ctx, n)
 	case *parser.ShowTrace:
@@ -57,6 +57,8 @@ func planColumns(plan planNode) sqlbase.ResultColumns {
 		return n.columns
 	case *explainPlanNode:
 		return n.results.columns
+	case *analyzeNode:
+		return planColumns(n.plan)
 	case *windowNode:
 		return n.values.columns
 	case *traceNode:
@@ -59,6 +59,24 @@ type planner struct {
 	// initializing plans to read from a table. This should be used with care.
 	skipSelectPrivilegeChecks bool
 
+	// If set, the IDs of the user-defined functions resolved while planning
+	// are recorded here. This is used to track the dependencies of views and
+	// functions on the functions they call.
+	functionDeps map[sqlbase.ID]struct{}
+
+	// If set, the scan of the designated table is filtered by its row-level
+	// security policies for an UPDATE or DELETE instead of those for SELECT.
+	policyTarget policyTarget
+
+	// triggerDepth is the number of triggers whose firing led to the
+	// statement being planned.
+	triggerDepth int
+
+	// If set, the index selection decisions of the prepared statement being
+	// planned are cached: the decisions recorded in the index selection cache
+	// are reused, or recorded.
+	indexSelection *indexSelectionState
+
 	// autoCommit indicates whether we're planning for a spontaneous transaction.
 	// If autoCommit is true, the plan is allowed (but not required) to
 	// commit the transaction along with other KV operations.
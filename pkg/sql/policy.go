@@ -0,0 +1,563 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// policyTarget designates the table modified by the UPDATE or DELETE
+// statement being planned. The scan of that table is filtered by the
+// policies for the statement instead of those for SELECT.
+type policyTarget struct {
+	tableID sqlbase.ID
+	cmd     sqlbase.TableDescriptor_Policy_Command
+}
+
+func policyCommandFromParser(c parser.PolicyCommand) sqlbase.TableDescriptor_Policy_Command {
+	switch c {
+	case parser.PolicySelect:
+		return sqlbase.TableDescriptor_Policy_SELECT
+	case parser.PolicyInsert:
+		return sqlbase.TableDescriptor_Policy_INSERT
+	case parser.PolicyUpdate:
+		return sqlbase.TableDescriptor_Policy_UPDATE
+	case parser.PolicyDelete:
+		return sqlbase.TableDescriptor_Policy_DELETE
+	default:
+		return sqlbase.TableDescriptor_Policy_ALL
+	}
+}
+
+// policyPredicate returns the predicate which rows must satisfy under the
+// policies of the table which apply to statements of kind cmd run by the
+// current user. When forWrite is set, the predicate is the one new rows
+// must satisfy. The predicates of the applicable policies are OR'ed
+// together; if no policy applies, no row satisfies the predicate. nil is
+// returned if rows are not restricted, either because row-level security
+// is disabled or because the user is root or has been granted the
+// BYPASSRLS privilege. Holding ALL privileges on the table is not enough.
+func (p *planner) policyPredicate(
+	tableDesc *sqlbase.TableDescriptor, cmd sqlbase.TableDescriptor_Policy_Command, forWrite bool,
+) (parser.Expr, error) {
+	if !tableDesc.RowLevelSecurity {
+		return nil, nil
+	}
+	if p.session.User == security.RootUser ||
+		tableDesc.Privileges.CheckPrivilege(p.session.User, privilege.BYPASSRLS) {
+		return nil, nil
+	}
+
+	var pred parser.Expr = parser.DBoolFalse
+	for i := range tableDesc.Policies {
+		policy := &tableDesc.Policies[i]
+		if !policy.AppliesTo(p.session.User, cmd) {
+			continue
+		}
+		exprStr := policy.UsingExpr
+		if forWrite && policy.CheckExpr != "" {
+			exprStr = policy.CheckExpr
+		}
+		if exprStr == "" {
+			// The policy does not restrict rows.
+			return nil, nil
+		}
+		expr, err := parser.ParseExpr(exprStr)
+		if err != nil {
+			return nil, err
+		}
+		expr = &parser.ParenExpr{Expr: expr}
+		if pred == parser.DBoolFalse {
+			pred = expr
+		} else {
+			pred = &parser.OrExpr{Left: pred, Right: expr}
+		}
+	}
+	return pred, nil
+}
+
+// addPolicyFilter filters the rows of the given table data source by the
+// policies for the statement being planned.
+func (p *planner) addPolicyFilter(
+	ctx context.Context, src planDataSource, desc *sqlbase.TableDescriptor,
+) (planDataSource, error) {
+	cmd := sqlbase.TableDescriptor_Policy_SELECT
+	if p.policyTarget.tableID == desc.ID {
+		// Only the first scan of the target table, which is the one producing
+		// the rows to modify, uses the policies for the modification.
+		cmd = p.policyTarget.cmd
+		p.policyTarget = policyTarget{}
+	}
+	pred, err := p.policyPredicate(desc, cmd, false /* forWrite */)
+	if err != nil || pred == nil {
+		return src, err
+	}
+
+	f := &filterNode{p: p, source: src}
+	f.ivarHelper = parser.MakeIndexedVarHelper(f, len(src.info.sourceColumns))
+	f.filter, err = p.analyzeExpr(ctx, pred, multiSourceInfo{src.info}, f.ivarHelper,
+		parser.TypeBool, true, "POLICY")
+	if err != nil {
+		return planDataSource{}, err
+	}
+	return planDataSource{info: src.info, plan: f}, nil
+}
+
+// policyChecker validates rows written to a table against the predicate of
+// its row-level security policies.
+type policyChecker struct {
+	tableName string
+	// existingRows is set when the checked rows are the existing rows to be
+	// updated by an upsert, rather than new rows.
+	existingRows bool
+
+	expr         parser.TypedExpr
+	cols         []sqlbase.ColumnDescriptor
+	sourceInfo   *dataSourceInfo
+	ivars        []parser.IndexedVar
+	curSourceRow parser.Datums
+	evalCtx      *parser.EvalContext
+}
+
+// makePolicyChecker returns a policyChecker for the rows read or written by
+// statements of kind cmd, or nil if the rows are not restricted.
+func (p *planner) makePolicyChecker(
+	ctx context.Context,
+	tn *parser.TableName,
+	tableDesc *sqlbase.TableDescriptor,
+	cmd sqlbase.TableDescriptor_Policy_Command,
+	existingRows bool,
+) (*policyChecker, error) {
+	pred, err := p.policyPredicate(tableDesc, cmd, !existingRows /* forWrite */)
+	if err != nil || pred == nil {
+		return nil, err
+	}
+
+	c := &policyChecker{
+		tableName:    tableDesc.Name,
+		existingRows: existingRows,
+		cols:         tableDesc.Columns,
+		sourceInfo: newSourceInfoForSingleTable(
+			*tn, sqlbase.ResultColumnsFromColDescs(tableDesc.Columns),
+		),
+		evalCtx: &p.evalCtx,
+	}
+	ivarHelper := parser.MakeIndexedVarHelper(c, len(c.cols))
+	c.expr, err = p.analyzeExpr(ctx, pred, multiSourceInfo{c.sourceInfo}, ivarHelper,
+		parser.TypeBool, true, "POLICY")
+	if err != nil {
+		return nil, err
+	}
+	c.ivars = ivarHelper.GetIndexedVars()
+	c.curSourceRow = make(parser.Datums, len(c.cols))
+	return c, nil
+}
+
+// loadRow sets the values of the IndexedVars used by the predicate. Any
+// value not passed is set to NULL, unless merge is true, in which case it
+// is left unchanged (allowing updating a subset of a row's values).
+func (c *policyChecker) loadRow(colIdx map[sqlbase.ColumnID]int, row parser.Datums, merge bool) {
+	for _, ivar := range c.ivars {
+		if ivar.Idx == invalidColIdx {
+			continue
+		}
+		if ri, ok := colIdx[c.cols[ivar.Idx].ID]; ok {
+			c.curSourceRow[ivar.Idx] = row[ri]
+		} else if !merge {
+			c.curSourceRow[ivar.Idx] = parser.DNull
+		}
+	}
+}
+
+// check returns an error if the loaded row does not satisfy the predicate.
+// Unlike for CHECK constraints, a NULL result is a violation.
+func (c *policyChecker) check() error {
+	ok, err := sqlbase.RunFilter(c.expr, c.evalCtx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if c.existingRows {
+			return pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
+				"new row violates row-level security policy (USING expression) for table %q",
+				c.tableName)
+		}
+		return pgerror.NewErrorf(pgerror.CodeInsufficientPrivilegeError,
+			"new row violates row-level security policy for table %q", c.tableName)
+	}
+	return nil
+}
+
+// checkRow checks the row whose values are laid out according to colIdx.
+func (c *policyChecker) checkRow(colIdx map[sqlbase.ColumnID]int, row parser.Datums) error {
+	c.loadRow(colIdx, row, false)
+	return c.check()
+}
+
+// checkUpdatedRow checks the row resulting from applying updateValues, laid
+// out according to updateIdx, to oldValues, laid out according to fetchIdx.
+func (c *policyChecker) checkUpdatedRow(
+	fetchIdx map[sqlbase.ColumnID]int,
+	oldValues parser.Datums,
+	updateIdx map[sqlbase.ColumnID]int,
+	updateValues parser.Datums,
+) error {
+	c.loadRow(fetchIdx, oldValues, false)
+	c.loadRow(updateIdx, updateValues, true)
+	return c.check()
+}
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (c *policyChecker) IndexedVarEval(idx int, ctx *parser.EvalContext) (parser.Datum, error) {
+	return c.curSourceRow[idx].Eval(ctx)
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (c *policyChecker) IndexedVarResolvedType(idx int) parser.Type {
+	return c.sourceInfo.sourceColumns[idx].Typ
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (c *policyChecker) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	c.sourceInfo.FormatVar(buf, f, idx)
+}
+
+// validatePolicyExpr checks that expr is a valid predicate over the rows of
+// the table, and returns the user-defined functions it calls.
+func (p *planner) validatePolicyExpr(
+	ctx context.Context, expr parser.Expr, tn *parser.TableName, tableDesc *sqlbase.TableDescriptor,
+) ([]sqlbase.ID, error) {
+	var v policyExprCheckVisitor
+	parser.WalkExprConst(&v, expr)
+	if v.err != nil {
+		return nil, v.err
+	}
+	if err := p.parser.AssertNoAggregationOrWindowing(
+		expr, "policy expressions", p.session.SearchPath,
+	); err != nil {
+		return nil, err
+	}
+
+	sourceInfo := newSourceInfoForSingleTable(
+		*tn, sqlbase.ResultColumnsFromColDescs(tableDesc.Columns),
+	)
+	var c policyChecker
+	c.sourceInfo = sourceInfo
+	ivarHelper := parser.MakeIndexedVarHelper(&c, len(tableDesc.Columns))
+	return p.collectFunctionDeps(func() error {
+		_, err := p.analyzeExpr(ctx, expr, multiSourceInfo{sourceInfo}, ivarHelper,
+			parser.TypeBool, true, "POLICY")
+		return err
+	})
+}
+
+// policyExprCheckVisitor is a parser.Visitor that checks that a policy
+// expression does not contain subqueries.
+type policyExprCheckVisitor struct {
+	err error
+}
+
+var _ parser.Visitor = &policyExprCheckVisitor{}
+
+func (v *policyExprCheckVisitor) VisitPre(expr parser.Expr) (recurse bool, newExpr parser.Expr) {
+	if v.err != nil {
+		return false, expr
+	}
+	if _, ok := expr.(*parser.Subquery); ok {
+		v.err = pgerror.NewErrorf(pgerror.CodeFeatureNotSupportedError,
+			"subqueries are not supported in policy expressions")
+		return false, expr
+	}
+	return true, expr
+}
+
+func (v *policyExprCheckVisitor) VisitPost(expr parser.Expr) parser.Expr { return expr }
+
+// policyFunctionDeps returns the user-defined functions called by the
+// policies of the table.
+func (p *planner) policyFunctionDeps(
+	ctx context.Context, tn *parser.TableName, tableDesc *sqlbase.TableDescriptor,
+) ([]sqlbase.ID, error) {
+	seen := make(map[sqlbase.ID]struct{})
+	var deps []sqlbase.ID
+	for _, policy := range tableDesc.Policies {
+		for _, exprStr := range []string{policy.UsingExpr, policy.CheckExpr} {
+			if exprStr == "" {
+				continue
+			}
+			expr, err := parser.ParseExpr(exprStr)
+			if err != nil {
+				return nil, err
+			}
+			ids, err := p.validatePolicyExpr(ctx, expr, tn, tableDesc)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				if _, ok := seen[id]; !ok {
+					seen[id] = struct{}{}
+					deps = append(deps, id)
+				}
+			}
+		}
+	}
+	return deps, nil
+}
+
+// policyReferencesColumn returns whether the predicates of the policy
+// reference the column with the given normalized name.
+func policyReferencesColumn(policy sqlbase.TableDescriptor_Policy, normColName string) (bool, error) {
+	found := false
+	preFn := func(expr parser.Expr) (err error, recurse bool, newExpr parser.Expr) {
+		if vBase, ok := expr.(parser.VarName); ok {
+			v, err := vBase.NormalizeVarName()
+			if err != nil {
+				return err, false, nil
+			}
+			if c, ok := v.(*parser.ColumnItem); ok && c.ColumnName.Normalize() == normColName {
+				found = true
+			}
+			return nil, false, expr
+		}
+		return nil, true, expr
+	}
+	for _, exprStr := range []string{policy.UsingExpr, policy.CheckExpr} {
+		if exprStr == "" {
+			continue
+		}
+		expr, err := parser.ParseExpr(exprStr)
+		if err != nil {
+			return false, err
+		}
+		if _, err := parser.SimpleVisit(expr, preFn); err != nil {
+			return false, err
+		}
+	}
+	return found, nil
+}
+
+type createPolicyNode struct {
+	p         *planner
+	n         *parser.CreatePolicy
+	tn        *parser.TableName
+	tableDesc *sqlbase.TableDescriptor
+}
+
+// CreatePolicy creates a row-level security policy.
+// Privileges: CREATE on table.
+//   Notes: postgres requires ownership of the table.
+func (p *planner) CreatePolicy(ctx context.Context, n *parser.CreatePolicy) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, true /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &createPolicyNode{p: p, n: n, tn: tn, tableDesc: tableDesc}, nil
+}
+
+func (n *createPolicyNode) Start(ctx context.Context) error {
+	if n.tableDesc.FindPolicyByName(n.n.Name) >= 0 {
+		return pgerror.NewErrorf(pgerror.CodeDuplicateObjectError,
+			"policy %q for table %q already exists", string(n.n.Name), n.tableDesc.Name)
+	}
+
+	policy := sqlbase.TableDescriptor_Policy{
+		Name:    string(n.n.Name),
+		Command: policyCommandFromParser(n.n.Command),
+	}
+	for _, role := range n.n.Roles {
+		policy.Roles = append(policy.Roles, role.Normalize())
+	}
+	var fnDeps []sqlbase.ID
+	for _, e := range []struct {
+		expr parser.Expr
+		dst  *string
+	}{
+		{n.n.Using, &policy.UsingExpr},
+		{n.n.WithCheck, &policy.CheckExpr},
+	} {
+		if e.expr == nil {
+			continue
+		}
+		deps, err := n.p.validatePolicyExpr(ctx, e.expr, n.tn, n.tableDesc)
+		if err != nil {
+			return err
+		}
+		fnDeps = append(fnDeps, deps...)
+		*e.dst = parser.Serialize(e.expr)
+	}
+
+	n.tableDesc.Policies = append(n.tableDesc.Policies, policy)
+	if err := n.tableDesc.SetUpVersion(); err != nil {
+		return err
+	}
+	if err := n.tableDesc.Validate(ctx, n.p.txn); err != nil {
+		return err
+	}
+	if err := n.p.writeTableDesc(ctx, n.tableDesc); err != nil {
+		return err
+	}
+	if err := n.p.addFunctionReferences(ctx, fnDeps, n.tableDesc.ID); err != nil {
+		return err
+	}
+
+	// Record this policy creation in the event log. This is an auditable log
+	// event and is recorded in the same transaction as the table descriptor
+	// update.
+	if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogCreatePolicy,
+		int32(n.tableDesc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TableName  string
+			PolicyName string
+			Statement  string
+			User       string
+		}{n.tableDesc.Name, n.n.Name.String(), n.n.String(), n.p.session.User},
+	); err != nil {
+		return err
+	}
+	n.p.notifySchemaChange(n.tableDesc, sqlbase.InvalidMutationID)
+	return nil
+}
+
+func (*createPolicyNode) Next(context.Context) (bool, error) { return false, nil }
+func (*createPolicyNode) Close(context.Context)              {}
+
+func (*createPolicyNode) Values() parser.Datums      { return parser.Datums{} }
+func (*createPolicyNode) DebugValues() debugValues   { return debugValues{} }
+func (*createPolicyNode) MarkDebug(mode explainMode) {}
+
+type dropPolicyNode struct {
+	p         *planner
+	n         *parser.DropPolicy
+	tn        *parser.TableName
+	tableDesc *sqlbase.TableDescriptor
+}
+
+// DropPolicy drops a row-level security policy.
+// Privileges: CREATE on table.
+//   Notes: postgres requires ownership of the table.
+func (p *planner) DropPolicy(ctx context.Context, n *parser.DropPolicy) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, true /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &dropPolicyNode{p: p, n: n, tn: tn, tableDesc: tableDesc}, nil
+}
+
+func (n *dropPolicyNode) Start(ctx context.Context) error {
+	idx := n.tableDesc.FindPolicyByName(n.n.Name)
+	if idx < 0 {
+		if n.n.IfExists {
+			return nil
+		}
+		return pgerror.NewErrorf(pgerror.CodeUndefinedObjectError,
+			"policy %q for table %q does not exist", string(n.n.Name), n.tableDesc.Name)
+	}
+
+	prevDeps, err := n.p.policyFunctionDeps(ctx, n.tn, n.tableDesc)
+	if err != nil {
+		return err
+	}
+	n.tableDesc.Policies = append(n.tableDesc.Policies[:idx], n.tableDesc.Policies[idx+1:]...)
+	deps, err := n.p.policyFunctionDeps(ctx, n.tn, n.tableDesc)
+	if err != nil {
+		return err
+	}
+	var unused []sqlbase.ID
+	for _, id := range prevDeps {
+		found := false
+		for _, dep := range deps {
+			if dep == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unused = append(unused, id)
+		}
+	}
+
+	if err := n.tableDesc.SetUpVersion(); err != nil {
+		return err
+	}
+	if err := n.tableDesc.Validate(ctx, n.p.txn); err != nil {
+		return err
+	}
+	if err := n.p.writeTableDesc(ctx, n.tableDesc); err != nil {
+		return err
+	}
+	if err := n.p.removeFunctionReferences(ctx, unused, n.tableDesc.ID); err != nil {
+		return err
+	}
+
+	// Record this policy removal in the event log. This is an auditable log
+	// event and is recorded in the same transaction as the table descriptor
+	// update.
+	if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogDropPolicy,
+		int32(n.tableDesc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TableName  string
+			PolicyName string
+			Statement  string
+			User       string
+		}{n.tableDesc.Name, n.n.Name.String(), n.n.String(), n.p.session.User},
+	); err != nil {
+		return err
+	}
+	n.p.notifySchemaChange(n.tableDesc, sqlbase.InvalidMutationID)
+	return nil
+}
+
+func (*dropPolicyNode) Next(context.Context) (bool, error) { return false, nil }
+func (*dropPolicyNode) Close(context.Context)              {}
+
+func (*dropPolicyNode) Values() parser.Datums      { return parser.Datums{} }
+func (*dropPolicyNode) DebugValues() debugValues   { return debugValues{} }
+func (*dropPolicyNode) MarkDebug(mode explainMode) {}
@@ -29,6 +29,10 @@ import (
 
 // PreparedStatement is a SQL statement that has been parsed and the types
 // of arguments and results have been determined.
+//
+// The statement is planned again on every execution: the planNodes embed the
+// values of the placeholders, so plans can't be reused across executions.
+// Only the index selection decisions are reused, see indexSelectionCache.
 type PreparedStatement struct {
 	// Str is the statement string prior to parsing, used to generate
 	// error messages. This may be used in
@@ -60,6 +64,15 @@ type Statement struct {
 	AST           parser.Statement
 	ExpectedTypes sqlbase.ResultColumns
 	queryHandle   queryHandle
+	// prepared is set if the statement is executed as a prepared statement,
+	// whose index selection decisions can be cached.
+	prepared *PreparedStatement
+	// portal is set if the statement opens or fetches from the cursor of a
+	// suspended portal.
+	portal *PreparedPortal
+	// streamCopyOut is set if the rows of the statement, a COPY TO, are
+	// streamed to the client through the session's CopyOutWriter.
+	streamCopyOut bool
 }
 
 func (s Statement) String() string {
@@ -185,9 +198,9 @@ func (ps PreparedStatements) Delete(ctx context.Context, name string) bool {
 	if stmt, ok := ps.Get(name); ok {
 		if ps.session.PreparedPortals.portals != nil {
 			for portalName := range stmt.portalNames {
-				if portal, ok := ps.session.PreparedPortals.Get(name); ok {
+				if portal, ok := ps.session.PreparedPortals.Get(portalName); ok {
 					delete(ps.session.PreparedPortals.portals, portalName)
-					portal.memAcc.Wsession(ps.session).Close(ctx)
+					portal.close(ctx, ps.session)
 				}
 			}
 		}
@@ -204,7 +217,7 @@ func (ps PreparedStatements) closeAll(ctx context.Context, s *Session) {
 		stmt.close(ctx, s)
 	}
 	for _, portal := range s.PreparedPortals.portals {
-		portal.memAcc.Wsession(s).Close(ctx)
+		portal.close(ctx, s)
 	}
 }
 
@@ -233,9 +246,44 @@ type PreparedPortal struct {
 
 	ProtocolMeta interface{} // a field for protocol implementations to hang metadata off of.
 
+	// The execution of a portal is suspended after the client asked for
+	// fewer rows than the statement returns. Inside a transaction block,
+	// the query of a suspended portal stays open in a cursor and its rows are
+	// computed as they are requested. Otherwise, Results buffers the whole
+	// result set, accounted for by the session monitor, and RowsSent is the
+	// number of rows already sent to the client. Several portals can be
+	// suspended at the same time.
+	Results  *StatementResults
+	RowsSent int
+	cursor   *sqlCursor
+
 	memAcc WrappableMemoryAccount
 }
 
+// Suspended returns whether the execution of the portal is suspended.
+func (p *PreparedPortal) Suspended() bool {
+	return p.Results != nil || p.cursor != nil
+}
+
+// ReleaseResults releases the result set or closes the query of a
+// suspended portal.
+func (p *PreparedPortal) ReleaseResults(ctx context.Context) {
+	if p.Results != nil {
+		p.Results.Close(ctx)
+		p.Results = nil
+	}
+	if p.cursor != nil {
+		p.cursor.close(ctx)
+		p.cursor = nil
+	}
+	p.RowsSent = 0
+}
+
+func (p *PreparedPortal) close(ctx context.Context, s *Session) {
+	p.ReleaseResults(ctx)
+	p.memAcc.Wsession(s).Close(ctx)
+}
+
 // PreparedPortals is a mapping of PreparedPortal names to their corresponding
 // PreparedPortals.
 type PreparedPortals struct {
@@ -279,19 +327,29 @@ func (pp PreparedPortals) New(
 	stmt.portalNames[name] = struct{}{}
 
 	if prevPortal, ok := pp.Get(name); ok {
-		prevPortal.memAcc.Wsession(pp.session).Close(ctx)
+		prevPortal.close(ctx, pp.session)
 	}
 
 	pp.portals[name] = portal
 	return portal, nil
 }
 
+// closeSuspended removes the portals whose execution is suspended. Like
+// cursors, suspended portals don't outlive their transaction.
+func (pp PreparedPortals) closeSuspended(ctx context.Context) {
+	for name, portal := range pp.portals {
+		if portal.Suspended() {
+			pp.Delete(ctx, name)
+		}
+	}
+}
+
 // Delete removes the PreparedPortal with the provided name from the PreparedPortals.
 // The method returns whether a portal with that name was found and removed.
 func (pp PreparedPortals) Delete(ctx context.Context, name string) bool {
 	if portal, ok := pp.Get(name); ok {
 		delete(portal.Stmt.portalNames, name)
-		portal.memAcc.Wsession(pp.session).Close(ctx)
+		portal.close(ctx, pp.session)
 		delete(pp.portals, name)
 		return true
 	}
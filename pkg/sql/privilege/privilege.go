@@ -41,6 +41,9 @@ const (
 	INSERT
 	DELETE
 	UPDATE
+	// BYPASSRLS exempts the grantee from the row-level security policies
+	// of a table. Unlike the other privileges, it is not implied by ALL.
+	BYPASSRLS
 )
 
 // Predefined sets of privileges.
@@ -56,7 +59,7 @@ func (k Kind) Mask() uint32 {
 
 // ByValue is just an array of privilege kinds sorted by value.
 var ByValue = [...]Kind{
-	ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE,
+	ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, BYPASSRLS,
 }
 
 // List is a list of privileges.
@@ -38,9 +38,10 @@ func TestPrivilegeDecode(t *testing.T) {
 		{144, privilege.List{privilege.GRANT, privilege.DELETE}, "GRANT, DELETE", "DELETE,GRANT"},
 		{2047,
 			privilege.List{privilege.ALL, privilege.CREATE, privilege.DROP, privilege.GRANT,
-				privilege.SELECT, privilege.INSERT, privilege.DELETE, privilege.UPDATE},
-			"ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE",
-			"ALL,CREATE,DELETE,DROP,GRANT,INSERT,SELECT,UPDATE",
+				privilege.SELECT, privilege.INSERT, privilege.DELETE, privilege.UPDATE,
+				privilege.BYPASSRLS},
+			"ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, BYPASSRLS",
+			"ALL,BYPASSRLS,CREATE,DELETE,DROP,GRANT,INSERT,SELECT,UPDATE",
 		},
 	}
 
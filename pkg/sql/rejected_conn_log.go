@@ -0,0 +1,124 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// RejectedConnection is the event log entry of a SQL connection attempt
+// that was denied by the host-based authentication rules, or that failed to
+// authenticate. All fields are always present in the entry.
+type RejectedConnection struct {
+	User       string
+	Database   string
+	RemoteAddr string
+	// Rule is the host-based authentication rule applied to the connection,
+	// as written in the configuration. It is empty if no rule matched.
+	Rule   string
+	Reason string
+}
+
+const (
+	// maxPendingRejectedConns is the number of rejected connections that can
+	// wait to be recorded before the connections being rejected are held
+	// back until there is room for them.
+	maxPendingRejectedConns = 1024
+	// maxRejectedConnBatch is the maximum number of rejected connections
+	// recorded in a single transaction.
+	maxRejectedConnBatch = 100
+)
+
+// rejectedConnLogger records the rejected connections in the event log in
+// the background, so that rejecting a connection doesn't wait on a
+// transaction. No rejection is dropped: when the queue is full, the
+// connection waits for room in the queue, and the rejections that can't be
+// recorded in the event log, because the write failed or the server is
+// shutting down, are written to the server log instead.
+type rejectedConnLogger struct {
+	pending chan RejectedConnection
+}
+
+func (l *rejectedConnLogger) init() {
+	l.pending = make(chan RejectedConnection, maxPendingRejectedConns)
+}
+
+// start starts the worker writing the rejected connections to the event
+// log.
+func (l *rejectedConnLogger) start(ctx context.Context, e *Executor) {
+	e.stopper.RunWorker(ctx, func(ctx context.Context) {
+		for {
+			select {
+			case rec := <-l.pending:
+				batch := []RejectedConnection{rec}
+				for len(batch) < maxRejectedConnBatch && len(l.pending) > 0 {
+					batch = append(batch, <-l.pending)
+				}
+				if err := l.record(ctx, e, batch); err != nil {
+					log.Warningf(ctx, "unable to record rejected connections in the event log: %v", err)
+					for _, rec := range batch {
+						logRejectedConnection(ctx, rec)
+					}
+				}
+			case <-e.stopper.ShouldQuiesce():
+				for {
+					select {
+					case rec := <-l.pending:
+						logRejectedConnection(ctx, rec)
+					default:
+						return
+					}
+				}
+			}
+		}
+	})
+}
+
+func (l *rejectedConnLogger) record(
+	ctx context.Context, e *Executor, batch []RejectedConnection,
+) error {
+	nodeID := int32(e.cfg.NodeID.Get())
+	eventLogger := MakeEventLogger(e.cfg.LeaseManager)
+	return e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		for _, rec := range batch {
+			if err := eventLogger.InsertEventRecord(
+				ctx, txn, EventLogRejectConnection, nodeID, nodeID, rec,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func logRejectedConnection(ctx context.Context, rec RejectedConnection) {
+	log.Warningf(ctx, "Event: %q, info: %+v", EventLogRejectConnection, rec)
+}
+
+// RecordRejectedConnection records a denied SQL connection attempt in the
+// event log. The entry is written asynchronously; the call only blocks while
+// the queue of entries waiting to be written is full.
+func (e *Executor) RecordRejectedConnection(ctx context.Context, rec RejectedConnection) {
+	select {
+	case e.rejectedConnLogger.pending <- rec:
+	case <-ctx.Done():
+		logRejectedConnection(ctx, rec)
+	case <-e.stopper.ShouldQuiesce():
+		logRejectedConnection(ctx, rec)
+	}
+}
@@ -411,6 +411,23 @@ func (p *planner) RenameColumn(ctx context.Context, n *parser.RenameColumn) (pla
 			tableDesc.Checks[i].Expr = after
 		}
 	}
+	for i := range tableDesc.Policies {
+		policy := &tableDesc.Policies[i]
+		for _, exprStr := range []*string{&policy.UsingExpr, &policy.CheckExpr} {
+			if *exprStr == "" {
+				continue
+			}
+			expr, err := parser.ParseExpr(*exprStr)
+			if err != nil {
+				return nil, err
+			}
+			expr, err = parser.SimpleVisit(expr, preFn)
+			if err != nil {
+				return nil, err
+			}
+			*exprStr = expr.String()
+		}
+	}
 	// Rename the column in the indexes.
 	tableDesc.RenameColumnDescriptor(col, normNewColName)
 
@@ -342,7 +342,7 @@ func (r *renderNode) initTargets(
 
 		// Output column names should exactly match the original expression, so we
 		// have to determine the output column name before we rewrite SRFs below.
-		outputName, err := getRenderColName(r.planner.session.SearchPath, target)
+		outputName, err := getRenderColName(&r.planner.semaCtx, target)
 		if err != nil {
 			return err
 		}
@@ -384,7 +384,7 @@ type srfExtractionVisitor struct {
 	err        error
 	srf        *parser.FuncExpr
 	ivarHelper *parser.IndexedVarHelper
-	searchPath parser.SearchPath
+	semaCtx    *parser.SemaContext
 }
 
 var _ parser.Visitor = &srfExtractionVisitor{}
@@ -397,7 +397,7 @@ func (v *srfExtractionVisitor) VisitPre(expr parser.Expr) (recurse bool, newNode
 func (v *srfExtractionVisitor) VisitPost(expr parser.Expr) parser.Expr {
 	switch t := expr.(type) {
 	case *parser.FuncExpr:
-		fd, err := t.Func.Resolve(v.searchPath)
+		fd, err := v.semaCtx.ResolveFunction(&t.Func)
 		if err != nil {
 			v.err = err
 			return expr
@@ -432,7 +432,7 @@ func (r *renderNode) rewriteSRFs(
 		err:        nil,
 		srf:        nil,
 		ivarHelper: &r.ivarHelper,
-		searchPath: r.planner.session.SearchPath,
+		semaCtx:    &r.planner.semaCtx,
 	}
 	expr, _ := parser.WalkExpr(v, target.Expr)
 	if v.err != nil {
@@ -491,7 +491,7 @@ func (r *renderNode) initWhere(ctx context.Context, whereExpr parser.Expr) (*fil
 }
 
 // getRenderColName returns the output column name for a render expression.
-func getRenderColName(searchPath parser.SearchPath, target parser.SelectExpr) (string, error) {
+func getRenderColName(semaCtx *parser.SemaContext, target parser.SelectExpr) (string, error) {
 	if target.As != "" {
 		return string(target.As), nil
 	}
@@ -521,7 +521,7 @@ func getRenderColName(searchPath parser.SearchPath, target parser.SelectExpr) (s
 	// For compatibility with Postgres, a render expression rooted by a
 	// set-returning function is named after that SRF.
 	case *parser.FuncExpr:
-		fd, err := t.Func.Resolve(searchPath)
+		fd, err := semaCtx.ResolveFunction(&t.Func)
 		if err != nil {
 			return "", err
 		}
@@ -0,0 +1,267 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	gosql "database/sql"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestCancelSession(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tc := serverutils.StartTestCluster(t, 2, /* numNodes */
+		base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+		})
+	defer tc.Stopper().Stop(context.TODO())
+
+	conn1 := tc.ServerConn(0)
+	conn2 := tc.ServerConn(1)
+	// Use a single connection on node 2, so that the session canceled below
+	// is the one used by the following statements.
+	conn2.SetMaxOpenConns(1)
+
+	// Open a transaction on node 2; canceling the session must abort it.
+	if _, err := conn2.Exec("BEGIN; SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var sessionID string
+	if err := conn2.QueryRow(
+		"SELECT session_id FROM [SHOW LOCAL SESSIONS]",
+	).Scan(&sessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn1.Exec("CANCEL SESSION $1", sessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection of the canceled session is closed with an error.
+	if _, err := conn2.Exec("SELECT 1"); !testutils.IsError(
+		err, "terminating connection due to administrator command|bad connection|EOF",
+	) {
+		t.Fatalf("expected the session to be canceled, got %v", err)
+	}
+
+	// The cancellation is recorded in the event log.
+	var count int
+	if err := conn1.QueryRow(
+		"SELECT COUNT(*) FROM system.eventlog WHERE eventType = $1 AND targetID = 2",
+		string(sql.EventLogCancelSession),
+	).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 cancel_session event, got %d", count)
+	}
+
+	if _, err := conn1.Exec("CANCEL SESSION $1", sessionID); !testutils.IsError(
+		err, "session ID .* not found",
+	) {
+		t.Fatalf("expected an error canceling an already canceled session, got %v", err)
+	}
+}
+
+// openSingleConn opens a gosql.DB using a single connection to the server, so
+// that the session variables set through it apply to all its statements.
+func openSingleConn(t *testing.T, s serverutils.TestServerInterface) (*gosql.DB, func()) {
+	pgURL, cleanupGoDB := sqlutils.PGUrl(t, s.ServingAddr(), t.Name(), url.User(security.RootUser))
+	db, err := gosql.Open("postgres", pgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, func() {
+		_ = db.Close()
+		cleanupGoDB()
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	if _, err := db.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.test (k INT PRIMARY KEY, v INT);
+INSERT INTO t.test VALUES (1, 1);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lay down an intent which blocks the reads of the row.
+	txn, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = txn.Rollback() }()
+	if _, err := txn.Exec("UPDATE t.test SET v = 2 WHERE k = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, cleanup := openSingleConn(t, s)
+	defer cleanup()
+	if _, err := conn.Exec("SET statement_timeout = '100ms'"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = conn.Exec("SELECT v FROM t.test WHERE k = 1")
+	if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != pgerror.CodeQueryCanceledError {
+		t.Fatalf("expected the statement to time out, got %v", err)
+	}
+	if !testutils.IsError(err, "statement timeout") {
+		t.Fatalf("expected a statement timeout error, got %v", err)
+	}
+
+	// Statements which finish in time are not affected.
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdleInTxnSessionTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	if _, err := db.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.test (k INT PRIMARY KEY, v INT);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, cleanup := openSingleConn(t, s)
+	defer cleanup()
+	if _, err := conn.Exec("SET idle_in_transaction_session_timeout = '100ms'"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A session which is idle outside of a transaction is not affected.
+	time.Sleep(500 * time.Millisecond)
+	if _, err := conn.Exec("BEGIN; INSERT INTO t.test VALUES (1, 1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection is closed once the session has been idle in the
+	// transaction for too long.
+	time.Sleep(500 * time.Millisecond)
+	if _, err := conn.Exec("SELECT 1"); !testutils.IsError(
+		err, "idle-in-transaction timeout|bad connection|EOF",
+	) {
+		t.Fatalf("expected the session to be terminated, got %v", err)
+	}
+
+	// The transaction was aborted, so its intent doesn't block other
+	// transactions.
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM t.test").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected the transaction to be aborted, found %d rows", count)
+	}
+}
+
+func TestUserSessionDefaults(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	checkTimeout := func(conn *gosql.DB, expected string) {
+		var timeout string
+		if err := conn.QueryRow("SHOW statement_timeout").Scan(&timeout); err != nil {
+			t.Fatal(err)
+		}
+		if timeout != expected {
+			t.Fatalf("expected statement_timeout %s, got %s", expected, timeout)
+		}
+	}
+
+	if _, err := db.Exec("ALTER USER root SET statement_timeout = '10s'"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user's default applies to its new sessions, and RESET restores it.
+	conn, cleanup := openSingleConn(t, s)
+	defer cleanup()
+	checkTimeout(conn, "10s")
+	if _, err := conn.Exec("SET statement_timeout = 0"); err != nil {
+		t.Fatal(err)
+	}
+	checkTimeout(conn, "0s")
+	if _, err := conn.Exec("RESET statement_timeout"); err != nil {
+		t.Fatal(err)
+	}
+	checkTimeout(conn, "10s")
+
+	// The defaults are cached by each node, and invalidated when the node
+	// changes them.
+	if _, err := db.Exec(
+		"UPSERT INTO system.user_settings VALUES ('root', 'statement_timeout', '30s')",
+	); err != nil {
+		t.Fatal(err)
+	}
+	conn2, cleanup2 := openSingleConn(t, s)
+	defer cleanup2()
+	checkTimeout(conn2, "10s")
+	if _, err := db.Exec("ALTER USER root SET statement_timeout = '15s'"); err != nil {
+		t.Fatal(err)
+	}
+	conn3, cleanup3 := openSingleConn(t, s)
+	defer cleanup3()
+	checkTimeout(conn3, "15s")
+
+	// Without a user default, the cluster default applies.
+	if _, err := db.Exec(`
+ALTER USER root RESET statement_timeout;
+SET CLUSTER SETTING sql.defaults.statement_timeout = '20s';
+`); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		conn, cleanup := openSingleConn(t, s)
+		defer cleanup()
+		var timeout string
+		if err := conn.QueryRow("SHOW statement_timeout").Scan(&timeout); err != nil {
+			return err
+		}
+		if timeout != "20s" {
+			return errors.Errorf("expected statement_timeout 20s, got %s", timeout)
+		}
+		return nil
+	})
+}
@@ -43,6 +43,10 @@ type scanNode struct {
 	// Set if the NO_INDEX_JOIN hint was given.
 	noIndexJoin bool
 
+	// Set if the statement's decisions are being recorded in the index
+	// selection cache; the index chosen by index selection is recorded there.
+	cachedScan *cachedScan
+
 	// The table columns, possibly including ones currently in schema changes.
 	cols []sqlbase.ColumnDescriptor
 	// There is a 1-1 correspondence between cols and resultColumns.
@@ -92,6 +96,12 @@ type scanNode struct {
 
 	disableBatchLimits bool
 
+	// estimatedScanRows is the number of rows of the index which index
+	// selection estimated are read, if scanRowsEstimated is set. It is only
+	// estimated for tables with statistics.
+	estimatedScanRows float64
+	scanRowsEstimated bool
+
 	scanVisibility scanVisibility
 	// This struct must be allocated on the heap and its location stay
 	// stable after construction because it implements
@@ -258,6 +268,7 @@ func (n *scanNode) initTable(
 		}
 	}
 	n.noIndexJoin = (indexHints != nil && indexHints.NoIndexJoin)
+	p.indexSelection.initScan(n)
 	return n.initDescDefaults(scanVisibility, wantedColumns)
 }
 
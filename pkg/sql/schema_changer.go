@@ -297,6 +297,31 @@ func (sc *SchemaChanger) maybeAddDropRename(
 	return false, nil
 }
 
+// maybePromoteEnumMembers makes the enum values added by ALTER TYPE ... ADD
+// VALUE writable in the table's columns. The new values are first published
+// as READ_ONLY, so that every node can decode them before any node is allowed
+// to write them.
+func (sc *SchemaChanger) maybePromoteEnumMembers(
+	ctx context.Context, table *sqlbase.TableDescriptor,
+) error {
+	if !table.HasReadOnlyEnumMembers() {
+		return nil
+	}
+	// Publish() waits for all the leases on the READ_ONLY version to be
+	// released before writing the promoted descriptor.
+	_, err := sc.leaseMgr.Publish(ctx, sc.tableID, func(desc *sqlbase.TableDescriptor) error {
+		if !desc.PromoteEnumMembers() {
+			// Return error so that Publish() doesn't increment the version.
+			return errDidntUpdateDescriptor
+		}
+		return nil
+	}, nil)
+	if err == errDidntUpdateDescriptor {
+		return nil
+	}
+	return err
+}
+
 // Execute the entire schema change in steps.
 func (sc *SchemaChanger) exec(ctx context.Context, evalCtx parser.EvalContext) error {
 	// Acquire lease.
@@ -339,6 +364,10 @@ func (sc *SchemaChanger) exec(ctx context.Context, evalCtx parser.EvalContext) e
 		return nil
 	}
 
+	if err := sc.maybePromoteEnumMembers(ctx, tableDesc); err != nil {
+		return err
+	}
+
 	// Wait for the schema change to propagate to all nodes after this function
 	// returns, so that the new schema is live everywhere. This is not needed for
 	// correctness but is done to make the UI experience/tests predictable.
@@ -929,7 +958,8 @@ func (s *SchemaChangeManager) Start(stopper *stop.Stopper) {
 						// unsetting UpVersion, and we still want to process
 						// outstanding mutations. Similar with a table marked for deletion.
 						if table.UpVersion || table.Dropped() || table.Adding() ||
-							table.Renamed() || len(table.Mutations) > 0 {
+							table.Renamed() || len(table.Mutations) > 0 ||
+							table.HasReadOnlyEnumMembers() {
 							if log.V(2) {
 								log.Infof(ctx, "%s: queue up pending schema change; table: %d, version: %d",
 									kv.Key, table.ID, table.Version)
@@ -957,7 +987,7 @@ func (s *SchemaChangeManager) Start(stopper *stop.Stopper) {
 							s.schemaChangers[table.ID] = schemaChanger
 						}
 
-					case *sqlbase.Descriptor_Database:
+					case *sqlbase.Descriptor_Database, *sqlbase.Descriptor_Type, *sqlbase.Descriptor_Function:
 						// Ignore.
 					}
 				}
@@ -39,7 +39,7 @@ type nameResolutionVisitor struct {
 	sources    multiSourceInfo
 	colOffsets []int
 	iVarHelper parser.IndexedVarHelper
-	searchPath parser.SearchPath
+	semaCtx    *parser.SemaContext
 
 	// foundDependentVars is set to true during the analysis if an
 	// expression was found which can change values between rows of the
@@ -95,12 +95,18 @@ func (v *nameResolutionVisitor) VisitPre(expr parser.Expr) (recurse bool, newNod
 		return true, ivar
 
 	case *parser.FuncExpr:
-		fd, err := t.Func.Resolve(v.searchPath)
+		fd, err := v.semaCtx.ResolveFunction(&t.Func)
 		if err != nil {
 			v.err = err
 			return false, expr
 		}
 
+		if fd.UserDefined != nil && fd.UserDefined.Volatility == parser.Volatile {
+			// Volatile user-defined functions must be evaluated for every row,
+			// like random().
+			v.foundDependentVars = true
+		}
+
 		if fd.HasOverloadsNeedingRepeatedEvaluation {
 			// TODO(knz): this property should really be an attribute of the
 			// individual overloads. By looking at the name-level property
@@ -189,7 +195,7 @@ func (p *planner) resolveNames(
 		sources:            sources,
 		colOffsets:         make([]int, len(sources)),
 		iVarHelper:         ivarHelper,
-		searchPath:         p.session.SearchPath,
+		semaCtx:            &p.semaCtx,
 		foundDependentVars: false,
 	}
 	colOffset := 0
@@ -18,6 +18,8 @@
 package sql
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"strings"
@@ -145,6 +147,62 @@ var DistSQLClusterExecMode = settings.RegisterEnumSetting(
 	},
 )
 
+// VectorizeExecMode controls if the DistSQL processors run with vectorized
+// operators.
+type VectorizeExecMode int64
+
+const (
+	// VectorizeOff means that the processors always work row at a time.
+	VectorizeOff VectorizeExecMode = iota
+	// VectorizeOn means that the processors supporting it run with vectorized
+	// operators working on batches of columns.
+	VectorizeOn
+)
+
+func (m VectorizeExecMode) String() string {
+	switch m {
+	case VectorizeOff:
+		return "off"
+	case VectorizeOn:
+		return "on"
+	default:
+		return fmt.Sprintf("invalid (%d)", m)
+	}
+}
+
+// VectorizeExecModeFromInt converts an int64 into a VectorizeExecMode
+func VectorizeExecModeFromInt(val int64) VectorizeExecMode {
+	return VectorizeExecMode(val)
+}
+
+// VectorizeClusterMode controls the cluster default for whether the DistSQL
+// processors run with vectorized operators.
+var VectorizeClusterMode = settings.RegisterEnumSetting(
+	"sql.defaults.vectorize",
+	"Default vectorized execution mode",
+	"Off",
+	map[int64]string{
+		int64(VectorizeOff): "Off",
+		int64(VectorizeOn):  "On",
+	},
+)
+
+// StatementTimeout controls the cluster default for the maximum duration of
+// the execution of a statement.
+var StatementTimeout = settings.RegisterNonNegativeDurationSetting(
+	"sql.defaults.statement_timeout",
+	"default maximum duration of any statement; zero disables the timeout",
+	0,
+)
+
+// IdleInTxnSessionTimeout controls the cluster default for the maximum
+// duration a session can remain idle inside an open transaction.
+var IdleInTxnSessionTimeout = settings.RegisterNonNegativeDurationSetting(
+	"sql.defaults.idle_in_transaction_session_timeout",
+	"default maximum duration a session can remain idle in an open transaction; zero disables the timeout",
+	0,
+)
+
 // queryPhase represents a phase during a query's execution.
 type queryPhase int
 
@@ -154,11 +212,18 @@ const (
 
 	// Execution phase.
 	executing = 1
+
+	// Waiting in the admission queue for SQL memory to become available,
+	// before preparing.
+	waiting = 2
 )
 
 // queryMeta stores metadata about a query. Stored as reference in
 // session.mu.ActiveQueries and planner.queryMeta.
 type queryMeta struct {
+	// The ID of the query, unique across the cluster.
+	id ClusterWideID
+
 	// The timestamp when this query began execution.
 	start time.Time
 
@@ -174,6 +239,40 @@ type queryMeta struct {
 
 	// Current phase of execution of query.
 	phase queryPhase
+
+	// session is the session running the query. Canceling a query cancels
+	// the context of the session's current transaction, which aborts the
+	// transaction and stops all its running operations, including the DistSQL
+	// flows on the other nodes. The context is looked up when the query is
+	// canceled, since the transaction can be restarted while the query runs.
+	session *Session
+
+	// canceled is set once the query was canceled, so that the error it
+	// returns is reported as a cancellation.
+	canceled bool
+
+	// timedOut is set when the query was canceled because it ran for longer
+	// than the statement_timeout of its session.
+	timedOut bool
+}
+
+// cancel cancels the query. It must be called with the lock of the session
+// of the query held.
+func (q *queryMeta) cancel() {
+	q.canceled = true
+	q.session.TxnState.cancelCtx()
+}
+
+// cancelError returns the error reported by the query if it was canceled, or
+// nil if it wasn't.
+func (q *queryMeta) cancelError() error {
+	if q.timedOut {
+		return sqlbase.NewStatementTimeoutError()
+	}
+	if q.canceled {
+		return sqlbase.NewQueryCanceledError()
+	}
+	return nil
 }
 
 // queryHandle is a type for uniquely identifying queries in a session.
@@ -195,12 +294,23 @@ type Session struct {
 	// DistSQLMode indicates whether to run queries using the distributed
 	// execution engine.
 	DistSQLMode DistSQLExecMode
+	// IdleInTxnSessionTimeout is the maximum duration the session can remain
+	// idle inside an open transaction before the connection is terminated and
+	// the transaction aborted. Zero disables the timeout.
+	IdleInTxnSessionTimeout time.Duration
 	// Location indicates the current time zone.
 	Location *time.Location
 	// SearchPath is a list of databases that will be searched for a table name
 	// before the database. Currently, this is used only for SELECTs.
 	// Names in the search path must have been normalized already.
 	SearchPath parser.SearchPath
+	// StatementTimeout is the maximum duration of the execution of a
+	// statement; statements running for longer are canceled. Zero disables
+	// the timeout.
+	StatementTimeout time.Duration
+	// VectorizeMode indicates whether the DistSQL processors run with
+	// vectorized operators.
+	VectorizeMode VectorizeExecMode
 	// User is the name of the user logged into the session.
 	User string
 
@@ -226,6 +336,8 @@ type Session struct {
 	// that have been prepared via pgwire.
 	PreparedStatements PreparedStatements
 	PreparedPortals    PreparedPortals
+	// cursors stores the cursors declared in the current transaction.
+	cursors cursorCollection
 	// virtualSchemas aliases Executor.virtualSchemas.
 	// It is duplicated in Session to provide easier access to
 	// the various methods that need this reference.
@@ -279,6 +391,12 @@ type Session struct {
 	// statistics for result sets (which escape transactions).
 	mon        mon.MemoryMonitor
 	sessionMon mon.MemoryMonitor
+	// memBudgets, if set, provides the memory group whose monitor is the
+	// pool of mon and the admission queue consulted before each query.
+	// memGroup is the group acquired by the session. See
+	// StartGroupMonitor().
+	memBudgets *MemoryBudgets
+	memGroup   *memoryGroup
 	// emergencyShutdown is set to true by EmergencyClose() to
 	// indicate to Finish() that the session is already closed.
 	emergencyShutdown bool
@@ -289,6 +407,9 @@ type Session struct {
 
 	// If set, contains the in progress COPY FROM columns.
 	copyFrom *copyNode
+	// CopyOutWriter, if set, is used to stream the rows of COPY TO
+	// statements to the client.
+	CopyOutWriter CopyOutWriter
 
 	// mu contains of all elements of the struct that can be changed
 	// after initialization, and may be accessed from another thread.
@@ -310,8 +431,20 @@ type Session struct {
 
 		// ActiveQueries contains all queries in flight.
 		ActiveQueries map[queryHandle]struct{}
+
+		// canceled is set once the session was canceled by CANCEL SESSION.
+		canceled bool
 	}
 
+	// id is the ID of the session, unique across the cluster.
+	id ClusterWideID
+
+	// cancelKey identifies the session in the pgwire CancelRequest messages
+	// with which a client cancels the queries running on its connection from
+	// another connection. Its high 32 bits hold the ID of the node of the
+	// session and its low 32 bits are random.
+	cancelKey uint64
+
 	//
 	// Testing state.
 	//
@@ -332,6 +465,17 @@ type Session struct {
 	// sqlStats tracks per-application statistics for all
 	// applications on each node.
 	sqlStats *sqlStats
+	// statsRefresher refreshes the statistics of the tables modified by the
+	// session.
+	statsRefresher *statsRefresher
+	// tableStats caches the table statistics used to plan the session's
+	// queries.
+	tableStats *tableStatsCache
+	// indexSelectionCache caches the index selection decisions of the
+	// session's prepared statements.
+	indexSelectionCache *indexSelectionCache
+	// userDefaultsCache caches the session variable defaults of the users.
+	userDefaultsCache *userDefaultsCache
 	// appStats track per-application SQL usage statistics.
 	appStats *appStats
 	// phaseTimes tracks session-level phase times. It is copied-by-value
@@ -348,6 +492,9 @@ type Session struct {
 type sessionDefaults struct {
 	applicationName string
 	database        string
+	// userVars holds the defaults of the session variables configured for
+	// the session's user with ALTER USER ... SET, keyed by variable name.
+	userVars map[string]string
 }
 
 // SessionArgs contains arguments for creating a new Session with NewSession().
@@ -382,6 +529,84 @@ func (r *SessionRegistry) deregister(s *Session) {
 	r.Unlock()
 }
 
+// CancelQuery looks up the query with the given ID and cancels it, if the
+// given user is allowed to do so. It returns whether the query was found and
+// canceled.
+func (r *SessionRegistry) CancelQuery(queryIDStr string, username string) (bool, error) {
+	queryID, err := StringToClusterWideID(queryIDStr)
+	if err != nil {
+		return false, errors.Wrapf(err, "query ID %s malformed", queryIDStr)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for s := range r.store {
+		if !(username == security.RootUser || username == security.NodeUser || username == s.User) {
+			// Skip this session.
+			continue
+		}
+
+		s.mu.Lock()
+		for query := range s.mu.ActiveQueries {
+			if query.id == queryID {
+				query.cancel()
+				s.mu.Unlock()
+				return true, nil
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return false, fmt.Errorf("query ID %s not found", queryID)
+}
+
+// CancelSession looks up the session with the given ID and cancels it, if
+// the given user is allowed to do so. It returns whether the session was
+// found and canceled.
+func (r *SessionRegistry) CancelSession(sessionIDStr string, username string) (bool, error) {
+	sessionID, err := StringToClusterWideID(sessionIDStr)
+	if err != nil {
+		return false, errors.Wrapf(err, "session ID %s malformed", sessionIDStr)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for s := range r.store {
+		if !(username == security.RootUser || username == security.NodeUser || username == s.User) {
+			// Skip this session.
+			continue
+		}
+		if s.id == sessionID {
+			s.cancelSession()
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("session ID %s not found", sessionID)
+}
+
+// CancelQueriesByKey cancels the queries running on the session with the
+// given pgwire cancel key. It returns whether such a session exists.
+func (r *SessionRegistry) CancelQueriesByKey(cancelKey uint64) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for s := range r.store {
+		if s.cancelKey != cancelKey {
+			continue
+		}
+		s.mu.Lock()
+		for query := range s.mu.ActiveQueries {
+			query.cancel()
+		}
+		s.mu.Unlock()
+		return true
+	}
+	return false
+}
+
 // SerializeAll returns a slice of all sessions in the registry, converted to serverpb.Sessions.
 func (r *SessionRegistry) SerializeAll() []serverpb.Session {
 	r.Lock()
@@ -407,17 +632,24 @@ func NewSession(
 		distSQLMode = DistSQLExecModeFromInt(e.cfg.TestingKnobs.OverrideDistSQLMode.Get())
 	}
 	s := &Session{
-		Database:         args.Database,
-		DistSQLMode:      distSQLMode,
-		SearchPath:       sqlbase.DefaultSearchPath,
-		Location:         time.UTC,
-		User:             args.User,
-		virtualSchemas:   e.virtualSchemas,
-		execCfg:          &e.cfg,
-		distSQLPlanner:   e.distSQLPlanner,
-		parallelizeQueue: MakeParallelizeQueue(NewSpanBasedDependencyAnalyzer()),
-		memMetrics:       memMetrics,
-		sqlStats:         &e.sqlStats,
+		Database:                args.Database,
+		DistSQLMode:             distSQLMode,
+		IdleInTxnSessionTimeout: IdleInTxnSessionTimeout.Get(),
+		SearchPath:              sqlbase.DefaultSearchPath,
+		StatementTimeout:        StatementTimeout.Get(),
+		VectorizeMode:           VectorizeExecModeFromInt(VectorizeClusterMode.Get()),
+		Location:                time.UTC,
+		User:                    args.User,
+		virtualSchemas:          e.virtualSchemas,
+		execCfg:                 &e.cfg,
+		distSQLPlanner:          e.distSQLPlanner,
+		parallelizeQueue:        MakeParallelizeQueue(NewSpanBasedDependencyAnalyzer()),
+		memMetrics:              memMetrics,
+		sqlStats:                &e.sqlStats,
+		statsRefresher:          &e.statsRefresher,
+		tableStats:              &e.tableStats,
+		indexSelectionCache:     &e.indexSelectionCache,
+		userDefaultsCache:       &e.userDefaultsCache,
 		defaults: sessionDefaults{
 			applicationName: args.ApplicationName,
 			database:        args.Database,
@@ -444,12 +676,49 @@ func NewSession(
 		s.eventLog = trace.NewEventLog(fmt.Sprintf("sql [%s]", args.User), remoteStr)
 	}
 	s.context, s.cancel = context.WithCancel(ctx)
+	s.id = e.generateID()
+
+	var secret [4]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		panic(err)
+	}
+	s.cancelKey = uint64(e.cfg.NodeID.Get())<<32 | uint64(binary.BigEndian.Uint32(secret[:]))
 
 	e.cfg.SessionRegistry.register(s)
 
 	return s
 }
 
+// ApplyUserDefaults applies to the session the defaults of the session
+// variables configured for its user with ALTER USER ... SET. RESET restores
+// these defaults too. Defaults which can no longer be applied, for example
+// because they refer to a database which was dropped, are skipped.
+func (s *Session) ApplyUserDefaults(e *Executor) error {
+	defaults, err := s.userDefaultsCache.get(s.Ctx(), e, s.User, s.memMetrics)
+	if err != nil || len(defaults) == 0 {
+		return err
+	}
+	// Some variables, like the database, are validated against the schema.
+	return e.cfg.DB.Txn(s.Ctx(), func(ctx context.Context, txn *client.Txn) error {
+		p := s.newPlanner(e, txn)
+		userVars := make(map[string]string, len(defaults))
+		for _, d := range defaults {
+			v, ok := varGen[d.varName]
+			if !ok || v.Set == nil {
+				continue
+			}
+			if err := v.Set(ctx, p, []parser.TypedExpr{parser.NewDString(d.value)}); err != nil {
+				log.Warningf(ctx, "unable to apply default %s = %q of user %s: %v",
+					d.varName, d.value, s.User, err)
+				continue
+			}
+			userVars[d.varName] = d.value
+		}
+		s.defaults.userVars = userVars
+		return nil
+	})
+}
+
 // Finish releases resources held by the Session. It is called by the Session's
 // main goroutine, so no synchronous queries will be in-flight during the
 // method's execution. However, it could be called when asynchronous queries are
@@ -502,6 +771,7 @@ func (s *Session) Finish(e *Executor) {
 	s.ClearStatementsAndPortals(s.context)
 	s.sessionMon.Stop(s.context)
 	s.mon.Stop(s.context)
+	s.releaseMemGroup(false /* emergency */)
 
 	if s.eventLog != nil {
 		s.eventLog.Finish()
@@ -543,6 +813,7 @@ func (s *Session) EmergencyClose() {
 	// Shut the remaining monitors down.
 	s.sessionMon.EmergencyStop(s.context)
 	s.mon.EmergencyStop(s.context)
+	s.releaseMemGroup(true /* emergency */)
 
 	// Finalize the event log.
 	if s.eventLog != nil {
@@ -578,6 +849,8 @@ func (s *Session) resetPlanner(p *planner, e *Executor, txn *client.Txn) {
 	p.semaCtx = parser.MakeSemaContext(s.User == security.RootUser)
 	p.semaCtx.Location = &s.Location
 	p.semaCtx.SearchPath = s.SearchPath
+	p.semaCtx.TypeResolver = p
+	p.semaCtx.FunctionResolver = p
 
 	p.evalCtx = s.evalCtx()
 	p.evalCtx.Planner = p
@@ -611,6 +884,7 @@ func (s *Session) evalCtx() parser.EvalContext {
 	return parser.EvalContext{
 		Location:   &s.Location,
 		Database:   s.Database,
+		User:       s.User,
 		SearchPath: s.SearchPath,
 		Ctx:        s.Ctx,
 		Mon:        &s.TxnState.mon,
@@ -635,14 +909,44 @@ func (s *Session) setTestingVerifyMetadata(fn func(config.SystemConfig) error) {
 	s.verifyFnCheckedOnce = false
 }
 
+// cancelSession cancels the session: its running queries are canceled, its
+// transaction is aborted and its client connection is closed as soon as the
+// connection notices it.
+func (s *Session) cancelSession() {
+	s.mu.Lock()
+	s.mu.canceled = true
+	for query := range s.mu.ActiveQueries {
+		query.cancel()
+	}
+	s.mu.Unlock()
+	// Canceling the session's context also cancels the context of its
+	// transaction, which is derived from it.
+	s.cancel()
+}
+
+// Canceled returns whether the session was canceled by CANCEL SESSION.
+func (s *Session) Canceled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mu.canceled
+}
+
+// CancelKey returns the key with which the queries of the session can be
+// canceled through a pgwire CancelRequest.
+func (s *Session) CancelKey() uint64 {
+	return s.cancelKey
+}
+
 // addActiveQuery adds a running query to the session's internal store of active
 // queries. Called from executor's execStmt and execStmtInParallel.
-func (s *Session) addActiveQuery(stmt Statement) queryHandle {
+func (s *Session) addActiveQuery(queryID ClusterWideID, stmt Statement) queryHandle {
 	s.mu.Lock()
 	query := &queryMeta{
-		start: timeutil.Now(),
-		stmt:  stmt.AST,
-		phase: preparing,
+		id:      queryID,
+		start:   timeutil.Now(),
+		stmt:    stmt.AST,
+		phase:   preparing,
+		session: s,
 	}
 	s.mu.ActiveQueries[query] = struct{}{}
 	s.mu.Unlock()
@@ -657,6 +961,60 @@ func (s *Session) removeActiveQuery(query queryHandle) {
 	s.mu.Unlock()
 }
 
+// timeoutQuery cancels a query which ran for longer than the session's
+// statement timeout.
+func (s *Session) timeoutQuery(query queryHandle) {
+	s.mu.Lock()
+	if _, ok := s.mu.ActiveQueries[query]; ok {
+		queryMeta := (*queryMeta)(query)
+		queryMeta.timedOut = true
+		queryMeta.cancel()
+	}
+	s.mu.Unlock()
+}
+
+// queryCancelError returns the error to report for the query if it was
+// canceled, or nil if it wasn't.
+func (s *Session) queryCancelError(query queryHandle) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return (*queryMeta)(query).cancelError()
+}
+
+// admitQuery moves the session to its current memory group, then blocks
+// until the session's memory budgets admit a new query, marking the
+// query as waiting in the meantime. Statements that end a transaction
+// are never held back, since they release memory.
+func (s *Session) admitQuery(ctx context.Context, stmt Statement) error {
+	if s.memBudgets == nil {
+		return nil
+	}
+	switch stmt.AST.(type) {
+	case *parser.CommitTransaction, *parser.RollbackTransaction,
+		*parser.ReleaseSavepoint, *parser.RollbackToSavepoint:
+		return nil
+	}
+	if err := s.refreshMemGroup(ctx); err != nil {
+		return err
+	}
+	query := stmt.queryHandle
+	waited := false
+	err := s.memBudgets.admit(ctx, func() {
+		waited = true
+		s.setQueryPhase(query, waiting)
+	})
+	if waited {
+		s.setQueryPhase(query, preparing)
+	}
+	return err
+}
+
+func (s *Session) setQueryPhase(query queryHandle, phase queryPhase) {
+	s.mu.Lock()
+	(*queryMeta)(query).phase = phase
+	s.mu.Unlock()
+}
+
 // setQueryExecutionMode is called upon start of execution of a query, and sets
 // the query's metadata to indicate whether it's distributed or not.
 func (s *Session) setQueryExecutionMode(query queryHandle, isDistributed bool) {
@@ -689,6 +1047,7 @@ func (s *Session) serialize() serverpb.Session {
 			sql = sql[:997] + "..."
 		}
 		activeQueries = append(activeQueries, serverpb.ActiveQuery{
+			ID:            query.id.String(),
 			Start:         query.start.UTC(),
 			Sql:           sql,
 			IsDistributed: query.isDistributed,
@@ -703,6 +1062,7 @@ func (s *Session) serialize() serverpb.Session {
 		Start:           s.phaseTimes[sessionInit].UTC(),
 		ActiveQueries:   activeQueries,
 		KvTxnID:         kvTxnID,
+		ID:              s.id.String(),
 	}
 }
 
@@ -751,6 +1111,11 @@ type txnState struct {
 		syncutil.RWMutex
 
 		txn *client.Txn
+
+		// cancel cancels Ctx. It is called when one of the txn's queries is
+		// canceled, possibly from another goroutine, and when the txn is
+		// finished.
+		cancel context.CancelFunc
 	}
 
 	// Ctx is the context for everything running in this SQL txn.
@@ -849,6 +1214,10 @@ func (ts *txnState) resetForNewSQLTxn(e *Executor, s *Session, implicitTxn bool)
 
 	// Put the new span in the context.
 	ctx = opentracing.ContextWithSpan(ctx, sp)
+	ctx, cancel := context.WithCancel(ctx)
+	ts.mu.Lock()
+	ts.mu.cancel = cancel
+	ts.mu.Unlock()
 
 	if !tracing.IsRecordable(sp) {
 		log.Fatalf(ctx, "non-recordable transaction span of type: %T", sp)
@@ -900,6 +1269,7 @@ func (ts *txnState) resetStateAndTxn(state TxnStateEnum) {
 // starting another SQL txn.
 // The session context is just used for logging the SQL trace.
 func (ts *txnState) finishSQLTxn(s *Session) {
+	s.closeCursors(ts.Ctx)
 	ts.mon.Stop(ts.Ctx)
 	if ts.sp == nil {
 		panic("No span in context? Was resetForNewSQLTxn() called previously?")
@@ -926,6 +1296,21 @@ func (ts *txnState) finishSQLTxn(s *Session) {
 		}
 	}
 	ts.sp = nil
+	ts.mu.Lock()
+	cancel := ts.mu.cancel
+	ts.mu.cancel = nil
+	ts.mu.Unlock()
+	cancel()
+}
+
+// cancelCtx cancels the context of the current transaction, if any.
+func (ts *txnState) cancelCtx() {
+	ts.mu.RLock()
+	cancel := ts.mu.cancel
+	ts.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
 }
 
 // updateStateAndCleanupOnErr updates txnState based on the type of error that we
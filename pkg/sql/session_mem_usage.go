@@ -21,6 +21,7 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
 	"github.com/cockroachdb/cockroach/pkg/sql/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 )
@@ -32,15 +33,15 @@ func (s *Session) OpenAccount() WrappableMemoryAccount {
 	return res
 }
 
-// OpenAccount interfaces between TxnState and mon.MemoryMonitor.
-func (ts *txnState) OpenAccount() WrappableMemoryAccount {
+// openAccount interfaces between planner and mon.MemoryMonitor.
+func (p *planner) openAccount() WrappableMemoryAccount {
 	res := WrappableMemoryAccount{}
-	ts.mon.OpenAccount(&res.acc)
+	p.evalCtx.Mon.OpenAccount(&res.acc)
 	return res
 }
 
 // WrappableMemoryAccount encapsulates a MemoryAccount to
-// give it the Wsession()/Wtxn() method below.
+// give it the Wsession()/Wplanner() method below.
 type WrappableMemoryAccount struct {
 	acc mon.MemoryAccount
 }
@@ -54,12 +55,12 @@ func (w *WrappableMemoryAccount) Wsession(s *Session) WrappedMemoryAccount {
 	}
 }
 
-// Wtxn captures the current txn-specific monitor pointer so it can be provided
-// transparently to the other Account APIs below.
-func (w *WrappableMemoryAccount) Wtxn(s *Session) WrappedMemoryAccount {
+// Wplanner captures the monitor pointer of the planner's statement so it can
+// be provided transparently to the other Account APIs below.
+func (w *WrappableMemoryAccount) Wplanner(p *planner) WrappedMemoryAccount {
 	return WrappedMemoryAccount{
 		acc: &w.acc,
-		mon: &s.TxnState.mon,
+		mon: p.evalCtx.Mon,
 	}
 }
 
@@ -142,10 +143,24 @@ func (s *Session) deriveAndStartMonitors() {
 		-1, noteworthyMemoryUsageBytes)
 }
 
+// startStmtMonitor starts a monitor tracking the memory used by the
+// execution of a single statement, capped by sql.memory.query_limit. It
+// draws from the transaction monitor and must be stopped once the
+// statement's plan is closed.
+func (s *Session) startStmtMonitor() *mon.MemoryMonitor {
+	stmtMon := mon.MakeMonitor("stmt",
+		nil, /* curCount */
+		nil, /* maxHist */
+		-1, noteworthyMemoryUsageBytes)
+	stmtMon.Start(s.Ctx(), &s.TxnState.mon, mon.BoundAccount{})
+	stmtMon.SetLimit(distsqlrun.QueryMemoryLimit.Get())
+	return &stmtMon
+}
+
 func (s *Session) makeBoundAccount() mon.BoundAccount {
 	return s.sessionMon.MakeBoundAccount()
 }
 
-func (ts *txnState) makeBoundAccount() mon.BoundAccount {
-	return ts.mon.MakeBoundAccount()
+func (p *planner) makeBoundAccount() mon.BoundAccount {
+	return p.evalCtx.Mon.MakeBoundAccount()
 }
@@ -18,6 +18,7 @@ package sql
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -79,6 +80,14 @@ func (p *planner) Set(ctx context.Context, n *parser.Set) (planNode, error) {
 			return nil, err
 		}
 	case parser.SetModeReset:
+		if userDefault, ok := p.session.defaults.userVars[strings.ToLower(name)]; ok && v.Set != nil {
+			// The user has its own default for the variable, configured with
+			// ALTER USER ... SET.
+			if err := v.Set(ctx, p, []parser.TypedExpr{parser.NewDString(userDefault)}); err != nil {
+				return nil, err
+			}
+			break
+		}
 		if v.Reset == nil {
 			return nil, fmt.Errorf("variable \"%s\" cannot be reset", name)
 		}
@@ -253,6 +262,63 @@ func (p *planner) getStringVal(name string, values []parser.TypedExpr) (string,
 	return p.datumAsString(name, values[0])
 }
 
+// getTimeoutVal interprets the value of a timeout session variable. Integers
+// are interpreted as milliseconds, like in Postgres; strings are interpreted
+// as a number of milliseconds or as an interval. A timeout of zero disables
+// the timeout.
+func (p *planner) getTimeoutVal(name string, values []parser.TypedExpr) (time.Duration, error) {
+	if len(values) != 1 {
+		return 0, fmt.Errorf("set %s: requires a single value", name)
+	}
+	d, err := values[0].Eval(&p.evalCtx)
+	if err != nil {
+		return 0, err
+	}
+
+	var timeout time.Duration
+	switch v := parser.UnwrapDatum(d).(type) {
+	case *parser.DString:
+		timeout, err = parseTimeout(string(*v))
+		if err != nil {
+			return 0, fmt.Errorf("set %s: invalid timeout %q: %v", name, string(*v), err)
+		}
+
+	case *parser.DInt:
+		timeout = time.Duration(*v) * time.Millisecond
+
+	case *parser.DInterval:
+		nanos, _, _, err := v.Duration.Encode()
+		if err != nil {
+			return 0, err
+		}
+		timeout = time.Duration(nanos)
+
+	default:
+		return 0, fmt.Errorf("set %s: bad timeout value: %s", name, d)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("set %s: timeout cannot be negative", name)
+	}
+	return timeout, nil
+}
+
+// parseTimeout parses a string timeout value, which is either a number of
+// milliseconds or an interval.
+func parseTimeout(s string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	interval, err := parser.ParseDInterval(s)
+	if err != nil {
+		return 0, err
+	}
+	nanos, _, _, err := interval.Duration.Encode()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(nanos), nil
+}
+
 func (p *planner) SetDefaultIsolation(n *parser.SetDefaultIsolation) (planNode, error) {
 	// Note: We also support SET DEFAULT_TRANSACTION_ISOLATION TO ' .... ' above.
 	// Ensure both versions stay in sync.
@@ -386,7 +386,12 @@ func (p *planner) ShowCreateTable(
 		constructor: func(ctx context.Context, p *planner) (planNode, error) {
 			v := p.newContainerValuesNode(columns, 0)
 
-			s, err := p.showCreateTable(ctx, tn.TableName, desc)
+			comments, err := p.getComments(ctx, desc.ID)
+			if err != nil {
+				v.rows.Close(ctx)
+				return nil, err
+			}
+			s, err := p.showCreateTable(ctx, tn.TableName, desc, comments)
 			if err != nil {
 				v.rows.Close(ctx)
 				return nil, err
@@ -404,23 +409,38 @@ func (p *planner) ShowCreateTable(
 	}, nil
 }
 
+// showCreateTable returns a CREATE TABLE statement for the table, followed
+// by the COMMENT ON statements which recreate its comments.
 func (p *planner) showCreateTable(
-	ctx context.Context, tn parser.Name, desc *sqlbase.TableDescriptor,
+	ctx context.Context,
+	tn parser.Name,
+	desc *sqlbase.TableDescriptor,
+	comments map[commentKey]string,
 ) (string, error) {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "CREATE TABLE %s (", tn)
 	var primary string
+	var primaryColID sqlbase.ColumnID
+	if desc.IsPhysicalTable() {
+		primaryColID = desc.PrimaryIndex.ColumnIDs[0]
+		if desc.PrimaryIndex.IsSharded() {
+			// The first column of a hash sharded primary key is its hidden
+			// shard column.
+			primaryColID = desc.PrimaryIndex.ColumnIDs[1]
+		}
+	}
 	for i, col := range desc.VisibleColumns() {
 		if i != 0 {
 			buf.WriteString(",")
 		}
 		buf.WriteString("\n\t")
 		buf.WriteString(col.SQLString())
-		if desc.IsPhysicalTable() && desc.PrimaryIndex.ColumnIDs[0] == col.ID {
+		if desc.IsPhysicalTable() && primaryColID == col.ID {
 			// Only set primary if the primary key is on a visible column (not rowid).
-			primary = fmt.Sprintf(",\n\tCONSTRAINT %s PRIMARY KEY (%s)",
+			primary = fmt.Sprintf(",\n\tCONSTRAINT %s PRIMARY KEY (%s)%s",
 				quoteNames(desc.PrimaryIndex.Name),
 				desc.PrimaryIndex.ColNamesString(),
+				desc.PrimaryIndex.ShardingString(),
 			)
 		}
 	}
@@ -480,6 +500,7 @@ func (p *planner) showCreateTable(
 		return "", err
 	}
 	buf.WriteString(interleave)
+	buf.WriteString(showCreateTableComments(tn, desc, comments))
 
 	return buf.String(), nil
 }
@@ -883,6 +904,7 @@ func (p *planner) ShowConstraints(
 
 func (p *planner) ShowQueries(ctx context.Context, n *parser.ShowQueries) (planNode, error) {
 	columns := sqlbase.ResultColumns{
+		{Name: "query_id", Typ: parser.TypeString},
 		{Name: "node_id", Typ: parser.TypeInt},
 		{Name: "username", Typ: parser.TypeString},
 		{Name: "start", Typ: parser.TypeTimestamp},
@@ -923,6 +945,7 @@ func (p *planner) ShowQueries(ctx context.Context, n *parser.ShowQueries) (planN
 						}
 					}
 					row := parser.Datums{
+						parser.NewDString(query.ID),
 						parser.NewDInt(parser.DInt(session.NodeID)),
 						parser.NewDString(session.Username),
 						parser.MakeDTimestamp(query.Start, time.Microsecond),
@@ -943,6 +966,7 @@ func (p *planner) ShowQueries(ctx context.Context, n *parser.ShowQueries) (planN
 				if rpcErr.NodeID != 0 {
 					// Add a row with this node ID, and nulls for all other columns
 					_, err := v.rows.AddRow(ctx, parser.Datums{
+						parser.DNull,
 						parser.NewDInt(parser.DInt(rpcErr.NodeID)),
 						parser.DNull,
 						parser.DNull,
@@ -968,6 +992,7 @@ func (p *planner) ShowQueries(ctx context.Context, n *parser.ShowQueries) (planN
 
 func (p *planner) ShowSessions(ctx context.Context, n *parser.ShowSessions) (planNode, error) {
 	columns := sqlbase.ResultColumns{
+		{Name: "session_id", Typ: parser.TypeString},
 		{Name: "node_id", Typ: parser.TypeInt},
 		{Name: "username", Typ: parser.TypeString},
 		{Name: "client_address", Typ: parser.TypeString},
@@ -1025,6 +1050,7 @@ func (p *planner) ShowSessions(ctx context.Context, n *parser.ShowSessions) (pla
 				}
 
 				row := parser.Datums{
+					parser.NewDString(session.ID),
 					parser.NewDInt(parser.DInt(session.NodeID)),
 					parser.NewDString(session.Username),
 					parser.NewDString(session.ClientAddress),
@@ -1044,6 +1070,7 @@ func (p *planner) ShowSessions(ctx context.Context, n *parser.ShowSessions) (pla
 				if rpcErr.NodeID != 0 {
 					// Add a row with this node ID, and nulls for all other columns
 					_, err := v.rows.AddRow(ctx, parser.Datums{
+						parser.DNull,
 						parser.NewDInt(parser.DInt(rpcErr.NodeID)),
 						parser.DNull,
 						parser.DNull,
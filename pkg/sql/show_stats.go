@@ -0,0 +1,159 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// This file implements the SHOW STATISTICS and SHOW HISTOGRAM statements:
+//   SHOW STATISTICS FOR TABLE t
+//   SHOW HISTOGRAM 123
+//
+// The first shows the statistics collected on the columns of a table by
+// CREATE STATISTICS and ANALYZE; the second shows the buckets of a histogram,
+// identified by the histogram_id column of SHOW STATISTICS.
+
+package sql
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+)
+
+var showStatsColumns = sqlbase.ResultColumns{
+	{Name: "statistics_name", Typ: parser.TypeString},
+	{Name: "column_name", Typ: parser.TypeString},
+	{Name: "created", Typ: parser.TypeTimestamp},
+	{Name: "row_count", Typ: parser.TypeInt},
+	{Name: "distinct_count", Typ: parser.TypeInt},
+	{Name: "null_count", Typ: parser.TypeInt},
+	{Name: "histogram_id", Typ: parser.TypeInt},
+}
+
+var showHistogramColumns = sqlbase.ResultColumns{
+	{Name: "upper_bound", Typ: parser.TypeString},
+	{Name: "range_rows", Typ: parser.TypeInt},
+	{Name: "equal_rows", Typ: parser.TypeInt},
+}
+
+// ShowStats returns the statistics collected on the columns of a table.
+// Privileges: Any privilege on table.
+//   Notes: postgres exposes statistics through the pg_stats view.
+func (p *planner) ShowStats(ctx context.Context, n *parser.ShowStats) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, false /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.anyPrivilege(desc); err != nil {
+		return nil, err
+	}
+
+	return &delayedNode{
+		name:    "SHOW STATISTICS FOR TABLE " + tn.String(),
+		columns: showStatsColumns,
+		constructor: func(ctx context.Context, p *planner) (planNode, error) {
+			rows, err := p.queryRowsAsRoot(ctx,
+				`SELECT "statisticID", name, "columnID", "createdAt",
+				        "rowCount", "distinctCount", "nullCount", histogram IS NOT NULL
+				 FROM system.table_statistics
+				 WHERE "tableID" = $1
+				 ORDER BY "createdAt", "columnID", "statisticID"`,
+				int(desc.ID))
+			if err != nil {
+				return nil, err
+			}
+
+			v := p.newContainerValuesNode(showStatsColumns, len(rows))
+			for _, r := range rows {
+				columnName := parser.DNull
+				if col, err := desc.FindColumnByID(sqlbase.ColumnID(parser.MustBeDInt(r[2]))); err == nil {
+					columnName = parser.NewDString(col.Name)
+				}
+				histogramID := parser.DNull
+				if *r[7].(*parser.DBool) {
+					histogramID = r[0]
+				}
+				newRow := parser.Datums{r[1], columnName, r[3], r[4], r[5], r[6], histogramID}
+				if _, err := v.rows.AddRow(ctx, newRow); err != nil {
+					v.Close(ctx)
+					return nil, err
+				}
+			}
+			return v, nil
+		},
+	}, nil
+}
+
+// ShowHistogram returns the buckets of a histogram.
+// Privileges: Any privilege on the table of the histogram.
+func (p *planner) ShowHistogram(ctx context.Context, n *parser.ShowHistogram) (planNode, error) {
+	return &delayedNode{
+		name:    fmt.Sprintf("SHOW HISTOGRAM %d", n.HistogramID),
+		columns: showHistogramColumns,
+		constructor: func(ctx context.Context, p *planner) (planNode, error) {
+			rows, err := p.queryRowsAsRoot(ctx,
+				`SELECT "tableID", "columnID", histogram
+				 FROM system.table_statistics
+				 WHERE "statisticID" = $1`,
+				n.HistogramID)
+			if err != nil {
+				return nil, err
+			}
+			if len(rows) == 0 || rows[0][2] == parser.DNull {
+				return nil, fmt.Errorf("histogram %d not found", n.HistogramID)
+			}
+			row := rows[0]
+
+			desc, err := sqlbase.GetTableDescFromID(ctx, p.txn, sqlbase.ID(parser.MustBeDInt(row[0])))
+			if err != nil {
+				return nil, err
+			}
+			if err := p.anyPrivilege(desc); err != nil {
+				return nil, err
+			}
+			col, err := desc.FindColumnByID(sqlbase.ColumnID(parser.MustBeDInt(row[1])))
+			if err != nil {
+				return nil, err
+			}
+
+			var histogram stats.HistogramData
+			if err := histogram.Unmarshal([]byte(*row[2].(*parser.DBytes))); err != nil {
+				return nil, err
+			}
+			upperBounds, err := histogram.DecodeUpperBounds(col.Type.ToDatumType())
+			if err != nil {
+				return nil, err
+			}
+
+			v := p.newContainerValuesNode(showHistogramColumns, len(histogram.Buckets))
+			for i, b := range histogram.Buckets {
+				newRow := parser.Datums{
+					parser.NewDString(parser.AsStringWithFlags(upperBounds[i], parser.FmtBareStrings)),
+					parser.NewDInt(parser.DInt(b.NumRange)),
+					parser.NewDInt(parser.DInt(b.NumEq)),
+				}
+				if _, err := v.rows.AddRow(ctx, newRow); err != nil {
+					v.Close(ctx)
+					return nil, err
+				}
+			}
+			return v, nil
+		},
+	}, nil
+}
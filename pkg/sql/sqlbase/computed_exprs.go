@@ -0,0 +1,229 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// IsComputed returns whether the values of the column are computed from the
+// other columns of the row.
+func (desc *ColumnDescriptor) IsComputed() bool {
+	return desc.ComputeExpr != nil
+}
+
+// IsSharded returns whether the index is hash sharded, in which case its
+// first column is the shard column.
+func (desc *IndexDescriptor) IsSharded() bool {
+	return desc.ShardBuckets > 0
+}
+
+// MakeShardColumn returns the hidden computed column holding the bucket of
+// the rows of an index on colNames sharded in the given number of buckets.
+func MakeShardColumn(colNames []string, buckets int32) ColumnDescriptor {
+	var buf bytes.Buffer
+	buf.WriteString("mod(fnv32(")
+	for i, c := range colNames {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "COALESCE(CAST(%s AS STRING), '')", parser.AsString(parser.Name(c)))
+	}
+	fmt.Fprintf(&buf, "), %d)", buckets)
+	expr := buf.String()
+	return ColumnDescriptor{
+		Name:        fmt.Sprintf("crdb_internal_%s_shard_%d", strings.Join(colNames, "_"), buckets),
+		Type:        ColumnType{Kind: ColumnType_INT},
+		Hidden:      true,
+		ComputeExpr: &expr,
+	}
+}
+
+// AddDependentComputedColumns appends to cols the computed columns of
+// tableDesc whose expressions reference one of cols, so that they are
+// recomputed when cols are updated. The computed columns being added which
+// can be written are included.
+func AddDependentComputedColumns(
+	tableDesc *TableDescriptor, cols []ColumnDescriptor,
+) ([]ColumnDescriptor, error) {
+	colIDSet := make(map[ColumnID]struct{}, len(cols))
+	for _, col := range cols {
+		colIDSet[col.ID] = struct{}{}
+	}
+	candidates := tableDesc.Columns
+	for _, m := range tableDesc.Mutations {
+		if col := m.GetColumn(); col != nil &&
+			m.State == DescriptorMutation_DELETE_AND_WRITE_ONLY {
+			candidates = append(candidates[:len(candidates):len(candidates)], *col)
+		}
+	}
+	for _, col := range candidates {
+		if !col.IsComputed() {
+			continue
+		}
+		if _, ok := colIDSet[col.ID]; ok {
+			continue
+		}
+		expr, err := parser.ParseExpr(*col.ComputeExpr)
+		if err != nil {
+			return nil, err
+		}
+		dependent := false
+		if _, err := parser.SimpleVisit(expr, func(expr parser.Expr) (error, bool, parser.Expr) {
+			n, ok := expr.(parser.UnresolvedName)
+			if !ok {
+				return nil, true, expr
+			}
+			ci, err := n.NormalizeUnqualifiedColumnItem()
+			if err != nil {
+				return err, false, nil
+			}
+			ref, err := tableDesc.FindActiveColumnByName(ci.ColumnName)
+			if err != nil {
+				return err, false, nil
+			}
+			if _, ok := colIDSet[ref.ID]; ok {
+				dependent = true
+			}
+			return nil, false, expr
+		}); err != nil {
+			return nil, err
+		}
+		if dependent {
+			colIDSet[col.ID] = struct{}{}
+			cols = append(cols, col)
+		}
+	}
+	return cols, nil
+}
+
+// ComputedColumns holds the expressions of computed columns, which are
+// evaluated on the row loaded with LoadRow.
+type ComputedColumns struct {
+	Cols  []ColumnDescriptor
+	Exprs []parser.TypedExpr
+
+	tableCols       []ColumnDescriptor
+	colIDtoRowIndex map[ColumnID]int
+	row             parser.Datums
+}
+
+var _ parser.IndexedVarContainer = &ComputedColumns{}
+
+// MakeComputedColumns returns the computed columns among cols. Their
+// expressions reference the columns of tableDesc, whose values are found in
+// the loaded row through colIDtoRowIndex. It returns nil if none of cols is
+// computed.
+func MakeComputedColumns(
+	tableDesc *TableDescriptor, cols []ColumnDescriptor, colIDtoRowIndex map[ColumnID]int,
+) (*ComputedColumns, error) {
+	c := &ComputedColumns{
+		tableCols:       tableDesc.Columns,
+		colIDtoRowIndex: colIDtoRowIndex,
+	}
+	var exprStrings []string
+	for _, col := range cols {
+		if col.IsComputed() {
+			c.Cols = append(c.Cols, col)
+			exprStrings = append(exprStrings, *col.ComputeExpr)
+		}
+	}
+	if len(c.Cols) == 0 {
+		return nil, nil
+	}
+	exprs, err := parser.ParseExprs(exprStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	ivarHelper := parser.MakeIndexedVarHelper(c, len(c.tableCols))
+	resolveColumns := func(expr parser.Expr) (error, bool, parser.Expr) {
+		n, ok := expr.(parser.UnresolvedName)
+		if !ok {
+			return nil, true, expr
+		}
+		ci, err := n.NormalizeUnqualifiedColumnItem()
+		if err != nil {
+			return err, false, nil
+		}
+		name := ci.ColumnName.Normalize()
+		for i := range c.tableCols {
+			if parser.ReNormalizeName(c.tableCols[i].Name) == name {
+				return nil, false, ivarHelper.IndexedVar(i)
+			}
+		}
+		return fmt.Errorf("column %q does not exist", name), false, nil
+	}
+
+	c.Exprs = make([]parser.TypedExpr, len(exprs))
+	for i, expr := range exprs {
+		expr, err := parser.SimpleVisit(expr, resolveColumns)
+		if err != nil {
+			return nil, err
+		}
+		typedExpr, err := parser.TypeCheck(expr, nil, c.Cols[i].Type.ToDatumType())
+		if err != nil {
+			return nil, err
+		}
+		c.Exprs[i] = typedExpr
+	}
+	return c, nil
+}
+
+// LoadRow sets the row on which the expressions are evaluated.
+func (c *ComputedColumns) LoadRow(row parser.Datums) {
+	c.row = row
+}
+
+// Compute evaluates the computed columns on row, which must hold the values
+// of the computed columns at the indexes given by colIDtoRowIndex, and
+// stores their values in it.
+func (c *ComputedColumns) Compute(evalCtx *parser.EvalContext, row parser.Datums) error {
+	if c == nil {
+		return nil
+	}
+	c.row = row
+	for i, col := range c.Cols {
+		d, err := c.Exprs[i].Eval(evalCtx)
+		if err != nil {
+			return err
+		}
+		row[c.colIDtoRowIndex[col.ID]] = d
+	}
+	return nil
+}
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (c *ComputedColumns) IndexedVarEval(idx int, ctx *parser.EvalContext) (parser.Datum, error) {
+	ri, ok := c.colIDtoRowIndex[c.tableCols[idx].ID]
+	if !ok || ri >= len(c.row) {
+		return parser.DNull, nil
+	}
+	return c.row[ri].Eval(ctx)
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (c *ComputedColumns) IndexedVarResolvedType(idx int) parser.Type {
+	return c.tableCols[idx].Type.ToDatumType()
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (c *ComputedColumns) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	parser.FormatNode(buf, f, parser.Name(c.tableCols[idx].Name))
+}
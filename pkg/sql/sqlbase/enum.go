@@ -0,0 +1,387 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
+// SetID implements the DescriptorProto interface.
+func (desc *TypeDescriptor) SetID(id ID) {
+	desc.ID = id
+}
+
+// TypeName returns the plain type of this descriptor.
+func (desc *TypeDescriptor) TypeName() string {
+	return "type"
+}
+
+// SetName implements the DescriptorProto interface.
+func (desc *TypeDescriptor) SetName(name string) {
+	desc.Name = name
+}
+
+// Validate validates that the type descriptor is well formed: the members
+// must have distinct labels and strictly increasing physical
+// representations.
+func (desc *TypeDescriptor) Validate() error {
+	if err := validateName(desc.Name, "type"); err != nil {
+		return err
+	}
+	if desc.ID == 0 {
+		return fmt.Errorf("invalid type ID %d", desc.ID)
+	}
+	if desc.ParentID == 0 {
+		return fmt.Errorf("invalid parent ID %d", desc.ParentID)
+	}
+	labels := make(map[string]struct{}, len(desc.EnumMembers))
+	for i, m := range desc.EnumMembers {
+		if _, ok := labels[m.LogicalRepresentation]; ok {
+			return fmt.Errorf("duplicate enum label %q", m.LogicalRepresentation)
+		}
+		labels[m.LogicalRepresentation] = struct{}{}
+		if len(m.PhysicalRepresentation) == 0 {
+			return fmt.Errorf("enum label %q has no physical representation", m.LogicalRepresentation)
+		}
+		if i > 0 && bytes.Compare(desc.EnumMembers[i-1].PhysicalRepresentation, m.PhysicalRepresentation) >= 0 {
+			return fmt.Errorf("enum labels %q and %q are out of order",
+				desc.EnumMembers[i-1].LogicalRepresentation, m.LogicalRepresentation)
+		}
+	}
+	return desc.Privileges.Validate(desc.GetID())
+}
+
+// ToDatumType returns the parser type corresponding to the descriptor.
+func (desc *TypeDescriptor) ToDatumType() *parser.TEnum {
+	return enumMembersToDatumType(desc.ID, desc.Name, desc.EnumMembers)
+}
+
+// HasReference returns whether the table with the given ID is recorded as
+// using the type.
+func (desc *TypeDescriptor) HasReference(id ID) bool {
+	for _, ref := range desc.ReferencingDescriptorIDs {
+		if ref == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AddReference records that the table with the given ID uses the type.
+func (desc *TypeDescriptor) AddReference(id ID) {
+	if !desc.HasReference(id) {
+		desc.ReferencingDescriptorIDs = append(desc.ReferencingDescriptorIDs, id)
+	}
+}
+
+// RemoveReference forgets that the table with the given ID uses the type.
+func (desc *TypeDescriptor) RemoveReference(id ID) {
+	for i, ref := range desc.ReferencingDescriptorIDs {
+		if ref == id {
+			desc.ReferencingDescriptorIDs = append(
+				desc.ReferencingDescriptorIDs[:i], desc.ReferencingDescriptorIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// MakeEnumMembers creates the members of a new enum type, in the order in
+// which the labels were given.
+func MakeEnumMembers(labels []string) ([]TypeDescriptor_EnumMember, error) {
+	reps := GenerateEnumPhysicalReps(len(labels))
+	members := make([]TypeDescriptor_EnumMember, len(labels))
+	seen := make(map[string]struct{}, len(labels))
+	for i, label := range labels {
+		if _, ok := seen[label]; ok {
+			return nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+				"enum label %q used more than once", label)
+		}
+		seen[label] = struct{}{}
+		members[i] = TypeDescriptor_EnumMember{
+			LogicalRepresentation:  label,
+			PhysicalRepresentation: reps[i],
+		}
+	}
+	return members, nil
+}
+
+// AddEnumMember adds a new member with the given label and capability to the
+// type. If existing is non-empty, the new member is placed immediately before
+// or after the member with that label; otherwise it is placed last. The new
+// member is returned. If the label already exists, the returned bool is false
+// and the type is left unchanged.
+func (desc *TypeDescriptor) AddEnumMember(
+	label string,
+	existing string,
+	before bool,
+	capability TypeDescriptor_EnumMember_Capability,
+) (TypeDescriptor_EnumMember, bool, error) {
+	for _, m := range desc.EnumMembers {
+		if m.LogicalRepresentation == label {
+			return TypeDescriptor_EnumMember{}, false, nil
+		}
+	}
+	// pos is the index at which the new member is inserted.
+	pos := len(desc.EnumMembers)
+	if existing != "" {
+		pos = -1
+		for i, m := range desc.EnumMembers {
+			if m.LogicalRepresentation == existing {
+				pos = i
+				break
+			}
+		}
+		if pos == -1 {
+			return TypeDescriptor_EnumMember{}, false, pgerror.NewErrorf(
+				pgerror.CodeInvalidParameterValueError, "%q is not an existing enum label", existing)
+		}
+		if !before {
+			pos++
+		}
+	}
+	var prev, next []byte
+	if pos > 0 {
+		prev = desc.EnumMembers[pos-1].PhysicalRepresentation
+	}
+	if pos < len(desc.EnumMembers) {
+		next = desc.EnumMembers[pos].PhysicalRepresentation
+	}
+	rep, err := enumBytesBetween(prev, next)
+	if err != nil {
+		return TypeDescriptor_EnumMember{}, false, err
+	}
+	member := TypeDescriptor_EnumMember{
+		LogicalRepresentation:  label,
+		PhysicalRepresentation: rep,
+		Capability:             capability,
+	}
+	desc.EnumMembers = append(desc.EnumMembers, TypeDescriptor_EnumMember{})
+	copy(desc.EnumMembers[pos+1:], desc.EnumMembers[pos:])
+	desc.EnumMembers[pos] = member
+	return member, true, nil
+}
+
+// AddEnumMember adds a member to an ENUM column type, keeping the members
+// ordered by physical representation.
+func (c *ColumnType) AddEnumMember(member TypeDescriptor_EnumMember) {
+	pos := sort.Search(len(c.EnumMembers), func(i int) bool {
+		return bytes.Compare(c.EnumMembers[i].PhysicalRepresentation, member.PhysicalRepresentation) >= 0
+	})
+	c.EnumMembers = append(c.EnumMembers, TypeDescriptor_EnumMember{})
+	copy(c.EnumMembers[pos+1:], c.EnumMembers[pos:])
+	c.EnumMembers[pos] = member
+}
+
+// enumColumnTypes returns the types of the ENUM columns of the table,
+// including columns which are being added or dropped.
+func (desc *TableDescriptor) enumColumnTypes() []*ColumnType {
+	var types []*ColumnType
+	for i := range desc.Columns {
+		if desc.Columns[i].Type.Kind == ColumnType_ENUM {
+			types = append(types, &desc.Columns[i].Type)
+		}
+	}
+	for i := range desc.Mutations {
+		if col := desc.Mutations[i].GetColumn(); col != nil && col.Type.Kind == ColumnType_ENUM {
+			types = append(types, &col.Type)
+		}
+	}
+	return types
+}
+
+// UsesType returns whether any column of the table, including columns which
+// are being added or dropped, uses the type with the given ID.
+func (desc *TableDescriptor) UsesType(typeID ID) bool {
+	for _, typ := range desc.enumColumnTypes() {
+		if typ.UserDefinedTypeID == typeID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddEnumMember adds a member to every column of the table that uses the
+// type with the given ID. It returns whether any column was changed.
+func (desc *TableDescriptor) AddEnumMember(typeID ID, member TypeDescriptor_EnumMember) bool {
+	changed := false
+	for _, typ := range desc.enumColumnTypes() {
+		if typ.UserDefinedTypeID == typeID {
+			typ.AddEnumMember(member)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// PromoteEnumMembers makes every READ_ONLY enum member used by the columns of
+// the table writable. It returns whether any member was changed.
+func (desc *TableDescriptor) PromoteEnumMembers() bool {
+	changed := false
+	for _, typ := range desc.enumColumnTypes() {
+		for j := range typ.EnumMembers {
+			if typ.EnumMembers[j].Capability == TypeDescriptor_EnumMember_READ_ONLY {
+				typ.EnumMembers[j].Capability = TypeDescriptor_EnumMember_ALL
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// HasReadOnlyEnumMembers returns whether any column of the table uses an
+// enum member which is not yet writable.
+func (desc *TableDescriptor) HasReadOnlyEnumMembers() bool {
+	for _, typ := range desc.enumColumnTypes() {
+		for _, m := range typ.EnumMembers {
+			if m.Capability == TypeDescriptor_EnumMember_READ_ONLY {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkEnumValue returns an error if the enum value cannot be written to the
+// column: the value must be a known, writable member of the column's type.
+func checkEnumValue(col ColumnDescriptor, v *parser.DEnum) error {
+	for _, m := range col.Type.EnumMembers {
+		if !bytes.Equal(m.PhysicalRepresentation, v.PhysicalRep) {
+			continue
+		}
+		if m.Capability == TypeDescriptor_EnumMember_READ_ONLY {
+			return pgerror.NewErrorf(pgerror.CodeObjectNotInPrerequisiteStateError,
+				"enum value %q is not yet public", v.LogicalRep)
+		}
+		return nil
+	}
+	return pgerror.NewErrorf(pgerror.CodeInvalidTextRepresentationError,
+		"invalid input value for enum %s: %q", col.Type.UserDefinedTypeName, v.LogicalRep)
+}
+
+func enumMembersToDatumType(
+	id ID, name string, members []TypeDescriptor_EnumMember,
+) *parser.TEnum {
+	t := &parser.TEnum{
+		TypeID:  uint32(id),
+		Name:    name,
+		Members: make([]parser.EnumMember, len(members)),
+	}
+	for i, m := range members {
+		t.Members[i] = parser.EnumMember{
+			LogicalRep:  m.LogicalRepresentation,
+			PhysicalRep: m.PhysicalRepresentation,
+			ReadOnly:    m.Capability == TypeDescriptor_EnumMember_READ_ONLY,
+		}
+	}
+	return t
+}
+
+// GenerateEnumPhysicalReps returns n byte strings in ascending order, spread
+// out evenly so that new values can later be added between any two of them
+// without having to re-encode existing data. Like the values returned by
+// enumBytesBetween, none of them ends in a zero byte.
+func GenerateEnumPhysicalReps(n int) [][]byte {
+	// Use the smallest fixed width that leaves room between the values. The
+	// step between two values is then at least 2.
+	width := 1
+	space := uint64(256)
+	for space <= uint64(n)*2 && width < 8 {
+		width++
+		space *= 256
+	}
+	step := space / uint64(n+1)
+	reps := make([][]byte, n)
+	for i := range reps {
+		v := uint64(i+1) * step
+		if v&0xff == 0 {
+			// Nothing sorts between a value x and x followed by zero bytes,
+			// so move the value up by one, which is still below the next one.
+			v++
+		}
+		b := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			b[j] = byte(v)
+			v >>= 8
+		}
+		reps[i] = b
+	}
+	return reps
+}
+
+// enumBytesBetween returns a byte string that sorts strictly between prev and
+// next. A nil prev means there is no lower bound, and a nil next means there
+// is no upper bound. The result never ends in a zero byte, so that a value
+// can always be found below it.
+//
+// Types created by earlier versions may have values ending in a zero byte. An
+// error is returned if next is such a value and prev is next without its
+// trailing zero bytes, since nothing sorts between the two.
+func enumBytesBetween(prev, next []byte) ([]byte, error) {
+	if prev != nil && next != nil && bytes.Compare(prev, next) >= 0 {
+		panic(errors.Errorf("enum physical representations out of order: %x >= %x", prev, next))
+	}
+	var result []byte
+	// bounded is true while result is a prefix of next.
+	bounded := next != nil
+	for i := 0; ; i++ {
+		if bounded && i >= len(next) {
+			// result is next itself, and prev only differs from it by
+			// trailing zero bytes.
+			return nil, pgerror.NewErrorf(pgerror.CodeProgramLimitExceededError,
+				"no room for a new enum value between %x and %x", prev, next)
+		}
+		lo := 0
+		if i < len(prev) {
+			lo = int(prev[i])
+		}
+		hi := 256
+		if bounded {
+			hi = int(next[i])
+		}
+		if hi-lo > 1 {
+			return append(result, byte((lo+hi)/2)), nil
+		}
+		result = append(result, byte(lo))
+		if hi != lo {
+			bounded = false
+		}
+	}
+}
+
+// GetTypeDescFromID retrieves the type descriptor for the type ID passed
+// in using an existing txn. Returns an error if the descriptor doesn't exist
+// or if it exists and is not a type.
+func GetTypeDescFromID(ctx context.Context, txn *client.Txn, id ID) (*TypeDescriptor, error) {
+	desc := &Descriptor{}
+	descKey := MakeDescMetadataKey(id)
+
+	if err := txn.GetProto(ctx, descKey, desc); err != nil {
+		return nil, err
+	}
+	typ := desc.GetType()
+	if typ == nil {
+		return nil, ErrDescriptorNotFound
+	}
+	return typ, nil
+}
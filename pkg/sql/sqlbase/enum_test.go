@@ -0,0 +1,149 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestGenerateEnumPhysicalReps(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	for _, n := range []int{0, 1, 2, 100, 127, 128, 1000, 100000} {
+		reps := GenerateEnumPhysicalReps(n)
+		if len(reps) != n {
+			t.Fatalf("%d: expected %d values, got %d", n, n, len(reps))
+		}
+		for i := range reps {
+			if i > 0 && bytes.Compare(reps[i-1], reps[i]) >= 0 {
+				t.Fatalf("%d: values %x and %x are out of order", n, reps[i-1], reps[i])
+			}
+			if reps[i][len(reps[i])-1] == 0 {
+				t.Fatalf("%d: value %x ends in a zero byte", n, reps[i])
+			}
+		}
+	}
+}
+
+func TestEnumBytesBetween(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testCases := []struct {
+		prev, next []byte
+	}{
+		{nil, nil},
+		{nil, []byte{1}},
+		{nil, []byte{0, 1}},
+		{[]byte{1}, nil},
+		{[]byte{255}, nil},
+		{[]byte{255, 255}, nil},
+		{[]byte{1}, []byte{2}},
+		{[]byte{1}, []byte{1, 1}},
+		{[]byte{1, 255}, []byte{2}},
+		{[]byte{1, 255, 255}, []byte{2, 0, 1}},
+	}
+	for _, tc := range testCases {
+		b, err := enumBytesBetween(tc.prev, tc.next)
+		if err != nil {
+			t.Fatalf("%x, %x: %s", tc.prev, tc.next, err)
+		}
+		if tc.prev != nil && bytes.Compare(tc.prev, b) >= 0 {
+			t.Errorf("%x, %x: %x is not greater than %x", tc.prev, tc.next, b, tc.prev)
+		}
+		if tc.next != nil && bytes.Compare(b, tc.next) >= 0 {
+			t.Errorf("%x, %x: %x is not less than %x", tc.prev, tc.next, b, tc.next)
+		}
+		if len(b) == 0 || b[len(b)-1] == 0 {
+			t.Errorf("%x, %x: %x ends in a zero byte", tc.prev, tc.next, b)
+		}
+	}
+
+	// Repeatedly inserting before the first value keeps the values ordered.
+	var desc TypeDescriptor
+	for i := 0; i < 100; i++ {
+		var existing string
+		if i > 0 {
+			existing = fmt.Sprintf("v%d", i-1)
+		}
+		if _, _, err := desc.AddEnumMember(
+			fmt.Sprintf("v%d", i), existing, true /* before */, TypeDescriptor_EnumMember_ALL,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < len(desc.EnumMembers); i++ {
+		prev, cur := desc.EnumMembers[i-1].PhysicalRepresentation, desc.EnumMembers[i].PhysicalRepresentation
+		if bytes.Compare(prev, cur) >= 0 {
+			t.Fatalf("values %x and %x are out of order", prev, cur)
+		}
+	}
+
+	// Nothing sorts between a value and the same value followed by a zero
+	// byte.
+	if _, err := enumBytesBetween([]byte{0xa3}, []byte{0xa3, 0}); !testutils.IsError(err, "no room") {
+		t.Errorf("expected a no room error, got %v", err)
+	}
+}
+
+func TestAddEnumMemberAroundValues(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// 0xa300 is the 128th of the values generated for 200 labels by earlier
+	// versions, which could end in a zero byte. Values must still be added
+	// before and after it.
+	legacy := TypeDescriptor{EnumMembers: []TypeDescriptor_EnumMember{
+		{LogicalRepresentation: "a", PhysicalRepresentation: []byte{0xa1, 0xba}},
+		{LogicalRepresentation: "b", PhysicalRepresentation: []byte{0xa3, 0x00}},
+		{LogicalRepresentation: "c", PhysicalRepresentation: []byte{0xa4, 0x46}},
+	}}
+	labels := make([]string, 200)
+	for i := range labels {
+		labels[i] = fmt.Sprintf("v%d", i)
+	}
+	members, err := MakeEnumMembers(labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := TypeDescriptor{EnumMembers: members}
+
+	for _, desc := range []*TypeDescriptor{&legacy, &generated} {
+		var existing []string
+		for _, m := range desc.EnumMembers {
+			existing = append(existing, m.LogicalRepresentation)
+		}
+		for _, label := range existing {
+			for _, before := range []bool{true, false} {
+				newLabel := fmt.Sprintf("%s-%t", label, before)
+				if _, _, err := desc.AddEnumMember(
+					newLabel, label, before, TypeDescriptor_EnumMember_ALL,
+				); err != nil {
+					t.Fatalf("adding %s: %s", newLabel, err)
+				}
+			}
+		}
+		for i, m := range desc.EnumMembers {
+			if i > 0 && bytes.Compare(desc.EnumMembers[i-1].PhysicalRepresentation, m.PhysicalRepresentation) >= 0 {
+				t.Fatalf("values %x and %x are out of order",
+					desc.EnumMembers[i-1].PhysicalRepresentation, m.PhysicalRepresentation)
+			}
+		}
+		if len(desc.EnumMembers) != 3*len(existing) {
+			t.Fatalf("expected %d values, got %d", 3*len(existing), len(desc.EnumMembers))
+		}
+	}
+}
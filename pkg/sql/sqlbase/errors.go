@@ -203,6 +203,19 @@ func NewStatementCompletionUnknownError(err *roachpb.AmbiguousResultError) error
 	return pgerror.NewErrorf(pgerror.CodeStatementCompletionUnknownError, err.Error())
 }
 
+// NewQueryCanceledError creates an error for a query which was canceled with
+// CANCEL QUERY or a pgwire CancelRequest.
+func NewQueryCanceledError() error {
+	return pgerror.NewError(pgerror.CodeQueryCanceledError, "query execution canceled")
+}
+
+// NewStatementTimeoutError creates an error for a query which was canceled
+// because it ran for longer than the statement_timeout of its session.
+func NewStatementTimeoutError() error {
+	return pgerror.NewError(
+		pgerror.CodeQueryCanceledError, "query execution canceled due to statement timeout")
+}
+
 func errHasCode(err error, code string) bool {
 	if pgErr, ok := pgerror.GetPGCause(err); ok {
 		return pgErr.Code == code
@@ -0,0 +1,148 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqlbase
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// SetID implements the DescriptorProto interface.
+func (desc *FunctionDescriptor) SetID(id ID) {
+	desc.ID = id
+}
+
+// TypeName returns the plain type of this descriptor.
+func (desc *FunctionDescriptor) TypeName() string {
+	return "function"
+}
+
+// SetName implements the DescriptorProto interface.
+func (desc *FunctionDescriptor) SetName(name string) {
+	desc.Name = name
+}
+
+// Validate validates that the function descriptor is well formed: the
+// parameters must have distinct names and the function must not depend on
+// itself.
+func (desc *FunctionDescriptor) Validate() error {
+	if err := validateName(desc.Name, "function"); err != nil {
+		return err
+	}
+	if desc.ID == 0 {
+		return fmt.Errorf("invalid function ID %d", desc.ID)
+	}
+	if desc.ParentID == 0 {
+		return fmt.Errorf("invalid parent ID %d", desc.ParentID)
+	}
+	names := make(map[string]struct{}, len(desc.Params))
+	for _, param := range desc.Params {
+		if param.Name == "" {
+			continue
+		}
+		if _, ok := names[param.Name]; ok {
+			return fmt.Errorf("parameter name %q used more than once", param.Name)
+		}
+		names[param.Name] = struct{}{}
+	}
+	if desc.Body == "" {
+		return fmt.Errorf("function %q has no body", desc.Name)
+	}
+	for _, id := range desc.DependsOn {
+		if id == desc.ID {
+			return fmt.Errorf("function %q depends on itself", desc.Name)
+		}
+	}
+	return desc.Privileges.Validate(desc.GetID())
+}
+
+// ToParser converts the volatility category to its parser equivalent.
+func (v FunctionDescriptor_Volatility) ToParser() parser.Volatility {
+	switch v {
+	case FunctionDescriptor_IMMUTABLE:
+		return parser.Immutable
+	case FunctionDescriptor_STABLE:
+		return parser.Stable
+	default:
+		return parser.Volatile
+	}
+}
+
+// VolatilityFromParser converts the volatility category given in a CREATE
+// FUNCTION statement. Functions are VOLATILE unless specified otherwise.
+func VolatilityFromParser(v parser.Volatility) FunctionDescriptor_Volatility {
+	switch v {
+	case parser.Immutable:
+		return FunctionDescriptor_IMMUTABLE
+	case parser.Stable:
+		return FunctionDescriptor_STABLE
+	default:
+		return FunctionDescriptor_VOLATILE
+	}
+}
+
+// HasReference returns whether the view or function with the given ID is
+// recorded as calling the function.
+func (desc *FunctionDescriptor) HasReference(id ID) bool {
+	for _, ref := range desc.ReferencingDescriptorIDs {
+		if ref == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AddReference records that the view or function with the given ID calls
+// the function.
+func (desc *FunctionDescriptor) AddReference(id ID) {
+	if !desc.HasReference(id) {
+		desc.ReferencingDescriptorIDs = append(desc.ReferencingDescriptorIDs, id)
+	}
+}
+
+// RemoveReference forgets that the view or function with the given ID calls
+// the function.
+func (desc *FunctionDescriptor) RemoveReference(id ID) {
+	for i, ref := range desc.ReferencingDescriptorIDs {
+		if ref == id {
+			desc.ReferencingDescriptorIDs = append(
+				desc.ReferencingDescriptorIDs[:i], desc.ReferencingDescriptorIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetFunctionDescFromID retrieves the function descriptor for the function
+// ID passed in using an existing txn. Returns an error if the descriptor
+// doesn't exist or if it exists and is not a function.
+func GetFunctionDescFromID(
+	ctx context.Context, txn *client.Txn, id ID,
+) (*FunctionDescriptor, error) {
+	desc := &Descriptor{}
+	descKey := MakeDescMetadataKey(id)
+
+	if err := txn.GetProto(ctx, descKey, desc); err != nil {
+		return nil, err
+	}
+	fn := desc.GetFunction()
+	if fn == nil {
+		return nil, ErrDescriptorNotFound
+	}
+	return fn, nil
+}
@@ -99,6 +99,8 @@ type kvFetcher struct {
 	kvs          []client.KeyValue
 	kvIndex      int
 	totalFetched int64
+	// bytesRead is the total size of the keys and values fetched.
+	bytesRead int64
 
 	// returnRangeInfo, is set, causes the kvFetcher to populate rangeInfos.
 	// See also rowFetcher.returnRangeInfo.
@@ -274,6 +276,12 @@ func (f *kvFetcher) fetch(ctx context.Context) error {
 
 	f.batchIdx++
 	f.totalFetched += int64(len(f.kvs))
+	for _, kv := range f.kvs {
+		f.bytesRead += int64(len(kv.Key))
+		if kv.Value != nil {
+			f.bytesRead += int64(len(kv.Value.RawBytes))
+		}
+	}
 	f.kvIndex = 0
 
 	// TODO(radu): We should fetch the next chunk in the background instead of waiting for the next
@@ -111,17 +111,20 @@ func NewDefaultPrivilegeDescriptor() *PrivilegeDescriptor {
 // them into ALL?
 func (p *PrivilegeDescriptor) Grant(user string, privList privilege.List) {
 	userPriv := p.findOrCreateUser(user)
+	bits := privList.ToBitField()
+	// BYPASSRLS is not covered by 'ALL' and is kept alongside it.
+	bypassRLS := (userPriv.Privileges | bits) & privilege.BYPASSRLS.Mask()
 	if isPrivilegeSet(userPriv.Privileges, privilege.ALL) {
-		// User already has 'ALL' privilege: no-op.
+		// User already has 'ALL' privilege: only BYPASSRLS can be added.
+		userPriv.Privileges |= bypassRLS
 		return
 	}
 
-	bits := privList.ToBitField()
 	if isPrivilegeSet(bits, privilege.ALL) {
 		// Granting 'ALL' privilege: overwrite.
 		// TODO(marc): the grammar does not allow it, but we should
 		// check if other privileges are being specified and error out.
-		userPriv.Privileges = privilege.ALL.Mask()
+		userPriv.Privileges = privilege.ALL.Mask() | bypassRLS
 		return
 	}
 	userPriv.Privileges |= bits
@@ -144,12 +147,13 @@ func (p *PrivilegeDescriptor) Revoke(user string, privList privilege.List) {
 		return
 	}
 
-	if isPrivilegeSet(userPriv.Privileges, privilege.ALL) {
+	if isPrivilegeSet(userPriv.Privileges, privilege.ALL) && bits&^privilege.BYPASSRLS.Mask() != 0 {
 		// User has 'ALL' privilege. Remove it and set
-		// all other privileges one.
-		userPriv.Privileges = 0
+		// all other privileges one, except BYPASSRLS which
+		// 'ALL' does not cover.
+		userPriv.Privileges &= privilege.BYPASSRLS.Mask()
 		for _, v := range privilege.ByValue {
-			if v != privilege.ALL {
+			if v != privilege.ALL && v != privilege.BYPASSRLS {
 				userPriv.Privileges |= v.Mask()
 			}
 		}
@@ -234,8 +238,9 @@ func (p PrivilegeDescriptor) CheckPrivilege(user string, priv privilege.Kind) bo
 		// User "node" has all privileges.
 		return user == security.NodeUser
 	}
-	// ALL is always good.
-	if isPrivilegeSet(userPriv.Privileges, privilege.ALL) {
+	// ALL is always good, except for BYPASSRLS which must be granted
+	// explicitly.
+	if priv != privilege.BYPASSRLS && isPrivilegeSet(userPriv.Privileges, privilege.ALL) {
 		return true
 	}
 	return isPrivilegeSet(userPriv.Privileges, priv)
@@ -56,6 +56,19 @@ func TestPrivilege(t *testing.T) {
 		{"foo", nil, privilege.List{privilege.SELECT, privilege.INSERT},
 			[]UserPrivilegeString{{"foo", []string{"CREATE", "DELETE", "DROP", "GRANT", "UPDATE"}}, {security.RootUser, []string{"ALL"}}},
 		},
+		// BYPASSRLS is not covered by ALL, and is kept alongside it.
+		{"foo", privilege.List{privilege.ALL, privilege.BYPASSRLS}, nil,
+			[]UserPrivilegeString{{"foo", []string{"ALL", "BYPASSRLS"}}, {security.RootUser, []string{"ALL"}}},
+		},
+		{"foo", nil, privilege.List{privilege.SELECT},
+			[]UserPrivilegeString{{"foo", []string{"BYPASSRLS", "CREATE", "DELETE", "DROP", "GRANT", "INSERT", "UPDATE"}}, {security.RootUser, []string{"ALL"}}},
+		},
+		{"foo", privilege.List{privilege.ALL}, privilege.List{privilege.BYPASSRLS},
+			[]UserPrivilegeString{{"foo", []string{"ALL"}}, {security.RootUser, []string{"ALL"}}},
+		},
+		{"foo", privilege.List{privilege.BYPASSRLS}, nil,
+			[]UserPrivilegeString{{"foo", []string{"ALL", "BYPASSRLS"}}, {security.RootUser, []string{"ALL"}}},
+		},
 		{"foo", nil, privilege.List{privilege.ALL},
 			[]UserPrivilegeString{{security.RootUser, []string{"ALL"}}},
 		},
@@ -121,6 +134,12 @@ func TestCheckPrivilege(t *testing.T) {
 			"foo", privilege.DROP, true},
 		{NewPrivilegeDescriptor("foo", privilege.List{privilege.CREATE, privilege.ALL}),
 			"foo", privilege.DROP, true},
+		{NewPrivilegeDescriptor("foo", privilege.List{privilege.ALL}),
+			"foo", privilege.BYPASSRLS, false},
+		{NewPrivilegeDescriptor("foo", privilege.List{privilege.ALL, privilege.BYPASSRLS}),
+			"foo", privilege.BYPASSRLS, true},
+		{NewPrivilegeDescriptor("foo", privilege.List{privilege.BYPASSRLS}),
+			"foo", privilege.BYPASSRLS, true},
 	}
 
 	for tcNum, tc := range testCases {
@@ -112,7 +112,7 @@ func (ti ColTypeInfo) Type(idx int) parser.Type {
 //
 // The acc argument indicates where to register memory allocations by
 // this row container. Should probably be created by
-// Session.makeBoundAccount() or Session.TxnState.makeBoundAccount().
+// Session.makeBoundAccount() or planner.makeBoundAccount().
 //
 // The rowCapacity argument indicates how many rows are to be
 // expected; it is used to pre-allocate the outer array of row
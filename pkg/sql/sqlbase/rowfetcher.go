@@ -97,6 +97,11 @@ type RowFetcher struct {
 
 	// Buffered allocation of decoded datums.
 	alloc DatumAlloc
+
+	// kvRequests and bytesRead accumulate the KV statistics of the previous
+	// scans; see KVStats().
+	kvRequests int64
+	bytesRead  int64
 }
 
 // debugRowFetch can be used to turn on some low-level debugging logs. We use
@@ -199,6 +204,9 @@ func (rf *RowFetcher) StartScan(
 		firstBatchLimit++
 	}
 
+	rf.kvRequests += int64(rf.kvFetcher.batchIdx)
+	rf.bytesRead += rf.kvFetcher.bytesRead
+
 	var err error
 	rf.kvFetcher, err = makeKVFetcher(txn, spans, rf.reverse, limitBatches, firstBatchLimit, rf.returnRangeInfo)
 	if err != nil {
@@ -210,6 +218,12 @@ func (rf *RowFetcher) StartScan(
 	return err
 }
 
+// KVStats returns the number of KV batch requests issued and the number of
+// bytes of keys and values read by all the scans of the RowFetcher.
+func (rf *RowFetcher) KVStats() (requests int64, bytesRead int64) {
+	return rf.kvRequests + int64(rf.kvFetcher.batchIdx), rf.bytesRead + rf.kvFetcher.bytesRead
+}
+
 // NextKey retrieves the next key/value and sets kv/kvEnd. Returns whether a row
 // has been completed.
 // TODO(andrei): change to return error
@@ -180,7 +180,7 @@ func (desc *IndexDescriptor) RunOverAllColumns(fn func(id ColumnID) error) error
 func (desc *IndexDescriptor) allocateName(tableDesc *TableDescriptor) {
 	segments := make([]string, 0, len(desc.ColumnNames)+2)
 	segments = append(segments, tableDesc.Name)
-	segments = append(segments, desc.ColumnNames...)
+	segments = append(segments, desc.UserColumnNames()...)
 	if desc.Unique {
 		segments = append(segments, "key")
 	} else {
@@ -263,19 +263,38 @@ func (desc *IndexDescriptor) FullColumnIDs() ([]ColumnID, []encoding.Direction)
 	return columnIDs, dirs
 }
 
+// UserColumnNames returns the names of the columns of the index specified by
+// the user, which exclude the shard column of a sharded index.
+func (desc *IndexDescriptor) UserColumnNames() []string {
+	if desc.IsSharded() {
+		return desc.ColumnNames[1:]
+	}
+	return desc.ColumnNames
+}
+
 // ColNamesString returns a string describing the column names and directions
-// in this index.
+// in this index. The shard column of a sharded index is omitted.
 func (desc *IndexDescriptor) ColNamesString() string {
 	var buf bytes.Buffer
-	for i, name := range desc.ColumnNames {
+	offset := len(desc.ColumnNames) - len(desc.UserColumnNames())
+	for i, name := range desc.UserColumnNames() {
 		if i > 0 {
 			buf.WriteString(", ")
 		}
-		fmt.Fprintf(&buf, "%s %s", parser.Name(name), desc.ColumnDirections[i])
+		fmt.Fprintf(&buf, "%s %s", parser.Name(name), desc.ColumnDirections[offset+i])
 	}
 	return buf.String()
 }
 
+// ShardingString returns the USING HASH clause of a sharded index, or the
+// empty string.
+func (desc *IndexDescriptor) ShardingString() string {
+	if !desc.IsSharded() {
+		return ""
+	}
+	return fmt.Sprintf(" USING HASH WITH BUCKET_COUNT = %d", desc.ShardBuckets)
+}
+
 var isUnique = map[bool]string{true: "UNIQUE "}
 
 // SQLString returns the SQL string describing this index. If non-empty,
@@ -293,11 +312,12 @@ func (desc *IndexDescriptor) SQLString(tableName string) string {
 	if tableName != "" {
 		onTable = fmt.Sprintf("ON %s ", tableName)
 	}
-	return fmt.Sprintf("%sINDEX %s%s (%s)%s",
+	return fmt.Sprintf("%sINDEX %s%s (%s)%s%s",
 		isUnique[desc.Unique],
 		onTable,
 		parser.AsString(parser.Name(desc.Name)),
 		desc.ColNamesString(),
+		desc.ShardingString(),
 		storing,
 	)
 }
@@ -1060,10 +1080,72 @@ func (desc *TableDescriptor) ValidateTable() error {
 		}
 	}
 
+	if err := desc.validatePolicies(); err != nil {
+		return err
+	}
+	if err := desc.validateTriggers(); err != nil {
+		return err
+	}
+
 	// Validate the privilege descriptor.
 	return desc.Privileges.Validate(desc.GetID())
 }
 
+// validatePolicies validates the row-level security policies of the table.
+func (desc *TableDescriptor) validatePolicies() error {
+	if !desc.IsPhysicalTable() && (desc.RowLevelSecurity || len(desc.Policies) > 0) {
+		return fmt.Errorf("%s %q cannot have row-level security policies", desc.TypeName(), desc.Name)
+	}
+	policyNames := make(map[string]struct{}, len(desc.Policies))
+	for _, policy := range desc.Policies {
+		if err := validateName(policy.Name, "policy"); err != nil {
+			return err
+		}
+		normName := parser.ReNormalizeName(policy.Name)
+		if _, ok := policyNames[normName]; ok {
+			return fmt.Errorf("duplicate policy name: %q", policy.Name)
+		}
+		policyNames[normName] = struct{}{}
+		if _, ok := TableDescriptor_Policy_Command_name[int32(policy.Command)]; !ok {
+			return fmt.Errorf("policy %q has unknown command %d", policy.Name, policy.Command)
+		}
+	}
+	return nil
+}
+
+// validateTriggers validates the triggers of the table.
+func (desc *TableDescriptor) validateTriggers() error {
+	if !desc.IsPhysicalTable() && len(desc.Triggers) > 0 {
+		return fmt.Errorf("%s %q cannot have triggers", desc.TypeName(), desc.Name)
+	}
+	triggerNames := make(map[string]struct{}, len(desc.Triggers))
+	for _, trigger := range desc.Triggers {
+		if err := validateName(trigger.Name, "trigger"); err != nil {
+			return err
+		}
+		normName := parser.ReNormalizeName(trigger.Name)
+		if _, ok := triggerNames[normName]; ok {
+			return fmt.Errorf("duplicate trigger name: %q", trigger.Name)
+		}
+		triggerNames[normName] = struct{}{}
+		if _, ok := TableDescriptor_Trigger_Timing_name[int32(trigger.Timing)]; !ok {
+			return fmt.Errorf("trigger %q has unknown timing %d", trigger.Name, trigger.Timing)
+		}
+		if len(trigger.Events) == 0 {
+			return fmt.Errorf("trigger %q has no events", trigger.Name)
+		}
+		for _, event := range trigger.Events {
+			if _, ok := TableDescriptor_Trigger_Event_name[int32(event)]; !ok {
+				return fmt.Errorf("trigger %q has unknown event %d", trigger.Name, event)
+			}
+		}
+		if trigger.FunctionID == 0 {
+			return fmt.Errorf("trigger %q has no function", trigger.Name)
+		}
+	}
+	return nil
+}
+
 func (desc *TableDescriptor) validateColumnFamilies(
 	columnIDs map[ColumnID]string,
 ) (map[ColumnID]FamilyID, error) {
@@ -1522,6 +1604,58 @@ func (desc *TableDescriptor) FindIndexByName(name parser.Name) (IndexDescriptor,
 	return desc.findIndexByNormalizedName(name.Normalize())
 }
 
+// FindPolicyByName returns the position in Policies of the row-level
+// security policy with the specified name, or -1 if there is no such
+// policy.
+func (desc *TableDescriptor) FindPolicyByName(name parser.Name) int {
+	normName := name.Normalize()
+	for i := range desc.Policies {
+		if parser.ReNormalizeName(desc.Policies[i].Name) == normName {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindTriggerByName returns the position in Triggers of the trigger with
+// the specified name, or -1 if there is no such trigger.
+func (desc *TableDescriptor) FindTriggerByName(name parser.Name) int {
+	normName := name.Normalize()
+	for i := range desc.Triggers {
+		if parser.ReNormalizeName(desc.Triggers[i].Name) == normName {
+			return i
+		}
+	}
+	return -1
+}
+
+// FiresOn returns whether the trigger fires on the given event.
+func (t *TableDescriptor_Trigger) FiresOn(event TableDescriptor_Trigger_Event) bool {
+	for _, e := range t.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesTo returns whether the policy restricts statements of the given
+// kind run by the given user.
+func (p *TableDescriptor_Policy) AppliesTo(user string, cmd TableDescriptor_Policy_Command) bool {
+	if p.Command != TableDescriptor_Policy_ALL && p.Command != cmd {
+		return false
+	}
+	if len(p.Roles) == 0 {
+		return true
+	}
+	for _, role := range p.Roles {
+		if role == user {
+			return true
+		}
+	}
+	return false
+}
+
 // RenameIndexDescriptor renames an index descriptor.
 func (desc *TableDescriptor) RenameIndexDescriptor(index IndexDescriptor, name string) {
 	id := index.ID
@@ -1741,6 +1875,8 @@ func (c *ColumnType) SQLString() string {
 		return fmt.Sprintf("%s COLLATE %s", ColumnType_STRING.String(), *c.Locale)
 	case ColumnType_INT_ARRAY:
 		return "INT[]"
+	case ColumnType_ENUM:
+		return parser.Name(c.UserDefinedTypeName).String()
 	}
 	return c.Kind.String()
 }
@@ -1843,10 +1979,25 @@ func DatumTypeToColumnType(ptyp parser.Type) ColumnType {
 	case parser.TypeIntVector:
 		ctyp.Kind = ColumnType_INT2VECTOR
 	default:
-		if t, ok := ptyp.(parser.TCollatedString); ok {
+		switch t := ptyp.(type) {
+		case parser.TCollatedString:
 			ctyp.Kind = ColumnType_COLLATEDSTRING
 			ctyp.Locale = &t.Locale
-		} else {
+		case *parser.TEnum:
+			ctyp.Kind = ColumnType_ENUM
+			ctyp.UserDefinedTypeID = ID(t.TypeID)
+			ctyp.UserDefinedTypeName = t.Name
+			ctyp.EnumMembers = make([]TypeDescriptor_EnumMember, len(t.Members))
+			for i, m := range t.Members {
+				ctyp.EnumMembers[i] = TypeDescriptor_EnumMember{
+					LogicalRepresentation:  m.LogicalRep,
+					PhysicalRepresentation: m.PhysicalRep,
+				}
+				if m.ReadOnly {
+					ctyp.EnumMembers[i].Capability = TypeDescriptor_EnumMember_READ_ONLY
+				}
+			}
+		default:
 			panic(fmt.Sprintf("unsupported result type: %s", ptyp))
 		}
 	}
@@ -1894,6 +2045,8 @@ func (c *ColumnType) ToDatumType() parser.Type {
 		return parser.TypeIntArray
 	case ColumnType_INT2VECTOR:
 		return parser.TypeIntVector
+	case ColumnType_ENUM:
+		return enumMembersToDatumType(c.UserDefinedTypeID, c.UserDefinedTypeName, c.EnumMembers)
 	}
 	return nil
 }
@@ -1934,6 +2087,10 @@ func (desc *Descriptor) GetID() ID {
 		return t.Table.ID
 	case *Descriptor_Database:
 		return t.Database.ID
+	case *Descriptor_Type:
+		return t.Type.ID
+	case *Descriptor_Function:
+		return t.Function.ID
 	default:
 		return 0
 	}
@@ -1946,6 +2103,10 @@ func (desc *Descriptor) GetName() string {
 		return t.Table.Name
 	case *Descriptor_Database:
 		return t.Database.Name
+	case *Descriptor_Type:
+		return t.Type.Name
+	case *Descriptor_Function:
+		return t.Function.Name
 	default:
 		return ""
 	}
@@ -123,6 +123,47 @@ CREATE TABLE system.jobs (
 	INDEX (status, created),
 	FAMILY (id, status, created, payload)
 );`
+
+	// comments stores the comments attached to databases, tables, columns and
+	// indexes with COMMENT ON. sub_id is 0 for databases and tables, and the
+	// column or index ID otherwise.
+	CommentsTableSchema = `
+CREATE TABLE system.comments (
+	type      INT    NOT NULL,
+	object_id INT    NOT NULL,
+	sub_id    INT    NOT NULL,
+	comment   STRING NOT NULL,
+	PRIMARY KEY (type, object_id, sub_id),
+	FAMILY (type, object_id, sub_id, comment)
+);`
+
+	// table_statistics stores the statistics collected by CREATE STATISTICS
+	// and ANALYZE, with one row per column.
+	TableStatisticsTableSchema = `
+CREATE TABLE system.table_statistics (
+	"tableID"       INT       NOT NULL,
+	"statisticID"   INT       NOT NULL DEFAULT unique_rowid(),
+	name            STRING,
+	"columnID"      INT       NOT NULL,
+	"createdAt"     TIMESTAMP NOT NULL DEFAULT now(),
+	"rowCount"      INT       NOT NULL,
+	"distinctCount" INT       NOT NULL,
+	"nullCount"     INT       NOT NULL,
+	histogram       BYTES,
+	PRIMARY KEY ("tableID", "statisticID"),
+	FAMILY ("tableID", "statisticID", name, "columnID", "createdAt", "rowCount", "distinctCount", "nullCount", histogram)
+);`
+
+	// user_settings stores the defaults of session variables configured for
+	// users with ALTER USER ... SET.
+	UserSettingsTableSchema = `
+CREATE TABLE system.user_settings (
+	username STRING NOT NULL,
+	variable STRING NOT NULL,
+	value    STRING NOT NULL,
+	PRIMARY KEY (username, variable),
+	FAMILY (username, variable, value)
+);`
 )
 
 func pk(name string) IndexDescriptor {
@@ -174,7 +215,10 @@ var SystemAllowedPrivileges = map[ID]privilege.Lists{
 	// users will be able to modify system tables' schemas at will. CREATE and
 	// DROP privileges are allowed on the above system tables for backwards
 	// compatibility reasons only!
-	keys.JobsTableID: {privilege.ReadWriteData},
+	keys.JobsTableID:            {privilege.ReadWriteData},
+	keys.CommentsTableID:        {privilege.ReadWriteData},
+	keys.TableStatisticsTableID: {privilege.ReadWriteData},
+	keys.UserSettingsTableID:    {privilege.ReadWriteData},
 }
 
 // SystemDesiredPrivileges returns the desired privilege list (i.e., the
@@ -522,6 +566,128 @@ var (
 		FormatVersion:  InterleavedFormatVersion,
 		NextMutationID: 1,
 	}
+
+	// CommentsTable is the descriptor for the comments table.
+	CommentsTable = TableDescriptor{
+		Name:     "comments",
+		ID:       keys.CommentsTableID,
+		ParentID: 1,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "type", ID: 1, Type: colTypeInt},
+			{Name: "object_id", ID: 2, Type: colTypeInt},
+			{Name: "sub_id", ID: 3, Type: colTypeInt},
+			{Name: "comment", ID: 4, Type: colTypeString},
+		},
+		NextColumnID: 5,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "fam_0_type_object_id_sub_id_comment",
+				ID:          0,
+				ColumnNames: []string{"type", "object_id", "sub_id", "comment"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: IndexDescriptor{
+			Name:             "primary",
+			ID:               1,
+			Unique:           true,
+			ColumnNames:      []string{"type", "object_id", "sub_id"},
+			ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC, IndexDescriptor_ASC},
+			ColumnIDs:        []ColumnID{1, 2, 3},
+		},
+		NextIndexID:    2,
+		Privileges:     NewPrivilegeDescriptor(security.RootUser, SystemDesiredPrivileges(keys.CommentsTableID)),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// TableStatisticsTable is the descriptor for the table statistics table.
+	TableStatisticsTable = TableDescriptor{
+		Name:     "table_statistics",
+		ID:       keys.TableStatisticsTableID,
+		ParentID: 1,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "tableID", ID: 1, Type: colTypeInt},
+			{Name: "statisticID", ID: 2, Type: colTypeInt, DefaultExpr: &uniqueRowIDString},
+			{Name: "name", ID: 3, Type: colTypeString, Nullable: true},
+			{Name: "columnID", ID: 4, Type: colTypeInt},
+			{Name: "createdAt", ID: 5, Type: colTypeTimestamp, DefaultExpr: &nowString},
+			{Name: "rowCount", ID: 6, Type: colTypeInt},
+			{Name: "distinctCount", ID: 7, Type: colTypeInt},
+			{Name: "nullCount", ID: 8, Type: colTypeInt},
+			{Name: "histogram", ID: 9, Type: colTypeBytes, Nullable: true},
+		},
+		NextColumnID: 10,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name: "fam_0_tableID_statisticID_name_columnID_createdAt_rowCount_distinctCount_nullCount_histogram",
+				ID:   0,
+				ColumnNames: []string{
+					"tableID",
+					"statisticID",
+					"name",
+					"columnID",
+					"createdAt",
+					"rowCount",
+					"distinctCount",
+					"nullCount",
+					"histogram",
+				},
+				ColumnIDs: []ColumnID{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: IndexDescriptor{
+			Name:             "primary",
+			ID:               1,
+			Unique:           true,
+			ColumnNames:      []string{"tableID", "statisticID"},
+			ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC},
+			ColumnIDs:        []ColumnID{1, 2},
+		},
+		NextIndexID:    2,
+		Privileges:     NewPrivilegeDescriptor(security.RootUser, SystemDesiredPrivileges(keys.TableStatisticsTableID)),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
+
+	// UserSettingsTable is the descriptor for the user settings table.
+	UserSettingsTable = TableDescriptor{
+		Name:     "user_settings",
+		ID:       keys.UserSettingsTableID,
+		ParentID: 1,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "username", ID: 1, Type: colTypeString},
+			{Name: "variable", ID: 2, Type: colTypeString},
+			{Name: "value", ID: 3, Type: colTypeString},
+		},
+		NextColumnID: 4,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "fam_0_username_variable_value",
+				ID:          0,
+				ColumnNames: []string{"username", "variable", "value"},
+				ColumnIDs:   []ColumnID{1, 2, 3},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: IndexDescriptor{
+			Name:             "primary",
+			ID:               1,
+			Unique:           true,
+			ColumnNames:      []string{"username", "variable"},
+			ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC},
+			ColumnIDs:        []ColumnID{1, 2},
+		},
+		NextIndexID:    2,
+		Privileges:     NewPrivilegeDescriptor(security.RootUser, SystemDesiredPrivileges(keys.UserSettingsTableID)),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
 )
 
 // Create the key/value pair for the default zone config entry.
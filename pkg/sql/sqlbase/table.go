@@ -90,6 +90,10 @@ func MakeColumnDefDescs(
 		}
 	}
 
+	if t, ok := d.Type.(*parser.UserDefinedColType); ok && t.Typ == nil {
+		return nil, nil, errors.Errorf("unresolved type %s", t)
+	}
+
 	// Set Type.Kind and Type.Locale.
 	colDatumType := parser.CastTargetToDatumType(d.Type)
 	col.Type = DatumTypeToColumnType(colDatumType)
@@ -157,6 +161,8 @@ func MakeColumnDefDescs(
 			return nil, nil, errors.Errorf("vectors of type %s are unsupported", t.ParamType)
 		}
 	case *parser.OidColType:
+	case *parser.UserDefinedColType:
+		// The type was resolved by the caller, see SemaContext.TypeResolver.
 	default:
 		return nil, nil, errors.Errorf("unexpected type %T", t)
 	}
@@ -549,6 +555,11 @@ func EncodeTableKey(b []byte, val parser.Datum, dir encoding.Direction) ([]byte,
 			return encoding.EncodeBytesAscending(b, t.Key), nil
 		}
 		return encoding.EncodeBytesDescending(b, t.Key), nil
+	case *parser.DEnum:
+		if dir == encoding.Ascending {
+			return encoding.EncodeBytesAscending(b, t.PhysicalRep), nil
+		}
+		return encoding.EncodeBytesDescending(b, t.PhysicalRep), nil
 	case *parser.DArray:
 		for _, datum := range t.Array {
 			var err error
@@ -601,6 +612,8 @@ func EncodeTableValue(appendTo []byte, colID ColumnID, val parser.Datum) ([]byte
 		return encoding.EncodeUUIDValue(appendTo, uint32(colID), t.UUID), nil
 	case *parser.DCollatedString:
 		return encoding.EncodeBytesValue(appendTo, uint32(colID), []byte(t.Contents)), nil
+	case *parser.DEnum:
+		return encoding.EncodeBytesValue(appendTo, uint32(colID), t.PhysicalRep), nil
 	case *parser.DOid:
 		return encoding.EncodeIntValue(appendTo, uint32(colID), int64(t.DInt)), nil
 	}
@@ -1175,6 +1188,19 @@ func DecodeTableKey(
 			}
 			return nil, nil, errors.Errorf("TODO(eisen): cannot decode collation key: %q", r)
 		}
+		if typ, ok := valType.(*parser.TEnum); ok {
+			var r []byte
+			if dir == encoding.Ascending {
+				rkey, r, err = encoding.DecodeBytesAscending(key, nil)
+			} else {
+				rkey, r, err = encoding.DecodeBytesDescending(key, nil)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			d, err := parser.NewDEnumFromPhysicalRep(typ, r)
+			return d, rkey, err
+		}
 		return nil, nil, errors.Errorf("TODO(pmattis): decoded index key: %s", valType)
 	}
 }
@@ -1251,6 +1277,15 @@ func DecodeTableValue(a *DatumAlloc, valType parser.Type, b []byte) (parser.Datu
 			b, data, err = encoding.DecodeBytesValue(b)
 			return parser.NewDCollatedString(string(data), typ.Locale, &a.env), b, err
 		}
+		if typ, ok := valType.(*parser.TEnum); ok {
+			var data []byte
+			b, data, err = encoding.DecodeBytesValue(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			d, err := parser.NewDEnumFromPhysicalRep(typ, data)
+			return d, b, err
+		}
 		return nil, nil, errors.Errorf("TODO(pmattis): decoded index value: %s", valType)
 	}
 }
@@ -1484,6 +1519,13 @@ func MarshalColumnValue(col ColumnDescriptor, val parser.Datum) (roachpb.Value,
 			r.SetInt(int64(v.DInt))
 			return r, nil
 		}
+	case ColumnType_ENUM:
+		if v, ok := val.(*parser.DEnum); ok {
+			if ID(v.Typ.TypeID) == col.Type.UserDefinedTypeID {
+				r.SetBytes(v.PhysicalRep)
+				return r, nil
+			}
+		}
 	default:
 		return r, errors.Errorf("unsupported column type: %s", col.Type.Kind)
 	}
@@ -1591,6 +1633,13 @@ func UnmarshalColumnValue(
 			return nil, err
 		}
 		return a.NewDOid(parser.MakeDOid(parser.DInt(v))), nil
+	case ColumnType_ENUM:
+		v, err := value.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+		return parser.NewDEnumFromPhysicalRep(
+			enumMembersToDatumType(typ.UserDefinedTypeID, typ.UserDefinedTypeName, typ.EnumMembers), v)
 	default:
 		return nil, errors.Errorf("unsupported column type: %s", typ.Kind)
 	}
@@ -1636,6 +1685,10 @@ func CheckValueWidth(col ColumnDescriptor, val parser.Datum) error {
 				return errors.Wrapf(err, "type %s (column %q)", col.Type.SQLString(), col.Name)
 			}
 		}
+	case ColumnType_ENUM:
+		if v, ok := val.(*parser.DEnum); ok {
+			return checkEnumValue(col, v)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,116 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+)
+
+// HistogramSupported returns whether histograms can be built on the values of
+// the given type. The upper bounds of the buckets are stored with the key
+// encoding of the type, which must be decodable.
+func HistogramSupported(typ parser.Type) bool {
+	switch typ.(type) {
+	case parser.TCollatedString, parser.TArray:
+		return false
+	}
+	return true
+}
+
+// EquiDepthHistogram builds a histogram where each bucket contains roughly the
+// same number of samples. The buckets can be larger when a boundary value is
+// frequent, because all the samples equal to the upper bound of a bucket are
+// counted in that bucket.
+//
+// samples must not contain NULLs; it is sorted in place. numRows is the number
+// of non-NULL values from which the samples were taken, and is used to scale
+// the counts of the buckets.
+func EquiDepthHistogram(
+	evalCtx *parser.EvalContext, samples parser.Datums, numRows int64, maxBuckets int,
+) (HistogramData, error) {
+	numSamples := len(samples)
+	if maxBuckets < 1 {
+		return HistogramData{}, errors.Errorf("invalid maxBuckets %d", maxBuckets)
+	}
+	if numSamples == 0 {
+		return HistogramData{}, nil
+	}
+	if numRows < int64(numSamples) {
+		return HistogramData{}, errors.Errorf("more samples (%d) than rows (%d)", numSamples, numRows)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Compare(evalCtx, samples[j]) < 0
+	})
+	if maxBuckets > numSamples {
+		maxBuckets = numSamples
+	}
+
+	h := HistogramData{Buckets: make([]HistogramData_Bucket, 0, maxBuckets)}
+	for i, b := 0, 0; b < maxBuckets && i < numSamples; b++ {
+		// num is the number of samples in this bucket.
+		num := (numSamples - i) / (maxBuckets - b)
+		if num < 1 {
+			num = 1
+		}
+		upper := samples[i+num-1]
+		// numLess is the number of samples in this bucket which are less than
+		// the upper bound.
+		numLess := 0
+		for ; numLess < num-1; numLess++ {
+			if samples[i+numLess].Compare(evalCtx, upper) == 0 {
+				break
+			}
+		}
+		// Extend the bucket to all the samples equal to the upper bound.
+		for ; i+num < numSamples; num++ {
+			if samples[i+num].Compare(evalCtx, upper) != 0 {
+				break
+			}
+		}
+
+		encoded, err := sqlbase.EncodeTableKey(nil, upper, encoding.Ascending)
+		if err != nil {
+			return HistogramData{}, err
+		}
+		h.Buckets = append(h.Buckets, HistogramData_Bucket{
+			NumEq:      int64(num-numLess) * numRows / int64(numSamples),
+			NumRange:   int64(numLess) * numRows / int64(numSamples),
+			UpperBound: encoded,
+		})
+		i += num
+	}
+	return h, nil
+}
+
+// DecodeUpperBounds returns the upper bounds of the buckets of the histogram,
+// which was built on values of the given type.
+func (h *HistogramData) DecodeUpperBounds(typ parser.Type) (parser.Datums, error) {
+	var alloc sqlbase.DatumAlloc
+	res := make(parser.Datums, len(h.Buckets))
+	for i := range h.Buckets {
+		d, _, err := sqlbase.DecodeTableKey(&alloc, typ, h.Buckets[i].UpperBound, encoding.Ascending)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = d
+	}
+	return res, nil
+}
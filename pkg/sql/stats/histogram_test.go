@@ -0,0 +1,136 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestEquiDepthHistogram(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	type expBucket struct {
+		upper    int
+		numEq    int64
+		numRange int64
+	}
+	testCases := []struct {
+		samples    []int
+		numRows    int64
+		maxBuckets int
+		buckets    []expBucket
+	}{
+		{
+			samples:    []int{1, 2, 4, 5, 5, 9},
+			numRows:    6,
+			maxBuckets: 2,
+			buckets: []expBucket{
+				{upper: 4, numEq: 1, numRange: 2},
+				{upper: 9, numEq: 1, numRange: 2},
+			},
+		},
+		{
+			// The samples are scaled to the number of rows.
+			samples:    []int{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			numRows:    100,
+			maxBuckets: 5,
+			buckets: []expBucket{
+				{upper: 2, numEq: 10, numRange: 10},
+				{upper: 4, numEq: 10, numRange: 10},
+				{upper: 6, numEq: 10, numRange: 10},
+				{upper: 8, numEq: 10, numRange: 10},
+				{upper: 10, numEq: 10, numRange: 10},
+			},
+		},
+		{
+			// A frequent value extends its bucket.
+			samples:    []int{1, 1, 1, 1, 1, 2, 3, 4},
+			numRows:    8,
+			maxBuckets: 3,
+			buckets: []expBucket{
+				{upper: 1, numEq: 5, numRange: 0},
+				{upper: 2, numEq: 1, numRange: 0},
+				{upper: 4, numEq: 1, numRange: 1},
+			},
+		},
+		{
+			// There are never more buckets than samples.
+			samples:    []int{3, 1, 2},
+			numRows:    3,
+			maxBuckets: 10,
+			buckets: []expBucket{
+				{upper: 1, numEq: 1, numRange: 0},
+				{upper: 2, numEq: 1, numRange: 0},
+				{upper: 3, numEq: 1, numRange: 0},
+			},
+		},
+		{
+			samples:    []int{},
+			numRows:    0,
+			maxBuckets: 10,
+			buckets:    []expBucket{},
+		},
+	}
+
+	evalCtx := parser.NewTestingEvalContext()
+	defer evalCtx.Stop(context.Background())
+
+	for i, tc := range testCases {
+		samples := make(parser.Datums, len(tc.samples))
+		for j := range tc.samples {
+			samples[j] = parser.NewDInt(parser.DInt(tc.samples[j]))
+		}
+		h, err := EquiDepthHistogram(evalCtx, samples, tc.numRows, tc.maxBuckets)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		bounds, err := h.DecodeUpperBounds(parser.TypeInt)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		buckets := make([]expBucket, len(h.Buckets))
+		for j, b := range h.Buckets {
+			buckets[j] = expBucket{
+				upper:    int(*bounds[j].(*parser.DInt)),
+				numEq:    b.NumEq,
+				numRange: b.NumRange,
+			}
+		}
+		if !reflect.DeepEqual(buckets, tc.buckets) {
+			t.Errorf("%d: expected buckets %v, got %v", i, tc.buckets, buckets)
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		samples := parser.Datums{parser.NewDInt(1), parser.NewDInt(2)}
+		if _, err := EquiDepthHistogram(evalCtx, samples, 1, 10); !testutils.IsError(
+			err, "more samples",
+		) {
+			t.Errorf("expected error, got %v", err)
+		}
+		if _, err := EquiDepthHistogram(evalCtx, samples, 2, 0); !testutils.IsError(
+			err, "invalid maxBuckets",
+		) {
+			t.Errorf("expected error, got %v", err)
+		}
+	})
+}
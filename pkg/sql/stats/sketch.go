@@ -0,0 +1,154 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+)
+
+// DistinctSketch estimates the number of distinct values in a multiset using
+// the "k minimum values" algorithm: the values are hashed and only the k
+// smallest hashes are kept. If the hashes are uniformly distributed, the k-th
+// smallest hash is about k/D of the hash space, where D is the number of
+// distinct values, so D is estimated as (k-1) * 2^64 / h_k.
+//
+// Sketches can be merged, so they can be computed on disjoint parts of the
+// multiset in parallel. The estimate is exact when there are fewer than k
+// distinct values.
+type DistinctSketch struct {
+	k int
+	// hashes is a max-heap of the smallest hashes seen so far.
+	hashes hashHeap
+	// present contains the hashes in the heap, so that duplicates are
+	// ignored.
+	present map[uint64]struct{}
+}
+
+// NewDistinctSketch creates a sketch which keeps the k smallest hashes.
+func NewDistinctSketch(k int) *DistinctSketch {
+	return &DistinctSketch{
+		k:       k,
+		present: make(map[uint64]struct{}),
+	}
+}
+
+// Add adds a value, given by its encoding, to the sketch. Equal values must
+// have equal encodings.
+func (s *DistinctSketch) Add(value []byte) {
+	h := fnv.New64a()
+	_, _ = h.Write(value)
+	s.addHash(mix64(h.Sum64()))
+}
+
+func (s *DistinctSketch) addHash(h uint64) {
+	if _, ok := s.present[h]; ok {
+		return
+	}
+	if len(s.hashes) < s.k {
+		heap.Push(&s.hashes, h)
+		s.present[h] = struct{}{}
+		return
+	}
+	if max := s.hashes[0]; h < max {
+		delete(s.present, max)
+		s.hashes[0] = h
+		heap.Fix(&s.hashes, 0)
+		s.present[h] = struct{}{}
+	}
+}
+
+// Merge adds the values of another sketch to this sketch.
+func (s *DistinctSketch) Merge(other *DistinctSketch) {
+	for _, h := range other.hashes {
+		s.addHash(h)
+	}
+}
+
+// Estimate returns the estimated number of distinct values added to the
+// sketch.
+func (s *DistinctSketch) Estimate() int64 {
+	if len(s.hashes) < s.k {
+		return int64(len(s.hashes))
+	}
+	// The hashes are in [0, 2^64), so the k-th smallest hash as a fraction of
+	// the hash space is (h_k + 1) / 2^64.
+	fraction := (float64(s.hashes[0]) + 1) / math.Exp2(64)
+	return int64(float64(s.k-1) / fraction)
+}
+
+// MarshalBinary encodes the sketch.
+func (s *DistinctSketch) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, (len(s.hashes)+1)*8)
+	b = encoding.EncodeUvarintAscending(b, uint64(s.k))
+	for _, h := range s.hashes {
+		b = encoding.EncodeUint64Ascending(b, h)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (s *DistinctSketch) UnmarshalBinary(b []byte) error {
+	b, k, err := encoding.DecodeUvarintAscending(b)
+	if err != nil {
+		return err
+	}
+	if len(b)%8 != 0 || uint64(len(b)/8) > k {
+		return errors.Errorf("invalid sketch encoding")
+	}
+	*s = *NewDistinctSketch(int(k))
+	for len(b) > 0 {
+		var h uint64
+		b, h, err = encoding.DecodeUint64Ascending(b)
+		if err != nil {
+			return err
+		}
+		s.addHash(h)
+	}
+	return nil
+}
+
+// mix64 is the finalizer of MurmurHash3; it spreads the bits of an FNV hash,
+// whose high bits are poorly distributed for short inputs.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// hashHeap is a max-heap of hashes.
+type hashHeap []uint64
+
+var _ heap.Interface = &hashHeap{}
+
+func (h hashHeap) Len() int            { return len(h) }
+func (h hashHeap) Less(i, j int) bool  { return h[i] > h[j] }
+func (h hashHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hashHeap) Push(x interface{}) { *h = append(*h, x.(uint64)) }
+func (h *hashHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func addInts(s *DistinctSketch, from, to int) {
+	var buf []byte
+	for i := from; i < to; i++ {
+		buf = encoding.EncodeVarintAscending(buf[:0], int64(i))
+		s.Add(buf)
+	}
+}
+
+func checkEstimate(t *testing.T, s *DistinctSketch, expected int64, tolerance float64) {
+	est := s.Estimate()
+	if math.Abs(float64(est-expected)) > tolerance*float64(expected) {
+		t.Errorf("expected %d distinct values (±%.0f%%), estimated %d", expected, tolerance*100, est)
+	}
+}
+
+func TestDistinctSketch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	t.Run("exact", func(t *testing.T) {
+		s := NewDistinctSketch(1024)
+		addInts(s, 0, 1000)
+		addInts(s, 0, 1000)
+		checkEstimate(t, s, 1000, 0)
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		s := NewDistinctSketch(1024)
+		addInts(s, 0, 100000)
+		checkEstimate(t, s, 100000, 0.1)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		a, b := NewDistinctSketch(1024), NewDistinctSketch(1024)
+		addInts(a, 0, 60000)
+		addInts(b, 40000, 100000)
+		a.Merge(b)
+		checkEstimate(t, a, 100000, 0.1)
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		s := NewDistinctSketch(256)
+		addInts(s, 0, 5000)
+		enc, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var d DistinctSketch
+		if err := d.UnmarshalBinary(enc); err != nil {
+			t.Fatal(err)
+		}
+		if d.k != s.k || d.Estimate() != s.Estimate() {
+			t.Errorf("expected sketch with k=%d estimating %d, got k=%d estimating %d",
+				s.k, s.Estimate(), d.k, d.Estimate())
+		}
+	})
+}
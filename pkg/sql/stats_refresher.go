@@ -0,0 +1,173 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+var automaticStatsEnabled = settings.RegisterBoolSetting(
+	"sql.stats.auto_refresh.enabled",
+	"automatically collect table statistics when enough rows have changed", true)
+
+var automaticStatsFractionStaleRows = settings.RegisterNonNegativeFloatSetting(
+	"sql.stats.auto_refresh.fraction_stale_rows",
+	"fraction of the rows of a table which must change to trigger a statistics refresh", 0.2)
+
+var automaticStatsMinStaleRows = settings.RegisterIntSetting(
+	"sql.stats.auto_refresh.min_stale_rows",
+	"minimum number of rows which must change to trigger a statistics refresh", 500)
+
+// autoStatsName is the name of the statistics collected by the
+// statsRefresher.
+const autoStatsName = "__auto__"
+
+// statsRefresher refreshes the statistics of tables in the background. The
+// row-modifying statements report the number of rows they modified; once
+// enough rows of a table have changed since its statistics were last
+// collected, new statistics are collected on all its columns.
+//
+// The counts are kept in memory and are local to this node, so a table whose
+// writes are spread over many nodes is refreshed less often.
+type statsRefresher struct {
+	e *Executor
+
+	mu struct {
+		syncutil.Mutex
+		tables map[sqlbase.ID]*tableMutations
+	}
+}
+
+// tableMutations tracks the rows of a table modified through this node since
+// its statistics were last refreshed.
+type tableMutations struct {
+	numRows int64
+	// refreshing is set while a refresh of the table is in progress.
+	refreshing bool
+}
+
+func (r *statsRefresher) init(e *Executor) {
+	r.e = e
+	r.mu.tables = make(map[sqlbase.ID]*tableMutations)
+}
+
+// notifyMutation records, once txn commits, that numRows rows of the table
+// with the given ID were modified.
+func (r *statsRefresher) notifyMutation(txn *client.Txn, tableID sqlbase.ID, numRows int) {
+	// Internal planners have no refresher. System tables are small and are
+	// not accessed through the cost-based planner.
+	if r == nil || numRows == 0 || tableID <= keys.MaxReservedDescID {
+		return
+	}
+	txn.AddCommitTrigger(func() {
+		r.addMutations(tableID, int64(numRows))
+	})
+}
+
+func (r *statsRefresher) addMutations(tableID sqlbase.ID, numRows int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.mu.tables[tableID]
+	if !ok {
+		t = &tableMutations{}
+		r.mu.tables[tableID] = t
+	}
+	t.numRows += numRows
+	if t.refreshing || !automaticStatsEnabled.Get() || r.e.cfg.TestingKnobs.DisableAutomaticStats ||
+		t.numRows < automaticStatsMinStaleRows.Get() {
+		return
+	}
+	t.refreshing = true
+	numStale := t.numRows
+
+	ctx := r.e.AnnotateCtx(context.Background())
+	if err := r.e.stopper.RunAsyncTask(ctx, "sql.statsRefresher: refresh", func(ctx context.Context) {
+		refreshed, err := r.maybeRefresh(ctx, tableID, numStale)
+		if err != nil {
+			log.Warningf(ctx, "failed to refresh the statistics of table %d: %v", tableID, err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		t.refreshing = false
+		if refreshed {
+			t.numRows -= numStale
+		}
+	}); err != nil {
+		t.refreshing = false
+	}
+}
+
+// maybeRefresh collects new statistics on the table if numStale is a large
+// enough fraction of its rows, and returns whether it did.
+func (r *statsRefresher) maybeRefresh(
+	ctx context.Context, tableID sqlbase.ID, numStale int64,
+) (bool, error) {
+	cfg := &r.e.cfg
+	refreshed := false
+	err := cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		refreshed = false
+		p := makeInternalPlanner("refresh-table-statistics", txn, security.RootUser, cfg.LeaseManager.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.tables.leaseMgr = cfg.LeaseManager
+		p.session.execCfg = cfg
+		p.session.distSQLPlanner = r.e.distSQLPlanner
+		p.session.tableStats = &r.e.tableStats
+
+		rows, err := p.queryRows(ctx,
+			`SELECT "rowCount" FROM system.table_statistics
+			 WHERE "tableID" = $1 ORDER BY "createdAt" DESC LIMIT 1`,
+			int(tableID))
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			rowCount := float64(parser.MustBeDInt(rows[0][0]))
+			if float64(numStale) < rowCount*automaticStatsFractionStaleRows.Get() {
+				return nil
+			}
+		}
+
+		tableDesc, err := sqlbase.GetTableDescFromID(ctx, txn, tableID)
+		if err != nil {
+			return err
+		}
+		if tableDesc.Dropped() {
+			return nil
+		}
+		name := autoStatsName
+		n := &createStatsNode{
+			p:           p,
+			tableDesc:   tableDesc,
+			name:        &name,
+			columns:     tableDesc.Columns,
+			description: "automatic statistics refresh of table " + tableDesc.Name,
+		}
+		if err := n.Start(ctx); err != nil {
+			return err
+		}
+		refreshed = true
+		return nil
+	})
+	return refreshed, err
+}
@@ -420,26 +420,68 @@ func getTableNames(
 		return e.tableNames(), nil
 	}
 
+	objects, err := getDatabaseObjects(ctx, txn, dbDesc)
+	return objects.tableNames, err
+}
+
+// databaseObjects holds the objects which live in the namespace of a
+// database.
+type databaseObjects struct {
+	tableNames parser.TableNames
+	types      []*sqlbase.TypeDescriptor
+	functions  []*sqlbase.FunctionDescriptor
+}
+
+// getDatabaseObjects returns the names of the tables and the descriptors of
+// the user-defined types and functions in the given database. Tables, types
+// and functions share the namespace of the database, so the descriptors of
+// all the namespace entries are needed to tell them apart.
+func getDatabaseObjects(
+	ctx context.Context, txn *client.Txn, dbDesc *sqlbase.DatabaseDescriptor,
+) (databaseObjects, error) {
+	var objects databaseObjects
 	prefix := sqlbase.MakeNameMetadataKey(dbDesc.ID, "")
 	sr, err := txn.Scan(ctx, prefix, prefix.PrefixEnd(), 0)
 	if err != nil {
-		return nil, err
+		return objects, err
+	}
+	if len(sr) == 0 {
+		return objects, nil
 	}
 
-	var tableNames parser.TableNames
+	b := &client.Batch{}
 	for _, row := range sr {
+		b.Get(sqlbase.MakeDescMetadataKey(sqlbase.ID(row.ValueInt())))
+	}
+	if err := txn.Run(ctx, b); err != nil {
+		return objects, err
+	}
+
+	for i, row := range sr {
+		desc := &sqlbase.Descriptor{}
+		if err := b.Results[i].Rows[0].ValueProto(desc); err != nil {
+			return objects, err
+		}
+		if typeDesc := desc.GetType(); typeDesc != nil {
+			objects.types = append(objects.types, typeDesc)
+			continue
+		}
+		if fnDesc := desc.GetFunction(); fnDesc != nil {
+			objects.functions = append(objects.functions, fnDesc)
+			continue
+		}
 		_, tableName, err := encoding.DecodeUnsafeStringAscending(
 			bytes.TrimPrefix(row.Key, prefix), nil)
 		if err != nil {
-			return nil, err
+			return objects, err
 		}
 		tn := parser.TableName{
 			DatabaseName: parser.Name(dbDesc.Name),
 			TableName:    parser.Name(tableName),
 		}
-		tableNames = append(tableNames, tn)
+		objects.tableNames = append(objects.tableNames, tn)
 	}
-	return tableNames, nil
+	return objects, nil
 }
 
 func (p *planner) getAliasedTableName(n parser.TableExpr) (*parser.TableName, error) {
@@ -502,6 +544,7 @@ func (p *planner) writeTableDesc(ctx context.Context, tableDesc *sqlbase.TableDe
 	// the descriptor the verification of the overwritten descriptor cannot be
 	// done.
 	p.session.setTestingVerifyMetadata(nil)
+	p.session.indexSelectionCache.invalidate(p.txn, tableDesc.ID)
 
 	return p.txn.Put(
 		ctx, sqlbase.MakeDescMetadataKey(tableDesc.GetID()), sqlbase.WrapDescriptor(tableDesc),
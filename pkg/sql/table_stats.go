@@ -0,0 +1,179 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// tableStatsCacheTTL is how long the statistics of a table are cached before
+// they are read again from system.table_statistics. Statistics collected on
+// this node invalidate the cache immediately.
+const tableStatsCacheTTL = time.Minute
+
+// tableStatistic is the most recent statistic collected on a column.
+type tableStatistic struct {
+	rowCount      int64
+	distinctCount int64
+	nullCount     int64
+
+	// histogram is nil if no histogram was collected on the column.
+	histogram *stats.HistogramData
+	// upperBounds are the decoded upper bounds of the histogram buckets.
+	upperBounds parser.Datums
+}
+
+// tableStats are the statistics of a table used by the planner to estimate
+// the number of rows produced by the nodes of a plan.
+type tableStats struct {
+	// rowCount is the row count of the most recent statistic.
+	rowCount int64
+	columns  map[sqlbase.ColumnID]*tableStatistic
+}
+
+// tableStatsCache caches the statistics of the tables accessed by the queries
+// planned on this node.
+type tableStatsCache struct {
+	e *Executor
+
+	mu struct {
+		syncutil.Mutex
+		entries map[sqlbase.ID]tableStatsCacheEntry
+	}
+}
+
+type tableStatsCacheEntry struct {
+	// stats is nil if the table has no statistics.
+	stats  *tableStats
+	loaded time.Time
+}
+
+func (c *tableStatsCache) init(e *Executor) {
+	c.e = e
+	c.mu.entries = make(map[sqlbase.ID]tableStatsCacheEntry)
+}
+
+// get returns the statistics of the given table, or nil if it has none.
+// Errors are logged and treated as an absence of statistics: the plan is
+// then chosen by the heuristics used for tables without statistics.
+func (c *tableStatsCache) get(ctx context.Context, desc *sqlbase.TableDescriptor) *tableStats {
+	// Internal planners have no cache. System tables are not analyzed, and
+	// reading the statistics must not require statistics.
+	if c == nil || desc.ID <= keys.MaxReservedDescID || !desc.IsTable() {
+		return nil
+	}
+	now := timeutil.Now()
+	c.mu.Lock()
+	entry, ok := c.mu.entries[desc.ID]
+	c.mu.Unlock()
+	if ok && now.Sub(entry.loaded) < tableStatsCacheTTL {
+		return entry.stats
+	}
+
+	ts, err := c.load(ctx, desc)
+	if err != nil {
+		log.Warningf(ctx, "failed to load the statistics of table %d: %v", desc.ID, err)
+		return nil
+	}
+	c.mu.Lock()
+	c.mu.entries[desc.ID] = tableStatsCacheEntry{stats: ts, loaded: now}
+	c.mu.Unlock()
+	return ts
+}
+
+// invalidate removes the cached statistics of the given table once txn
+// commits.
+func (c *tableStatsCache) invalidate(txn *client.Txn, tableID sqlbase.ID) {
+	if c == nil {
+		return
+	}
+	txn.AddCommitTrigger(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.mu.entries, tableID)
+	})
+}
+
+// load reads the most recent statistic of each column of the table.
+func (c *tableStatsCache) load(
+	ctx context.Context, desc *sqlbase.TableDescriptor,
+) (*tableStats, error) {
+	cfg := &c.e.cfg
+	var rows []parser.Datums
+	if err := cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("load-table-statistics", txn, security.RootUser, cfg.LeaseManager.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.tables.leaseMgr = cfg.LeaseManager
+
+		var err error
+		rows, err = p.queryRows(ctx,
+			`SELECT "columnID", "rowCount", "distinctCount", "nullCount", histogram
+			 FROM system.table_statistics
+			 WHERE "tableID" = $1
+			 ORDER BY "createdAt" DESC`,
+			int(desc.ID))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ts := &tableStats{
+		rowCount: int64(parser.MustBeDInt(rows[0][1])),
+		columns:  make(map[sqlbase.ColumnID]*tableStatistic),
+	}
+	for _, r := range rows {
+		colID := sqlbase.ColumnID(parser.MustBeDInt(r[0]))
+		if _, ok := ts.columns[colID]; ok {
+			// We already have a more recent statistic on this column.
+			continue
+		}
+		col, err := desc.FindColumnByID(colID)
+		if err != nil {
+			// The column was dropped.
+			continue
+		}
+		stat := &tableStatistic{
+			rowCount:      int64(parser.MustBeDInt(r[1])),
+			distinctCount: int64(parser.MustBeDInt(r[2])),
+			nullCount:     int64(parser.MustBeDInt(r[3])),
+		}
+		if r[4] != parser.DNull {
+			stat.histogram = &stats.HistogramData{}
+			if err := stat.histogram.Unmarshal([]byte(*r[4].(*parser.DBytes))); err != nil {
+				return nil, err
+			}
+			if stat.upperBounds, err = stat.histogram.DecodeUpperBounds(col.Type.ToDatumType()); err != nil {
+				return nil, err
+			}
+		}
+		ts.columns[colID] = stat
+	}
+	return ts, nil
+}
@@ -92,6 +92,13 @@ type tableInserter struct {
 	ri         sqlbase.RowInserter
 	autoCommit bool
 
+	// If set, the inserted rows are checked against the row-level security
+	// policies of the table.
+	policyCheck *policyChecker
+
+	// If set, the INSERT triggers of the table fire for the inserted rows.
+	triggers *triggerRunner
+
 	// Set by init.
 	txn *client.Txn
 	b   *client.Batch
@@ -108,6 +115,20 @@ func (ti *tableInserter) init(txn *client.Txn) error {
 func (ti *tableInserter) row(
 	ctx context.Context, values parser.Datums, traceKV bool,
 ) (parser.Datums, error) {
+	if ti.triggers != nil && ti.triggers.hasRowTriggers {
+		newRow := ti.triggers.makeRow(ti.ri.InsertColIDtoRowIndex, values)
+		if err := ti.triggers.beforeRow(
+			ctx, sqlbase.TableDescriptor_Trigger_INSERT, nil, newRow,
+		); err != nil {
+			return nil, err
+		}
+		ti.triggers.afterRow(sqlbase.TableDescriptor_Trigger_INSERT, nil, newRow)
+	}
+	if ti.policyCheck != nil {
+		if err := ti.policyCheck.checkRow(ti.ri.InsertColIDtoRowIndex, values); err != nil {
+			return nil, err
+		}
+	}
 	return nil, ti.ri.InsertRow(ctx, ti.b, values, false, traceKV)
 }
 
@@ -125,6 +146,9 @@ func (ti *tableInserter) finalize(ctx context.Context, _ bool) error {
 	if err != nil {
 		return sqlbase.ConvertBatchError(ti.ri.Helper.TableDesc, ti.b)
 	}
+	if ti.triggers != nil {
+		return ti.triggers.afterStatement(ctx)
+	}
 	return nil
 }
 
@@ -137,9 +161,17 @@ type tableUpdater struct {
 	ru         sqlbase.RowUpdater
 	autoCommit bool
 
+	// If set, the updated rows are checked against the row-level security
+	// policies of the table.
+	policyCheck *policyChecker
+
+	// If set, the UPDATE triggers of the table fire for the updated rows.
+	triggers *triggerRunner
+
 	// Set by init.
-	txn *client.Txn
-	b   *client.Batch
+	txn                   *client.Txn
+	b                     *client.Batch
+	updateColIDtoRowIndex map[sqlbase.ColumnID]int
 }
 
 func (tu *tableUpdater) walkExprs(_ func(desc string, index int, expr parser.TypedExpr)) {}
@@ -147,6 +179,9 @@ func (tu *tableUpdater) walkExprs(_ func(desc string, index int, expr parser.Typ
 func (tu *tableUpdater) init(txn *client.Txn) error {
 	tu.txn = txn
 	tu.b = txn.NewBatch()
+	if tu.policyCheck != nil || (tu.triggers != nil && tu.triggers.hasRowTriggers) {
+		tu.updateColIDtoRowIndex = sqlbase.ColIDtoRowIndexFromCols(tu.ru.UpdateCols)
+	}
 	return nil
 }
 
@@ -155,6 +190,23 @@ func (tu *tableUpdater) row(
 ) (parser.Datums, error) {
 	oldValues := values[:len(tu.ru.FetchCols)]
 	updateValues := values[len(tu.ru.FetchCols):]
+	if tu.triggers != nil && tu.triggers.hasRowTriggers {
+		oldRow := tu.triggers.makeRow(tu.ru.FetchColIDtoRowIndex, oldValues)
+		newRow := tu.triggers.makeUpdatedRow(oldRow, tu.updateColIDtoRowIndex, updateValues)
+		if err := tu.triggers.beforeRow(
+			ctx, sqlbase.TableDescriptor_Trigger_UPDATE, oldRow, newRow,
+		); err != nil {
+			return nil, err
+		}
+		tu.triggers.afterRow(sqlbase.TableDescriptor_Trigger_UPDATE, oldRow, newRow)
+	}
+	if tu.policyCheck != nil {
+		if err := tu.policyCheck.checkUpdatedRow(
+			tu.ru.FetchColIDtoRowIndex, oldValues, tu.updateColIDtoRowIndex, updateValues,
+		); err != nil {
+			return nil, err
+		}
+	}
 	return tu.ru.UpdateRow(ctx, tu.b, oldValues, updateValues, traceKV)
 }
 
@@ -172,6 +224,9 @@ func (tu *tableUpdater) finalize(ctx context.Context, _ bool) error {
 	if err != nil {
 		return sqlbase.ConvertBatchError(tu.ru.Helper.TableDesc, tu.b)
 	}
+	if tu.triggers != nil {
+		return tu.triggers.afterStatement(ctx)
+	}
 	return nil
 }
 
@@ -218,6 +273,20 @@ type tableUpserter struct {
 	// These are set for ON CONFLICT DO UPDATE, but not for DO NOTHING
 	updateCols []sqlbase.ColumnDescriptor
 	evaler     tableUpsertEvaler
+	// evalCtx is used to compute the computed columns among updateCols,
+	// which follow the columns updated by evaler.
+	evalCtx *parser.EvalContext
+
+	// If set, the rows proposed for insertion, the existing rows to be
+	// updated and the updated rows, respectively, are checked against the
+	// row-level security policies of the table.
+	insertPolicyCheck   *policyChecker
+	existingPolicyCheck *policyChecker
+	updatePolicyCheck   *policyChecker
+
+	// If set, the INSERT or UPDATE triggers of the table fire for the
+	// inserted or updated rows, respectively.
+	triggers *triggerRunner
 
 	// Set by init.
 	txn                   *client.Txn
@@ -229,6 +298,7 @@ type tableUpserter struct {
 	fetchCols             []sqlbase.ColumnDescriptor
 	fetchColIDtoRowIndex  map[sqlbase.ColumnID]int
 	fetcher               sqlbase.RowFetcher
+	computedCols          *sqlbase.ComputedColumns
 
 	// Used for the fast path.
 	fastPathBatch *client.Batch
@@ -269,7 +339,12 @@ func (tu *tableUpserter) init(txn *client.Txn) error {
 		// path is disabled during all mutations.
 		len(tu.tableDesc.Mutations) == 0 &&
 		// For the fast path, all columns must be specified in the insert.
-		len(tu.ri.InsertCols) == len(tu.tableDesc.Columns)
+		len(tu.ri.InsertCols) == len(tu.tableDesc.Columns) &&
+		// The rows being replaced must be checked against the row-level
+		// security policies of the table.
+		tu.existingPolicyCheck == nil && tu.updatePolicyCheck == nil &&
+		// Row triggers need to know whether each row is inserted or updated.
+		(tu.triggers == nil || !tu.triggers.hasRowTriggers)
 	if enableFastPath {
 		tu.fastPathBatch = tu.txn.NewBatch()
 		tu.fastPathKeys = make(map[string]struct{})
@@ -299,6 +374,10 @@ func (tu *tableUpserter) init(txn *client.Txn) error {
 		for i, updateCol := range tu.ru.UpdateCols {
 			tu.updateColIDtoRowIndex[updateCol.ID] = i
 		}
+		tu.computedCols, err = makeUpdateComputedColumns(tu.tableDesc, &tu.ru)
+		if err != nil {
+			return err
+		}
 	}
 
 	valNeededForCol := make([]bool, len(tu.fetchCols))
@@ -317,6 +396,12 @@ func (tu *tableUpserter) init(txn *client.Txn) error {
 func (tu *tableUpserter) row(
 	ctx context.Context, row parser.Datums, traceKV bool,
 ) (parser.Datums, error) {
+	if tu.insertPolicyCheck != nil {
+		if err := tu.insertPolicyCheck.checkRow(tu.ri.InsertColIDtoRowIndex, row); err != nil {
+			return nil, err
+		}
+	}
+
 	if tu.fastPathBatch != nil {
 		primaryKey, _, err := sqlbase.EncodeIndexKey(
 			tu.tableDesc, &tu.tableDesc.PrimaryIndex, tu.ri.InsertColIDtoRowIndex, row, tu.indexKeyPrefix)
@@ -354,6 +439,15 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize, traceKV bool) erro
 		existingRow := existingRows[i]
 
 		if existingRow == nil {
+			if tu.triggers != nil && tu.triggers.hasRowTriggers {
+				newRow := tu.triggers.makeRow(tu.ri.InsertColIDtoRowIndex, insertRow)
+				if err := tu.triggers.beforeRow(
+					ctx, sqlbase.TableDescriptor_Trigger_INSERT, nil, newRow,
+				); err != nil {
+					return err
+				}
+				tu.triggers.afterRow(sqlbase.TableDescriptor_Trigger_INSERT, nil, newRow)
+			}
 			err := tu.ri.InsertRow(ctx, b, insertRow, false, traceKV)
 			if err != nil {
 				return err
@@ -362,10 +456,43 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize, traceKV bool) erro
 			// If len(tu.updateCols) == 0, then we're in the DO NOTHING case.
 			if len(tu.updateCols) > 0 {
 				existingValues := existingRow[:len(tu.ru.FetchCols)]
+				if tu.existingPolicyCheck != nil {
+					if err := tu.existingPolicyCheck.checkRow(
+						tu.fetchColIDtoRowIndex, existingValues,
+					); err != nil {
+						return err
+					}
+				}
 				updateValues, err := tu.evaler.eval(insertRow, existingValues)
 				if err != nil {
 					return err
 				}
+				if tu.computedCols != nil {
+					updateValues = append(updateValues,
+						make(parser.Datums, len(tu.ru.UpdateCols)-len(updateValues))...)
+					if err := computeUpdatedValues(
+						tu.evalCtx, tu.computedCols, existingValues, updateValues,
+					); err != nil {
+						return err
+					}
+				}
+				if tu.triggers != nil && tu.triggers.hasRowTriggers {
+					oldRow := tu.triggers.makeRow(tu.fetchColIDtoRowIndex, existingValues)
+					newRow := tu.triggers.makeUpdatedRow(oldRow, tu.updateColIDtoRowIndex, updateValues)
+					if err := tu.triggers.beforeRow(
+						ctx, sqlbase.TableDescriptor_Trigger_UPDATE, oldRow, newRow,
+					); err != nil {
+						return err
+					}
+					tu.triggers.afterRow(sqlbase.TableDescriptor_Trigger_UPDATE, oldRow, newRow)
+				}
+				if tu.updatePolicyCheck != nil {
+					if err := tu.updatePolicyCheck.checkUpdatedRow(
+						tu.fetchColIDtoRowIndex, existingValues, tu.updateColIDtoRowIndex, updateValues,
+					); err != nil {
+						return err
+					}
+				}
 				_, err = tu.ru.UpdateRow(ctx, b, existingValues, updateValues, traceKV)
 				if err != nil {
 					return err
@@ -513,9 +640,16 @@ func (tu *tableUpserter) finalize(ctx context.Context, traceKV bool) error {
 			// coordinator.
 			return tu.txn.CommitInBatch(ctx, tu.fastPathBatch)
 		}
-		return tu.txn.Run(ctx, tu.fastPathBatch)
+		if err := tu.txn.Run(ctx, tu.fastPathBatch); err != nil {
+			return err
+		}
+	} else if err := tu.flush(ctx, true /* finalize */, traceKV); err != nil {
+		return err
 	}
-	return tu.flush(ctx, true /* finalize */, traceKV)
+	if tu.triggers != nil {
+		return tu.triggers.afterStatement(ctx)
+	}
+	return nil
 }
 
 func (tu *tableUpserter) spans() (reads, writes roachpb.Spans, err error) {
@@ -527,6 +661,9 @@ type tableDeleter struct {
 	rd         sqlbase.RowDeleter
 	autoCommit bool
 
+	// If set, the DELETE triggers of the table fire for the deleted rows.
+	triggers *triggerRunner
+
 	// Set by init.
 	txn *client.Txn
 	b   *client.Batch
@@ -543,6 +680,15 @@ func (td *tableDeleter) init(txn *client.Txn) error {
 func (td *tableDeleter) row(
 	ctx context.Context, values parser.Datums, traceKV bool,
 ) (parser.Datums, error) {
+	if td.triggers != nil && td.triggers.hasRowTriggers {
+		oldRow := td.triggers.makeRow(td.rd.FetchColIDtoRowIndex, values)
+		if err := td.triggers.beforeRow(
+			ctx, sqlbase.TableDescriptor_Trigger_DELETE, oldRow, nil,
+		); err != nil {
+			return nil, err
+		}
+		td.triggers.afterRow(sqlbase.TableDescriptor_Trigger_DELETE, oldRow, nil)
+	}
 	return nil, td.rd.DeleteRow(ctx, td.b, values, traceKV)
 }
 
@@ -553,11 +699,23 @@ func (td *tableDeleter) finalize(ctx context.Context, _ bool) error {
 		// coordinator.
 		return td.txn.CommitInBatch(ctx, td.b)
 	}
-	return td.txn.Run(ctx, td.b)
+	if err := td.txn.Run(ctx, td.b); err != nil {
+		return err
+	}
+	if td.triggers != nil {
+		return td.triggers.afterStatement(ctx)
+	}
+	return nil
 }
 
 // fastPathAvailable returns true if the fastDelete optimization can be used.
 func (td *tableDeleter) fastPathAvailable(ctx context.Context) bool {
+	if td.triggers != nil && td.triggers.hasRowTriggers {
+		if log.V(2) {
+			log.Info(ctx, "delete forced to scan: values required for row triggers")
+		}
+		return false
+	}
 	if len(td.rd.Helper.Indexes) != 0 {
 		if log.V(2) {
 			log.Infof(ctx, "delete forced to scan: values required to update %d secondary indexes", len(td.rd.Helper.Indexes))
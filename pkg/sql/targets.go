@@ -40,7 +40,7 @@ func (p *planner) computeRender(
 	// column name, we determine the name before we perform any manipulations to
 	// the expression.
 	if outputName == autoGenerateRenderOutputName {
-		if outputName, err = getRenderColName(p.session.SearchPath, target); err != nil {
+		if outputName, err = getRenderColName(&p.semaCtx, target); err != nil {
 			return sqlbase.ResultColumn{}, nil, err
 		}
 	}
@@ -0,0 +1,598 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"strconv"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// maxTriggerDepth is the maximum nesting depth of triggers, which is reached
+// when the statements run by triggers keep firing other triggers.
+const maxTriggerDepth = 16
+
+func triggerTimingFromParser(t parser.TriggerTiming) sqlbase.TableDescriptor_Trigger_Timing {
+	if t == parser.TriggerAfter {
+		return sqlbase.TableDescriptor_Trigger_AFTER
+	}
+	return sqlbase.TableDescriptor_Trigger_BEFORE
+}
+
+func triggerEventFromParser(e parser.TriggerEvent) sqlbase.TableDescriptor_Trigger_Event {
+	switch e {
+	case parser.TriggerUpdate:
+		return sqlbase.TableDescriptor_Trigger_UPDATE
+	case parser.TriggerDelete:
+		return sqlbase.TableDescriptor_Trigger_DELETE
+	default:
+		return sqlbase.TableDescriptor_Trigger_INSERT
+	}
+}
+
+// preparedTrigger is a trigger ready to fire on one kind of event.
+type preparedTrigger struct {
+	name       string
+	timing     sqlbase.TableDescriptor_Trigger_Timing
+	event      sqlbase.TableDescriptor_Trigger_Event
+	forEachRow bool
+	// sql is the body of the trigger function, in which the references to the
+	// fields of OLD and NEW are replaced by placeholders.
+	sql string
+	// args describes the values of the placeholders in sql.
+	args []triggerArg
+}
+
+// triggerArg is a reference to a field of OLD or NEW.
+type triggerArg struct {
+	old bool
+	// colIdx is the position of the field in the columns of the table.
+	colIdx int
+}
+
+// triggerRow is a row modified by a statement, for which the AFTER ROW
+// triggers have yet to fire.
+type triggerRow struct {
+	event    sqlbase.TableDescriptor_Trigger_Event
+	old, new parser.Datums
+}
+
+// triggerRunner fires the triggers of a table for a statement modifying it.
+// The rows passed to the triggers hold the values of the columns of the
+// table, in order.
+//
+// BEFORE triggers fire right away. AFTER ROW triggers fire once the writes of
+// the statement have been flushed, followed by the AFTER STATEMENT triggers.
+// The statements run by the triggers are part of the transaction of the
+// statement firing them.
+type triggerRunner struct {
+	p         *planner
+	tableDesc *sqlbase.TableDescriptor
+	triggers  []preparedTrigger
+
+	// hasRowTriggers is set if any of the triggers fires for each row, in
+	// which case the values of all the columns of the modified rows are
+	// needed.
+	hasRowTriggers bool
+
+	pending []triggerRow
+}
+
+// makeTriggerRunner returns a triggerRunner firing the triggers of the table
+// on the given events, or nil if the table has no such triggers.
+func (p *planner) makeTriggerRunner(
+	ctx context.Context,
+	tableDesc *sqlbase.TableDescriptor,
+	events ...sqlbase.TableDescriptor_Trigger_Event,
+) (*triggerRunner, error) {
+	var tr *triggerRunner
+	for _, event := range events {
+		for i := range tableDesc.Triggers {
+			trigger := &tableDesc.Triggers[i]
+			if !trigger.FiresOn(event) {
+				continue
+			}
+			fnDesc, err := sqlbase.GetFunctionDescFromID(ctx, p.txn, trigger.FunctionID)
+			if err != nil {
+				return nil, err
+			}
+			prepared, err := prepareTrigger(tableDesc, trigger, event, fnDesc)
+			if err != nil {
+				return nil, err
+			}
+			if tr == nil {
+				tr = &triggerRunner{p: p, tableDesc: tableDesc}
+			}
+			tr.triggers = append(tr.triggers, prepared)
+			tr.hasRowTriggers = tr.hasRowTriggers || trigger.ForEachRow
+		}
+	}
+	return tr, nil
+}
+
+// prepareTrigger prepares the body of the function of a trigger to run when
+// the trigger fires on the given event.
+func prepareTrigger(
+	tableDesc *sqlbase.TableDescriptor,
+	trigger *sqlbase.TableDescriptor_Trigger,
+	event sqlbase.TableDescriptor_Trigger_Event,
+	fnDesc *sqlbase.FunctionDescriptor,
+) (preparedTrigger, error) {
+	body, err := parser.ParseTriggerFunctionBody(fnDesc.Body)
+	if err != nil {
+		return preparedTrigger{}, err
+	}
+
+	level := "STATEMENT"
+	if trigger.ForEachRow {
+		level = "ROW"
+	}
+	v := triggerRowVisitor{
+		cols:   make(map[string]int, len(tableDesc.Columns)),
+		hasOld: trigger.ForEachRow && event != sqlbase.TableDescriptor_Trigger_INSERT,
+		hasNew: trigger.ForEachRow && event != sqlbase.TableDescriptor_Trigger_DELETE,
+		vars: map[string]parser.Datum{
+			"tg_name":       parser.NewDString(trigger.Name),
+			"tg_when":       parser.NewDString(trigger.Timing.String()),
+			"tg_level":      parser.NewDString(level),
+			"tg_op":         parser.NewDString(event.String()),
+			"tg_table_name": parser.NewDString(tableDesc.Name),
+		},
+		tableDesc: tableDesc,
+	}
+	for i := range tableDesc.Columns {
+		v.cols[parser.ReNormalizeName(tableDesc.Columns[i].Name)] = i
+	}
+	body, _ = parser.WalkStmt(&v, body)
+	if v.err != nil {
+		return preparedTrigger{}, v.err
+	}
+
+	return preparedTrigger{
+		name:       trigger.Name,
+		timing:     trigger.Timing,
+		event:      event,
+		forEachRow: trigger.ForEachRow,
+		sql:        parser.AsStringWithFlags(body, parser.FmtParsable),
+		args:       v.args,
+	}, nil
+}
+
+// triggerRowVisitor replaces, in the body of a trigger function, the
+// references to the fields of the OLD and NEW rows by placeholders cast to
+// the types of the columns, and the special variables TG_NAME, TG_WHEN,
+// TG_LEVEL, TG_OP and TG_TABLE_NAME by their values.
+type triggerRowVisitor struct {
+	tableDesc *sqlbase.TableDescriptor
+	// cols maps the normalized names of the columns of the table to their
+	// positions.
+	cols           map[string]int
+	hasOld, hasNew bool
+	vars           map[string]parser.Datum
+
+	args []triggerArg
+	err  error
+}
+
+var _ parser.Visitor = &triggerRowVisitor{}
+
+func (v *triggerRowVisitor) VisitPre(expr parser.Expr) (recurse bool, newExpr parser.Expr) {
+	if v.err != nil {
+		return false, expr
+	}
+	switch t := expr.(type) {
+	case parser.UnresolvedName:
+		switch len(t) {
+		case 1:
+			if name, ok := t[0].(parser.Name); ok {
+				if d, ok := v.vars[name.Normalize()]; ok {
+					return false, d
+				}
+			}
+		case 2:
+			record, ok := t[0].(parser.Name)
+			if !ok {
+				break
+			}
+			field, ok := t[1].(parser.Name)
+			if !ok {
+				break
+			}
+			recordName := record.Normalize()
+			if recordName != "old" && recordName != "new" {
+				break
+			}
+			old := recordName == "old"
+			if (old && !v.hasOld) || (!old && !v.hasNew) {
+				v.err = pgerror.NewErrorf(pgerror.CodeObjectNotInPrerequisiteStateError,
+					"record %q is not assigned yet", recordName)
+				return false, expr
+			}
+			colIdx, ok := v.cols[field.Normalize()]
+			if !ok {
+				v.err = pgerror.NewErrorf(pgerror.CodeUndefinedColumnError,
+					"record %q has no field %q", recordName, field.Normalize())
+				return false, expr
+			}
+			return false, v.placeholder(triggerArg{old: old, colIdx: colIdx})
+		}
+	case *parser.Placeholder:
+		v.err = pgerror.NewErrorf(pgerror.CodeUndefinedParameterError,
+			"there is no parameter $%s", t.Name)
+		return false, expr
+	}
+	return true, expr
+}
+
+func (*triggerRowVisitor) VisitPost(expr parser.Expr) parser.Expr { return expr }
+
+// placeholder returns the placeholder standing for the given field, cast to
+// the type of the column so that the body is typed the same way whatever the
+// values of the fields.
+func (v *triggerRowVisitor) placeholder(arg triggerArg) parser.Expr {
+	pos := -1
+	for i := range v.args {
+		if v.args[i] == arg {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		pos = len(v.args)
+		v.args = append(v.args, arg)
+	}
+	placeholder := parser.NewPlaceholder(strconv.Itoa(pos + 1))
+	colType, err := parser.DatumTypeToColumnType(v.tableDesc.Columns[arg.colIdx].Type.ToDatumType())
+	if err != nil {
+		// Not all types can be named in a cast; leave the placeholder to be
+		// typed from its value.
+		return placeholder
+	}
+	return &parser.CastExpr{Expr: placeholder, Type: colType}
+}
+
+// makeRow returns the values of the columns of the table, in order, from a
+// row laid out as described by colIDtoRowIndex. The columns missing from the
+// row are NULL.
+func (tr *triggerRunner) makeRow(
+	colIDtoRowIndex map[sqlbase.ColumnID]int, values parser.Datums,
+) parser.Datums {
+	row := make(parser.Datums, len(tr.tableDesc.Columns))
+	for i, col := range tr.tableDesc.Columns {
+		if idx, ok := colIDtoRowIndex[col.ID]; ok {
+			row[i] = values[idx]
+		} else {
+			row[i] = parser.DNull
+		}
+	}
+	return row
+}
+
+// makeUpdatedRow returns a copy of row, as returned by makeRow, in which the
+// values of the updated columns are replaced by those in updateValues, laid
+// out as described by updateColIDtoRowIndex.
+func (tr *triggerRunner) makeUpdatedRow(
+	row parser.Datums, updateColIDtoRowIndex map[sqlbase.ColumnID]int, updateValues parser.Datums,
+) parser.Datums {
+	newRow := append(parser.Datums(nil), row...)
+	for i, col := range tr.tableDesc.Columns {
+		if idx, ok := updateColIDtoRowIndex[col.ID]; ok {
+			newRow[i] = updateValues[idx]
+		}
+	}
+	return newRow
+}
+
+// beforeStatement fires the BEFORE STATEMENT triggers. It must be called
+// before any row is modified.
+func (tr *triggerRunner) beforeStatement(ctx context.Context) error {
+	for i := range tr.triggers {
+		t := &tr.triggers[i]
+		if t.timing == sqlbase.TableDescriptor_Trigger_BEFORE && !t.forEachRow {
+			if err := tr.fire(ctx, t, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// beforeRow fires the BEFORE ROW triggers for a row about to be modified.
+// The old row is nil for an insert, and the new row is nil for a delete.
+func (tr *triggerRunner) beforeRow(
+	ctx context.Context, event sqlbase.TableDescriptor_Trigger_Event, old, new parser.Datums,
+) error {
+	for i := range tr.triggers {
+		t := &tr.triggers[i]
+		if t.timing == sqlbase.TableDescriptor_Trigger_BEFORE && t.forEachRow && t.event == event {
+			if err := tr.fire(ctx, t, old, new); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// afterRow records that the AFTER ROW triggers are to fire for a modified
+// row, once the writes of the statement have been flushed.
+func (tr *triggerRunner) afterRow(
+	event sqlbase.TableDescriptor_Trigger_Event, old, new parser.Datums,
+) {
+	for i := range tr.triggers {
+		t := &tr.triggers[i]
+		if t.timing == sqlbase.TableDescriptor_Trigger_AFTER && t.forEachRow && t.event == event {
+			tr.pending = append(tr.pending, triggerRow{event: event, old: old, new: new})
+			return
+		}
+	}
+}
+
+// afterStatement fires the AFTER ROW triggers for the rows recorded by
+// afterRow, followed by the AFTER STATEMENT triggers. It must be called once
+// the writes of the statement have been flushed.
+func (tr *triggerRunner) afterStatement(ctx context.Context) error {
+	pending := tr.pending
+	tr.pending = nil
+	for _, row := range pending {
+		for i := range tr.triggers {
+			t := &tr.triggers[i]
+			if t.timing == sqlbase.TableDescriptor_Trigger_AFTER && t.forEachRow && t.event == row.event {
+				if err := tr.fire(ctx, t, row.old, row.new); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for i := range tr.triggers {
+		t := &tr.triggers[i]
+		if t.timing == sqlbase.TableDescriptor_Trigger_AFTER && !t.forEachRow {
+			if err := tr.fire(ctx, t, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fire runs the body of the function of a trigger.
+func (tr *triggerRunner) fire(
+	ctx context.Context, t *preparedTrigger, old, new parser.Datums,
+) error {
+	if tr.p.triggerDepth >= maxTriggerDepth {
+		return pgerror.NewErrorf(pgerror.CodeStatementTooComplexError,
+			"trigger %q on table %q exceeded the maximum trigger nesting depth (%d)",
+			t.name, tr.tableDesc.Name, maxTriggerDepth)
+	}
+	args := make([]interface{}, len(t.args))
+	for i, arg := range t.args {
+		if arg.old {
+			args[i] = old[arg.colIdx]
+		} else {
+			args[i] = new[arg.colIdx]
+		}
+	}
+
+	// The statement firing the trigger is still using its planner, so the
+	// body is run by a planner of its own, in the same transaction.
+	np := tr.p.session.newPlanner(nil, tr.p.txn)
+	np.evalCtx = tr.p.evalCtx
+	np.evalCtx.Planner = np
+	np.triggerDepth = tr.p.triggerDepth + 1
+	_, err := np.exec(ctx, t.sql, args...)
+	return err
+}
+
+type createTriggerNode struct {
+	p         *planner
+	n         *parser.CreateTrigger
+	tableDesc *sqlbase.TableDescriptor
+	fnDesc    *sqlbase.FunctionDescriptor
+}
+
+// CreateTrigger creates a trigger.
+// Privileges: CREATE on table.
+//   Notes: postgres requires the TRIGGER privilege on the table and EXECUTE
+//          on the function.
+func (p *planner) CreateTrigger(ctx context.Context, n *parser.CreateTrigger) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, true /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	fn, err := n.Func.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	fnDesc, err := getFunctionDesc(ctx, p.txn, p.getVirtualTabler(), fn)
+	if err != nil {
+		return nil, err
+	}
+	if fnDesc == nil {
+		return nil, newUndefinedFunctionError(fn.Table())
+	}
+	if !fnDesc.ReturnsTrigger {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidObjectDefinitionError,
+			"function %q must return type trigger", fn.Table())
+	}
+
+	return &createTriggerNode{p: p, n: n, tableDesc: tableDesc, fnDesc: fnDesc}, nil
+}
+
+func (n *createTriggerNode) Start(ctx context.Context) error {
+	if n.tableDesc.FindTriggerByName(n.n.Name) >= 0 {
+		return pgerror.NewErrorf(pgerror.CodeDuplicateObjectError,
+			"trigger %q for table %q already exists", string(n.n.Name), n.tableDesc.Name)
+	}
+
+	trigger := sqlbase.TableDescriptor_Trigger{
+		Name:       string(n.n.Name),
+		Timing:     triggerTimingFromParser(n.n.Timing),
+		ForEachRow: n.n.ForEachRow,
+		FunctionID: n.fnDesc.ID,
+	}
+	for _, event := range n.n.Events {
+		e := triggerEventFromParser(event)
+		if !trigger.FiresOn(e) {
+			trigger.Events = append(trigger.Events, e)
+		}
+	}
+
+	n.tableDesc.Triggers = append(n.tableDesc.Triggers, trigger)
+	if err := n.tableDesc.SetUpVersion(); err != nil {
+		return err
+	}
+	if err := n.tableDesc.Validate(ctx, n.p.txn); err != nil {
+		return err
+	}
+	if err := n.p.writeTableDesc(ctx, n.tableDesc); err != nil {
+		return err
+	}
+	if err := n.p.addFunctionReferences(
+		ctx, []sqlbase.ID{n.fnDesc.ID}, n.tableDesc.ID,
+	); err != nil {
+		return err
+	}
+
+	// Record this trigger creation in the event log. This is an auditable log
+	// event and is recorded in the same transaction as the table descriptor
+	// update.
+	if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogCreateTrigger,
+		int32(n.tableDesc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TableName   string
+			TriggerName string
+			Statement   string
+			User        string
+		}{n.tableDesc.Name, n.n.Name.String(), n.n.String(), n.p.session.User},
+	); err != nil {
+		return err
+	}
+	n.p.notifySchemaChange(n.tableDesc, sqlbase.InvalidMutationID)
+	return nil
+}
+
+func (*createTriggerNode) Next(context.Context) (bool, error) { return false, nil }
+func (*createTriggerNode) Close(context.Context)              {}
+
+func (*createTriggerNode) Values() parser.Datums      { return parser.Datums{} }
+func (*createTriggerNode) DebugValues() debugValues   { return debugValues{} }
+func (*createTriggerNode) MarkDebug(mode explainMode) {}
+
+type dropTriggerNode struct {
+	p         *planner
+	n         *parser.DropTrigger
+	tableDesc *sqlbase.TableDescriptor
+}
+
+// DropTrigger drops a trigger.
+// Privileges: CREATE on table.
+//   Notes: postgres requires ownership of the table.
+func (p *planner) DropTrigger(ctx context.Context, n *parser.DropTrigger) (planNode, error) {
+	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	tableDesc, err := mustGetTableDesc(ctx, p.txn, p.getVirtualTabler(), tn, true /*allowAdding*/)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.CheckPrivilege(tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &dropTriggerNode{p: p, n: n, tableDesc: tableDesc}, nil
+}
+
+func (n *dropTriggerNode) Start(ctx context.Context) error {
+	idx := n.tableDesc.FindTriggerByName(n.n.Name)
+	if idx < 0 {
+		if n.n.IfExists {
+			return nil
+		}
+		return pgerror.NewErrorf(pgerror.CodeUndefinedObjectError,
+			"trigger %q for table %q does not exist", string(n.n.Name), n.tableDesc.Name)
+	}
+
+	fnID := n.tableDesc.Triggers[idx].FunctionID
+	n.tableDesc.Triggers = append(n.tableDesc.Triggers[:idx], n.tableDesc.Triggers[idx+1:]...)
+	unused := []sqlbase.ID{fnID}
+	for _, trigger := range n.tableDesc.Triggers {
+		if trigger.FunctionID == fnID {
+			unused = nil
+			break
+		}
+	}
+
+	if err := n.tableDesc.SetUpVersion(); err != nil {
+		return err
+	}
+	if err := n.tableDesc.Validate(ctx, n.p.txn); err != nil {
+		return err
+	}
+	if err := n.p.writeTableDesc(ctx, n.tableDesc); err != nil {
+		return err
+	}
+	if err := n.p.removeFunctionReferences(ctx, unused, n.tableDesc.ID); err != nil {
+		return err
+	}
+
+	// Record this trigger removal in the event log. This is an auditable log
+	// event and is recorded in the same transaction as the table descriptor
+	// update.
+	if err := MakeEventLogger(n.p.LeaseMgr()).InsertEventRecord(
+		ctx,
+		n.p.txn,
+		EventLogDropTrigger,
+		int32(n.tableDesc.ID),
+		int32(n.p.evalCtx.NodeID),
+		struct {
+			TableName   string
+			TriggerName string
+			Statement   string
+			User        string
+		}{n.tableDesc.Name, n.n.Name.String(), n.n.String(), n.p.session.User},
+	); err != nil {
+		return err
+	}
+	n.p.notifySchemaChange(n.tableDesc, sqlbase.InvalidMutationID)
+	return nil
+}
+
+func (*dropTriggerNode) Next(context.Context) (bool, error) { return false, nil }
+func (*dropTriggerNode) Close(context.Context)              {}
+
+func (*dropTriggerNode) Values() parser.Datums      { return parser.Datums{} }
+func (*dropTriggerNode) DebugValues() debugValues   { return debugValues{} }
+func (*dropTriggerNode) MarkDebug(mode explainMode) {}
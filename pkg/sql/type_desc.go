@@ -0,0 +1,169 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+var _ parser.TypeResolver = &planner{}
+
+// getTypeDesc returns the descriptor of the user-defined type with the given
+// name, or nil if there is no such type.
+func getTypeDesc(
+	ctx context.Context, txn *client.Txn, vt VirtualTabler, tn *parser.TableName,
+) (*sqlbase.TypeDescriptor, error) {
+	dbDesc, err := MustGetDatabaseDesc(ctx, txn, vt, tn.Database())
+	if err != nil {
+		return nil, err
+	}
+
+	desc := sqlbase.TypeDescriptor{}
+	found, err := getDescriptor(ctx, txn, tableKey{parentID: dbDesc.ID, name: tn.Table()}, &desc)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return &desc, nil
+}
+
+// mustGetTypeDesc returns the descriptor of the user-defined type with the
+// given name, or an error if there is no such type.
+func mustGetTypeDesc(
+	ctx context.Context, txn *client.Txn, vt VirtualTabler, tn *parser.TableName,
+) (*sqlbase.TypeDescriptor, error) {
+	desc, err := getTypeDesc(ctx, txn, vt, tn)
+	if err != nil {
+		return nil, err
+	}
+	if desc == nil {
+		return nil, newUndefinedTypeError(tn.Table())
+	}
+	return desc, nil
+}
+
+func newUndefinedTypeError(name string) error {
+	return pgerror.NewErrorf(pgerror.CodeUndefinedObjectError, "type %q does not exist", name)
+}
+
+// ResolveType implements the parser.TypeResolver interface.
+func (p *planner) ResolveType(name *parser.NormalizableTableName) (parser.Type, error) {
+	tn, err := name.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+	// Virtual schemas do not contain types, so this check avoids the
+	// confusing "database does not exist" error for e.g. pg_catalog.foo.
+	if _, ok := p.getVirtualTabler().getVirtualSchemaEntry(tn.Database()); ok {
+		return nil, newUndefinedTypeError(name.String())
+	}
+	desc, err := getTypeDesc(p.session.Ctx(), p.txn, p.getVirtualTabler(), tn)
+	if err != nil {
+		return nil, err
+	}
+	if desc == nil {
+		return nil, newUndefinedTypeError(name.String())
+	}
+	return desc.ToDatumType(), nil
+}
+
+// resolveColumnTypes resolves the user-defined types used by the column
+// definitions in defs.
+func (p *planner) resolveColumnTypes(defs parser.TableDefs) error {
+	for _, def := range defs {
+		if d, ok := def.(*parser.ColumnTableDef); ok {
+			if err := p.resolveColumnType(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *planner) resolveColumnType(d *parser.ColumnTableDef) error {
+	if t, ok := d.Type.(*parser.UserDefinedColType); ok {
+		return p.semaCtx.ResolveUserDefinedType(t)
+	}
+	return nil
+}
+
+// addTypeReferences records, in the descriptors of the user-defined types
+// used by the table's columns, that the table depends on them.
+func (p *planner) addTypeReferences(ctx context.Context, tableDesc *sqlbase.TableDescriptor) error {
+	for _, col := range tableDesc.Columns {
+		if col.Type.Kind == sqlbase.ColumnType_ENUM {
+			if err := p.addTypeReference(ctx, col.Type.UserDefinedTypeID, tableDesc.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addTypeReference records in the descriptor of the type with ID typeID that
+// the table with ID tableID depends on it.
+func (p *planner) addTypeReference(ctx context.Context, typeID, tableID sqlbase.ID) error {
+	typeDesc, err := sqlbase.GetTypeDescFromID(ctx, p.txn, typeID)
+	if err != nil {
+		return err
+	}
+	if typeDesc.HasReference(tableID) {
+		return nil
+	}
+	typeDesc.AddReference(tableID)
+	return p.writeTypeDesc(ctx, typeDesc)
+}
+
+// writeTypeDesc writes the type descriptor in the current transaction.
+func (p *planner) writeTypeDesc(ctx context.Context, typeDesc *sqlbase.TypeDescriptor) error {
+	if err := typeDesc.Validate(); err != nil {
+		return err
+	}
+	p.session.setTestingVerifyMetadata(nil)
+	return p.txn.Put(
+		ctx, sqlbase.MakeDescMetadataKey(typeDesc.GetID()), sqlbase.WrapDescriptor(typeDesc),
+	)
+}
+
+// liveTypeReferences returns the descriptors of the tables that still use the
+// type. References to tables which have since been dropped are ignored.
+func (p *planner) liveTypeReferences(
+	ctx context.Context, typeDesc *sqlbase.TypeDescriptor,
+) ([]*sqlbase.TableDescriptor, error) {
+	var tables []*sqlbase.TableDescriptor
+	for _, id := range typeDesc.ReferencingDescriptorIDs {
+		tableDesc, err := sqlbase.GetTableDescFromID(ctx, p.txn, id)
+		if err == sqlbase.ErrDescriptorNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tableDesc.Dropped() {
+			continue
+		}
+		if tableDesc.UsesType(typeDesc.ID) {
+			tables = append(tables, tableDesc)
+		}
+	}
+	return tables, nil
+}
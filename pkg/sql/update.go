@@ -38,6 +38,10 @@ type editNodeBase struct {
 	p         *planner
 	rh        *returningHelper
 	tableDesc *sqlbase.TableDescriptor
+
+	// If set, the triggers of the table fire for the statement. The
+	// tableWriter fires the row triggers and the AFTER STATEMENT triggers.
+	triggers *triggerRunner
 }
 
 func (p *planner) makeEditNode(
@@ -69,6 +73,9 @@ type editNodeRun struct {
 	rows      planNode
 	tw        tableWriter
 	resultRow parser.Datums
+	// numRows is the number of rows written, reported to the statistics
+	// refresher once the batch is finished.
+	numRows int
 
 	explain explainMode
 }
@@ -101,6 +108,12 @@ func (r *editNodeRun) startEditNode(ctx context.Context, en *editNodeBase) error
 		}
 	}
 
+	if en.triggers != nil && r.explain != explainDebug {
+		if err := en.triggers.beforeStatement(ctx); err != nil {
+			return err
+		}
+	}
+
 	return r.rows.Start(ctx)
 }
 
@@ -134,6 +147,7 @@ type updateNode struct {
 	tw            tableUpdater
 	checkHelper   checkHelper
 	sourceSlots   []sourceSlot
+	computedCols  *sqlbase.ComputedColumns
 
 	run struct {
 		// The following fields are populated during Start().
@@ -261,8 +275,30 @@ func (p *planner) Update(
 		return nil, err
 	}
 
+	// The computed columns which depend on the updated columns are updated
+	// too. They follow the columns assigned by the SET expressions, and their
+	// values are computed once the new values of the latter are known.
+	updateCols, err = sqlbase.AddDependentComputedColumns(en.tableDesc, updateCols)
+	if err != nil {
+		return nil, err
+	}
+
+	policyCheck, err := p.makePolicyChecker(
+		ctx, tn, en.tableDesc, sqlbase.TableDescriptor_Policy_UPDATE, false, /* existingRows */
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	en.triggers, err = p.makeTriggerRunner(ctx, en.tableDesc, sqlbase.TableDescriptor_Trigger_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+
 	var requestedCols []sqlbase.ColumnDescriptor
-	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs || len(en.tableDesc.Checks) > 0 {
+	if _, retExprs := n.Returning.(*parser.ReturningExprs); retExprs ||
+		len(en.tableDesc.Checks) > 0 || policyCheck != nil ||
+		(en.triggers != nil && en.triggers.hasRowTriggers) {
 		// TODO(dan): This could be made tighter, just the rows needed for RETURNING
 		// exprs.
 		requestedCols = en.tableDesc.Columns
@@ -276,17 +312,27 @@ func (p *planner) Update(
 	if err != nil {
 		return nil, err
 	}
-	tw := tableUpdater{ru: ru, autoCommit: p.autoCommit}
+	// The AFTER triggers fire once the batch has been run, so the transaction
+	// cannot be committed along with it.
+	tw := tableUpdater{
+		ru:          ru,
+		autoCommit:  p.autoCommit && en.triggers == nil,
+		policyCheck: policyCheck,
+		triggers:    en.triggers,
+	}
 
 	tracing.AnnotateTrace()
 
 	// We construct a query containing the columns being updated, and then later merge the values
 	// they are being updated with into that renderNode to ideally reuse some of the queries.
+	// The rows to update are restricted by the UPDATE policies of the table.
+	p.policyTarget = policyTarget{tableID: en.tableDesc.ID, cmd: sqlbase.TableDescriptor_Policy_UPDATE}
 	rows, err := p.SelectClause(ctx, &parser.SelectClause{
 		Exprs: sqlbase.ColumnsSelectors(ru.FetchCols),
 		From:  &parser.From{Tables: []parser.TableExpr{n.Table}},
 		Where: n.Where,
 	}, nil, nil, nil, publicAndNonPublicColumns)
+	p.policyTarget = policyTarget{}
 	if err != nil {
 		return nil, err
 	}
@@ -382,6 +428,9 @@ func (p *planner) Update(
 		tw:            tw,
 		sourceSlots:   sourceSlots,
 	}
+	if un.computedCols, err = makeUpdateComputedColumns(en.tableDesc, &ru); err != nil {
+		return nil, err
+	}
 	if err := un.checkHelper.init(ctx, p, tn, en.tableDesc); err != nil {
 		return nil, err
 	}
@@ -410,6 +459,9 @@ func (u *updateNode) Next(ctx context.Context) (bool, error) {
 			// We're done. Finish the batch.
 			err = u.tw.finalize(ctx, u.p.session.Tracing.KVTracingEnabled())
 		}
+		if err == nil {
+			u.p.session.statsRefresher.notifyMutation(u.p.txn, u.tableDesc.ID, u.run.numRows)
+		}
 		return false, err
 	}
 
@@ -434,6 +486,11 @@ func (u *updateNode) Next(ctx context.Context) (bool, error) {
 			valueIdx++
 		}
 	}
+	if err := computeUpdatedValues(
+		&u.p.evalCtx, u.computedCols, oldValues, updateValues,
+	); err != nil {
+		return false, err
+	}
 
 	if err := u.checkHelper.loadRow(u.tw.ru.FetchColIDtoRowIndex, oldValues, false); err != nil {
 		return false, err
@@ -466,6 +523,7 @@ func (u *updateNode) Next(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	u.run.numRows++
 
 	resultRow, err := u.rh.cookResultRow(newValues)
 	if err != nil {
@@ -505,6 +563,42 @@ func (p *planner) namesForExprs(exprs parser.UpdateExprs) (parser.UnresolvedName
 	return names, nil
 }
 
+// makeUpdateComputedColumns returns the computed columns among the columns
+// updated by ru. They are evaluated on the fetched values followed by the
+// updated values, see computeUpdatedValues.
+func makeUpdateComputedColumns(
+	tableDesc *sqlbase.TableDescriptor, ru *sqlbase.RowUpdater,
+) (*sqlbase.ComputedColumns, error) {
+	colIDtoRowIndex := make(map[sqlbase.ColumnID]int, len(ru.FetchCols)+len(ru.UpdateCols))
+	for colID, i := range ru.FetchColIDtoRowIndex {
+		colIDtoRowIndex[colID] = i
+	}
+	for i, col := range ru.UpdateCols {
+		colIDtoRowIndex[col.ID] = len(ru.FetchCols) + i
+	}
+	return sqlbase.MakeComputedColumns(tableDesc, ru.UpdateCols, colIDtoRowIndex)
+}
+
+// computeUpdatedValues computes the values of the computed columns among the
+// updated columns, from the old values of the row and its updated values, and
+// stores them in updateValues.
+func computeUpdatedValues(
+	evalCtx *parser.EvalContext,
+	computedCols *sqlbase.ComputedColumns,
+	oldValues, updateValues parser.Datums,
+) error {
+	if computedCols == nil {
+		return nil
+	}
+	row := make(parser.Datums, 0, len(oldValues)+len(updateValues))
+	row = append(append(row, oldValues...), updateValues...)
+	if err := computedCols.Compute(evalCtx, row); err != nil {
+		return err
+	}
+	copy(updateValues, row[len(oldValues):])
+	return nil
+}
+
 func fillDefault(expr parser.Expr, index int, defaultExprs []parser.TypedExpr) parser.Expr {
 	switch expr.(type) {
 	case parser.DefaultVal:
@@ -164,6 +164,19 @@ func (uh *upsertHelper) eval(
 	return ret, nil
 }
 
+// upsertTriggerEvents returns the events on which the triggers of the table
+// fire for an INSERT statement with the given ON CONFLICT clause, if any. The
+// rows updated by an upsert fire the UPDATE triggers, and the other rows the
+// INSERT triggers.
+func upsertTriggerEvents(onConflict *parser.OnConflict) []sqlbase.TableDescriptor_Trigger_Event {
+	if onConflict == nil || onConflict.DoNothing {
+		return []sqlbase.TableDescriptor_Trigger_Event{sqlbase.TableDescriptor_Trigger_INSERT}
+	}
+	return []sqlbase.TableDescriptor_Trigger_Event{
+		sqlbase.TableDescriptor_Trigger_INSERT, sqlbase.TableDescriptor_Trigger_UPDATE,
+	}
+}
+
 // upsertExprsAndIndex returns the upsert conflict index and the (possibly
 // synthetic) SET expressions used when a row conflicts.
 func upsertExprsAndIndex(
@@ -184,6 +197,10 @@ func upsertExprsAndIndex(
 		}
 		updateExprs := make(parser.UpdateExprs, 0, len(insertCols))
 		for _, c := range insertCols {
+			// The computed columns are recomputed from the updated columns.
+			if c.IsComputed() {
+				continue
+			}
 			if _, ok := indexColSet[c.ID]; !ok {
 				names := parser.UnresolvedNames{
 					parser.UnresolvedName{parser.Name(c.Name)},
@@ -202,10 +219,11 @@ func upsertExprsAndIndex(
 		if !index.Unique {
 			return false
 		}
-		if len(index.ColumnNames) != len(onConflict.Columns) {
+		colNames := index.UserColumnNames()
+		if len(colNames) != len(onConflict.Columns) {
 			return false
 		}
-		for i, colName := range index.ColumnNames {
+		for i, colName := range colNames {
 			if parser.ReNormalizeName(colName) != onConflict.Columns[i].Normalize() {
 				return false
 			}
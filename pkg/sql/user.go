@@ -57,3 +57,30 @@ func GetUserHashedPassword(
 
 	return hashedPassword, nil
 }
+
+// UpgradeUserHashedPassword replaces the legacy password hash of a user by a
+// SCRAM verifier of the same password, unless the password was changed
+// since oldHashedPassword was read.
+func UpgradeUserHashedPassword(
+	ctx context.Context,
+	executor *Executor,
+	metrics *MemoryMetrics,
+	username string,
+	oldHashedPassword []byte,
+	password string,
+) error {
+	newHashedPassword, err := security.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	normalizedUsername := parser.Name(username).Normalize()
+	return executor.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("upgrade-pwd", txn, security.RootUser, metrics)
+		defer finishInternalPlanner(p)
+		const upgradeHashedPassword = `UPDATE system.users SET "hashedPassword" = $3 ` +
+			`WHERE username = $1 AND "hashedPassword" = $2`
+		_, err := p.exec(ctx, upgradeHashedPassword,
+			normalizedUsername, oldHashedPassword, newHashedPassword)
+		return err
+	})
+}
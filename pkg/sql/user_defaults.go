@@ -0,0 +1,126 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var userDefaultsCacheTTL = settings.RegisterDurationSetting(
+	"sql.user_defaults.cache_ttl",
+	"duration for which each node caches the session variable defaults configured with "+
+		"ALTER USER ... SET (set to 0 to disable)",
+	time.Minute,
+)
+
+// userDefault is the default of a session variable for the sessions of a
+// user, as configured with ALTER USER ... SET.
+type userDefault struct {
+	varName string
+	value   string
+}
+
+type userDefaultsEntry struct {
+	defaults []userDefault
+	loaded   time.Time
+}
+
+// userDefaultsCache caches the contents of system.user_settings per user, so
+// that opening a session does not require a KV read. The entry of a user is
+// invalidated when this node changes the user's defaults; changes made on
+// other nodes are picked up once the entry expires.
+type userDefaultsCache struct {
+	mu struct {
+		syncutil.Mutex
+		entries map[string]userDefaultsEntry
+		// generation is incremented by each invalidation, so that values
+		// loaded concurrently with an invalidation are not cached.
+		generation int64
+	}
+}
+
+func (c *userDefaultsCache) init() {
+	c.mu.entries = make(map[string]userDefaultsEntry)
+}
+
+// get returns the defaults of the given user, reading them from
+// system.user_settings if they aren't cached.
+func (c *userDefaultsCache) get(
+	ctx context.Context, e *Executor, user string, memMetrics *MemoryMetrics,
+) ([]userDefault, error) {
+	ttl := userDefaultsCacheTTL.Get()
+	now := timeutil.Now()
+	c.mu.Lock()
+	entry, ok := c.mu.entries[user]
+	generation := c.mu.generation
+	c.mu.Unlock()
+	if ok && now.Sub(entry.loaded) < ttl {
+		return entry.defaults, nil
+	}
+
+	var defaults []userDefault
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		ip := makeInternalPlanner("user-settings", txn, security.RootUser, memMetrics)
+		defer finishInternalPlanner(ip)
+		ip.session.tables.leaseMgr = e.cfg.LeaseManager
+		rows, err := ip.queryRows(
+			ctx, "SELECT variable, value FROM system.user_settings WHERE username = $1", user,
+		)
+		if err != nil {
+			return err
+		}
+		defaults = make([]userDefault, len(rows))
+		for i, row := range rows {
+			defaults[i] = userDefault{
+				varName: string(parser.MustBeDString(row[0])),
+				value:   string(parser.MustBeDString(row[1])),
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		c.mu.Lock()
+		if c.mu.generation == generation {
+			c.mu.entries[user] = userDefaultsEntry{defaults: defaults, loaded: now}
+		}
+		c.mu.Unlock()
+	}
+	return defaults, nil
+}
+
+// invalidate removes the cached defaults of the given user once txn commits.
+func (c *userDefaultsCache) invalidate(txn *client.Txn, user string) {
+	if c == nil {
+		return
+	}
+	txn.AddCommitTrigger(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.mu.entries, user)
+		c.mu.generation++
+	})
+}
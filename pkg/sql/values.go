@@ -52,7 +52,7 @@ func (p *planner) newContainerValuesNode(columns sqlbase.ResultColumns, capacity
 		p:       p,
 		columns: columns,
 		rows: sqlbase.NewRowContainer(
-			p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(columns), capacity,
+			p.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(columns), capacity,
 		),
 	}
 }
@@ -128,7 +128,7 @@ func (n *valuesNode) Start(ctx context.Context) error {
 	// from other planNodes), so its expressions need evaluting.
 	// This may run subqueries.
 	n.rows = sqlbase.NewRowContainer(
-		n.p.session.TxnState.makeBoundAccount(),
+		n.p.makeBoundAccount(),
 		sqlbase.ColTypeInfoFromResCols(n.columns),
 		len(n.n.Tuples),
 	)
@@ -145,6 +145,46 @@ var varGen = map[string]sessionVar{
 			return nil
 		},
 	},
+	`vectorize`: {
+		Set: func(_ context.Context, p *planner, values []parser.TypedExpr) error {
+			s, err := p.getStringVal(`vectorize`, values)
+			if err != nil {
+				return err
+			}
+			switch parser.Name(s).Normalize() {
+			case parser.ReNormalizeName("off"):
+				p.session.VectorizeMode = VectorizeOff
+			case parser.ReNormalizeName("on"):
+				p.session.VectorizeMode = VectorizeOn
+			default:
+				return fmt.Errorf("set vectorize: \"%s\" not supported", s)
+			}
+
+			return nil
+		},
+		Get: func(p *planner) string {
+			return p.session.VectorizeMode.String()
+		},
+		Reset: func(p *planner) error {
+			p.session.VectorizeMode = VectorizeExecModeFromInt(VectorizeClusterMode.Get())
+			return nil
+		},
+	},
+	`idle_in_transaction_session_timeout`: {
+		Set: func(_ context.Context, p *planner, values []parser.TypedExpr) error {
+			timeout, err := p.getTimeoutVal(`idle_in_transaction_session_timeout`, values)
+			if err != nil {
+				return err
+			}
+			p.session.IdleInTxnSessionTimeout = timeout
+			return nil
+		},
+		Get: func(p *planner) string { return p.session.IdleInTxnSessionTimeout.String() },
+		Reset: func(p *planner) error {
+			p.session.IdleInTxnSessionTimeout = IdleInTxnSessionTimeout.Get()
+			return nil
+		},
+	},
 	`search_path`: {
 		Set: func(_ context.Context, p *planner, values []parser.TypedExpr) error {
 			// https://www.postgresql.org/docs/9.6/static/runtime-config-client.html
@@ -195,6 +235,21 @@ var varGen = map[string]sessionVar{
 		Get:   func(*planner) string { return "on" },
 		Reset: func(*planner) error { return nil },
 	},
+	`statement_timeout`: {
+		Set: func(_ context.Context, p *planner, values []parser.TypedExpr) error {
+			timeout, err := p.getTimeoutVal(`statement_timeout`, values)
+			if err != nil {
+				return err
+			}
+			p.session.StatementTimeout = timeout
+			return nil
+		},
+		Get: func(p *planner) string { return p.session.StatementTimeout.String() },
+		Reset: func(p *planner) error {
+			p.session.StatementTimeout = StatementTimeout.Get()
+			return nil
+		},
+	},
 	`application_name`: {
 		Set: func(_ context.Context, p *planner, values []parser.TypedExpr) error {
 			// Set by clients to improve query logging.
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 )
 
 // planObserver is the interface to implement by components that need
@@ -84,6 +85,10 @@ func (v *planVisitor) visit(plan planNode) {
 	if v.err != nil {
 		return
 	}
+	if a, ok := plan.(*analyzeNode); ok {
+		// The instrumentation of EXPLAIN ANALYZE is transparent.
+		plan = a.plan
+	}
 
 	name := nodeName(plan)
 	recurse := true
@@ -203,6 +208,25 @@ func (v *planVisitor) visit(plan planNode) {
 				buf.WriteByte(')')
 				v.observer.attr(name, "equality", buf.String())
 			}
+			if len(n.mergeJoinOrdering) > 0 {
+				var buf bytes.Buffer
+				for i, c := range n.mergeJoinOrdering {
+					if i > 0 {
+						buf.WriteByte(',')
+					}
+					if c.Direction == encoding.Descending {
+						buf.WriteByte('-')
+					} else {
+						buf.WriteByte('+')
+					}
+					buf.WriteByte('(')
+					parser.FormatNode(&buf, parser.FmtSimple, n.pred.leftColNames[c.ColIdx])
+					buf.WriteByte('=')
+					parser.FormatNode(&buf, parser.FmtSimple, n.pred.rightColNames[c.ColIdx])
+					buf.WriteByte(')')
+				}
+				v.observer.attr(name, "mergeJoinOrder", buf.String())
+			}
 		}
 		subplans := v.expr(name, "pred", -1, n.pred.onCond, nil)
 		v.subqueries(name, subplans)
@@ -491,45 +515,61 @@ func nodeName(plan planNode) string {
 // strings are constant and not precomptued so that the type names can
 // be changed without changing the output of "EXPLAIN".
 var planNodeNames = map[reflect.Type]string{
-	reflect.TypeOf(&alterTableNode{}):       "alter table",
-	reflect.TypeOf(&copyNode{}):             "copy",
-	reflect.TypeOf(&createDatabaseNode{}):   "create database",
-	reflect.TypeOf(&createIndexNode{}):      "create index",
-	reflect.TypeOf(&createTableNode{}):      "create table",
-	reflect.TypeOf(&createUserNode{}):       "create user",
-	reflect.TypeOf(&createViewNode{}):       "create view",
-	reflect.TypeOf(&delayedNode{}):          "virtual table",
-	reflect.TypeOf(&deleteNode{}):           "delete",
-	reflect.TypeOf(&distinctNode{}):         "distinct",
-	reflect.TypeOf(&dropDatabaseNode{}):     "drop database",
-	reflect.TypeOf(&dropIndexNode{}):        "drop index",
-	reflect.TypeOf(&dropTableNode{}):        "drop table",
-	reflect.TypeOf(&dropViewNode{}):         "drop view",
-	reflect.TypeOf(&dropUserNode{}):         "drop user",
-	reflect.TypeOf(&emptyNode{}):            "empty",
-	reflect.TypeOf(&explainDebugNode{}):     "explain debug",
-	reflect.TypeOf(&explainDistSQLNode{}):   "explain dist_sql",
-	reflect.TypeOf(&explainPlanNode{}):      "explain plan",
-	reflect.TypeOf(&traceNode{}):            "show trace for",
-	reflect.TypeOf(&filterNode{}):           "filter",
-	reflect.TypeOf(&groupNode{}):            "group",
-	reflect.TypeOf(&hookFnNode{}):           "plugin",
-	reflect.TypeOf(&indexJoinNode{}):        "index-join",
-	reflect.TypeOf(&insertNode{}):           "insert",
-	reflect.TypeOf(&joinNode{}):             "join",
-	reflect.TypeOf(&limitNode{}):            "limit",
-	reflect.TypeOf(&ordinalityNode{}):       "ordinality",
-	reflect.TypeOf(&relocateNode{}):         "relocate",
-	reflect.TypeOf(&renderNode{}):           "render",
-	reflect.TypeOf(&scanNode{}):             "scan",
-	reflect.TypeOf(&scatterNode{}):          "scatter",
-	reflect.TypeOf(&showRangesNode{}):       "showRanges",
-	reflect.TypeOf(&showFingerprintsNode{}): "showFingerprints",
-	reflect.TypeOf(&sortNode{}):             "sort",
-	reflect.TypeOf(&splitNode{}):            "split",
-	reflect.TypeOf(&unionNode{}):            "union",
-	reflect.TypeOf(&updateNode{}):           "update",
-	reflect.TypeOf(&valueGenerator{}):       "generator",
-	reflect.TypeOf(&valuesNode{}):           "values",
-	reflect.TypeOf(&windowNode{}):           "window",
+	reflect.TypeOf(&alterTableNode{}):           "alter table",
+	reflect.TypeOf(&alterTypeNode{}):            "alter type",
+	reflect.TypeOf(&alterUserSetNode{}):         "alter user",
+	reflect.TypeOf(&alterUserSetPasswordNode{}): "alter user",
+	reflect.TypeOf(&analyzeNode{}):              "analyze",
+	reflect.TypeOf(&cancelQueryNode{}):          "cancel query",
+	reflect.TypeOf(&cancelSessionNode{}):        "cancel session",
+	reflect.TypeOf(&commentNode{}):              "comment",
+	reflect.TypeOf(&copyNode{}):                 "copy",
+	reflect.TypeOf(&createDatabaseNode{}):       "create database",
+	reflect.TypeOf(&createFunctionNode{}):       "create function",
+	reflect.TypeOf(&createIndexNode{}):          "create index",
+	reflect.TypeOf(&createPolicyNode{}):         "create policy",
+	reflect.TypeOf(&createStatsNode{}):          "create statistics",
+	reflect.TypeOf(&createTableNode{}):          "create table",
+	reflect.TypeOf(&createTriggerNode{}):        "create trigger",
+	reflect.TypeOf(&createTypeNode{}):           "create type",
+	reflect.TypeOf(&createUserNode{}):           "create user",
+	reflect.TypeOf(&createViewNode{}):           "create view",
+	reflect.TypeOf(&delayedNode{}):              "virtual table",
+	reflect.TypeOf(&deleteNode{}):               "delete",
+	reflect.TypeOf(&distinctNode{}):             "distinct",
+	reflect.TypeOf(&dropDatabaseNode{}):         "drop database",
+	reflect.TypeOf(&dropFunctionNode{}):         "drop function",
+	reflect.TypeOf(&dropIndexNode{}):            "drop index",
+	reflect.TypeOf(&dropPolicyNode{}):           "drop policy",
+	reflect.TypeOf(&dropTableNode{}):            "drop table",
+	reflect.TypeOf(&dropTriggerNode{}):          "drop trigger",
+	reflect.TypeOf(&dropTypeNode{}):             "drop type",
+	reflect.TypeOf(&dropViewNode{}):             "drop view",
+	reflect.TypeOf(&dropUserNode{}):             "drop user",
+	reflect.TypeOf(&emptyNode{}):                "empty",
+	reflect.TypeOf(&explainDebugNode{}):         "explain debug",
+	reflect.TypeOf(&explainDistSQLNode{}):       "explain dist_sql",
+	reflect.TypeOf(&explainPlanNode{}):          "explain plan",
+	reflect.TypeOf(&traceNode{}):                "show trace for",
+	reflect.TypeOf(&filterNode{}):               "filter",
+	reflect.TypeOf(&groupNode{}):                "group",
+	reflect.TypeOf(&hookFnNode{}):               "plugin",
+	reflect.TypeOf(&indexJoinNode{}):            "index-join",
+	reflect.TypeOf(&insertNode{}):               "insert",
+	reflect.TypeOf(&joinNode{}):                 "join",
+	reflect.TypeOf(&limitNode{}):                "limit",
+	reflect.TypeOf(&ordinalityNode{}):           "ordinality",
+	reflect.TypeOf(&relocateNode{}):             "relocate",
+	reflect.TypeOf(&renderNode{}):               "render",
+	reflect.TypeOf(&scanNode{}):                 "scan",
+	reflect.TypeOf(&scatterNode{}):              "scatter",
+	reflect.TypeOf(&showRangesNode{}):           "showRanges",
+	reflect.TypeOf(&showFingerprintsNode{}):     "showFingerprints",
+	reflect.TypeOf(&sortNode{}):                 "sort",
+	reflect.TypeOf(&splitNode{}):                "split",
+	reflect.TypeOf(&unionNode{}):                "union",
+	reflect.TypeOf(&updateNode{}):               "update",
+	reflect.TypeOf(&valueGenerator{}):           "generator",
+	reflect.TypeOf(&valuesNode{}):               "values",
+	reflect.TypeOf(&windowNode{}):               "window",
 }
@@ -92,11 +92,11 @@ func (p *planner) window(
 
 	window.replaceIndexVarsAndAggFuncs(s)
 
-	acc := p.session.TxnState.makeBoundAccount()
+	acc := p.makeBoundAccount()
 	window.wrappedRenderVals = sqlbase.NewRowContainer(
 		acc, sqlbase.ColTypeInfoFromResCols(s.columns), 0,
 	)
-	window.windowsAcc = p.session.TxnState.OpenAccount()
+	window.windowsAcc = p.openAccount()
 
 	return window, nil
 }
@@ -584,7 +584,7 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 	}
 
 	windowCount := len(n.funcs)
-	acc := n.windowsAcc.Wtxn(n.planner.session)
+	acc := n.windowsAcc.Wplanner(n.planner)
 
 	winValSz := uintptr(rowCount) * unsafe.Sizeof([]parser.Datum{})
 	winAllocSz := uintptr(rowCount*windowCount) * unsafe.Sizeof(parser.Datum(nil))
@@ -748,10 +748,10 @@ func (n *windowNode) computeWindows(ctx context.Context) error {
 // populateValues populates n.values with final datum values after computing
 // window result values in n.windowValues.
 func (n *windowNode) populateValues(ctx context.Context) error {
-	acc := n.windowsAcc.Wtxn(n.planner.session)
+	acc := n.windowsAcc.Wplanner(n.planner)
 	rowCount := n.wrappedRenderVals.Len()
 	n.values.rows = sqlbase.NewRowContainer(
-		n.planner.session.TxnState.makeBoundAccount(),
+		n.planner.makeBoundAccount(),
 		sqlbase.ColTypeInfoFromResCols(n.values.columns),
 		rowCount,
 	)
@@ -808,6 +808,72 @@ func (n *windowNode) populateValues(ctx context.Context) error {
 	return nil
 }
 
+// distSQLRenders returns expressions computing the columns of the windowNode
+// from the columns of the wrapped plan and the results of the window
+// functions, for use by the distSQL planner. Assuming the wrapped plan has N
+// columns, variables @1 to @N refer to its columns and variable @(N+i+1)
+// refers to the result of the i-th window function in n.funcs.
+func (n *windowNode) distSQLRenders() []parser.TypedExpr {
+	numWrappedCols := len(planColumns(n.plan))
+	renders := make([]parser.TypedExpr, len(n.windowRender))
+	// The columns are walked like in populateValues.
+	curColIdx := 0
+	curFnIdx := 0
+	for j, render := range n.windowRender {
+		if render == nil {
+			renders[j] = parser.NewOrdinalReference(curColIdx)
+			curColIdx++
+			continue
+		}
+		for ; curFnIdx < len(n.funcs); curFnIdx++ {
+			windowFn := n.funcs[curFnIdx]
+			if windowFn.argIdxStart != curColIdx {
+				break
+			}
+			curColIdx += windowFn.argCount
+		}
+		replaceWindowVars := func(expr parser.Expr) (error, bool, parser.Expr) {
+			switch t := expr.(type) {
+			case *windowFuncHolder:
+				return nil, false, parser.NewOrdinalReference(numWrappedCols + t.funcIdx)
+			case *parser.IndexedVar:
+				return nil, false, parser.NewOrdinalReference(n.wrappedColumn(t))
+			default:
+				return nil, true, expr
+			}
+		}
+		expr, err := parser.SimpleVisit(render, replaceWindowVars)
+		if err != nil {
+			panic(err)
+		}
+		renders[j] = expr.(parser.TypedExpr)
+	}
+	return renders
+}
+
+// wrappedColumn returns the column of the wrapped plan that holds the value of
+// an IndexedVar found above the windowing level (see
+// replaceIndexVarsAndAggFuncs).
+func (n *windowNode) wrappedColumn(ivar *parser.IndexedVar) int {
+	// The container of an IndexedVar is only exposed to formatting functions.
+	col := -1
+	parser.AsStringWithFlags(ivar, parser.FmtIndexedVarFormat(
+		parser.FmtSimple,
+		func(_ *bytes.Buffer, _ parser.FmtFlags, c parser.IndexedVarContainer, idx int) {
+			switch c {
+			case &n.colContainer:
+				col = n.colContainer.idxMap[idx]
+			case &n.aggContainer:
+				col = n.aggContainer.idxMap[idx]
+			}
+		},
+	))
+	if col == -1 {
+		panic(fmt.Sprintf("IndexedVar %d is not bound to the windowNode", ivar.Idx))
+	}
+	return col
+}
+
 func (n *windowNode) Close(ctx context.Context) {
 	n.plan.Close(ctx)
 	if n.wrappedRenderVals != nil {
@@ -816,7 +882,7 @@ func (n *windowNode) Close(ctx context.Context) {
 	}
 	if n.windowValues != nil {
 		n.windowValues = nil
-		n.windowsAcc.Wtxn(n.planner.session).Close(ctx)
+		n.windowsAcc.Wplanner(n.planner).Close(ctx)
 	}
 	n.values.Close(ctx)
 }
@@ -24,6 +24,24 @@ export const DROP_INDEX = "drop_index";
 export const CREATE_VIEW = "create_view";
 // Recorded when a view is dropped.
 export const DROP_VIEW = "drop_view";
+// Recorded when a policy is created.
+export const CREATE_POLICY = "create_policy";
+// Recorded when a policy is dropped.
+export const DROP_POLICY = "drop_policy";
+// Recorded when a trigger is created.
+export const CREATE_TRIGGER = "create_trigger";
+// Recorded when a trigger is dropped.
+export const DROP_TRIGGER = "drop_trigger";
+// Recorded when a type is created.
+export const CREATE_TYPE = "create_type";
+// Recorded when a type is dropped.
+export const DROP_TYPE = "drop_type";
+// Recorded when a type is altered.
+export const ALTER_TYPE = "alter_type";
+// Recorded when a function is created.
+export const CREATE_FUNCTION = "create_function";
+// Recorded when a function is dropped.
+export const DROP_FUNCTION = "drop_function";
 // Recorded when an in-progress schema change encounters a problem and is
 // reversed.
 export const REVERSE_SCHEMA_CHANGE = "reverse_schema_change";
@@ -33,13 +51,20 @@ export const FINISH_SCHEMA_CHANGE = "finish_schema_change";
 export const NODE_JOIN = "node_join";
 // Recorded when an existing node rejoins the cluster after being offline.
 export const NODE_RESTART = "node_restart";
+// Recorded when a session is canceled.
+export const CANCEL_SESSION = "cancel_session";
+// Recorded when a SQL connection is denied.
+export const REJECT_CONNECTION = "reject_connection";
 
 // Node Event Types
 export const nodeEvents = [NODE_JOIN, NODE_RESTART];
-export const databaseEvents = [CREATE_DATABASE, DROP_DATABASE];
+export const databaseEvents = [CREATE_DATABASE, DROP_DATABASE, CREATE_TYPE, DROP_TYPE, ALTER_TYPE,
+  CREATE_FUNCTION, DROP_FUNCTION];
 export const tableEvents = [CREATE_TABLE, DROP_TABLE, ALTER_TABLE, CREATE_INDEX,
-  DROP_INDEX, CREATE_VIEW, DROP_VIEW, REVERSE_SCHEMA_CHANGE, FINISH_SCHEMA_CHANGE];
-export const allEvents = [...nodeEvents, ...databaseEvents, ...tableEvents];
+  DROP_INDEX, CREATE_VIEW, DROP_VIEW, CREATE_POLICY, DROP_POLICY, CREATE_TRIGGER, DROP_TRIGGER,
+  REVERSE_SCHEMA_CHANGE, FINISH_SCHEMA_CHANGE];
+export const sessionEvents = [CANCEL_SESSION, REJECT_CONNECTION];
+export const allEvents = [...nodeEvents, ...databaseEvents, ...tableEvents, ...sessionEvents];
 
 interface EventSet {
   [key: string]: number;
@@ -53,6 +53,7 @@ export function getEventInfo(e: Event$Properties): SimplifiedEvent {
     DroppedTables: string[],
     IndexName: string,
     MutationID: string,
+    SessionID: string,
     TableName: string,
     User: string,
     ViewName: string,
@@ -95,6 +96,33 @@ export function getEventInfo(e: Event$Properties): SimplifiedEvent {
     case eventTypes.DROP_VIEW:
       content = <span>View Dropped: User {info.User} dropped view {info.ViewName}</span>;
       break;
+    case eventTypes.CREATE_POLICY:
+      content = <span>Policy Created: User {info.User} created policy {info.PolicyName} on table {info.TableName}</span>;
+      break;
+    case eventTypes.DROP_POLICY:
+      content = <span>Policy Dropped: User {info.User} dropped policy {info.PolicyName} on table {info.TableName}</span>;
+      break;
+    case eventTypes.CREATE_TRIGGER:
+      content = <span>Trigger Created: User {info.User} created trigger {info.TriggerName} on table {info.TableName}</span>;
+      break;
+    case eventTypes.DROP_TRIGGER:
+      content = <span>Trigger Dropped: User {info.User} dropped trigger {info.TriggerName} on table {info.TableName}</span>;
+      break;
+    case eventTypes.CREATE_TYPE:
+      content = <span>Type Created: User {info.User} created type {info.TypeName}</span>;
+      break;
+    case eventTypes.DROP_TYPE:
+      content = <span>Type Dropped: User {info.User} dropped type {info.TypeName}</span>;
+      break;
+    case eventTypes.ALTER_TYPE:
+      content = <span>Type Altered: User {info.User} altered type {info.TypeName}</span>;
+      break;
+    case eventTypes.CREATE_FUNCTION:
+      content = <span>Function Created: User {info.User} created function {info.FunctionName}</span>;
+      break;
+    case eventTypes.DROP_FUNCTION:
+      content = <span>Function Dropped: User {info.User} dropped function {info.FunctionName}</span>;
+      break;
     case eventTypes.REVERSE_SCHEMA_CHANGE:
       content = <span>Schema Change Reversed: Schema change with ID {info.MutationID} was reversed.</span>;
       break;
@@ -107,6 +135,12 @@ export function getEventInfo(e: Event$Properties): SimplifiedEvent {
     case eventTypes.NODE_RESTART:
       content = <span>Node Rejoined: Node {targetId} rejoined the cluster</span>;
       break;
+    case eventTypes.CANCEL_SESSION:
+      content = <span>Session Canceled: User {info.User} canceled session {info.SessionID} on node {targetId}</span>;
+      break;
+    case eventTypes.REJECT_CONNECTION:
+      content = <span>Connection Rejected: Connection of user {info.User} from {info.RemoteAddr} was rejected on node {targetId}: {info.Reason}</span>;
+      break;
     default:
       content = <span>Unknown Event Type: {e.event_type}, content: {s(info)}</span>;
   }
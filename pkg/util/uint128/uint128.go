@@ -18,6 +18,9 @@ package uint128
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
 )
 
 // Uint128 is a big-endian 128 bit unsigned integer which wraps two uint64s.
@@ -33,6 +36,11 @@ func (u Uint128) GetBytes() []byte {
 	return buf
 }
 
+// String returns a hexadecimal string representation.
+func (u Uint128) String() string {
+	return hex.EncodeToString(u.GetBytes())
+}
+
 // Add returns a new Uint128 incremented by n.
 func (u Uint128) Add(n uint64) Uint128 {
 	lo := u.lo + n
@@ -59,3 +67,29 @@ func FromBytes(b []byte) Uint128 {
 	lo := binary.BigEndian.Uint64(b[8:])
 	return Uint128{hi, lo}
 }
+
+// FromInts takes in two unsigned 64-bit integers and constructs a Uint128.
+func FromInts(hi uint64, lo uint64) Uint128 {
+	return Uint128{hi, lo}
+}
+
+// FromString parses a hexadecimal string as a 128-bit big-endian unsigned
+// integer.
+func FromString(s string) (Uint128, error) {
+	if len(s) > 32 {
+		return Uint128{}, errors.Errorf("input string %s too large for uint128", s)
+	}
+	bytes, err := hex.DecodeString(s)
+	if err != nil {
+		return Uint128{}, errors.Wrapf(err, "could not decode %s as hex", s)
+	}
+
+	// Grow the byte slice if it's smaller than 16 bytes, by prepending 0s.
+	if len(bytes) < 16 {
+		bytesCopy := make([]byte, 16)
+		copy(bytesCopy[(16-len(bytes)):], bytes)
+		bytes = bytesCopy
+	}
+
+	return FromBytes(bytes), nil
+}
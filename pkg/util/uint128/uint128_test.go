@@ -31,6 +31,40 @@ func TestBytes(t *testing.T) {
 	}
 }
 
+func TestString(t *testing.T) {
+	testData := []struct {
+		num Uint128
+		str string
+	}{
+		{Uint128{0, 0}, "00000000000000000000000000000000"},
+		{Uint128{0, 255}, "000000000000000000000000000000ff"},
+		{Uint128{1, 2}, "00000000000000010000000000000002"},
+		{Uint128{18446744073709551615, 18446744073709551615}, "ffffffffffffffffffffffffffffffff"},
+	}
+
+	for _, test := range testData {
+		if s := test.num.String(); s != test.str {
+			t.Errorf("expected %v to be formatted as %s, got %s", test.num, test.str, s)
+		}
+		res, err := FromString(test.str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != test.num {
+			t.Errorf("expected %s to be parsed as %v, got %v", test.str, test.num, res)
+		}
+	}
+
+	if res, err := FromString("ff"); err != nil || res != (Uint128{0, 255}) {
+		t.Errorf("expected ff to be parsed as {0 255}, got %v (err: %v)", res, err)
+	}
+	for _, s := range []string{"xyz", "123", "000000000000000000000000000000000"} {
+		if _, err := FromString(s); err == nil {
+			t.Errorf("expected %s not to be parsed", s)
+		}
+	}
+}
+
 func TestSub(t *testing.T) {
 	testData := []struct {
 		num      Uint128